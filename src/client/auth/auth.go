@@ -62,10 +62,15 @@ func IsNotActivatedError(e error) bool {
 
 // NotAuthorizedError is returned if the user is not authorized to perform
 // a certain operation on the repo 'Repo' (to do so, they would need to have the
-// authorization scope in 'Required').
+// authorization scope in 'Required'). 'Actual' is the scope that they have,
+// read from revision 'ACLRevision' of the repo's ACL (0 if it has none), or
+// from pachd's cache of the cluster admins if 'Cached' is set.
 type NotAuthorizedError struct {
-	Repo     string
-	Required Scope
+	Repo        string
+	Required    Scope
+	Actual      Scope
+	ACLRevision int64
+	Cached      bool
 }
 
 // This error message string is matched in the UI. If edited,
@@ -78,7 +83,15 @@ func (e *NotAuthorizedError) Error() string {
 		msg += " on the repo " + e.Repo
 	}
 	if e.Required != Scope_NONE {
-		msg += ", must have at least " + e.Required.String() + " access"
+		msg += ", must have at least " + e.Required.String() + " access" +
+			" (you have " + e.Actual.String() + " access"
+		if e.ACLRevision != 0 {
+			msg += fmt.Sprintf(", as of ACL revision %d", e.ACLRevision)
+		}
+		if e.Cached {
+			msg += ", from cache"
+		}
+		msg += ")"
 	}
 	return msg
 }
@@ -33,6 +33,25 @@
 		GetACLResponse
 		SetACLRequest
 		SetACLResponse
+		RepoACL
+		ExportACLsRequest
+		ExportACLsResponse
+		ImportACLsRequest
+		ImportACLsResponse
+		ApplyACLTemplateRequest
+		ApplyACLTemplateResponse
+		DiffACLsRequest
+		ACLChange
+		DiffACLsResponse
+		ServiceAccount
+		CreateServiceAccountRequest
+		CreateServiceAccountResponse
+		RotateServiceAccountTokenRequest
+		RotateServiceAccountTokenResponse
+		GetServiceAccountsRequest
+		GetServiceAccountsResponse
+		DeleteServiceAccountRequest
+		DeleteServiceAccountResponse
 		GetCapabilityRequest
 		GetCapabilityResponse
 		RevokeAuthTokenRequest
@@ -94,20 +113,23 @@ func (Scope) EnumDescriptor() ([]byte, []int) { return fileDescriptorAuth, []int
 type User_UserType int32
 
 const (
-	User_INVALID  User_UserType = 0
-	User_HUMAN    User_UserType = 1
-	User_PIPELINE User_UserType = 2
+	User_INVALID         User_UserType = 0
+	User_HUMAN           User_UserType = 1
+	User_PIPELINE        User_UserType = 2
+	User_SERVICE_ACCOUNT User_UserType = 3
 )
 
 var User_UserType_name = map[int32]string{
 	0: "INVALID",
 	1: "HUMAN",
 	2: "PIPELINE",
+	3: "SERVICE_ACCOUNT",
 }
 var User_UserType_value = map[string]int32{
-	"INVALID":  0,
-	"HUMAN":    1,
-	"PIPELINE": 2,
+	"INVALID":         0,
+	"HUMAN":           1,
+	"PIPELINE":        2,
+	"SERVICE_ACCOUNT": 3,
 }
 
 func (x User_UserType) String() string {
@@ -383,6 +405,16 @@ func (m *AuthorizeRequest) GetScope() Scope {
 
 type AuthorizeResponse struct {
 	Authorized bool `protobuf:"varint,1,opt,name=authorized,proto3" json:"authorized,omitempty"`
+	// scope is the caller's current scope on the repo, and acl_revision is the
+	// revision of the repo's ACL that it was read from (0 if the repo has no
+	// ACL), so that a caller who isn't authorized can see why
+	Scope       Scope `protobuf:"varint,2,opt,name=scope,proto3,enum=auth.Scope" json:"scope,omitempty"`
+	ACLRevision int64 `protobuf:"varint,3,opt,name=acl_revision,json=aclRevision,proto3" json:"acl_revision,omitempty"`
+	// cached is set if the result came from pachd's cache of the cluster
+	// admins rather than from an ACL read from etcd, which is the case for
+	// admins and service accounts owned by admins. The cache is updated by
+	// watching etcd, so it can briefly lag a change to the admins.
+	Cached bool `protobuf:"varint,4,opt,name=cached,proto3" json:"cached,omitempty"`
 }
 
 func (m *AuthorizeResponse) Reset()                    { *m = AuthorizeResponse{} }
@@ -397,6 +429,27 @@ func (m *AuthorizeResponse) GetAuthorized() bool {
 	return false
 }
 
+func (m *AuthorizeResponse) GetScope() Scope {
+	if m != nil {
+		return m.Scope
+	}
+	return Scope_NONE
+}
+
+func (m *AuthorizeResponse) GetACLRevision() int64 {
+	if m != nil {
+		return m.ACLRevision
+	}
+	return 0
+}
+
+func (m *AuthorizeResponse) GetCached() bool {
+	if m != nil {
+		return m.Cached
+	}
+	return false
+}
+
 type GetScopeRequest struct {
 	Username string   `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
 	Repos    []string `protobuf:"bytes,2,rep,name=repos" json:"repos,omitempty"`
@@ -541,13 +594,431 @@ func (m *SetACLResponse) String() string            { return proto.CompactTextSt
 func (*SetACLResponse) ProtoMessage()               {}
 func (*SetACLResponse) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{24} }
 
+type RepoACL struct {
+	Repo string `protobuf:"bytes,1,opt,name=repo,proto3" json:"repo,omitempty"`
+	ACL  *ACL   `protobuf:"bytes,2,opt,name=acl" json:"acl,omitempty"`
+}
+
+func (m *RepoACL) Reset()                    { *m = RepoACL{} }
+func (m *RepoACL) String() string            { return proto.CompactTextString(m) }
+func (*RepoACL) ProtoMessage()               {}
+func (*RepoACL) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{25} }
+
+func (m *RepoACL) GetRepo() string {
+	if m != nil {
+		return m.Repo
+	}
+	return ""
+}
+
+func (m *RepoACL) GetACL() *ACL {
+	if m != nil {
+		return m.ACL
+	}
+	return nil
+}
+
+type ExportACLsRequest struct {
+	// repos are the repos whose ACLs are exported. If it's empty, the ACLs of
+	// every repo that the caller can read are exported.
+	Repos []string `protobuf:"bytes,1,rep,name=repos" json:"repos,omitempty"`
+}
+
+func (m *ExportACLsRequest) Reset()                    { *m = ExportACLsRequest{} }
+func (m *ExportACLsRequest) String() string            { return proto.CompactTextString(m) }
+func (*ExportACLsRequest) ProtoMessage()               {}
+func (*ExportACLsRequest) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{26} }
+
+func (m *ExportACLsRequest) GetRepos() []string {
+	if m != nil {
+		return m.Repos
+	}
+	return nil
+}
+
+type ExportACLsResponse struct {
+	ACLs []*RepoACL `protobuf:"bytes,1,rep,name=acls" json:"acls,omitempty"`
+}
+
+func (m *ExportACLsResponse) Reset()                    { *m = ExportACLsResponse{} }
+func (m *ExportACLsResponse) String() string            { return proto.CompactTextString(m) }
+func (*ExportACLsResponse) ProtoMessage()               {}
+func (*ExportACLsResponse) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{27} }
+
+func (m *ExportACLsResponse) GetACLs() []*RepoACL {
+	if m != nil {
+		return m.ACLs
+	}
+	return nil
+}
+
+type ImportACLsRequest struct {
+	// acls replace the ACLs of their repos, all at once. The caller must be
+	// able to set the ACL of every repo, otherwise none are set.
+	ACLs []*RepoACL `protobuf:"bytes,1,rep,name=acls" json:"acls,omitempty"`
+}
+
+func (m *ImportACLsRequest) Reset()                    { *m = ImportACLsRequest{} }
+func (m *ImportACLsRequest) String() string            { return proto.CompactTextString(m) }
+func (*ImportACLsRequest) ProtoMessage()               {}
+func (*ImportACLsRequest) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{28} }
+
+func (m *ImportACLsRequest) GetACLs() []*RepoACL {
+	if m != nil {
+		return m.ACLs
+	}
+	return nil
+}
+
+type ImportACLsResponse struct {
+}
+
+func (m *ImportACLsResponse) Reset()                    { *m = ImportACLsResponse{} }
+func (m *ImportACLsResponse) String() string            { return proto.CompactTextString(m) }
+func (*ImportACLsResponse) ProtoMessage()               {}
+func (*ImportACLsResponse) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{29} }
+
+type ApplyACLTemplateRequest struct {
+	Template *ACL `protobuf:"bytes,1,opt,name=template" json:"template,omitempty"`
+	// repos are the names of the repos that the template is applied to, or
+	// glob patterns (e.g. "team-a-*") matching them.
+	Repos []string `protobuf:"bytes,2,rep,name=repos" json:"repos,omitempty"`
+	// replace makes the template the whole ACL of each repo. Otherwise the
+	// scopes of the users in the template are set, a scope of NONE removing
+	// the user, and other users keep their scopes.
+	Replace bool `protobuf:"varint,3,opt,name=replace,proto3" json:"replace,omitempty"`
+}
+
+func (m *ApplyACLTemplateRequest) Reset()                    { *m = ApplyACLTemplateRequest{} }
+func (m *ApplyACLTemplateRequest) String() string            { return proto.CompactTextString(m) }
+func (*ApplyACLTemplateRequest) ProtoMessage()               {}
+func (*ApplyACLTemplateRequest) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{30} }
+
+func (m *ApplyACLTemplateRequest) GetTemplate() *ACL {
+	if m != nil {
+		return m.Template
+	}
+	return nil
+}
+
+func (m *ApplyACLTemplateRequest) GetRepos() []string {
+	if m != nil {
+		return m.Repos
+	}
+	return nil
+}
+
+func (m *ApplyACLTemplateRequest) GetReplace() bool {
+	if m != nil {
+		return m.Replace
+	}
+	return false
+}
+
+type ApplyACLTemplateResponse struct {
+	// repos are the repos whose ACLs the template was applied to.
+	Repos []string `protobuf:"bytes,1,rep,name=repos" json:"repos,omitempty"`
+}
+
+func (m *ApplyACLTemplateResponse) Reset()                    { *m = ApplyACLTemplateResponse{} }
+func (m *ApplyACLTemplateResponse) String() string            { return proto.CompactTextString(m) }
+func (*ApplyACLTemplateResponse) ProtoMessage()               {}
+func (*ApplyACLTemplateResponse) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{31} }
+
+func (m *ApplyACLTemplateResponse) GetRepos() []string {
+	if m != nil {
+		return m.Repos
+	}
+	return nil
+}
+
+type DiffACLsRequest struct {
+	// desired are the ACLs that the repos should have.
+	Desired []*RepoACL `protobuf:"bytes,1,rep,name=desired" json:"desired,omitempty"`
+}
+
+func (m *DiffACLsRequest) Reset()                    { *m = DiffACLsRequest{} }
+func (m *DiffACLsRequest) String() string            { return proto.CompactTextString(m) }
+func (*DiffACLsRequest) ProtoMessage()               {}
+func (*DiffACLsRequest) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{32} }
+
+func (m *DiffACLsRequest) GetDesired() []*RepoACL {
+	if m != nil {
+		return m.Desired
+	}
+	return nil
+}
+
+// ACLChange is a user whose actual scope in a repo differs from their desired
+// one.
+type ACLChange struct {
+	Repo     string `protobuf:"bytes,1,opt,name=repo,proto3" json:"repo,omitempty"`
+	Username string `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	Actual   Scope  `protobuf:"varint,3,opt,name=actual,proto3,enum=auth.Scope" json:"actual,omitempty"`
+	Desired  Scope  `protobuf:"varint,4,opt,name=desired,proto3,enum=auth.Scope" json:"desired,omitempty"`
+}
+
+func (m *ACLChange) Reset()                    { *m = ACLChange{} }
+func (m *ACLChange) String() string            { return proto.CompactTextString(m) }
+func (*ACLChange) ProtoMessage()               {}
+func (*ACLChange) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{33} }
+
+func (m *ACLChange) GetRepo() string {
+	if m != nil {
+		return m.Repo
+	}
+	return ""
+}
+
+func (m *ACLChange) GetUsername() string {
+	if m != nil {
+		return m.Username
+	}
+	return ""
+}
+
+func (m *ACLChange) GetActual() Scope {
+	if m != nil {
+		return m.Actual
+	}
+	return Scope_NONE
+}
+
+func (m *ACLChange) GetDesired() Scope {
+	if m != nil {
+		return m.Desired
+	}
+	return Scope_NONE
+}
+
+type DiffACLsResponse struct {
+	Changes []*ACLChange `protobuf:"bytes,1,rep,name=changes" json:"changes,omitempty"`
+}
+
+func (m *DiffACLsResponse) Reset()                    { *m = DiffACLsResponse{} }
+func (m *DiffACLsResponse) String() string            { return proto.CompactTextString(m) }
+func (*DiffACLsResponse) ProtoMessage()               {}
+func (*DiffACLsResponse) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{34} }
+
+func (m *DiffACLsResponse) GetChanges() []*ACLChange {
+	if m != nil {
+		return m.Changes
+	}
+	return nil
+}
+
+// ServiceAccount is an identity for automation. It can read its input repos
+// and write its output repos, and nothing else, regardless of ACLs, but never
+// has more access to a repo than its owner.
+type ServiceAccount struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// owner is the user that created the service account
+	Owner       string   `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	InputRepos  []string `protobuf:"bytes,3,rep,name=input_repos,json=inputRepos" json:"input_repos,omitempty"`
+	OutputRepos []string `protobuf:"bytes,4,rep,name=output_repos,json=outputRepos" json:"output_repos,omitempty"`
+	// token_hash is the hash of the service account's current token. It's not
+	// returned by GetServiceAccounts.
+	TokenHash string `protobuf:"bytes,5,opt,name=token_hash,json=tokenHash,proto3" json:"token_hash,omitempty"`
+}
+
+func (m *ServiceAccount) Reset()                    { *m = ServiceAccount{} }
+func (m *ServiceAccount) String() string            { return proto.CompactTextString(m) }
+func (*ServiceAccount) ProtoMessage()               {}
+func (*ServiceAccount) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{35} }
+
+func (m *ServiceAccount) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ServiceAccount) GetOwner() string {
+	if m != nil {
+		return m.Owner
+	}
+	return ""
+}
+
+func (m *ServiceAccount) GetInputRepos() []string {
+	if m != nil {
+		return m.InputRepos
+	}
+	return nil
+}
+
+func (m *ServiceAccount) GetOutputRepos() []string {
+	if m != nil {
+		return m.OutputRepos
+	}
+	return nil
+}
+
+func (m *ServiceAccount) GetTokenHash() string {
+	if m != nil {
+		return m.TokenHash
+	}
+	return ""
+}
+
+type CreateServiceAccountRequest struct {
+	Name        string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	InputRepos  []string `protobuf:"bytes,2,rep,name=input_repos,json=inputRepos" json:"input_repos,omitempty"`
+	OutputRepos []string `protobuf:"bytes,3,rep,name=output_repos,json=outputRepos" json:"output_repos,omitempty"`
+}
+
+func (m *CreateServiceAccountRequest) Reset()         { *m = CreateServiceAccountRequest{} }
+func (m *CreateServiceAccountRequest) String() string { return proto.CompactTextString(m) }
+func (*CreateServiceAccountRequest) ProtoMessage()    {}
+func (*CreateServiceAccountRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptorAuth, []int{36}
+}
+
+func (m *CreateServiceAccountRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *CreateServiceAccountRequest) GetInputRepos() []string {
+	if m != nil {
+		return m.InputRepos
+	}
+	return nil
+}
+
+func (m *CreateServiceAccountRequest) GetOutputRepos() []string {
+	if m != nil {
+		return m.OutputRepos
+	}
+	return nil
+}
+
+type CreateServiceAccountResponse struct {
+	Token string `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+}
+
+func (m *CreateServiceAccountResponse) Reset()         { *m = CreateServiceAccountResponse{} }
+func (m *CreateServiceAccountResponse) String() string { return proto.CompactTextString(m) }
+func (*CreateServiceAccountResponse) ProtoMessage()    {}
+func (*CreateServiceAccountResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptorAuth, []int{37}
+}
+
+func (m *CreateServiceAccountResponse) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+type RotateServiceAccountTokenRequest struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+}
+
+func (m *RotateServiceAccountTokenRequest) Reset()         { *m = RotateServiceAccountTokenRequest{} }
+func (m *RotateServiceAccountTokenRequest) String() string { return proto.CompactTextString(m) }
+func (*RotateServiceAccountTokenRequest) ProtoMessage()    {}
+func (*RotateServiceAccountTokenRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptorAuth, []int{38}
+}
+
+func (m *RotateServiceAccountTokenRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type RotateServiceAccountTokenResponse struct {
+	Token string `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+}
+
+func (m *RotateServiceAccountTokenResponse) Reset()         { *m = RotateServiceAccountTokenResponse{} }
+func (m *RotateServiceAccountTokenResponse) String() string { return proto.CompactTextString(m) }
+func (*RotateServiceAccountTokenResponse) ProtoMessage()    {}
+func (*RotateServiceAccountTokenResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptorAuth, []int{39}
+}
+
+func (m *RotateServiceAccountTokenResponse) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+type GetServiceAccountsRequest struct {
+}
+
+func (m *GetServiceAccountsRequest) Reset()                    { *m = GetServiceAccountsRequest{} }
+func (m *GetServiceAccountsRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetServiceAccountsRequest) ProtoMessage()               {}
+func (*GetServiceAccountsRequest) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{40} }
+
+type GetServiceAccountsResponse struct {
+	ServiceAccounts []*ServiceAccount `protobuf:"bytes,1,rep,name=service_accounts,json=serviceAccounts" json:"service_accounts,omitempty"`
+}
+
+func (m *GetServiceAccountsResponse) Reset()                    { *m = GetServiceAccountsResponse{} }
+func (m *GetServiceAccountsResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetServiceAccountsResponse) ProtoMessage()               {}
+func (*GetServiceAccountsResponse) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{41} }
+
+func (m *GetServiceAccountsResponse) GetServiceAccounts() []*ServiceAccount {
+	if m != nil {
+		return m.ServiceAccounts
+	}
+	return nil
+}
+
+type DeleteServiceAccountRequest struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+}
+
+func (m *DeleteServiceAccountRequest) Reset()         { *m = DeleteServiceAccountRequest{} }
+func (m *DeleteServiceAccountRequest) String() string { return proto.CompactTextString(m) }
+func (*DeleteServiceAccountRequest) ProtoMessage()    {}
+func (*DeleteServiceAccountRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptorAuth, []int{42}
+}
+
+func (m *DeleteServiceAccountRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type DeleteServiceAccountResponse struct {
+}
+
+func (m *DeleteServiceAccountResponse) Reset()         { *m = DeleteServiceAccountResponse{} }
+func (m *DeleteServiceAccountResponse) String() string { return proto.CompactTextString(m) }
+func (*DeleteServiceAccountResponse) ProtoMessage()    {}
+func (*DeleteServiceAccountResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptorAuth, []int{43}
+}
+
 type GetCapabilityRequest struct {
+	// service_account, if set, is the name of the service account that the
+	// capability is for, instead of the caller, who must own it or be an admin.
+	// It's how pipelines run as a service account.
+	ServiceAccount string `protobuf:"bytes,1,opt,name=service_account,json=serviceAccount,proto3" json:"service_account,omitempty"`
 }
 
 func (m *GetCapabilityRequest) Reset()                    { *m = GetCapabilityRequest{} }
 func (m *GetCapabilityRequest) String() string            { return proto.CompactTextString(m) }
 func (*GetCapabilityRequest) ProtoMessage()               {}
-func (*GetCapabilityRequest) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{25} }
+func (*GetCapabilityRequest) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{44} }
+
+func (m *GetCapabilityRequest) GetServiceAccount() string {
+	if m != nil {
+		return m.ServiceAccount
+	}
+	return ""
+}
 
 type GetCapabilityResponse struct {
 	Capability string `protobuf:"bytes,1,opt,name=capability,proto3" json:"capability,omitempty"`
@@ -556,7 +1027,7 @@ type GetCapabilityResponse struct {
 func (m *GetCapabilityResponse) Reset()                    { *m = GetCapabilityResponse{} }
 func (m *GetCapabilityResponse) String() string            { return proto.CompactTextString(m) }
 func (*GetCapabilityResponse) ProtoMessage()               {}
-func (*GetCapabilityResponse) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{26} }
+func (*GetCapabilityResponse) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{45} }
 
 func (m *GetCapabilityResponse) GetCapability() string {
 	if m != nil {
@@ -572,7 +1043,7 @@ type RevokeAuthTokenRequest struct {
 func (m *RevokeAuthTokenRequest) Reset()                    { *m = RevokeAuthTokenRequest{} }
 func (m *RevokeAuthTokenRequest) String() string            { return proto.CompactTextString(m) }
 func (*RevokeAuthTokenRequest) ProtoMessage()               {}
-func (*RevokeAuthTokenRequest) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{27} }
+func (*RevokeAuthTokenRequest) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{46} }
 
 func (m *RevokeAuthTokenRequest) GetToken() string {
 	if m != nil {
@@ -587,7 +1058,7 @@ type RevokeAuthTokenResponse struct {
 func (m *RevokeAuthTokenResponse) Reset()                    { *m = RevokeAuthTokenResponse{} }
 func (m *RevokeAuthTokenResponse) String() string            { return proto.CompactTextString(m) }
 func (*RevokeAuthTokenResponse) ProtoMessage()               {}
-func (*RevokeAuthTokenResponse) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{28} }
+func (*RevokeAuthTokenResponse) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{47} }
 
 func init() {
 	proto.RegisterType((*ActivateRequest)(nil), "auth.ActivateRequest")
@@ -615,6 +1086,25 @@ func init() {
 	proto.RegisterType((*GetACLResponse)(nil), "auth.GetACLResponse")
 	proto.RegisterType((*SetACLRequest)(nil), "auth.SetACLRequest")
 	proto.RegisterType((*SetACLResponse)(nil), "auth.SetACLResponse")
+	proto.RegisterType((*RepoACL)(nil), "auth.RepoACL")
+	proto.RegisterType((*ExportACLsRequest)(nil), "auth.ExportACLsRequest")
+	proto.RegisterType((*ExportACLsResponse)(nil), "auth.ExportACLsResponse")
+	proto.RegisterType((*ImportACLsRequest)(nil), "auth.ImportACLsRequest")
+	proto.RegisterType((*ImportACLsResponse)(nil), "auth.ImportACLsResponse")
+	proto.RegisterType((*ApplyACLTemplateRequest)(nil), "auth.ApplyACLTemplateRequest")
+	proto.RegisterType((*ApplyACLTemplateResponse)(nil), "auth.ApplyACLTemplateResponse")
+	proto.RegisterType((*DiffACLsRequest)(nil), "auth.DiffACLsRequest")
+	proto.RegisterType((*ACLChange)(nil), "auth.ACLChange")
+	proto.RegisterType((*DiffACLsResponse)(nil), "auth.DiffACLsResponse")
+	proto.RegisterType((*ServiceAccount)(nil), "auth.ServiceAccount")
+	proto.RegisterType((*CreateServiceAccountRequest)(nil), "auth.CreateServiceAccountRequest")
+	proto.RegisterType((*CreateServiceAccountResponse)(nil), "auth.CreateServiceAccountResponse")
+	proto.RegisterType((*RotateServiceAccountTokenRequest)(nil), "auth.RotateServiceAccountTokenRequest")
+	proto.RegisterType((*RotateServiceAccountTokenResponse)(nil), "auth.RotateServiceAccountTokenResponse")
+	proto.RegisterType((*GetServiceAccountsRequest)(nil), "auth.GetServiceAccountsRequest")
+	proto.RegisterType((*GetServiceAccountsResponse)(nil), "auth.GetServiceAccountsResponse")
+	proto.RegisterType((*DeleteServiceAccountRequest)(nil), "auth.DeleteServiceAccountRequest")
+	proto.RegisterType((*DeleteServiceAccountResponse)(nil), "auth.DeleteServiceAccountResponse")
 	proto.RegisterType((*GetCapabilityRequest)(nil), "auth.GetCapabilityRequest")
 	proto.RegisterType((*GetCapabilityResponse)(nil), "auth.GetCapabilityResponse")
 	proto.RegisterType((*RevokeAuthTokenRequest)(nil), "auth.RevokeAuthTokenRequest")
@@ -650,6 +1140,18 @@ type APIClient interface {
 	SetScope(ctx context.Context, in *SetScopeRequest, opts ...grpc.CallOption) (*SetScopeResponse, error)
 	GetACL(ctx context.Context, in *GetACLRequest, opts ...grpc.CallOption) (*GetACLResponse, error)
 	SetACL(ctx context.Context, in *SetACLRequest, opts ...grpc.CallOption) (*SetACLResponse, error)
+	// ExportACLs, ImportACLs, ApplyACLTemplate and DiffACLs get, set and
+	// compare the ACLs of many repos at once
+	ExportACLs(ctx context.Context, in *ExportACLsRequest, opts ...grpc.CallOption) (*ExportACLsResponse, error)
+	ImportACLs(ctx context.Context, in *ImportACLsRequest, opts ...grpc.CallOption) (*ImportACLsResponse, error)
+	ApplyACLTemplate(ctx context.Context, in *ApplyACLTemplateRequest, opts ...grpc.CallOption) (*ApplyACLTemplateResponse, error)
+	DiffACLs(ctx context.Context, in *DiffACLsRequest, opts ...grpc.CallOption) (*DiffACLsResponse, error)
+	// CreateServiceAccount, RotateServiceAccountToken, GetServiceAccounts and
+	// DeleteServiceAccount manage service accounts, whose tokens don't expire
+	CreateServiceAccount(ctx context.Context, in *CreateServiceAccountRequest, opts ...grpc.CallOption) (*CreateServiceAccountResponse, error)
+	RotateServiceAccountToken(ctx context.Context, in *RotateServiceAccountTokenRequest, opts ...grpc.CallOption) (*RotateServiceAccountTokenResponse, error)
+	GetServiceAccounts(ctx context.Context, in *GetServiceAccountsRequest, opts ...grpc.CallOption) (*GetServiceAccountsResponse, error)
+	DeleteServiceAccount(ctx context.Context, in *DeleteServiceAccountRequest, opts ...grpc.CallOption) (*DeleteServiceAccountResponse, error)
 	GetCapability(ctx context.Context, in *GetCapabilityRequest, opts ...grpc.CallOption) (*GetCapabilityResponse, error)
 	RevokeAuthToken(ctx context.Context, in *RevokeAuthTokenRequest, opts ...grpc.CallOption) (*RevokeAuthTokenResponse, error)
 }
@@ -761,35 +1263,107 @@ func (c *aPIClient) SetACL(ctx context.Context, in *SetACLRequest, opts ...grpc.
 	return out, nil
 }
 
-func (c *aPIClient) GetCapability(ctx context.Context, in *GetCapabilityRequest, opts ...grpc.CallOption) (*GetCapabilityResponse, error) {
-	out := new(GetCapabilityResponse)
-	err := grpc.Invoke(ctx, "/auth.API/GetCapability", in, out, c.cc, opts...)
+func (c *aPIClient) ExportACLs(ctx context.Context, in *ExportACLsRequest, opts ...grpc.CallOption) (*ExportACLsResponse, error) {
+	out := new(ExportACLsResponse)
+	err := grpc.Invoke(ctx, "/auth.API/ExportACLs", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *aPIClient) RevokeAuthToken(ctx context.Context, in *RevokeAuthTokenRequest, opts ...grpc.CallOption) (*RevokeAuthTokenResponse, error) {
-	out := new(RevokeAuthTokenResponse)
-	err := grpc.Invoke(ctx, "/auth.API/RevokeAuthToken", in, out, c.cc, opts...)
+func (c *aPIClient) ImportACLs(ctx context.Context, in *ImportACLsRequest, opts ...grpc.CallOption) (*ImportACLsResponse, error) {
+	out := new(ImportACLsResponse)
+	err := grpc.Invoke(ctx, "/auth.API/ImportACLs", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-// Server API for API service
+func (c *aPIClient) ApplyACLTemplate(ctx context.Context, in *ApplyACLTemplateRequest, opts ...grpc.CallOption) (*ApplyACLTemplateResponse, error) {
+	out := new(ApplyACLTemplateResponse)
+	err := grpc.Invoke(ctx, "/auth.API/ApplyACLTemplate", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
 
-type APIServer interface {
-	// Activate/Deactivate the auth API. 'Activate' sets an initial set of admins
-	// for the Pachyderm cluster, and 'Deactivate' removes all ACLs, tokens, and
-	// admins from the Pachyderm cluster, making all data publicly accessable
-	Activate(context.Context, *ActivateRequest) (*ActivateResponse, error)
-	Deactivate(context.Context, *DeactivateRequest) (*DeactivateResponse, error)
-	// GetAdmins returns the current list of cluster admins
-	GetAdmins(context.Context, *GetAdminsRequest) (*GetAdminsResponse, error)
-	// ModifyAdmins adds or removes admins from the cluster
+func (c *aPIClient) DiffACLs(ctx context.Context, in *DiffACLsRequest, opts ...grpc.CallOption) (*DiffACLsResponse, error) {
+	out := new(DiffACLsResponse)
+	err := grpc.Invoke(ctx, "/auth.API/DiffACLs", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) CreateServiceAccount(ctx context.Context, in *CreateServiceAccountRequest, opts ...grpc.CallOption) (*CreateServiceAccountResponse, error) {
+	out := new(CreateServiceAccountResponse)
+	err := grpc.Invoke(ctx, "/auth.API/CreateServiceAccount", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) RotateServiceAccountToken(ctx context.Context, in *RotateServiceAccountTokenRequest, opts ...grpc.CallOption) (*RotateServiceAccountTokenResponse, error) {
+	out := new(RotateServiceAccountTokenResponse)
+	err := grpc.Invoke(ctx, "/auth.API/RotateServiceAccountToken", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) GetServiceAccounts(ctx context.Context, in *GetServiceAccountsRequest, opts ...grpc.CallOption) (*GetServiceAccountsResponse, error) {
+	out := new(GetServiceAccountsResponse)
+	err := grpc.Invoke(ctx, "/auth.API/GetServiceAccounts", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) DeleteServiceAccount(ctx context.Context, in *DeleteServiceAccountRequest, opts ...grpc.CallOption) (*DeleteServiceAccountResponse, error) {
+	out := new(DeleteServiceAccountResponse)
+	err := grpc.Invoke(ctx, "/auth.API/DeleteServiceAccount", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) GetCapability(ctx context.Context, in *GetCapabilityRequest, opts ...grpc.CallOption) (*GetCapabilityResponse, error) {
+	out := new(GetCapabilityResponse)
+	err := grpc.Invoke(ctx, "/auth.API/GetCapability", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) RevokeAuthToken(ctx context.Context, in *RevokeAuthTokenRequest, opts ...grpc.CallOption) (*RevokeAuthTokenResponse, error) {
+	out := new(RevokeAuthTokenResponse)
+	err := grpc.Invoke(ctx, "/auth.API/RevokeAuthToken", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for API service
+
+type APIServer interface {
+	// Activate/Deactivate the auth API. 'Activate' sets an initial set of admins
+	// for the Pachyderm cluster, and 'Deactivate' removes all ACLs, tokens, and
+	// admins from the Pachyderm cluster, making all data publicly accessable
+	Activate(context.Context, *ActivateRequest) (*ActivateResponse, error)
+	Deactivate(context.Context, *DeactivateRequest) (*DeactivateResponse, error)
+	// GetAdmins returns the current list of cluster admins
+	GetAdmins(context.Context, *GetAdminsRequest) (*GetAdminsResponse, error)
+	// ModifyAdmins adds or removes admins from the cluster
 	ModifyAdmins(context.Context, *ModifyAdminsRequest) (*ModifyAdminsResponse, error)
 	Authenticate(context.Context, *AuthenticateRequest) (*AuthenticateResponse, error)
 	Authorize(context.Context, *AuthorizeRequest) (*AuthorizeResponse, error)
@@ -798,6 +1372,18 @@ type APIServer interface {
 	SetScope(context.Context, *SetScopeRequest) (*SetScopeResponse, error)
 	GetACL(context.Context, *GetACLRequest) (*GetACLResponse, error)
 	SetACL(context.Context, *SetACLRequest) (*SetACLResponse, error)
+	// ExportACLs, ImportACLs, ApplyACLTemplate and DiffACLs get, set and
+	// compare the ACLs of many repos at once
+	ExportACLs(context.Context, *ExportACLsRequest) (*ExportACLsResponse, error)
+	ImportACLs(context.Context, *ImportACLsRequest) (*ImportACLsResponse, error)
+	ApplyACLTemplate(context.Context, *ApplyACLTemplateRequest) (*ApplyACLTemplateResponse, error)
+	DiffACLs(context.Context, *DiffACLsRequest) (*DiffACLsResponse, error)
+	// CreateServiceAccount, RotateServiceAccountToken, GetServiceAccounts and
+	// DeleteServiceAccount manage service accounts, whose tokens don't expire
+	CreateServiceAccount(context.Context, *CreateServiceAccountRequest) (*CreateServiceAccountResponse, error)
+	RotateServiceAccountToken(context.Context, *RotateServiceAccountTokenRequest) (*RotateServiceAccountTokenResponse, error)
+	GetServiceAccounts(context.Context, *GetServiceAccountsRequest) (*GetServiceAccountsResponse, error)
+	DeleteServiceAccount(context.Context, *DeleteServiceAccountRequest) (*DeleteServiceAccountResponse, error)
 	GetCapability(context.Context, *GetCapabilityRequest) (*GetCapabilityResponse, error)
 	RevokeAuthToken(context.Context, *RevokeAuthTokenRequest) (*RevokeAuthTokenResponse, error)
 }
@@ -1004,6 +1590,150 @@ func _API_SetACL_Handler(srv interface{}, ctx context.Context, dec func(interfac
 	return interceptor(ctx, in, info, handler)
 }
 
+func _API_ExportACLs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportACLsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).ExportACLs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/auth.API/ExportACLs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).ExportACLs(ctx, req.(*ExportACLsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_ImportACLs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportACLsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).ImportACLs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/auth.API/ImportACLs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).ImportACLs(ctx, req.(*ImportACLsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_ApplyACLTemplate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ApplyACLTemplateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).ApplyACLTemplate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/auth.API/ApplyACLTemplate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).ApplyACLTemplate(ctx, req.(*ApplyACLTemplateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_DiffACLs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DiffACLsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).DiffACLs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/auth.API/DiffACLs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).DiffACLs(ctx, req.(*DiffACLsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_CreateServiceAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateServiceAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).CreateServiceAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/auth.API/CreateServiceAccount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).CreateServiceAccount(ctx, req.(*CreateServiceAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_RotateServiceAccountToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RotateServiceAccountTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).RotateServiceAccountToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/auth.API/RotateServiceAccountToken",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).RotateServiceAccountToken(ctx, req.(*RotateServiceAccountTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_GetServiceAccounts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetServiceAccountsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetServiceAccounts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/auth.API/GetServiceAccounts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetServiceAccounts(ctx, req.(*GetServiceAccountsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_DeleteServiceAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteServiceAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).DeleteServiceAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/auth.API/DeleteServiceAccount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).DeleteServiceAccount(ctx, req.(*DeleteServiceAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _API_GetCapability_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetCapabilityRequest)
 	if err := dec(in); err != nil {
@@ -1088,6 +1818,38 @@ var _API_serviceDesc = grpc.ServiceDesc{
 			MethodName: "SetACL",
 			Handler:    _API_SetACL_Handler,
 		},
+		{
+			MethodName: "ExportACLs",
+			Handler:    _API_ExportACLs_Handler,
+		},
+		{
+			MethodName: "ImportACLs",
+			Handler:    _API_ImportACLs_Handler,
+		},
+		{
+			MethodName: "ApplyACLTemplate",
+			Handler:    _API_ApplyACLTemplate_Handler,
+		},
+		{
+			MethodName: "DiffACLs",
+			Handler:    _API_DiffACLs_Handler,
+		},
+		{
+			MethodName: "CreateServiceAccount",
+			Handler:    _API_CreateServiceAccount_Handler,
+		},
+		{
+			MethodName: "RotateServiceAccountToken",
+			Handler:    _API_RotateServiceAccountToken_Handler,
+		},
+		{
+			MethodName: "GetServiceAccounts",
+			Handler:    _API_GetServiceAccounts_Handler,
+		},
+		{
+			MethodName: "DeleteServiceAccount",
+			Handler:    _API_DeleteServiceAccount_Handler,
+		},
 		{
 			MethodName: "GetCapability",
 			Handler:    _API_GetCapability_Handler,
@@ -1557,6 +2319,26 @@ func (m *AuthorizeResponse) MarshalTo(dAtA []byte) (int, error) {
 		}
 		i++
 	}
+	if m.Scope != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintAuth(dAtA, i, uint64(m.Scope))
+	}
+	if m.ACLRevision != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintAuth(dAtA, i, uint64(m.ACLRevision))
+	}
+	if m.Cached {
+		dAtA[i] = 0x20
+		i++
+		if m.Cached {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
 	return i, nil
 }
 
@@ -1791,7 +2573,7 @@ func (m *SetACLResponse) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
-func (m *GetCapabilityRequest) Marshal() (dAtA []byte, err error) {
+func (m *RepoACL) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -1801,15 +2583,31 @@ func (m *GetCapabilityRequest) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *GetCapabilityRequest) MarshalTo(dAtA []byte) (int, error) {
+func (m *RepoACL) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
+	if len(m.Repo) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintAuth(dAtA, i, uint64(len(m.Repo)))
+		i += copy(dAtA[i:], m.Repo)
+	}
+	if m.ACL != nil {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintAuth(dAtA, i, uint64(m.ACL.Size()))
+		n5, err := m.ACL.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n5
+	}
 	return i, nil
 }
 
-func (m *GetCapabilityResponse) Marshal() (dAtA []byte, err error) {
+func (m *ExportACLsRequest) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -1819,21 +2617,30 @@ func (m *GetCapabilityResponse) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *GetCapabilityResponse) MarshalTo(dAtA []byte) (int, error) {
+func (m *ExportACLsRequest) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if len(m.Capability) > 0 {
-		dAtA[i] = 0xa
-		i++
-		i = encodeVarintAuth(dAtA, i, uint64(len(m.Capability)))
-		i += copy(dAtA[i:], m.Capability)
+	if len(m.Repos) > 0 {
+		for _, s := range m.Repos {
+			dAtA[i] = 0xa
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
 	}
 	return i, nil
 }
 
-func (m *RevokeAuthTokenRequest) Marshal() (dAtA []byte, err error) {
+func (m *ExportACLsResponse) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -1843,21 +2650,27 @@ func (m *RevokeAuthTokenRequest) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *RevokeAuthTokenRequest) MarshalTo(dAtA []byte) (int, error) {
+func (m *ExportACLsResponse) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if len(m.Token) > 0 {
-		dAtA[i] = 0xa
-		i++
-		i = encodeVarintAuth(dAtA, i, uint64(len(m.Token)))
-		i += copy(dAtA[i:], m.Token)
+	if len(m.ACLs) > 0 {
+		for _, msg := range m.ACLs {
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintAuth(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
 	}
 	return i, nil
 }
 
-func (m *RevokeAuthTokenResponse) Marshal() (dAtA []byte, err error) {
+func (m *ImportACLsRequest) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -1867,356 +2680,2854 @@ func (m *RevokeAuthTokenResponse) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *RevokeAuthTokenResponse) MarshalTo(dAtA []byte) (int, error) {
+func (m *ImportACLsRequest) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
+	if len(m.ACLs) > 0 {
+		for _, msg := range m.ACLs {
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintAuth(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
 	return i, nil
 }
 
-func encodeFixed64Auth(dAtA []byte, offset int, v uint64) int {
-	dAtA[offset] = uint8(v)
-	dAtA[offset+1] = uint8(v >> 8)
-	dAtA[offset+2] = uint8(v >> 16)
-	dAtA[offset+3] = uint8(v >> 24)
-	dAtA[offset+4] = uint8(v >> 32)
-	dAtA[offset+5] = uint8(v >> 40)
-	dAtA[offset+6] = uint8(v >> 48)
-	dAtA[offset+7] = uint8(v >> 56)
-	return offset + 8
-}
-func encodeFixed32Auth(dAtA []byte, offset int, v uint32) int {
-	dAtA[offset] = uint8(v)
-	dAtA[offset+1] = uint8(v >> 8)
-	dAtA[offset+2] = uint8(v >> 16)
-	dAtA[offset+3] = uint8(v >> 24)
-	return offset + 4
-}
-func encodeVarintAuth(dAtA []byte, offset int, v uint64) int {
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
-	}
-	dAtA[offset] = uint8(v)
-	return offset + 1
-}
-func (m *ActivateRequest) Size() (n int) {
-	var l int
-	_ = l
-	if len(m.Admins) > 0 {
-		for _, s := range m.Admins {
-			l = len(s)
-			n += 1 + l + sovAuth(uint64(l))
-		}
+func (m *ImportACLsResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	return n
-}
-
-func (m *ActivateResponse) Size() (n int) {
-	var l int
-	_ = l
-	return n
-}
-
-func (m *DeactivateRequest) Size() (n int) {
-	var l int
-	_ = l
-	return n
+	return dAtA[:n], nil
 }
 
-func (m *DeactivateResponse) Size() (n int) {
+func (m *ImportACLsResponse) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	return n
+	return i, nil
 }
 
-func (m *GetAdminsRequest) Size() (n int) {
-	var l int
-	_ = l
-	return n
+func (m *ApplyACLTemplateRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
 }
 
-func (m *GetAdminsResponse) Size() (n int) {
+func (m *ApplyACLTemplateRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if len(m.Admins) > 0 {
-		for _, s := range m.Admins {
-			l = len(s)
-			n += 1 + l + sovAuth(uint64(l))
+	if m.Template != nil {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintAuth(dAtA, i, uint64(m.Template.Size()))
+		n6, err := m.Template.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
 		}
+		i += n6
 	}
-	return n
-}
-
-func (m *ModifyAdminsRequest) Size() (n int) {
-	var l int
-	_ = l
-	if len(m.Add) > 0 {
-		for _, s := range m.Add {
+	if len(m.Repos) > 0 {
+		for _, s := range m.Repos {
+			dAtA[i] = 0x12
+			i++
 			l = len(s)
-			n += 1 + l + sovAuth(uint64(l))
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
 		}
 	}
-	if len(m.Remove) > 0 {
-		for _, s := range m.Remove {
-			l = len(s)
-			n += 1 + l + sovAuth(uint64(l))
+	if m.Replace {
+		dAtA[i] = 0x18
+		i++
+		if m.Replace {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
 		}
+		i++
 	}
-	return n
+	return i, nil
 }
 
-func (m *ModifyAdminsResponse) Size() (n int) {
-	var l int
-	_ = l
-	return n
+func (m *ApplyACLTemplateResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
 }
 
-func (m *User) Size() (n int) {
+func (m *ApplyACLTemplateResponse) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	l = len(m.Username)
-	if l > 0 {
-		n += 1 + l + sovAuth(uint64(l))
-	}
-	if m.Type != 0 {
-		n += 1 + sovAuth(uint64(m.Type))
+	if len(m.Repos) > 0 {
+		for _, s := range m.Repos {
+			dAtA[i] = 0xa
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
 	}
-	return n
+	return i, nil
 }
 
-func (m *AuthenticateRequest) Size() (n int) {
-	var l int
-	_ = l
-	l = len(m.GithubToken)
-	if l > 0 {
-		n += 1 + l + sovAuth(uint64(l))
-	}
-	l = len(m.GithubUsername)
-	if l > 0 {
-		n += 1 + l + sovAuth(uint64(l))
+func (m *DiffACLsRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return dAtA[:n], nil
 }
 
-func (m *AuthenticateResponse) Size() (n int) {
+func (m *DiffACLsRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	l = len(m.PachToken)
-	if l > 0 {
-		n += 1 + l + sovAuth(uint64(l))
+	if len(m.Desired) > 0 {
+		for _, msg := range m.Desired {
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintAuth(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
 	}
-	return n
+	return i, nil
 }
 
-func (m *WhoAmIRequest) Size() (n int) {
-	var l int
-	_ = l
-	return n
+func (m *ACLChange) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
 }
 
-func (m *WhoAmIResponse) Size() (n int) {
+func (m *ACLChange) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	l = len(m.Username)
-	if l > 0 {
-		n += 1 + l + sovAuth(uint64(l))
+	if len(m.Repo) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintAuth(dAtA, i, uint64(len(m.Repo)))
+		i += copy(dAtA[i:], m.Repo)
 	}
-	if m.IsAdmin {
-		n += 2
+	if len(m.Username) > 0 {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintAuth(dAtA, i, uint64(len(m.Username)))
+		i += copy(dAtA[i:], m.Username)
 	}
-	return n
+	if m.Actual != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintAuth(dAtA, i, uint64(m.Actual))
+	}
+	if m.Desired != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintAuth(dAtA, i, uint64(m.Desired))
+	}
+	return i, nil
 }
 
-func (m *ACLEntry) Size() (n int) {
-	var l int
-	_ = l
-	l = len(m.Username)
-	if l > 0 {
-		n += 1 + l + sovAuth(uint64(l))
-	}
-	if m.Scope != 0 {
-		n += 1 + sovAuth(uint64(m.Scope))
+func (m *DiffACLsResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return dAtA[:n], nil
 }
 
-func (m *ACL) Size() (n int) {
+func (m *DiffACLsResponse) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if len(m.Entries) > 0 {
-		for k, v := range m.Entries {
-			_ = k
-			_ = v
-			mapEntrySize := 1 + len(k) + sovAuth(uint64(len(k))) + 1 + sovAuth(uint64(v))
-			n += mapEntrySize + 1 + sovAuth(uint64(mapEntrySize))
+	if len(m.Changes) > 0 {
+		for _, msg := range m.Changes {
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintAuth(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
 		}
 	}
-	return n
+	return i, nil
 }
 
-func (m *AuthorizeRequest) Size() (n int) {
+func (m *ServiceAccount) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ServiceAccount) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	l = len(m.Repo)
-	if l > 0 {
-		n += 1 + l + sovAuth(uint64(l))
+	if len(m.Name) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintAuth(dAtA, i, uint64(len(m.Name)))
+		i += copy(dAtA[i:], m.Name)
 	}
-	if m.Scope != 0 {
-		n += 1 + sovAuth(uint64(m.Scope))
+	if len(m.Owner) > 0 {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintAuth(dAtA, i, uint64(len(m.Owner)))
+		i += copy(dAtA[i:], m.Owner)
 	}
-	return n
+	if len(m.InputRepos) > 0 {
+		for _, s := range m.InputRepos {
+			dAtA[i] = 0x1a
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
+	}
+	if len(m.OutputRepos) > 0 {
+		for _, s := range m.OutputRepos {
+			dAtA[i] = 0x22
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
+	}
+	if len(m.TokenHash) > 0 {
+		dAtA[i] = 0x2a
+		i++
+		i = encodeVarintAuth(dAtA, i, uint64(len(m.TokenHash)))
+		i += copy(dAtA[i:], m.TokenHash)
+	}
+	return i, nil
 }
 
-func (m *AuthorizeResponse) Size() (n int) {
-	var l int
-	_ = l
-	if m.Authorized {
-		n += 2
+func (m *CreateServiceAccountRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return dAtA[:n], nil
 }
 
-func (m *GetScopeRequest) Size() (n int) {
+func (m *CreateServiceAccountRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	l = len(m.Username)
-	if l > 0 {
-		n += 1 + l + sovAuth(uint64(l))
+	if len(m.Name) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintAuth(dAtA, i, uint64(len(m.Name)))
+		i += copy(dAtA[i:], m.Name)
 	}
-	if len(m.Repos) > 0 {
-		for _, s := range m.Repos {
+	if len(m.InputRepos) > 0 {
+		for _, s := range m.InputRepos {
+			dAtA[i] = 0x12
+			i++
 			l = len(s)
-			n += 1 + l + sovAuth(uint64(l))
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
 		}
 	}
-	return n
+	if len(m.OutputRepos) > 0 {
+		for _, s := range m.OutputRepos {
+			dAtA[i] = 0x1a
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
+	}
+	return i, nil
 }
 
-func (m *GetScopeResponse) Size() (n int) {
+func (m *CreateServiceAccountResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CreateServiceAccountResponse) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if len(m.Scopes) > 0 {
-		l = 0
-		for _, e := range m.Scopes {
-			l += sovAuth(uint64(e))
-		}
-		n += 1 + sovAuth(uint64(l)) + l
+	if len(m.Token) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintAuth(dAtA, i, uint64(len(m.Token)))
+		i += copy(dAtA[i:], m.Token)
 	}
-	return n
+	return i, nil
 }
 
-func (m *SetScopeRequest) Size() (n int) {
+func (m *RotateServiceAccountTokenRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RotateServiceAccountTokenRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	l = len(m.Username)
-	if l > 0 {
-		n += 1 + l + sovAuth(uint64(l))
+	if len(m.Name) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintAuth(dAtA, i, uint64(len(m.Name)))
+		i += copy(dAtA[i:], m.Name)
 	}
-	l = len(m.Repo)
-	if l > 0 {
-		n += 1 + l + sovAuth(uint64(l))
+	return i, nil
+}
+
+func (m *RotateServiceAccountTokenResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	if m.Scope != 0 {
-		n += 1 + sovAuth(uint64(m.Scope))
+	return dAtA[:n], nil
+}
+
+func (m *RotateServiceAccountTokenResponse) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Token) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintAuth(dAtA, i, uint64(len(m.Token)))
+		i += copy(dAtA[i:], m.Token)
 	}
-	return n
+	return i, nil
 }
 
-func (m *SetScopeResponse) Size() (n int) {
+func (m *GetServiceAccountsRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetServiceAccountsRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	return n
+	return i, nil
 }
 
-func (m *GetACLRequest) Size() (n int) {
+func (m *GetServiceAccountsResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetServiceAccountsResponse) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	l = len(m.Repo)
-	if l > 0 {
-		n += 1 + l + sovAuth(uint64(l))
+	if len(m.ServiceAccounts) > 0 {
+		for _, msg := range m.ServiceAccounts {
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintAuth(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
 	}
-	return n
+	return i, nil
 }
 
-func (m *GetACLResponse) Size() (n int) {
+func (m *DeleteServiceAccountRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *DeleteServiceAccountRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if m.ACL != nil {
-		l = m.ACL.Size()
-		n += 1 + l + sovAuth(uint64(l))
+	if len(m.Name) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintAuth(dAtA, i, uint64(len(m.Name)))
+		i += copy(dAtA[i:], m.Name)
 	}
-	return n
+	return i, nil
 }
 
-func (m *SetACLRequest) Size() (n int) {
+func (m *DeleteServiceAccountResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *DeleteServiceAccountResponse) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	l = len(m.Repo)
-	if l > 0 {
-		n += 1 + l + sovAuth(uint64(l))
+	return i, nil
+}
+
+func (m *GetCapabilityRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	if m.NewACL != nil {
-		l = m.NewACL.Size()
-		n += 1 + l + sovAuth(uint64(l))
+	return dAtA[:n], nil
+}
+
+func (m *GetCapabilityRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.ServiceAccount) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintAuth(dAtA, i, uint64(len(m.ServiceAccount)))
+		i += copy(dAtA[i:], m.ServiceAccount)
+	}
+	return i, nil
+}
+
+func (m *GetCapabilityResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetCapabilityResponse) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Capability) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintAuth(dAtA, i, uint64(len(m.Capability)))
+		i += copy(dAtA[i:], m.Capability)
+	}
+	return i, nil
+}
+
+func (m *RevokeAuthTokenRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RevokeAuthTokenRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Token) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintAuth(dAtA, i, uint64(len(m.Token)))
+		i += copy(dAtA[i:], m.Token)
+	}
+	return i, nil
+}
+
+func (m *RevokeAuthTokenResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RevokeAuthTokenResponse) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	return i, nil
+}
+
+func encodeFixed64Auth(dAtA []byte, offset int, v uint64) int {
+	dAtA[offset] = uint8(v)
+	dAtA[offset+1] = uint8(v >> 8)
+	dAtA[offset+2] = uint8(v >> 16)
+	dAtA[offset+3] = uint8(v >> 24)
+	dAtA[offset+4] = uint8(v >> 32)
+	dAtA[offset+5] = uint8(v >> 40)
+	dAtA[offset+6] = uint8(v >> 48)
+	dAtA[offset+7] = uint8(v >> 56)
+	return offset + 8
+}
+func encodeFixed32Auth(dAtA []byte, offset int, v uint32) int {
+	dAtA[offset] = uint8(v)
+	dAtA[offset+1] = uint8(v >> 8)
+	dAtA[offset+2] = uint8(v >> 16)
+	dAtA[offset+3] = uint8(v >> 24)
+	return offset + 4
+}
+func encodeVarintAuth(dAtA []byte, offset int, v uint64) int {
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return offset + 1
+}
+func (m *ActivateRequest) Size() (n int) {
+	var l int
+	_ = l
+	if len(m.Admins) > 0 {
+		for _, s := range m.Admins {
+			l = len(s)
+			n += 1 + l + sovAuth(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ActivateResponse) Size() (n int) {
+	var l int
+	_ = l
+	return n
+}
+
+func (m *DeactivateRequest) Size() (n int) {
+	var l int
+	_ = l
+	return n
+}
+
+func (m *DeactivateResponse) Size() (n int) {
+	var l int
+	_ = l
+	return n
+}
+
+func (m *GetAdminsRequest) Size() (n int) {
+	var l int
+	_ = l
+	return n
+}
+
+func (m *GetAdminsResponse) Size() (n int) {
+	var l int
+	_ = l
+	if len(m.Admins) > 0 {
+		for _, s := range m.Admins {
+			l = len(s)
+			n += 1 + l + sovAuth(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ModifyAdminsRequest) Size() (n int) {
+	var l int
+	_ = l
+	if len(m.Add) > 0 {
+		for _, s := range m.Add {
+			l = len(s)
+			n += 1 + l + sovAuth(uint64(l))
+		}
+	}
+	if len(m.Remove) > 0 {
+		for _, s := range m.Remove {
+			l = len(s)
+			n += 1 + l + sovAuth(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ModifyAdminsResponse) Size() (n int) {
+	var l int
+	_ = l
+	return n
+}
+
+func (m *User) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Username)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	if m.Type != 0 {
+		n += 1 + sovAuth(uint64(m.Type))
+	}
+	return n
+}
+
+func (m *AuthenticateRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.GithubToken)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	l = len(m.GithubUsername)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	return n
+}
+
+func (m *AuthenticateResponse) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.PachToken)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	return n
+}
+
+func (m *WhoAmIRequest) Size() (n int) {
+	var l int
+	_ = l
+	return n
+}
+
+func (m *WhoAmIResponse) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Username)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	if m.IsAdmin {
+		n += 2
+	}
+	return n
+}
+
+func (m *ACLEntry) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Username)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	if m.Scope != 0 {
+		n += 1 + sovAuth(uint64(m.Scope))
+	}
+	return n
+}
+
+func (m *ACL) Size() (n int) {
+	var l int
+	_ = l
+	if len(m.Entries) > 0 {
+		for k, v := range m.Entries {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovAuth(uint64(len(k))) + 1 + sovAuth(uint64(v))
+			n += mapEntrySize + 1 + sovAuth(uint64(mapEntrySize))
+		}
+	}
+	return n
+}
+
+func (m *AuthorizeRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Repo)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	if m.Scope != 0 {
+		n += 1 + sovAuth(uint64(m.Scope))
+	}
+	return n
+}
+
+func (m *AuthorizeResponse) Size() (n int) {
+	var l int
+	_ = l
+	if m.Authorized {
+		n += 2
+	}
+	if m.Scope != 0 {
+		n += 1 + sovAuth(uint64(m.Scope))
+	}
+	if m.ACLRevision != 0 {
+		n += 1 + sovAuth(uint64(m.ACLRevision))
+	}
+	if m.Cached {
+		n += 2
+	}
+	return n
+}
+
+func (m *GetScopeRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Username)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	if len(m.Repos) > 0 {
+		for _, s := range m.Repos {
+			l = len(s)
+			n += 1 + l + sovAuth(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *GetScopeResponse) Size() (n int) {
+	var l int
+	_ = l
+	if len(m.Scopes) > 0 {
+		l = 0
+		for _, e := range m.Scopes {
+			l += sovAuth(uint64(e))
+		}
+		n += 1 + sovAuth(uint64(l)) + l
+	}
+	return n
+}
+
+func (m *SetScopeRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Username)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	l = len(m.Repo)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	if m.Scope != 0 {
+		n += 1 + sovAuth(uint64(m.Scope))
+	}
+	return n
+}
+
+func (m *SetScopeResponse) Size() (n int) {
+	var l int
+	_ = l
+	return n
+}
+
+func (m *GetACLRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Repo)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	return n
+}
+
+func (m *GetACLResponse) Size() (n int) {
+	var l int
+	_ = l
+	if m.ACL != nil {
+		l = m.ACL.Size()
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	return n
+}
+
+func (m *SetACLRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Repo)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	if m.NewACL != nil {
+		l = m.NewACL.Size()
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	return n
+}
+
+func (m *SetACLResponse) Size() (n int) {
+	var l int
+	_ = l
+	return n
+}
+
+func (m *RepoACL) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Repo)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	if m.ACL != nil {
+		l = m.ACL.Size()
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	return n
+}
+
+func (m *ExportACLsRequest) Size() (n int) {
+	var l int
+	_ = l
+	if len(m.Repos) > 0 {
+		for _, s := range m.Repos {
+			l = len(s)
+			n += 1 + l + sovAuth(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ExportACLsResponse) Size() (n int) {
+	var l int
+	_ = l
+	if len(m.ACLs) > 0 {
+		for _, e := range m.ACLs {
+			l = e.Size()
+			n += 1 + l + sovAuth(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ImportACLsRequest) Size() (n int) {
+	var l int
+	_ = l
+	if len(m.ACLs) > 0 {
+		for _, e := range m.ACLs {
+			l = e.Size()
+			n += 1 + l + sovAuth(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ImportACLsResponse) Size() (n int) {
+	var l int
+	_ = l
+	return n
+}
+
+func (m *ApplyACLTemplateRequest) Size() (n int) {
+	var l int
+	_ = l
+	if m.Template != nil {
+		l = m.Template.Size()
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	if len(m.Repos) > 0 {
+		for _, s := range m.Repos {
+			l = len(s)
+			n += 1 + l + sovAuth(uint64(l))
+		}
+	}
+	if m.Replace {
+		n += 2
+	}
+	return n
+}
+
+func (m *ApplyACLTemplateResponse) Size() (n int) {
+	var l int
+	_ = l
+	if len(m.Repos) > 0 {
+		for _, s := range m.Repos {
+			l = len(s)
+			n += 1 + l + sovAuth(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *DiffACLsRequest) Size() (n int) {
+	var l int
+	_ = l
+	if len(m.Desired) > 0 {
+		for _, e := range m.Desired {
+			l = e.Size()
+			n += 1 + l + sovAuth(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ACLChange) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Repo)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	l = len(m.Username)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	if m.Actual != 0 {
+		n += 1 + sovAuth(uint64(m.Actual))
+	}
+	if m.Desired != 0 {
+		n += 1 + sovAuth(uint64(m.Desired))
+	}
+	return n
+}
+
+func (m *DiffACLsResponse) Size() (n int) {
+	var l int
+	_ = l
+	if len(m.Changes) > 0 {
+		for _, e := range m.Changes {
+			l = e.Size()
+			n += 1 + l + sovAuth(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ServiceAccount) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	l = len(m.Owner)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	if len(m.InputRepos) > 0 {
+		for _, s := range m.InputRepos {
+			l = len(s)
+			n += 1 + l + sovAuth(uint64(l))
+		}
+	}
+	if len(m.OutputRepos) > 0 {
+		for _, s := range m.OutputRepos {
+			l = len(s)
+			n += 1 + l + sovAuth(uint64(l))
+		}
+	}
+	l = len(m.TokenHash)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	return n
+}
+
+func (m *CreateServiceAccountRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	if len(m.InputRepos) > 0 {
+		for _, s := range m.InputRepos {
+			l = len(s)
+			n += 1 + l + sovAuth(uint64(l))
+		}
+	}
+	if len(m.OutputRepos) > 0 {
+		for _, s := range m.OutputRepos {
+			l = len(s)
+			n += 1 + l + sovAuth(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *CreateServiceAccountResponse) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Token)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	return n
+}
+
+func (m *RotateServiceAccountTokenRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	return n
+}
+
+func (m *RotateServiceAccountTokenResponse) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Token)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	return n
+}
+
+func (m *GetServiceAccountsRequest) Size() (n int) {
+	var l int
+	_ = l
+	return n
+}
+
+func (m *GetServiceAccountsResponse) Size() (n int) {
+	var l int
+	_ = l
+	if len(m.ServiceAccounts) > 0 {
+		for _, e := range m.ServiceAccounts {
+			l = e.Size()
+			n += 1 + l + sovAuth(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *DeleteServiceAccountRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	return n
+}
+
+func (m *DeleteServiceAccountResponse) Size() (n int) {
+	var l int
+	_ = l
+	return n
+}
+
+func (m *GetCapabilityRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.ServiceAccount)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	return n
+}
+
+func (m *GetCapabilityResponse) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Capability)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	return n
+}
+
+func (m *RevokeAuthTokenRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Token)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	return n
+}
+
+func (m *RevokeAuthTokenResponse) Size() (n int) {
+	var l int
+	_ = l
+	return n
+}
+
+func sovAuth(x uint64) (n int) {
+	for {
+		n++
+		x >>= 7
+		if x == 0 {
+			break
+		}
+	}
+	return n
+}
+func sozAuth(x uint64) (n int) {
+	return sovAuth(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *ActivateRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAuth
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ActivateRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ActivateRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Admins", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Admins = append(m.Admins, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAuth(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAuth
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ActivateResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAuth
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ActivateResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ActivateResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAuth(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAuth
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *DeactivateRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAuth
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: DeactivateRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: DeactivateRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAuth(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAuth
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *DeactivateResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAuth
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: DeactivateResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: DeactivateResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAuth(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAuth
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetAdminsRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAuth
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetAdminsRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetAdminsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAuth(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAuth
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetAdminsResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAuth
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetAdminsResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetAdminsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Admins", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Admins = append(m.Admins, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAuth(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAuth
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ModifyAdminsRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAuth
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ModifyAdminsRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ModifyAdminsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Add", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Add = append(m.Add, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Remove", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Remove = append(m.Remove, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAuth(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAuth
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ModifyAdminsResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAuth
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ModifyAdminsResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ModifyAdminsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAuth(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAuth
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *User) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAuth
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: User: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: User: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Username", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Username = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+			}
+			m.Type = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Type |= (User_UserType(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAuth(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAuth
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *AuthenticateRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAuth
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: AuthenticateRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: AuthenticateRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field GithubToken", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.GithubToken = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field GithubUsername", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.GithubUsername = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAuth(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAuth
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *AuthenticateResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAuth
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: AuthenticateResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: AuthenticateResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PachToken", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.PachToken = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAuth(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAuth
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *WhoAmIRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAuth
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: WhoAmIRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: WhoAmIRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAuth(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAuth
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *WhoAmIResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAuth
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: WhoAmIResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: WhoAmIResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Username", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Username = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IsAdmin", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.IsAdmin = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAuth(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAuth
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ACLEntry) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAuth
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ACLEntry: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ACLEntry: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Username", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Username = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Scope", wireType)
+			}
+			m.Scope = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Scope |= (Scope(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAuth(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAuth
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ACL) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAuth
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ACL: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ACL: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Entries", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var keykey uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				keykey |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			var stringLenmapkey uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLenmapkey |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLenmapkey := int(stringLenmapkey)
+			if intStringLenmapkey < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postStringIndexmapkey := iNdEx + intStringLenmapkey
+			if postStringIndexmapkey > l {
+				return io.ErrUnexpectedEOF
+			}
+			mapkey := string(dAtA[iNdEx:postStringIndexmapkey])
+			iNdEx = postStringIndexmapkey
+			if m.Entries == nil {
+				m.Entries = make(map[string]Scope)
+			}
+			if iNdEx < postIndex {
+				var valuekey uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowAuth
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					valuekey |= (uint64(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				var mapvalue Scope
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowAuth
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					mapvalue |= (Scope(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.Entries[mapkey] = mapvalue
+			} else {
+				var mapvalue Scope
+				m.Entries[mapkey] = mapvalue
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAuth(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAuth
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *AuthorizeRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAuth
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: AuthorizeRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: AuthorizeRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Repo", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Repo = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Scope", wireType)
+			}
+			m.Scope = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Scope |= (Scope(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAuth(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAuth
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *AuthorizeResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAuth
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: AuthorizeResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: AuthorizeResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Authorized", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Authorized = bool(v != 0)
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Scope", wireType)
+			}
+			m.Scope = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Scope |= (Scope(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ACLRevision", wireType)
+			}
+			m.ACLRevision = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ACLRevision |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Cached", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Cached = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAuth(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAuth
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetScopeRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAuth
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetScopeRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetScopeRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Username", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Username = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Repos", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Repos = append(m.Repos, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAuth(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAuth
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
 	}
-	return n
-}
-
-func (m *SetACLResponse) Size() (n int) {
-	var l int
-	_ = l
-	return n
-}
 
-func (m *GetCapabilityRequest) Size() (n int) {
-	var l int
-	_ = l
-	return n
-}
-
-func (m *GetCapabilityResponse) Size() (n int) {
-	var l int
-	_ = l
-	l = len(m.Capability)
-	if l > 0 {
-		n += 1 + l + sovAuth(uint64(l))
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	return n
+	return nil
 }
-
-func (m *RevokeAuthTokenRequest) Size() (n int) {
-	var l int
-	_ = l
-	l = len(m.Token)
-	if l > 0 {
-		n += 1 + l + sovAuth(uint64(l))
+func (m *GetScopeResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAuth
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetScopeResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetScopeResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType == 0 {
+				var v Scope
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowAuth
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= (Scope(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.Scopes = append(m.Scopes, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowAuth
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= (int(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthAuth
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				for iNdEx < postIndex {
+					var v Scope
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowAuth
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= (Scope(b) & 0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.Scopes = append(m.Scopes, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field Scopes", wireType)
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAuth(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAuth
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
 	}
-	return n
-}
-
-func (m *RevokeAuthTokenResponse) Size() (n int) {
-	var l int
-	_ = l
-	return n
-}
 
-func sovAuth(x uint64) (n int) {
-	for {
-		n++
-		x >>= 7
-		if x == 0 {
-			break
-		}
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	return n
-}
-func sozAuth(x uint64) (n int) {
-	return sovAuth(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+	return nil
 }
-func (m *ActivateRequest) Unmarshal(dAtA []byte) error {
+func (m *SetScopeRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2239,15 +5550,44 @@ func (m *ActivateRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ActivateRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: SetScopeRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ActivateRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: SetScopeRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Username", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Username = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Admins", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Repo", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -2272,8 +5612,27 @@ func (m *ActivateRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Admins = append(m.Admins, string(dAtA[iNdEx:postIndex]))
+			m.Repo = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Scope", wireType)
+			}
+			m.Scope = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Scope |= (Scope(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAuth(dAtA[iNdEx:])
@@ -2295,7 +5654,7 @@ func (m *ActivateRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ActivateResponse) Unmarshal(dAtA []byte) error {
+func (m *SetScopeResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2318,10 +5677,10 @@ func (m *ActivateResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ActivateResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: SetScopeResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ActivateResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: SetScopeResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		default:
@@ -2345,7 +5704,7 @@ func (m *ActivateResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *DeactivateRequest) Unmarshal(dAtA []byte) error {
+func (m *GetACLRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2368,12 +5727,41 @@ func (m *DeactivateRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: DeactivateRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: GetACLRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: DeactivateRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: GetACLRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Repo", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Repo = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAuth(dAtA[iNdEx:])
@@ -2395,7 +5783,7 @@ func (m *DeactivateRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *DeactivateResponse) Unmarshal(dAtA []byte) error {
+func (m *GetACLResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2418,12 +5806,45 @@ func (m *DeactivateResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: DeactivateResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: GetACLResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: DeactivateResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: GetACLResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ACL", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.ACL == nil {
+				m.ACL = &ACL{}
+			}
+			if err := m.ACL.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAuth(dAtA[iNdEx:])
@@ -2445,7 +5866,7 @@ func (m *DeactivateResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *GetAdminsRequest) Unmarshal(dAtA []byte) error {
+func (m *SetACLRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2468,12 +5889,74 @@ func (m *GetAdminsRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: GetAdminsRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: SetACLRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: GetAdminsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: SetACLRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Repo", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Repo = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NewACL", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.NewACL == nil {
+				m.NewACL = &ACL{}
+			}
+			if err := m.NewACL.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAuth(dAtA[iNdEx:])
@@ -2495,7 +5978,7 @@ func (m *GetAdminsRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *GetAdminsResponse) Unmarshal(dAtA []byte) error {
+func (m *SetACLResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2518,41 +6001,12 @@ func (m *GetAdminsResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: GetAdminsResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: SetACLResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: GetAdminsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: SetACLResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Admins", wireType)
-			}
-			var stringLen uint64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowAuth
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthAuth
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Admins = append(m.Admins, string(dAtA[iNdEx:postIndex]))
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAuth(dAtA[iNdEx:])
@@ -2574,7 +6028,7 @@ func (m *GetAdminsResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ModifyAdminsRequest) Unmarshal(dAtA []byte) error {
+func (m *RepoACL) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2597,15 +6051,15 @@ func (m *ModifyAdminsRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ModifyAdminsRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: RepoACL: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ModifyAdminsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: RepoACL: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Add", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Repo", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -2630,13 +6084,13 @@ func (m *ModifyAdminsRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Add = append(m.Add, string(dAtA[iNdEx:postIndex]))
+			m.Repo = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Remove", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ACL", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAuth
@@ -2646,71 +6100,25 @@ func (m *ModifyAdminsRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				msglen |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthAuth
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Remove = append(m.Remove, string(dAtA[iNdEx:postIndex]))
-			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipAuth(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if skippy < 0 {
-				return ErrInvalidLengthAuth
-			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *ModifyAdminsResponse) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowAuth
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
-			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= (uint64(b) & 0x7F) << shift
-			if b < 0x80 {
-				break
+			if m.ACL == nil {
+				m.ACL = &ACL{}
 			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: ModifyAdminsResponse: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ModifyAdminsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
+			if err := m.ACL.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAuth(dAtA[iNdEx:])
@@ -2732,7 +6140,7 @@ func (m *ModifyAdminsResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *User) Unmarshal(dAtA []byte) error {
+func (m *ExportACLsRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2755,15 +6163,15 @@ func (m *User) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: User: wiretype end group for non-group")
+			return fmt.Errorf("proto: ExportACLsRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: User: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ExportACLsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Username", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Repos", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -2788,27 +6196,8 @@ func (m *User) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Username = string(dAtA[iNdEx:postIndex])
+			m.Repos = append(m.Repos, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
-		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
-			}
-			m.Type = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowAuth
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.Type |= (User_UserType(b) & 0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAuth(dAtA[iNdEx:])
@@ -2830,7 +6219,7 @@ func (m *User) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *AuthenticateRequest) Unmarshal(dAtA []byte) error {
+func (m *ExportACLsResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2853,17 +6242,17 @@ func (m *AuthenticateRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: AuthenticateRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: ExportACLsResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: AuthenticateRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ExportACLsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field GithubToken", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ACLs", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAuth
@@ -2873,49 +6262,22 @@ func (m *AuthenticateRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				msglen |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthAuth
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.GithubToken = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field GithubUsername", wireType)
-			}
-			var stringLen uint64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowAuth
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthAuth
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
+			m.ACLs = append(m.ACLs, &RepoACL{})
+			if err := m.ACLs[len(m.ACLs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
-			m.GithubUsername = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -2938,7 +6300,7 @@ func (m *AuthenticateRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *AuthenticateResponse) Unmarshal(dAtA []byte) error {
+func (m *ImportACLsRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2961,17 +6323,17 @@ func (m *AuthenticateResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: AuthenticateResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: ImportACLsRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: AuthenticateResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ImportACLsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field PachToken", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ACLs", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAuth
@@ -2981,20 +6343,22 @@ func (m *AuthenticateResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				msglen |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthAuth
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.PachToken = string(dAtA[iNdEx:postIndex])
+			m.ACLs = append(m.ACLs, &RepoACL{})
+			if err := m.ACLs[len(m.ACLs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -3017,7 +6381,7 @@ func (m *AuthenticateResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *WhoAmIRequest) Unmarshal(dAtA []byte) error {
+func (m *ImportACLsResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3040,10 +6404,10 @@ func (m *WhoAmIRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: WhoAmIRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: ImportACLsResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: WhoAmIRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ImportACLsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		default:
@@ -3067,7 +6431,7 @@ func (m *WhoAmIRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *WhoAmIResponse) Unmarshal(dAtA []byte) error {
+func (m *ApplyACLTemplateRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3090,15 +6454,48 @@ func (m *WhoAmIResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: WhoAmIResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: ApplyACLTemplateRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: WhoAmIResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ApplyACLTemplateRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Username", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Template", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Template == nil {
+				m.Template = &ACL{}
+			}
+			if err := m.Template.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Repos", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -3123,11 +6520,11 @@ func (m *WhoAmIResponse) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Username = string(dAtA[iNdEx:postIndex])
+			m.Repos = append(m.Repos, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
-		case 2:
+		case 3:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field IsAdmin", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Replace", wireType)
 			}
 			var v int
 			for shift := uint(0); ; shift += 7 {
@@ -3144,7 +6541,7 @@ func (m *WhoAmIResponse) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
-			m.IsAdmin = bool(v != 0)
+			m.Replace = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAuth(dAtA[iNdEx:])
@@ -3166,7 +6563,7 @@ func (m *WhoAmIResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ACLEntry) Unmarshal(dAtA []byte) error {
+func (m *ApplyACLTemplateResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3189,15 +6586,15 @@ func (m *ACLEntry) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ACLEntry: wiretype end group for non-group")
+			return fmt.Errorf("proto: ApplyACLTemplateResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ACLEntry: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ApplyACLTemplateResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Username", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Repos", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -3222,27 +6619,8 @@ func (m *ACLEntry) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Username = string(dAtA[iNdEx:postIndex])
+			m.Repos = append(m.Repos, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
-		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Scope", wireType)
-			}
-			m.Scope = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowAuth
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.Scope |= (Scope(b) & 0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAuth(dAtA[iNdEx:])
@@ -3264,7 +6642,7 @@ func (m *ACLEntry) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ACL) Unmarshal(dAtA []byte) error {
+func (m *DiffACLsRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3287,116 +6665,41 @@ func (m *ACL) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ACL: wiretype end group for non-group")
+			return fmt.Errorf("proto: DiffACLsRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ACL: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: DiffACLsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Entries", wireType)
-			}
-			var msglen int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowAuth
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				msglen |= (int(b) & 0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			if msglen < 0 {
-				return ErrInvalidLengthAuth
-			}
-			postIndex := iNdEx + msglen
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			var keykey uint64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowAuth
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				keykey |= (uint64(b) & 0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			var stringLenmapkey uint64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowAuth
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				stringLenmapkey |= (uint64(b) & 0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			intStringLenmapkey := int(stringLenmapkey)
-			if intStringLenmapkey < 0 {
-				return ErrInvalidLengthAuth
-			}
-			postStringIndexmapkey := iNdEx + intStringLenmapkey
-			if postStringIndexmapkey > l {
-				return io.ErrUnexpectedEOF
-			}
-			mapkey := string(dAtA[iNdEx:postStringIndexmapkey])
-			iNdEx = postStringIndexmapkey
-			if m.Entries == nil {
-				m.Entries = make(map[string]Scope)
-			}
-			if iNdEx < postIndex {
-				var valuekey uint64
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowAuth
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					valuekey |= (uint64(b) & 0x7F) << shift
-					if b < 0x80 {
-						break
-					}
-				}
-				var mapvalue Scope
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowAuth
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					mapvalue |= (Scope(b) & 0x7F) << shift
-					if b < 0x80 {
-						break
-					}
+				return fmt.Errorf("proto: wrong wireType = %d for field Desired", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
 				}
-				m.Entries[mapkey] = mapvalue
-			} else {
-				var mapvalue Scope
-				m.Entries[mapkey] = mapvalue
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Desired = append(m.Desired, &RepoACL{})
+			if err := m.Desired[len(m.Desired)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
 			iNdEx = postIndex
 		default:
@@ -3420,7 +6723,7 @@ func (m *ACL) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *AuthorizeRequest) Unmarshal(dAtA []byte) error {
+func (m *ACLChange) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3443,10 +6746,10 @@ func (m *AuthorizeRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: AuthorizeRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: ACLChange: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: AuthorizeRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ACLChange: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
@@ -3479,10 +6782,39 @@ func (m *AuthorizeRequest) Unmarshal(dAtA []byte) error {
 			m.Repo = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Username", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Username = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Scope", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Actual", wireType)
 			}
-			m.Scope = 0
+			m.Actual = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAuth
@@ -3492,7 +6824,26 @@ func (m *AuthorizeRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Scope |= (Scope(b) & 0x7F) << shift
+				m.Actual |= (Scope(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Desired", wireType)
+			}
+			m.Desired = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Desired |= (Scope(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -3518,7 +6869,7 @@ func (m *AuthorizeRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *AuthorizeResponse) Unmarshal(dAtA []byte) error {
+func (m *DiffACLsResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3541,17 +6892,17 @@ func (m *AuthorizeResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: AuthorizeResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: DiffACLsResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: AuthorizeResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: DiffACLsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Authorized", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Changes", wireType)
 			}
-			var v int
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAuth
@@ -3561,12 +6912,23 @@ func (m *AuthorizeResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= (int(b) & 0x7F) << shift
+				msglen |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.Authorized = bool(v != 0)
+			if msglen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Changes = append(m.Changes, &ACLChange{})
+			if err := m.Changes[len(m.Changes)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAuth(dAtA[iNdEx:])
@@ -3588,7 +6950,7 @@ func (m *AuthorizeResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *GetScopeRequest) Unmarshal(dAtA []byte) error {
+func (m *ServiceAccount) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3611,15 +6973,15 @@ func (m *GetScopeRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: GetScopeRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: ServiceAccount: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: GetScopeRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ServiceAccount: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Username", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -3644,11 +7006,11 @@ func (m *GetScopeRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Username = string(dAtA[iNdEx:postIndex])
+			m.Name = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Repos", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Owner", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -3673,120 +7035,95 @@ func (m *GetScopeRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Repos = append(m.Repos, string(dAtA[iNdEx:postIndex]))
+			m.Owner = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipAuth(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if skippy < 0 {
-				return ErrInvalidLengthAuth
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field InputRepos", wireType)
 			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *GetScopeResponse) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowAuth
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAuth
 			}
-			if iNdEx >= l {
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= (uint64(b) & 0x7F) << shift
-			if b < 0x80 {
-				break
+			m.InputRepos = append(m.InputRepos, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OutputRepos", wireType)
 			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: GetScopeResponse: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: GetScopeResponse: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
-			if wireType == 0 {
-				var v Scope
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowAuth
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					v |= (Scope(b) & 0x7F) << shift
-					if b < 0x80 {
-						break
-					}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
 				}
-				m.Scopes = append(m.Scopes, v)
-			} else if wireType == 2 {
-				var packedLen int
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowAuth
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					packedLen |= (int(b) & 0x7F) << shift
-					if b < 0x80 {
-						break
-					}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
 				}
-				if packedLen < 0 {
-					return ErrInvalidLengthAuth
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
 				}
-				postIndex := iNdEx + packedLen
-				if postIndex > l {
-					return io.ErrUnexpectedEOF
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.OutputRepos = append(m.OutputRepos, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TokenHash", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
 				}
-				for iNdEx < postIndex {
-					var v Scope
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowAuth
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						v |= (Scope(b) & 0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-					m.Scopes = append(m.Scopes, v)
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
 				}
-			} else {
-				return fmt.Errorf("proto: wrong wireType = %d for field Scopes", wireType)
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
 			}
+			m.TokenHash = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAuth(dAtA[iNdEx:])
@@ -3808,7 +7145,7 @@ func (m *GetScopeResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *SetScopeRequest) Unmarshal(dAtA []byte) error {
+func (m *CreateServiceAccountRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3831,15 +7168,15 @@ func (m *SetScopeRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: SetScopeRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: CreateServiceAccountRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SetScopeRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: CreateServiceAccountRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Username", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -3864,11 +7201,11 @@ func (m *SetScopeRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Username = string(dAtA[iNdEx:postIndex])
+			m.Name = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Repo", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field InputRepos", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -3893,13 +7230,13 @@ func (m *SetScopeRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Repo = string(dAtA[iNdEx:postIndex])
+			m.InputRepos = append(m.InputRepos, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
 		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Scope", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OutputRepos", wireType)
 			}
-			m.Scope = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAuth
@@ -3909,11 +7246,21 @@ func (m *SetScopeRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Scope |= (Scope(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.OutputRepos = append(m.OutputRepos, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAuth(dAtA[iNdEx:])
@@ -3935,7 +7282,7 @@ func (m *SetScopeRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *SetScopeResponse) Unmarshal(dAtA []byte) error {
+func (m *CreateServiceAccountResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3958,12 +7305,41 @@ func (m *SetScopeResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: SetScopeResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: CreateServiceAccountResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SetScopeResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: CreateServiceAccountResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Token", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Token = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAuth(dAtA[iNdEx:])
@@ -3985,7 +7361,7 @@ func (m *SetScopeResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *GetACLRequest) Unmarshal(dAtA []byte) error {
+func (m *RotateServiceAccountTokenRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4008,15 +7384,15 @@ func (m *GetACLRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: GetACLRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: RotateServiceAccountTokenRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: GetACLRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: RotateServiceAccountTokenRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Repo", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -4041,7 +7417,7 @@ func (m *GetACLRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Repo = string(dAtA[iNdEx:postIndex])
+			m.Name = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -4064,7 +7440,7 @@ func (m *GetACLRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *GetACLResponse) Unmarshal(dAtA []byte) error {
+func (m *RotateServiceAccountTokenResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4087,17 +7463,17 @@ func (m *GetACLResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: GetACLResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: RotateServiceAccountTokenResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: GetACLResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: RotateServiceAccountTokenResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ACL", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Token", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAuth
@@ -4107,25 +7483,71 @@ func (m *GetACLResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= (int(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthAuth
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.ACL == nil {
-				m.ACL = &ACL{}
-			}
-			if err := m.ACL.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.Token = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAuth(dAtA[iNdEx:])
+			if err != nil {
 				return err
 			}
-			iNdEx = postIndex
+			if skippy < 0 {
+				return ErrInvalidLengthAuth
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetServiceAccountsRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAuth
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetServiceAccountsRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetServiceAccountsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAuth(dAtA[iNdEx:])
@@ -4147,7 +7569,7 @@ func (m *GetACLResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *SetACLRequest) Unmarshal(dAtA []byte) error {
+func (m *GetServiceAccountsResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4170,17 +7592,17 @@ func (m *SetACLRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: SetACLRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: GetServiceAccountsResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SetACLRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: GetServiceAccountsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Repo", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ServiceAccounts", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAuth
@@ -4190,26 +7612,78 @@ func (m *SetACLRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				msglen |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthAuth
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Repo = string(dAtA[iNdEx:postIndex])
+			m.ServiceAccounts = append(m.ServiceAccounts, &ServiceAccount{})
+			if err := m.ServiceAccounts[len(m.ServiceAccounts)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
 			iNdEx = postIndex
-		case 2:
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAuth(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAuth
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *DeleteServiceAccountRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAuth
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: DeleteServiceAccountRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: DeleteServiceAccountRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field NewACL", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAuth
@@ -4219,24 +7693,20 @@ func (m *SetACLRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= (int(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthAuth
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.NewACL == nil {
-				m.NewACL = &ACL{}
-			}
-			if err := m.NewACL.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
+			m.Name = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -4259,7 +7729,7 @@ func (m *SetACLRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *SetACLResponse) Unmarshal(dAtA []byte) error {
+func (m *DeleteServiceAccountResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4282,10 +7752,10 @@ func (m *SetACLResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: SetACLResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: DeleteServiceAccountResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SetACLResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: DeleteServiceAccountResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		default:
@@ -4338,6 +7808,35 @@ func (m *GetCapabilityRequest) Unmarshal(dAtA []byte) error {
 			return fmt.Errorf("proto: GetCapabilityRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ServiceAccount", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ServiceAccount = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAuth(dAtA[iNdEx:])
@@ -4675,67 +8174,104 @@ var (
 func init() { proto.RegisterFile("client/auth/auth.proto", fileDescriptorAuth) }
 
 var fileDescriptorAuth = []byte{
-	// 984 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x56, 0x5f, 0x6f, 0xdb, 0x54,
-	0x14, 0x8f, 0xf3, 0xd7, 0x39, 0x69, 0x13, 0xf7, 0x36, 0x4b, 0x5b, 0xc3, 0xb2, 0xed, 0x0e, 0x69,
-	0x05, 0xa4, 0x6c, 0xea, 0x28, 0x43, 0x4c, 0x02, 0x79, 0x69, 0x14, 0x8c, 0xb2, 0xac, 0xb2, 0x5b,
-	0xfa, 0x58, 0xb9, 0xc9, 0xa5, 0xb1, 0x9a, 0xda, 0x21, 0x76, 0x5a, 0x05, 0x09, 0x89, 0x8f, 0xc1,
-	0x47, 0xe2, 0x91, 0x07, 0x9e, 0x27, 0x14, 0xbe, 0x08, 0xba, 0xff, 0x5c, 0xdb, 0xf1, 0x0a, 0xbc,
-	0x44, 0xd7, 0xbf, 0x73, 0xce, 0xef, 0x9c, 0xfb, 0xbb, 0xf7, 0x9c, 0x1b, 0x68, 0x8d, 0xa6, 0x2e,
-	0xf1, 0xc2, 0xe7, 0xce, 0x22, 0x9c, 0xb0, 0x9f, 0xce, 0x6c, 0xee, 0x87, 0x3e, 0x2a, 0xd2, 0xb5,
-	0xde, 0xbc, 0xf4, 0x2f, 0x7d, 0x06, 0x3c, 0xa7, 0x2b, 0x6e, 0xc3, 0x9f, 0x42, 0xc3, 0x18, 0x85,
-	0xee, 0x8d, 0x13, 0x12, 0x8b, 0xfc, 0xb4, 0x20, 0x41, 0x88, 0x5a, 0x50, 0x76, 0xc6, 0xd7, 0xae,
-	0x17, 0xec, 0xe6, 0x1f, 0x17, 0xf6, 0xab, 0x96, 0xf8, 0xc2, 0x08, 0xb4, 0x3b, 0xd7, 0x60, 0xe6,
-	0x7b, 0x01, 0xc1, 0xdb, 0xb0, 0x75, 0x44, 0x9c, 0x24, 0x01, 0x6e, 0x02, 0x8a, 0x83, 0xc2, 0x15,
-	0x81, 0xd6, 0x27, 0xa1, 0xc1, 0xb8, 0xa4, 0xe7, 0xe7, 0xb0, 0x15, 0xc3, 0xb8, 0x63, 0x2c, 0xbf,
-	0x92, 0xc8, 0xff, 0x2d, 0x6c, 0xbf, 0xf5, 0xc7, 0xee, 0x8f, 0xcb, 0x04, 0x07, 0xd2, 0xa0, 0xe0,
-	0x8c, 0xc7, 0xc2, 0x97, 0x2e, 0x29, 0xc1, 0x9c, 0x5c, 0xfb, 0x37, 0x44, 0x6e, 0x80, 0x7f, 0xe1,
-	0x16, 0x34, 0x93, 0x04, 0xa2, 0xb2, 0x5f, 0xa0, 0x78, 0x1a, 0x90, 0x39, 0xd2, 0x41, 0x5d, 0x04,
-	0x64, 0xee, 0x39, 0xd7, 0x64, 0x57, 0x79, 0xac, 0xec, 0x57, 0xad, 0xe8, 0x1b, 0x3d, 0x83, 0x62,
-	0xb8, 0x9c, 0x51, 0x46, 0x65, 0xbf, 0x7e, 0xb0, 0xdd, 0x61, 0xf2, 0xd2, 0x28, 0xf6, 0x73, 0xb2,
-	0x9c, 0x11, 0x8b, 0x39, 0xe0, 0x17, 0xa0, 0x4a, 0x04, 0xd5, 0xa0, 0x62, 0x0e, 0x7f, 0x30, 0x06,
-	0xe6, 0x91, 0x96, 0x43, 0x55, 0x28, 0x7d, 0x77, 0xfa, 0xd6, 0x18, 0x6a, 0x0a, 0xda, 0x00, 0xf5,
-	0xd8, 0x3c, 0xee, 0x0d, 0xcc, 0x61, 0x4f, 0xcb, 0x63, 0x07, 0xb6, 0x8d, 0x45, 0x38, 0x21, 0x5e,
-	0xe8, 0x8e, 0x62, 0xc7, 0xf0, 0x04, 0x36, 0x2e, 0xdd, 0x70, 0xb2, 0xb8, 0x38, 0x0f, 0xfd, 0x2b,
-	0xe2, 0x89, 0x8a, 0x6a, 0x1c, 0x3b, 0xa1, 0x10, 0x7a, 0x06, 0x0d, 0xe1, 0x12, 0xd5, 0x9d, 0x67,
-	0x5e, 0x75, 0x0e, 0x9f, 0x0a, 0x14, 0x1f, 0x42, 0x33, 0x99, 0x42, 0x48, 0xfd, 0x10, 0x60, 0xe6,
-	0x8c, 0x26, 0x89, 0x0c, 0x55, 0x8a, 0x30, 0x7e, 0xdc, 0x80, 0xcd, 0xb3, 0x89, 0x6f, 0x5c, 0x9b,
-	0xf2, 0xbc, 0xfa, 0x50, 0x97, 0x80, 0x60, 0xb8, 0x4f, 0xb3, 0x3d, 0x50, 0xdd, 0xe0, 0x9c, 0x9d,
-	0x1e, 0xab, 0x4b, 0xb5, 0x2a, 0x6e, 0xc0, 0xb4, 0xc7, 0x26, 0xa8, 0x46, 0x77, 0xd0, 0xf3, 0xc2,
-	0xf9, 0xf2, 0x5e, 0x8a, 0x27, 0x50, 0x0a, 0x46, 0x7e, 0xa4, 0x7b, 0x8d, 0xeb, 0x6e, 0x53, 0xc8,
-	0xe2, 0x16, 0xfc, 0xab, 0x02, 0x05, 0xa3, 0x3b, 0x40, 0x2f, 0xa0, 0x42, 0xbc, 0x70, 0xee, 0x12,
-	0x7e, 0x6f, 0x6a, 0x07, 0x2d, 0xee, 0x6c, 0x74, 0x07, 0x9d, 0x1e, 0x37, 0xb0, 0x7c, 0x96, 0x74,
-	0xd3, 0xfb, 0xb0, 0x11, 0x37, 0xd0, 0x9b, 0x74, 0x45, 0x96, 0xa2, 0x06, 0xba, 0xa4, 0xe9, 0x6f,
-	0x9c, 0xe9, 0x22, 0x3b, 0x3d, 0xb3, 0x7c, 0x9d, 0xff, 0x4a, 0xc1, 0x26, 0x68, 0x54, 0x5e, 0x7f,
-	0xee, 0xfe, 0x1c, 0x1d, 0x1f, 0x82, 0xe2, 0x9c, 0xcc, 0x7c, 0xc1, 0xc6, 0xd6, 0xff, 0x65, 0x37,
-	0x2f, 0x61, 0x2b, 0x46, 0x25, 0x44, 0x6e, 0x03, 0x38, 0x12, 0x1c, 0x33, 0x46, 0xd5, 0x8a, 0x21,
-	0xb8, 0x0b, 0x8d, 0x3e, 0x09, 0x39, 0x8f, 0x48, 0x7f, 0x9f, 0xa8, 0x4d, 0x28, 0xd1, 0x72, 0x64,
-	0x7f, 0xf3, 0x0f, 0xfc, 0x8a, 0xf5, 0xa7, 0x20, 0x11, 0x89, 0x9f, 0x42, 0x99, 0x95, 0xc5, 0x25,
-	0x4d, 0x55, 0x2c, 0x4c, 0x78, 0x0c, 0x0d, 0xfb, 0x7f, 0x64, 0x97, 0xc2, 0xe4, 0xb3, 0x84, 0x29,
-	0x7c, 0x50, 0x18, 0x04, 0x9a, 0x9d, 0x2a, 0x0f, 0x3f, 0x85, 0x4d, 0x3a, 0x3e, 0xba, 0x83, 0x7b,
-	0x44, 0xc7, 0x5f, 0x42, 0x5d, 0x3a, 0x89, 0x5d, 0x7d, 0x02, 0x05, 0x67, 0x34, 0x65, 0x4e, 0xb5,
-	0x83, 0x6a, 0x74, 0x4b, 0xde, 0x54, 0x56, 0xef, 0x1f, 0xd1, 0xab, 0x64, 0x51, 0x33, 0xb6, 0x61,
-	0xd3, 0xfe, 0x37, 0x72, 0xd4, 0x81, 0x8a, 0x47, 0x6e, 0xcf, 0x29, 0x5d, 0x3e, 0x4d, 0x07, 0xab,
-	0xf7, 0x8f, 0xca, 0x43, 0x72, 0x4b, 0x29, 0xca, 0x1e, 0xb9, 0x35, 0x46, 0x53, 0xac, 0x41, 0xdd,
-	0x4e, 0x14, 0x43, 0x87, 0x52, 0x9f, 0x84, 0x5d, 0x67, 0xe6, 0x5c, 0xb8, 0x53, 0x37, 0x5c, 0xca,
-	0x56, 0x7b, 0x05, 0x0f, 0x52, 0xf8, 0xdd, 0x65, 0x18, 0x45, 0xa8, 0x28, 0x26, 0x86, 0xe0, 0x0e,
-	0xb4, 0x2c, 0x72, 0xe3, 0x5f, 0x11, 0x7a, 0x8f, 0x58, 0x1f, 0xcb, 0x0d, 0x34, 0xa1, 0x14, 0x6f,
-	0x74, 0xfe, 0x81, 0xf7, 0x60, 0x67, 0xcd, 0x9f, 0xa7, 0xfa, 0xec, 0x0b, 0x28, 0x31, 0xc1, 0x91,
-	0x0a, 0xc5, 0xe1, 0xbb, 0x61, 0x4f, 0xcb, 0x21, 0x80, 0xb2, 0xd5, 0x33, 0x8e, 0x7a, 0x96, 0xa6,
-	0xd0, 0xf5, 0x99, 0x65, 0x9e, 0xf4, 0x2c, 0x2d, 0x4f, 0xa7, 0xdb, 0xbb, 0xb3, 0x61, 0xcf, 0xd2,
-	0x0a, 0x07, 0x7f, 0x96, 0xa1, 0x60, 0x1c, 0x9b, 0xe8, 0x35, 0xa8, 0xf2, 0xbd, 0x40, 0x0f, 0x84,
-	0x2c, 0xc9, 0x97, 0x42, 0x6f, 0xa5, 0x61, 0x21, 0x4a, 0x0e, 0x19, 0x00, 0x77, 0x6f, 0x08, 0xda,
-	0xe1, 0x7e, 0x6b, 0x4f, 0x8d, 0xbe, 0xbb, 0x6e, 0x88, 0x28, 0xbe, 0x81, 0x6a, 0xf4, 0xb8, 0x20,
-	0x91, 0x29, 0xfd, 0x02, 0xe9, 0x3b, 0x6b, 0x78, 0x14, 0xdf, 0x87, 0x8d, 0xf8, 0x73, 0x81, 0xf6,
-	0xb8, 0x6b, 0xc6, 0x1b, 0xa4, 0xeb, 0x59, 0xa6, 0x38, 0x51, 0x7c, 0xfa, 0x4a, 0xa2, 0x8c, 0xa1,
-	0x2f, 0x89, 0xb2, 0x86, 0x35, 0xdf, 0x51, 0x34, 0x1c, 0xe4, 0x8e, 0xd2, 0x83, 0x47, 0xee, 0x68,
-	0x6d, 0x8a, 0xe0, 0x1c, 0x3a, 0x84, 0x32, 0x1f, 0xdf, 0x48, 0x3c, 0x60, 0x89, 0xe9, 0xae, 0x37,
-	0x93, 0x60, 0x14, 0xf6, 0x1a, 0x54, 0x39, 0x19, 0xe4, 0x41, 0xa6, 0xc6, 0x8d, 0xde, 0x4a, 0xc3,
-	0xf1, 0x60, 0x3b, 0x15, 0x6c, 0x67, 0x07, 0xdb, 0xeb, 0xc1, 0x87, 0x50, 0xe6, 0xbd, 0x2b, 0x0b,
-	0x4e, 0xb4, 0xbb, 0x2c, 0x38, 0xd9, 0xde, 0x3c, 0xcc, 0x4e, 0x84, 0xd9, 0x59, 0x61, 0x76, 0x3a,
-	0xec, 0x7b, 0x36, 0x4e, 0xee, 0x5a, 0x0e, 0xe9, 0x11, 0xff, 0x5a, 0x7f, 0xea, 0x1f, 0x65, 0xda,
-	0x22, 0xae, 0x63, 0x68, 0xa4, 0xba, 0x0a, 0x7d, 0xcc, 0x23, 0xb2, 0x9b, 0x53, 0x7f, 0xf8, 0x01,
-	0xab, 0x64, 0x7c, 0xa3, 0xfd, 0xbe, 0x6a, 0x2b, 0x7f, 0xac, 0xda, 0xca, 0x5f, 0xab, 0xb6, 0xf2,
-	0xdb, 0xdf, 0xed, 0xdc, 0x45, 0x99, 0xfd, 0x85, 0x7b, 0xf9, 0x4f, 0x00, 0x00, 0x00, 0xff, 0xff,
-	0xe1, 0x42, 0x30, 0x6b, 0xf8, 0x09, 0x00, 0x00,
+	// 1581 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x58, 0xcb, 0x53, 0xdb, 0x48,
+	0x1a, 0x47, 0xb6, 0xb1, 0xcd, 0x67, 0xc0, 0xa2, 0x71, 0x8c, 0x11, 0xe1, 0xd5, 0xd9, 0x2c, 0x64,
+	0x53, 0x45, 0xb2, 0x6c, 0x1e, 0xbb, 0x49, 0xed, 0x66, 0x85, 0x70, 0x11, 0x6f, 0x39, 0x0e, 0x25,
+	0x43, 0x52, 0x7b, 0x98, 0x72, 0x29, 0x72, 0x07, 0xab, 0x62, 0x4b, 0x1a, 0x49, 0x86, 0x61, 0x4e,
+	0x73, 0x9b, 0xfb, 0x9c, 0xe6, 0x32, 0x97, 0xf9, 0x6b, 0xe6, 0x38, 0x7f, 0x01, 0x35, 0xc5, 0xfc,
+	0x15, 0x73, 0x9b, 0x52, 0x77, 0x4b, 0xd6, 0x0b, 0x93, 0x5c, 0x5c, 0xea, 0xef, 0xf1, 0xfb, 0x5e,
+	0xdd, 0xfd, 0x7d, 0x6d, 0xa8, 0xeb, 0x43, 0x83, 0x98, 0xde, 0x23, 0x6d, 0xec, 0x0d, 0xe8, 0xcf,
+	0x9e, 0xed, 0x58, 0x9e, 0x85, 0x0a, 0xfe, 0xb7, 0x54, 0x3b, 0xb3, 0xce, 0x2c, 0x4a, 0x78, 0xe4,
+	0x7f, 0x31, 0x1e, 0x7e, 0x00, 0x55, 0x59, 0xf7, 0x8c, 0x73, 0xcd, 0x23, 0x2a, 0xf9, 0x7a, 0x4c,
+	0x5c, 0x0f, 0xd5, 0xa1, 0xa8, 0xf5, 0x47, 0x86, 0xe9, 0x36, 0x72, 0x5b, 0xf9, 0xdd, 0x39, 0x95,
+	0xaf, 0x30, 0x02, 0x71, 0x22, 0xea, 0xda, 0x96, 0xe9, 0x12, 0xbc, 0x0c, 0x4b, 0x87, 0x44, 0x8b,
+	0x03, 0xe0, 0x1a, 0xa0, 0x28, 0x91, 0x8b, 0x22, 0x10, 0x8f, 0x88, 0x27, 0x53, 0xac, 0x40, 0xf2,
+	0x21, 0x2c, 0x45, 0x68, 0x4c, 0x30, 0x62, 0x5f, 0x88, 0xd9, 0x7f, 0x05, 0xcb, 0x6f, 0xac, 0xbe,
+	0xf1, 0xf1, 0x32, 0x86, 0x81, 0x44, 0xc8, 0x6b, 0xfd, 0x3e, 0x97, 0xf5, 0x3f, 0x7d, 0x00, 0x87,
+	0x8c, 0xac, 0x73, 0x12, 0x04, 0xc0, 0x56, 0xb8, 0x0e, 0xb5, 0x38, 0x00, 0xf7, 0xec, 0x07, 0x01,
+	0x0a, 0xa7, 0x2e, 0x71, 0x90, 0x04, 0xe5, 0xb1, 0x4b, 0x1c, 0x53, 0x1b, 0x91, 0x86, 0xb0, 0x25,
+	0xec, 0xce, 0xa9, 0xe1, 0x1a, 0xed, 0x40, 0xc1, 0xbb, 0xb4, 0x7d, 0x48, 0x61, 0x77, 0x71, 0x7f,
+	0x79, 0x8f, 0xe6, 0xd7, 0xd7, 0xa2, 0x3f, 0x27, 0x97, 0x36, 0x51, 0xa9, 0x00, 0x6e, 0x42, 0x39,
+	0xa0, 0xa0, 0x0a, 0x94, 0x5a, 0x9d, 0x77, 0x72, 0xbb, 0x75, 0x28, 0xce, 0xa0, 0x39, 0x98, 0x7d,
+	0x7d, 0xfa, 0x46, 0xee, 0x88, 0x02, 0x9a, 0x87, 0xf2, 0x71, 0xeb, 0xb8, 0xd9, 0x6e, 0x75, 0x9a,
+	0x62, 0x0e, 0x2d, 0x43, 0xb5, 0xdb, 0x54, 0xdf, 0xb5, 0x94, 0x66, 0x4f, 0x56, 0x94, 0xb7, 0xa7,
+	0x9d, 0x13, 0x31, 0x8f, 0x35, 0x58, 0x96, 0xc7, 0xde, 0x80, 0x98, 0x9e, 0xa1, 0x47, 0x8a, 0xb3,
+	0x0d, 0xf3, 0x67, 0x86, 0x37, 0x18, 0x7f, 0xe8, 0x79, 0xd6, 0x27, 0x62, 0x72, 0x37, 0x2b, 0x8c,
+	0x76, 0xe2, 0x93, 0xd0, 0x0e, 0x54, 0xb9, 0x48, 0x18, 0x4c, 0x8e, 0x4a, 0x2d, 0x32, 0xf2, 0x29,
+	0xa7, 0xe2, 0xa7, 0x50, 0x8b, 0x9b, 0xe0, 0x05, 0x58, 0x07, 0xb0, 0x35, 0x7d, 0x10, 0xb3, 0x30,
+	0xe7, 0x53, 0x28, 0x3e, 0xae, 0xc2, 0xc2, 0xfb, 0x81, 0x25, 0x8f, 0x5a, 0x41, 0x15, 0x8f, 0x60,
+	0x31, 0x20, 0x70, 0x84, 0x69, 0x89, 0x5c, 0x85, 0xb2, 0xe1, 0xf6, 0x68, 0x4d, 0xa9, 0x5f, 0x65,
+	0xb5, 0x64, 0xb8, 0xb4, 0x22, 0xb8, 0x05, 0x65, 0x59, 0x69, 0x37, 0x4d, 0xcf, 0xb9, 0x9c, 0x0a,
+	0xb1, 0x0d, 0xb3, 0xae, 0x6e, 0x85, 0xc5, 0xa8, 0xb0, 0x62, 0x74, 0x7d, 0x92, 0xca, 0x38, 0xf8,
+	0x3b, 0x01, 0xf2, 0xb2, 0xd2, 0x46, 0x8f, 0xa1, 0x44, 0x4c, 0xcf, 0x31, 0x08, 0xdb, 0x4d, 0x95,
+	0xfd, 0x3a, 0x13, 0x96, 0x95, 0xf6, 0x5e, 0x93, 0x31, 0xa8, 0x3d, 0x35, 0x10, 0x93, 0x8e, 0x60,
+	0x3e, 0xca, 0xf0, 0xf7, 0xd7, 0x27, 0x72, 0xc9, 0x7d, 0xf0, 0x3f, 0x7d, 0xf3, 0xe7, 0xda, 0x70,
+	0x9c, 0x6d, 0x9e, 0x72, 0x5e, 0xe4, 0xfe, 0x29, 0xe0, 0x16, 0x88, 0x7e, 0x7a, 0x2d, 0xc7, 0xf8,
+	0x36, 0x2c, 0x1f, 0x82, 0x82, 0x43, 0x6c, 0x8b, 0xa3, 0xd1, 0xef, 0xcf, 0x89, 0xe6, 0x67, 0x01,
+	0x96, 0x22, 0x58, 0x3c, 0xcb, 0x1b, 0x00, 0x5a, 0x40, 0xec, 0x53, 0xc8, 0xb2, 0x1a, 0xa1, 0x7c,
+	0x06, 0x30, 0xda, 0x87, 0x79, 0x4d, 0x1f, 0xf6, 0x1c, 0x72, 0x6e, 0xb8, 0x86, 0x65, 0x36, 0xf2,
+	0x5b, 0xc2, 0x6e, 0xfe, 0xa0, 0x7a, 0x7d, 0xb5, 0x59, 0x91, 0x95, 0xb6, 0xca, 0xc9, 0x6a, 0x45,
+	0xd3, 0x87, 0xc1, 0xc2, 0x3f, 0x5e, 0xba, 0xa6, 0x0f, 0x48, 0xbf, 0x51, 0xa0, 0x26, 0xf9, 0x0a,
+	0x2b, 0x50, 0x3d, 0x22, 0x1e, 0x83, 0xe7, 0xe1, 0x4e, 0x2b, 0x62, 0x0d, 0x66, 0xfd, 0xf0, 0x83,
+	0x5b, 0x86, 0x2d, 0xf0, 0x73, 0x10, 0x27, 0x20, 0x3c, 0xce, 0x7b, 0x50, 0xa4, 0xde, 0xb2, 0x12,
+	0x26, 0x02, 0xe1, 0x2c, 0xdc, 0x87, 0x6a, 0xf7, 0x0b, 0xac, 0x07, 0x85, 0xc8, 0x65, 0x15, 0x22,
+	0x7f, 0x63, 0x21, 0x10, 0x88, 0xdd, 0x84, 0x7b, 0xf8, 0x1e, 0x2c, 0xf8, 0x97, 0x98, 0xd2, 0x0e,
+	0xec, 0x66, 0x14, 0x19, 0x3f, 0x83, 0xc5, 0x40, 0x88, 0x47, 0xf5, 0x17, 0xc8, 0x6b, 0xfa, 0x90,
+	0x0a, 0x55, 0xf6, 0xe7, 0xc2, 0x5d, 0x79, 0x50, 0xba, 0xbe, 0xda, 0xf4, 0xb7, 0xae, 0xea, 0xb3,
+	0x71, 0x17, 0x16, 0xba, 0xb7, 0x81, 0xa3, 0x3d, 0x28, 0x99, 0xe4, 0xa2, 0xe7, 0xc3, 0xe5, 0x92,
+	0x70, 0x70, 0x7d, 0xb5, 0x59, 0xec, 0x90, 0x0b, 0x1f, 0xa2, 0x68, 0x92, 0x0b, 0x59, 0x1f, 0x62,
+	0x11, 0x16, 0xbb, 0x31, 0x67, 0xb0, 0x02, 0x25, 0x95, 0xd8, 0x96, 0x7f, 0x62, 0xb2, 0x0c, 0x70,
+	0x5f, 0x73, 0xd3, 0x7d, 0x7d, 0x00, 0x4b, 0xcd, 0x6f, 0x6c, 0xcb, 0xf1, 0x91, 0xc3, 0xeb, 0x39,
+	0x2c, 0xb3, 0x10, 0x2d, 0xb3, 0x0c, 0x28, 0x2a, 0xca, 0x53, 0xf2, 0x10, 0x0a, 0x9a, 0x3e, 0x0c,
+	0x4e, 0xea, 0x02, 0xb3, 0xc3, 0xfd, 0x3a, 0x28, 0x5f, 0x5f, 0x6d, 0x16, 0xa8, 0x38, 0x15, 0xc2,
+	0xff, 0x85, 0xa5, 0xd6, 0x28, 0x69, 0xed, 0x8b, 0x10, 0x6a, 0x80, 0x5a, 0xa3, 0xa4, 0x13, 0xd8,
+	0x86, 0x15, 0xd9, 0xb6, 0x87, 0x97, 0xb2, 0xd2, 0x3e, 0x21, 0x23, 0x7b, 0x18, 0xb9, 0x7c, 0xef,
+	0x43, 0xd9, 0xe3, 0xa4, 0x54, 0xdd, 0xd4, 0x90, 0x95, 0xbd, 0xb3, 0x51, 0x03, 0x4a, 0x0e, 0xb1,
+	0x87, 0x9a, 0xce, 0xf6, 0x57, 0x59, 0x0d, 0x96, 0xf8, 0x31, 0x34, 0xd2, 0x16, 0x79, 0x4a, 0xb2,
+	0xd3, 0xf7, 0x02, 0xaa, 0x87, 0xc6, 0xc7, 0x8f, 0xd1, 0xc8, 0x77, 0xa0, 0xd4, 0x27, 0xae, 0xe1,
+	0x90, 0x7e, 0x66, 0xf0, 0x6a, 0xc0, 0xc5, 0xdf, 0x0b, 0x30, 0x27, 0x2b, 0x6d, 0x65, 0xa0, 0x99,
+	0x67, 0x24, 0xb3, 0xda, 0xd1, 0x73, 0x93, 0x4b, 0x9c, 0x9b, 0x7b, 0x50, 0xd4, 0x74, 0x6f, 0xac,
+	0x0d, 0xb3, 0x0e, 0x09, 0x67, 0xa1, 0xfb, 0x13, 0x5f, 0x0a, 0x69, 0xa9, 0xd0, 0x93, 0x7f, 0x83,
+	0x38, 0x89, 0x82, 0xc7, 0xfb, 0x00, 0x4a, 0x3a, 0xf5, 0x2c, 0xa8, 0x61, 0x35, 0xcc, 0x30, 0xf3,
+	0x58, 0x0d, 0xf8, 0xf8, 0x27, 0xc1, 0xdf, 0xc6, 0xce, 0xb9, 0xa1, 0x13, 0x59, 0xd7, 0xad, 0xb1,
+	0x49, 0x0f, 0x47, 0xe4, 0xb4, 0x17, 0x82, 0x7b, 0xc6, 0xba, 0x30, 0x89, 0xc3, 0x43, 0x61, 0x0b,
+	0xb4, 0x09, 0x15, 0xc3, 0xb4, 0xc7, 0x5e, 0x8f, 0x65, 0x37, 0x4f, 0xb3, 0x0b, 0x94, 0xa4, 0xd2,
+	0x72, 0x6d, 0xc3, 0xbc, 0x35, 0xf6, 0x26, 0x12, 0x05, 0x2a, 0x51, 0x61, 0x34, 0x26, 0xb2, 0x0e,
+	0x40, 0x5b, 0x64, 0x6f, 0xa0, 0xb9, 0x83, 0xc6, 0x2c, 0xeb, 0x93, 0x94, 0xf2, 0x5a, 0x73, 0x07,
+	0x78, 0x0c, 0x6b, 0x8a, 0x43, 0x34, 0x8f, 0xc4, 0x9d, 0x8c, 0x1c, 0xe4, 0x94, 0xaf, 0x09, 0xaf,
+	0x72, 0xb7, 0x7a, 0x95, 0x4f, 0x79, 0x85, 0x9f, 0xc0, 0xdd, 0x6c, 0xb3, 0x93, 0x1d, 0x15, 0x6d,
+	0xec, 0x6c, 0x81, 0x9f, 0xc1, 0x96, 0x6a, 0x79, 0x29, 0x2d, 0xda, 0xf1, 0xa7, 0x78, 0x8c, 0xff,
+	0x05, 0xdb, 0x53, 0xf4, 0xa6, 0x9a, 0x5c, 0x83, 0x55, 0xff, 0xaa, 0x8f, 0xe9, 0x85, 0x93, 0xe1,
+	0x57, 0x20, 0x65, 0x31, 0x39, 0xe0, 0x2b, 0x10, 0x5d, 0xc6, 0xea, 0x69, 0x9c, 0xc7, 0xb7, 0x4b,
+	0x8d, 0xef, 0xb4, 0x78, 0xec, 0x55, 0x37, 0x0e, 0x84, 0xff, 0x0e, 0x6b, 0x87, 0x64, 0x48, 0xbe,
+	0xa0, 0x36, 0x78, 0x03, 0xee, 0x66, 0xab, 0xf0, 0x7b, 0xe3, 0x15, 0xd4, 0x8e, 0x88, 0xa7, 0x68,
+	0xb6, 0xf6, 0xc1, 0x18, 0x1a, 0xde, 0xe5, 0xe4, 0x60, 0x56, 0x13, 0xbe, 0x72, 0xd8, 0xc5, 0xb8,
+	0x53, 0xf8, 0x39, 0xdc, 0x49, 0x00, 0x4c, 0xfa, 0xbc, 0x1e, 0x52, 0xb9, 0x72, 0x84, 0x82, 0xf7,
+	0xa0, 0xae, 0x92, 0x73, 0xeb, 0x13, 0xf1, 0x47, 0x84, 0x58, 0xc5, 0xb2, 0x13, 0xbf, 0x0a, 0x2b,
+	0x29, 0x79, 0x66, 0xea, 0x6f, 0x4f, 0x60, 0x96, 0x1e, 0x52, 0x54, 0x86, 0x42, 0xe7, 0x6d, 0xa7,
+	0x29, 0xce, 0x20, 0x80, 0xa2, 0xda, 0x94, 0x0f, 0x9b, 0xaa, 0x28, 0xf8, 0xdf, 0xef, 0xd5, 0xd6,
+	0x49, 0x53, 0x15, 0x73, 0xfe, 0x38, 0xfb, 0xf6, 0x7d, 0xa7, 0xa9, 0x8a, 0xf9, 0xfd, 0x3f, 0x2a,
+	0x90, 0x97, 0x8f, 0x5b, 0xe8, 0x25, 0x94, 0x83, 0x17, 0x02, 0xba, 0xc3, 0xcf, 0x6d, 0xfc, 0x6d,
+	0x20, 0xd5, 0x93, 0x64, 0x9e, 0xbd, 0x19, 0x24, 0x03, 0x4c, 0x5e, 0x0d, 0x68, 0x85, 0xc9, 0xa5,
+	0x1e, 0x17, 0x52, 0x23, 0xcd, 0x08, 0x21, 0xfe, 0x03, 0x73, 0xe1, 0x73, 0x02, 0x71, 0x4b, 0xc9,
+	0x37, 0x87, 0xb4, 0x92, 0xa2, 0x87, 0xfa, 0x47, 0x30, 0x1f, 0x7d, 0x20, 0xa0, 0x55, 0x26, 0x9a,
+	0xf1, 0xea, 0x90, 0xa4, 0x2c, 0x56, 0x14, 0x28, 0x3a, 0x59, 0x07, 0x40, 0x19, 0x03, 0xbd, 0x24,
+	0x65, 0xb1, 0xa2, 0x11, 0x85, 0x73, 0x5f, 0x10, 0x51, 0x72, 0xa8, 0x94, 0x56, 0x52, 0xf4, 0x50,
+	0xff, 0x29, 0x14, 0xd9, 0x68, 0x8e, 0xf8, 0x8b, 0x25, 0x36, 0xb9, 0x4b, 0xb5, 0x38, 0x31, 0x54,
+	0x7b, 0x09, 0xe5, 0x60, 0x0a, 0x0b, 0x0a, 0x99, 0x18, 0xed, 0xa4, 0x7a, 0x92, 0x1c, 0x55, 0xee,
+	0x26, 0x94, 0xbb, 0xd9, 0xca, 0xdd, 0xb4, 0xf2, 0x53, 0x28, 0xb2, 0x39, 0x29, 0x70, 0x38, 0x36,
+	0x5a, 0x49, 0xb5, 0x38, 0x31, 0xaa, 0xd6, 0x8d, 0xa9, 0x75, 0xb3, 0xd4, 0xba, 0x49, 0x35, 0x19,
+	0x60, 0x32, 0x86, 0x04, 0x7b, 0x2e, 0x35, 0xc3, 0x48, 0x8d, 0x34, 0x23, 0x0a, 0xd1, 0x1a, 0x25,
+	0x21, 0x5a, 0xa3, 0x1b, 0x20, 0x32, 0xe6, 0x8d, 0x19, 0xd4, 0x05, 0x31, 0xd9, 0xff, 0xd1, 0x3a,
+	0xaf, 0x69, 0xf6, 0x24, 0x22, 0x6d, 0xdc, 0xc4, 0x8e, 0x56, 0x21, 0x68, 0xae, 0x41, 0x15, 0x12,
+	0x23, 0x83, 0x54, 0x4f, 0x92, 0x43, 0xe5, 0x1e, 0xd4, 0xb2, 0x7a, 0x08, 0xda, 0x66, 0x1a, 0x53,
+	0xda, 0x9a, 0x84, 0xa7, 0x89, 0x84, 0x06, 0x4c, 0x58, 0xbd, 0xb1, 0x6d, 0xa0, 0xbf, 0x32, 0x88,
+	0xdb, 0xfa, 0x91, 0xb4, 0x73, 0xab, 0x5c, 0x68, 0xef, 0xff, 0x80, 0xd2, 0xed, 0x04, 0x6d, 0x4e,
+	0xf6, 0x70, 0x66, 0x17, 0x92, 0xb6, 0x6e, 0x16, 0x88, 0xe6, 0x2a, 0xab, 0x2f, 0x04, 0xb9, 0x9a,
+	0xd2, 0x66, 0x24, 0x3c, 0x4d, 0x24, 0x34, 0xf0, 0x3f, 0x58, 0x88, 0xf5, 0x05, 0x24, 0x85, 0x5e,
+	0xa5, 0xba, 0x8d, 0xb4, 0x96, 0xc9, 0x0b, 0xb1, 0x8e, 0xa1, 0x9a, 0xb8, 0xfa, 0xd1, 0x5d, 0x9e,
+	0xc5, 0xcc, 0x0e, 0x22, 0xad, 0xdf, 0xc0, 0x0d, 0x10, 0x0f, 0xc4, 0x5f, 0xae, 0x37, 0x84, 0x5f,
+	0xaf, 0x37, 0x84, 0xdf, 0xae, 0x37, 0x84, 0x1f, 0x7f, 0xdf, 0x98, 0xf9, 0x50, 0xa4, 0xff, 0x2c,
+	0xfd, 0xe3, 0xcf, 0x01, 0x00, 0xd5, 0x3c, 0xae, 0x23, 0x8f, 0x12, 0x00, 0x00,
 }
@@ -3,12 +3,16 @@ package client
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"path/filepath"
+	"time"
 
 	"github.com/gogo/protobuf/types"
 	"github.com/pachyderm/pachyderm/src/client/pfs"
 	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+	"google.golang.org/grpc/metadata"
 )
 
 // NewRepo creates a pfs.Repo.
@@ -53,6 +57,21 @@ func (c APIClient) CreateRepo(repoName string) error {
 	return grpcutil.ScrubGRPC(err)
 }
 
+// WithPriority returns a new APIClient whose requests are tagged with
+// priority, so that e.g. a bulk download can be run as batch work which
+// yields to interactive requests. Only cluster admins can raise the priority
+// of an operation that's batch work by default.
+func (c *APIClient) WithPriority(priority pfs.Priority) *APIClient {
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	md[pfs.PriorityKey] = []string{priority.String()}
+	return c.WithCtx(metadata.NewOutgoingContext(ctx, md))
+}
+
 // InspectRepo returns info about a specific Repo.
 func (c APIClient) InspectRepo(repoName string) (*pfs.RepoInfo, error) {
 	resp, err := c.PfsAPIClient.InspectRepo(
@@ -67,6 +86,23 @@ func (c APIClient) InspectRepo(repoName string) (*pfs.RepoInfo, error) {
 	return resp, nil
 }
 
+// InspectRepoSubvenance is like InspectRepo, but also returns the repos
+// downstream of the repo, that is the ones that have it in their provenance,
+// in RepoInfo.Subvenance.
+func (c APIClient) InspectRepoSubvenance(repoName string) (*pfs.RepoInfo, error) {
+	resp, err := c.PfsAPIClient.InspectRepo(
+		c.Ctx(),
+		&pfs.InspectRepoRequest{
+			Repo:       NewRepo(repoName),
+			Subvenance: true,
+		},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return resp, nil
+}
+
 // ListRepo returns info about all Repos.
 // provenance specifies a set of provenance repos, only repos which have ALL of
 // the specified repos as provenance will be returned unless provenance is nil
@@ -86,6 +122,67 @@ func (c APIClient) ListRepo(provenance []string) ([]*pfs.RepoInfo, error) {
 	return repoInfos.RepoInfo, nil
 }
 
+// ListRepoByLabels is like ListRepo, but only returns info about the repos
+// whose labels match labelSelector, e.g. "team=genomics,tier!=bronze", see
+// pfs.ListRepoRequest.LabelSelector.
+func (c APIClient) ListRepoByLabels(labelSelector string, provenance []string) ([]*pfs.RepoInfo, error) {
+	request := &pfs.ListRepoRequest{LabelSelector: labelSelector}
+	for _, repoName := range provenance {
+		request.Provenance = append(request.Provenance, NewRepo(repoName))
+	}
+	repoInfos, err := c.PfsAPIClient.ListRepo(
+		c.Ctx(),
+		request,
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return repoInfos.RepoInfo, nil
+}
+
+// ListRepoPage returns at most limit repos (all of them if it's 0), sorted by
+// sortBy, starting after the last repo of the page whose token is pageToken
+// (from the start if it's ""). It also returns the token of the next page,
+// which is "" once every repo has been listed.
+func (c APIClient) ListRepoPage(sortBy pfs.RepoSort, reverse bool, limit int64, pageToken string) ([]*pfs.RepoInfo, string, error) {
+	response, err := c.PfsAPIClient.ListRepo(
+		c.Ctx(),
+		&pfs.ListRepoRequest{
+			SortBy:    sortBy,
+			Reverse:   reverse,
+			Limit:     limit,
+			PageToken: pageToken,
+		},
+	)
+	if err != nil {
+		return nil, "", grpcutil.ScrubGRPC(err)
+	}
+	return response.RepoInfo, response.NextPageToken, nil
+}
+
+// ArchiveRepo retires a repo without deleting its data: it can still be
+// read, but it can't be written to, and it's hidden from ListRepo.
+func (c APIClient) ArchiveRepo(repoName string) error {
+	_, err := c.PfsAPIClient.ArchiveRepo(
+		c.Ctx(),
+		&pfs.ArchiveRepoRequest{
+			Repo: NewRepo(repoName),
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// UnarchiveRepo makes an archived repo writable and listed again.
+func (c APIClient) UnarchiveRepo(repoName string) error {
+	_, err := c.PfsAPIClient.UnarchiveRepo(
+		c.Ctx(),
+		&pfs.UnarchiveRepoRequest{
+			Repo: NewRepo(repoName),
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
 // DeleteRepo deletes a repo and reclaims the storage space it was using. Note
 // that as of 1.0 we do not reclaim the blocks that the Repo was referencing,
 // this is because they may also be referenced by other Repos and deleting them
@@ -104,6 +201,178 @@ func (c APIClient) DeleteRepo(repoName string, force bool) error {
 	return err
 }
 
+// CreateTempRepo creates a temporary repo, which is deleted along with its
+// data once ttlSeconds pass without it being renewed by RenewRepo.
+func (c APIClient) CreateTempRepo(repoName string, ttlSeconds int64) error {
+	_, err := c.PfsAPIClient.CreateRepo(
+		c.Ctx(),
+		&pfs.CreateRepoRequest{
+			Repo:       NewRepo(repoName),
+			TtlSeconds: ttlSeconds,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// CreateProxyRepo creates a repo that's a read-through proxy of remoteRepo on
+// the cluster whose pachd is at address. Commits and data of remoteRepo are
+// copied into the proxy as they're read through it.
+func (c APIClient) CreateProxyRepo(repoName string, address string, remoteRepo string) error {
+	_, err := c.PfsAPIClient.CreateRepo(
+		c.Ctx(),
+		&pfs.CreateRepoRequest{
+			Repo: NewRepo(repoName),
+			Remote: &pfs.RemoteRepo{
+				Address: address,
+				Repo:    remoteRepo,
+			},
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// CreateCompressedRepo creates a repo whose data is compressed with
+// compression at rest. Reads of the repo's files return them decompressed.
+func (c APIClient) CreateCompressedRepo(repoName string, compression pfs.Compression) error {
+	_, err := c.PfsAPIClient.CreateRepo(
+		c.Ctx(),
+		&pfs.CreateRepoRequest{
+			Repo:        NewRepo(repoName),
+			Compression: compression,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// RenewRepo extends the lease of a temporary repo to ttlSeconds from now, or
+// to the ttl it was created with if ttlSeconds is 0.
+func (c APIClient) RenewRepo(repoName string, ttlSeconds int64) error {
+	_, err := c.PfsAPIClient.RenewRepo(
+		c.Ctx(),
+		&pfs.RenewRepoRequest{
+			Repo:       NewRepo(repoName),
+			TtlSeconds: ttlSeconds,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// ExportProvenanceGraph returns the provenance graph of repos and the repos
+// upstream and downstream of them, or of every repo if repoNames is empty.
+// If commits is set, the repos' commits are in the graph too.
+func (c APIClient) ExportProvenanceGraph(repoNames []string, commits bool) (*pfs.ProvenanceGraph, error) {
+	request := &pfs.ExportProvenanceGraphRequest{Commits: commits}
+	for _, repoName := range repoNames {
+		request.Repos = append(request.Repos, NewRepo(repoName))
+	}
+	graph, err := c.PfsAPIClient.ExportProvenanceGraph(c.Ctx(), request)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return graph, nil
+}
+
+// ExportRepo writes a bundle of repoName to writer: its finished commits and
+// branches, and the objects they reference. ImportRepo can create the repo
+// from it, in this cluster or another one.
+func (c APIClient) ExportRepo(repoName string, writer io.Writer) error {
+	if c.streamSemaphore != nil {
+		c.streamSemaphore <- struct{}{}
+		defer func() { <-c.streamSemaphore }()
+	}
+	exportRepoClient, err := c.PfsAPIClient.ExportRepo(
+		c.Ctx(),
+		&pfs.ExportRepoRequest{
+			Repo: NewRepo(repoName),
+		},
+	)
+	if err != nil {
+		return grpcutil.ScrubGRPC(err)
+	}
+	if err := grpcutil.WriteFromStreamingBytesClient(exportRepoClient, writer); err != nil {
+		return grpcutil.ScrubGRPC(err)
+	}
+	return nil
+}
+
+// ImportRepo creates the repo repoName from the bundle read from reader,
+// which was written by ExportRepo. If repoName is empty, the repo is named
+// after the bundle's repo.
+func (c APIClient) ImportRepo(repoName string, reader io.Reader) error {
+	// the stream is cancelled, rather than closed, if reader fails, so that
+	// a partial bundle isn't imported
+	ctx, cancel := context.WithCancel(c.Ctx())
+	defer cancel()
+	importRepoClient, err := c.PfsAPIClient.ImportRepo(ctx)
+	if err != nil {
+		return grpcutil.ScrubGRPC(err)
+	}
+	request := &pfs.ImportRepoRequest{}
+	if repoName != "" {
+		request.Repo = NewRepo(repoName)
+	}
+	// Buffer the bundle so that we don't exceed the grpc MaxMsgSize
+	buf := make([]byte, grpcutil.MaxMsgSize/2)
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			request.Value = buf[:n]
+			if err := importRepoClient.Send(request); err != nil {
+				return grpcutil.ScrubGRPC(err)
+			}
+			request = &pfs.ImportRepoRequest{}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := importRepoClient.CloseAndRecv(); err != nil {
+		return grpcutil.ScrubGRPC(err)
+	}
+	return nil
+}
+
+// CreateSessionRepo creates a temporary repo for scratch data that lasts as
+// long as the caller does. Its lease is renewed in the background until the
+// returned function is called, which deletes the repo. If the caller goes
+// away without calling it, the lease isn't renewed and the repo is deleted
+// within ttlSeconds.
+func (c APIClient) CreateSessionRepo(repoName string, ttlSeconds int64) (func() error, error) {
+	if ttlSeconds <= 0 {
+		return nil, fmt.Errorf("the ttl of a session repo must be positive")
+	}
+	if err := c.CreateTempRepo(repoName, ttlSeconds); err != nil {
+		return nil, err
+	}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		// renew well before the lease expires, so that a failed renewal
+		// can be retried
+		ticker := time.NewTicker(time.Duration(ttlSeconds) * time.Second / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-c.Ctx().Done():
+				return
+			case <-ticker.C:
+				c.RenewRepo(repoName, ttlSeconds)
+			}
+		}
+	}()
+	return func() error {
+		close(done)
+		<-stopped
+		return c.DeleteRepo(repoName, false)
+	}, nil
+}
+
 // StartCommit begins the process of committing data to a Repo. Once started
 // you can write to the Commit with PutFile and when all the data has been
 // written you must finish the Commit with FinishCommit. NOTE, data is not
@@ -140,7 +409,7 @@ func (c APIClient) BuildCommit(repoName string, branch string, parent string, tr
 		&pfs.BuildCommitRequest{
 			Parent: NewCommit(repoName, parent),
 			Branch: branch,
-			Tree:   &pfs.Object{treeObject},
+			Tree:   &pfs.Object{Hash: treeObject},
 		},
 	)
 	if err != nil {
@@ -182,6 +451,25 @@ func (c APIClient) StartCommitParent(repoName string, branch string, parentCommi
 	return commit, nil
 }
 
+// StartCommitFromBase is like StartCommitParent, but the new commit starts
+// with the content of baseCommit, a finished commit in the same repo, rather
+// than its parent's. Nothing is copied, so this is a cheap way to branch off
+// an old commit and change a few files.
+func (c APIClient) StartCommitFromBase(repoName string, branch string, parentCommit string, baseCommit string) (*pfs.Commit, error) {
+	commit, err := c.PfsAPIClient.StartCommit(
+		c.Ctx(),
+		&pfs.StartCommitRequest{
+			Parent: NewCommit(repoName, parentCommit),
+			Branch: branch,
+			Base:   NewCommit(repoName, baseCommit),
+		},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return commit, nil
+}
+
 // FinishCommit ends the process of committing data to a Repo and persists the
 // Commit. Once a Commit is finished the data becomes immutable and future
 // attempts to write to it with PutFile will error.
@@ -195,108 +483,346 @@ func (c APIClient) FinishCommit(repoName string, commitID string) error {
 	return grpcutil.ScrubGRPC(err)
 }
 
-// InspectCommit returns info about a specific Commit.
-func (c APIClient) InspectCommit(repoName string, commitID string) (*pfs.CommitInfo, error) {
-	commitInfo, err := c.PfsAPIClient.InspectCommit(
+// FinishCommitWithDataCard is like FinishCommit but also attaches dataCard to
+// the Commit. The data card is stored in the Commit's metadata, not as a file,
+// and can be retrieved with GetDataCard or found with SearchDataCards.
+func (c APIClient) FinishCommitWithDataCard(repoName string, commitID string, dataCard *pfs.DataCard) error {
+	_, err := c.PfsAPIClient.FinishCommit(
 		c.Ctx(),
-		&pfs.InspectCommitRequest{
+		&pfs.FinishCommitRequest{
+			Commit:   NewCommit(repoName, commitID),
+			DataCard: dataCard,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// StageCommit is like FinishCommitWithDataCard, but the Commit isn't
+// published: the branches that it's the head of are moved back to its parent
+// until PublishCommit is called. dataCard may be nil.
+func (c APIClient) StageCommit(repoName string, commitID string, dataCard *pfs.DataCard) error {
+	_, err := c.PfsAPIClient.FinishCommit(
+		c.Ctx(),
+		&pfs.FinishCommitRequest{
+			Commit:   NewCommit(repoName, commitID),
+			DataCard: dataCard,
+			Stage:    true,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// PublishCommit makes a Commit that was finished with StageCommit the head
+// of the branches it was staged from. It fails if any of those branches have
+// moved since.
+func (c APIClient) PublishCommit(repoName string, commitID string) error {
+	_, err := c.PfsAPIClient.PublishCommit(
+		c.Ctx(),
+		&pfs.PublishCommitRequest{
 			Commit: NewCommit(repoName, commitID),
 		},
 	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// ApproveCommit records the caller's approval of a finished Commit, with an
+// optional comment. Approvals are returned by InspectCommit.
+func (c APIClient) ApproveCommit(repoName string, commitID string, comment string) error {
+	return c.reviewCommit(repoName, commitID, pfs.ReviewDecision_APPROVE, comment)
+}
+
+// RejectCommit records the caller's rejection of a finished Commit, with an
+// optional comment. Rejections are returned by InspectCommit.
+func (c APIClient) RejectCommit(repoName string, commitID string, comment string) error {
+	return c.reviewCommit(repoName, commitID, pfs.ReviewDecision_REJECT, comment)
+}
+
+func (c APIClient) reviewCommit(repoName string, commitID string, decision pfs.ReviewDecision, comment string) error {
+	_, err := c.PfsAPIClient.ReviewCommit(
+		c.Ctx(),
+		&pfs.ReviewCommitRequest{
+			Commit:   NewCommit(repoName, commitID),
+			Decision: decision,
+			Comment:  comment,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// ListPendingApprovals returns info about the staged Commits in a Repo that
+// are waiting to be published, oldest first, including their reviews. If
+// branch isn't "" only the Commits staged from it are returned.
+func (c APIClient) ListPendingApprovals(repoName string, branch string) ([]*pfs.CommitInfo, error) {
+	commitInfos, err := c.PfsAPIClient.ListPendingApprovals(
+		c.Ctx(),
+		&pfs.ListPendingApprovalsRequest{
+			Repo:   NewRepo(repoName),
+			Branch: branch,
+		},
+	)
 	if err != nil {
 		return nil, grpcutil.ScrubGRPC(err)
 	}
-	return commitInfo, nil
+	return commitInfos.CommitInfo, nil
 }
 
-// ListCommit lists commits.
-// If only `repo` is given, all commits in the repo are returned.
-// If `to` is given, only the ancestors of `to`, including `to` itself,
-// are considered.
-// If `from` is given, only the descendents of `from`, including `from`
-// itself, are considered.
-// `number` determines how many commits are returned.  If `number` is 0,
-// all commits that match the aforementioned criteria are returned.
-func (c APIClient) ListCommit(repoName string, to string, from string, number uint64) ([]*pfs.CommitInfo, error) {
-	req := &pfs.ListCommitRequest{
-		Repo:   NewRepo(repoName),
-		Number: number,
+// GetDataCard returns the data card attached to a Commit, or nil if the
+// Commit doesn't have one.
+func (c APIClient) GetDataCard(repoName string, commitID string) (*pfs.DataCard, error) {
+	commitInfo, err := c.InspectCommit(repoName, commitID)
+	if err != nil {
+		return nil, err
 	}
-	if from != "" {
-		req.From = NewCommit(repoName, from)
+	return commitInfo.DataCard, nil
+}
+
+// SearchDataCards returns info about the Commits whose data cards mention
+// query in their description or schema and, if license is non-empty, have
+// that license. If repoName is "" the data cards in all repos are searched.
+func (c APIClient) SearchDataCards(repoName string, query string, license string) ([]*pfs.CommitInfo, error) {
+	req := &pfs.SearchDataCardsRequest{
+		Query:   query,
+		License: license,
 	}
-	if to != "" {
-		req.To = NewCommit(repoName, to)
+	if repoName != "" {
+		req.Repo = NewRepo(repoName)
 	}
-	commitInfos, err := c.PfsAPIClient.ListCommit(
-		c.Ctx(),
-		req,
-	)
+	commitInfos, err := c.PfsAPIClient.SearchDataCards(c.Ctx(), req)
 	if err != nil {
 		return nil, grpcutil.ScrubGRPC(err)
 	}
 	return commitInfos.CommitInfo, nil
 }
 
-// ListCommitByRepo lists all commits in a repo.
-func (c APIClient) ListCommitByRepo(repoName string) ([]*pfs.CommitInfo, error) {
-	return c.ListCommit(repoName, "", "", 0)
+// CreateCommitHook adds a hook to the end of a repo's commit hooks. Each
+// time a commit is finished in the repo, a CommitHookEvent describing it is
+// POSTed to url. An event that isn't acknowledged is POSTed again with the
+// same ID and sequence, so the hook can ignore events it's already processed.
+func (c APIClient) CreateCommitHook(repoName string, name string, url string) error {
+	_, err := c.PfsAPIClient.CreateCommitHook(
+		c.Ctx(),
+		&pfs.CreateCommitHookRequest{
+			Repo: NewRepo(repoName),
+			Name: name,
+			Url:  url,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
 }
 
-// ListBranch lists the active branches on a Repo.
-func (c APIClient) ListBranch(repoName string) ([]*pfs.BranchInfo, error) {
-	branchInfos, err := c.PfsAPIClient.ListBranch(
+// ListCommitHook returns info about a repo's commit hooks, in the order
+// that they're run, including their delivery state on each branch.
+func (c APIClient) ListCommitHook(repoName string) ([]*pfs.CommitHookInfo, error) {
+	commitHookInfos, err := c.PfsAPIClient.ListCommitHook(
 		c.Ctx(),
-		&pfs.ListBranchRequest{
+		&pfs.ListCommitHookRequest{
 			Repo: NewRepo(repoName),
 		},
 	)
 	if err != nil {
 		return nil, grpcutil.ScrubGRPC(err)
 	}
-	return branchInfos.BranchInfo, nil
+	return commitHookInfos.CommitHookInfo, nil
 }
 
-// SetBranch sets a commit and its ancestors as a branch
-func (c APIClient) SetBranch(repoName string, commit string, branch string) error {
-	_, err := c.PfsAPIClient.SetBranch(
+// DeleteCommitHook deletes a commit hook.
+func (c APIClient) DeleteCommitHook(repoName string, name string) error {
+	_, err := c.PfsAPIClient.DeleteCommitHook(
 		c.Ctx(),
-		&pfs.SetBranchRequest{
-			Commit: NewCommit(repoName, commit),
-			Branch: branch,
+		&pfs.DeleteCommitHookRequest{
+			Repo: NewRepo(repoName),
+			Name: name,
 		},
 	)
 	return grpcutil.ScrubGRPC(err)
 }
 
-// DeleteBranch deletes a branch, but leaves the commits themselves intact.
-// In other words, those commits can still be accessed via commit IDs and
-// other branches they happen to be on.
-func (c APIClient) DeleteBranch(repoName string, branch string) error {
-	_, err := c.PfsAPIClient.DeleteBranch(
+// InspectCommit returns info about a specific Commit.
+func (c APIClient) InspectCommit(repoName string, commitID string) (*pfs.CommitInfo, error) {
+	commitInfo, err := c.PfsAPIClient.InspectCommit(
 		c.Ctx(),
-		&pfs.DeleteBranchRequest{
-			Repo:   NewRepo(repoName),
-			Branch: branch,
+		&pfs.InspectCommitRequest{
+			Commit: NewCommit(repoName, commitID),
 		},
 	)
-	return grpcutil.ScrubGRPC(err)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return commitInfo, nil
 }
 
-// DeleteCommit deletes a commit.
-// Note it is currently not implemented.
-func (c APIClient) DeleteCommit(repoName string, commitID string) error {
-	_, err := c.PfsAPIClient.DeleteCommit(
+// InspectCommitSubvenance is like InspectCommit, but also returns the
+// commits downstream of the commit, that is the ones that have it in their
+// provenance, in CommitInfo.Subvenance.
+func (c APIClient) InspectCommitSubvenance(repoName string, commitID string) (*pfs.CommitInfo, error) {
+	commitInfo, err := c.PfsAPIClient.InspectCommit(
 		c.Ctx(),
-		&pfs.DeleteCommitRequest{
-			Commit: NewCommit(repoName, commitID),
+		&pfs.InspectCommitRequest{
+			Commit:     NewCommit(repoName, commitID),
+			Subvenance: true,
 		},
 	)
-	return grpcutil.ScrubGRPC(err)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return commitInfo, nil
 }
 
-// FlushCommit returns an iterator that returns commits that have the
-// specified `commits` as provenance.  Note that the iterator can block if
+// InspectCommitFields is like InspectCommit, but only returns the given
+// fields of the CommitInfo, if any, less omitFields, see
+// pfs.ListCommitRequest.Fields.
+func (c APIClient) InspectCommitFields(repoName string, commitID string, fields []string, omitFields []string) (*pfs.CommitInfo, error) {
+	commitInfo, err := c.PfsAPIClient.InspectCommit(
+		c.Ctx(),
+		&pfs.InspectCommitRequest{
+			Commit:     NewCommit(repoName, commitID),
+			Fields:     fields,
+			OmitFields: omitFields,
+		},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return commitInfo, nil
+}
+
+// ListCommit lists commits.
+// If only `repo` is given, all commits in the repo are returned.
+// If `to` is given, only the ancestors of `to`, including `to` itself,
+// are considered.
+// If `from` is given, only the descendents of `from`, including `from`
+// itself, are considered.
+// `number` determines how many commits are returned.  If `number` is 0,
+// all commits that match the aforementioned criteria are returned.
+func (c APIClient) ListCommit(repoName string, to string, from string, number uint64) ([]*pfs.CommitInfo, error) {
+	req := &pfs.ListCommitRequest{
+		Repo:   NewRepo(repoName),
+		Number: number,
+	}
+	if from != "" {
+		req.From = NewCommit(repoName, from)
+	}
+	if to != "" {
+		req.To = NewCommit(repoName, to)
+	}
+	commitInfos, err := c.PfsAPIClient.ListCommit(
+		c.Ctx(),
+		req,
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return commitInfos.CommitInfo, nil
+}
+
+// ListCommitFields is like ListCommit, but only returns the given fields of
+// each CommitInfo, if any, less omitFields, e.g. omitting "provenance" keeps
+// the listing of a repo that's far downstream small.
+func (c APIClient) ListCommitFields(repoName string, to string, from string, number uint64, fields []string, omitFields []string) ([]*pfs.CommitInfo, error) {
+	req := &pfs.ListCommitRequest{
+		Repo:       NewRepo(repoName),
+		Number:     number,
+		Fields:     fields,
+		OmitFields: omitFields,
+	}
+	if from != "" {
+		req.From = NewCommit(repoName, from)
+	}
+	if to != "" {
+		req.To = NewCommit(repoName, to)
+	}
+	commitInfos, err := c.PfsAPIClient.ListCommit(
+		c.Ctx(),
+		req,
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return commitInfos.CommitInfo, nil
+}
+
+// BisectCommits returns the commit halfway between the commits good and bad
+// of repoName, to find the first bad commit after good. Each commit it
+// returns is tested and passed back as good or bad, until it returns the
+// first bad commit. If bad is empty, it's the head of branch, and if good is
+// empty, every ancestor of bad could be the first bad commit.
+func (c APIClient) BisectCommits(repoName string, branch string, good string, bad string) (*pfs.BisectCommitsResponse, error) {
+	req := &pfs.BisectCommitsRequest{
+		Repo:   NewRepo(repoName),
+		Branch: branch,
+	}
+	if good != "" {
+		req.Good = NewCommit(repoName, good)
+	}
+	if bad != "" {
+		req.Bad = NewCommit(repoName, bad)
+	}
+	response, err := c.PfsAPIClient.BisectCommits(c.Ctx(), req)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return response, nil
+}
+
+// ListCommitByRepo lists all commits in a repo.
+func (c APIClient) ListCommitByRepo(repoName string) ([]*pfs.CommitInfo, error) {
+	return c.ListCommit(repoName, "", "", 0)
+}
+
+// ListBranch lists the active branches on a Repo.
+func (c APIClient) ListBranch(repoName string) ([]*pfs.BranchInfo, error) {
+	branchInfos, err := c.PfsAPIClient.ListBranch(
+		c.Ctx(),
+		&pfs.ListBranchRequest{
+			Repo: NewRepo(repoName),
+		},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return branchInfos.BranchInfo, nil
+}
+
+// SetBranch sets a commit and its ancestors as a branch
+func (c APIClient) SetBranch(repoName string, commit string, branch string) error {
+	_, err := c.PfsAPIClient.SetBranch(
+		c.Ctx(),
+		&pfs.SetBranchRequest{
+			Commit: NewCommit(repoName, commit),
+			Branch: branch,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// DeleteBranch deletes a branch, but leaves the commits themselves intact.
+// In other words, those commits can still be accessed via commit IDs and
+// other branches they happen to be on.
+func (c APIClient) DeleteBranch(repoName string, branch string) error {
+	_, err := c.PfsAPIClient.DeleteBranch(
+		c.Ctx(),
+		&pfs.DeleteBranchRequest{
+			Repo:   NewRepo(repoName),
+			Branch: branch,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// DeleteCommit deletes a commit.
+// Note it is currently not implemented.
+func (c APIClient) DeleteCommit(repoName string, commitID string) error {
+	_, err := c.PfsAPIClient.DeleteCommit(
+		c.Ctx(),
+		&pfs.DeleteCommitRequest{
+			Commit: NewCommit(repoName, commitID),
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// FlushCommit returns an iterator that returns commits that have the
+// specified `commits` as provenance.  Note that the iterator can block if
 // jobs have not successfully completed. This in effect waits for all of the
 // jobs that are triggered by a set of commits to complete.
 //
@@ -352,10 +878,23 @@ func (c *commitInfoIterator) Close() {
 // SubscribeCommit is like ListCommit but it keeps listening for commits as
 // they come in.
 func (c APIClient) SubscribeCommit(repo string, branch string, from string) (CommitInfoIterator, error) {
+	return c.subscribeCommit(repo, branch, from, false)
+}
+
+// SubscribeCommitWithHints is like SubscribeCommit, but each commit has an
+// invalidation hint: the paths under which it differs from the commit before
+// it, so that clients that cache the branch's content can invalidate just
+// those.
+func (c APIClient) SubscribeCommitWithHints(repo string, branch string, from string) (CommitInfoIterator, error) {
+	return c.subscribeCommit(repo, branch, from, true)
+}
+
+func (c APIClient) subscribeCommit(repo string, branch string, from string, invalidationHints bool) (CommitInfoIterator, error) {
 	ctx, cancel := context.WithCancel(c.Ctx())
 	req := &pfs.SubscribeCommitRequest{
-		Repo:   NewRepo(repo),
-		Branch: branch,
+		Repo:              NewRepo(repo),
+		Branch:            branch,
+		InvalidationHints: invalidationHints,
 	}
 	if from != "" {
 		req.From = NewCommit(repo, from)
@@ -369,9 +908,15 @@ func (c APIClient) SubscribeCommit(repo string, branch string, from string) (Com
 }
 
 // PutObject puts a value into the object store and tags it with 0 or more tags.
-func (c APIClient) PutObject(_r io.Reader, tags ...string) (object *pfs.Object, _ int64, retErr error) {
+func (c APIClient) PutObject(r io.Reader, tags ...string) (*pfs.Object, int64, error) {
+	return c.PutObjectWithStorageClass(r, "", tags...)
+}
+
+// PutObjectWithStorageClass is like PutObject, but the value is written in
+// storageClass, see pfs.RepoInfo.StorageClass, unless it's already stored.
+func (c APIClient) PutObjectWithStorageClass(_r io.Reader, storageClass string, tags ...string) (object *pfs.Object, _ int64, retErr error) {
 	r := grpcutil.ReaderWrapper{_r}
-	w, err := c.newPutObjectWriteCloser(tags...)
+	w, err := c.newPutObjectWriteCloser(storageClass, tags...)
 	if err != nil {
 		return nil, 0, grpcutil.ScrubGRPC(err)
 	}
@@ -396,9 +941,15 @@ func (c APIClient) PutObject(_r io.Reader, tags ...string) (object *pfs.Object,
 // PutObjectSplit is the same as PutObject except that the data is splitted
 // into several smaller objects.  This is primarily useful if you'd like to
 // be able to resume upload.
-func (c APIClient) PutObjectSplit(_r io.Reader) (objects []*pfs.Object, _ int64, retErr error) {
+func (c APIClient) PutObjectSplit(r io.Reader) ([]*pfs.Object, int64, error) {
+	return c.PutObjectSplitWithStorageClass(r, "")
+}
+
+// PutObjectSplitWithStorageClass is like PutObjectSplit, but the objects are
+// written in storageClass, see PutObjectWithStorageClass.
+func (c APIClient) PutObjectSplitWithStorageClass(_r io.Reader, storageClass string) (objects []*pfs.Object, _ int64, retErr error) {
 	r := grpcutil.ReaderWrapper{_r}
-	w, err := c.newPutObjectSplitWriteCloser()
+	w, err := c.newPutObjectSplitWriteCloser(storageClass)
 	if err != nil {
 		return nil, 0, grpcutil.ScrubGRPC(err)
 	}
@@ -467,6 +1018,85 @@ func (c APIClient) GetObjects(hashes []string, offset uint64, size uint64, write
 	return nil
 }
 
+// GetFileObjects writes the content of the file made up of objects, starting
+// at offset and limited to size bytes unless it's 0, to writer. Unlike
+// GetObjects it decompresses objects that are compressed.
+func (c APIClient) GetFileObjects(objects []*pfs.Object, offset uint64, size uint64, writer io.Writer) error {
+	if !pfs.Compressed(objects) {
+		var hashes []string
+		for _, object := range objects {
+			hashes = append(hashes, object.Hash)
+		}
+		return c.GetObjects(hashes, offset, size, writer)
+	}
+	w := &offsetWriter{w: writer, skip: offset, limit: size}
+	for _, object := range objects {
+		if err := c.getDecompressedObject(object, w); err != nil {
+			if err == errWriterFull {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (c APIClient) getDecompressedObject(object *pfs.Object, writer io.Writer) (retErr error) {
+	r, w := io.Pipe()
+	go func() {
+		w.CloseWithError(c.GetObject(object.Hash, w))
+	}()
+	defer func() {
+		r.CloseWithError(retErr)
+	}()
+	decompressed, err := pfs.NewDecompressReader(object.Compression, r)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := decompressed.Close(); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	_, err = io.Copy(writer, decompressed)
+	return err
+}
+
+var errWriterFull = errors.New("writer full")
+
+// offsetWriter discards the first skip bytes written to it and then writes at
+// most limit bytes to w, unless limit is 0, after which it returns
+// errWriterFull.
+type offsetWriter struct {
+	w       io.Writer
+	skip    uint64
+	limit   uint64
+	written uint64
+}
+
+func (w *offsetWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	if w.skip >= uint64(len(p)) {
+		w.skip -= uint64(len(p))
+		return n, nil
+	}
+	p = p[w.skip:]
+	w.skip = 0
+	if w.limit != 0 && w.written+uint64(len(p)) >= w.limit {
+		p = p[:w.limit-w.written]
+		if _, err := w.w.Write(p); err != nil {
+			return 0, err
+		}
+		w.written = w.limit
+		return n, errWriterFull
+	}
+	if _, err := w.w.Write(p); err != nil {
+		return 0, err
+	}
+	w.written += uint64(len(p))
+	return n, nil
+}
+
 // ReadObjects gets  several objects by hash and returns them directly as []byte.
 func (c APIClient) ReadObjects(hashes []string, offset uint64, size uint64) ([]byte, error) {
 	var buffer bytes.Buffer
@@ -543,7 +1173,7 @@ func (c APIClient) Compact() error {
 // NOTE: PutFileWriter returns an io.WriteCloser you must call Close on it when
 // you are done writing.
 func (c APIClient) PutFileWriter(repoName string, commitID string, path string) (io.WriteCloser, error) {
-	return c.newPutFileWriteCloser(repoName, commitID, path, pfs.Delimiter_NONE, 0, 0, nil)
+	return c.newPutFileWriteCloser(repoName, commitID, path, pfs.Delimiter_NONE, 0, 0, nil, pfs.PutFileMode_APPEND)
 }
 
 // PutFileSplitWriter writes a multiple files to PFS by splitting up the data
@@ -552,11 +1182,7 @@ func (c APIClient) PutFileWriter(repoName string, commitID string, path string)
 // you are done writing.
 func (c APIClient) PutFileSplitWriter(repoName string, commitID string, path string,
 	delimiter pfs.Delimiter, targetFileDatums int64, targetFileBytes int64, overwrite bool) (io.WriteCloser, error) {
-	var overwriteIndex *pfs.OverwriteIndex
-	if overwrite {
-		overwriteIndex = &pfs.OverwriteIndex{0}
-	}
-	return c.newPutFileWriteCloser(repoName, commitID, path, delimiter, targetFileDatums, targetFileBytes, overwriteIndex)
+	return c.newPutFileWriteCloser(repoName, commitID, path, delimiter, targetFileDatums, targetFileBytes, nil, putFileMode(overwrite))
 }
 
 // PutFile writes a file to PFS from a reader.
@@ -573,10 +1199,64 @@ func (c APIClient) PutFile(repoName string, commitID string, path string, reader
 // object starting from which you'd like to overwrite.  If you want to
 // overwrite the entire file, specify an index of 0.
 func (c APIClient) PutFileOverwrite(repoName string, commitID string, path string, reader io.Reader, overwriteIndex int64) (_ int, retErr error) {
-	writer, err := c.newPutFileWriteCloser(repoName, commitID, path, pfs.Delimiter_NONE, 0, 0, &pfs.OverwriteIndex{overwriteIndex})
+	writer, err := c.newPutFileWriteCloser(repoName, commitID, path, pfs.Delimiter_NONE, 0, 0, &pfs.OverwriteIndex{overwriteIndex}, pfs.PutFileMode_APPEND)
+	if err != nil {
+		return 0, grpcutil.ScrubGRPC(err)
+	}
+	defer func() {
+		if err := writer.Close(); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	written, err := io.Copy(writer, reader)
+	return int(written), err
+}
+
+// PutFileWithMode is like PutFile but mode determines what's done with the
+// file's existing content: APPEND adds to it, OVERWRITE replaces it and
+// CREATE_ONLY fails if the file already exists.
+func (c APIClient) PutFileWithMode(repoName string, commitID string, path string, mode pfs.PutFileMode, reader io.Reader) (_ int, retErr error) {
+	writer, err := c.newPutFileWriteCloser(repoName, commitID, path, pfs.Delimiter_NONE, 0, 0, nil, mode)
+	if err != nil {
+		return 0, grpcutil.ScrubGRPC(err)
+	}
+	defer func() {
+		if err := writer.Close(); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	written, err := io.Copy(writer, reader)
+	return int(written), err
+}
+
+// PutFileWithTTL is like PutFile, but path expires ttlSeconds from now, at
+// which point it's deleted from the branch in a commit that pfs makes.
+// commitID has to be a branch, or the head of one.
+func (c APIClient) PutFileWithTTL(repoName string, commitID string, path string, ttlSeconds int64, reader io.Reader) (_ int, retErr error) {
+	writer, err := c.newPutFileWriteCloser(repoName, commitID, path, pfs.Delimiter_NONE, 0, 0, nil, pfs.PutFileMode_APPEND)
+	if err != nil {
+		return 0, grpcutil.ScrubGRPC(err)
+	}
+	writer.request.TtlSeconds = ttlSeconds
+	defer func() {
+		if err := writer.Close(); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	written, err := io.Copy(writer, reader)
+	return int(written), err
+}
+
+// PutFileWithCommitLock is like PutFile, but it fails with ErrCommitLocked,
+// without writing anything, if path is locked by a commit lock other than
+// lockID, which is "" if the caller holds no lock. See AcquireCommitLock.
+func (c APIClient) PutFileWithCommitLock(repoName string, commitID string, path string, lockID string, reader io.Reader) (_ int, retErr error) {
+	writer, err := c.newPutFileWriteCloser(repoName, commitID, path, pfs.Delimiter_NONE, 0, 0, nil, pfs.PutFileMode_APPEND)
 	if err != nil {
 		return 0, grpcutil.ScrubGRPC(err)
 	}
+	writer.request.CheckCommitLocks = true
+	writer.request.CommitLockID = lockID
 	defer func() {
 		if err := writer.Close(); err != nil && retErr == nil {
 			retErr = err
@@ -586,6 +1266,44 @@ func (c APIClient) PutFileOverwrite(repoName string, commitID string, path strin
 	return int(written), err
 }
 
+// PutFileByHash is like PutFileWithMode, but it first sends the hashes of
+// the content in reader, and only sends the content itself if the commit, or
+// its parent, doesn't already have it, so putting data that's mostly
+// unchanged is fast. It returns whether the content was sent.
+func (c APIClient) PutFileByHash(repoName string, commitID string, path string, mode pfs.PutFileMode, reader io.ReadSeeker) (bool, error) {
+	// the content is hashed as PutFile stores it, in chunks that are
+	// compressed if the repo's data is
+	repoInfo, err := c.InspectRepo(repoName)
+	if err != nil {
+		return false, err
+	}
+	hashes, size, err := pfs.ChunkHashes(repoInfo.Compression, reader)
+	if err != nil {
+		return false, err
+	}
+	response, err := c.PfsAPIClient.PutFileByHash(
+		c.Ctx(),
+		&pfs.PutFileByHashRequest{
+			File:         NewFile(repoName, commitID, path),
+			ObjectHashes: hashes,
+			Mode:         mode,
+			Compression:  repoInfo.Compression,
+			SizeBytes:    size,
+		},
+	)
+	if err != nil {
+		return false, grpcutil.ScrubGRPC(err)
+	}
+	if response.Written {
+		return false, nil
+	}
+	if _, err := reader.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+	_, err = c.PutFileWithMode(repoName, commitID, path, mode, reader)
+	return true, err
+}
+
 //PutFileSplit writes a file to PFS from a reader
 // delimiter is used to tell PFS how to break the input into blocks
 func (c APIClient) PutFileSplit(repoName string, commitID string, path string, delimiter pfs.Delimiter, targetFileDatums int64, targetFileBytes int64, overwrite bool, reader io.Reader) (_ int, retErr error) {
@@ -602,6 +1320,57 @@ func (c APIClient) PutFileSplit(repoName string, commitID string, path string, d
 	return int(written), err
 }
 
+// PutFileSplitOptions say how PutFileSplitWithOptions splits the data that
+// it puts. They're the fields of PutFileRequest that do, see it for what each
+// one does and which can be used together.
+type PutFileSplitOptions struct {
+	Delimiter        pfs.Delimiter
+	TargetFileDatums int64
+	TargetFileBytes  int64
+	TargetFileCount  int64
+	Overwrite        bool
+	ComputeStats     bool
+	DivertErrors     bool
+	HeaderLines      int64
+	FooterLines      int64
+	Separator        []byte
+	SeparatorRegex   string
+	JSONSchema       []byte
+}
+
+// PutFileSplitWithOptions is like PutFileSplit, but the data is split as
+// options say. The response says how many records were written and, when
+// errors are diverted, how many records were diverted and where the errors
+// file is.
+func (c APIClient) PutFileSplitWithOptions(repoName string, commitID string, path string, options PutFileSplitOptions, reader io.Reader) (*pfs.PutFileResponse, error) {
+	writer, err := c.newPutFileWriteCloser(repoName, commitID, path, options.Delimiter, options.TargetFileDatums, options.TargetFileBytes, nil, putFileMode(options.Overwrite))
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	writer.request.TargetFileCount = options.TargetFileCount
+	writer.request.ComputeStats = options.ComputeStats
+	writer.request.DivertErrors = options.DivertErrors
+	writer.request.HeaderLines = options.HeaderLines
+	writer.request.FooterLines = options.FooterLines
+	writer.request.Separator = options.Separator
+	writer.request.SeparatorRegex = options.SeparatorRegex
+	writer.request.JsonSchema = options.JSONSchema
+	if _, err := io.Copy(writer, reader); err != nil {
+		writer.Close()
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return writer.response, nil
+}
+
+// PutFileTar extracts the tar (or gzipped tar) archive in reader into path,
+// the extraction is done by the server so only the archive is sent.
+func (c APIClient) PutFileTar(repoName string, commitID string, path string, reader io.Reader) (int, error) {
+	return c.PutFileSplit(repoName, commitID, path, pfs.Delimiter_TAR, 0, 0, false, reader)
+}
+
 // PutFileURL puts a file using the content found at a URL.
 // The URL is sent to the server which performs the request.
 // recursive allow for recursive scraping of some types URLs for example on s3:// urls.
@@ -615,51 +1384,580 @@ func (c APIClient) PutFileURL(repoName string, commitID string, path string, url
 			retErr = grpcutil.ScrubGRPC(err)
 		}
 	}()
-	var overwriteIndex *pfs.OverwriteIndex
-	if overwrite {
-		overwriteIndex = &pfs.OverwriteIndex{0}
-	}
 	if err := putFileClient.Send(&pfs.PutFileRequest{
-		File:           NewFile(repoName, commitID, path),
-		Url:            url,
-		Recursive:      recursive,
-		OverwriteIndex: overwriteIndex,
+		File:      NewFile(repoName, commitID, path),
+		Url:       url,
+		Recursive: recursive,
+		Mode:      putFileMode(overwrite),
 	}); err != nil {
 		return grpcutil.ScrubGRPC(err)
 	}
 	return nil
 }
 
-// CopyFile copys a file from one pfs location to another. It can be used on
-// directories or regular files.
-func (c APIClient) CopyFile(srcRepo, srcCommit, srcPath, dstRepo, dstCommit, dstPath string, overwrite bool) error {
-	if _, err := c.PfsAPIClient.CopyFile(c.Ctx(),
-		&pfs.CopyFileRequest{
-			Src:       NewFile(srcRepo, srcCommit, srcPath),
-			Dst:       NewFile(dstRepo, dstCommit, dstPath),
-			Overwrite: overwrite,
-		}); err != nil {
-		return grpcutil.ScrubGRPC(err)
-	}
-	return nil
+// PutFilesBatch writes and deletes many files atomically, either all of its
+// writes appear in their commits or none of them do.
+// NOTE: PutFilesBatch's writes have no effect until Close is called, and
+// won't be made at all if any of them fail.
+type PutFilesBatch struct {
+	putFilesClient pfs.API_PutFilesClient
 }
 
-// GetFile returns the contents of a file at a specific Commit.
-// offset specifies a number of bytes that should be skipped in the beginning of the file.
-// size limits the total amount of data returned, note you will get fewer bytes
-// than size if you pass a value larger than the size of the file.
-// If size is set to 0 then all of the data will be returned.
-func (c APIClient) GetFile(repoName string, commitID string, path string, offset int64, size int64, writer io.Writer) error {
-	if c.streamSemaphore != nil {
-		c.streamSemaphore <- struct{}{}
-		defer func() { <-c.streamSemaphore }()
-	}
-	apiGetFileClient, err := c.getFile(repoName, commitID, path, offset, size)
+// NewPutFilesBatch returns a new PutFilesBatch.
+func (c APIClient) NewPutFilesBatch() (*PutFilesBatch, error) {
+	putFilesClient, err := c.PfsAPIClient.PutFiles(c.Ctx())
 	if err != nil {
-		return grpcutil.ScrubGRPC(err)
-	}
-	if err := grpcutil.WriteFromStreamingBytesClient(apiGetFileClient, writer); err != nil {
-		return grpcutil.ScrubGRPC(err)
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return &PutFilesBatch{
+		putFilesClient: putFilesClient,
+	}, nil
+}
+
+// PutFile adds a write of the data in reader to the batch.
+func (b *PutFilesBatch) PutFile(repoName string, commitID string, path string, reader io.Reader) (int, error) {
+	return b.PutFileSplit(repoName, commitID, path, pfs.Delimiter_NONE, 0, 0, false, reader)
+}
+
+// PutFileOverwrite is like PutFile but it overwrites the file rather than
+// appending to it.
+func (b *PutFilesBatch) PutFileOverwrite(repoName string, commitID string, path string, reader io.Reader) (int, error) {
+	return b.PutFileSplit(repoName, commitID, path, pfs.Delimiter_NONE, 0, 0, true, reader)
+}
+
+// PutFileWithMode is like APIClient.PutFileWithMode, but adds the write to
+// the batch.
+func (b *PutFilesBatch) PutFileWithMode(repoName string, commitID string, path string, mode pfs.PutFileMode, reader io.Reader) (int, error) {
+	return b.putFile(&pfs.PutFileRequest{
+		File: NewFile(repoName, commitID, path),
+		Mode: mode,
+	}, reader)
+}
+
+// PutFileSplit is like APIClient.PutFileSplit, but adds the write to the batch.
+func (b *PutFilesBatch) PutFileSplit(repoName string, commitID string, path string, delimiter pfs.Delimiter, targetFileDatums int64, targetFileBytes int64, overwrite bool, reader io.Reader) (int, error) {
+	return b.putFile(&pfs.PutFileRequest{
+		File:             NewFile(repoName, commitID, path),
+		Delimiter:        delimiter,
+		TargetFileDatums: targetFileDatums,
+		TargetFileBytes:  targetFileBytes,
+		Mode:             putFileMode(overwrite),
+	}, reader)
+}
+
+func (b *PutFilesBatch) putFile(request *pfs.PutFileRequest, reader io.Reader) (int, error) {
+	buf := grpcutil.GetBuffer()
+	defer grpcutil.PutBuffer(buf)
+	var written int
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 || request.File != nil {
+			request.Value = buf[:n]
+			if err := b.putFilesClient.Send(&pfs.PutFilesRequest{PutFile: request}); err != nil {
+				return written, grpcutil.ScrubGRPC(err)
+			}
+			written += n
+			// File is only needed on the first request
+			request = &pfs.PutFileRequest{}
+		}
+		if err == io.EOF {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+}
+
+// DeleteFile adds the deletion of a file to the batch.
+func (b *PutFilesBatch) DeleteFile(repoName string, commitID string, path string) error {
+	return grpcutil.ScrubGRPC(b.putFilesClient.Send(&pfs.PutFilesRequest{
+		DeleteFile: &pfs.DeleteFileRequest{
+			File: NewFile(repoName, commitID, path),
+		},
+	}))
+}
+
+// DeleteFileGlob adds a delete of the files that pattern matches to the
+// batch, see APIClient.DeleteFileGlob.
+func (b *PutFilesBatch) DeleteFileGlob(repoName string, commitID string, pattern string) error {
+	return grpcutil.ScrubGRPC(b.putFilesClient.Send(&pfs.PutFilesRequest{
+		DeleteFile: &pfs.DeleteFileRequest{
+			File: NewFile(repoName, commitID, pattern),
+			Glob: true,
+		},
+	}))
+}
+
+// Close makes all of the writes in the batch, or none of them if any fail.
+func (b *PutFilesBatch) Close() error {
+	_, err := b.putFilesClient.CloseAndRecv()
+	return grpcutil.ScrubGRPC(err)
+}
+
+// CopyFile copys a file from one pfs location to another. It can be used on
+// directories or regular files.
+func (c APIClient) CopyFile(srcRepo, srcCommit, srcPath, dstRepo, dstCommit, dstPath string, overwrite bool) error {
+	if _, err := c.PfsAPIClient.CopyFile(c.Ctx(),
+		&pfs.CopyFileRequest{
+			Src:       NewFile(srcRepo, srcCommit, srcPath),
+			Dst:       NewFile(dstRepo, dstCommit, dstPath),
+			Overwrite: overwrite,
+		}); err != nil {
+		return grpcutil.ScrubGRPC(err)
+	}
+	return nil
+}
+
+// CopyFileOverwrite is like CopyFile but it copies into the destination
+// files starting from the object at overwriteIndex, rather than appending to
+// them. Existing objects starting from the index are deleted, so copying to an
+// index of 0 replaces the files.
+func (c APIClient) CopyFileOverwrite(srcRepo, srcCommit, srcPath, dstRepo, dstCommit, dstPath string, overwriteIndex int64) error {
+	if _, err := c.PfsAPIClient.CopyFile(c.Ctx(),
+		&pfs.CopyFileRequest{
+			Src:            NewFile(srcRepo, srcCommit, srcPath),
+			Dst:            NewFile(dstRepo, dstCommit, dstPath),
+			OverwriteIndex: &pfs.OverwriteIndex{overwriteIndex},
+		}); err != nil {
+		return grpcutil.ScrubGRPC(err)
+	}
+	return nil
+}
+
+// MoveFile moves the file or directory at srcPath to dstPath within an open
+// Commit, replacing whatever is at dstPath. The moved content isn't copied.
+func (c APIClient) MoveFile(repoName string, commitID string, srcPath string, dstPath string) error {
+	_, err := c.PfsAPIClient.MoveFile(
+		c.Ctx(),
+		&pfs.MoveFileRequest{
+			Src: NewFile(repoName, commitID, srcPath),
+			Dst: NewFile(repoName, commitID, dstPath),
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// CompactFile rewrites the file at path, or the files under it if it's a
+// directory, into as few objects as possible. The compacted files are put in
+// a new commit on top of commitID, unless inPlace is set, in which case
+// commitID must be a branch that allows compaction in place.
+func (c APIClient) CompactFile(repoName string, commitID string, path string, inPlace bool) (*pfs.CompactResponse, error) {
+	response, err := c.PfsAPIClient.CompactFile(
+		c.Ctx(),
+		&pfs.CompactFileRequest{
+			File:    NewFile(repoName, commitID, path),
+			InPlace: inPlace,
+		},
+	)
+	return response, grpcutil.ScrubGRPC(err)
+}
+
+// CompactCommit compacts every file in a commit, see CompactFile.
+func (c APIClient) CompactCommit(repoName string, commitID string, inPlace bool) (*pfs.CompactResponse, error) {
+	response, err := c.PfsAPIClient.CompactCommit(
+		c.Ctx(),
+		&pfs.CompactCommitRequest{
+			Commit:  NewCommit(repoName, commitID),
+			InPlace: inPlace,
+		},
+	)
+	return response, grpcutil.ScrubGRPC(err)
+}
+
+// SetCompactInPlace sets whether the head of a branch may be compacted in
+// place, which rewrites the head commit rather than adding one.
+func (c APIClient) SetCompactInPlace(repoName string, branch string, allow bool) error {
+	_, err := c.PfsAPIClient.SetCompactInPlace(
+		c.Ctx(),
+		&pfs.SetCompactInPlaceRequest{
+			Repo:   NewRepo(repoName),
+			Branch: branch,
+			Allow:  allow,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// SetProtectedPaths sets the path prefixes of a repo, e.g. "/raw", under
+// which only the repo's owners can put, copy, move or delete files. It
+// replaces the repo's protected paths; passing none unprotects the repo.
+func (c APIClient) SetProtectedPaths(repoName string, paths ...string) error {
+	_, err := c.PfsAPIClient.SetProtectedPaths(
+		c.Ctx(),
+		&pfs.SetProtectedPathsRequest{
+			Repo:  NewRepo(repoName),
+			Paths: paths,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// SetResidency sets the residency tags of a repo, e.g. "eu-only", which
+// constrain the clusters that its data can be placed on, e.g. by proxy repos.
+// It replaces the repo's tags; passing none removes them.
+func (c APIClient) SetResidency(repoName string, residency ...string) error {
+	_, err := c.PfsAPIClient.SetResidency(
+		c.Ctx(),
+		&pfs.SetResidencyRequest{
+			Repo:      NewRepo(repoName),
+			Residency: residency,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// SetStorageClass sets the storage class that a repo's data is written in
+// from now on, see pfs.RepoInfo.StorageClass. "" sets it to the object
+// store's default class.
+func (c APIClient) SetStorageClass(repoName string, storageClass string) error {
+	_, err := c.PfsAPIClient.SetStorageClass(
+		c.Ctx(),
+		&pfs.SetStorageClassRequest{
+			Repo:         NewRepo(repoName),
+			StorageClass: storageClass,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// UpdateRepoMetadata removes the labels and annotations of a repo with the
+// keys in removeLabels and removeAnnotations, and then adds labels and
+// annotations to them, replacing those with the same keys.
+func (c APIClient) UpdateRepoMetadata(repoName string, labels map[string]string, annotations map[string]string, removeLabels []string, removeAnnotations []string) error {
+	_, err := c.PfsAPIClient.UpdateRepoMetadata(
+		c.Ctx(),
+		&pfs.UpdateRepoMetadataRequest{
+			Repo:              NewRepo(repoName),
+			Labels:            labels,
+			Annotations:       annotations,
+			RemoveLabels:      removeLabels,
+			RemoveAnnotations: removeAnnotations,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// SetReadFilter sets the filter that callers with less than
+// filter.ExemptScope on a repo read the records of its files through, so
+// that e.g. most readers of a dataset only see its non-sensitive records. A
+// nil filter removes the repo's filter.
+func (c APIClient) SetReadFilter(repoName string, filter *pfs.ReadFilter) error {
+	_, err := c.PfsAPIClient.SetReadFilter(
+		c.Ctx(),
+		&pfs.SetReadFilterRequest{
+			Repo:   NewRepo(repoName),
+			Filter: filter,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// SetCompactionPolicy sets the policy that the branches of a repo are
+// compacted by automatically, see pfs.CompactionPolicy. A nil policy removes
+// the repo's policy. Compactions are run with the caller's credentials.
+func (c APIClient) SetCompactionPolicy(repoName string, policy *pfs.CompactionPolicy) error {
+	_, err := c.PfsAPIClient.SetCompactionPolicy(
+		c.Ctx(),
+		&pfs.SetCompactionPolicyRequest{
+			Repo:   NewRepo(repoName),
+			Policy: policy,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// InspectCompactionPolicy returns the compaction policy of a repo, along with
+// the state of its automatic compactions.
+func (c APIClient) InspectCompactionPolicy(repoName string) (*pfs.CompactionPolicyInfo, error) {
+	policyInfo, err := c.PfsAPIClient.InspectCompactionPolicy(
+		c.Ctx(),
+		&pfs.InspectCompactionPolicyRequest{
+			Repo: NewRepo(repoName),
+		},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return policyInfo, nil
+}
+
+// SetRetentionPolicy sets the rules that the branches of a repo are retained
+// by, see pfs.RetentionPolicy. A nil policy removes the repo's policy. Plans
+// are carried out with the caller's credentials.
+func (c APIClient) SetRetentionPolicy(repoName string, policy *pfs.RetentionPolicy) error {
+	_, err := c.PfsAPIClient.SetRetentionPolicy(
+		c.Ctx(),
+		&pfs.SetRetentionPolicyRequest{
+			Repo:   NewRepo(repoName),
+			Policy: policy,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// InspectRetentionPolicy returns the retention policy of a repo, along with
+// the heads of deleted branches that it keeps and its latest plans.
+func (c APIClient) InspectRetentionPolicy(repoName string) (*pfs.RetentionPolicyInfo, error) {
+	policyInfo, err := c.PfsAPIClient.InspectRetentionPolicy(
+		c.Ctx(),
+		&pfs.InspectRetentionPolicyRequest{
+			Repo: NewRepo(repoName),
+		},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return policyInfo, nil
+}
+
+// PlanRetention returns the plan that would enforce the retention policy of a
+// repo now, without carrying it out.
+func (c APIClient) PlanRetention(repoName string) (*pfs.RetentionPlan, error) {
+	plan, err := c.PfsAPIClient.PlanRetention(
+		c.Ctx(),
+		&pfs.PlanRetentionRequest{
+			Repo: NewRepo(repoName),
+		},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return plan, nil
+}
+
+// AcquireCommitLock locks the paths under path in an open commit for owner,
+// so that external writers can divide the commit between them. The lock is
+// advisory, and it expires unless it's renewed within ttlSeconds (0 for the
+// default). It fails if another lock holds path, a path under it or a path
+// above it. The lock's ID renews and releases it.
+func (c APIClient) AcquireCommitLock(repoName string, commitID string, path string, owner string, ttlSeconds int64) (*pfs.CommitLock, error) {
+	lock, err := c.PfsAPIClient.AcquireCommitLock(
+		c.Ctx(),
+		&pfs.AcquireCommitLockRequest{
+			File:       NewFile(repoName, commitID, path),
+			Owner:      owner,
+			TtlSeconds: ttlSeconds,
+		},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return lock, nil
+}
+
+// RenewCommitLock extends the lease of a commit lock to ttlSeconds from now.
+func (c APIClient) RenewCommitLock(repoName string, commitID string, id string, ttlSeconds int64) (*pfs.CommitLock, error) {
+	lock, err := c.PfsAPIClient.RenewCommitLock(
+		c.Ctx(),
+		&pfs.RenewCommitLockRequest{
+			Commit:     NewCommit(repoName, commitID),
+			ID:         id,
+			TtlSeconds: ttlSeconds,
+		},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return lock, nil
+}
+
+// ReleaseCommitLock releases a commit lock.
+func (c APIClient) ReleaseCommitLock(repoName string, commitID string, id string) error {
+	_, err := c.PfsAPIClient.ReleaseCommitLock(
+		c.Ctx(),
+		&pfs.ReleaseCommitLockRequest{
+			Commit: NewCommit(repoName, commitID),
+			ID:     id,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// ListCommitLock returns the locks held on a commit.
+func (c APIClient) ListCommitLock(repoName string, commitID string) ([]*pfs.CommitLock, error) {
+	resp, err := c.PfsAPIClient.ListCommitLock(
+		c.Ctx(),
+		&pfs.ListCommitLockRequest{
+			Commit: NewCommit(repoName, commitID),
+		},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return resp.Locks, nil
+}
+
+// StartUpload starts a resumable upload of a file into an open commit, the
+// upload's ID puts its chunks and completes it.
+func (c APIClient) StartUpload(repoName string, commitID string, path string, overwrite bool) (*pfs.UploadSession, error) {
+	session, err := c.PfsAPIClient.StartUpload(
+		c.Ctx(),
+		&pfs.StartUploadRequest{
+			File: NewFile(repoName, commitID, path),
+			Mode: putFileMode(overwrite),
+		},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return session, nil
+}
+
+// PutChunk uploads the data in reader as the chunk at index of an upload,
+// which must be the upload's next chunk. The chunk is acknowledged once
+// PutChunk returns without an error.
+func (c APIClient) PutChunk(uploadID string, index int64, reader io.Reader) (*pfs.UploadSession, error) {
+	// the stream is cancelled, rather than closed, if reader fails, so that
+	// the part of the chunk that was sent isn't acknowledged
+	ctx, cancel := context.WithCancel(c.Ctx())
+	defer cancel()
+	putChunkClient, err := c.PfsAPIClient.PutChunk(ctx)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	request := &pfs.PutChunkRequest{
+		UploadID: uploadID,
+		Index:    index,
+	}
+	// Buffer the chunk so that we don't exceed the grpc MaxMsgSize
+	buf := make([]byte, grpcutil.MaxMsgSize/2)
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			request.Value = buf[:n]
+			if err := putChunkClient.Send(request); err != nil {
+				return nil, grpcutil.ScrubGRPC(err)
+			}
+			request = &pfs.PutChunkRequest{}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if request.UploadID != "" {
+		// the chunk is empty, the upload ID still has to be sent
+		if err := putChunkClient.Send(request); err != nil {
+			return nil, grpcutil.ScrubGRPC(err)
+		}
+	}
+	session, err := putChunkClient.CloseAndRecv()
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return session, nil
+}
+
+// InspectUpload returns the state of an upload, including the index of the
+// chunk to resume it from.
+func (c APIClient) InspectUpload(uploadID string) (*pfs.UploadSession, error) {
+	session, err := c.PfsAPIClient.InspectUpload(
+		c.Ctx(),
+		&pfs.InspectUploadRequest{
+			UploadID: uploadID,
+		},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return session, nil
+}
+
+// CompleteUpload writes the acknowledged chunks of an upload to its file.
+func (c APIClient) CompleteUpload(uploadID string) error {
+	_, err := c.PfsAPIClient.CompleteUpload(
+		c.Ctx(),
+		&pfs.CompleteUploadRequest{
+			UploadID: uploadID,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// PutFileResumable uploads the data in reader as chunks of chunkSize bytes
+// of an upload, and then completes it. If it's interrupted, calling it
+// again with the same upload ID and data resumes the upload after its last
+// acknowledged chunk.
+func (c APIClient) PutFileResumable(uploadID string, reader io.ReadSeeker, chunkSize int64) error {
+	if chunkSize <= 0 {
+		return fmt.Errorf("the chunk size of an upload must be positive")
+	}
+	session, err := c.InspectUpload(uploadID)
+	if err != nil {
+		return err
+	}
+	if _, err := reader.Seek(session.SizeBytes, io.SeekStart); err != nil {
+		return err
+	}
+	buf := make([]byte, chunkSize)
+	for index := session.Chunks; ; index++ {
+		n, err := io.ReadFull(reader, buf)
+		if n > 0 {
+			if _, err := c.PutChunk(uploadID, index, bytes.NewReader(buf[:n])); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return c.CompleteUpload(uploadID)
+}
+
+// PutSymlink creates a symlink at path that points to target, replacing
+// whatever is at path. An absolute target is a path in the same commit, a
+// relative one is relative to the directory that contains the symlink.
+func (c APIClient) PutSymlink(repoName string, commitID string, path string, target string) error {
+	_, err := c.PfsAPIClient.PutSymlink(
+		c.Ctx(),
+		&pfs.PutSymlinkRequest{
+			File:   NewFile(repoName, commitID, path),
+			Target: target,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// GetFile returns the contents of a file at a specific Commit.
+// offset specifies a number of bytes that should be skipped in the beginning of the file.
+// size limits the total amount of data returned, note you will get fewer bytes
+// than size if you pass a value larger than the size of the file.
+// If size is set to 0 then all of the data will be returned.
+func (c APIClient) GetFile(repoName string, commitID string, path string, offset int64, size int64, writer io.Writer) error {
+	if c.streamSemaphore != nil {
+		c.streamSemaphore <- struct{}{}
+		defer func() { <-c.streamSemaphore }()
+	}
+	apiGetFileClient, err := c.getFile(repoName, commitID, path, offset, size, false)
+	if err != nil {
+		return grpcutil.ScrubGRPC(err)
+	}
+	if err := grpcutil.WriteFromStreamingBytesClient(apiGetFileClient, writer); err != nil {
+		return grpcutil.ScrubGRPC(err)
+	}
+	return nil
+}
+
+// GetFileFollowSymlinks is the same as GetFile, except that the symlinks in
+// path are resolved, so if path is a symlink the contents of the file it
+// points to are returned.
+func (c APIClient) GetFileFollowSymlinks(repoName string, commitID string, path string, offset int64, size int64, writer io.Writer) error {
+	if c.streamSemaphore != nil {
+		c.streamSemaphore <- struct{}{}
+		defer func() { <-c.streamSemaphore }()
+	}
+	apiGetFileClient, err := c.getFile(repoName, commitID, path, offset, size, true)
+	if err != nil {
+		return grpcutil.ScrubGRPC(err)
+	}
+	if err := grpcutil.WriteFromStreamingBytesClient(apiGetFileClient, writer); err != nil {
+		return grpcutil.ScrubGRPC(err)
 	}
 	return nil
 }
@@ -670,23 +1968,166 @@ func (c APIClient) GetFile(repoName string, commitID string, path string, offset
 // than size if you pass a value larger than the size of the file.
 // If size is set to 0 then all of the data will be returned.
 func (c APIClient) GetFileReader(repoName string, commitID string, path string, offset int64, size int64) (io.Reader, error) {
-	apiGetFileClient, err := c.getFile(repoName, commitID, path, offset, size)
+	apiGetFileClient, err := c.getFile(repoName, commitID, path, offset, size, false)
 	if err != nil {
 		return nil, grpcutil.ScrubGRPC(err)
 	}
 	return grpcutil.NewStreamingBytesReader(apiGetFileClient), nil
 }
 
-func (c APIClient) getFile(repoName string, commitID string, path string, offset int64,
-	size int64) (pfs.API_GetFileClient, error) {
-	return c.PfsAPIClient.GetFile(
+// GetFileVerified is like GetFile, but the server checks the file's objects
+// against their hashes as it reads them, and against the file's SHA-256
+// digest if the whole file is read. A mismatch fails with an error that
+// pfs.IsIntegrityError matches, and writer may have been written to already.
+func (c APIClient) GetFileVerified(repoName string, commitID string, path string, offset int64, size int64, writer io.Writer) error {
+	if c.streamSemaphore != nil {
+		c.streamSemaphore <- struct{}{}
+		defer func() { <-c.streamSemaphore }()
+	}
+	apiGetFileClient, err := c.PfsAPIClient.GetFile(
 		c.Ctx(),
 		&pfs.GetFileRequest{
 			File:        NewFile(repoName, commitID, path),
 			OffsetBytes: offset,
 			SizeBytes:   size,
+			Verify:      true,
+		},
+	)
+	if err != nil {
+		return grpcutil.ScrubGRPC(err)
+	}
+	if err := grpcutil.WriteFromStreamingBytesClient(apiGetFileClient, writer); err != nil {
+		return grpcutil.ScrubGRPC(err)
+	}
+	return nil
+}
+
+func (c APIClient) getFile(repoName string, commitID string, path string, offset int64,
+	size int64, followSymlinks bool) (pfs.API_GetFileClient, error) {
+	return c.PfsAPIClient.GetFile(
+		c.Ctx(),
+		&pfs.GetFileRequest{
+			File:           NewFile(repoName, commitID, path),
+			OffsetBytes:    offset,
+			SizeBytes:      size,
+			FollowSymlinks: followSymlinks,
+		},
+	)
+}
+
+// GetRecords writes num records of the directory at path, which was
+// produced by a split PutFile, to writer, starting from the record at offset.
+// Records are numbered from 0 across all of the directory's files. If num is
+// 0 all of the records from offset on are written.
+func (c APIClient) GetRecords(repoName string, commitID string, path string, offset int64, num int64, writer io.Writer) error {
+	if c.streamSemaphore != nil {
+		c.streamSemaphore <- struct{}{}
+		defer func() { <-c.streamSemaphore }()
+	}
+	apiGetRecordsClient, err := c.PfsAPIClient.GetRecords(
+		c.Ctx(),
+		&pfs.GetRecordsRequest{
+			File:          NewFile(repoName, commitID, path),
+			OffsetRecords: offset,
+			NumRecords:    num,
+		},
+	)
+	if err != nil {
+		return grpcutil.ScrubGRPC(err)
+	}
+	if err := grpcutil.WriteFromStreamingBytesClient(apiGetRecordsClient, writer); err != nil {
+		return grpcutil.ScrubGRPC(err)
+	}
+	return nil
+}
+
+// GetFileTar writes a tar archive of the file or directory at path to
+// writer. Entries in the archive are named relative to path.
+func (c APIClient) GetFileTar(repoName string, commitID string, path string, writer io.Writer) error {
+	if c.streamSemaphore != nil {
+		c.streamSemaphore <- struct{}{}
+		defer func() { <-c.streamSemaphore }()
+	}
+	apiGetFileTarClient, err := c.PfsAPIClient.GetFileTar(
+		c.Ctx(),
+		&pfs.GetFileTarRequest{
+			File: NewFile(repoName, commitID, path),
+		},
+	)
+	if err != nil {
+		return grpcutil.ScrubGRPC(err)
+	}
+	if err := grpcutil.WriteFromStreamingBytesClient(apiGetFileTarClient, writer); err != nil {
+		return grpcutil.ScrubGRPC(err)
+	}
+	return nil
+}
+
+// FilterFile writes the lines of the files matching the glob pattern path
+// that match regex to writer.
+func (c APIClient) FilterFile(repoName string, commitID string, path string, regex string, writer io.Writer) error {
+	return c.filterFile(repoName, commitID, path, regex, "", writer)
+}
+
+// FilterFileJSON writes the JSON values in the files matching the glob
+// pattern path for which the JMESPath expression jmesPath is truthy to
+// writer, one per line.
+func (c APIClient) FilterFileJSON(repoName string, commitID string, path string, jmesPath string, writer io.Writer) error {
+	return c.filterFile(repoName, commitID, path, "", jmesPath, writer)
+}
+
+func (c APIClient) filterFile(repoName string, commitID string, path string, regex string, jmesPath string, writer io.Writer) error {
+	if c.streamSemaphore != nil {
+		c.streamSemaphore <- struct{}{}
+		defer func() { <-c.streamSemaphore }()
+	}
+	apiFilterFileClient, err := c.PfsAPIClient.FilterFile(
+		c.Ctx(),
+		&pfs.FilterFileRequest{
+			File:     NewFile(repoName, commitID, path),
+			Regex:    regex,
+			JmesPath: jmesPath,
+		},
+	)
+	if err != nil {
+		return grpcutil.ScrubGRPC(err)
+	}
+	if err := grpcutil.WriteFromStreamingBytesClient(apiFilterFileClient, writer); err != nil {
+		return grpcutil.ScrubGRPC(err)
+	}
+	return nil
+}
+
+// GrepFile calls f with each line of the files matching the glob pattern
+// that matches the Go regular expression regex, in path order. If limit is
+// greater than 0 the search stops after limit matches. Returning an error
+// from f stops the search and returns the error.
+func (c APIClient) GrepFile(repoName string, commitID string, pattern string, regex string, limit int64, f func(*pfs.GrepMatch) error) error {
+	ctx, cancel := context.WithCancel(c.Ctx())
+	defer cancel()
+	stream, err := c.PfsAPIClient.GrepFile(
+		ctx,
+		&pfs.GrepFileRequest{
+			Commit:  NewCommit(repoName, commitID),
+			Pattern: pattern,
+			Regex:   regex,
+			Limit:   limit,
 		},
 	)
+	if err != nil {
+		return grpcutil.ScrubGRPC(err)
+	}
+	for {
+		match, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return grpcutil.ScrubGRPC(err)
+		}
+		if err := f(match); err != nil {
+			return err
+		}
+	}
 }
 
 // InspectFile returns info about a specific file.
@@ -709,33 +2150,201 @@ func (c APIClient) inspectFile(repoName string, commitID string, path string) (*
 
 // ListFile returns info about all files in a Commit.
 func (c APIClient) ListFile(repoName string, commitID string, path string) ([]*pfs.FileInfo, error) {
+	return c.listFile(repoName, commitID, path, false)
+}
+
+// ListFileFollowSymlinks is the same as ListFile, except that the symlinks in
+// path are resolved, and each symlink in the directory is reported with the
+// type and size of the file it points to.
+func (c APIClient) ListFileFollowSymlinks(repoName string, commitID string, path string) ([]*pfs.FileInfo, error) {
+	return c.listFile(repoName, commitID, path, true)
+}
+
+// ListFileFast is the same as ListFile, except that the files' sizes and
+// hashes are omitted, which makes listing large open commits faster.
+func (c APIClient) ListFileFast(repoName string, commitID string, path string) ([]*pfs.FileInfo, error) {
+	fileInfos, _, err := c.ListFilePageMode(repoName, commitID, path, false, 0, "", pfs.ListFileMode_ListFile_FAST)
+	return fileInfos, err
+}
+
+// ListFileRecursive returns info about every file and directory under path,
+// depth-first, rather than only the ones directly in it.
+func (c APIClient) ListFileRecursive(repoName string, commitID string, path string) ([]*pfs.FileInfo, error) {
+	fileInfos, _, err := c.ListFilePageMode(repoName, commitID, path, false, 0, "", pfs.ListFileMode_ListFile_RECURSE)
+	return fileInfos, err
+}
+
+func (c APIClient) listFile(repoName string, commitID string, path string, followSymlinks bool) ([]*pfs.FileInfo, error) {
+	fileInfos, _, err := c.ListFilePage(repoName, commitID, path, followSymlinks, 0, "")
+	return fileInfos, err
+}
+
+// ListFilePage returns at most limit of the files in a directory, ordered by
+// path, starting after the last file of the page whose token is pageToken
+// (from the start if it's ""). It also returns the token of the next page,
+// which is "" once every file has been listed. followSymlinks works like it
+// does for ListFileFollowSymlinks.
+func (c APIClient) ListFilePage(repoName string, commitID string, path string, followSymlinks bool, limit int64, pageToken string) ([]*pfs.FileInfo, string, error) {
+	return c.ListFilePageMode(repoName, commitID, path, followSymlinks, limit, pageToken, pfs.ListFileMode_ListFile_NORMAL)
+}
+
+// ListFilePageMode is the same as ListFilePage, except that the files are
+// listed in the given mode, see pfs.ListFileMode.
+func (c APIClient) ListFilePageMode(repoName string, commitID string, path string, followSymlinks bool, limit int64, pageToken string, mode pfs.ListFileMode) ([]*pfs.FileInfo, string, error) {
 	fileInfos, err := c.PfsAPIClient.ListFile(
 		c.Ctx(),
 		&pfs.ListFileRequest{
-			File: NewFile(repoName, commitID, path),
+			File:           NewFile(repoName, commitID, path),
+			FollowSymlinks: followSymlinks,
+			Limit:          limit,
+			PageToken:      pageToken,
+			Mode:           mode,
 		},
 	)
 	if err != nil {
-		return nil, grpcutil.ScrubGRPC(err)
+		return nil, "", grpcutil.ScrubGRPC(err)
 	}
-	return fileInfos.FileInfo, nil
+	return fileInfos.FileInfo, fileInfos.NextPageToken, nil
 }
 
 // GlobFile returns files that match a given glob pattern in a given commit.
 // The pattern is documented here:
 // https://golang.org/pkg/path/filepath/#Match
 func (c APIClient) GlobFile(repoName string, commitID string, pattern string) ([]*pfs.FileInfo, error) {
+	fileInfos, _, err := c.GlobFilePage(repoName, commitID, pattern, 0, "")
+	return fileInfos, err
+}
+
+// GlobFilePage returns at most limit of the files that match a glob pattern,
+// ordered by path, paged like ListFilePage.
+func (c APIClient) GlobFilePage(repoName string, commitID string, pattern string, limit int64, pageToken string) ([]*pfs.FileInfo, string, error) {
+	return c.globFile(repoName, commitID, pattern, false, limit, pageToken)
+}
+
+// GlobDirectories returns the directories that match a glob pattern. The
+// size of each directory is the total size of the files under it.
+func (c APIClient) GlobDirectories(repoName string, commitID string, pattern string) ([]*pfs.FileInfo, error) {
+	fileInfos, _, err := c.globFile(repoName, commitID, pattern, true, 0, "")
+	return fileInfos, err
+}
+
+func (c APIClient) globFile(repoName string, commitID string, pattern string, directoriesOnly bool, limit int64, pageToken string) ([]*pfs.FileInfo, string, error) {
 	fileInfos, err := c.PfsAPIClient.GlobFile(
 		c.Ctx(),
 		&pfs.GlobFileRequest{
-			Commit:  NewCommit(repoName, commitID),
-			Pattern: pattern,
+			Commit:          NewCommit(repoName, commitID),
+			Pattern:         pattern,
+			Limit:           limit,
+			PageToken:       pageToken,
+			DirectoriesOnly: directoriesOnly,
+		},
+	)
+	if err != nil {
+		return nil, "", grpcutil.ScrubGRPC(err)
+	}
+	return fileInfos.FileInfo, fileInfos.NextPageToken, nil
+}
+
+// SearchFile calls walkFn with the info of each file and directory whose
+// path matches the Go regular expression regex, in path order. Paths start
+// with "/". Returning an error from walkFn stops the search and returns the
+// error.
+func (c APIClient) SearchFile(repoName string, commitID string, regex string, walkFn WalkFn) error {
+	ctx, cancel := context.WithCancel(c.Ctx())
+	defer cancel()
+	stream, err := c.PfsAPIClient.SearchFile(
+		ctx,
+		&pfs.SearchFileRequest{
+			Commit: NewCommit(repoName, commitID),
+			Regex:  regex,
+		},
+	)
+	if err != nil {
+		return grpcutil.ScrubGRPC(err)
+	}
+	for {
+		fileInfo, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return grpcutil.ScrubGRPC(err)
+		}
+		if err := walkFn(fileInfo); err != nil {
+			return err
+		}
+	}
+}
+
+// WalkFile calls walkFn with the FileInfo of the file or directory at path
+// and then with those of everything under it, depth-first in path order.
+// Unlike Walk, the whole walk is done by a single streaming request, so
+// walkFn is called as the files are found.
+func (c APIClient) WalkFile(repoName string, commitID string, path string, walkFn WalkFn) error {
+	ctx, cancel := context.WithCancel(c.Ctx())
+	defer cancel()
+	stream, err := c.PfsAPIClient.WalkFile(
+		ctx,
+		&pfs.WalkFileRequest{
+			File: NewFile(repoName, commitID, path),
+		},
+	)
+	if err != nil {
+		return grpcutil.ScrubGRPC(err)
+	}
+	for {
+		fileInfo, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return grpcutil.ScrubGRPC(err)
+		}
+		if err := walkFn(fileInfo); err != nil {
+			return err
+		}
+	}
+}
+
+// GetManifest returns the paths, sizes and objects of the files in a
+// finished commit that match any of globs, or of every file if there are no
+// globs. The files can then be read with GetManifestEntry, without asking pfs
+// about them again.
+func (c APIClient) GetManifest(repoName string, commitID string, globs ...string) (*pfs.Manifest, error) {
+	manifest, err := c.PfsAPIClient.GetManifest(
+		c.Ctx(),
+		&pfs.GetManifestRequest{
+			Commit: NewCommit(repoName, commitID),
+			Globs:  globs,
 		},
 	)
 	if err != nil {
 		return nil, grpcutil.ScrubGRPC(err)
 	}
-	return fileInfos.FileInfo, nil
+	return manifest, nil
+}
+
+// GetManifestEntry writes the content of the file in a manifest entry,
+// starting at offset and limited to size bytes unless it's 0, to writer.
+func (c APIClient) GetManifestEntry(entry *pfs.ManifestEntry, offset uint64, size uint64, writer io.Writer) error {
+	if entry.FileType != pfs.FileType_FILE {
+		return fmt.Errorf("%s is not a file", entry.Path)
+	}
+	return c.GetFileObjects(entry.Objects, offset, size, writer)
+}
+
+// PutFilesFromManifest puts the files in entries into a commit atomically.
+// Each entry has objects that are already in the object store, which aren't
+// copied, a url that pachd fetches, or a symlink target. A manifest returned
+// by GetManifest can be put into another commit this way.
+func (c APIClient) PutFilesFromManifest(repoName string, commitID string, entries []*pfs.ManifestEntry, overwrite bool) error {
+	_, err := c.PfsAPIClient.PutFilesFromManifest(
+		c.Ctx(),
+		&pfs.PutFilesFromManifestRequest{
+			Commit:    NewCommit(repoName, commitID),
+			Entries:   entries,
+			Overwrite: overwrite,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
 }
 
 // DiffFile returns the difference between 2 paths, old path may be omitted in
@@ -763,6 +2372,82 @@ func (c APIClient) DiffFile(newRepoName, newCommitID, newPath, oldRepoName,
 	return resp.NewFiles, resp.OldFiles, nil
 }
 
+// DiffFileSummary is like DiffFile, but it only returns the number of files
+// that were added, deleted and modified, and their sizes, which is much
+// cheaper than returning every file when a lot of files have changed.
+func (c APIClient) DiffFileSummary(newRepoName, newCommitID, newPath, oldRepoName,
+	oldCommitID, oldPath string, shallow bool) (*pfs.DiffFileSummary, error) {
+	var oldFile *pfs.File
+	if oldRepoName != "" {
+		oldFile = NewFile(oldRepoName, oldCommitID, oldPath)
+	}
+	resp, err := c.PfsAPIClient.DiffFile(
+		c.Ctx(),
+		&pfs.DiffFileRequest{
+			NewFile: NewFile(newRepoName, newCommitID, newPath),
+			OldFile: oldFile,
+			Shallow: shallow,
+			Summary: true,
+		},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return resp.Summary, nil
+}
+
+// PreviewMerge returns what merging theirs into ours, two commits or branches
+// of repoName, would do, without creating any commit.
+func (c APIClient) PreviewMerge(repoName string, ours string, theirs string) (*pfs.PreviewMergeResponse, error) {
+	response, err := c.PfsAPIClient.PreviewMerge(
+		c.Ctx(),
+		&pfs.PreviewMergeRequest{
+			Ours:   NewCommit(repoName, ours),
+			Theirs: NewCommit(repoName, theirs),
+		},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return response, nil
+}
+
+// Merge merges theirs, a commit or branch of repoName, into branch. If there
+// are conflicts, the commit that's returned isn't on branch, and each
+// conflict must be resolved with ResolveConflict.
+func (c APIClient) Merge(repoName string, branch string, theirs string) (*pfs.MergeResponse, error) {
+	response, err := c.PfsAPIClient.Merge(
+		c.Ctx(),
+		&pfs.MergeRequest{
+			Repo:   NewRepo(repoName),
+			Branch: branch,
+			Theirs: NewCommit(repoName, theirs),
+		},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return response, nil
+}
+
+// ResolveConflict resolves the conflicting path of a commit made by Merge or
+// ResolveConflict by keeping the version given by resolution, it returns the
+// new commit.
+func (c APIClient) ResolveConflict(repoName string, commitID string, path string, resolution pfs.ConflictResolution) (*pfs.MergeResponse, error) {
+	response, err := c.PfsAPIClient.ResolveConflict(
+		c.Ctx(),
+		&pfs.ResolveConflictRequest{
+			Commit:     NewCommit(repoName, commitID),
+			Path:       path,
+			Resolution: resolution,
+		},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return response, nil
+}
+
 // WalkFn is the type of the function called for each file in Walk.
 // Returning a non-nil error from WalkFn will result in Walk aborting and
 // returning said error.
@@ -801,13 +2486,278 @@ func (c APIClient) DeleteFile(repoName string, commitID string, path string) err
 	return err
 }
 
+// DeleteFileGlob deletes every file and directory in a commit that pattern, a
+// glob, matches, as a single write however many files that is.
+func (c APIClient) DeleteFileGlob(repoName string, commitID string, pattern string) error {
+	_, err := c.PfsAPIClient.DeleteFile(
+		c.Ctx(),
+		&pfs.DeleteFileRequest{
+			File: NewFile(repoName, commitID, pattern),
+			Glob: true,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// SetSchema sets the schema for the files under path in a repo, pass "" as
+// path to set the schema for the whole repo, or a nil schema to remove the
+// schema set on path. The schema that applies to a file is returned by
+// InspectFile.
+func (c APIClient) SetSchema(repoName string, path string, schema *pfs.Schema) error {
+	_, err := c.PfsAPIClient.SetSchema(
+		c.Ctx(),
+		&pfs.SetSchemaRequest{
+			Repo:   NewRepo(repoName),
+			Path:   path,
+			Schema: schema,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// InspectFeatureUsage returns the feature usage counts that pachd reports
+// when feature telemetry is enabled, exactly as they would be sent.
+func (c APIClient) InspectFeatureUsage() (*pfs.FeatureUsage, error) {
+	usage, err := c.PfsAPIClient.InspectFeatureUsage(c.Ctx(), &types.Empty{})
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return usage, nil
+}
+
+// ListFeatureFlags returns the feature flags of the PFS driver, with their
+// settings for the cluster and for every repo they're set for.
+func (c APIClient) ListFeatureFlags() ([]*pfs.FeatureFlag, error) {
+	resp, err := c.PfsAPIClient.ListFeatureFlags(c.Ctx(), &pfs.ListFeatureFlagsRequest{})
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return resp.Flags, nil
+}
+
+// SetFeatureFlag turns the feature flag called name on or off for repoName,
+// or for the cluster if repoName is "". FeatureFlagSetting_UNSET removes the
+// setting, so that the repo gets the cluster's setting, and the cluster the
+// flag's default.
+func (c APIClient) SetFeatureFlag(name string, repoName string, setting pfs.FeatureFlagSetting) error {
+	request := &pfs.SetFeatureFlagRequest{
+		Name:    name,
+		Setting: setting,
+	}
+	if repoName != "" {
+		request.Repo = NewRepo(repoName)
+	}
+	_, err := c.PfsAPIClient.SetFeatureFlag(c.Ctx(), request)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// Apply reconciles the repos in spec, and their branches, with their desired
+// state. With prune, the repos that aren't in spec, and the branches that
+// aren't in their repo's spec, are deleted. With dryRun, nothing is changed.
+// The actions that were (or would be) taken are returned in order.
+func (c APIClient) Apply(spec *pfs.ApplySpec, prune bool, dryRun bool) ([]*pfs.ApplyAction, error) {
+	resp, err := c.PfsAPIClient.Apply(
+		c.Ctx(),
+		&pfs.ApplyRequest{
+			Spec:   spec,
+			Prune:  prune,
+			DryRun: dryRun,
+		},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return resp.Actions, nil
+}
+
+// Export returns the spec of the current state of repos, or of every repo if
+// none are passed, in the format that Apply takes. Applying it to another
+// cluster clones the repos' configuration, and dry-run applying it to the
+// same cluster later shows how the repos have drifted since.
+func (c APIClient) Export(repos ...string) (*pfs.ApplySpec, error) {
+	request := &pfs.ExportRequest{}
+	for _, repo := range repos {
+		request.Repos = append(request.Repos, NewRepo(repo))
+	}
+	spec, err := c.PfsAPIClient.Export(c.Ctx(), request)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return spec, nil
+}
+
+// RebuildObjectRefCounts recomputes the number of commits that reference each
+// object. Once the counts have been rebuilt, the objects that a deleted repo
+// was the last user of are deleted after a grace period. Like GarbageCollect,
+// it must be run while no data is being added or removed.
+func (c APIClient) RebuildObjectRefCounts() error {
+	_, err := c.PfsAPIClient.RebuildObjectRefCounts(c.Ctx(), &types.Empty{})
+	return grpcutil.ScrubGRPC(err)
+}
+
+// RebuildProvenance re-derives the full provenance of every repo, and the
+// number of repos that depend on each repo, from the provenance that the
+// repos were created or last updated with. Only cluster admins can call it.
+func (c APIClient) RebuildProvenance() error {
+	_, err := c.PfsAPIClient.RebuildProvenance(c.Ctx(), &types.Empty{})
+	return grpcutil.ScrubGRPC(err)
+}
+
+// RecomputeRepoSize resets the size of repoName, or of every repo if it's "",
+// to the size of the data that its branch heads hold, see pfs.RepoSizeChange.
+// It returns the sizes before and after.
+func (c APIClient) RecomputeRepoSize(repoName string) ([]*pfs.RepoSizeChange, error) {
+	request := &pfs.RecomputeRepoSizeRequest{}
+	if repoName != "" {
+		request.Repo = NewRepo(repoName)
+	}
+	response, err := c.PfsAPIClient.RecomputeRepoSize(c.Ctx(), request)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return response.Repos, nil
+}
+
+// FsckProvenance checks the provenance of every repo and commit, and the repo
+// ref counts, returning the inconsistencies that it finds. If fix is set they
+// are repaired, see pfs.ProvenanceInconsistency.
+func (c APIClient) FsckProvenance(fix bool) ([]*pfs.ProvenanceInconsistency, error) {
+	response, err := c.PfsAPIClient.FsckProvenance(c.Ctx(), &pfs.FsckProvenanceRequest{Fix: fix})
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return response.Inconsistencies, nil
+}
+
+// InspectAudit returns the report of the consistency audit that pachd runs
+// when it starts, see pfs.AuditFinding for what it finds.
+func (c APIClient) InspectAudit() (*pfs.AuditReport, error) {
+	report, err := c.PfsAPIClient.InspectAudit(c.Ctx(), &types.Empty{})
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return report, nil
+}
+
+// ListAdminJobs returns the long-running admin operations, such as
+// RebuildObjectRefCounts, that are running or finished recently, oldest
+// first. If jobType is set, only the jobs running that operation are
+// returned.
+func (c APIClient) ListAdminJobs(jobType string) ([]*pfs.AdminJobInfo, error) {
+	jobInfos, err := c.PfsAPIClient.ListAdminJobs(
+		c.Ctx(),
+		&pfs.ListAdminJobsRequest{
+			Type: jobType,
+		},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return jobInfos.JobInfo, nil
+}
+
+// InspectAdminJob returns the status of an admin operation.
+func (c APIClient) InspectAdminJob(id string) (*pfs.AdminJobInfo, error) {
+	jobInfo, err := c.PfsAPIClient.InspectAdminJob(
+		c.Ctx(),
+		&pfs.InspectAdminJobRequest{
+			Id: id,
+		},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return jobInfo, nil
+}
+
+// CancelAdminJob asks a running admin operation to stop.
+func (c APIClient) CancelAdminJob(id string) error {
+	_, err := c.PfsAPIClient.CancelAdminJob(
+		c.Ctx(),
+		&pfs.CancelAdminJobRequest{
+			Id: id,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// SetRepoQuota sets the limits on the rate of a repo's operations, which
+// only cluster admins can do. A nil quota removes the repo's quota.
+func (c APIClient) SetRepoQuota(repoName string, quota *pfs.RepoQuota) error {
+	_, err := c.PfsAPIClient.SetRepoQuota(
+		c.Ctx(),
+		&pfs.SetRepoQuotaRequest{
+			Repo:  NewRepo(repoName),
+			Quota: quota,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// ListRepoUsage returns the rate of each repo's operations on the pachd that
+// serves the request, busiest first, and their quotas.
+func (c APIClient) ListRepoUsage() ([]*pfs.RepoUsage, error) {
+	usage, err := c.PfsAPIClient.ListRepoUsage(
+		c.Ctx(),
+		&pfs.ListRepoUsageRequest{},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return usage.Usage, nil
+}
+
+// SetMetadataExport sets the schedule on which the cluster's metadata is
+// exported to a repo as Parquet files, see pfs.MetadataExport, which only
+// cluster admins can do. A nil export stops metadata from being exported.
+func (c APIClient) SetMetadataExport(export *pfs.MetadataExport) error {
+	_, err := c.PfsAPIClient.SetMetadataExport(
+		c.Ctx(),
+		&pfs.SetMetadataExportRequest{
+			Export: export,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// InspectMetadataExport returns the cluster's metadata export, along with
+// the state of its exports.
+func (c APIClient) InspectMetadataExport() (*pfs.MetadataExportInfo, error) {
+	exportInfo, err := c.PfsAPIClient.InspectMetadataExport(c.Ctx(), &types.Empty{})
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return exportInfo, nil
+}
+
+// ExportMetadata exports the cluster's metadata now, rather than waiting for
+// its next export, and returns the commit that it's exported to.
+func (c APIClient) ExportMetadata() (*pfs.Commit, error) {
+	commit, err := c.PfsAPIClient.ExportMetadata(c.Ctx(), &types.Empty{})
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return commit, nil
+}
+
 type putFileWriteCloser struct {
 	request       *pfs.PutFileRequest
 	putFileClient pfs.API_PutFileClient
 	sent          bool
+	// response is set by Close
+	response *pfs.PutFileResponse
+}
+
+// putFileMode returns the PutFileMode for the overwrite flag taken by many
+// of the PutFile methods.
+func putFileMode(overwrite bool) pfs.PutFileMode {
+	if overwrite {
+		return pfs.PutFileMode_OVERWRITE
+	}
+	return pfs.PutFileMode_APPEND
 }
 
-func (c APIClient) newPutFileWriteCloser(repoName string, commitID string, path string, delimiter pfs.Delimiter, targetFileDatums int64, targetFileBytes int64, overwriteIndex *pfs.OverwriteIndex) (*putFileWriteCloser, error) {
+func (c APIClient) newPutFileWriteCloser(repoName string, commitID string, path string, delimiter pfs.Delimiter, targetFileDatums int64, targetFileBytes int64, overwriteIndex *pfs.OverwriteIndex, mode pfs.PutFileMode) (*putFileWriteCloser, error) {
 	putFileClient, err := c.PfsAPIClient.PutFile(c.Ctx())
 	if err != nil {
 		return nil, err
@@ -819,6 +2769,7 @@ func (c APIClient) newPutFileWriteCloser(repoName string, commitID string, path
 			TargetFileDatums: targetFileDatums,
 			TargetFileBytes:  targetFileBytes,
 			OverwriteIndex:   overwriteIndex,
+			Mode:             mode,
 		},
 		putFileClient: putFileClient,
 	}, nil
@@ -859,8 +2810,12 @@ func (w *putFileWriteCloser) Close() error {
 			return err
 		}
 	}
-	_, err := w.putFileClient.CloseAndRecv()
-	return grpcutil.ScrubGRPC(err)
+	response, err := w.putFileClient.CloseAndRecv()
+	if err != nil {
+		return grpcutil.ScrubGRPC(err)
+	}
+	w.response = response
+	return nil
 }
 
 type putObjectWriteCloser struct {
@@ -869,7 +2824,7 @@ type putObjectWriteCloser struct {
 	object  *pfs.Object
 }
 
-func (c APIClient) newPutObjectWriteCloser(tags ...string) (*putObjectWriteCloser, error) {
+func (c APIClient) newPutObjectWriteCloser(storageClass string, tags ...string) (*putObjectWriteCloser, error) {
 	client, err := c.ObjectAPIClient.PutObject(c.Ctx())
 	if err != nil {
 		return nil, grpcutil.ScrubGRPC(err)
@@ -880,7 +2835,8 @@ func (c APIClient) newPutObjectWriteCloser(tags ...string) (*putObjectWriteClose
 	}
 	return &putObjectWriteCloser{
 		request: &pfs.PutObjectRequest{
-			Tags: _tags,
+			Tags:         _tags,
+			StorageClass: storageClass,
 		},
 		client: client,
 	}, nil
@@ -906,13 +2862,13 @@ type putObjectSplitWriteCloser struct {
 	objects []*pfs.Object
 }
 
-func (c APIClient) newPutObjectSplitWriteCloser() (*putObjectSplitWriteCloser, error) {
+func (c APIClient) newPutObjectSplitWriteCloser(storageClass string) (*putObjectSplitWriteCloser, error) {
 	client, err := c.ObjectAPIClient.PutObjectSplit(c.Ctx())
 	if err != nil {
 		return nil, grpcutil.ScrubGRPC(err)
 	}
 	return &putObjectSplitWriteCloser{
-		request: &pfs.PutObjectRequest{},
+		request: &pfs.PutObjectRequest{StorageClass: storageClass},
 		client:  client,
 	}, nil
 }
@@ -1,11 +1,16 @@
 package pfs
 
 import (
+	"compress/gzip"
 	"crypto/sha512"
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
 	"hash"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
 )
 
 var (
@@ -34,3 +39,162 @@ func GetBlock(hash hash.Hash) *Block {
 		Hash: base64.URLEncoding.EncodeToString(hash.Sum(nil)),
 	}
 }
+
+// DigestHeader is the header of a verified GetFile in which the hex SHA-256
+// digest of the file is sent, see FileInfo.Sha256.
+const DigestHeader = "pfs-sha256"
+
+const integrityErrorMsg = "integrity check failed"
+
+// IntegrityError is returned by a verified GetFile if the content that's read
+// doesn't match what was written: either an object of the file doesn't match
+// its hash, or the file's objects don't match its digest, in which case
+// Object is empty.
+type IntegrityError struct {
+	Path     string
+	Object   string
+	Expected string
+	Actual   string
+}
+
+func (e *IntegrityError) Error() string {
+	if e.Object != "" {
+		return fmt.Sprintf("%s for %s: object %s has hash %s", integrityErrorMsg, e.Path, e.Object, e.Actual)
+	}
+	return fmt.Sprintf("%s for %s: expected SHA-256 %s, got %s", integrityErrorMsg, e.Path, e.Expected, e.Actual)
+}
+
+// IsIntegrityError returns true if err is an IntegrityError, which may have
+// crossed a GRPC boundary.
+func IsIntegrityError(err error) bool {
+	return strings.Contains(err.Error(), integrityErrorMsg)
+}
+
+// Observe widens c's bounds to include value.
+func (c *ColumnStats) Observe(value string) {
+	if c.Min == "" || lessStatValue(value, c.Min) {
+		c.Min = value
+	}
+	if c.Max == "" || lessStatValue(c.Max, value) {
+		c.Max = value
+	}
+}
+
+// MergeTableStats returns the stats of the concatenation of the tables
+// described by a and b. If either is nil the result is nil, since the stats
+// of part of the data aren't known.
+func MergeTableStats(a *TableStats, b *TableStats) *TableStats {
+	if a == nil || b == nil {
+		return nil
+	}
+	result := &TableStats{RowCount: a.RowCount + b.RowCount}
+	index := make(map[string]*ColumnStats)
+	for _, columns := range [][]*ColumnStats{a.Columns, b.Columns} {
+		for _, column := range columns {
+			merged, ok := index[column.Name]
+			if !ok {
+				merged = &ColumnStats{Name: column.Name}
+				index[column.Name] = merged
+				result.Columns = append(result.Columns, merged)
+			}
+			if column.Min != "" {
+				merged.Observe(column.Min)
+			}
+			if column.Max != "" {
+				merged.Observe(column.Max)
+			}
+		}
+	}
+	return result
+}
+
+// lessStatValue compares two column values numerically if they're both
+// numbers and lexicographically otherwise.
+func lessStatValue(a string, b string) bool {
+	aFloat, aErr := strconv.ParseFloat(a, 64)
+	bFloat, bErr := strconv.ParseFloat(b, 64)
+	if aErr == nil && bErr == nil {
+		return aFloat < bFloat
+	}
+	return a < b
+}
+
+// PriorityKey is the metadata key that requests are tagged with their
+// Priority under.
+const PriorityKey = "pach-priority"
+
+// Compressed returns true if any of objects is compressed, in which case
+// they have to be decompressed one at a time to read the file they make up.
+func Compressed(objects []*Object) bool {
+	for _, object := range objects {
+		if object.Compression != Compression_UNCOMPRESSED {
+			return true
+		}
+	}
+	return false
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// NewCompressWriter returns a writer that compresses what's written to it
+// with compression and writes the result to w. It must be closed to flush
+// the compressed data, closing it doesn't close w.
+func NewCompressWriter(compression Compression, w io.Writer) (io.WriteCloser, error) {
+	switch compression {
+	case Compression_UNCOMPRESSED:
+		return nopWriteCloser{w}, nil
+	case Compression_GZIP:
+		return gzip.NewWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unrecognized compression %s", compression)
+	}
+}
+
+// ChunkHashes returns the hashes of the objects that PutFile stores the
+// content in r as, in a repo whose data is compressed with compression, along
+// with the size of the content. The content is split into ChunkSize chunks,
+// each of which is compressed before it's hashed.
+func ChunkHashes(compression Compression, r io.Reader) ([]string, int64, error) {
+	var hashes []string
+	var size int64
+	for {
+		hash := NewHash()
+		w, err := NewCompressWriter(compression, hash)
+		if err != nil {
+			return nil, 0, err
+		}
+		n, err := io.CopyN(w, r, ChunkSize)
+		if err != nil && err != io.EOF {
+			return nil, 0, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, 0, err
+		}
+		size += n
+		if n > 0 || len(hashes) == 0 {
+			hashes = append(hashes, EncodeHash(hash.Sum(nil)))
+		}
+		if n < ChunkSize {
+			return hashes, size, nil
+		}
+	}
+}
+
+// NewDecompressReader returns a reader of the data in r, which was compressed
+// with compression. Closing it doesn't close r.
+func NewDecompressReader(compression Compression, r io.Reader) (io.ReadCloser, error) {
+	switch compression {
+	case Compression_UNCOMPRESSED:
+		return ioutil.NopCloser(r), nil
+	case Compression_GZIP:
+		return gzip.NewReader(r)
+	default:
+		return nil, fmt.Errorf("unrecognized compression %s", compression)
+	}
+}
@@ -1336,6 +1336,7 @@ type PipelineInfo struct {
 	Reason             string                      `protobuf:"bytes,28,opt,name=reason,proto3" json:"reason,omitempty"`
 	MaxQueueSize       int64                       `protobuf:"varint,29,opt,name=max_queue_size,json=maxQueueSize,proto3" json:"max_queue_size,omitempty"`
 	Service            *Service                    `protobuf:"bytes,30,opt,name=service" json:"service,omitempty"`
+	ServiceAccount     string                      `protobuf:"bytes,31,opt,name=service_account,json=serviceAccount,proto3" json:"service_account,omitempty"`
 }
 
 func (m *PipelineInfo) Reset()                    { *m = PipelineInfo{} }
@@ -1511,6 +1512,13 @@ func (m *PipelineInfo) GetService() *Service {
 	return nil
 }
 
+func (m *PipelineInfo) GetServiceAccount() string {
+	if m != nil {
+		return m.ServiceAccount
+	}
+	return ""
+}
+
 type PipelineInfos struct {
 	PipelineInfo []*PipelineInfo `protobuf:"bytes,1,rep,name=pipeline_info,json=pipelineInfo" json:"pipeline_info,omitempty"`
 }
@@ -2021,6 +2029,10 @@ type CreatePipelineRequest struct {
 	Batch        bool     `protobuf:"varint,19,opt,name=batch,proto3" json:"batch,omitempty"`
 	MaxQueueSize int64    `protobuf:"varint,20,opt,name=max_queue_size,json=maxQueueSize,proto3" json:"max_queue_size,omitempty"`
 	Service      *Service `protobuf:"bytes,21,opt,name=service" json:"service,omitempty"`
+	// service_account, if set, is the name of the service account that the
+	// pipeline runs as, instead of the user that creates it. It must be able
+	// to read the pipeline's inputs and write its output repo.
+	ServiceAccount string `protobuf:"bytes,22,opt,name=service_account,json=serviceAccount,proto3" json:"service_account,omitempty"`
 }
 
 func (m *CreatePipelineRequest) Reset()                    { *m = CreatePipelineRequest{} }
@@ -2147,6 +2159,13 @@ func (m *CreatePipelineRequest) GetService() *Service {
 	return nil
 }
 
+func (m *CreatePipelineRequest) GetServiceAccount() string {
+	if m != nil {
+		return m.ServiceAccount
+	}
+	return ""
+}
+
 type InspectPipelineRequest struct {
 	Pipeline *Pipeline `protobuf:"bytes,1,opt,name=pipeline" json:"pipeline,omitempty"`
 }
@@ -4632,6 +4651,14 @@ func (m *PipelineInfo) MarshalTo(dAtA []byte) (int, error) {
 		}
 		i += n47
 	}
+	if len(m.ServiceAccount) > 0 {
+		dAtA[i] = 0xfa
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintPps(dAtA, i, uint64(len(m.ServiceAccount)))
+		i += copy(dAtA[i:], m.ServiceAccount)
+	}
 	return i, nil
 }
 
@@ -5476,6 +5503,14 @@ func (m *CreatePipelineRequest) MarshalTo(dAtA []byte) (int, error) {
 		}
 		i += n77
 	}
+	if len(m.ServiceAccount) > 0 {
+		dAtA[i] = 0xb2
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintPps(dAtA, i, uint64(len(m.ServiceAccount)))
+		i += copy(dAtA[i:], m.ServiceAccount)
+	}
 	return i, nil
 }
 
@@ -6439,6 +6474,10 @@ func (m *PipelineInfo) Size() (n int) {
 		l = m.Service.Size()
 		n += 2 + l + sovPps(uint64(l))
 	}
+	l = len(m.ServiceAccount)
+	if l > 0 {
+		n += 2 + l + sovPps(uint64(l))
+	}
 	return n
 }
 
@@ -6767,6 +6806,10 @@ func (m *CreatePipelineRequest) Size() (n int) {
 		l = m.Service.Size()
 		n += 2 + l + sovPps(uint64(l))
 	}
+	l = len(m.ServiceAccount)
+	if l > 0 {
+		n += 2 + l + sovPps(uint64(l))
+	}
 	return n
 }
 
@@ -12051,6 +12094,35 @@ func (m *PipelineInfo) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 31:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ServiceAccount", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPps
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthPps
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ServiceAccount = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipPps(dAtA[iNdEx:])
@@ -14517,6 +14589,35 @@ func (m *CreatePipelineRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 22:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ServiceAccount", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPps
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthPps
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ServiceAccount = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipPps(dAtA[iNdEx:])
@@ -15333,213 +15434,214 @@ var (
 func init() { proto.RegisterFile("client/pps/pps.proto", fileDescriptorPps) }
 
 var fileDescriptorPps = []byte{
-	// 3325 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x5a, 0xcd, 0x73, 0xdc, 0xc6,
-	0x95, 0xe7, 0x7c, 0x0f, 0xde, 0x60, 0x86, 0xc3, 0xe6, 0x87, 0xa0, 0x91, 0x45, 0x52, 0xd0, 0x4a,
-	0x96, 0x54, 0x5e, 0xca, 0xa6, 0xbc, 0x5a, 0xaf, 0xed, 0xb5, 0xcd, 0x2f, 0xa9, 0x48, 0x71, 0xe5,
-	0x59, 0x50, 0xf2, 0x1e, 0x51, 0x18, 0xa0, 0x67, 0x08, 0x09, 0x03, 0xc0, 0x00, 0x86, 0x12, 0x7d,
-	0xda, 0xff, 0x20, 0x95, 0x8f, 0x4a, 0xa5, 0x52, 0x95, 0x93, 0x0f, 0xb9, 0xe4, 0x90, 0x73, 0x2a,
-	0xd7, 0x54, 0xf9, 0x94, 0xca, 0x5f, 0xa0, 0x4a, 0x29, 0xff, 0x44, 0x2e, 0xa9, 0x4a, 0xf5, 0xeb,
-	0x06, 0x06, 0x98, 0x01, 0x87, 0xa4, 0x95, 0x03, 0xab, 0xd0, 0xaf, 0x5f, 0x77, 0xbf, 0x7e, 0xdd,
-	0xef, 0xf7, 0x7e, 0xfd, 0x86, 0xb0, 0x64, 0x3a, 0x36, 0x75, 0xa3, 0xfb, 0xbe, 0x1f, 0xb2, 0xbf,
-	0x0d, 0x3f, 0xf0, 0x22, 0x8f, 0x94, 0x7c, 0x3f, 0xec, 0x5c, 0x1b, 0x78, 0xde, 0xc0, 0xa1, 0xf7,
-	0x51, 0xd4, 0x1b, 0xf5, 0xef, 0xd3, 0xa1, 0x1f, 0x9d, 0x72, 0x8d, 0xce, 0xda, 0x64, 0x67, 0x64,
-	0x0f, 0x69, 0x18, 0x19, 0x43, 0x5f, 0x28, 0xac, 0x4e, 0x2a, 0x58, 0xa3, 0xc0, 0x88, 0x6c, 0xcf,
-	0x15, 0xfd, 0x4b, 0x03, 0x6f, 0xe0, 0xe1, 0xe7, 0x7d, 0xf6, 0x15, 0x4b, 0x63, 0x73, 0xfa, 0x21,
-	0xfb, 0xe3, 0x52, 0xb5, 0x0f, 0xd5, 0x23, 0x6a, 0x06, 0x34, 0x22, 0x04, 0xca, 0xae, 0x31, 0xa4,
-	0x4a, 0x61, 0xbd, 0x70, 0x47, 0xd2, 0xf0, 0x9b, 0x5c, 0x07, 0x18, 0x7a, 0x23, 0x37, 0xd2, 0x7d,
-	0x23, 0x3a, 0x56, 0x8a, 0xd8, 0x23, 0xa1, 0xa4, 0x6b, 0x44, 0xc7, 0xe4, 0x0a, 0xd4, 0xa8, 0x7b,
-	0xa2, 0x9f, 0x18, 0x81, 0x52, 0xc2, 0xbe, 0x2a, 0x75, 0x4f, 0xbe, 0x31, 0x02, 0xd2, 0x86, 0xd2,
-	0x4b, 0x7a, 0xaa, 0x94, 0x51, 0xc8, 0x3e, 0xd5, 0x3f, 0x15, 0x41, 0x7a, 0x16, 0x18, 0x6e, 0xd8,
-	0xf7, 0x82, 0x21, 0x59, 0x82, 0x8a, 0x3d, 0x34, 0x06, 0xf1, 0x62, 0xbc, 0xc1, 0x46, 0x99, 0x43,
-	0x4b, 0x29, 0xae, 0x97, 0xd8, 0x28, 0x73, 0x68, 0x91, 0xbb, 0x50, 0xa2, 0xee, 0x89, 0x52, 0x5a,
-	0x2f, 0xdd, 0x69, 0x6c, 0x5e, 0xd9, 0x60, 0x5e, 0x4c, 0x26, 0xd9, 0xd8, 0x73, 0x4f, 0xf6, 0xdc,
-	0x28, 0x38, 0xd5, 0x98, 0x0e, 0xb9, 0x05, 0xb5, 0x10, 0x37, 0x12, 0x2a, 0x65, 0x54, 0x6f, 0xa0,
-	0x3a, 0xdf, 0x9c, 0x16, 0xf7, 0xb1, 0x95, 0xc3, 0xc8, 0xb2, 0x5d, 0xa5, 0x82, 0xab, 0xf0, 0x06,
-	0xf9, 0x00, 0x88, 0x61, 0x9a, 0xd4, 0x8f, 0xf4, 0x80, 0x46, 0xa3, 0xc0, 0xd5, 0x4d, 0xcf, 0xa2,
-	0x4a, 0x75, 0xbd, 0x74, 0xa7, 0xa4, 0xb5, 0x79, 0x8f, 0x86, 0x1d, 0x3b, 0x9e, 0x45, 0xd9, 0x1c,
-	0x16, 0xed, 0x8d, 0x06, 0x4a, 0x6d, 0xbd, 0x70, 0xa7, 0xae, 0xf1, 0x06, 0x9b, 0x03, 0xb7, 0xa1,
-	0xfb, 0x23, 0xc7, 0xd1, 0x63, 0x5b, 0x24, 0x5c, 0xa6, 0x8d, 0x3d, 0xdd, 0x91, 0xe3, 0x70, 0x7b,
-	0xc2, 0xce, 0x43, 0xa8, 0xc7, 0xf6, 0xc7, 0xde, 0x2a, 0x24, 0xde, 0x62, 0x2b, 0x9c, 0x18, 0xce,
-	0x88, 0x0a, 0x97, 0xf3, 0xc6, 0xa7, 0xc5, 0x4f, 0x0a, 0x6a, 0x07, 0xaa, 0x7b, 0x83, 0x80, 0x86,
-	0x21, 0x1b, 0xf5, 0x5c, 0x3b, 0x8c, 0x47, 0x3d, 0xd7, 0x0e, 0xd5, 0xeb, 0x50, 0x3a, 0xf0, 0x7a,
-	0x64, 0x05, 0x8a, 0xb6, 0xc5, 0xe5, 0xdb, 0xd5, 0xb7, 0x6f, 0xd6, 0x8a, 0xfb, 0xbb, 0x5a, 0xd1,
-	0xb6, 0xd4, 0x23, 0xa8, 0x1d, 0xd1, 0xe0, 0xc4, 0x36, 0x29, 0xb9, 0x09, 0x4d, 0xdb, 0x8d, 0x68,
-	0xe0, 0x1a, 0x8e, 0xee, 0x7b, 0x41, 0x84, 0xda, 0x15, 0x4d, 0x8e, 0x85, 0x5d, 0x2f, 0x88, 0x98,
-	0x12, 0x7d, 0x9d, 0x56, 0x2a, 0x72, 0xa5, 0x58, 0xc8, 0x94, 0xd4, 0xdf, 0x15, 0x40, 0xda, 0x8a,
-	0xbc, 0xe1, 0xbe, 0xeb, 0x8f, 0xf2, 0xef, 0x10, 0x81, 0x72, 0x40, 0x7d, 0x4f, 0x6c, 0x05, 0xbf,
-	0xc9, 0x0a, 0x54, 0x7b, 0x81, 0xe1, 0x9a, 0xc7, 0xf1, 0xbd, 0xe1, 0x2d, 0x26, 0x37, 0xbd, 0xe1,
-	0xd0, 0x8e, 0xc4, 0xd5, 0x11, 0x2d, 0x36, 0xc7, 0xc0, 0xf1, 0x7a, 0x4a, 0x85, 0xcf, 0xc1, 0xbe,
-	0x99, 0xcc, 0x31, 0xbe, 0x3b, 0x55, 0xaa, 0x78, 0x08, 0xf8, 0x4d, 0xd6, 0xa0, 0xd1, 0x0f, 0xbc,
-	0xa1, 0x2e, 0x26, 0xa9, 0xa1, 0x3a, 0x30, 0xd1, 0x0e, 0x4a, 0xd4, 0x9f, 0x16, 0x40, 0xda, 0x09,
-	0x3c, 0xf7, 0xd2, 0xe6, 0x8a, 0x19, 0x4b, 0x93, 0x66, 0x85, 0x3e, 0x35, 0x85, 0xb1, 0xf8, 0x4d,
-	0x3e, 0x64, 0x17, 0xcc, 0x08, 0x22, 0xb4, 0xb5, 0xb1, 0xd9, 0xd9, 0xe0, 0xc1, 0xba, 0x11, 0x07,
-	0xeb, 0xc6, 0xb3, 0x38, 0x9a, 0x35, 0xae, 0xa8, 0xfe, 0xbc, 0x00, 0x15, 0x6e, 0x8f, 0x0a, 0x65,
-	0x23, 0xf2, 0x86, 0x68, 0x4f, 0x63, 0xb3, 0x85, 0x17, 0x38, 0x71, 0xae, 0x86, 0x7d, 0x64, 0x1d,
-	0x2a, 0x66, 0xe0, 0x85, 0x21, 0x86, 0x49, 0x63, 0x13, 0x50, 0x89, 0x2b, 0xf0, 0x0e, 0xa6, 0x31,
-	0x72, 0x6d, 0xcf, 0x15, 0x61, 0x93, 0xd1, 0xc0, 0x0e, 0xb6, 0x8e, 0x19, 0x78, 0x2e, 0xda, 0x1d,
-	0xaf, 0x93, 0x78, 0x45, 0xc3, 0x3e, 0xf5, 0x25, 0xd4, 0x0f, 0xbc, 0x1e, 0xb7, 0xeb, 0x66, 0xb2,
-	0x7f, 0x6e, 0x59, 0x63, 0x83, 0x01, 0x08, 0x77, 0xe9, 0xd4, 0x19, 0x15, 0x73, 0xce, 0xa8, 0x94,
-	0x3a, 0xa3, 0xd8, 0xe9, 0xe5, 0xb1, 0xd3, 0xd5, 0xe7, 0x30, 0xdf, 0x35, 0x02, 0xc3, 0x71, 0xa8,
-	0x63, 0x87, 0xc3, 0x23, 0xe6, 0xc7, 0x0e, 0xd4, 0x4d, 0xcf, 0x0d, 0x23, 0xc3, 0xe5, 0x17, 0xaf,
-	0xac, 0x25, 0x6d, 0xb2, 0x0e, 0x0d, 0xd3, 0xa3, 0xfd, 0xbe, 0x6d, 0x32, 0x44, 0xc3, 0xd9, 0x0b,
-	0x5a, 0x5a, 0x74, 0x50, 0xae, 0x17, 0xda, 0x45, 0xf5, 0x01, 0x48, 0xb8, 0x81, 0x47, 0xb6, 0x83,
-	0x07, 0x8b, 0x28, 0x26, 0xd6, 0x65, 0xdf, 0x4c, 0x76, 0x6c, 0x84, 0xc7, 0x78, 0x56, 0xb2, 0x86,
-	0xdf, 0xea, 0x67, 0x50, 0xd9, 0x35, 0xa2, 0xd1, 0xf0, 0xac, 0x38, 0x22, 0x1d, 0x28, 0xbd, 0x10,
-	0xfb, 0x6c, 0x6c, 0xd6, 0xd1, 0x79, 0x07, 0x5e, 0x4f, 0x63, 0x42, 0xf5, 0x87, 0x02, 0x48, 0x38,
-	0x7a, 0xdf, 0xed, 0x7b, 0xec, 0x24, 0x2c, 0xd6, 0x10, 0x6e, 0xe3, 0x27, 0x81, 0xdd, 0x1a, 0xef,
-	0x20, 0xb7, 0xf0, 0xb6, 0x44, 0x3c, 0xd0, 0x5b, 0x9b, 0xf3, 0x63, 0x8d, 0x23, 0x26, 0xd6, 0x78,
-	0x2f, 0x79, 0x9f, 0xab, 0x85, 0xb8, 0xd5, 0xc6, 0xe6, 0x02, 0xaa, 0x75, 0x03, 0xcf, 0xa4, 0x61,
-	0xc8, 0x14, 0x43, 0xae, 0x18, 0x92, 0xdb, 0x20, 0xf9, 0xfd, 0x50, 0xe7, 0x73, 0xf2, 0xe3, 0x95,
-	0xf0, 0xb0, 0x98, 0x0b, 0xb4, 0xba, 0xdf, 0x47, 0x75, 0x4a, 0x6e, 0x40, 0xd9, 0x32, 0x22, 0x03,
-	0x51, 0xb0, 0xb1, 0xd9, 0x4c, 0x54, 0x98, 0xd9, 0x1a, 0x76, 0xa9, 0x9f, 0x01, 0x24, 0x3b, 0x09,
-	0xc9, 0xbf, 0x03, 0xa0, 0xc5, 0xba, 0xed, 0xf6, 0x3d, 0xa5, 0x80, 0xc3, 0x5a, 0x63, 0x6b, 0x71,
-	0x9c, 0x64, 0xc5, 0x9f, 0xea, 0xef, 0x19, 0x2c, 0x0c, 0x06, 0x01, 0x1d, 0xb0, 0xd5, 0x96, 0xa0,
-	0x62, 0xb2, 0xa4, 0x81, 0x7e, 0x28, 0x69, 0xbc, 0xc1, 0x9c, 0x3f, 0xa4, 0x86, 0x8b, 0x5b, 0x2f,
-	0x68, 0xf8, 0xcd, 0x22, 0x2d, 0x8c, 0x2c, 0x8b, 0x9e, 0x88, 0x43, 0x15, 0x2d, 0x72, 0x17, 0xda,
-	0x7d, 0xbb, 0x1f, 0x1d, 0xeb, 0x3e, 0x0d, 0x4c, 0xea, 0x46, 0xb6, 0xc3, 0xb7, 0x57, 0xd0, 0xe6,
-	0x51, 0xde, 0x4d, 0xc4, 0xe4, 0x21, 0x5c, 0x71, 0x6d, 0x97, 0x46, 0xa7, 0xfa, 0xd4, 0x88, 0x0a,
-	0x8e, 0x58, 0xe6, 0xdd, 0x8f, 0xb2, 0xe3, 0xd4, 0x9f, 0x15, 0x41, 0x4e, 0xbb, 0x94, 0x7c, 0x01,
-	0x4d, 0xcb, 0x7b, 0xe5, 0x3a, 0x9e, 0x61, 0xe9, 0x2c, 0x05, 0x8b, 0x53, 0xbc, 0x3a, 0x15, 0xd1,
-	0xbb, 0x22, 0xfd, 0x6a, 0x72, 0xac, 0xcf, 0x62, 0x9c, 0x7c, 0x0e, 0xb2, 0xcf, 0xe7, 0xe3, 0xc3,
-	0x8b, 0xe7, 0x0d, 0x6f, 0x08, 0x75, 0x1c, 0xfd, 0x29, 0x34, 0x46, 0xfe, 0x78, 0xed, 0xd2, 0x79,
-	0x83, 0x81, 0x6b, 0xe3, 0xd8, 0x5b, 0xd0, 0x4a, 0x2c, 0xef, 0x9d, 0x46, 0x34, 0x44, 0x5f, 0x95,
-	0xb5, 0x64, 0x3f, 0xdb, 0x4c, 0x48, 0x6e, 0x80, 0x2c, 0x96, 0xe0, 0x4a, 0x15, 0x54, 0x12, 0xcb,
-	0xa2, 0x8a, 0xfa, 0xeb, 0x22, 0x2c, 0x27, 0xe7, 0x98, 0xf1, 0xce, 0x83, 0x7c, 0xef, 0x08, 0xd0,
-	0x8a, 0x87, 0x4c, 0xb8, 0xe4, 0xa3, 0x5c, 0x97, 0x4c, 0x8e, 0xc9, 0xf8, 0xe1, 0x7e, 0x9e, 0x1f,
-	0x26, 0x47, 0xa4, 0x37, 0xff, 0x1f, 0xb9, 0x9b, 0x9f, 0x1e, 0x33, 0xe1, 0x8c, 0x8f, 0x72, 0x9c,
-	0x91, 0x63, 0x5a, 0xda, 0x39, 0xff, 0x28, 0x80, 0xfc, 0x7f, 0x5e, 0xf0, 0x92, 0x06, 0xcc, 0x25,
-	0xa3, 0x90, 0xdc, 0x05, 0xe9, 0x15, 0xb6, 0xf5, 0x04, 0x38, 0xe4, 0xb7, 0x6f, 0xd6, 0xea, 0x5c,
-	0x69, 0x7f, 0x57, 0xab, 0xf3, 0xee, 0x7d, 0x8b, 0xac, 0x43, 0xf5, 0x85, 0xd7, 0x63, 0x7a, 0x88,
-	0x97, 0xdb, 0xd2, 0xdb, 0x37, 0x6b, 0x15, 0x06, 0xb8, 0xbb, 0x5a, 0xe5, 0x85, 0xd7, 0xdb, 0xb7,
-	0x18, 0x48, 0x63, 0x88, 0x96, 0x52, 0xb1, 0x96, 0xa0, 0x19, 0x8f, 0x51, 0xf2, 0x31, 0xd4, 0x30,
-	0x87, 0x50, 0x4b, 0x6c, 0x72, 0x56, 0xba, 0x89, 0x55, 0xc7, 0x68, 0x52, 0x39, 0x07, 0x4d, 0xae,
-	0x03, 0x7c, 0x3b, 0xa2, 0x23, 0xaa, 0x87, 0xf6, 0x77, 0x14, 0x13, 0x6d, 0x49, 0x93, 0x50, 0x72,
-	0x64, 0x7f, 0x47, 0xd5, 0x03, 0x90, 0x35, 0x1a, 0x7a, 0xa3, 0xc0, 0xa4, 0x08, 0xd9, 0x8c, 0xbf,
-	0xf9, 0x23, 0xdc, 0x78, 0x51, 0x63, 0x9f, 0x2c, 0x9c, 0x87, 0x74, 0xe8, 0x05, 0xa7, 0x22, 0x2b,
-	0x88, 0x16, 0xd3, 0x1c, 0xf8, 0x23, 0x3c, 0xcc, 0x92, 0xc6, 0x3e, 0xd5, 0xdf, 0x4a, 0x50, 0xc3,
-	0x7c, 0xd3, 0xf7, 0x62, 0x80, 0x2d, 0xe4, 0x00, 0x2c, 0xf9, 0x00, 0xa4, 0x28, 0x66, 0x80, 0x99,
-	0xeb, 0x93, 0xf0, 0x42, 0x6d, 0xac, 0x40, 0xee, 0x42, 0xdd, 0xb7, 0x7d, 0xea, 0xd8, 0x6e, 0x7c,
-	0x73, 0x9a, 0x7c, 0xb3, 0x42, 0xa8, 0x25, 0xdd, 0xe4, 0x7d, 0x00, 0xdf, 0x08, 0xa8, 0x1b, 0xe9,
-	0x6c, 0xed, 0xea, 0xc4, 0xda, 0x12, 0xef, 0x63, 0xf4, 0x2a, 0xe5, 0xf3, 0xda, 0xc5, 0x7d, 0xfe,
-	0x10, 0xea, 0x7d, 0xdb, 0xb5, 0xc3, 0x63, 0x6a, 0x29, 0xf5, 0x73, 0x87, 0x25, 0xba, 0xe4, 0x43,
-	0x68, 0x7a, 0xa3, 0xc8, 0x1f, 0x45, 0x31, 0xa7, 0x91, 0xa6, 0x33, 0xb0, 0xcc, 0x35, 0x78, 0x8b,
-	0xdc, 0x8c, 0x53, 0x0a, 0x60, 0x4a, 0x69, 0xc6, 0x7b, 0xc8, 0x24, 0x94, 0x2f, 0xa1, 0xed, 0x8f,
-	0x13, 0xae, 0x8e, 0x2c, 0x46, 0xc6, 0x99, 0x97, 0xb8, 0x83, 0xb2, 0xd9, 0x58, 0x9b, 0xf7, 0x27,
-	0xd2, 0xf3, 0x5d, 0x68, 0xc7, 0xae, 0xd3, 0x4f, 0x68, 0x10, 0x32, 0xbe, 0xd1, 0x44, 0xfc, 0x98,
-	0x8f, 0xe5, 0xdf, 0x70, 0x31, 0xb9, 0xcd, 0x98, 0x39, 0xf2, 0x4e, 0xa5, 0x85, 0x4b, 0xc8, 0x82,
-	0x99, 0xa3, 0x4c, 0x8b, 0x3b, 0x19, 0xcb, 0xa0, 0x48, 0x6d, 0x95, 0xf9, 0x78, 0x8f, 0x7e, 0xb8,
-	0xc1, 0xd9, 0xae, 0x26, 0xba, 0x18, 0x29, 0x15, 0xfe, 0x10, 0x04, 0x72, 0x01, 0x2f, 0x96, 0x70,
-	0xc1, 0x36, 0xa7, 0x91, 0xf7, 0xa0, 0x21, 0x94, 0x90, 0xca, 0x91, 0x54, 0x1e, 0xd4, 0xa8, 0xef,
-	0x69, 0xc0, 0x7b, 0xd9, 0x37, 0x51, 0xa0, 0x16, 0x50, 0xce, 0xd8, 0x96, 0xd0, 0xfe, 0xb8, 0x89,
-	0x28, 0x6a, 0x44, 0x86, 0x2e, 0xd0, 0x88, 0x5a, 0xca, 0x0a, 0xde, 0xd7, 0x26, 0x93, 0x76, 0x63,
-	0x21, 0x0b, 0x12, 0x54, 0x8b, 0xbc, 0xc8, 0x70, 0x94, 0x2b, 0x3c, 0x48, 0x98, 0xe4, 0x19, 0x13,
-	0x90, 0x87, 0xd0, 0x14, 0x98, 0x10, 0x22, 0x48, 0x28, 0x0a, 0xc6, 0x33, 0x0f, 0xba, 0x34, 0x7a,
-	0x68, 0xf2, 0xab, 0x34, 0x96, 0x3c, 0x84, 0x66, 0x20, 0x82, 0x8b, 0x1f, 0xcf, 0xd5, 0x54, 0xb0,
-	0xa6, 0xc3, 0x4e, 0x93, 0x83, 0x74, 0x10, 0xae, 0x43, 0xc5, 0x66, 0x28, 0xa1, 0x74, 0x52, 0x9c,
-	0x43, 0xb0, 0x3f, 0xec, 0x20, 0x1b, 0x00, 0x2e, 0x7d, 0x15, 0xfb, 0xef, 0x1a, 0xaa, 0xcd, 0xa3,
-	0x73, 0xb8, 0xfb, 0x78, 0x2e, 0x77, 0xe9, 0x2b, 0xe1, 0xcd, 0x75, 0x68, 0xd8, 0xae, 0x19, 0xd0,
-	0x21, 0x75, 0xd9, 0x0e, 0xdf, 0x43, 0x2e, 0x97, 0x16, 0x91, 0x0d, 0x90, 0x11, 0x30, 0xe2, 0x3b,
-	0x7a, 0x7d, 0xfa, 0x8e, 0x36, 0x50, 0x41, 0x5c, 0xd1, 0x1b, 0x20, 0xa3, 0xcb, 0xc2, 0x97, 0xb6,
-	0xef, 0x53, 0x4b, 0x59, 0x45, 0xa7, 0x35, 0x98, 0xec, 0x88, 0x8b, 0xc6, 0x18, 0xb5, 0x76, 0x0e,
-	0x46, 0xdd, 0x00, 0x99, 0xba, 0x46, 0xcf, 0xa1, 0x3a, 0xd7, 0x5f, 0xe7, 0xe6, 0x71, 0x19, 0x4f,
-	0x55, 0x8c, 0xa6, 0x1b, 0x4e, 0xa4, 0xdc, 0x10, 0x34, 0xdd, 0x70, 0x22, 0x46, 0x49, 0x7a, 0x46,
-	0x64, 0x1e, 0x2b, 0x2a, 0x7f, 0xc3, 0x61, 0x83, 0xe1, 0x55, 0x40, 0x8d, 0xd0, 0x73, 0x95, 0x9b,
-	0x1c, 0xaf, 0x78, 0xeb, 0xa0, 0x5c, 0x2f, 0xb7, 0x2b, 0x07, 0xe5, 0x7a, 0xa5, 0x5d, 0x55, 0x77,
-	0xa1, 0xca, 0x8f, 0x2d, 0xf7, 0xf9, 0x70, 0x3b, 0x4b, 0xe8, 0xda, 0x13, 0xc7, 0x1c, 0x07, 0xa0,
-	0xfa, 0x40, 0xd0, 0x6b, 0xc6, 0xad, 0xde, 0x87, 0x3a, 0xe6, 0x82, 0x31, 0xb3, 0x92, 0xe3, 0xa0,
-	0xc5, 0xb3, 0xa8, 0xbd, 0xe0, 0x1f, 0xea, 0x2a, 0xd4, 0x63, 0xe4, 0xca, 0x5b, 0x5c, 0xfd, 0xbe,
-	0x00, 0xcd, 0x58, 0x81, 0x33, 0xf7, 0xeb, 0xe2, 0x35, 0x53, 0x98, 0x0c, 0x81, 0xc9, 0x77, 0x58,
-	0x31, 0xf3, 0x0e, 0x8b, 0xb9, 0x7c, 0x29, 0x87, 0xcb, 0x97, 0x73, 0xb8, 0x7c, 0x25, 0xe5, 0x81,
-	0x35, 0x28, 0xb3, 0x07, 0x97, 0x80, 0xd0, 0xcc, 0x25, 0xc0, 0x0e, 0xf5, 0x17, 0x75, 0x90, 0xc7,
-	0x56, 0xf6, 0xbd, 0x0c, 0x4a, 0x17, 0x66, 0xa3, 0xf4, 0xe5, 0xe0, 0xff, 0xbf, 0x00, 0xcc, 0x80,
-	0x1a, 0x11, 0xb5, 0x74, 0x23, 0x12, 0x06, 0xcd, 0x82, 0x5d, 0x49, 0x68, 0x6f, 0x45, 0xe4, 0x4e,
-	0x7c, 0x8e, 0x35, 0x3c, 0x47, 0x92, 0x31, 0x28, 0x03, 0xa5, 0x37, 0x40, 0x0e, 0x28, 0x23, 0x91,
-	0x3a, 0x0d, 0x02, 0x2f, 0x40, 0x74, 0x97, 0xb4, 0x06, 0x97, 0xed, 0x31, 0x11, 0xf9, 0x12, 0x80,
-	0x1d, 0x30, 0xd2, 0x5e, 0x5e, 0x12, 0x68, 0x6c, 0xae, 0x67, 0x66, 0x64, 0x7e, 0x60, 0xe7, 0xbd,
-	0x83, 0x2a, 0xbc, 0xac, 0x21, 0xbd, 0x88, 0xdb, 0xb9, 0x70, 0x0d, 0x97, 0x81, 0x6b, 0x05, 0x6a,
-	0x31, 0x4a, 0x37, 0x38, 0xca, 0x89, 0xe6, 0x8f, 0x44, 0xdd, 0x76, 0x0e, 0xea, 0xf2, 0xf7, 0xd2,
-	0xc2, 0xd4, 0x7b, 0xe9, 0x09, 0x2c, 0x85, 0xa6, 0xe1, 0x50, 0x9d, 0x11, 0x2e, 0x3d, 0x3a, 0x0e,
-	0x68, 0x78, 0xec, 0x39, 0x96, 0x80, 0xe5, 0x19, 0x94, 0x96, 0xe0, 0xb0, 0x5d, 0xef, 0x95, 0xfb,
-	0x2c, 0x1e, 0x34, 0x0d, 0x8b, 0x8b, 0x97, 0x84, 0xc5, 0xa5, 0xb3, 0x60, 0x71, 0x1d, 0x1a, 0x16,
-	0x0d, 0xcd, 0xc0, 0xf6, 0xd9, 0xe2, 0xca, 0x32, 0x3f, 0xc6, 0x94, 0x68, 0x12, 0x08, 0x57, 0xa6,
-	0x81, 0xf0, 0x3a, 0x80, 0x69, 0x98, 0xc7, 0x82, 0x30, 0x5d, 0xe1, 0xf5, 0x32, 0x94, 0x30, 0xc2,
-	0x34, 0x85, 0x55, 0xca, 0xd9, 0x58, 0x75, 0x35, 0x85, 0x55, 0xab, 0x6c, 0x56, 0xdf, 0xe8, 0xd9,
-	0x8e, 0x1d, 0x9d, 0x22, 0xae, 0x4b, 0x5a, 0x4a, 0x32, 0xc6, 0xb2, 0x6b, 0xf9, 0x58, 0xf6, 0x5e,
-	0x1a, 0xcb, 0xc8, 0xbf, 0x41, 0x6b, 0x68, 0xbc, 0xd6, 0x53, 0xc4, 0xee, 0x3a, 0xc2, 0xaf, 0x3c,
-	0x34, 0x5e, 0xff, 0x6f, 0xcc, 0xed, 0xd2, 0x49, 0x7b, 0x75, 0x46, 0xd2, 0xee, 0x7c, 0x0e, 0xad,
-	0xec, 0xb5, 0x4d, 0x57, 0xb3, 0x2a, 0x39, 0xd5, 0xac, 0x4a, 0xaa, 0x9a, 0x75, 0x50, 0xae, 0x97,
-	0xda, 0x65, 0x8e, 0xae, 0xea, 0xe3, 0x34, 0x76, 0x31, 0x58, 0x7c, 0x08, 0xcd, 0x84, 0x62, 0xa4,
-	0xb0, 0x71, 0x61, 0x2a, 0x70, 0x34, 0xd9, 0x4f, 0xb5, 0xd4, 0xef, 0x2b, 0xd0, 0xde, 0xc1, 0x40,
-	0x66, 0xcc, 0x8d, 0x7e, 0x3b, 0xa2, 0x61, 0x94, 0x05, 0x8e, 0xc2, 0x65, 0x78, 0x63, 0x71, 0x36,
-	0x22, 0xe5, 0x85, 0x66, 0xed, 0x32, 0xa1, 0x99, 0xf2, 0x74, 0xfd, 0x62, 0xf4, 0x48, 0x3a, 0x3b,
-	0x50, 0xf3, 0x68, 0x19, 0xe4, 0xd3, 0xb2, 0xa9, 0x98, 0x6e, 0x9c, 0xcf, 0xa4, 0xe4, 0x59, 0x4c,
-	0x2a, 0xcb, 0xa0, 0x9b, 0x67, 0x33, 0xe8, 0xa9, 0x18, 0x6e, 0x5d, 0x32, 0x86, 0xe7, 0x2f, 0x46,
-	0x6d, 0xda, 0x97, 0xa5, 0x36, 0x0b, 0xd3, 0x11, 0x3d, 0x19, 0xb2, 0xe4, 0xec, 0x90, 0x5d, 0xcc,
-	0xa3, 0x17, 0x4b, 0xa9, 0x90, 0xcc, 0x5c, 0xf7, 0x2e, 0x2c, 0xec, 0xbb, 0x6c, 0xf7, 0x51, 0xea,
-	0x96, 0xce, 0x7a, 0xf9, 0xac, 0x41, 0xa3, 0xe7, 0x78, 0xe6, 0x4b, 0x7d, 0xcc, 0x2f, 0xea, 0x1a,
-	0xa0, 0x08, 0xf3, 0x91, 0xfa, 0x9b, 0x02, 0xb4, 0x0e, 0xed, 0x30, 0x3d, 0xdf, 0x25, 0x32, 0xeb,
-	0x06, 0xc8, 0xe8, 0xc3, 0x98, 0xc3, 0x15, 0xe3, 0x22, 0x7a, 0x8a, 0xc3, 0xa1, 0x82, 0xe0, 0x70,
-	0x53, 0x0f, 0x93, 0xd2, 0x39, 0x0f, 0x13, 0x75, 0x03, 0xda, 0xbb, 0xd4, 0xa1, 0x99, 0xb0, 0x9c,
-	0xb1, 0x61, 0xf5, 0x03, 0x68, 0x1d, 0x45, 0x9e, 0x7f, 0x41, 0xed, 0x3f, 0x14, 0xa0, 0xf5, 0x98,
-	0x46, 0x87, 0xde, 0x20, 0xbc, 0x88, 0x37, 0x2f, 0x11, 0xe1, 0x31, 0x5b, 0xed, 0xdb, 0x4e, 0x44,
-	0x83, 0x10, 0x1f, 0xe4, 0x12, 0x67, 0xab, 0x8f, 0xb8, 0x08, 0xdf, 0xb9, 0x46, 0x18, 0xd1, 0x00,
-	0x99, 0x50, 0x5d, 0x13, 0xad, 0x71, 0x01, 0xb0, 0x7a, 0x46, 0x01, 0x50, 0x5c, 0x86, 0x3f, 0x16,
-	0x01, 0x0e, 0xbd, 0xc1, 0xff, 0xd0, 0x30, 0x34, 0x06, 0x58, 0x9e, 0x4f, 0xa2, 0x38, 0x45, 0xf2,
-	0x12, 0x98, 0x7b, 0xca, 0x78, 0xd6, 0xb8, 0x82, 0x50, 0x3a, 0xa7, 0x82, 0x50, 0x9e, 0x51, 0x41,
-	0xb8, 0x07, 0xc5, 0xa4, 0x10, 0x30, 0x8b, 0x1a, 0x15, 0xa3, 0x90, 0x91, 0x88, 0x21, 0xb7, 0x10,
-	0xf7, 0x23, 0x69, 0x71, 0x33, 0x5b, 0xf8, 0xa8, 0xcd, 0x2c, 0x7c, 0x10, 0x28, 0x8f, 0x42, 0xca,
-	0x69, 0x52, 0x5d, 0xc3, 0x6f, 0x72, 0x1b, 0xea, 0xa2, 0xb8, 0x68, 0x21, 0xb8, 0x49, 0xdb, 0x8d,
-	0xb7, 0x6f, 0xd6, 0x6a, 0xbc, 0xb2, 0xb8, 0xab, 0xd5, 0x78, 0x5d, 0xd1, 0x4a, 0xb9, 0x19, 0xd2,
-	0x6e, 0x56, 0x9f, 0xc1, 0xa2, 0xc6, 0x1f, 0x6d, 0xdc, 0xb7, 0x17, 0x38, 0xff, 0xc9, 0x43, 0x2d,
-	0x4e, 0x1d, 0xaa, 0xfa, 0x9f, 0xb0, 0x28, 0x22, 0x34, 0x33, 0xeb, 0xb9, 0x45, 0x5d, 0x55, 0x87,
-	0x36, 0x8b, 0xc3, 0x0b, 0xdb, 0x72, 0x0d, 0x24, 0xdf, 0x18, 0x88, 0x64, 0x5c, 0xc4, 0x64, 0x5c,
-	0x67, 0x02, 0x4c, 0xc4, 0x58, 0xb6, 0x1e, 0x50, 0x51, 0x2b, 0xc1, 0x6f, 0xf5, 0x14, 0x16, 0x52,
-	0x0b, 0x84, 0xbe, 0xe7, 0x86, 0x58, 0x28, 0x1b, 0x57, 0x68, 0xc3, 0x33, 0x4a, 0xb4, 0x60, 0x8d,
-	0x4b, 0xba, 0x6b, 0xd0, 0xc0, 0x37, 0xab, 0xce, 0xe6, 0x0c, 0xc5, 0xc2, 0x80, 0xa2, 0x2e, 0x93,
-	0xe4, 0x2e, 0xfd, 0xe7, 0x0a, 0x2c, 0xf3, 0xe4, 0x9a, 0x44, 0xca, 0xe5, 0xb1, 0xe6, 0x72, 0x2c,
-	0x7e, 0x05, 0xaa, 0x23, 0xdf, 0x62, 0x98, 0x27, 0x82, 0x8b, 0xb7, 0xde, 0x3d, 0xf3, 0x5e, 0x28,
-	0xa3, 0x4e, 0xa5, 0x49, 0xc8, 0x49, 0x93, 0x67, 0x51, 0xdc, 0xc6, 0xbf, 0x84, 0xe2, 0xca, 0x97,
-	0x4c, 0x8f, 0xcd, 0x0b, 0x52, 0xdc, 0xd6, 0xb9, 0x14, 0x77, 0xfe, 0x3c, 0x8a, 0xdb, 0x3e, 0x8f,
-	0xe2, 0x2e, 0x4c, 0xe7, 0xcb, 0xf7, 0x40, 0x0a, 0xa8, 0x28, 0xaa, 0x88, 0x7c, 0x3a, 0x16, 0x8c,
-	0x33, 0xe7, 0x62, 0x9a, 0xcc, 0x4e, 0x93, 0xd6, 0xa5, 0xd9, 0xa4, 0x75, 0x79, 0x06, 0x95, 0xca,
-	0xe4, 0xe1, 0x1d, 0x58, 0x11, 0x51, 0xfe, 0xe3, 0x2f, 0xb4, 0xba, 0x0c, 0x8b, 0x2c, 0x20, 0x27,
-	0x66, 0x50, 0x7f, 0x59, 0x80, 0x65, 0x9e, 0xf2, 0xde, 0x21, 0x58, 0xd6, 0xd8, 0xa1, 0xb1, 0x39,
-	0x18, 0xad, 0x0a, 0xe3, 0xbc, 0x6f, 0xc5, 0x99, 0x34, 0x4c, 0x29, 0x20, 0x47, 0x2b, 0xa5, 0x15,
-	0x90, 0x98, 0xb5, 0xa1, 0x64, 0x38, 0x8e, 0x78, 0xb8, 0xb3, 0x4f, 0x75, 0x0b, 0x96, 0x8e, 0x18,
-	0x5c, 0xbe, 0xc3, 0x96, 0xbf, 0x82, 0x45, 0x96, 0x9d, 0xdf, 0x61, 0x86, 0x9f, 0x14, 0x60, 0x49,
-	0xa3, 0xc1, 0xc8, 0x7d, 0x07, 0xe7, 0xdc, 0x82, 0x1a, 0x7d, 0x6d, 0x3a, 0x23, 0x8b, 0xe6, 0x11,
-	0x96, 0xb8, 0x8f, 0xa9, 0xd9, 0x2e, 0x57, 0x2b, 0xe5, 0xa8, 0x89, 0x3e, 0xf5, 0x0a, 0x2c, 0x3f,
-	0x36, 0x82, 0x9e, 0x31, 0xa0, 0x3b, 0x9e, 0xe3, 0x50, 0x33, 0x8a, 0x0f, 0x52, 0x81, 0x95, 0xc9,
-	0x0e, 0x8e, 0xba, 0xf7, 0x74, 0xac, 0xe3, 0xf0, 0x1f, 0xd5, 0xda, 0x20, 0x1f, 0x7c, 0xbd, 0xad,
-	0x1f, 0x3d, 0xdb, 0xd2, 0x9e, 0xed, 0x3f, 0x7d, 0xdc, 0x9e, 0x23, 0xf3, 0xd0, 0x60, 0x12, 0xed,
-	0xf9, 0xd3, 0xa7, 0x4c, 0x50, 0x88, 0x05, 0x8f, 0xb6, 0xf6, 0x0f, 0x9f, 0x6b, 0x7b, 0xed, 0x62,
-	0x2c, 0x38, 0x7a, 0xbe, 0xb3, 0xb3, 0x77, 0x74, 0xd4, 0x2e, 0x91, 0x16, 0x00, 0x13, 0x3c, 0xd9,
-	0x3f, 0x3c, 0xdc, 0xdb, 0x6d, 0x97, 0xef, 0x7d, 0x25, 0x7e, 0x86, 0xe3, 0x4b, 0x00, 0x54, 0xd9,
-	0xd8, 0xbd, 0xdd, 0xf6, 0x1c, 0x69, 0x40, 0x2d, 0x1e, 0x56, 0xc0, 0xc6, 0x93, 0xfd, 0x6e, 0x77,
-	0x6f, 0xb7, 0x5d, 0x24, 0x32, 0xd4, 0x13, 0x23, 0x4a, 0xf7, 0xbe, 0x84, 0x46, 0xaa, 0x00, 0xc5,
-	0x56, 0xec, 0x7e, 0xbd, 0x9b, 0xd8, 0x34, 0x17, 0x0b, 0xc6, 0x73, 0xb5, 0x00, 0x98, 0x40, 0x2c,
-	0x54, 0xbc, 0xf7, 0xff, 0xa9, 0xb2, 0x12, 0x9f, 0x63, 0x19, 0x16, 0xba, 0xfb, 0xdd, 0xbd, 0xc3,
-	0xfd, 0xa7, 0x7b, 0xe9, 0xed, 0x2e, 0x41, 0x3b, 0x11, 0x8f, 0xf7, 0x7c, 0x05, 0x16, 0xc7, 0xd2,
-	0xbd, 0x44, 0xbd, 0x98, 0x51, 0x8f, 0x3d, 0x52, 0x22, 0x8b, 0x30, 0x9f, 0x48, 0xbb, 0x5b, 0xcf,
-	0x8f, 0x98, 0x17, 0x36, 0xff, 0x5e, 0x87, 0xd2, 0x56, 0x77, 0x9f, 0x6c, 0x80, 0x94, 0x3c, 0xed,
-	0xc8, 0xb2, 0xf8, 0xe1, 0x3a, 0xfb, 0xd4, 0xeb, 0x24, 0xd9, 0x55, 0x9d, 0x23, 0x1f, 0x03, 0x8c,
-	0x59, 0x36, 0x59, 0x11, 0x90, 0x38, 0x41, 0xbb, 0x3b, 0x99, 0x72, 0x9b, 0x3a, 0x47, 0xee, 0x43,
-	0x4d, 0x10, 0x69, 0xb2, 0x88, 0x5d, 0x59, 0x5a, 0xdd, 0x69, 0xa6, 0xf5, 0x43, 0x75, 0x8e, 0x7c,
-	0x0e, 0x52, 0x42, 0x6d, 0x85, 0x59, 0x93, 0x54, 0xb7, 0xb3, 0x32, 0x95, 0x05, 0xf6, 0x86, 0x7e,
-	0x74, 0xaa, 0xce, 0x91, 0x4f, 0xa0, 0x26, 0x88, 0xae, 0x58, 0x2e, 0x4b, 0x7b, 0x67, 0x8c, 0xfc,
-	0x14, 0xe4, 0x34, 0x45, 0x21, 0x4a, 0x7a, 0x83, 0x69, 0xfe, 0xd1, 0x99, 0x20, 0x02, 0xdc, 0xe6,
-	0x84, 0x44, 0x08, 0x9b, 0x27, 0x59, 0x4b, 0x67, 0x65, 0x52, 0xcc, 0x6f, 0xbd, 0x3a, 0x47, 0xb6,
-	0xf1, 0xb7, 0x9f, 0x84, 0x72, 0x89, 0x95, 0x73, 0x58, 0xd8, 0x0c, 0xeb, 0x1f, 0x41, 0x2b, 0x4b,
-	0x25, 0x48, 0x27, 0x75, 0xa2, 0x13, 0xa8, 0x30, 0x63, 0x9e, 0x1d, 0x98, 0x9f, 0x80, 0x70, 0x72,
-	0x2d, 0xed, 0x88, 0xc9, 0x99, 0xa6, 0x2b, 0x08, 0xea, 0x1c, 0xf9, 0x02, 0xe4, 0x34, 0x84, 0x8b,
-	0x0d, 0xe5, 0xa0, 0x7a, 0x87, 0x4c, 0x0d, 0x0f, 0xf9, 0x66, 0xb2, 0x50, 0x2f, 0x36, 0x93, 0x8b,
-	0xff, 0x33, 0x36, 0xb3, 0x0b, 0xcd, 0x0c, 0x34, 0x93, 0xab, 0xe2, 0x4a, 0x4c, 0xc3, 0xf5, 0x8c,
-	0x59, 0xb6, 0x41, 0x4e, 0xa3, 0xb3, 0xd8, 0x4d, 0x0e, 0x60, 0xcf, 0xb6, 0x24, 0x03, 0xcf, 0xc2,
-	0x92, 0x3c, 0xc8, 0x9e, 0x31, 0xcb, 0x7f, 0xc7, 0xa1, 0xb1, 0xe5, 0x38, 0xe4, 0x0c, 0xb5, 0x19,
-	0xc3, 0x1f, 0x40, 0x4d, 0xbc, 0xea, 0x44, 0x6c, 0x64, 0xdf, 0x78, 0x1d, 0xfe, 0x1f, 0x13, 0xe3,
-	0xb7, 0x93, 0x3a, 0xf7, 0x61, 0x81, 0x3c, 0x81, 0x56, 0x16, 0xae, 0xc5, 0x59, 0xe4, 0x82, 0x7b,
-	0xe7, 0x5a, 0x6e, 0x5f, 0x7c, 0xd3, 0xb7, 0xdb, 0x3f, 0xbc, 0x5d, 0x2d, 0xfc, 0xe5, 0xed, 0x6a,
-	0xe1, 0xaf, 0x6f, 0x57, 0x0b, 0xbf, 0xfa, 0xdb, 0xea, 0x5c, 0xaf, 0x8a, 0x56, 0x3e, 0xf8, 0x67,
-	0x00, 0x00, 0x00, 0xff, 0xff, 0xf8, 0xc9, 0x91, 0x3c, 0xe1, 0x27, 0x00, 0x00,
+	// 3334 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x5a, 0xcd, 0x6f, 0xdb, 0x48,
+	0x96, 0xb7, 0x44, 0x7d, 0xf1, 0x49, 0x96, 0xe5, 0xf2, 0x47, 0x18, 0xa5, 0x63, 0x3b, 0xcc, 0xe6,
+	0x13, 0xbd, 0x4e, 0xb7, 0xd3, 0x9b, 0xed, 0xed, 0xee, 0xed, 0x6e, 0x7f, 0x25, 0xb0, 0xe3, 0x4d,
+	0x6b, 0xe9, 0xa4, 0xf7, 0x48, 0x50, 0x64, 0x49, 0x66, 0x42, 0x91, 0x6c, 0x92, 0x72, 0xe2, 0x3e,
+	0xed, 0x7f, 0xb0, 0xd8, 0x5d, 0x60, 0x30, 0x18, 0x60, 0x4e, 0x7d, 0x98, 0xcb, 0x1c, 0xe6, 0x3c,
+	0x98, 0x39, 0x0e, 0xd0, 0xc7, 0xf9, 0x0b, 0x82, 0x41, 0xe6, 0x8f, 0x98, 0xb9, 0x0c, 0x30, 0xa8,
+	0x57, 0x45, 0x8a, 0x94, 0x68, 0xc9, 0xee, 0xcc, 0xc1, 0x00, 0xeb, 0xd5, 0xab, 0x8f, 0xf7, 0x5e,
+	0xbd, 0xdf, 0xfb, 0x55, 0xc9, 0xb0, 0x6c, 0x3a, 0x36, 0x75, 0xa3, 0x07, 0xbe, 0x1f, 0xb2, 0xbf,
+	0x4d, 0x3f, 0xf0, 0x22, 0x8f, 0x48, 0xbe, 0x1f, 0xb6, 0xaf, 0xf5, 0x3d, 0xaf, 0xef, 0xd0, 0x07,
+	0x28, 0xea, 0x0e, 0x7b, 0x0f, 0xe8, 0xc0, 0x8f, 0xce, 0xb8, 0x46, 0x7b, 0x7d, 0xbc, 0x33, 0xb2,
+	0x07, 0x34, 0x8c, 0x8c, 0x81, 0x2f, 0x14, 0xd6, 0xc6, 0x15, 0xac, 0x61, 0x60, 0x44, 0xb6, 0xe7,
+	0x8a, 0xfe, 0xe5, 0xbe, 0xd7, 0xf7, 0xf0, 0xf3, 0x01, 0xfb, 0x8a, 0xa5, 0xf1, 0x76, 0x7a, 0x21,
+	0xfb, 0xe3, 0x52, 0xb5, 0x07, 0x95, 0x63, 0x6a, 0x06, 0x34, 0x22, 0x04, 0x4a, 0xae, 0x31, 0xa0,
+	0x4a, 0x61, 0xa3, 0x70, 0x57, 0xd6, 0xf0, 0x9b, 0x5c, 0x07, 0x18, 0x78, 0x43, 0x37, 0xd2, 0x7d,
+	0x23, 0x3a, 0x51, 0x8a, 0xd8, 0x23, 0xa3, 0xa4, 0x63, 0x44, 0x27, 0xe4, 0x0a, 0x54, 0xa9, 0x7b,
+	0xaa, 0x9f, 0x1a, 0x81, 0x22, 0x61, 0x5f, 0x85, 0xba, 0xa7, 0xdf, 0x1a, 0x01, 0x69, 0x81, 0xf4,
+	0x8a, 0x9e, 0x29, 0x25, 0x14, 0xb2, 0x4f, 0xf5, 0x0f, 0x45, 0x90, 0x9f, 0x07, 0x86, 0x1b, 0xf6,
+	0xbc, 0x60, 0x40, 0x96, 0xa1, 0x6c, 0x0f, 0x8c, 0x7e, 0xbc, 0x18, 0x6f, 0xb0, 0x51, 0xe6, 0xc0,
+	0x52, 0x8a, 0x1b, 0x12, 0x1b, 0x65, 0x0e, 0x2c, 0x72, 0x0f, 0x24, 0xea, 0x9e, 0x2a, 0xd2, 0x86,
+	0x74, 0xb7, 0xbe, 0x75, 0x65, 0x93, 0x79, 0x31, 0x99, 0x64, 0x73, 0xdf, 0x3d, 0xdd, 0x77, 0xa3,
+	0xe0, 0x4c, 0x63, 0x3a, 0xe4, 0x16, 0x54, 0x43, 0x34, 0x24, 0x54, 0x4a, 0xa8, 0x5e, 0x47, 0x75,
+	0x6e, 0x9c, 0x16, 0xf7, 0xb1, 0x95, 0xc3, 0xc8, 0xb2, 0x5d, 0xa5, 0x8c, 0xab, 0xf0, 0x06, 0xf9,
+	0x10, 0x88, 0x61, 0x9a, 0xd4, 0x8f, 0xf4, 0x80, 0x46, 0xc3, 0xc0, 0xd5, 0x4d, 0xcf, 0xa2, 0x4a,
+	0x65, 0x43, 0xba, 0x2b, 0x69, 0x2d, 0xde, 0xa3, 0x61, 0xc7, 0xae, 0x67, 0x51, 0x36, 0x87, 0x45,
+	0xbb, 0xc3, 0xbe, 0x52, 0xdd, 0x28, 0xdc, 0xad, 0x69, 0xbc, 0xc1, 0xe6, 0x40, 0x33, 0x74, 0x7f,
+	0xe8, 0x38, 0x7a, 0xbc, 0x17, 0x19, 0x97, 0x69, 0x61, 0x4f, 0x67, 0xe8, 0x38, 0x7c, 0x3f, 0x61,
+	0xfb, 0x11, 0xd4, 0xe2, 0xfd, 0xc7, 0xde, 0x2a, 0x24, 0xde, 0x62, 0x2b, 0x9c, 0x1a, 0xce, 0x90,
+	0x0a, 0x97, 0xf3, 0xc6, 0x67, 0xc5, 0x4f, 0x0b, 0x6a, 0x1b, 0x2a, 0xfb, 0xfd, 0x80, 0x86, 0x21,
+	0x1b, 0xf5, 0x42, 0x3b, 0x8a, 0x47, 0xbd, 0xd0, 0x8e, 0xd4, 0xeb, 0x20, 0x1d, 0x7a, 0x5d, 0xb2,
+	0x0a, 0x45, 0xdb, 0xe2, 0xf2, 0x9d, 0xca, 0xbb, 0xb7, 0xeb, 0xc5, 0x83, 0x3d, 0xad, 0x68, 0x5b,
+	0xea, 0x31, 0x54, 0x8f, 0x69, 0x70, 0x6a, 0x9b, 0x94, 0xdc, 0x84, 0x79, 0xdb, 0x8d, 0x68, 0xe0,
+	0x1a, 0x8e, 0xee, 0x7b, 0x41, 0x84, 0xda, 0x65, 0xad, 0x11, 0x0b, 0x3b, 0x5e, 0x10, 0x31, 0x25,
+	0xfa, 0x26, 0xad, 0x54, 0xe4, 0x4a, 0xf4, 0xcd, 0x48, 0x49, 0xfd, 0x75, 0x01, 0xe4, 0xed, 0xc8,
+	0x1b, 0x1c, 0xb8, 0xfe, 0x30, 0xff, 0x0c, 0x11, 0x28, 0x05, 0xd4, 0xf7, 0x84, 0x29, 0xf8, 0x4d,
+	0x56, 0xa1, 0xd2, 0x0d, 0x0c, 0xd7, 0x3c, 0x89, 0xcf, 0x0d, 0x6f, 0x31, 0xb9, 0xe9, 0x0d, 0x06,
+	0x76, 0x24, 0x8e, 0x8e, 0x68, 0xb1, 0x39, 0xfa, 0x8e, 0xd7, 0x55, 0xca, 0x7c, 0x0e, 0xf6, 0xcd,
+	0x64, 0x8e, 0xf1, 0xfd, 0x99, 0x52, 0xc1, 0x20, 0xe0, 0x37, 0x59, 0x87, 0x7a, 0x2f, 0xf0, 0x06,
+	0xba, 0x98, 0xa4, 0x8a, 0xea, 0xc0, 0x44, 0xbb, 0x28, 0x51, 0xff, 0xb7, 0x00, 0xf2, 0x6e, 0xe0,
+	0xb9, 0x97, 0xde, 0xae, 0x98, 0x51, 0x1a, 0xdf, 0x56, 0xe8, 0x53, 0x53, 0x6c, 0x16, 0xbf, 0xc9,
+	0x47, 0xec, 0x80, 0x19, 0x41, 0x84, 0x7b, 0xad, 0x6f, 0xb5, 0x37, 0x79, 0xb2, 0x6e, 0xc6, 0xc9,
+	0xba, 0xf9, 0x3c, 0xce, 0x66, 0x8d, 0x2b, 0xaa, 0xff, 0x5f, 0x80, 0x32, 0xdf, 0x8f, 0x0a, 0x25,
+	0x23, 0xf2, 0x06, 0xb8, 0x9f, 0xfa, 0x56, 0x13, 0x0f, 0x70, 0xe2, 0x5c, 0x0d, 0xfb, 0xc8, 0x06,
+	0x94, 0xcd, 0xc0, 0x0b, 0x43, 0x4c, 0x93, 0xfa, 0x16, 0xa0, 0x12, 0x57, 0xe0, 0x1d, 0x4c, 0x63,
+	0xe8, 0xda, 0x9e, 0xab, 0x48, 0x93, 0x1a, 0xd8, 0xc1, 0xd6, 0x31, 0x03, 0xcf, 0x55, 0x4a, 0xa9,
+	0x75, 0x12, 0xaf, 0x68, 0xd8, 0xa7, 0xbe, 0x82, 0xda, 0xa1, 0xd7, 0xe5, 0xfb, 0xba, 0x99, 0xd8,
+	0xcf, 0x77, 0x56, 0xdf, 0x64, 0x00, 0xc2, 0x5d, 0x3a, 0x11, 0xa3, 0x62, 0x4e, 0x8c, 0xa4, 0x54,
+	0x8c, 0x62, 0xa7, 0x97, 0x46, 0x4e, 0x57, 0x5f, 0xc0, 0x42, 0xc7, 0x08, 0x0c, 0xc7, 0xa1, 0x8e,
+	0x1d, 0x0e, 0x8e, 0x99, 0x1f, 0xdb, 0x50, 0x33, 0x3d, 0x37, 0x8c, 0x0c, 0x97, 0x1f, 0xbc, 0x92,
+	0x96, 0xb4, 0xc9, 0x06, 0xd4, 0x4d, 0x8f, 0xf6, 0x7a, 0xb6, 0xc9, 0x10, 0x0d, 0x67, 0x2f, 0x68,
+	0x69, 0xd1, 0x61, 0xa9, 0x56, 0x68, 0x15, 0xd5, 0x87, 0x20, 0xa3, 0x01, 0x8f, 0x6d, 0x07, 0x03,
+	0x8b, 0x28, 0x26, 0xd6, 0x65, 0xdf, 0x4c, 0x76, 0x62, 0x84, 0x27, 0x18, 0xab, 0x86, 0x86, 0xdf,
+	0xea, 0xe7, 0x50, 0xde, 0x33, 0xa2, 0xe1, 0xe0, 0xbc, 0x3c, 0x22, 0x6d, 0x90, 0x5e, 0x0a, 0x3b,
+	0xeb, 0x5b, 0x35, 0x74, 0xde, 0xa1, 0xd7, 0xd5, 0x98, 0x50, 0xfd, 0xb1, 0x00, 0x32, 0x8e, 0x3e,
+	0x70, 0x7b, 0x1e, 0x8b, 0x84, 0xc5, 0x1a, 0xc2, 0x6d, 0x3c, 0x12, 0xd8, 0xad, 0xf1, 0x0e, 0x72,
+	0x0b, 0x4f, 0x4b, 0xc4, 0x13, 0xbd, 0xb9, 0xb5, 0x30, 0xd2, 0x38, 0x66, 0x62, 0x8d, 0xf7, 0x92,
+	0x3b, 0x5c, 0x2d, 0x44, 0x53, 0xeb, 0x5b, 0x8b, 0xa8, 0xd6, 0x09, 0x3c, 0x93, 0x86, 0x21, 0x53,
+	0x0c, 0xb9, 0x62, 0x48, 0x6e, 0x83, 0xec, 0xf7, 0x42, 0x9d, 0xcf, 0xc9, 0xc3, 0x2b, 0x63, 0xb0,
+	0x98, 0x0b, 0xb4, 0x9a, 0xdf, 0x43, 0x75, 0x4a, 0x6e, 0x40, 0xc9, 0x32, 0x22, 0x03, 0x51, 0xb0,
+	0xbe, 0x35, 0x9f, 0xa8, 0xb0, 0x6d, 0x6b, 0xd8, 0xa5, 0x7e, 0x0e, 0x90, 0x58, 0x12, 0x92, 0x7f,
+	0x06, 0xc0, 0x1d, 0xeb, 0xb6, 0xdb, 0xf3, 0x94, 0xc2, 0x86, 0x94, 0x1c, 0x9c, 0x44, 0x49, 0x93,
+	0xad, 0xf8, 0x53, 0xfd, 0x0d, 0x83, 0x85, 0x7e, 0x3f, 0xa0, 0x7d, 0xb6, 0xda, 0x32, 0x94, 0x4d,
+	0x56, 0x34, 0xd0, 0x0f, 0x92, 0xc6, 0x1b, 0xcc, 0xf9, 0x03, 0x6a, 0xb8, 0x68, 0x7a, 0x41, 0xc3,
+	0x6f, 0x96, 0x69, 0x61, 0x64, 0x59, 0xf4, 0x54, 0x04, 0x55, 0xb4, 0xc8, 0x3d, 0x68, 0xf5, 0xec,
+	0x5e, 0x74, 0xa2, 0xfb, 0x34, 0x30, 0xa9, 0x1b, 0xd9, 0x0e, 0x37, 0xaf, 0xa0, 0x2d, 0xa0, 0xbc,
+	0x93, 0x88, 0xc9, 0x23, 0xb8, 0xe2, 0xda, 0x2e, 0x8d, 0xce, 0xf4, 0x89, 0x11, 0x65, 0x1c, 0xb1,
+	0xc2, 0xbb, 0x1f, 0x67, 0xc7, 0xa9, 0xff, 0x57, 0x84, 0x46, 0xda, 0xa5, 0xe4, 0x4b, 0x98, 0xb7,
+	0xbc, 0xd7, 0xae, 0xe3, 0x19, 0x96, 0xce, 0x4a, 0xb0, 0x88, 0xe2, 0xd5, 0x89, 0x8c, 0xde, 0x13,
+	0xe5, 0x57, 0x6b, 0xc4, 0xfa, 0x2c, 0xc7, 0xc9, 0x17, 0xd0, 0xf0, 0xf9, 0x7c, 0x7c, 0x78, 0x71,
+	0xd6, 0xf0, 0xba, 0x50, 0xc7, 0xd1, 0x9f, 0x41, 0x7d, 0xe8, 0x8f, 0xd6, 0x96, 0x66, 0x0d, 0x06,
+	0xae, 0x8d, 0x63, 0x6f, 0x41, 0x33, 0xd9, 0x79, 0xf7, 0x2c, 0xa2, 0x21, 0xfa, 0xaa, 0xa4, 0x25,
+	0xf6, 0xec, 0x30, 0x21, 0xb9, 0x01, 0x8d, 0xa1, 0x9f, 0x52, 0x2a, 0xa3, 0x92, 0x58, 0x16, 0x55,
+	0xd4, 0x5f, 0x14, 0x61, 0x25, 0x89, 0x63, 0xc6, 0x3b, 0x0f, 0xf3, 0xbd, 0x23, 0x40, 0x2b, 0x1e,
+	0x32, 0xe6, 0x92, 0x8f, 0x73, 0x5d, 0x32, 0x3e, 0x26, 0xe3, 0x87, 0x07, 0x79, 0x7e, 0x18, 0x1f,
+	0x91, 0x36, 0xfe, 0x5f, 0x72, 0x8d, 0x9f, 0x1c, 0x33, 0xe6, 0x8c, 0x8f, 0x73, 0x9c, 0x91, 0xb3,
+	0xb5, 0xb4, 0x73, 0xfe, 0x56, 0x80, 0xc6, 0x7f, 0x79, 0xc1, 0x2b, 0x1a, 0x30, 0x97, 0x0c, 0x43,
+	0x72, 0x0f, 0xe4, 0xd7, 0xd8, 0xd6, 0x13, 0xe0, 0x68, 0xbc, 0x7b, 0xbb, 0x5e, 0xe3, 0x4a, 0x07,
+	0x7b, 0x5a, 0x8d, 0x77, 0x1f, 0x58, 0x64, 0x03, 0x2a, 0x2f, 0xbd, 0x2e, 0xd3, 0x43, 0xbc, 0xdc,
+	0x91, 0xdf, 0xbd, 0x5d, 0x2f, 0x33, 0xc0, 0xdd, 0xd3, 0xca, 0x2f, 0xbd, 0xee, 0x81, 0xc5, 0x40,
+	0x1a, 0x53, 0x54, 0x4a, 0xe5, 0x5a, 0x82, 0x66, 0x3c, 0x47, 0xc9, 0x27, 0x50, 0xc5, 0x1a, 0x42,
+	0x2d, 0xa5, 0x34, 0xb3, 0xdc, 0xc4, 0xaa, 0x23, 0x34, 0x29, 0xcf, 0x40, 0x93, 0xeb, 0x00, 0xdf,
+	0x0d, 0xe9, 0x90, 0xea, 0xa1, 0xfd, 0x3d, 0xc5, 0x42, 0x2b, 0x69, 0x32, 0x4a, 0x8e, 0xed, 0xef,
+	0xa9, 0x7a, 0x08, 0x0d, 0x8d, 0x86, 0xde, 0x30, 0x30, 0x29, 0x42, 0x36, 0xe3, 0x6f, 0xfe, 0x10,
+	0x0d, 0x2f, 0x6a, 0xec, 0x93, 0xa5, 0xf3, 0x80, 0x0e, 0xbc, 0xe0, 0x4c, 0x54, 0x05, 0xd1, 0x62,
+	0x9a, 0x7d, 0x7f, 0x88, 0xc1, 0x94, 0x34, 0xf6, 0xa9, 0xfe, 0x4a, 0x86, 0x2a, 0xd6, 0x9b, 0x9e,
+	0x17, 0x03, 0x6c, 0x21, 0x07, 0x60, 0xc9, 0x87, 0x20, 0x47, 0x31, 0x03, 0xcc, 0x1c, 0x9f, 0x84,
+	0x17, 0x6a, 0x23, 0x05, 0x72, 0x0f, 0x6a, 0xbe, 0xed, 0x53, 0xc7, 0x76, 0xe3, 0x93, 0x33, 0xcf,
+	0x8d, 0x15, 0x42, 0x2d, 0xe9, 0x26, 0x77, 0x00, 0x7c, 0x23, 0xa0, 0x6e, 0xa4, 0xb3, 0xb5, 0x2b,
+	0x63, 0x6b, 0xcb, 0xbc, 0x8f, 0xd1, 0xab, 0x94, 0xcf, 0xab, 0x17, 0xf7, 0xf9, 0x23, 0xa8, 0xf5,
+	0x6c, 0xd7, 0x0e, 0x4f, 0xa8, 0xa5, 0xd4, 0x66, 0x0e, 0x4b, 0x74, 0xc9, 0x47, 0x30, 0xef, 0x0d,
+	0x23, 0x7f, 0x18, 0xc5, 0x9c, 0x46, 0x9e, 0xac, 0xc0, 0x0d, 0xae, 0xc1, 0x5b, 0xe4, 0x66, 0x5c,
+	0x52, 0x00, 0x4b, 0xca, 0x7c, 0x6c, 0x43, 0xa6, 0xa0, 0x7c, 0x05, 0x2d, 0x7f, 0x54, 0x70, 0x75,
+	0x64, 0x31, 0x0d, 0x9c, 0x79, 0x99, 0x3b, 0x28, 0x5b, 0x8d, 0xb5, 0x05, 0x3f, 0x2b, 0x60, 0x80,
+	0x1c, 0xbb, 0x4e, 0x3f, 0xa5, 0x41, 0xc8, 0xf8, 0xc6, 0x3c, 0xe2, 0xc7, 0x42, 0x2c, 0xff, 0x96,
+	0x8b, 0xc9, 0x6d, 0xc6, 0xcc, 0x91, 0x77, 0x2a, 0x4d, 0x5c, 0xa2, 0x21, 0x98, 0x39, 0xca, 0xb4,
+	0xb8, 0x93, 0xb1, 0x0c, 0x8a, 0xd4, 0x56, 0x59, 0x88, 0x6d, 0xf4, 0xc3, 0x4d, 0xce, 0x76, 0x35,
+	0xd1, 0xc5, 0x48, 0xa9, 0xf0, 0x87, 0x20, 0x90, 0x8b, 0x78, 0xb0, 0x84, 0x0b, 0x76, 0x50, 0x46,
+	0xee, 0x43, 0x5d, 0x28, 0x21, 0x95, 0x23, 0xa9, 0x3a, 0xa8, 0x51, 0xdf, 0xd3, 0x80, 0xf7, 0xb2,
+	0x6f, 0xa2, 0x40, 0x35, 0xa0, 0x9c, 0xb1, 0x2d, 0xe3, 0xfe, 0xe3, 0x26, 0xa2, 0xa8, 0x11, 0x19,
+	0xba, 0x40, 0x23, 0x6a, 0x29, 0xab, 0x78, 0x5e, 0xe7, 0x99, 0xb4, 0x13, 0x0b, 0x59, 0x92, 0xa0,
+	0x5a, 0xe4, 0x45, 0x86, 0xa3, 0x5c, 0xe1, 0x49, 0xc2, 0x24, 0xcf, 0x99, 0x80, 0x3c, 0x82, 0x79,
+	0x81, 0x09, 0x21, 0x82, 0x84, 0xa2, 0x6c, 0x48, 0x49, 0xd2, 0xa5, 0xd1, 0x43, 0x6b, 0xbc, 0x4e,
+	0xb5, 0xd8, 0xb8, 0x40, 0x24, 0x17, 0x0f, 0xcf, 0xd5, 0x54, 0xb2, 0xa6, 0xd3, 0x4e, 0x6b, 0x04,
+	0xa9, 0x16, 0xe3, 0x1c, 0x36, 0x43, 0x09, 0xa5, 0x9d, 0xe2, 0x1c, 0x82, 0xfd, 0x61, 0x07, 0xd9,
+	0x04, 0x70, 0xe9, 0xeb, 0xd8, 0x7f, 0xd7, 0x50, 0x6d, 0x01, 0x9d, 0xc3, 0xdd, 0xc7, 0x6b, 0xb9,
+	0x4b, 0x5f, 0xf3, 0x26, 0x63, 0x5b, 0xb6, 0x6b, 0x06, 0x74, 0x40, 0x5d, 0x66, 0xe1, 0x07, 0xc8,
+	0xe5, 0xd2, 0x22, 0xb2, 0x09, 0x0d, 0x04, 0x8c, 0xf8, 0x8c, 0x5e, 0x9f, 0x3c, 0xa3, 0x75, 0x54,
+	0xe0, 0x0d, 0x56, 0x78, 0xd0, 0x65, 0xe1, 0x2b, 0xdb, 0xf7, 0xa9, 0xa5, 0xac, 0xa1, 0xd3, 0xea,
+	0x4c, 0x76, 0xcc, 0x45, 0x23, 0x8c, 0x5a, 0x9f, 0x81, 0x51, 0x37, 0xa0, 0x41, 0x5d, 0xa3, 0xeb,
+	0x50, 0x9d, 0xeb, 0x6f, 0xf0, 0xed, 0x71, 0x19, 0x6a, 0x22, 0x4d, 0x37, 0x9c, 0x48, 0xb9, 0x21,
+	0x68, 0xba, 0xe1, 0x44, 0x8c, 0x92, 0x74, 0x8d, 0xc8, 0x3c, 0x51, 0x54, 0x7e, 0x87, 0xc3, 0x06,
+	0xc3, 0xab, 0x80, 0x1a, 0xa1, 0xe7, 0x2a, 0x37, 0x39, 0x5e, 0xf1, 0xd6, 0x61, 0xa9, 0x56, 0x6a,
+	0x95, 0x0f, 0x4b, 0xb5, 0x72, 0xab, 0xa2, 0xee, 0x41, 0x85, 0x87, 0x2d, 0xf7, 0xfa, 0x70, 0x3b,
+	0x4b, 0xe8, 0x5a, 0x63, 0x61, 0x8e, 0x13, 0x50, 0x7d, 0x28, 0xe8, 0x35, 0xe3, 0x56, 0x77, 0xa0,
+	0x86, 0xb5, 0x60, 0xc4, 0xac, 0x1a, 0x71, 0xd2, 0x62, 0x2c, 0xaa, 0x2f, 0xf9, 0x87, 0xba, 0x06,
+	0xb5, 0x18, 0xb9, 0xf2, 0x16, 0x57, 0x7f, 0x28, 0xc0, 0x7c, 0xac, 0xc0, 0x99, 0xfb, 0x75, 0x71,
+	0x9b, 0x29, 0x8c, 0xa7, 0xc0, 0xf8, 0x3d, 0xac, 0x98, 0xb9, 0x87, 0xc5, 0x5c, 0x5e, 0xca, 0xe1,
+	0xf2, 0xa5, 0x1c, 0x2e, 0x5f, 0x4e, 0x79, 0x60, 0x1d, 0x4a, 0xec, 0xc2, 0xa5, 0x54, 0x26, 0x0f,
+	0x01, 0x76, 0xa8, 0xbf, 0xaf, 0x41, 0x63, 0xb4, 0xcb, 0x9e, 0x97, 0x41, 0xe9, 0xc2, 0x74, 0x94,
+	0xbe, 0x1c, 0xfc, 0xff, 0x1b, 0x80, 0x19, 0x50, 0x23, 0xa2, 0x96, 0x6e, 0x44, 0x4a, 0x65, 0x26,
+	0xec, 0xca, 0x42, 0x7b, 0x3b, 0x22, 0x77, 0xe3, 0x38, 0x56, 0x31, 0x8e, 0x24, 0xb3, 0xa1, 0x0c,
+	0x94, 0xde, 0x80, 0x46, 0x40, 0x19, 0x89, 0xd4, 0x69, 0x10, 0x78, 0x01, 0xa2, 0xbb, 0xac, 0xd5,
+	0xb9, 0x6c, 0x9f, 0x89, 0xc8, 0x57, 0x00, 0x2c, 0xc0, 0x48, 0x7b, 0xf9, 0x93, 0x40, 0x7d, 0x6b,
+	0x23, 0x33, 0x23, 0xf3, 0x03, 0x8b, 0xf7, 0x2e, 0xaa, 0xf0, 0x67, 0x0d, 0xf9, 0x65, 0xdc, 0xce,
+	0x85, 0x6b, 0xb8, 0x0c, 0x5c, 0x2b, 0x50, 0x8d, 0x51, 0xba, 0xce, 0x51, 0x4e, 0x34, 0x7f, 0x22,
+	0xea, 0xb6, 0x72, 0x50, 0x97, 0xdf, 0x97, 0x16, 0x27, 0xee, 0x4b, 0x4f, 0x61, 0x39, 0x34, 0x0d,
+	0x87, 0xea, 0x8c, 0x70, 0xe9, 0xd1, 0x49, 0x40, 0xc3, 0x13, 0xcf, 0xb1, 0x14, 0x32, 0x8b, 0xd2,
+	0x12, 0x1c, 0xb6, 0xe7, 0xbd, 0x76, 0x9f, 0xc7, 0x83, 0x26, 0x61, 0x71, 0xe9, 0x92, 0xb0, 0xb8,
+	0x7c, 0x1e, 0x2c, 0x6e, 0x40, 0xdd, 0xa2, 0xa1, 0x19, 0xd8, 0x3e, 0x5b, 0x5c, 0x59, 0xe1, 0x61,
+	0x4c, 0x89, 0xc6, 0x81, 0x70, 0x75, 0x12, 0x08, 0xaf, 0x03, 0x98, 0x86, 0x79, 0x22, 0x08, 0xd3,
+	0x15, 0xfe, 0x5e, 0x86, 0x12, 0x46, 0x98, 0x26, 0xb0, 0x4a, 0x39, 0x1f, 0xab, 0xae, 0xa6, 0xb0,
+	0x6a, 0x8d, 0xcd, 0xea, 0x1b, 0x5d, 0xdb, 0xb1, 0xa3, 0x33, 0xc4, 0x75, 0x59, 0x4b, 0x49, 0x46,
+	0x58, 0x76, 0x2d, 0x1f, 0xcb, 0x3e, 0x48, 0x63, 0x19, 0xf9, 0x27, 0x68, 0x0e, 0x8c, 0x37, 0x7a,
+	0x8a, 0xd8, 0x5d, 0x47, 0xf8, 0x6d, 0x0c, 0x8c, 0x37, 0xff, 0x19, 0x73, 0xbb, 0x74, 0xd1, 0x5e,
+	0x9b, 0x56, 0xb4, 0xef, 0xc0, 0x82, 0xf8, 0xd4, 0x0d, 0x93, 0x5f, 0xf2, 0xd6, 0x71, 0xb9, 0xa6,
+	0x10, 0x6f, 0x73, 0x69, 0xfb, 0x0b, 0x68, 0x66, 0xcf, 0x77, 0xfa, 0xd9, 0xab, 0x9c, 0xf3, 0xec,
+	0x55, 0x4e, 0x3d, 0x7b, 0x1d, 0x96, 0x6a, 0x52, 0xab, 0xc4, 0x61, 0x58, 0x7d, 0x92, 0x06, 0x39,
+	0x86, 0x9f, 0x8f, 0x60, 0x3e, 0xe1, 0x22, 0x29, 0x10, 0x5d, 0x9c, 0xc8, 0x30, 0xad, 0xe1, 0xa7,
+	0x5a, 0xea, 0x0f, 0x65, 0x68, 0xed, 0x62, 0xc6, 0x33, 0x8a, 0x47, 0xbf, 0x1b, 0xd2, 0x30, 0xca,
+	0x22, 0x4c, 0xe1, 0x32, 0x04, 0xb3, 0x38, 0x1d, 0xba, 0xf2, 0x72, 0xb8, 0x7a, 0x99, 0x1c, 0x4e,
+	0x85, 0xa4, 0x76, 0x31, 0x1e, 0x25, 0x9f, 0x9f, 0xd1, 0x79, 0xfc, 0x0d, 0xf2, 0xf9, 0xdb, 0x44,
+	0xf2, 0xd7, 0x67, 0x53, 0xae, 0xc6, 0x34, 0xca, 0x95, 0xa5, 0xda, 0xf3, 0xe7, 0x53, 0xed, 0x89,
+	0x64, 0x6f, 0x5e, 0x32, 0xd9, 0x17, 0x2e, 0xc6, 0x81, 0x5a, 0x97, 0xe5, 0x40, 0x8b, 0x93, 0xa9,
+	0x3f, 0x9e, 0xdb, 0xe4, 0xfc, 0xdc, 0x5e, 0xca, 0xe3, 0x21, 0xcb, 0xa9, 0xdc, 0xcd, 0x1c, 0xf7,
+	0x0e, 0x2c, 0x1e, 0xb8, 0xcc, 0xfa, 0x28, 0x75, 0x4a, 0xa7, 0x5d, 0x91, 0xd6, 0xa1, 0xde, 0x75,
+	0x3c, 0xf3, 0x95, 0x3e, 0x22, 0x22, 0x35, 0x0d, 0x50, 0x84, 0x85, 0x4b, 0xfd, 0x65, 0x01, 0x9a,
+	0x47, 0x76, 0x98, 0x9e, 0xef, 0x12, 0x25, 0x78, 0x13, 0x1a, 0xe8, 0xc3, 0x98, 0xec, 0x15, 0x37,
+	0xa4, 0xf1, 0x3a, 0x5f, 0x47, 0x05, 0xde, 0x98, 0xbc, 0xc1, 0x48, 0x33, 0x6e, 0x30, 0xea, 0x26,
+	0xb4, 0xf6, 0xa8, 0x43, 0x23, 0x7a, 0x31, 0x83, 0xd5, 0x0f, 0xa1, 0x79, 0x1c, 0x79, 0xfe, 0x05,
+	0xb5, 0x7f, 0x5b, 0x80, 0xe6, 0x13, 0x1a, 0x1d, 0x79, 0xfd, 0xf0, 0x22, 0xde, 0xbc, 0x44, 0x86,
+	0xc7, 0xb4, 0xb6, 0x67, 0x3b, 0x11, 0x0d, 0x42, 0xbc, 0xb9, 0xcb, 0x9c, 0xd6, 0x3e, 0xe6, 0x22,
+	0xbc, 0x10, 0x1b, 0x61, 0x44, 0x03, 0xa4, 0x4c, 0x35, 0x4d, 0xb4, 0x46, 0x2f, 0x85, 0x95, 0x73,
+	0x5e, 0x0a, 0xc5, 0x61, 0xf8, 0x5d, 0x11, 0xe0, 0xc8, 0xeb, 0xff, 0x07, 0x0d, 0x43, 0xf6, 0x8b,
+	0xc9, 0xcd, 0x14, 0xf2, 0xa5, 0xd8, 0x60, 0x02, 0x73, 0xcf, 0x18, 0x21, 0x1b, 0x3d, 0x35, 0x48,
+	0x33, 0x9e, 0x1a, 0x4a, 0x53, 0x9e, 0x1a, 0xee, 0x43, 0x31, 0x79, 0x31, 0x98, 0xc6, 0xa1, 0x8a,
+	0x51, 0xc8, 0xd8, 0xc6, 0x80, 0xef, 0x10, 0xed, 0x91, 0xb5, 0xb8, 0x99, 0x7d, 0x21, 0xa9, 0x4e,
+	0x7d, 0x21, 0x21, 0x50, 0x1a, 0x86, 0x94, 0xf3, 0xa9, 0x9a, 0x86, 0xdf, 0xe4, 0x36, 0xd4, 0xc4,
+	0x2b, 0xa4, 0x85, 0xe0, 0x26, 0xef, 0xd4, 0xdf, 0xbd, 0x5d, 0xaf, 0xf2, 0x27, 0xc8, 0x3d, 0xad,
+	0x8a, 0x9d, 0x07, 0x56, 0xca, 0xcd, 0x90, 0x76, 0xb3, 0xfa, 0x1c, 0x96, 0x34, 0x7e, 0xbb, 0xe3,
+	0xbe, 0xbd, 0x40, 0xfc, 0xc7, 0x83, 0x5a, 0x9c, 0x08, 0xaa, 0xfa, 0xaf, 0xb0, 0x24, 0x32, 0x34,
+	0x33, 0xeb, 0xcc, 0xd7, 0x5f, 0x55, 0x87, 0x16, 0xcb, 0xc3, 0x0b, 0xef, 0xe5, 0x1a, 0xc8, 0xbe,
+	0xd1, 0x17, 0x55, 0xbb, 0x88, 0x55, 0xbb, 0xc6, 0x04, 0x58, 0xb1, 0xf1, 0x7d, 0xbb, 0x4f, 0xc5,
+	0xa3, 0x0a, 0x7e, 0xab, 0x67, 0xb0, 0x98, 0x5a, 0x20, 0xf4, 0x3d, 0x37, 0xc4, 0x17, 0xb5, 0xd1,
+	0x53, 0x6e, 0x78, 0xce, 0x5b, 0x2e, 0x24, 0x6f, 0xb9, 0x21, 0x03, 0x14, 0xbc, 0xdc, 0xea, 0x6c,
+	0xce, 0x50, 0x2c, 0x0c, 0x28, 0xea, 0x30, 0x49, 0xee, 0xd2, 0x7f, 0x29, 0xc3, 0x0a, 0x2f, 0xae,
+	0x49, 0xa6, 0x5c, 0x1e, 0x6b, 0x2e, 0x47, 0xf7, 0x57, 0xa1, 0x32, 0xf4, 0x2d, 0x86, 0x79, 0x22,
+	0xb9, 0x78, 0xeb, 0xfd, 0x2b, 0xef, 0x85, 0x2a, 0xea, 0x44, 0x99, 0x84, 0x9c, 0x32, 0x79, 0x1e,
+	0x17, 0xae, 0xff, 0x43, 0xb8, 0x70, 0xe3, 0x92, 0xe5, 0x71, 0xfe, 0x82, 0x5c, 0xb8, 0x39, 0x93,
+	0x0b, 0x2f, 0xcc, 0xe2, 0xc2, 0xad, 0x59, 0x5c, 0x78, 0x71, 0xb2, 0x5e, 0x7e, 0x00, 0x72, 0x40,
+	0xc5, 0xeb, 0x8b, 0xa8, 0xa7, 0x23, 0xc1, 0xa8, 0x72, 0x2e, 0xa5, 0x59, 0xef, 0x24, 0xbb, 0x5d,
+	0x9e, 0xce, 0x6e, 0x57, 0x2e, 0xc9, 0x6e, 0x57, 0xf3, 0xd8, 0x6d, 0xa6, 0x60, 0xef, 0xc2, 0xaa,
+	0x80, 0x83, 0x9f, 0x7e, 0xf2, 0xd5, 0x15, 0x58, 0x62, 0x99, 0x3b, 0x36, 0x83, 0xfa, 0xb3, 0x02,
+	0xac, 0xf0, 0xda, 0xf8, 0x1e, 0x59, 0xb5, 0xce, 0xa2, 0xcb, 0xe6, 0x60, 0xfc, 0x2b, 0x8c, 0x09,
+	0x82, 0x15, 0x97, 0xdc, 0x30, 0xa5, 0x80, 0x64, 0x4e, 0x4a, 0x2b, 0x20, 0x83, 0x6b, 0x81, 0x64,
+	0x38, 0x8e, 0x78, 0x0a, 0x60, 0x9f, 0xea, 0x36, 0x2c, 0x1f, 0x33, 0x5c, 0x7d, 0x0f, 0x93, 0xbf,
+	0x86, 0x25, 0x56, 0xc6, 0xdf, 0x63, 0x86, 0xff, 0x29, 0xc0, 0xb2, 0x46, 0x83, 0xa1, 0xfb, 0x1e,
+	0xce, 0xb9, 0x05, 0x55, 0xfa, 0xc6, 0x74, 0x86, 0x16, 0xcd, 0x63, 0x36, 0x71, 0x1f, 0x53, 0xb3,
+	0x5d, 0xae, 0x26, 0xe5, 0xa8, 0x89, 0x3e, 0xf5, 0x0a, 0xac, 0x3c, 0x31, 0x82, 0xae, 0xd1, 0xa7,
+	0xbb, 0x9e, 0xe3, 0x50, 0x33, 0x8a, 0x03, 0xa9, 0xc0, 0xea, 0x78, 0x07, 0x87, 0xe7, 0xfb, 0x3a,
+	0xbe, 0x0c, 0xf1, 0x9f, 0xe9, 0x5a, 0xd0, 0x38, 0xfc, 0x66, 0x47, 0x3f, 0x7e, 0xbe, 0xad, 0x3d,
+	0x3f, 0x78, 0xf6, 0xa4, 0x35, 0x47, 0x16, 0xa0, 0xce, 0x24, 0xda, 0x8b, 0x67, 0xcf, 0x98, 0xa0,
+	0x10, 0x0b, 0x1e, 0x6f, 0x1f, 0x1c, 0xbd, 0xd0, 0xf6, 0x5b, 0xc5, 0x58, 0x70, 0xfc, 0x62, 0x77,
+	0x77, 0xff, 0xf8, 0xb8, 0x25, 0x91, 0x26, 0x00, 0x13, 0x3c, 0x3d, 0x38, 0x3a, 0xda, 0xdf, 0x6b,
+	0x95, 0xee, 0x7f, 0x2d, 0x7e, 0xd8, 0xe3, 0x4b, 0x00, 0x54, 0xd8, 0xd8, 0xfd, 0xbd, 0xd6, 0x1c,
+	0xa9, 0x43, 0x35, 0x1e, 0x56, 0xc0, 0xc6, 0xd3, 0x83, 0x4e, 0x67, 0x7f, 0xaf, 0x55, 0x24, 0x0d,
+	0xa8, 0x25, 0x9b, 0x90, 0xee, 0x7f, 0x05, 0xf5, 0xd4, 0x93, 0x16, 0x5b, 0xb1, 0xf3, 0xcd, 0x5e,
+	0xb2, 0xa7, 0xb9, 0x58, 0x30, 0x9a, 0xab, 0x09, 0xc0, 0x04, 0x62, 0xa1, 0xe2, 0xfd, 0xff, 0x4e,
+	0x3d, 0x54, 0xf1, 0x39, 0x56, 0x60, 0xb1, 0x73, 0xd0, 0xd9, 0x3f, 0x3a, 0x78, 0xb6, 0x9f, 0x36,
+	0x77, 0x19, 0x5a, 0x89, 0x78, 0x64, 0xf3, 0x15, 0x58, 0x1a, 0x49, 0xf7, 0x13, 0xf5, 0x62, 0x46,
+	0x3d, 0xf6, 0x88, 0x44, 0x96, 0x60, 0x21, 0x91, 0x76, 0xb6, 0x5f, 0x1c, 0x33, 0x2f, 0x6c, 0xfd,
+	0xb5, 0x06, 0xd2, 0x76, 0xe7, 0x80, 0x6c, 0x82, 0xcc, 0xcb, 0x14, 0xbb, 0x70, 0xac, 0x88, 0x9f,
+	0xc2, 0xb3, 0x77, 0xc2, 0x76, 0x52, 0x86, 0xd5, 0x39, 0xf2, 0x09, 0xc0, 0x88, 0x8e, 0x93, 0x55,
+	0x81, 0x9d, 0x63, 0xfc, 0xbc, 0x9d, 0x79, 0xc0, 0x53, 0xe7, 0xc8, 0x03, 0xa8, 0x0a, 0xc6, 0x4d,
+	0x96, 0xb0, 0x2b, 0xcb, 0xbf, 0xdb, 0xf3, 0x69, 0xfd, 0x50, 0x9d, 0x23, 0x5f, 0x80, 0x9c, 0x70,
+	0x60, 0xb1, 0xad, 0x71, 0x4e, 0xdc, 0x5e, 0x9d, 0x28, 0x17, 0xfb, 0xec, 0xdf, 0x88, 0xd4, 0x39,
+	0xf2, 0x29, 0x54, 0x05, 0x23, 0x16, 0xcb, 0x65, 0xf9, 0xf1, 0x94, 0x91, 0x9f, 0x41, 0x23, 0xcd,
+	0x65, 0x88, 0x92, 0x36, 0x30, 0x4d, 0x54, 0xda, 0x63, 0x8c, 0x81, 0xef, 0x39, 0x61, 0x1b, 0x62,
+	0xcf, 0xe3, 0xf4, 0xa6, 0xbd, 0x3a, 0x2e, 0xe6, 0xa7, 0x5e, 0x9d, 0x23, 0x3b, 0xf8, 0x6b, 0x52,
+	0xc2, 0xcd, 0xc4, 0xca, 0x39, 0x74, 0x6d, 0xca, 0xee, 0x1f, 0x43, 0x33, 0xcb, 0x39, 0x48, 0x3b,
+	0x15, 0xd1, 0x31, 0x54, 0x98, 0x32, 0xcf, 0x2e, 0x2c, 0x8c, 0x41, 0x38, 0xb9, 0x96, 0x76, 0xc4,
+	0xf8, 0x4c, 0x93, 0x4f, 0x0d, 0xea, 0x1c, 0xf9, 0x12, 0x1a, 0x69, 0x08, 0x17, 0x06, 0xe5, 0xa0,
+	0x7a, 0x9b, 0x4c, 0x0c, 0x0f, 0xb9, 0x31, 0x59, 0xa8, 0x17, 0xc6, 0xe4, 0xe2, 0xff, 0x14, 0x63,
+	0xf6, 0x60, 0x3e, 0x03, 0xcd, 0xe4, 0xaa, 0x38, 0x12, 0x93, 0x70, 0x3d, 0x65, 0x96, 0x1d, 0x68,
+	0xa4, 0xd1, 0x59, 0x58, 0x93, 0x03, 0xd8, 0xd3, 0x77, 0x92, 0x81, 0x67, 0xb1, 0x93, 0x3c, 0xc8,
+	0x9e, 0x32, 0xcb, 0xbf, 0xc7, 0xa9, 0xb1, 0xed, 0x38, 0xe4, 0x1c, 0xb5, 0x29, 0xc3, 0x1f, 0x42,
+	0x55, 0x5c, 0xff, 0x44, 0x6e, 0x64, 0x2f, 0x83, 0x6d, 0xfe, 0x3f, 0x18, 0xa3, 0x4b, 0x96, 0x3a,
+	0xf7, 0x51, 0x81, 0x3c, 0x85, 0x66, 0x16, 0xae, 0x45, 0x2c, 0x72, 0xc1, 0xbd, 0x7d, 0x2d, 0xb7,
+	0x2f, 0x3e, 0xe9, 0x3b, 0xad, 0x1f, 0xdf, 0xad, 0x15, 0xfe, 0xf8, 0x6e, 0xad, 0xf0, 0xa7, 0x77,
+	0x6b, 0x85, 0x9f, 0xff, 0x79, 0x6d, 0xae, 0x5b, 0xc1, 0x5d, 0x3e, 0xfc, 0xfb, 0x00, 0x1b, 0x72,
+	0x15, 0x20, 0x33, 0x28, 0x00, 0x00,
 }
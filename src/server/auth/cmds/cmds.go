@@ -3,6 +3,7 @@ package cmds
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -12,6 +13,8 @@ import (
 	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
 	"github.com/pachyderm/pachyderm/src/server/pkg/cmdutil"
 
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
 	"github.com/spf13/cobra"
 )
 
@@ -348,6 +351,284 @@ func ModifyAdminsCmd() *cobra.Command {
 	return modifyAdmins
 }
 
+// readJSON reads the JSON message in the file at path, or stdin if path is
+// "-", into msg.
+func readJSON(path string, msg proto.Message) (retErr error) {
+	var r io.Reader
+	if path == "-" {
+		r = os.Stdin
+		fmt.Fprintln(os.Stderr, "Reading from stdin.")
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := f.Close(); err != nil && retErr == nil {
+				retErr = err
+			}
+		}()
+		r = f
+	}
+	return jsonpb.Unmarshal(r, msg)
+}
+
+// ExportACLsCmd returns a cobra command that prints the ACLs of many repos, in
+// the format that ImportACLsCmd and DiffACLsCmd read
+func ExportACLsCmd() *cobra.Command {
+	exportACLs := &cobra.Command{
+		Use:   "export-acls [repo...]",
+		Short: "Print the ACLs of 'repo's, or of every repo you can read",
+		Long: "Print the ACLs of the given repos as JSON, or, if no repos are " +
+			"given, the ACLs of every repo that you have at least \"reader\" " +
+			"access to. The output can be edited and passed to 'pachctl auth " +
+			"import-acls' or 'pachctl auth diff-acls'",
+		Run: cmdutil.Run(func(args []string) error {
+			c, err := client.NewOnUserMachine(true, "user")
+			if err != nil {
+				return fmt.Errorf("could not connect: %v", err)
+			}
+			resp, err := c.ExportACLs(c.Ctx(), &auth.ExportACLsRequest{
+				Repos: args,
+			})
+			if err != nil {
+				return grpcutil.ScrubGRPC(err)
+			}
+			marshaller := &jsonpb.Marshaler{Indent: "  "}
+			if err := marshaller.Marshal(os.Stdout, resp); err != nil {
+				return err
+			}
+			fmt.Println()
+			return nil
+		}),
+	}
+	return exportACLs
+}
+
+// ImportACLsCmd returns a cobra command that sets the ACLs of many repos at
+// once
+func ImportACLsCmd() *cobra.Command {
+	var file string
+	importACLs := &cobra.Command{
+		Use:   "import-acls -f file",
+		Short: "Set the ACLs of many repos at once",
+		Long: "Set the ACLs of the repos in 'file', which is in the format " +
+			"that 'pachctl auth export-acls' prints. Either every ACL is set or, " +
+			"if you aren't an owner of one of the repos, none are. A repo with " +
+			"an empty ACL has its ACL removed",
+		Run: cmdutil.RunFixedArgs(0, func([]string) error {
+			req := &auth.ImportACLsRequest{}
+			if err := readJSON(file, req); err != nil {
+				return err
+			}
+			c, err := client.NewOnUserMachine(true, "user")
+			if err != nil {
+				return fmt.Errorf("could not connect: %v", err)
+			}
+			_, err = c.ImportACLs(c.Ctx(), req)
+			return grpcutil.ScrubGRPC(err)
+		}),
+	}
+	importACLs.Flags().StringVarP(&file, "file", "f", "-", "The file "+
+		"containing the ACLs, or \"-\" to read them from stdin")
+	return importACLs
+}
+
+// ApplyACLTemplateCmd returns a cobra command that applies the same ACL
+// entries to many repos
+func ApplyACLTemplateCmd() *cobra.Command {
+	var file string
+	var replace bool
+	applyACLTemplate := &cobra.Command{
+		Use:   "apply-acl-template -f template repo...",
+		Short: "Apply the ACL in 'template' to every 'repo'",
+		Long: "Apply the ACL in 'template', a JSON ACL such as " +
+			"{\"entries\": {\"github-alice\": \"READER\"}}, to every 'repo', " +
+			"each of which may be a glob pattern such as 'team-a-*'. The " +
+			"template's entries are added to each repo's ACL, with \"NONE\" " +
+			"removing an entry, unless --replace is set, in which case each " +
+			"repo's ACL becomes the template",
+		Run: cmdutil.Run(func(args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("must provide at least one repo")
+			}
+			template := &auth.ACL{}
+			if err := readJSON(file, template); err != nil {
+				return err
+			}
+			c, err := client.NewOnUserMachine(true, "user")
+			if err != nil {
+				return fmt.Errorf("could not connect: %v", err)
+			}
+			resp, err := c.ApplyACLTemplate(c.Ctx(), &auth.ApplyACLTemplateRequest{
+				Template: template,
+				Repos:    args,
+				Replace:  replace,
+			})
+			if err != nil {
+				return grpcutil.ScrubGRPC(err)
+			}
+			for _, repo := range resp.Repos {
+				fmt.Println(repo)
+			}
+			return nil
+		}),
+	}
+	applyACLTemplate.Flags().StringVarP(&file, "file", "f", "-", "The file "+
+		"containing the template, or \"-\" to read it from stdin")
+	applyACLTemplate.Flags().BoolVar(&replace, "replace", false, "Replace "+
+		"each repo's ACL with the template, rather than adding to it")
+	return applyACLTemplate
+}
+
+// DiffACLsCmd returns a cobra command that prints the differences between
+// the ACLs of many repos and the ACLs that they should have
+func DiffACLsCmd() *cobra.Command {
+	var file string
+	diffACLs := &cobra.Command{
+		Use:   "diff-acls -f file",
+		Short: "Print how the ACLs of repos differ from the ACLs in 'file'",
+		Long: "Print each user whose access to a repo differs from the access " +
+			"that the desired ACLs in 'file', which is in the format that " +
+			"'pachctl auth export-acls' prints, give them. Nothing is changed",
+		Run: cmdutil.RunFixedArgs(0, func([]string) error {
+			desired := &auth.ImportACLsRequest{}
+			if err := readJSON(file, desired); err != nil {
+				return err
+			}
+			c, err := client.NewOnUserMachine(true, "user")
+			if err != nil {
+				return fmt.Errorf("could not connect: %v", err)
+			}
+			resp, err := c.DiffACLs(c.Ctx(), &auth.DiffACLsRequest{
+				Desired: desired.ACLs,
+			})
+			if err != nil {
+				return grpcutil.ScrubGRPC(err)
+			}
+			for _, change := range resp.Changes {
+				fmt.Printf("%s\t%s\t%s -> %s\n", change.Repo, change.Username,
+					change.Actual, change.Desired)
+			}
+			return nil
+		}),
+	}
+	diffACLs.Flags().StringVarP(&file, "file", "f", "-", "The file "+
+		"containing the desired ACLs, or \"-\" to read them from stdin")
+	return diffACLs
+}
+
+// CreateServiceAccountCmd returns a cobra command that creates a service
+// account and prints its token
+func CreateServiceAccountCmd() *cobra.Command {
+	var inputs []string
+	var outputs []string
+	createServiceAccount := &cobra.Command{
+		Use:   "create-service-account name",
+		Short: "Create a service account and print its token",
+		Long: "Create a service account, which can read the repos given with " +
+			"--input and write the repos given with --output, and nothing else. " +
+			"It never has more access to a repo than you do. The token that's " +
+			"printed doesn't expire, and can be replaced with 'pachctl auth " +
+			"rotate-service-account-token'",
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			c, err := client.NewOnUserMachine(true, "user")
+			if err != nil {
+				return fmt.Errorf("could not connect: %v", err)
+			}
+			resp, err := c.CreateServiceAccount(c.Ctx(), &auth.CreateServiceAccountRequest{
+				Name:        args[0],
+				InputRepos:  inputs,
+				OutputRepos: outputs,
+			})
+			if err != nil {
+				return grpcutil.ScrubGRPC(err)
+			}
+			fmt.Println(resp.Token)
+			return nil
+		}),
+	}
+	createServiceAccount.Flags().StringSliceVar(&inputs, "input", []string{},
+		"Comma-separated list of repos the service account can read")
+	createServiceAccount.Flags().StringSliceVar(&outputs, "output", []string{},
+		"Comma-separated list of repos the service account can write")
+	return createServiceAccount
+}
+
+// RotateServiceAccountTokenCmd returns a cobra command that replaces the
+// token of a service account
+func RotateServiceAccountTokenCmd() *cobra.Command {
+	rotateServiceAccountToken := &cobra.Command{
+		Use:   "rotate-service-account-token name",
+		Short: "Replace the token of a service account and print the new one",
+		Long: "Replace the token of a service account and print the new one. " +
+			"The old token stops working immediately",
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			c, err := client.NewOnUserMachine(true, "user")
+			if err != nil {
+				return fmt.Errorf("could not connect: %v", err)
+			}
+			resp, err := c.RotateServiceAccountToken(c.Ctx(), &auth.RotateServiceAccountTokenRequest{
+				Name: args[0],
+			})
+			if err != nil {
+				return grpcutil.ScrubGRPC(err)
+			}
+			fmt.Println(resp.Token)
+			return nil
+		}),
+	}
+	return rotateServiceAccountToken
+}
+
+// ListServiceAccountsCmd returns a cobra command that lists the service
+// accounts that the caller owns, or all of them for admins
+func ListServiceAccountsCmd() *cobra.Command {
+	listServiceAccounts := &cobra.Command{
+		Use:   "list-service-accounts",
+		Short: "List the service accounts you own",
+		Long:  "List the service accounts you own, or, for admins, every service account",
+		Run: cmdutil.RunFixedArgs(0, func([]string) error {
+			c, err := client.NewOnUserMachine(true, "user")
+			if err != nil {
+				return fmt.Errorf("could not connect: %v", err)
+			}
+			resp, err := c.GetServiceAccounts(c.Ctx(), &auth.GetServiceAccountsRequest{})
+			if err != nil {
+				return grpcutil.ScrubGRPC(err)
+			}
+			for _, serviceAccount := range resp.ServiceAccounts {
+				fmt.Printf("%s\t%s\tinputs: %s\toutputs: %s\n", serviceAccount.Name,
+					serviceAccount.Owner, strings.Join(serviceAccount.InputRepos, ","),
+					strings.Join(serviceAccount.OutputRepos, ","))
+			}
+			return nil
+		}),
+	}
+	return listServiceAccounts
+}
+
+// DeleteServiceAccountCmd returns a cobra command that deletes a service
+// account and revokes its token
+func DeleteServiceAccountCmd() *cobra.Command {
+	deleteServiceAccount := &cobra.Command{
+		Use:   "delete-service-account name",
+		Short: "Delete a service account and revoke its token",
+		Long:  "Delete a service account and revoke its token",
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			c, err := client.NewOnUserMachine(true, "user")
+			if err != nil {
+				return fmt.Errorf("could not connect: %v", err)
+			}
+			_, err = c.DeleteServiceAccount(c.Ctx(), &auth.DeleteServiceAccountRequest{
+				Name: args[0],
+			})
+			return grpcutil.ScrubGRPC(err)
+		}),
+	}
+	return deleteServiceAccount
+}
+
 // Cmds returns a list of cobra commands for authenticating and authorizing
 // users in an auth-enabled Pachyderm cluster.
 func Cmds() []*cobra.Command {
@@ -366,5 +647,13 @@ func Cmds() []*cobra.Command {
 	auth.AddCommand(GetCmd())
 	auth.AddCommand(ListAdminsCmd())
 	auth.AddCommand(ModifyAdminsCmd())
+	auth.AddCommand(ExportACLsCmd())
+	auth.AddCommand(ImportACLsCmd())
+	auth.AddCommand(ApplyACLTemplateCmd())
+	auth.AddCommand(DiffACLsCmd())
+	auth.AddCommand(CreateServiceAccountCmd())
+	auth.AddCommand(RotateServiceAccountTokenCmd())
+	auth.AddCommand(ListServiceAccountsCmd())
+	auth.AddCommand(DeleteServiceAccountCmd())
 	return []*cobra.Command{auth}
 }
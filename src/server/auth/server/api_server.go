@@ -40,6 +40,8 @@ const (
 	aclsPrefix   = "/acls"
 	adminsPrefix = "/admins"
 
+	serviceAccountsPrefix = "/serviceAccounts"
+
 	defaultTokenTTLSecs = 14 * 24 * 60 * 60 // two weeks
 
 	// magicUser is a special, unrevokable cluster administrator. It's not
@@ -72,6 +74,8 @@ type apiServer struct {
 	// admins is a collection of username -> Empty mappings (keys indicate which
 	// github users are cluster admins)
 	admins col.Collection
+	// serviceAccounts is a collection of name -> ServiceAccount mappings.
+	serviceAccounts col.Collection
 }
 
 // LogReq is like log.Logger.Log(), but it assumes that it's being called from
@@ -143,6 +147,13 @@ func NewAuthServer(pachdAddress string, etcdAddress string, etcdPrefix string) (
 			&types.BoolValue{}, // typeof(epsilon) == types.BoolValue; epsilon is the only value
 			nil,
 		),
+		serviceAccounts: col.NewCollection(
+			etcdClient,
+			path.Join(etcdPrefix, serviceAccountsPrefix),
+			nil,
+			&authclient.ServiceAccount{},
+			nil,
+		),
 	}
 	go s.getPachClient() // initialize connection to Pachd
 	go s.watchAdmins(path.Join(etcdPrefix, adminsPrefix))
@@ -264,6 +275,7 @@ func (a *apiServer) Deactivate(ctx context.Context, req *authclient.DeactivateRe
 	_, err = col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
 		a.acls.ReadWrite(stm).DeleteAll()
 		a.tokens.ReadWrite(stm).DeleteAll()
+		a.serviceAccounts.ReadWrite(stm).DeleteAll()
 		a.admins.ReadWrite(stm).DeleteAll() // watchAdmins() will see the write
 		return nil
 	})
@@ -459,7 +471,7 @@ func (a *apiServer) Authorize(ctx context.Context, req *authclient.AuthorizeRequ
 
 	// admins are always authorized
 	if a.isAdmin(user.Username) {
-		return &authclient.AuthorizeResponse{Authorized: true}, nil
+		return &authclient.AuthorizeResponse{Authorized: true, Cached: true}, nil
 	}
 
 	// If the cluster's enterprise token is expired, only admins and pipelines may
@@ -473,17 +485,65 @@ func (a *apiServer) Authorize(ctx context.Context, req *authclient.AuthorizeRequ
 			"cluster (only a cluster admin can authorize)")
 	}
 
+	// Service accounts' access doesn't come from ACLs
+	if user.Type == authclient.User_SERVICE_ACCOUNT {
+		scope, revision, cached, err := a.serviceAccountScope(ctx, user, req.Repo)
+		if err != nil {
+			return nil, err
+		}
+		return &authclient.AuthorizeResponse{
+			Authorized:  req.Scope <= scope,
+			Scope:       scope,
+			ACLRevision: revision,
+			Cached:      cached,
+		}, nil
+	}
+
 	// Get ACL to check
-	var acl authclient.ACL
-	if err := a.acls.ReadOnly(ctx).Get(req.Repo, &acl); err != nil && !col.IsErrNotFound(err) {
-		return nil, fmt.Errorf("error getting ACL for repo \"%s\": %v", req.Repo, err)
+	acl, revision, err := a.getACL(ctx, req.Repo)
+	if err != nil {
+		return nil, err
 	}
 
 	return &authclient.AuthorizeResponse{
-		Authorized: req.Scope <= acl.Entries[user.Username],
+		Authorized:  req.Scope <= acl.Entries[user.Username],
+		Scope:       acl.Entries[user.Username],
+		ACLRevision: revision,
 	}, nil
 }
 
+// getACL reads the ACL of repo, which is empty if it has none, and returns it
+// with the etcd revision at which it was last modified (0 if it has none).
+func (a *apiServer) getACL(ctx context.Context, repo string) (*authclient.ACL, int64, error) {
+	resp, err := a.etcdClient.Get(ctx, a.acls.Path(repo))
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting ACL for repo \"%s\": %v", repo, err)
+	}
+	acl := &authclient.ACL{}
+	if len(resp.Kvs) == 0 {
+		return acl, 0, nil
+	}
+	if err := acl.Unmarshal(resp.Kvs[0].Value); err != nil {
+		return nil, 0, fmt.Errorf("error getting ACL for repo \"%s\": %v", repo, err)
+	}
+	return acl, resp.Kvs[0].ModRevision, nil
+}
+
+// notAuthorizedError returns the error for user not having required access to
+// repo, with the scope and ACL revision that getACL reads.
+func (a *apiServer) notAuthorizedError(ctx context.Context, user *authclient.User, repo string, required authclient.Scope) error {
+	acl, revision, err := a.getACL(ctx, repo)
+	if err != nil {
+		return err
+	}
+	return &authclient.NotAuthorizedError{
+		Repo:        repo,
+		Required:    required,
+		Actual:      acl.Entries[user.Username],
+		ACLRevision: revision,
+	}
+}
+
 func (a *apiServer) WhoAmI(ctx context.Context, req *authclient.WhoAmIRequest) (resp *authclient.WhoAmIResponse, retErr error) {
 	a.LogReq(req)
 	defer func(start time.Time) { a.LogResp(req, resp, retErr, time.Since(start)) }(time.Now())
@@ -569,10 +629,7 @@ func (a *apiServer) SetScope(ctx context.Context, req *authclient.SetScopeReques
 			return err
 		}
 		if !authorized {
-			return &authclient.NotAuthorizedError{
-				Repo:     req.Repo,
-				Required: authclient.Scope_OWNER,
-			}
+			return a.notAuthorizedError(ctx, user, req.Repo, authclient.Scope_OWNER)
 		}
 
 		// Scope change is authorized. Make the change
@@ -620,21 +677,28 @@ func (a *apiServer) GetScope(ctx context.Context, req *authclient.GetScopeReques
 	// what will happen if the user's admin privileges are revoked
 
 	// Read repo ACL from etcd
-	acls := a.acls.ReadOnly(ctx)
 	resp = new(authclient.GetScopeResponse)
 
 	for _, repo := range req.Repos {
-		var acl authclient.ACL
-		if err := acls.Get(repo, &acl); err != nil && !col.IsErrNotFound(err) {
+		acl, revision, err := a.getACL(ctx, repo)
+		if err != nil {
 			return nil, err
 		}
-		if req.Username == "" {
+		if req.Username == "" && user.Type == authclient.User_SERVICE_ACCOUNT {
+			scope, _, _, err := a.serviceAccountScope(ctx, user, repo)
+			if err != nil {
+				return nil, err
+			}
+			resp.Scopes = append(resp.Scopes, scope)
+		} else if req.Username == "" {
 			resp.Scopes = append(resp.Scopes, acl.Entries[user.Username])
 		} else {
 			if !a.isAdmin(user.Username) && acl.Entries[user.Username] < authclient.Scope_READER {
 				return nil, &authclient.NotAuthorizedError{
-					Repo:     repo,
-					Required: authclient.Scope_READER,
+					Repo:        repo,
+					Required:    authclient.Scope_READER,
+					Actual:      acl.Entries[user.Username],
+					ACLRevision: revision,
 				}
 			}
 			resp.Scopes = append(resp.Scopes, acl.Entries[req.Username])
@@ -672,17 +736,20 @@ func (a *apiServer) GetACL(ctx context.Context, req *authclient.GetACLRequest) (
 	}
 
 	// Read repo ACL from etcd
-	resp = &authclient.GetACLResponse{
-		ACL: &authclient.ACL{},
-	}
-	if err = a.acls.ReadOnly(ctx).Get(req.Repo, resp.ACL); err != nil && !col.IsErrNotFound(err) {
+	acl, revision, err := a.getACL(ctx, req.Repo)
+	if err != nil {
 		return nil, err
 	}
+	resp = &authclient.GetACLResponse{
+		ACL: acl,
+	}
 	// For now, require READER access to read repo metadata (commits, and ACLs)
 	if !a.isAdmin(user.Username) && resp.ACL.Entries[user.Username] < authclient.Scope_READER {
 		return nil, &authclient.NotAuthorizedError{
-			Repo:     req.Repo,
-			Required: authclient.Scope_READER,
+			Repo:        req.Repo,
+			Required:    authclient.Scope_READER,
+			Actual:      resp.ACL.Entries[user.Username],
+			ACLRevision: revision,
 		}
 	}
 	return resp, nil
@@ -711,66 +778,12 @@ func (a *apiServer) SetACL(ctx context.Context, req *authclient.SetACLRequest) (
 		acls := a.acls.ReadWrite(stm)
 
 		// determine if the caller is authorized to set this repo's ACL
-		authorized, err := func() (bool, error) {
-			if a.isAdmin(user.Username) {
-				// admins are automatically authorized
-				return true, nil
-			}
-
-			// Check if the cluster's enterprise token is expired (fail if so)
-			state, err := a.getEnterpriseTokenState()
-			if err != nil {
-				return false, fmt.Errorf("error confirming Pachyderm Enterprise token: %v", err)
-			}
-			if state != enterpriseclient.State_ACTIVE {
-				return false, fmt.Errorf("Pachyderm Enterprise is not active in this " +
-					"cluster (only a cluster admin can modify an ACL)")
-			}
-
-			// Check if there is an existing ACL, and if the user is on it
-			var acl authclient.ACL
-			if err := acls.Get(req.Repo, &acl); err != nil {
-				// ACL not found -- construct empty ACL proto
-				acl.Entries = make(map[string]authclient.Scope)
-			}
-			if len(acl.Entries) > 0 {
-				// ACL is present; caller must be authorized directly
-				if acl.Entries[user.Username] == authclient.Scope_OWNER {
-					return true, nil
-				}
-				return false, nil
-			}
-
-			// No ACL -- check if the repo being modified exists
-			pachClient, err := a.getPachClient()
-			if err != nil {
-				return false, fmt.Errorf("could not check if repo \"%s\" exists: %v", req.Repo, err)
-			}
-			_, err = pachClient.InspectRepo(req.Repo)
-			err = grpcutil.ScrubGRPC(err)
-			if err == nil {
-				// Repo exists -- user isn't authorized
-				return false, nil
-			} else if !strings.HasSuffix(err.Error(), "not found") {
-				// Unclear if repo exists -- return error
-				return false, fmt.Errorf("could not inspect \"%s\": %v", req.Repo, err)
-			} else if req.NewACL != nil && len(req.NewACL.Entries) == 1 &&
-				req.NewACL.Entries[user.Username] == authclient.Scope_OWNER {
-				// Special case: Repo doesn't exist, but user is creating a new Repo, and
-				// making themself the owner, e.g. for CreateRepo or CreatePipeline, then
-				// the request is authorized
-				return true, nil
-			}
-			return false, err
-		}()
+		authorized, err := a.canSetACL(acls, user, req.Repo, req.NewACL)
 		if err != nil {
 			return err
 		}
 		if !authorized {
-			return &authclient.NotAuthorizedError{
-				Repo:     req.Repo,
-				Required: authclient.Scope_OWNER,
-			}
+			return a.notAuthorizedError(ctx, user, req.Repo, authclient.Scope_OWNER)
 		}
 
 		// Set new ACL
@@ -785,13 +798,70 @@ func (a *apiServer) SetACL(ctx context.Context, req *authclient.SetACLRequest) (
 	return &authclient.SetACLResponse{}, nil
 }
 
+// canSetACL returns true if user is authorized to set the ACL of repo to
+// newACL. acls must be read in the transaction that sets it.
+func (a *apiServer) canSetACL(acls col.ReadWriteCollection, user *authclient.User, repo string, newACL *authclient.ACL) (bool, error) {
+	if a.isAdmin(user.Username) {
+		// admins are automatically authorized
+		return true, nil
+	}
+
+	// Check if the cluster's enterprise token is expired (fail if so)
+	state, err := a.getEnterpriseTokenState()
+	if err != nil {
+		return false, fmt.Errorf("error confirming Pachyderm Enterprise token: %v", err)
+	}
+	if state != enterpriseclient.State_ACTIVE {
+		return false, fmt.Errorf("Pachyderm Enterprise is not active in this " +
+			"cluster (only a cluster admin can modify an ACL)")
+	}
+
+	// Check if there is an existing ACL, and if the user is on it
+	var acl authclient.ACL
+	if err := acls.Get(repo, &acl); err != nil {
+		// ACL not found -- construct empty ACL proto
+		acl.Entries = make(map[string]authclient.Scope)
+	}
+	if len(acl.Entries) > 0 {
+		// ACL is present; caller must be authorized directly
+		if acl.Entries[user.Username] == authclient.Scope_OWNER {
+			return true, nil
+		}
+		return false, nil
+	}
+
+	// No ACL -- check if the repo being modified exists
+	pachClient, err := a.getPachClient()
+	if err != nil {
+		return false, fmt.Errorf("could not check if repo \"%s\" exists: %v", repo, err)
+	}
+	_, err = pachClient.InspectRepo(repo)
+	err = grpcutil.ScrubGRPC(err)
+	if err == nil {
+		// Repo exists -- user isn't authorized
+		return false, nil
+	} else if !strings.HasSuffix(err.Error(), "not found") {
+		// Unclear if repo exists -- return error
+		return false, fmt.Errorf("could not inspect \"%s\": %v", repo, err)
+	} else if newACL != nil && len(newACL.Entries) == 1 &&
+		newACL.Entries[user.Username] == authclient.Scope_OWNER {
+		// Special case: Repo doesn't exist, but user is creating a new Repo, and
+		// making themself the owner, e.g. for CreateRepo or CreatePipeline, then
+		// the request is authorized
+		return true, nil
+	}
+	return false, err
+}
+
 func (a *apiServer) GetCapability(ctx context.Context, req *authclient.GetCapabilityRequest) (resp *authclient.GetCapabilityResponse, retErr error) {
 	a.LogReq(req)
 	defer func(start time.Time) { a.LogResp(req, resp, retErr, time.Since(start)) }(time.Now())
 
 	// Generate User that the capability token will point to
 	var user *authclient.User
-	if !a.isActivated() {
+	if !a.isActivated() && req.ServiceAccount != "" {
+		return nil, authclient.NotActivatedError{}
+	} else if !a.isActivated() {
 		// If auth service is not activated, we want to return a capability
 		// that's able to access any repo.  That way, when we create a
 		// pipeline, we can assign it with a capability that would allow
@@ -811,6 +881,12 @@ func (a *apiServer) GetCapability(ctx context.Context, req *authclient.GetCapabi
 	capability := uuid.NewWithoutDashes()
 	_, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
 		tokens := a.tokens.ReadWrite(stm)
+		if req.ServiceAccount != "" {
+			var err error
+			if user, err = a.serviceAccountCapabilityUser(stm, user, req.ServiceAccount); err != nil {
+				return err
+			}
+		}
 		// Capabilities are forever; they don't expire.
 		return tokens.Put(hashToken(capability), user)
 	})
@@ -842,7 +918,17 @@ func (a *apiServer) RevokeAuthToken(ctx context.Context, req *authclient.RevokeA
 		if err := tokens.Get(hashToken(req.Token), &user); err != nil && !col.IsErrNotFound(err) {
 			return err
 		}
-		if user.Type != authclient.User_PIPELINE {
+		if user.Type == authclient.User_SERVICE_ACCOUNT {
+			// only the capabilities of a service account can be revoked,
+			// its own token is rotated
+			isCapability, err := a.isServiceAccountCapability(stm, &user, hashToken(req.Token))
+			if err != nil {
+				return err
+			}
+			if !isCapability {
+				return fmt.Errorf("cannot revoke a service account's token, rotate it instead")
+			}
+		} else if user.Type != authclient.User_PIPELINE {
 			return fmt.Errorf("cannot revoke a non-pipeline auth token")
 		}
 		return tokens.Delete(hashToken(req.Token))
@@ -1184,8 +1184,515 @@ func TestGetScopeRequiresReader(t *testing.T) {
 	require.Matches(t, "not authorized", err.Error())
 }
 
+// TestBulkACLs tests exporting, templating, diffing and importing the ACLs of
+// several repos at once
+func TestBulkACLs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+	alice, bob := uniqueString("alice"), uniqueString("bob")
+	aliceClient, bobClient := getPachClient(t, alice), getPachClient(t, bob)
+
+	// alice creates two repos with a common prefix
+	prefix := uniqueString("TestBulkACLs")
+	repoA, repoB := prefix+"_a", prefix+"_b"
+	require.NoError(t, aliceClient.CreateRepo(repoA))
+	require.NoError(t, aliceClient.CreateRepo(repoB))
+
+	// alice gives bob READER access to both with a template
+	applyResp, err := aliceClient.ApplyACLTemplate(aliceClient.Ctx(), &auth.ApplyACLTemplateRequest{
+		Template: acl(bob, "reader"),
+		Repos:    []string{prefix + "_*"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{repoA, repoB}, applyResp.Repos)
+	require.Equal(t, acl(alice, "owner", bob, "reader"), GetACL(t, aliceClient, repoA))
+	require.Equal(t, acl(alice, "owner", bob, "reader"), GetACL(t, aliceClient, repoB))
+
+	// bob can export the ACLs, but can't apply a template to them
+	exportResp, err := bobClient.ExportACLs(bobClient.Ctx(), &auth.ExportACLsRequest{
+		Repos: []string{repoA, repoB},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(exportResp.ACLs))
+	require.Equal(t, acl(alice, "owner", bob, "reader"), exportResp.ACLs[1].ACL)
+	_, err = bobClient.ApplyACLTemplate(bobClient.Ctx(), &auth.ApplyACLTemplateRequest{
+		Template: acl(bob, "owner"),
+		Repos:    []string{prefix + "_*"},
+	})
+	require.YesError(t, err)
+	require.Matches(t, "not authorized", err.Error())
+
+	// alice diffs a desired state in which bob can write to repoB
+	desired := []*auth.RepoACL{
+		{Repo: repoA, ACL: acl(alice, "owner", bob, "reader")},
+		{Repo: repoB, ACL: acl(alice, "owner", bob, "writer")},
+	}
+	diffResp, err := aliceClient.DiffACLs(aliceClient.Ctx(), &auth.DiffACLsRequest{
+		Desired: desired,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []*auth.ACLChange{{
+		Repo:     repoB,
+		Username: bob,
+		Actual:   auth.Scope_READER,
+		Desired:  auth.Scope_WRITER,
+	}}, diffResp.Changes)
+
+	// alice imports the desired state, after which nothing differs
+	_, err = aliceClient.ImportACLs(aliceClient.Ctx(), &auth.ImportACLsRequest{
+		ACLs: desired,
+	})
+	require.NoError(t, err)
+	require.Equal(t, acl(alice, "owner", bob, "writer"), GetACL(t, aliceClient, repoB))
+	diffResp, err = aliceClient.DiffACLs(aliceClient.Ctx(), &auth.DiffACLsRequest{
+		Desired: desired,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 0, len(diffResp.Changes))
+}
+
 // TestListRepoNotLoggedInError makes sure that if a user isn't logged in, and
 // they call ListRepo(), they get an error.
+func TestServiceAccounts(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+	alice, bob := uniqueString("alice"), uniqueString("bob")
+	aliceClient, bobClient := getPachClient(t, alice), getPachClient(t, bob)
+
+	// alice creates an input, an output and an unrelated repo
+	prefix := uniqueString("TestServiceAccounts")
+	input, output, other := prefix+"_in", prefix+"_out", prefix+"_other"
+	require.NoError(t, aliceClient.CreateRepo(input))
+	require.NoError(t, aliceClient.CreateRepo(output))
+	require.NoError(t, aliceClient.CreateRepo(other))
+	commit, err := aliceClient.StartCommit(input, "master")
+	require.NoError(t, err)
+	_, err = aliceClient.PutFile(input, commit.ID, "file", strings.NewReader("foo"))
+	require.NoError(t, err)
+	require.NoError(t, aliceClient.FinishCommit(input, commit.ID))
+
+	// bob can't create a service account with access that bob doesn't have
+	name := uniqueString("sa")
+	_, err = bobClient.CreateServiceAccount(bobClient.Ctx(), &auth.CreateServiceAccountRequest{
+		Name:       name,
+		InputRepos: []string{input},
+	})
+	require.YesError(t, err)
+	require.Matches(t, "not authorized", err.Error())
+
+	// alice creates one that reads input and writes output
+	createResp, err := aliceClient.CreateServiceAccount(aliceClient.Ctx(), &auth.CreateServiceAccountRequest{
+		Name:        name,
+		InputRepos:  []string{input},
+		OutputRepos: []string{output},
+	})
+	require.NoError(t, err)
+	saClient := *getPachClient(t, "")
+	saClient.SetAuthToken(createResp.Token)
+	whoAmIResp, err := saClient.WhoAmI(saClient.Ctx(), &auth.WhoAmIRequest{})
+	require.NoError(t, err)
+	require.Equal(t, "service:"+name, whoAmIResp.Username)
+
+	// the service account can read input and write output, and nothing else
+	var buf bytes.Buffer
+	require.NoError(t, saClient.GetFile(input, "master", "file", 0, 0, &buf))
+	require.Equal(t, "foo", buf.String())
+	commit, err = saClient.StartCommit(output, "master")
+	require.NoError(t, err)
+	require.NoError(t, saClient.FinishCommit(output, commit.ID))
+	_, err = saClient.StartCommit(input, "master")
+	require.YesError(t, err)
+	require.Matches(t, "not authorized", err.Error())
+	_, err = saClient.StartCommit(other, "master")
+	require.YesError(t, err)
+	require.Matches(t, "not authorized", err.Error())
+	scopeResp, err := saClient.GetScope(saClient.Ctx(), &auth.GetScopeRequest{
+		Repos: []string{input, output, other},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []auth.Scope{auth.Scope_READER, auth.Scope_WRITER, auth.Scope_NONE}, scopeResp.Scopes)
+
+	// bob doesn't see alice's service account, alice does
+	listResp, err := bobClient.GetServiceAccounts(bobClient.Ctx(), &auth.GetServiceAccountsRequest{})
+	require.NoError(t, err)
+	for _, serviceAccount := range listResp.ServiceAccounts {
+		require.NotEqual(t, name, serviceAccount.Name)
+	}
+	listResp, err = aliceClient.GetServiceAccounts(aliceClient.Ctx(), &auth.GetServiceAccountsRequest{})
+	require.NoError(t, err)
+	var found bool
+	for _, serviceAccount := range listResp.ServiceAccounts {
+		if serviceAccount.Name == name {
+			found = true
+			require.Equal(t, alice, serviceAccount.Owner)
+			require.Equal(t, "", serviceAccount.TokenHash)
+		}
+	}
+	require.True(t, found)
+
+	// a service account never has more access than its owner: bob creates one
+	// that reads input, which stops working when alice revokes bob's access
+	_, err = aliceClient.SetScope(aliceClient.Ctx(), &auth.SetScopeRequest{
+		Username: bob,
+		Scope:    auth.Scope_READER,
+		Repo:     input,
+	})
+	require.NoError(t, err)
+	bobName := uniqueString("sa")
+	bobResp, err := bobClient.CreateServiceAccount(bobClient.Ctx(), &auth.CreateServiceAccountRequest{
+		Name:       bobName,
+		InputRepos: []string{input},
+	})
+	require.NoError(t, err)
+	bobSAClient := *getPachClient(t, "")
+	bobSAClient.SetAuthToken(bobResp.Token)
+	buf.Reset()
+	require.NoError(t, bobSAClient.GetFile(input, "master", "file", 0, 0, &buf))
+	_, err = aliceClient.SetScope(aliceClient.Ctx(), &auth.SetScopeRequest{
+		Username: bob,
+		Scope:    auth.Scope_NONE,
+		Repo:     input,
+	})
+	require.NoError(t, err)
+	err = bobSAClient.GetFile(input, "master", "file", 0, 0, &buf)
+	require.YesError(t, err)
+	require.Matches(t, "not authorized", err.Error())
+
+	// rotating the token revokes the old one
+	rotateResp, err := aliceClient.RotateServiceAccountToken(aliceClient.Ctx(), &auth.RotateServiceAccountTokenRequest{
+		Name: name,
+	})
+	require.NoError(t, err)
+	_, err = saClient.WhoAmI(saClient.Ctx(), &auth.WhoAmIRequest{})
+	require.YesError(t, err)
+	saClient.SetAuthToken(rotateResp.Token)
+	_, err = saClient.WhoAmI(saClient.Ctx(), &auth.WhoAmIRequest{})
+	require.NoError(t, err)
+
+	// only the owner can delete it, which revokes its token
+	_, err = bobClient.DeleteServiceAccount(bobClient.Ctx(), &auth.DeleteServiceAccountRequest{
+		Name: name,
+	})
+	require.YesError(t, err)
+	_, err = aliceClient.DeleteServiceAccount(aliceClient.Ctx(), &auth.DeleteServiceAccountRequest{
+		Name: name,
+	})
+	require.NoError(t, err)
+	_, err = saClient.WhoAmI(saClient.Ctx(), &auth.WhoAmIRequest{})
+	require.YesError(t, err)
+}
+
+func TestServiceAccountPipeline(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+	alice := uniqueString("alice")
+	aliceClient := getPachClient(t, alice)
+
+	// alice creates a service account that reads input and writes the output
+	// repo of a pipeline that doesn't exist yet
+	input, other := uniqueString("TestServiceAccountPipeline_in"), uniqueString("TestServiceAccountPipeline_other")
+	require.NoError(t, aliceClient.CreateRepo(input))
+	require.NoError(t, aliceClient.CreateRepo(other))
+	pipeline := uniqueString("alice-pipeline")
+	name := uniqueString("sa")
+	_, err := aliceClient.CreateServiceAccount(aliceClient.Ctx(), &auth.CreateServiceAccountRequest{
+		Name:        name,
+		InputRepos:  []string{input},
+		OutputRepos: []string{pipeline},
+	})
+	require.NoError(t, err)
+
+	createPipeline := func(pipeline string, repo string, serviceAccount string) error {
+		_, err := aliceClient.PpsAPIClient.CreatePipeline(aliceClient.Ctx(), &pps.CreatePipelineRequest{
+			Pipeline: client.NewPipeline(pipeline),
+			Transform: &pps.Transform{
+				Cmd:   []string{"bash"},
+				Stdin: []string{fmt.Sprintf("cp /pfs/%s/* /pfs/out/", repo)},
+			},
+			ParallelismSpec: &pps.ParallelismSpec{Constant: 1},
+			Input:           client.NewAtomInput(repo, "/*"),
+			ServiceAccount:  serviceAccount,
+		})
+		return err
+	}
+	// a pipeline can't run as a service account that can't read its inputs,
+	// or write its output repo
+	require.YesError(t, createPipeline(pipeline, other, name))
+	require.YesError(t, createPipeline(uniqueString("alice-pipeline"), input, name))
+	require.YesError(t, createPipeline(pipeline, input, uniqueString("missing")))
+	require.NoError(t, createPipeline(pipeline, input, name))
+	pipelineInfo, err := aliceClient.InspectPipeline(pipeline)
+	require.NoError(t, err)
+	require.Equal(t, name, pipelineInfo.ServiceAccount)
+
+	// its workers run as the service account
+	commit, err := aliceClient.StartCommit(input, "master")
+	require.NoError(t, err)
+	_, err = aliceClient.PutFile(input, commit.ID, "file", strings.NewReader("foo"))
+	require.NoError(t, err)
+	require.NoError(t, aliceClient.FinishCommit(input, commit.ID))
+	iter, err := aliceClient.FlushCommit(
+		[]*pfs.Commit{commit},
+		[]*pfs.Repo{{Name: pipeline}},
+	)
+	require.NoError(t, err)
+	require.NoErrorWithinT(t, 60*time.Second, func() error {
+		_, err := iter.Next()
+		return err
+	})
+	var buf bytes.Buffer
+	require.NoError(t, aliceClient.GetFile(pipeline, "master", "file", 0, 0, &buf))
+	require.Equal(t, "foo", buf.String())
+
+	// deleting the pipeline revokes its capability, but not the service
+	// account
+	require.NoError(t, aliceClient.DeletePipeline(pipeline, true))
+	listResp, err := aliceClient.GetServiceAccounts(aliceClient.Ctx(), &auth.GetServiceAccountsRequest{})
+	require.NoError(t, err)
+	var found bool
+	for _, serviceAccount := range listResp.ServiceAccounts {
+		found = found || serviceAccount.Name == name
+	}
+	require.True(t, found)
+}
+
+func TestNotAuthorizedErrorDiagnostics(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+	alice, bob := uniqueString("alice"), uniqueString("bob")
+	aliceClient, bobClient := getPachClient(t, alice), getPachClient(t, bob)
+
+	// alice creates a repo and gives bob READER access
+	repo := uniqueString("TestNotAuthorizedErrorDiagnostics")
+	require.NoError(t, aliceClient.CreateRepo(repo))
+	_, err := aliceClient.SetScope(aliceClient.Ctx(), &auth.SetScopeRequest{
+		Username: bob,
+		Scope:    auth.Scope_READER,
+		Repo:     repo,
+	})
+	require.NoError(t, err)
+
+	// bob can see which scope bob has, and which ACL it was read from
+	_, err = bobClient.StartCommit(repo, "master")
+	require.YesError(t, err)
+	require.Matches(t, "must have at least WRITER access \\(you have READER "+
+		"access, as of ACL revision [1-9][0-9]*\\)", err.Error())
+	_, err = bobClient.SetScope(bobClient.Ctx(), &auth.SetScopeRequest{
+		Username: bob,
+		Scope:    auth.Scope_OWNER,
+		Repo:     repo,
+	})
+	require.YesError(t, err)
+	require.Matches(t, "must have at least OWNER access \\(you have READER "+
+		"access, as of ACL revision [1-9][0-9]*\\)", err.Error())
+	resp, err := bobClient.Authorize(bobClient.Ctx(), &auth.AuthorizeRequest{
+		Repo:  repo,
+		Scope: auth.Scope_WRITER,
+	})
+	require.NoError(t, err)
+	require.False(t, resp.Authorized)
+	require.Equal(t, auth.Scope_READER, resp.Scope)
+	require.True(t, resp.ACLRevision > 0)
+	require.False(t, resp.Cached)
+
+	// the scope of a service account owned by an admin comes from the cache
+	// of admins
+	adminClient := getPachClient(t, "admin")
+	createResp, err := adminClient.CreateServiceAccount(adminClient.Ctx(), &auth.CreateServiceAccountRequest{
+		Name:       uniqueString("sa"),
+		InputRepos: []string{repo},
+	})
+	require.NoError(t, err)
+	saClient := *getPachClient(t, "")
+	saClient.SetAuthToken(createResp.Token)
+	_, err = saClient.StartCommit(repo, "master")
+	require.YesError(t, err)
+	require.Matches(t, "must have at least WRITER access \\(you have READER "+
+		"access, from cache\\)", err.Error())
+	resp, err = saClient.Authorize(saClient.Ctx(), &auth.AuthorizeRequest{
+		Repo:  repo,
+		Scope: auth.Scope_WRITER,
+	})
+	require.NoError(t, err)
+	require.False(t, resp.Authorized)
+	require.Equal(t, auth.Scope_READER, resp.Scope)
+	require.True(t, resp.Cached)
+}
+
+func TestProtectedPaths(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+	alice, bob := uniqueString("alice"), uniqueString("bob")
+	aliceClient, bobClient := getPachClient(t, alice), getPachClient(t, bob)
+
+	// alice creates a repo, protects /raw and gives bob WRITER access
+	repo := uniqueString("TestProtectedPaths")
+	require.NoError(t, aliceClient.CreateRepo(repo))
+	require.YesError(t, aliceClient.SetProtectedPaths(repo, "/raw/*.csv"))
+	require.NoError(t, aliceClient.SetProtectedPaths(repo, "/raw/**"))
+	repoInfo, err := aliceClient.InspectRepo(repo)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/raw"}, repoInfo.ProtectedPaths)
+	_, err = aliceClient.SetScope(aliceClient.Ctx(), &auth.SetScopeRequest{
+		Username: bob,
+		Scope:    auth.Scope_WRITER,
+		Repo:     repo,
+	})
+	require.NoError(t, err)
+
+	// bob can't protect or unprotect paths
+	err = bobClient.SetProtectedPaths(repo)
+	require.YesError(t, err)
+	require.Matches(t, "not authorized", err.Error())
+
+	// alice can write under /raw, bob can only write elsewhere
+	before, err := aliceClient.StartCommit(repo, "master")
+	require.NoError(t, err)
+	require.NoError(t, aliceClient.FinishCommit(repo, before.ID))
+	commit, err := aliceClient.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = aliceClient.PutFile(repo, commit.ID, "raw/data", strings.NewReader("foo"))
+	require.NoError(t, err)
+	_, err = bobClient.PutFile(repo, commit.ID, "raw/other", strings.NewReader("foo"))
+	require.YesError(t, err)
+	require.Matches(t, "protected path \"/raw\"", err.Error())
+	_, err = bobClient.PutFile(repo, commit.ID, "rawdata", strings.NewReader("foo"))
+	require.NoError(t, err)
+	require.YesError(t, bobClient.CopyFile(repo, commit.ID, "rawdata", repo, commit.ID, "raw/copy", false))
+	require.YesError(t, bobClient.DeleteFile(repo, commit.ID, "raw/data"))
+	// deleting a directory that contains a protected path is also a write to it
+	require.YesError(t, bobClient.DeleteFile(repo, commit.ID, "/"))
+	require.YesError(t, bobClient.DeleteFileGlob(repo, commit.ID, "r*"))
+	require.NoError(t, bobClient.DeleteFile(repo, commit.ID, "rawdata"))
+	require.NoError(t, aliceClient.FinishCommit(repo, commit.ID))
+	// nor can bob change /raw by starting a commit from a base without it
+	_, err = bobClient.StartCommitFromBase(repo, "master", "", before.ID)
+	require.YesError(t, err)
+	require.Matches(t, "protected path \"/raw\"", err.Error())
+
+	// once alice unprotects the repo, bob can write anywhere
+	require.NoError(t, aliceClient.SetProtectedPaths(repo))
+	commit, err = bobClient.StartCommit(repo, "master")
+	require.NoError(t, err)
+	require.NoError(t, bobClient.DeleteFile(repo, commit.ID, "raw/data"))
+	require.NoError(t, bobClient.FinishCommit(repo, commit.ID))
+}
+
+func TestReadFilter(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+	alice, bob := uniqueString("alice"), uniqueString("bob")
+	aliceClient, bobClient := getPachClient(t, alice), getPachClient(t, bob)
+
+	// alice creates a repo with public and private records, and gives bob
+	// READER access
+	repo := uniqueString("TestReadFilter")
+	require.NoError(t, aliceClient.CreateRepo(repo))
+	_, err := aliceClient.PutFile(repo, "master", "data", strings.NewReader("public,1\nprivate,2\npublic,3\n"))
+	require.NoError(t, err)
+	_, err = aliceClient.SetScope(aliceClient.Ctx(), &auth.SetScopeRequest{
+		Username: bob,
+		Scope:    auth.Scope_READER,
+		Repo:     repo,
+	})
+	require.NoError(t, err)
+
+	// bob can't set a read filter, alice can
+	err = bobClient.SetReadFilter(repo, &pfs.ReadFilter{Regex: "^public,"})
+	require.YesError(t, err)
+	require.Matches(t, "not authorized", err.Error())
+	require.YesError(t, aliceClient.SetReadFilter(repo, &pfs.ReadFilter{Regex: "("}))
+	require.NoError(t, aliceClient.SetReadFilter(repo, &pfs.ReadFilter{Regex: "^public,"}))
+	repoInfo, err := aliceClient.InspectRepo(repo)
+	require.NoError(t, err)
+	require.Equal(t, auth.Scope_WRITER, repoInfo.ReadFilter.ExemptScope)
+
+	// alice reads the whole file, bob only reads the public records
+	var buf bytes.Buffer
+	require.NoError(t, aliceClient.GetFile(repo, "master", "data", 0, 0, &buf))
+	require.Equal(t, "public,1\nprivate,2\npublic,3\n", buf.String())
+	buf.Reset()
+	require.NoError(t, bobClient.GetFile(repo, "master", "data", 0, 0, &buf))
+	require.Equal(t, "public,1\npublic,3\n", buf.String())
+	buf.Reset()
+	require.NoError(t, bobClient.GetFile(repo, "master", "data", 9, 0, &buf))
+	require.Equal(t, "public,3\n", buf.String())
+	buf.Reset()
+	require.NoError(t, bobClient.FilterFile(repo, "master", "*", "2", &buf))
+	require.Equal(t, "", buf.String())
+	require.YesError(t, bobClient.GetFileTar(repo, "master", "/", &buf))
+
+	// bob can't get the file's objects, which can be read unfiltered
+	fileInfo, err := aliceClient.InspectFile(repo, "master", "data")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(fileInfo.Objects))
+	fileInfo, err = bobClient.InspectFile(repo, "master", "data")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(fileInfo.Objects))
+	_, err = bobClient.GetManifest(repo, "master")
+	require.YesError(t, err)
+	bobRepo := uniqueString("TestReadFilterBob")
+	require.NoError(t, bobClient.CreateRepo(bobRepo))
+	_, err = bobClient.StartCommit(bobRepo, "master")
+	require.NoError(t, err)
+	require.YesError(t, bobClient.CopyFile(repo, "master", "data", bobRepo, "master", "data", false))
+
+	// once alice removes the filter, bob reads the whole file
+	require.NoError(t, aliceClient.SetReadFilter(repo, nil))
+	buf.Reset()
+	require.NoError(t, bobClient.GetFile(repo, "master", "data", 0, 0, &buf))
+	require.Equal(t, "public,1\nprivate,2\npublic,3\n", buf.String())
+}
+
+func TestSearchDataCardsSkipsUnreadableRepos(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+	alice, bob := uniqueString("alice"), uniqueString("bob")
+	aliceClient, bobClient := getPachClient(t, alice), getPachClient(t, bob)
+
+	// alice and bob each create a repo with a data card that mentions query
+	query := uniqueString("TestSearchDataCards")
+	var commits []*pfs.Commit
+	for _, c := range []*client.APIClient{aliceClient, bobClient} {
+		repo := uniqueString("TestSearchDataCardsSkipsUnreadableRepos")
+		require.NoError(t, c.CreateRepo(repo))
+		commit, err := c.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, c.FinishCommitWithDataCard(repo, commit.ID, &pfs.DataCard{Description: query}))
+		commits = append(commits, commit)
+	}
+
+	// an unscoped search by bob only finds bob's commit, as bob can't read
+	// alice's repo, but searching alice's repo directly still fails
+	commitInfos, err := bobClient.SearchDataCards("", query, "")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(commitInfos))
+	require.Equal(t, commits[1].ID, commitInfos[0].Commit.ID)
+	_, err = bobClient.SearchDataCards(commits[0].Repo.Name, query, "")
+	require.YesError(t, err)
+	require.Matches(t, "not authorized", err.Error())
+
+	// alice's search only finds alice's commit
+	commitInfos, err = aliceClient.SearchDataCards("", query, "")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(commitInfos))
+	require.Equal(t, commits[0].ID, commitInfos[0].Commit.ID)
+}
+
 func TestListRepoNotLoggedInError(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
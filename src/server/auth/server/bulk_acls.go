@@ -0,0 +1,280 @@
+package server
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"golang.org/x/net/context"
+
+	authclient "github.com/pachyderm/pachyderm/src/client/auth"
+	enterpriseclient "github.com/pachyderm/pachyderm/src/client/enterprise"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+)
+
+// getACLReader returns the calling user, who may read the ACLs of the repos
+// they have READER access to, unless Pachyderm Enterprise isn't active and
+// they aren't an admin.
+func (a *apiServer) getACLReader(ctx context.Context) (*authclient.User, error) {
+	if !a.isActivated() {
+		return nil, authclient.NotActivatedError{}
+	}
+	user, err := a.getAuthenticatedUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	state, err := a.getEnterpriseTokenState()
+	if err != nil {
+		return nil, fmt.Errorf("error confirming Pachyderm Enterprise token: %v", err)
+	}
+	if state != enterpriseclient.State_ACTIVE && !a.isAdmin(user.Username) {
+		return nil, fmt.Errorf("Pachyderm Enterprise is not active in this " +
+			"cluster (only a cluster admin can perform any operations)")
+	}
+	return user, nil
+}
+
+// canReadACL returns true if user can read acl, the ACL of a repo.
+func (a *apiServer) canReadACL(user *authclient.User, acl *authclient.ACL) bool {
+	return a.isAdmin(user.Username) || acl.Entries[user.Username] >= authclient.Scope_READER
+}
+
+// readACL reads the ACL of repo, which is empty if it has none, checking that
+// user can read it.
+func (a *apiServer) readACL(ctx context.Context, user *authclient.User, repo string) (*authclient.ACL, error) {
+	acl, revision, err := a.getACL(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	if !a.canReadACL(user, acl) {
+		return nil, &authclient.NotAuthorizedError{
+			Repo:        repo,
+			Required:    authclient.Scope_READER,
+			Actual:      acl.Entries[user.Username],
+			ACLRevision: revision,
+		}
+	}
+	return acl, nil
+}
+
+func (a *apiServer) ExportACLs(ctx context.Context, req *authclient.ExportACLsRequest) (resp *authclient.ExportACLsResponse, retErr error) {
+	a.LogReq(req)
+	defer func(start time.Time) { a.LogResp(req, resp, retErr, time.Since(start)) }(time.Now())
+	user, err := a.getACLReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp = &authclient.ExportACLsResponse{}
+	if len(req.Repos) > 0 {
+		for _, repo := range req.Repos {
+			acl, err := a.readACL(ctx, user, repo)
+			if err != nil {
+				return nil, err
+			}
+			resp.ACLs = append(resp.ACLs, &authclient.RepoACL{Repo: repo, ACL: acl})
+		}
+		return resp, nil
+	}
+	// every ACL that the caller can read, the others are skipped
+	iter, err := a.acls.ReadOnly(ctx).List()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var repo string
+		acl := &authclient.ACL{}
+		ok, err := iter.Next(&repo, acl)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		if a.canReadACL(user, acl) {
+			resp.ACLs = append(resp.ACLs, &authclient.RepoACL{Repo: repo, ACL: acl})
+		}
+	}
+	sort.Slice(resp.ACLs, func(i, j int) bool { return resp.ACLs[i].Repo < resp.ACLs[j].Repo })
+	return resp, nil
+}
+
+func (a *apiServer) ImportACLs(ctx context.Context, req *authclient.ImportACLsRequest) (resp *authclient.ImportACLsResponse, retErr error) {
+	a.LogReq(req)
+	defer func(start time.Time) { a.LogResp(req, resp, retErr, time.Since(start)) }(time.Now())
+	if !a.isActivated() {
+		return nil, authclient.NotActivatedError{}
+	}
+	for _, repoACL := range req.ACLs {
+		if repoACL.Repo == "" {
+			return nil, fmt.Errorf("invalid request: every ACL must have the name of its repo")
+		}
+	}
+	user, err := a.getAuthenticatedUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
+		acls := a.acls.ReadWrite(stm)
+		for _, repoACL := range req.ACLs {
+			if err := a.putACL(ctx, acls, user, repoACL.Repo, repoACL.ACL); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("could not import ACLs: %v", err)
+	}
+	return &authclient.ImportACLsResponse{}, nil
+}
+
+// putACL sets the ACL of repo to acl, in the transaction that acls is read
+// in, if user is authorized to.
+func (a *apiServer) putACL(ctx context.Context, acls col.ReadWriteCollection, user *authclient.User, repo string, acl *authclient.ACL) error {
+	authorized, err := a.canSetACL(acls, user, repo, acl)
+	if err != nil {
+		return err
+	}
+	if !authorized {
+		return a.notAuthorizedError(ctx, user, repo, authclient.Scope_OWNER)
+	}
+	if acl == nil || len(acl.Entries) == 0 {
+		return acls.Delete(repo)
+	}
+	return acls.Put(repo, acl)
+}
+
+func (a *apiServer) ApplyACLTemplate(ctx context.Context, req *authclient.ApplyACLTemplateRequest) (resp *authclient.ApplyACLTemplateResponse, retErr error) {
+	a.LogReq(req)
+	defer func(start time.Time) { a.LogResp(req, resp, retErr, time.Since(start)) }(time.Now())
+	if !a.isActivated() {
+		return nil, authclient.NotActivatedError{}
+	}
+	if len(req.Repos) == 0 {
+		return nil, fmt.Errorf("invalid request: must provide the repos to apply the template to")
+	}
+	user, err := a.getAuthenticatedUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	repos, err := a.matchRepos(ctx, req.Repos)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
+		acls := a.acls.ReadWrite(stm)
+		for _, repo := range repos {
+			acl := &authclient.ACL{Entries: make(map[string]authclient.Scope)}
+			if !req.Replace {
+				if err := acls.Get(repo, acl); err != nil && !col.IsErrNotFound(err) {
+					return err
+				}
+				if acl.Entries == nil {
+					acl.Entries = make(map[string]authclient.Scope)
+				}
+			}
+			if req.Template != nil {
+				for username, scope := range req.Template.Entries {
+					if scope == authclient.Scope_NONE {
+						delete(acl.Entries, username)
+					} else {
+						acl.Entries[username] = scope
+					}
+				}
+			}
+			if err := a.putACL(ctx, acls, user, repo, acl); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("could not apply ACL template: %v", err)
+	}
+	return &authclient.ApplyACLTemplateResponse{Repos: repos}, nil
+}
+
+// matchRepos returns the names of the existing repos that patterns, which
+// are repo names or glob patterns, match, in order.
+func (a *apiServer) matchRepos(ctx context.Context, patterns []string) ([]string, error) {
+	pachClient, err := a.getPachClient()
+	if err != nil {
+		return nil, err
+	}
+	repoInfos, err := pachClient.WithCtx(ctx).ListRepo(nil)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	matched := make(map[string]bool)
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid repo pattern %q: %v", pattern, err)
+		}
+		var found bool
+		for _, repoInfo := range repoInfos {
+			if ok, _ := path.Match(pattern, repoInfo.Repo.Name); ok {
+				matched[repoInfo.Repo.Name] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no repos match %q", pattern)
+		}
+	}
+	var repos []string
+	for repo := range matched {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+	return repos, nil
+}
+
+func (a *apiServer) DiffACLs(ctx context.Context, req *authclient.DiffACLsRequest) (resp *authclient.DiffACLsResponse, retErr error) {
+	a.LogReq(req)
+	defer func(start time.Time) { a.LogResp(req, resp, retErr, time.Since(start)) }(time.Now())
+	user, err := a.getACLReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp = &authclient.DiffACLsResponse{}
+	for _, desired := range req.Desired {
+		actual, err := a.readACL(ctx, user, desired.Repo)
+		if err != nil {
+			return nil, err
+		}
+		resp.Changes = append(resp.Changes, diffACL(desired.Repo, actual, desired.ACL)...)
+	}
+	return resp, nil
+}
+
+// diffACL returns the users whose scopes in repo differ between actual and
+// desired, either of which may be nil, ordered by username.
+func diffACL(repo string, actual *authclient.ACL, desired *authclient.ACL) []*authclient.ACLChange {
+	usernames := make(map[string]bool)
+	for _, acl := range []*authclient.ACL{actual, desired} {
+		if acl == nil {
+			continue
+		}
+		for username := range acl.Entries {
+			usernames[username] = true
+		}
+	}
+	var changes []*authclient.ACLChange
+	for username := range usernames {
+		change := &authclient.ACLChange{
+			Repo:     repo,
+			Username: username,
+			Actual:   actual.GetEntries()[username],
+			Desired:  desired.GetEntries()[username],
+		}
+		if change.Actual != change.Desired {
+			changes = append(changes, change)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Username < changes[j].Username })
+	return changes
+}
@@ -0,0 +1,293 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+
+	authclient "github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+)
+
+// serviceAccountUserPrefix prefixes the username of service accounts, so
+// that they can't be mistaken for GitHub users (whose names can't contain
+// ':') in logs or WhoAmI
+const serviceAccountUserPrefix = "service:"
+
+func validateServiceAccountName(name string) error {
+	if name == "" {
+		return fmt.Errorf("invalid request: must set the service account's name")
+	}
+	if strings.ContainsAny(name, "/:") {
+		return fmt.Errorf("invalid service account name \"%s\": it can't contain '/' or ':'", name)
+	}
+	return nil
+}
+
+// serviceAccountScope returns the scope that user, a service account, has
+// on repo: WRITER on its output repos, READER on its input repos and NONE on
+// the others, but no more than its owner has, unless the owner is an admin.
+// It also returns the revision of the owner's ACL entry that was read, if
+// any, and whether the scope came from the cache of admins, which it does if
+// the owner is one.
+func (a *apiServer) serviceAccountScope(ctx context.Context, user *authclient.User, repo string) (authclient.Scope, int64, bool, error) {
+	name := strings.TrimPrefix(user.Username, serviceAccountUserPrefix)
+	var serviceAccount authclient.ServiceAccount
+	if err := a.serviceAccounts.ReadOnly(ctx).Get(name, &serviceAccount); err != nil {
+		if col.IsErrNotFound(err) {
+			return authclient.Scope_NONE, 0, false, fmt.Errorf("service account \"%s\" has been deleted", name)
+		}
+		return authclient.Scope_NONE, 0, false, err
+	}
+	scope := authclient.Scope_NONE
+	for _, input := range serviceAccount.InputRepos {
+		if input == repo {
+			scope = authclient.Scope_READER
+		}
+	}
+	for _, output := range serviceAccount.OutputRepos {
+		if output == repo {
+			scope = authclient.Scope_WRITER
+		}
+	}
+	if scope == authclient.Scope_NONE {
+		return scope, 0, false, nil
+	}
+	if a.isAdmin(serviceAccount.Owner) {
+		return scope, 0, true, nil
+	}
+	acl, revision, err := a.getACL(ctx, repo)
+	if err != nil {
+		return authclient.Scope_NONE, 0, false, err
+	}
+	if acl.Entries[serviceAccount.Owner] < scope {
+		return acl.Entries[serviceAccount.Owner], revision, false, nil
+	}
+	return scope, revision, false, nil
+}
+
+// canManageServiceAccount returns true if user can rotate the token of, or
+// delete, serviceAccount.
+func (a *apiServer) canManageServiceAccount(user *authclient.User, serviceAccount *authclient.ServiceAccount) bool {
+	return a.isAdmin(user.Username) || user.Username == serviceAccount.Owner ||
+		user.Username == serviceAccountUserPrefix+serviceAccount.Name
+}
+
+// putServiceAccountToken mints a new token for serviceAccount, replacing its
+// current one, if any, and stores both.
+func (a *apiServer) putServiceAccountToken(stm col.STM, serviceAccount *authclient.ServiceAccount) (string, error) {
+	tokens := a.tokens.ReadWrite(stm)
+	if serviceAccount.TokenHash != "" {
+		if err := tokens.Delete(serviceAccount.TokenHash); err != nil && !col.IsErrNotFound(err) {
+			return "", err
+		}
+	}
+	token := uuid.NewWithoutDashes()
+	serviceAccount.TokenHash = hashToken(token)
+	// Like capabilities, service account tokens don't expire; they're rotated
+	if err := tokens.Put(serviceAccount.TokenHash, &authclient.User{
+		Username: serviceAccountUserPrefix + serviceAccount.Name,
+		Type:     authclient.User_SERVICE_ACCOUNT,
+	}); err != nil {
+		return "", err
+	}
+	return token, a.serviceAccounts.ReadWrite(stm).Put(serviceAccount.Name, serviceAccount)
+}
+
+// serviceAccountCapabilityUser returns the user that a capability for the
+// service account named name is for, if caller can manage it. Unlike the
+// service account's token, capabilities aren't revoked by rotating it, they're
+// revoked by whoever they were given to, e.g. when a pipeline is deleted.
+func (a *apiServer) serviceAccountCapabilityUser(stm col.STM, caller *authclient.User, name string) (*authclient.User, error) {
+	var serviceAccount authclient.ServiceAccount
+	if err := a.serviceAccounts.ReadWrite(stm).Get(name, &serviceAccount); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil, fmt.Errorf("service account \"%s\" doesn't exist", name)
+		}
+		return nil, err
+	}
+	// unlike rotating its token, the service account itself can't do this,
+	// as its owner couldn't revoke the capabilities that it got
+	if !a.isAdmin(caller.Username) && caller.Username != serviceAccount.Owner {
+		return nil, fmt.Errorf("only the owner of service account \"%s\", or an admin, can get a capability for it", name)
+	}
+	return &authclient.User{
+		Username: serviceAccountUserPrefix + serviceAccount.Name,
+		Type:     authclient.User_SERVICE_ACCOUNT,
+	}, nil
+}
+
+// isServiceAccountCapability returns true if the token with tokenHash, which
+// is for user, a service account, is a capability for it rather than its own
+// token.
+func (a *apiServer) isServiceAccountCapability(stm col.STM, user *authclient.User, tokenHash string) (bool, error) {
+	var serviceAccount authclient.ServiceAccount
+	if err := a.serviceAccounts.ReadWrite(stm).Get(strings.TrimPrefix(user.Username, serviceAccountUserPrefix), &serviceAccount); err != nil {
+		if col.IsErrNotFound(err) {
+			// the service account was deleted, its capabilities are left
+			return true, nil
+		}
+		return false, err
+	}
+	return serviceAccount.TokenHash != tokenHash, nil
+}
+
+func (a *apiServer) CreateServiceAccount(ctx context.Context, req *authclient.CreateServiceAccountRequest) (resp *authclient.CreateServiceAccountResponse, retErr error) {
+	a.LogReq(req)
+	defer func(start time.Time) { a.LogResp(req, resp, retErr, time.Since(start)) }(time.Now())
+	user, err := a.getACLReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if user.Type == authclient.User_SERVICE_ACCOUNT {
+		return nil, fmt.Errorf("service accounts can't create service accounts")
+	}
+	if err := validateServiceAccountName(req.Name); err != nil {
+		return nil, err
+	}
+	if len(req.InputRepos) == 0 && len(req.OutputRepos) == 0 {
+		return nil, fmt.Errorf("invalid request: a service account must have at least one input or output repo")
+	}
+
+	// A service account can't be given access its owner doesn't have
+	if !a.isAdmin(user.Username) {
+		scopes := make(map[string]authclient.Scope)
+		for _, repo := range req.InputRepos {
+			scopes[repo] = authclient.Scope_READER
+		}
+		for _, repo := range req.OutputRepos {
+			scopes[repo] = authclient.Scope_WRITER
+		}
+		for repo, scope := range scopes {
+			acl, revision, err := a.getACL(ctx, repo)
+			if err != nil {
+				return nil, err
+			}
+			if revision == 0 {
+				// the repo doesn't exist yet, e.g. it's the output repo of
+				// a pipeline that will run as the service account, whose
+				// access is still capped by its owner's once it does
+				continue
+			}
+			if acl.Entries[user.Username] < scope {
+				return nil, &authclient.NotAuthorizedError{
+					Repo:        repo,
+					Required:    scope,
+					Actual:      acl.Entries[user.Username],
+					ACLRevision: revision,
+				}
+			}
+		}
+	}
+
+	var token string
+	if _, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
+		if err := a.serviceAccounts.ReadWrite(stm).Get(req.Name, &authclient.ServiceAccount{}); err == nil {
+			return fmt.Errorf("service account \"%s\" already exists", req.Name)
+		} else if !col.IsErrNotFound(err) {
+			return err
+		}
+		token, err = a.putServiceAccountToken(stm, &authclient.ServiceAccount{
+			Name:        req.Name,
+			Owner:       user.Username,
+			InputRepos:  req.InputRepos,
+			OutputRepos: req.OutputRepos,
+		})
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return &authclient.CreateServiceAccountResponse{Token: token}, nil
+}
+
+func (a *apiServer) RotateServiceAccountToken(ctx context.Context, req *authclient.RotateServiceAccountTokenRequest) (resp *authclient.RotateServiceAccountTokenResponse, retErr error) {
+	a.LogReq(req)
+	defer func(start time.Time) { a.LogResp(req, resp, retErr, time.Since(start)) }(time.Now())
+	user, err := a.getACLReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var token string
+	if _, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
+		var serviceAccount authclient.ServiceAccount
+		if err := a.serviceAccounts.ReadWrite(stm).Get(req.Name, &serviceAccount); err != nil {
+			return err
+		}
+		if !a.canManageServiceAccount(user, &serviceAccount) {
+			return fmt.Errorf("only the owner of service account \"%s\", or an admin, can rotate its token", req.Name)
+		}
+		token, err = a.putServiceAccountToken(stm, &serviceAccount)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return &authclient.RotateServiceAccountTokenResponse{Token: token}, nil
+}
+
+func (a *apiServer) GetServiceAccounts(ctx context.Context, req *authclient.GetServiceAccountsRequest) (resp *authclient.GetServiceAccountsResponse, retErr error) {
+	a.LogReq(req)
+	defer func(start time.Time) { a.LogResp(req, resp, retErr, time.Since(start)) }(time.Now())
+	user, err := a.getACLReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// every service account that the caller can manage, the others are skipped
+	iter, err := a.serviceAccounts.ReadOnly(ctx).List()
+	if err != nil {
+		return nil, err
+	}
+	resp = &authclient.GetServiceAccountsResponse{}
+	for {
+		var name string
+		serviceAccount := &authclient.ServiceAccount{}
+		ok, err := iter.Next(&name, serviceAccount)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		if !a.canManageServiceAccount(user, serviceAccount) {
+			continue
+		}
+		serviceAccount.TokenHash = ""
+		resp.ServiceAccounts = append(resp.ServiceAccounts, serviceAccount)
+	}
+	sort.Slice(resp.ServiceAccounts, func(i, j int) bool {
+		return resp.ServiceAccounts[i].Name < resp.ServiceAccounts[j].Name
+	})
+	return resp, nil
+}
+
+func (a *apiServer) DeleteServiceAccount(ctx context.Context, req *authclient.DeleteServiceAccountRequest) (resp *authclient.DeleteServiceAccountResponse, retErr error) {
+	a.LogReq(req)
+	defer func(start time.Time) { a.LogResp(req, resp, retErr, time.Since(start)) }(time.Now())
+	user, err := a.getACLReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
+		serviceAccounts := a.serviceAccounts.ReadWrite(stm)
+		var serviceAccount authclient.ServiceAccount
+		if err := serviceAccounts.Get(req.Name, &serviceAccount); err != nil {
+			return err
+		}
+		if !a.canManageServiceAccount(user, &serviceAccount) {
+			return fmt.Errorf("only the owner of service account \"%s\", or an admin, can delete it", req.Name)
+		}
+		if err := a.tokens.ReadWrite(stm).Delete(serviceAccount.TokenHash); err != nil && !col.IsErrNotFound(err) {
+			return err
+		}
+		return serviceAccounts.Delete(req.Name)
+	}); err != nil {
+		return nil, err
+	}
+	return &authclient.DeleteServiceAccountResponse{}, nil
+}
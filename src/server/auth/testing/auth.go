@@ -76,3 +76,43 @@ func (a *InactiveAPIServer) GetCapability(ctx context.Context, req *auth.GetCapa
 func (a *InactiveAPIServer) RevokeAuthToken(ctx context.Context, req *auth.RevokeAuthTokenRequest) (resp *auth.RevokeAuthTokenResponse, retErr error) {
 	return nil, auth.NotActivatedError{}
 }
+
+// ExportACLs implements the ExportACLs RPC, but just returns NotActivatedError
+func (a *InactiveAPIServer) ExportACLs(ctx context.Context, req *auth.ExportACLsRequest) (resp *auth.ExportACLsResponse, retErr error) {
+	return nil, auth.NotActivatedError{}
+}
+
+// ImportACLs implements the ImportACLs RPC, but just returns NotActivatedError
+func (a *InactiveAPIServer) ImportACLs(ctx context.Context, req *auth.ImportACLsRequest) (resp *auth.ImportACLsResponse, retErr error) {
+	return nil, auth.NotActivatedError{}
+}
+
+// ApplyACLTemplate implements the ApplyACLTemplate RPC, but just returns NotActivatedError
+func (a *InactiveAPIServer) ApplyACLTemplate(ctx context.Context, req *auth.ApplyACLTemplateRequest) (resp *auth.ApplyACLTemplateResponse, retErr error) {
+	return nil, auth.NotActivatedError{}
+}
+
+// DiffACLs implements the DiffACLs RPC, but just returns NotActivatedError
+func (a *InactiveAPIServer) DiffACLs(ctx context.Context, req *auth.DiffACLsRequest) (resp *auth.DiffACLsResponse, retErr error) {
+	return nil, auth.NotActivatedError{}
+}
+
+// CreateServiceAccount implements the CreateServiceAccount RPC, but just returns NotActivatedError
+func (a *InactiveAPIServer) CreateServiceAccount(ctx context.Context, req *auth.CreateServiceAccountRequest) (resp *auth.CreateServiceAccountResponse, retErr error) {
+	return nil, auth.NotActivatedError{}
+}
+
+// RotateServiceAccountToken implements the RotateServiceAccountToken RPC, but just returns NotActivatedError
+func (a *InactiveAPIServer) RotateServiceAccountToken(ctx context.Context, req *auth.RotateServiceAccountTokenRequest) (resp *auth.RotateServiceAccountTokenResponse, retErr error) {
+	return nil, auth.NotActivatedError{}
+}
+
+// GetServiceAccounts implements the GetServiceAccounts RPC, but just returns NotActivatedError
+func (a *InactiveAPIServer) GetServiceAccounts(ctx context.Context, req *auth.GetServiceAccountsRequest) (resp *auth.GetServiceAccountsResponse, retErr error) {
+	return nil, auth.NotActivatedError{}
+}
+
+// DeleteServiceAccount implements the DeleteServiceAccount RPC, but just returns NotActivatedError
+func (a *InactiveAPIServer) DeleteServiceAccount(ctx context.Context, req *auth.DeleteServiceAccountRequest) (resp *auth.DeleteServiceAccountResponse, retErr error) {
+	return nil, auth.NotActivatedError{}
+}
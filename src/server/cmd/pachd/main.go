@@ -30,6 +30,7 @@ import (
 	"github.com/pachyderm/pachyderm/src/server/pkg/metrics"
 	"github.com/pachyderm/pachyderm/src/server/pkg/migration"
 	"github.com/pachyderm/pachyderm/src/server/pkg/netutil"
+	"github.com/pachyderm/pachyderm/src/server/pkg/throttle"
 	pps_server "github.com/pachyderm/pachyderm/src/server/pps/server"
 
 	log "github.com/sirupsen/logrus"
@@ -66,9 +67,16 @@ type appEnv struct {
 	EtcdAddress           string `env:"ETCD_PORT_2379_TCP_ADDR,required"`
 	Namespace             string `env:"NAMESPACE,default=default"`
 	Metrics               bool   `env:"METRICS,default=true"`
+	FeatureTelemetry      bool   `env:"FEATURE_TELEMETRY,default=false"`
 	Init                  bool   `env:"INIT,default=false"`
 	BlockCacheBytes       string `env:"BLOCK_CACHE_BYTES,default=1G"`
 	PFSCacheSize          string `env:"PFS_CACHE_SIZE,default=0"`
+	PFSMaxProvenanceDepth int64  `env:"PFS_MAX_PROVENANCE_DEPTH,default=0"`
+	PFSTransferRate       string `env:"PFS_TRANSFER_RATE,default="`
+	PFSTransferWindows    string `env:"PFS_TRANSFER_WINDOWS,default="`
+	PFSInteractiveLoad    int64  `env:"PFS_INTERACTIVE_LOAD,default=16"`
+	PFSBatchConcurrency   int64  `env:"PFS_BATCH_CONCURRENCY,default=0"`
+	PFSResidency          string `env:"PFS_RESIDENCY,default="`
 	WorkerImage           string `env:"WORKER_IMAGE,default="`
 	WorkerSidecarImage    string `env:"WORKER_SIDECAR_IMAGE,default="`
 	WorkerImagePullPolicy string `env:"WORKER_IMAGE_PULL_POLICY,default="`
@@ -76,6 +84,37 @@ type appEnv struct {
 	IAMRole               string `env:"IAM_ROLE,default="`
 }
 
+// newTransferThrottle returns the throttle of the data that proxy repos fetch
+// from other clusters. PFS_TRANSFER_RATE is the most bytes per second they
+// fetch, e.g. "10M", and PFS_TRANSFER_WINDOWS are the daily windows, e.g.
+// "22:00-06:00", in which they can fetch it.
+func newTransferThrottle(appEnv *appEnv) (*throttle.Throttle, error) {
+	var rate int64
+	if appEnv.PFSTransferRate != "" {
+		var err error
+		if rate, err = units.RAMInBytes(appEnv.PFSTransferRate); err != nil {
+			return nil, fmt.Errorf("error parsing PFS_TRANSFER_RATE: %v", err)
+		}
+	}
+	windows, err := throttle.ParseWindows(appEnv.PFSTransferWindows)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing PFS_TRANSFER_WINDOWS: %v", err)
+	}
+	return throttle.New(rate, windows), nil
+}
+
+// parseResidency returns the residency tags that the cluster satisfies.
+// PFS_RESIDENCY is a comma-separated list of them, e.g. "eu-only,eu-west".
+func parseResidency(residency string) []string {
+	var tags []string
+	for _, tag := range strings.Split(residency, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
 func main() {
 	switch mode {
 	case "full":
@@ -119,6 +158,10 @@ func doSidecarMode(appEnvObj interface{}) error {
 	if appEnv.Metrics {
 		reporter = metrics.NewReporter(clusterID, kubeClient)
 	}
+	var featureReporter *metrics.FeatureReporter
+	if appEnv.FeatureTelemetry {
+		featureReporter = metrics.NewFeatureReporter(clusterID)
+	}
 	address, err := netutil.ExternalIP()
 	if err != nil {
 		return err
@@ -128,7 +171,11 @@ func doSidecarMode(appEnvObj interface{}) error {
 	if err != nil {
 		return err
 	}
-	pfsAPIServer, err := pfs_server.NewAPIServer(address, []string{etcdAddress}, appEnv.PFSEtcdPrefix, int64(pfsCacheSize))
+	transferThrottle, err := newTransferThrottle(appEnv)
+	if err != nil {
+		return err
+	}
+	pfsAPIServer, err := pfs_server.NewAPIServer(address, []string{etcdAddress}, appEnv.PFSEtcdPrefix, int64(pfsCacheSize), appEnv.PFSMaxProvenanceDepth, transferThrottle, appEnv.PFSInteractiveLoad, appEnv.PFSBatchConcurrency, featureReporter, parseResidency(appEnv.PFSResidency))
 	if err != nil {
 		return err
 	}
@@ -243,6 +290,10 @@ func doFullMode(appEnvObj interface{}) error {
 	if appEnv.Metrics {
 		reporter = metrics.NewReporter(clusterID, kubeClient)
 	}
+	var featureReporter *metrics.FeatureReporter
+	if appEnv.FeatureTelemetry {
+		featureReporter = metrics.NewFeatureReporter(clusterID)
+	}
 	address, err := netutil.ExternalIP()
 	if err != nil {
 		return err
@@ -270,7 +321,11 @@ func doFullMode(appEnvObj interface{}) error {
 		address,
 	)
 	cacheServer := cache_server.NewCacheServer(router, appEnv.NumShards)
-	pfsAPIServer, err := pfs_server.NewAPIServer(address, []string{etcdAddress}, appEnv.PFSEtcdPrefix, int64(pfsCacheSize))
+	transferThrottle, err := newTransferThrottle(appEnv)
+	if err != nil {
+		return err
+	}
+	pfsAPIServer, err := pfs_server.NewAPIServer(address, []string{etcdAddress}, appEnv.PFSEtcdPrefix, int64(pfsCacheSize), appEnv.PFSMaxProvenanceDepth, transferThrottle, appEnv.PFSInteractiveLoad, appEnv.PFSBatchConcurrency, featureReporter, parseResidency(appEnv.PFSResidency))
 	if err != nil {
 		return err
 	}
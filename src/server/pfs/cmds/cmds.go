@@ -3,13 +3,17 @@ package cmds
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 	"syscall"
 	"text/tabwriter"
@@ -18,13 +22,16 @@ import (
 
 	"github.com/gogo/protobuf/jsonpb"
 	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/auth"
 	"github.com/pachyderm/pachyderm/src/client/limit"
 	pfsclient "github.com/pachyderm/pachyderm/src/client/pfs"
 	"github.com/pachyderm/pachyderm/src/server/pfs/fuse"
 	"github.com/pachyderm/pachyderm/src/server/pfs/pretty"
 	"github.com/pachyderm/pachyderm/src/server/pkg/cmdutil"
 	"github.com/pachyderm/pachyderm/src/server/pkg/sync"
+	"github.com/pachyderm/pachyderm/src/server/pkg/throttle"
 
+	units "github.com/docker/go-units"
 	"github.com/spf13/cobra"
 )
 
@@ -45,6 +52,10 @@ func Cmds(noMetrics *bool) []*cobra.Command {
 		cmd.Flags().BoolVar(&raw, "raw", false, "disable pretty printing, print raw json")
 	}
 	marshaller := &jsonpb.Marshaler{Indent: "  "}
+	var output string
+	outputFlag := func(cmd *cobra.Command) {
+		cmd.Flags().StringVar(&output, "output", "", fmt.Sprintf("print the listing in this format, one of %v, for use by other tools", pretty.ListingFormats))
+	}
 
 	repo := &cobra.Command{
 		Use:   "repo",
@@ -58,49 +69,89 @@ func Cmds(noMetrics *bool) []*cobra.Command {
 	}
 
 	var description string
+	var repoTTL int64
+	var remote string
+	var compression string
 	createRepo := &cobra.Command{
 		Use:   "create-repo repo-name",
 		Short: "Create a new repo.",
-		Long:  "Create a new repo.",
+		Long: `Create a new repo.
+
+Examples:
+
+` + codestart + `# Create a temporary repo for scratch data, which is deleted unless
+# renew-repo is run within the next hour
+$ pachctl create-repo scratch --ttl 3600
+
+# Create a repo that serves the data in repo "images" on the cluster at
+# pachd.example.com:650, fetching it from there as it's read
+$ pachctl create-repo images --remote pachd.example.com:650/images
+
+# Create a repo whose data is stored gzipped
+$ pachctl create-repo logs --compression gzip` + codeend,
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
 			c, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
-			_, err = c.PfsAPIClient.CreateRepo(
-				c.Ctx(),
-				&pfsclient.CreateRepoRequest{
-					Repo:        client.NewRepo(args[0]),
-					Description: description,
-				},
-			)
+			parsedCompression, err := parseCompression(compression)
+			if err != nil {
+				return err
+			}
+			request := &pfsclient.CreateRepoRequest{
+				Repo:        client.NewRepo(args[0]),
+				Description: description,
+				TtlSeconds:  repoTTL,
+				Compression: parsedCompression,
+			}
+			if remote != "" {
+				i := strings.LastIndex(remote, "/")
+				if i <= 0 || i == len(remote)-1 {
+					return fmt.Errorf("remote must be of the form address/repo, got %s", remote)
+				}
+				request.Remote = &pfsclient.RemoteRepo{
+					Address: remote[:i],
+					Repo:    remote[i+1:],
+				}
+			}
+			_, err = c.PfsAPIClient.CreateRepo(c.Ctx(), request)
 			return err
 		}),
 	}
 	createRepo.Flags().StringVarP(&description, "description", "d", "", "A description of the repo.")
+	createRepo.Flags().Int64Var(&repoTTL, "ttl", 0, "Make the repo temporary: it's deleted, with its data, once this many seconds pass without it being renewed by renew-repo.")
+	createRepo.Flags().StringVar(&remote, "remote", "", "Make the repo a read-through proxy of a repo on another cluster, given as address/repo, e.g. pachd.example.com:650/images.")
+	createRepo.Flags().StringVar(&compression, "compression", "none", "The codec that the repo's data is compressed with at rest, none or gzip.")
 
 	updateRepo := &cobra.Command{
 		Use:   "update-repo repo-name",
 		Short: "Update a repo.",
-		Long:  "Update a repo.",
+		Long:  "Update a repo. Changing its compression only affects data written afterwards.",
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
 			c, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
+			parsedCompression, err := parseCompression(compression)
+			if err != nil {
+				return err
+			}
 			_, err = c.PfsAPIClient.CreateRepo(
 				c.Ctx(),
 				&pfsclient.CreateRepoRequest{
 					Repo:        client.NewRepo(args[0]),
 					Description: description,
 					Update:      true,
+					Compression: parsedCompression,
 				},
 			)
 			return err
 		}),
 	}
 	updateRepo.Flags().StringVarP(&description, "description", "d", "", "A description of the repo.")
+	updateRepo.Flags().StringVar(&compression, "compression", "none", "The codec that the repo's data is compressed with at rest, none or gzip.")
 
+	var subvenance bool
 	inspectRepo := &cobra.Command{
 		Use:   "inspect-repo repo-name",
 		Short: "Return info about a repo.",
@@ -110,7 +161,12 @@ func Cmds(noMetrics *bool) []*cobra.Command {
 			if err != nil {
 				return err
 			}
-			repoInfo, err := c.InspectRepo(args[0])
+			var repoInfo *pfsclient.RepoInfo
+			if subvenance {
+				repoInfo, err = c.InspectRepoSubvenance(args[0])
+			} else {
+				repoInfo, err = c.InspectRepo(args[0])
+			}
 			if err != nil {
 				return err
 			}
@@ -123,9 +179,14 @@ func Cmds(noMetrics *bool) []*cobra.Command {
 			return pretty.PrintDetailedRepoInfo(repoInfo)
 		}),
 	}
+	inspectRepo.Flags().BoolVar(&subvenance, "subvenance", false, "Also return the repos downstream of the repo, that is the ones that have it in their provenance.")
 	rawFlag(inspectRepo)
 
 	var listRepoProvenance cmdutil.RepeatedStringArg
+	var listRepoSelector string
+	var listArchived, listRepoReverse bool
+	var listRepoSort, listRepoPageToken string
+	var listRepoLimit int64
 	listRepo := &cobra.Command{
 		Use:   "list-repo",
 		Short: "Return all repos.",
@@ -135,10 +196,31 @@ func Cmds(noMetrics *bool) []*cobra.Command {
 			if err != nil {
 				return err
 			}
-			repoInfos, err := c.ListRepo(listRepoProvenance)
+			request := &pfsclient.ListRepoRequest{
+				LabelSelector:   listRepoSelector,
+				IncludeArchived: listArchived,
+				Reverse:         listRepoReverse,
+				Limit:           listRepoLimit,
+				PageToken:       listRepoPageToken,
+			}
+			if listRepoSort != "" {
+				sortBy, ok := pfsclient.RepoSort_value["RepoSort_"+strings.Replace(strings.ToUpper(listRepoSort), "-", "_", -1)]
+				if !ok {
+					return fmt.Errorf("unrecognized sort %s, it must be name, size, created or last-commit", listRepoSort)
+				}
+				request.SortBy = pfsclient.RepoSort(sortBy)
+			}
+			for _, repoName := range listRepoProvenance {
+				request.Provenance = append(request.Provenance, client.NewRepo(repoName))
+			}
+			response, err := c.PfsAPIClient.ListRepo(c.Ctx(), request)
 			if err != nil {
 				return err
 			}
+			repoInfos := response.RepoInfo
+			if response.NextPageToken != "" {
+				fmt.Fprintf(os.Stderr, "More repos match, list them with --page-token %q\n", response.NextPageToken)
+			}
 			if raw {
 				for _, repoInfo := range repoInfos {
 					if err := marshaller.Marshal(os.Stdout, repoInfo); err != nil {
@@ -158,8 +240,164 @@ func Cmds(noMetrics *bool) []*cobra.Command {
 		}),
 	}
 	listRepo.Flags().VarP(&listRepoProvenance, "provenance", "p", "list only repos with the specified repos provenance")
+	listRepo.Flags().StringVarP(&listRepoSelector, "selector", "l", "", "list only repos whose labels match this selector, e.g. \"team=genomics,tier!=bronze\"; requirements are key=value, key!=value, key and !key")
+	listRepo.Flags().BoolVar(&listArchived, "archived", false, "list archived repos too")
+	listRepo.Flags().StringVar(&listRepoSort, "sort", "", "sort the repos by name, size, created or last-commit; by default the most recently updated repos are listed first")
+	listRepo.Flags().BoolVar(&listRepoReverse, "reverse", false, "reverse the order of --sort")
+	listRepo.Flags().Int64Var(&listRepoLimit, "limit", 0, "list at most this many repos, the rest can be listed with --page-token")
+	listRepo.Flags().StringVar(&listRepoPageToken, "page-token", "", "list the repos after the last repo of a previous --limit listing, with the same --sort and --reverse")
 	rawFlag(listRepo)
 
+	archiveRepo := &cobra.Command{
+		Use:   "archive-repo repo-name",
+		Short: "Retire a repo without deleting its data.",
+		Long:  "Retire a repo without deleting its data. An archived repo can still be read, but it can't be written to, it's only listed by list-repo --archived, and it isn't flushed or subscribed to. A repo can't be archived while it has open commits.",
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			return client.ArchiveRepo(args[0])
+		}),
+	}
+
+	unarchiveRepo := &cobra.Command{
+		Use:   "unarchive-repo repo-name",
+		Short: "Make an archived repo writable again.",
+		Long:  "Make an archived repo writable, and listed by list-repo, again.",
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			return client.UnarchiveRepo(args[0])
+		}),
+	}
+
+	var exportRepoPath string
+	exportRepo := &cobra.Command{
+		Use:   "export-repo repo-name",
+		Short: "Write a bundle of a repo.",
+		Long: `Write a bundle of a repo: its finished commits and branches, and the data they reference. import-repo creates the repo from it, in this cluster or another one, so bundles can be used to move repos between clusters, or to archive them.
+
+Examples:
+
+` + codestart + `# Write a bundle of repo foo to foo.bundle
+$ pachctl export-repo foo -o foo.bundle
+` + codeend,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) (retErr error) {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			w := io.Writer(os.Stdout)
+			if exportRepoPath != "" {
+				f, err := os.Create(exportRepoPath)
+				if err != nil {
+					return err
+				}
+				defer func() {
+					if err := f.Close(); err != nil && retErr == nil {
+						retErr = err
+					}
+				}()
+				w = f
+			}
+			return client.ExportRepo(args[0], w)
+		}),
+	}
+	exportRepo.Flags().StringVarP(&exportRepoPath, "output", "o", "", "The file to write the bundle to, it's written to stdout if this isn't set.")
+
+	var importRepoName string
+	var importRepoPath string
+	importRepo := &cobra.Command{
+		Use:   "import-repo",
+		Short: "Create a repo from a bundle.",
+		Long: `Create a repo from a bundle written by export-repo. The repo's commits keep their IDs, but not their provenance. If the import fails, the repo is deleted.
+
+Examples:
+
+` + codestart + `# Create repo foo from foo.bundle
+$ pachctl import-repo -f foo.bundle
+
+# Create repo bar from foo.bundle
+$ pachctl import-repo -f foo.bundle --repo bar
+` + codeend,
+		Run: cmdutil.RunFixedArgs(0, func(args []string) (retErr error) {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			var r io.Reader
+			if importRepoPath == "-" {
+				r = os.Stdin
+				fmt.Print("Reading from stdin.\n")
+			} else {
+				f, err := os.Open(importRepoPath)
+				if err != nil {
+					return err
+				}
+				defer func() {
+					if err := f.Close(); err != nil && retErr == nil {
+						retErr = err
+					}
+				}()
+				r = f
+			}
+			return client.ImportRepo(importRepoName, r)
+		}),
+	}
+	importRepo.Flags().StringVarP(&importRepoPath, "file", "f", "-", "The bundle to import, - reads it from stdin.")
+	importRepo.Flags().StringVar(&importRepoName, "repo", "", "The name of the repo to create, it's the name of the bundle's repo if this isn't set.")
+
+	renewRepo := &cobra.Command{
+		Use:   "renew-repo repo-name",
+		Short: "Extend the lease of a temporary repo.",
+		Long:  "Extend the lease of a temporary repo, which hasn't expired, so that it isn't deleted.",
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			return client.RenewRepo(args[0], repoTTL)
+		}),
+	}
+	renewRepo.Flags().Int64Var(&repoTTL, "ttl", 0, "The number of seconds until the repo is deleted unless it's renewed again, the ttl it was created with if it isn't set.")
+
+	var graphCommits bool
+	var graphFormat string
+	exportProvenanceGraph := &cobra.Command{
+		Use:   "export-provenance-graph [repo-name...]",
+		Short: "Export the provenance graph of repos and their commits.",
+		Long: `Export the provenance graph of repos, and of their commits, in Graphviz's DOT language or the JSON Graph Format. If repos are given, the graph is limited to them and the repos upstream and downstream of them.
+
+Examples:
+
+` + codestart + `# render the provenance of every repo as an image
+$ pachctl export-provenance-graph | dot -Tpng > provenance.png
+
+# export the lineage of the commits upstream and downstream of repo "foo"
+$ pachctl export-provenance-graph foo --commits --format json
+` + codeend,
+		Run: cmdutil.Run(func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			graph, err := client.ExportProvenanceGraph(args, graphCommits)
+			if err != nil {
+				return err
+			}
+			if raw {
+				return marshaller.Marshal(os.Stdout, graph)
+			}
+			return pretty.EncodeProvenanceGraph(os.Stdout, graph, graphFormat)
+		}),
+	}
+	exportProvenanceGraph.Flags().BoolVar(&graphCommits, "commits", false, "Include the repos' commits in the graph.")
+	exportProvenanceGraph.Flags().StringVar(&graphFormat, "format", pretty.GraphFormatDOT, fmt.Sprintf("The format of the graph, one of %v.", pretty.GraphFormats))
+	rawFlag(exportProvenanceGraph)
+
 	var force bool
 	var all bool
 	deleteRepo := &cobra.Command{
@@ -195,6 +433,163 @@ func Cmds(noMetrics *bool) []*cobra.Command {
 	deleteRepo.Flags().BoolVarP(&force, "force", "f", false, "remove the repo regardless of errors; use with care")
 	deleteRepo.Flags().BoolVar(&all, "all", false, "remove all repos")
 
+	setProtectedPaths := &cobra.Command{
+		Use:   "set-protected-paths repo-name [path...]",
+		Short: "Protect paths in a repo from writes by non-owners.",
+		Long: `Only let the owners of a repo put, copy, move or delete files under the given path prefixes, e.g. to keep a buggy pipeline from overwriting source data. The paths replace the repo's protected paths; giving none unprotects the repo.
+
+Examples:
+
+` + codestart + `# Only let owners of repo "foo" write under "/raw"
+$ pachctl set-protected-paths foo /raw/**
+
+# Unprotect repo "foo"
+$ pachctl set-protected-paths foo
+` + codeend,
+		Run: cmdutil.Run(func(args []string) error {
+			if len(args) < 1 {
+				return fmt.Errorf("a repo name must be provided")
+			}
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			return client.SetProtectedPaths(args[0], args[1:]...)
+		}),
+	}
+
+	setResidency := &cobra.Command{
+		Use:   "set-residency repo-name [tag...]",
+		Short: "Constrain the clusters that a repo's data can be placed on.",
+		Long: `Set the residency tags of a repo, e.g. "eu-only". Its data can only be placed on clusters that satisfy every one of them, as set by their pachd's PFS_RESIDENCY, e.g. by proxy repos of it or by applying an exported spec of it. The tags replace the repo's tags; giving none removes them.
+
+Examples:
+
+` + codestart + `# Keep the data of repo "foo" in the EU
+$ pachctl set-residency foo eu-only
+
+# Remove the residency tags of repo "foo"
+$ pachctl set-residency foo
+` + codeend,
+		Run: cmdutil.Run(func(args []string) error {
+			if len(args) < 1 {
+				return fmt.Errorf("a repo name must be provided")
+			}
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			return client.SetResidency(args[0], args[1:]...)
+		}),
+	}
+
+	setStorageClass := &cobra.Command{
+		Use:   "set-storage-class repo-name [storage-class]",
+		Short: "Set the storage class that a repo's data is written in.",
+		Long: `Set the object store's storage class that a repo's data is written in, e.g. STANDARD_IA on S3 or NEARLINE on Google Cloud Storage, so that rarely read repos cost less to store. It applies to data written from now on. Giving no class writes the repo's data in the object store's default class.
+
+Examples:
+
+` + codestart + `# Write the data of archival repo "foo" in S3's infrequent access class
+$ pachctl set-storage-class foo STANDARD_IA
+
+# Write the data of repo "foo" in the default class
+$ pachctl set-storage-class foo
+` + codeend,
+		Run: cmdutil.RunBoundedArgs(1, 2, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			var storageClass string
+			if len(args) > 1 {
+				storageClass = args[1]
+			}
+			return client.SetStorageClass(args[0], storageClass)
+		}),
+	}
+
+	var labels, annotations cmdutil.RepeatedStringArg
+	var removeLabels, removeAnnotations []string
+	updateRepoMetadata := &cobra.Command{
+		Use:   "update-repo-metadata repo-name",
+		Short: "Set or remove the labels and annotations of a repo.",
+		Long: `Set or remove the labels and annotations of a repo. Labels organize repos, and list-repo --selector lists the repos with the given labels. Annotations are arbitrary metadata, which can't be selected by. Labels and annotations are removed before any are set.
+
+Examples:
+
+` + codestart + `# Label repo "foo" as belonging to the genomics team, and annotate it with its owner's email
+$ pachctl update-repo-metadata foo --label team=genomics --label tier=gold --annotation owner=jdoe@example.com
+
+# Remove the tier label of repo "foo"
+$ pachctl update-repo-metadata foo --remove-label tier
+` + codeend,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			parsedLabels, err := parseKeyValues(labels)
+			if err != nil {
+				return err
+			}
+			parsedAnnotations, err := parseKeyValues(annotations)
+			if err != nil {
+				return err
+			}
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			return client.UpdateRepoMetadata(args[0], parsedLabels, parsedAnnotations, removeLabels, removeAnnotations)
+		}),
+	}
+	updateRepoMetadata.Flags().VarP(&labels, "label", "l", "A label to set, as key=value, can be repeated.")
+	updateRepoMetadata.Flags().VarP(&annotations, "annotation", "a", "An annotation to set, as key=value, can be repeated.")
+	updateRepoMetadata.Flags().StringSliceVar(&removeLabels, "remove-label", nil, "The key of a label to remove, can be repeated.")
+	updateRepoMetadata.Flags().StringSliceVar(&removeAnnotations, "remove-annotation", nil, "The key of an annotation to remove, can be repeated.")
+
+	var readFilterRegex string
+	var readFilterJMESPath string
+	var exemptScope string
+	var removeReadFilter bool
+	setReadFilter := &cobra.Command{
+		Use:   "set-read-filter repo-name",
+		Short: "Filter the records of a repo's files for most readers.",
+		Long: `Set the filter that users with less than the exempt scope on a repo read the records of its files through, e.g. to share a dataset with its sensitive records hidden from most readers. Records are lines, filtered by --regex, or JSON values, filtered by --jmes-path. Files can't be read as tar archives (get-file --tar), or by record (get-records), through a filter.
+
+Examples:
+
+` + codestart + `# Only show the lines of repo "foo" that start with "public," to its readers
+$ pachctl set-read-filter foo --regex "^public,"
+
+# Only show the JSON records of repo "foo" that are public to readers and writers
+$ pachctl set-read-filter foo --jmes-path 'public' --exempt-scope owner
+
+# Remove the read filter of repo "foo"
+$ pachctl set-read-filter foo --remove
+` + codeend,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			if removeReadFilter {
+				return client.SetReadFilter(args[0], nil)
+			}
+			filter := &pfsclient.ReadFilter{
+				Regex:    readFilterRegex,
+				JmesPath: readFilterJMESPath,
+			}
+			if exemptScope != "" {
+				if filter.ExemptScope, err = auth.ParseScope(exemptScope); err != nil {
+					return err
+				}
+			}
+			return client.SetReadFilter(args[0], filter)
+		}),
+	}
+	setReadFilter.Flags().StringVar(&readFilterRegex, "regex", "", "the regex that the lines readers can read match")
+	setReadFilter.Flags().StringVar(&readFilterJMESPath, "jmes-path", "", "the JMESPath expression that's truthy for the JSON records readers can read")
+	setReadFilter.Flags().StringVar(&exemptScope, "exempt-scope", "", "the scope (reader, writer or owner) that reads files unfiltered; writer by default")
+	setReadFilter.Flags().BoolVar(&removeReadFilter, "remove", false, "remove the repo's read filter")
+
 	commit := &cobra.Command{
 		Use:   "commit",
 		Short: "Docs for commits.",
@@ -217,6 +612,7 @@ This layers the data in the commit over the data in the parent.
 	}
 
 	var parent string
+	var base string
 	startCommit := &cobra.Command{
 		Use:   "start-commit repo-name [branch]",
 		Short: "Start a new commit.",
@@ -235,6 +631,10 @@ $ pachctl start-commit test patch -p master
 
 # Start a commit with XXX as the parent in repo "test", not on any branch
 $ pachctl start-commit test -p XXX
+
+# Start a commit on branch "master" in repo "test" with the content of commit
+# XXX rather than of master's head, e.g. to undo the commits since XXX
+$ pachctl start-commit test master --from XXX
 ` + codeend,
 		Run: cmdutil.RunBoundedArgs(1, 2, func(args []string) error {
 			client, err := client.NewOnUserMachine(metrics, "user")
@@ -245,7 +645,12 @@ $ pachctl start-commit test -p XXX
 			if len(args) == 2 {
 				branch = args[1]
 			}
-			commit, err := client.StartCommitParent(args[0], branch, parent)
+			var commit *pfsclient.Commit
+			if base != "" {
+				commit, err = client.StartCommitFromBase(args[0], branch, parent, base)
+			} else {
+				commit, err = client.StartCommitParent(args[0], branch, parent)
+			}
 			if err != nil {
 				return err
 			}
@@ -254,67 +659,234 @@ $ pachctl start-commit test -p XXX
 		}),
 	}
 	startCommit.Flags().StringVarP(&parent, "parent", "p", "", "The parent of the new commit, unneeded if branch is specified and you want to use the previous head of the branch as the parent.")
+	startCommit.Flags().StringVar(&base, "from", "", "A finished commit in the repo whose content the new commit starts with, rather than its parent's; no data is copied.")
 
+	var dataCardPath string
+	var stage bool
 	finishCommit := &cobra.Command{
 		Use:   "finish-commit repo-name commit-id",
 		Short: "Finish a started commit.",
-		Long:  "Finish a started commit. Commit-id must be a writeable commit.",
-		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+		Long: `Finish a started commit. Commit-id must be a writeable commit.
+
+Examples:
+
+` + codestart + `# Finish commit XXX in repo "test"
+$ pachctl finish-commit test XXX
+
+# Finish commit XXX in repo "test" and attach the data card in card.json
+$ pachctl finish-commit test XXX --data-card card.json
+
+# Finish commit XXX in repo "test" without publishing it to its branch
+$ pachctl finish-commit test XXX --stage
+` + codeend,
+		Run: cmdutil.RunFixedArgs(2, func(args []string) (retErr error) {
 			client, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
-			return client.FinishCommit(args[0], args[1])
+			if dataCardPath == "" {
+				if stage {
+					return client.StageCommit(args[0], args[1], nil)
+				}
+				return client.FinishCommit(args[0], args[1])
+			}
+			var dataCardReader io.Reader
+			if dataCardPath == "-" {
+				dataCardReader = os.Stdin
+				fmt.Print("Reading from stdin.\n")
+			} else {
+				dataCardFile, err := os.Open(dataCardPath)
+				if err != nil {
+					return err
+				}
+				defer func() {
+					if err := dataCardFile.Close(); err != nil && retErr == nil {
+						retErr = err
+					}
+				}()
+				dataCardReader = dataCardFile
+			}
+			dataCard := &pfsclient.DataCard{}
+			if err := jsonpb.Unmarshal(dataCardReader, dataCard); err != nil {
+				return err
+			}
+			if stage {
+				return client.StageCommit(args[0], args[1], dataCard)
+			}
+			return client.FinishCommitWithDataCard(args[0], args[1], dataCard)
 		}),
 	}
+	finishCommit.Flags().StringVar(&dataCardPath, "data-card", "", "a JSON file containing a data card (description, license, row_count and schema) to attach to the commit; \"-\" reads from stdin")
+	finishCommit.Flags().BoolVar(&stage, "stage", false, "finish the commit without publishing it: its branch keeps pointing at its parent until the commit is published with publish-commit")
 
-	inspectCommit := &cobra.Command{
-		Use:   "inspect-commit repo-name commit-id",
-		Short: "Return info about a commit.",
-		Long:  "Return info about a commit.",
+	publishCommit := &cobra.Command{
+		Use:   "publish-commit repo-name commit-id",
+		Short: "Publish a staged commit.",
+		Long: `Publish a commit that was finished with --stage, making it the head of the branch it was started on.
+
+Publishing fails if the branch has moved since the commit was staged.
+
+Examples:
+
+` + codestart + `# Publish staged commit XXX in repo "test"
+$ pachctl publish-commit test XXX
+` + codeend,
 		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
 			client, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
-			commitInfo, err := client.InspectCommit(args[0], args[1])
-			if err != nil {
-				return err
-			}
-			if commitInfo == nil {
-				return fmt.Errorf("commit %s not found", args[1])
-			}
-			if raw {
-				return marshaller.Marshal(os.Stdout, commitInfo)
-			}
-			return pretty.PrintDetailedCommitInfo(commitInfo)
+			return client.PublishCommit(args[0], args[1])
 		}),
 	}
-	rawFlag(inspectCommit)
 
-	var from string
-	var number int
-	listCommit := &cobra.Command{
-		Use:   "list-commit repo-name",
-		Short: "Return all commits on a set of repos.",
-		Long: `Return all commits on a set of repos.
+	var reviewComment string
+	approveCommit := &cobra.Command{
+		Use:   "approve-commit repo-name commit-id",
+		Short: "Approve a finished commit.",
+		Long: `Record your approval of a finished commit. Reviews are shown by inspect-commit.
 
 Examples:
 
-` + codestart + `# return commits in repo "foo"
-$ pachctl list-commit foo
+` + codestart + `# Approve commit XXX in repo "test"
+$ pachctl approve-commit test XXX -m "looks good"
+` + codeend,
+		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			return client.ApproveCommit(args[0], args[1], reviewComment)
+		}),
+	}
+	approveCommit.Flags().StringVarP(&reviewComment, "message", "m", "", "a comment to attach to the review")
 
-# return commits in repo "foo" on branch "master"
-$ pachctl list-commit foo master
+	rejectCommit := &cobra.Command{
+		Use:   "reject-commit repo-name commit-id",
+		Short: "Reject a finished commit.",
+		Long: `Record your rejection of a finished commit. Reviews are shown by inspect-commit.
 
-# return the last 20 commits in repo "foo" on branch "master"
-$ pachctl list-commit foo master -n 20
+Examples:
+
+` + codestart + `# Reject commit XXX in repo "test"
+$ pachctl reject-commit test XXX -m "schema changed"
+` + codeend,
+		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			return client.RejectCommit(args[0], args[1], reviewComment)
+		}),
+	}
+	rejectCommit.Flags().StringVarP(&reviewComment, "message", "m", "", "a comment to attach to the review")
+
+	listPendingApprovals := &cobra.Command{
+		Use:   "list-pending-approvals repo-name [branch]",
+		Short: "Return the staged commits waiting to be published.",
+		Long: `Return the staged commits in a repo that are waiting to be published, oldest first.
+
+Examples:
+
+` + codestart + `# return all staged commits in repo "foo"
+$ pachctl list-pending-approvals foo
+
+# return the staged commits in repo "foo" that were started on branch "master"
+$ pachctl list-pending-approvals foo master
+` + codeend,
+		Run: cmdutil.RunBoundedArgs(1, 2, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			var branch string
+			if len(args) == 2 {
+				branch = args[1]
+			}
+			commitInfos, err := c.ListPendingApprovals(args[0], branch)
+			if err != nil {
+				return err
+			}
+			if raw {
+				for _, commitInfo := range commitInfos {
+					if err := marshaller.Marshal(os.Stdout, commitInfo); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+			writer := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
+			pretty.PrintCommitInfoHeader(writer)
+			for _, commitInfo := range commitInfos {
+				pretty.PrintCommitInfo(writer, commitInfo)
+			}
+			return writer.Flush()
+		}),
+	}
+	rawFlag(listPendingApprovals)
+
+	var commitFields, omitCommitFields cmdutil.RepeatedStringArg
+	inspectCommit := &cobra.Command{
+		Use:   "inspect-commit repo-name commit-id",
+		Short: "Return info about a commit.",
+		Long:  "Return info about a commit.",
+		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			commitInfo, err := c.PfsAPIClient.InspectCommit(
+				c.Ctx(),
+				&pfsclient.InspectCommitRequest{
+					Commit:     client.NewCommit(args[0], args[1]),
+					Subvenance: subvenance,
+					Fields:     commitFields,
+					OmitFields: omitCommitFields,
+				},
+			)
+			if err != nil {
+				return err
+			}
+			if commitInfo == nil {
+				return fmt.Errorf("commit %s not found", args[1])
+			}
+			if raw {
+				return marshaller.Marshal(os.Stdout, commitInfo)
+			}
+			return pretty.PrintDetailedCommitInfo(commitInfo)
+		}),
+	}
+	inspectCommit.Flags().BoolVar(&subvenance, "subvenance", false, "Also return the commits downstream of the commit, that is the ones that have it in their provenance.")
+	inspectCommit.Flags().Var(&commitFields, "fields", "Only return these fields of the commit info, named as they are in pfs.proto, e.g. \"finished\".")
+	inspectCommit.Flags().Var(&omitCommitFields, "omit", "Leave out these fields of the commit info, named as they are in pfs.proto, e.g. \"provenance\".")
+	rawFlag(inspectCommit)
+
+	var from string
+	var number int
+	listCommit := &cobra.Command{
+		Use:   "list-commit repo-name",
+		Short: "Return all commits on a set of repos.",
+		Long: `Return all commits on a set of repos.
+
+Examples:
+
+` + codestart + `# return commits in repo "foo"
+$ pachctl list-commit foo
+
+# return commits in repo "foo" on branch "master"
+$ pachctl list-commit foo master
+
+# return the last 20 commits in repo "foo" on branch "master"
+$ pachctl list-commit foo master -n 20
 
 # return commits that are the ancestors of XXX
 $ pachctl list-commit foo XXX
 
 # return commits in repo "foo" since commit XXX
 $ pachctl list-commit foo master --from XXX
+
+# return commits in repo "foo" as CSV
+$ pachctl list-commit foo --output csv
 ` + codeend,
 		Run: cmdutil.RunBoundedArgs(1, 2, func(args []string) (retErr error) {
 			c, err := client.NewOnUserMachine(metrics, "user")
@@ -327,11 +899,26 @@ $ pachctl list-commit foo master --from XXX
 				to = args[1]
 			}
 
-			commitInfos, err := c.ListCommit(args[0], to, from, uint64(number))
+			if output != "" && raw {
+				return fmt.Errorf("only one of --output and --raw can be set")
+			}
+			commitInfos, err := c.ListCommitFields(args[0], to, from, uint64(number), commitFields, omitCommitFields)
 			if err != nil {
 				return err
 			}
 
+			if output != "" {
+				encoder, err := pretty.NewListingEncoder(output, os.Stdout)
+				if err != nil {
+					return err
+				}
+				for _, commitInfo := range commitInfos {
+					if err := encoder.EncodeCommitInfo(commitInfo); err != nil {
+						return err
+					}
+				}
+				return encoder.Flush()
+			}
 			if raw {
 				for _, commitInfo := range commitInfos {
 					if err := marshaller.Marshal(os.Stdout, commitInfo); err != nil {
@@ -350,7 +937,91 @@ $ pachctl list-commit foo master --from XXX
 	}
 	listCommit.Flags().StringVarP(&from, "from", "f", "", "list all commits since this commit")
 	listCommit.Flags().IntVarP(&number, "number", "n", 0, "list only this many commits; if set to zero, list all commits")
+	listCommit.Flags().Var(&commitFields, "fields", "only return these fields of each commit info, named as they are in pfs.proto, e.g. \"finished\"")
+	listCommit.Flags().Var(&omitCommitFields, "omit", "leave out these fields of each commit info, named as they are in pfs.proto, e.g. \"provenance\"")
 	rawFlag(listCommit)
+	outputFlag(listCommit)
+
+	var good, bad string
+	bisectCommits := &cobra.Command{
+		Use:   "bisect-commits repo-name branch",
+		Short: "Find the first bad commit of a branch by testing as few commits as possible.",
+		Long: `Find the first bad commit of a branch by testing as few commits as possible. Each run returns the commit halfway between the last good and bad commits; test it and run bisect-commits again, passing it with --good or --bad, until the first bad commit is found. The bad commit is the head of the branch unless --bad is set.
+
+Examples:
+
+` + codestart + `# find the first bad commit after XXX on branch "master" of repo "foo"
+$ pachctl bisect-commits foo master --good XXX
+Test commit YYY next
+# YYY turns out to be good
+$ pachctl bisect-commits foo master --good YYY
+` + codeend,
+		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			response, err := c.BisectCommits(args[0], args[1], good, bad)
+			if err != nil {
+				return err
+			}
+			if raw {
+				return marshaller.Marshal(os.Stdout, response)
+			}
+			pretty.PrintBisectCommitsResponse(response)
+			return nil
+		}),
+	}
+	bisectCommits.Flags().StringVar(&good, "good", "", "the last commit known to be good; if it isn't set, every ancestor of the bad commit could be the first bad one")
+	bisectCommits.Flags().StringVar(&bad, "bad", "", "the first commit known to be bad; it's the head of the branch if this isn't set")
+	rawFlag(bisectCommits)
+
+	var query, license string
+	searchDataCards := &cobra.Command{
+		Use:   "search-data-cards [repo-name]",
+		Short: "Return the commits whose data cards match a query.",
+		Long: `Return the commits whose data cards match a query. If no repo is given, the data cards in all repos are searched.
+
+Examples:
+
+` + codestart + `# return commits in repo "foo" with a data card that mentions "users"
+$ pachctl search-data-cards foo --query users
+
+# return commits in any repo with a data card licensed under "MIT"
+$ pachctl search-data-cards --license MIT
+` + codeend,
+		Run: cmdutil.RunBoundedArgs(0, 1, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			var repoName string
+			if len(args) == 1 {
+				repoName = args[0]
+			}
+			commitInfos, err := c.SearchDataCards(repoName, query, license)
+			if err != nil {
+				return err
+			}
+			if raw {
+				for _, commitInfo := range commitInfos {
+					if err := marshaller.Marshal(os.Stdout, commitInfo); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+			writer := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
+			pretty.PrintCommitInfoHeader(writer)
+			for _, commitInfo := range commitInfos {
+				pretty.PrintCommitInfo(writer, commitInfo)
+			}
+			return writer.Flush()
+		}),
+	}
+	searchDataCards.Flags().StringVarP(&query, "query", "q", "", "only return data cards whose description or schema contains this string")
+	searchDataCards.Flags().StringVarP(&license, "license", "l", "", "only return data cards with this license")
+	rawFlag(searchDataCards)
 
 	printCommitIter := func(commitIter client.CommitInfoIterator) error {
 		if raw {
@@ -427,6 +1098,7 @@ $ pachctl flush-commit foo/XXX -r bar -r baz
 	rawFlag(flushCommit)
 
 	var new bool
+	var hints bool
 	subscribeCommit := &cobra.Command{
 		Use:   "subscribe-commit repo branch",
 		Short: "Print commits as they are created (finished).",
@@ -446,6 +1118,10 @@ $ pachctl subscribe-commit test master --from XXX
 # subscribe to commits in repo "test" on branch "master", but only for new
 # commits created from now on.
 $ pachctl subscribe-commit test master --new
+
+# subscribe to commits in repo "test" on branch "master", with the paths that
+# each one changed
+$ pachctl subscribe-commit test master --hints --raw
 ` + codeend,
 		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
 			repo, branch := args[0], args[1]
@@ -462,7 +1138,12 @@ $ pachctl subscribe-commit test master --new
 				from = branch
 			}
 
-			commitIter, err := c.SubscribeCommit(repo, branch, from)
+			var commitIter client.CommitInfoIterator
+			if hints {
+				commitIter, err = c.SubscribeCommitWithHints(repo, branch, from)
+			} else {
+				commitIter, err = c.SubscribeCommit(repo, branch, from)
+			}
 			if err != nil {
 				return err
 			}
@@ -472,6 +1153,7 @@ $ pachctl subscribe-commit test master --new
 	}
 	subscribeCommit.Flags().StringVar(&from, "from", "", "subscribe to all commits since this commit")
 	subscribeCommit.Flags().BoolVar(&new, "new", false, "subscribe to only new commits created from now on")
+	subscribeCommit.Flags().BoolVar(&hints, "hints", false, "include the paths that changed since the previous commit in each commit, they're printed with --raw")
 	rawFlag(subscribeCommit)
 
 	deleteCommit := &cobra.Command{
@@ -554,92 +1236,442 @@ $ pachctl set-branch foo test master` + codeend,
 		}),
 	}
 
-	file := &cobra.Command{
-		Use:   "file",
-		Short: "Docs for files.",
-		Long: `Files are the lowest level data object in Pachyderm.
+	var disallow bool
+	setCompactInPlace := &cobra.Command{
+		Use:   "set-compact-in-place <repo-name> <branch-name>",
+		Short: "Allow a branch's head to be compacted in place.",
+		Long: `Allow compact-file and compact-commit to rewrite the head of a branch in place, rather than adding a compacted commit to it. The content of the head doesn't change, only the objects that it's stored in.
 
-	Files can be written to started (but not finished) commits with put-file.
-	Files can be read from finished commits with get-file.`,
-		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
-			return nil
+Examples:
+
+` + codestart + `# Allow compacting the head of branch "master" in repo "foo" in place
+$ pachctl set-compact-in-place foo master
+
+# Stop allowing it
+$ pachctl set-compact-in-place foo master --disallow
+` + codeend,
+		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			return client.SetCompactInPlace(args[0], args[1], !disallow)
 		}),
 	}
+	setCompactInPlace.Flags().BoolVar(&disallow, "disallow", false, "Stop allowing the branch to be compacted in place.")
 
-	var filePaths []string
-	var recursive bool
-	var inputFile string
-	var parallelism uint
-	var split string
-	var targetFileDatums uint
-	var targetFileBytes uint
-	var putFileCommit bool
-	var overwrite bool
-	putFile := &cobra.Command{
-		Use:   "put-file repo-name branch path/to/file/in/pfs",
-		Short: "Put a file into the filesystem.",
-		Long: `Put-file supports a number of ways to insert data into pfs:
-` + codestart + `# Put data from stdin as repo/branch/path:
-$ echo "data" | pachctl put-file repo branch path
+	var policyBranches []string
+	var policy pfsclient.CompactionPolicy
+	var deletePolicy bool
+	setCompactionPolicy := &cobra.Command{
+		Use:   "set-compaction-policy <repo-name>",
+		Short: "Compact the fragmented files in a repo automatically.",
+		Long: `Compact the fragmented files on the heads of a repo's branches automatically, in the background. A file is compacted once it's made up of at least --min-objects objects whose average size is at most --max-average-object-size, see compact-file. Each head is checked once, and at most --max-per-hour compactions are run on the repo in any hour.
 
-# Put data from stding as repo/branch/path and start / finish a new commit on the branch.
-$ echo "data" | pachctl put-file -c repo branch path
+Compactions are run with your credentials. Setting a policy replaces the repo's existing policy.
 
-# Put a file from the local filesystem as repo/branch/path:
-$ pachctl put-file repo branch path -f file
+Examples:
 
-# Put a file from the local filesystem as repo/branch/file:
-$ pachctl put-file repo branch -f file
+` + codestart + `# Compact the head of branch "master" in repo "foo" in place once it has files of 100 or more objects
+$ pachctl set-compaction-policy foo --branch master --min-objects 100 --in-place
 
-# Put the contents of a directory as repo/branch/path/dir/file:
-$ pachctl put-file -r repo branch path -f dir
+# Stop compacting repo "foo" automatically
+$ pachctl set-compaction-policy foo --delete
+` + codeend,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			if deletePolicy {
+				return client.SetCompactionPolicy(args[0], nil)
+			}
+			policy.Branches = policyBranches
+			return client.SetCompactionPolicy(args[0], &policy)
+		}),
+	}
+	setCompactionPolicy.Flags().StringSliceVarP(&policyBranches, "branch", "b", nil, "A branch to compact, can be repeated; every branch is compacted if none is given.")
+	setCompactionPolicy.Flags().Int64Var(&policy.MinObjects, "min-objects", 0, "The number of objects that a file must be made up of to be compacted (default 16).")
+	setCompactionPolicy.Flags().Int64Var(&policy.MaxAverageObjectBytes, "max-average-object-size", 0, "The average size, in bytes, that the objects of a file must be under to be compacted (default 1MB).")
+	setCompactionPolicy.Flags().Int64Var(&policy.MaxCompactionsPerHour, "max-per-hour", 0, "The maximum number of compactions run on the repo in an hour (default 6).")
+	setCompactionPolicy.Flags().BoolVar(&policy.InPlace, "in-place", false, "Compact heads in place, which their branches must allow (see set-compact-in-place), rather than adding compaction commits to them.")
+	setCompactionPolicy.Flags().BoolVar(&deletePolicy, "delete", false, "Remove the repo's compaction policy.")
+
+	inspectCompactionPolicy := &cobra.Command{
+		Use:   "inspect-compaction-policy <repo-name>",
+		Short: "Return a repo's compaction policy.",
+		Long:  "Return a repo's compaction policy and the state of its automatic compactions on each branch.",
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			policyInfo, err := client.InspectCompactionPolicy(args[0])
+			if err != nil {
+				return err
+			}
+			if raw {
+				return marshaller.Marshal(os.Stdout, policyInfo)
+			}
+			return pretty.PrintDetailedCompactionPolicyInfo(policyInfo)
+		}),
+	}
+	rawFlag(inspectCompactionPolicy)
 
-# Put the contents of a directory as repo/branch/dir/file:
-$ pachctl put-file -r repo branch -f dir
+	var retentionBranches []string
+	var retentionPolicy pfsclient.RetentionPolicy
+	var deleteRetentionPolicy bool
+	setRetentionPolicy := &cobra.Command{
+		Use:   "set-retention-policy <repo-name>",
+		Short: "Expire a repo's idle branches automatically.",
+		Long: `Set the rules that a repo's branches are retained by, which are enforced in the background. Branches whose heads were finished more than --expire-idle-branches seconds ago are deleted. The heads of deleted branches, however they're deleted, are kept for --keep-deleted-heads seconds, and can be restored with set-branch while they are; inspect-retention-policy lists them.
 
-# Put the data from a URL as repo/branch/path:
-$ pachctl put-file repo branch path -f http://host/path
+Each enforcement is planned, and the plan is recorded before it's carried out; inspect-retention-policy shows the latest plans, and plan-retention shows what would be done now. With --dry-run plans are recorded but not carried out.
 
-# Put the data from a URL as repo/branch/path:
-$ pachctl put-file repo branch -f http://host/path
+Plans are carried out with your credentials. Setting a policy replaces the repo's existing policy.
 
-# Put several files or URLs that are listed in file.
-# Files and URLs should be newline delimited.
-$ pachctl put-file repo branch -i file
+Examples:
 
-# Put several files or URLs that are listed at URL.
-# NOTE this URL can reference local files, so it could cause you to put sensitive
-# files into your Pachyderm cluster.
-$ pachctl put-file repo branch -i http://host/path
-` + codeend + `
-NOTE there's a small performance overhead for using a branch name as opposed
-to a commit ID in put-file.  In most cases the performance overhead is
-negligible, but if you are putting a large number of small files, you might
-want to consider using commit IDs directly.
-`,
-		Run: cmdutil.RunBoundedArgs(2, 3, func(args []string) (retErr error) {
-			client, err := client.NewOnUserMachineWithConcurrency(metrics, "user", parallelism)
+` + codestart + `# Delete the branches of repo "foo" with no commits in 90 days, keeping their heads for 7 days
+$ pachctl set-retention-policy foo --expire-idle-branches 7776000 --keep-deleted-heads 604800
+
+# Record the plans of the policy without carrying them out
+$ pachctl set-retention-policy foo --expire-idle-branches 7776000 --dry-run
+
+# Stop enforcing retention on repo "foo"
+$ pachctl set-retention-policy foo --delete
+` + codeend,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
-			repoName := args[0]
-			branch := args[1]
-			var path string
-			if len(args) == 3 {
-				path = args[2]
-			}
-			if putFileCommit {
-				if _, err := client.StartCommit(repoName, branch); err != nil {
-					return err
-				}
-				defer func() {
-					if err := client.FinishCommit(repoName, branch); err != nil && retErr == nil {
-						retErr = err
-					}
-				}()
+			if deleteRetentionPolicy {
+				return client.SetRetentionPolicy(args[0], nil)
 			}
-
-			limiter := limit.New(int(parallelism))
+			retentionPolicy.Branches = retentionBranches
+			return client.SetRetentionPolicy(args[0], &retentionPolicy)
+		}),
+	}
+	setRetentionPolicy.Flags().StringSliceVarP(&retentionBranches, "branch", "b", nil, "A branch that the policy applies to, can be repeated; it applies to every branch if none is given.")
+	setRetentionPolicy.Flags().Int64Var(&retentionPolicy.ExpireIdleBranchSeconds, "expire-idle-branches", 0, "The number of seconds after its head was finished that a branch is deleted (default never).")
+	setRetentionPolicy.Flags().Int64Var(&retentionPolicy.KeepDeletedHeadSeconds, "keep-deleted-heads", 0, "The number of seconds that the heads of deleted branches are kept for (default they aren't kept).")
+	setRetentionPolicy.Flags().BoolVar(&retentionPolicy.DryRun, "dry-run", false, "Record the policy's plans without carrying them out.")
+	setRetentionPolicy.Flags().BoolVar(&deleteRetentionPolicy, "delete", false, "Remove the repo's retention policy.")
+
+	inspectRetentionPolicy := &cobra.Command{
+		Use:   "inspect-retention-policy <repo-name>",
+		Short: "Return a repo's retention policy.",
+		Long:  "Return a repo's retention policy, the heads of deleted branches that it keeps, and its latest plans.",
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			policyInfo, err := client.InspectRetentionPolicy(args[0])
+			if err != nil {
+				return err
+			}
+			if raw {
+				return marshaller.Marshal(os.Stdout, policyInfo)
+			}
+			return pretty.PrintDetailedRetentionPolicyInfo(policyInfo)
+		}),
+	}
+	rawFlag(inspectRetentionPolicy)
+
+	planRetention := &cobra.Command{
+		Use:   "plan-retention <repo-name>",
+		Short: "Return what a repo's retention policy would do now.",
+		Long:  "Return the plan that would enforce a repo's retention policy now, without carrying it out.",
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			plan, err := client.PlanRetention(args[0])
+			if err != nil {
+				return err
+			}
+			if raw {
+				return marshaller.Marshal(os.Stdout, plan)
+			}
+			return pretty.PrintDetailedRetentionPlan(plan)
+		}),
+	}
+	rawFlag(planRetention)
+
+	createCommitHook := &cobra.Command{
+		Use:   "create-commit-hook <repo-name> <hook-name> <url>",
+		Short: "Notify a url each time a commit is finished in a repo.",
+		Long: `Notify a url each time a commit is finished in a repo.
+
+A JSON CommitHookEvent describing the commit is POSTed to the url. Commits on
+the same branch are delivered in order, and failed deliveries are retried
+until the url responds with a 2xx status. An event may be delivered more than
+once, so receivers should deduplicate events by commit ID. A repo's hooks are
+run in the order that they were created: each hook is only notified of a
+commit once the hooks before it have acknowledged it.
+
+Examples:
+
+` + codestart + `# Notify an indexer each time a commit is finished in repo foo.
+$ pachctl create-commit-hook foo indexer http://indexer:8080/commits` + codeend,
+		Run: cmdutil.RunFixedArgs(3, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			return client.CreateCommitHook(args[0], args[1], args[2])
+		}),
+	}
+
+	listCommitHook := &cobra.Command{
+		Use:   "list-commit-hook <repo-name>",
+		Short: "Return the commit hooks in a repo.",
+		Long:  "Return the commit hooks in a repo, in the order that they're run, and their delivery state on each branch.",
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			hookInfos, err := client.ListCommitHook(args[0])
+			if err != nil {
+				return err
+			}
+			if raw {
+				for _, hookInfo := range hookInfos {
+					if err := marshaller.Marshal(os.Stdout, hookInfo); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+			writer := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
+			pretty.PrintCommitHookHeader(writer)
+			for _, hookInfo := range hookInfos {
+				pretty.PrintCommitHookInfo(writer, hookInfo)
+			}
+			return writer.Flush()
+		}),
+	}
+	rawFlag(listCommitHook)
+
+	deleteCommitHook := &cobra.Command{
+		Use:   "delete-commit-hook <repo-name> <hook-name>",
+		Short: "Delete a commit hook.",
+		Long:  "Delete a commit hook.",
+		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			return client.DeleteCommitHook(args[0], args[1])
+		}),
+	}
+
+	var lockOwner string
+	var lockTTL int64
+	acquireCommitLock := &cobra.Command{
+		Use:   "acquire-commit-lock <repo-name> <commit-id> <path>",
+		Short: "Lock a path of an open commit.",
+		Long: `Lock a path of an open commit, and everything under it, so that external
+writers can divide the commit between them. The lock is advisory: writes aren't
+checked against it, but no other lock can be acquired on the path, on a path
+under it or on a path above it. The lock expires unless it's renewed within
+its ttl, and it's released when the commit is finished. Its ID is printed.
+
+Examples:
+
+` + codestart + `# Lock /2018-06 of the open commit on master for 5 minutes
+$ pachctl acquire-commit-lock foo master /2018-06 --owner ingest-1 --ttl 300` + codeend,
+		Run: cmdutil.RunFixedArgs(3, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			lock, err := client.AcquireCommitLock(args[0], args[1], args[2], lockOwner, lockTTL)
+			if err != nil {
+				return err
+			}
+			fmt.Println(lock.ID)
+			return nil
+		}),
+	}
+	acquireCommitLock.Flags().StringVar(&lockOwner, "owner", "", "A description of the lock's holder, shown by list-commit-lock.")
+	acquireCommitLock.Flags().Int64Var(&lockTTL, "ttl", 0, "The number of seconds until the lock expires unless it's renewed, 60 if it isn't set.")
+
+	renewCommitLock := &cobra.Command{
+		Use:   "renew-commit-lock <repo-name> <commit-id> <lock-id>",
+		Short: "Extend the lease of a commit lock.",
+		Long:  "Extend the lease of a commit lock that hasn't expired, to its ttl from now.",
+		Run: cmdutil.RunFixedArgs(3, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			_, err = client.RenewCommitLock(args[0], args[1], args[2], lockTTL)
+			return err
+		}),
+	}
+	renewCommitLock.Flags().Int64Var(&lockTTL, "ttl", 0, "The number of seconds until the lock expires unless it's renewed again, 60 if it isn't set.")
+
+	releaseCommitLock := &cobra.Command{
+		Use:   "release-commit-lock <repo-name> <commit-id> <lock-id>",
+		Short: "Release a commit lock.",
+		Long:  "Release a commit lock.",
+		Run: cmdutil.RunFixedArgs(3, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			return client.ReleaseCommitLock(args[0], args[1], args[2])
+		}),
+	}
+
+	listCommitLock := &cobra.Command{
+		Use:   "list-commit-lock <repo-name> <commit-id>",
+		Short: "Return the locks held on an open commit.",
+		Long:  "Return the locks held on an open commit.",
+		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			locks, err := client.ListCommitLock(args[0], args[1])
+			if err != nil {
+				return err
+			}
+			if raw {
+				for _, lock := range locks {
+					if err := marshaller.Marshal(os.Stdout, lock); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+			writer := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
+			pretty.PrintCommitLockHeader(writer)
+			for _, lock := range locks {
+				pretty.PrintCommitLock(writer, lock)
+			}
+			return writer.Flush()
+		}),
+	}
+	rawFlag(listCommitLock)
+
+	file := &cobra.Command{
+		Use:   "file",
+		Short: "Docs for files.",
+		Long: `Files are the lowest level data object in Pachyderm.
+
+	Files can be written to started (but not finished) commits with put-file.
+	Files can be read from finished commits with get-file.`,
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			return nil
+		}),
+	}
+
+	var filePaths []string
+	var recursive bool
+	var inputFile string
+	var parallelism uint
+	var split string
+	var targetFileDatums uint
+	var targetFileBytes uint
+	var stats bool
+	var divertErrors bool
+	var targetFileCount uint
+	var headerLines uint
+	var footerLines uint
+	var separator string
+	var separatorRegex string
+	var jsonSchemaFile string
+	var putFileCommit bool
+	var overwrite bool
+	var createOnly bool
+	var byHash bool
+	var fileTTL int64
+	var checkLocks bool
+	var putFileLockID string
+	putFile := &cobra.Command{
+		Use:   "put-file repo-name branch path/to/file/in/pfs",
+		Short: "Put a file into the filesystem.",
+		Long: `Put-file supports a number of ways to insert data into pfs:
+` + codestart + `# Put data from stdin as repo/branch/path:
+$ echo "data" | pachctl put-file repo branch path
+
+# Put data from stding as repo/branch/path and start / finish a new commit on the branch.
+$ echo "data" | pachctl put-file -c repo branch path
+
+# Put a file from the local filesystem as repo/branch/path:
+$ pachctl put-file repo branch path -f file
+
+# Put a file from the local filesystem as repo/branch/file:
+$ pachctl put-file repo branch -f file
+
+# Put the contents of a directory as repo/branch/path/dir/file:
+$ pachctl put-file -r repo branch path -f dir
+
+# Put the contents of a directory as repo/branch/dir/file:
+$ pachctl put-file -r repo branch -f dir
+
+# Put the data from a URL as repo/branch/path:
+$ pachctl put-file repo branch path -f http://host/path
+
+# Put the data from a URL as repo/branch/path:
+$ pachctl put-file repo branch -f http://host/path
+
+# Put a FASTA file as repo/branch/path, split into files of 100 sequences:
+$ pachctl put-file repo branch path -f seqs.fasta --split separator --separator-regex '\n(>)' --target-file-datums 100
+
+# Put a Parquet file as repo/branch/path, split into files of 4 row groups:
+$ pachctl put-file repo branch path -f data.parquet --split parquet --target-file-datums 4
+
+# Put several files or URLs that are listed in file.
+# Files and URLs should be newline delimited.
+$ pachctl put-file repo branch -i file
+
+# Put several files or URLs that are listed at URL.
+# NOTE this URL can reference local files, so it could cause you to put sensitive
+# files into your Pachyderm cluster.
+$ pachctl put-file repo branch -i http://host/path
+` + codeend + `
+NOTE there's a small performance overhead for using a branch name as opposed
+to a commit ID in put-file.  In most cases the performance overhead is
+negligible, but if you are putting a large number of small files, you might
+want to consider using commit IDs directly.
+`,
+		Run: cmdutil.RunBoundedArgs(2, 3, func(args []string) (retErr error) {
+			client, err := client.NewOnUserMachineWithConcurrency(metrics, "user", parallelism)
+			if err != nil {
+				return err
+			}
+			repoName := args[0]
+			branch := args[1]
+			var path string
+			if len(args) == 3 {
+				path = args[2]
+			}
+			if putFileCommit {
+				if _, err := client.StartCommit(repoName, branch); err != nil {
+					return err
+				}
+				defer func() {
+					if err := client.FinishCommit(repoName, branch); err != nil && retErr == nil {
+						retErr = err
+					}
+				}()
+			}
+
+			var jsonSchema []byte
+			if jsonSchemaFile != "" {
+				if jsonSchema, err = ioutil.ReadFile(jsonSchemaFile); err != nil {
+					return err
+				}
+			}
+
+			limiter := limit.New(int(parallelism))
 			var sources []string
 			if inputFile != "" {
 				var r io.Reader
@@ -687,19 +1719,19 @@ want to consider using commit IDs directly.
 						return fmt.Errorf("no filename specified")
 					}
 					eg.Go(func() error {
-						return putFileHelper(client, repoName, branch, joinPaths("", source), source, recursive, overwrite, limiter, split, targetFileDatums, targetFileBytes)
+						return putFileHelper(client, repoName, branch, joinPaths("", source), source, recursive, overwrite, createOnly, byHash, limiter, split, targetFileDatums, targetFileBytes, targetFileCount, stats, divertErrors, headerLines, footerLines, separator, separatorRegex, jsonSchema, fileTTL, checkLocks || putFileLockID != "", putFileLockID)
 					})
 				} else if len(sources) == 1 && len(args) == 3 {
 					// We have a single source and the user has specified a path,
 					// we use the path and ignore source (in terms of naming the file).
 					eg.Go(func() error {
-						return putFileHelper(client, repoName, branch, path, source, recursive, overwrite, limiter, split, targetFileDatums, targetFileBytes)
+						return putFileHelper(client, repoName, branch, path, source, recursive, overwrite, createOnly, byHash, limiter, split, targetFileDatums, targetFileBytes, targetFileCount, stats, divertErrors, headerLines, footerLines, separator, separatorRegex, jsonSchema, fileTTL, checkLocks || putFileLockID != "", putFileLockID)
 					})
 				} else if len(sources) > 1 && len(args) == 3 {
 					// We have multiple sources and the user has specified a path,
 					// we use that path as a prefix for the filepaths.
 					eg.Go(func() error {
-						return putFileHelper(client, repoName, branch, joinPaths(path, source), source, recursive, overwrite, limiter, split, targetFileDatums, targetFileBytes)
+						return putFileHelper(client, repoName, branch, joinPaths(path, source), source, recursive, overwrite, createOnly, byHash, limiter, split, targetFileDatums, targetFileBytes, targetFileCount, stats, divertErrors, headerLines, footerLines, separator, separatorRegex, jsonSchema, fileTTL, checkLocks || putFileLockID != "", putFileLockID)
 					})
 				}
 			}
@@ -710,11 +1742,76 @@ want to consider using commit IDs directly.
 	putFile.Flags().StringVarP(&inputFile, "input-file", "i", "", "Read filepaths or URLs from a file.  If - is used, paths are read from the standard input.")
 	putFile.Flags().BoolVarP(&recursive, "recursive", "r", false, "Recursively put the files in a directory.")
 	putFile.Flags().UintVarP(&parallelism, "parallelism", "p", DefaultParallelism, "The maximum number of files that can be uploaded in parallel.")
-	putFile.Flags().StringVar(&split, "split", "", "Split the input file into smaller files, subject to the constraints of --target-file-datums and --target-file-bytes. Permissible values are `json` and `line`.")
+	putFile.Flags().StringVar(&split, "split", "", "Split the input file into smaller files, subject to the constraints of --target-file-datums and --target-file-bytes. Permissible values are `json`, `line`, `separator`, `parquet` and `tar`; `separator` splits on --separator or --separator-regex, `parquet` splits a Parquet file on its row groups and `tar` extracts a tar (or tar.gz) archive into the target directory.")
 	putFile.Flags().UintVar(&targetFileDatums, "target-file-datums", 0, "The upper bound of the number of datums that each file contains, the last file will contain fewer if the datums don't divide evenly; needs to be used with --split.")
 	putFile.Flags().UintVar(&targetFileBytes, "target-file-bytes", 0, "The target upper bound of the number of bytes that each file contains; needs to be used with --split.")
+	putFile.Flags().UintVar(&targetFileCount, "target-file-count", 0, "The number of files to split the data into, with the same number of datums give or take one; needs to be used with --split json or --split line.")
+	putFile.Flags().BoolVar(&stats, "stats", false, "Compute the row count and the min and max of each column of every file written, the stats are shown by inspect-file; needs to be used with --split json or --split line (CSV with a header line).")
+	putFile.Flags().UintVar(&headerLines, "header-lines", 0, "The number of lines at the start of the input, such as a CSV header, to add to the start of every file written by --split line.")
+	putFile.Flags().UintVar(&footerLines, "footer-lines", 0, "The number of lines at the end of the input to add to the end of every file written by --split line.")
+	putFile.Flags().BoolVar(&divertErrors, "divert-errors", false, "Write the records that can't be parsed, with their line numbers, to an errors file under /_errors instead of failing; needs to be used with --split json or --split line.")
+	putFile.Flags().StringVar(&separator, "separator", "", "The byte sequence, which may contain escapes such as \\n and \\x1e, that ends each record of the input; needs to be used with --split separator.")
+	putFile.Flags().StringVar(&separatorRegex, "separator-regex", "", "The regexp that ends each record of the input, or if it has a parenthesized subexpression, whose first subexpression starts each record; needs to be used with --split separator.")
+	putFile.Flags().StringVar(&jsonSchemaFile, "json-schema", "", "A file with a JSON Schema that every record must match, the ones that don't fail the put, or are written to the errors file with --divert-errors; needs to be used with --split json.")
 	putFile.Flags().BoolVarP(&putFileCommit, "commit", "c", false, "Put file(s) in a new commit.")
 	putFile.Flags().BoolVarP(&overwrite, "overwrite", "o", false, "Overwrite the existing content of the file, either from previous commits or previous calls to put-file within this commit.")
+	putFile.Flags().Int64Var(&fileTTL, "ttl", 0, "Delete the file from the branch this many seconds from now, in a commit that pfs makes. Putting it again with --ttl moves the deletion.")
+	putFile.Flags().BoolVar(&checkLocks, "check-locks", false, "Fail, without writing anything, if the file is locked by a commit lock (see acquire-commit-lock) other than --lock-id.")
+	putFile.Flags().StringVar(&putFileLockID, "lock-id", "", "The ID of the commit lock held by this writer, which implies --check-locks.")
+	putFile.Flags().BoolVar(&byHash, "by-hash", false, "Send the hashes of each file's content first, and only upload the content if pfs doesn't already have it, which makes re-putting unchanged data fast.")
+	putFile.Flags().BoolVar(&createOnly, "create-only", false, "Fail rather than write to a file that already exists, either from previous commits or previous calls to put-file within this commit.")
+
+	var uploadFile string
+	var uploadID string
+	var chunkSize int64
+	uploadFileCmd := &cobra.Command{
+		Use:   "upload-file repo-name commit-id path/to/file/in/pfs",
+		Short: "Put a local file into an open commit with a resumable upload.",
+		Long: `Put a local file into an open commit with a resumable upload, for large
+files sent over unreliable links. The file is uploaded in chunks, and if the
+upload is interrupted, rerunning the command with --upload-id resumes it after
+the last chunk that pachd acknowledged. The upload's ID is printed when it's
+started.
+
+Examples:
+
+` + codestart + `# Upload a large file in 64MB chunks
+$ pachctl upload-file foo master /data.bin -f data.bin
+
+# Resume an interrupted upload
+$ pachctl upload-file foo master /data.bin -f data.bin --upload-id 2a3bd4c0e8f54d6a9d3c9f1a7e5b6c8d` + codeend,
+		Run: cmdutil.RunFixedArgs(3, func(args []string) (retErr error) {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			if uploadFile == "" {
+				return fmt.Errorf("the file to upload needs to be set with -f")
+			}
+			f, err := os.Open(uploadFile)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if err := f.Close(); err != nil && retErr == nil {
+					retErr = err
+				}
+			}()
+			if uploadID == "" {
+				session, err := client.StartUpload(args[0], args[1], args[2], overwrite)
+				if err != nil {
+					return err
+				}
+				uploadID = session.ID
+				fmt.Fprintf(os.Stderr, "Started upload %s, rerun with --upload-id %s to resume it if it's interrupted.\n", uploadID, uploadID)
+			}
+			return client.PutFileResumable(uploadID, f, chunkSize)
+		}),
+	}
+	uploadFileCmd.Flags().StringVarP(&uploadFile, "file", "f", "", "The local file to upload.")
+	uploadFileCmd.Flags().StringVar(&uploadID, "upload-id", "", "The ID of an interrupted upload of the same file to resume.")
+	uploadFileCmd.Flags().Int64Var(&chunkSize, "chunk-size", 64*1024*1024, "The number of bytes in each chunk, data sent since the last acknowledged chunk is resent when an upload is resumed.")
+	uploadFileCmd.Flags().BoolVarP(&overwrite, "overwrite", "o", false, "Overwrite the existing content of the file when the upload completes.")
 
 	copyFile := &cobra.Command{
 		Use:   "copy-file src-repo src-commit src-path dst-repo dst-commit dst-path",
@@ -730,37 +1827,163 @@ want to consider using commit IDs directly.
 	}
 	copyFile.Flags().BoolVarP(&overwrite, "overwrite", "o", false, "Overwrite the existing content of the file, either from previous commits or previous calls to put-file within this commit.")
 
-	var outputPath string
-	getFile := &cobra.Command{
-		Use:   "get-file repo-name commit-id path/to/file",
-		Short: "Return the contents of a file.",
-		Long: `Return the contents of a file.
-` + codestart + `# get file "XXX" on branch "master" in repo "foo"
-$ pachctl get-file foo master XXX
+	moveFile := &cobra.Command{
+		Use:   "move-file repo-name commit-id src-path dst-path",
+		Short: "Move a file or directory within an open commit.",
+		Long: `Move a file or directory within an open commit, replacing whatever is at dst-path. The content isn't copied, so moving a large directory is cheap.
 
-# get file "XXX" in the parent of the current head of branch "master"
-# in repo "foo"
-$ pachctl get-file foo master^ XXX
+Examples:
 
-# get file "XXX" in the grandparent of the current head of branch "master"
-# in repo "foo"
-$ pachctl get-file foo master^2 XXX
+` + codestart + `# Rename directory "staging" to "data" in the open commit on branch "master" in repo "foo"
+$ pachctl move-file foo master staging data
 ` + codeend,
-		Run: cmdutil.RunFixedArgs(3, func(args []string) error {
+		Run: cmdutil.RunFixedArgs(4, func(args []string) error {
 			client, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
-			if recursive {
-				if outputPath == "" {
-					return fmt.Errorf("an output path needs to be specified when using the --recursive flag")
-				}
-				puller := sync.NewPuller()
-				return puller.Pull(client, outputPath, args[0], args[1], args[2], false, int(parallelism), nil, "")
-			}
-			var w io.Writer
-			// If an output path is given, print the output to stdout
-			if outputPath == "" {
+			return client.MoveFile(args[0], args[1], args[2], args[3])
+		}),
+	}
+
+	var inPlace bool
+	compactFile := &cobra.Command{
+		Use:   "compact-file repo-name commit-id path/to/file",
+		Short: "Rewrite a fragmented file into as few objects as possible.",
+		Long: `Rewrite a file that's made up of many small objects, for example one that was appended to many times, or every such file under a directory, into as few objects as possible, which makes it faster to read.
+
+The compacted files are put in a new commit on top of commit-id, unless --in-place is set, in which case commit-id must be a branch that allows it (see set-compact-in-place) and its head is rewritten.
+
+Examples:
+
+` + codestart + `# Compact "logs/app.log" on branch "master" in repo "foo"
+$ pachctl compact-file foo master logs/app.log
+` + codeend,
+		Run: cmdutil.RunFixedArgs(3, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			response, err := client.CompactFile(args[0], args[1], args[2], inPlace)
+			if err != nil {
+				return err
+			}
+			printCompactResponse(response)
+			return nil
+		}),
+	}
+	compactFile.Flags().BoolVar(&inPlace, "in-place", false, "Rewrite the head of the branch commit-id rather than adding a commit to it.")
+
+	compactCommit := &cobra.Command{
+		Use:   "compact-commit repo-name commit-id",
+		Short: "Rewrite the fragmented files in a commit into as few objects as possible.",
+		Long: `Rewrite every file in a commit that's made up of many small objects into as few objects as possible, see compact-file.
+
+Examples:
+
+` + codestart + `# Compact the head of branch "master" in repo "foo" in place
+$ pachctl compact-commit foo master --in-place
+` + codeend,
+		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			response, err := client.CompactCommit(args[0], args[1], inPlace)
+			if err != nil {
+				return err
+			}
+			printCompactResponse(response)
+			return nil
+		}),
+	}
+	compactCommit.Flags().BoolVar(&inPlace, "in-place", false, "Rewrite the head of the branch commit-id rather than adding a commit to it.")
+
+	putSymlink := &cobra.Command{
+		Use:   "put-symlink repo-name commit-id path/to/link target",
+		Short: "Put a symlink into the filesystem.",
+		Long: `Put a symlink to another path in the same commit into the filesystem.
+
+An absolute target is a path in the commit, a relative one is relative to the directory that contains the symlink. The symlink replaces whatever is at its path.
+
+Examples:
+
+` + codestart + `# Put a symlink "latest" on branch "master" in repo "foo" that points to "v2/data.csv"
+$ pachctl put-symlink foo master latest /v2/data.csv
+` + codeend,
+		Run: cmdutil.RunFixedArgs(4, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			return client.PutSymlink(args[0], args[1], args[2], args[3])
+		}),
+	}
+
+	var outputPath string
+	var windowsPaths bool
+	var tarArchive bool
+	var followSymlinks bool
+	var verify bool
+	var limitRate string
+	var windows string
+	getFile := &cobra.Command{
+		Use:   "get-file repo-name commit-id path/to/file",
+		Short: "Return the contents of a file.",
+		Long: `Return the contents of a file.
+` + codestart + `# get file "XXX" on branch "master" in repo "foo"
+$ pachctl get-file foo master XXX
+
+# get file "XXX" in the parent of the current head of branch "master"
+# in repo "foo"
+$ pachctl get-file foo master^ XXX
+
+# get file "XXX" in the grandparent of the current head of branch "master"
+# in repo "foo"
+$ pachctl get-file foo master^2 XXX
+
+# download directory "dir" on branch "master" in repo "foo" as a tar archive
+$ pachctl get-file foo master dir --tar -o dir.tar
+
+# download directory "dir" at no more than 10MB/s, and only overnight
+$ pachctl get-file foo master dir -r -o dir --limit-rate 10M --windows 22:00-06:00
+` + codeend,
+		Run: cmdutil.RunFixedArgs(3, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			if recursive && tarArchive {
+				return fmt.Errorf("the --recursive and --tar flags are mutually exclusive")
+			}
+			if verify && (recursive || tarArchive || followSymlinks) {
+				return fmt.Errorf("--verify can't be used with --recursive, --tar or --follow-symlinks")
+			}
+			t, err := newThrottle(limitRate, windows)
+			if err != nil {
+				return err
+			}
+			if recursive {
+				if outputPath == "" {
+					return fmt.Errorf("an output path needs to be specified when using the --recursive flag")
+				}
+				puller := sync.NewPuller()
+				if windowsPaths {
+					puller.TranslatePaths()
+				}
+				puller.Throttle(t)
+				if err := puller.Pull(client, outputPath, args[0], args[1], args[2], false, int(parallelism), nil, ""); err != nil {
+					return err
+				}
+				for _, translation := range puller.Translations() {
+					fmt.Fprintf(os.Stderr, "%s was written to %s (%s)\n", translation.PFSPath,
+						filepath.Join(outputPath, filepath.FromSlash(translation.LocalPath)), strings.Join(translation.Reasons, ", "))
+				}
+				return nil
+			}
+			var w io.Writer
+			// If an output path is given, print the output to stdout
+			if outputPath == "" {
 				w = os.Stdout
 			} else {
 				f, err := os.Create(outputPath)
@@ -770,12 +1993,119 @@ $ pachctl get-file foo master^2 XXX
 				defer f.Close()
 				w = f
 			}
+			w = t.Writer(context.Background(), w)
+			if tarArchive {
+				return client.GetFileTar(args[0], args[1], args[2], w)
+			}
+			if followSymlinks {
+				return client.GetFileFollowSymlinks(args[0], args[1], args[2], 0, 0, w)
+			}
+			if verify {
+				return client.GetFileVerified(args[0], args[1], args[2], 0, 0, w)
+			}
 			return client.GetFile(args[0], args[1], args[2], 0, 0, w)
 		}),
 	}
 	getFile.Flags().BoolVarP(&recursive, "recursive", "r", false, "Recursively download a directory.")
 	getFile.Flags().StringVarP(&outputPath, "output", "o", "", "The path where data will be downloaded.")
+	getFile.Flags().BoolVar(&windowsPaths, "windows-paths", runtime.GOOS == "windows", "Rename files whose paths can't be created on Windows (reserved names, invalid characters, case collisions) when using the --recursive flag; renamed files are reported on stderr.")
+	getFile.Flags().BoolVar(&tarArchive, "tar", false, "Download a file or directory as a tar archive.")
+	getFile.Flags().BoolVarP(&followSymlinks, "follow-symlinks", "L", false, "Resolve the symlinks in the path, getting a symlink returns the content of the file it points to.")
+	getFile.Flags().BoolVar(&verify, "verify", false, "Check the file's content against the hashes it was stored with as it's downloaded, failing with an integrity error on a mismatch.")
 	getFile.Flags().UintVarP(&parallelism, "parallelism", "p", DefaultParallelism, "The maximum number of files that can be downloaded in parallel")
+	getFile.Flags().StringVar(&limitRate, "limit-rate", "", "The most data to download per second, e.g. 10M.")
+	getFile.Flags().StringVar(&windows, "windows", "", "Only download data within these daily windows of local time, e.g. 22:00-06:00,12:00-13:00; the download waits for the next window outside of them.")
+
+	var offsetRecords int64
+	var numRecords int64
+	getRecords := &cobra.Command{
+		Use:   "get-records repo-name commit-id path/to/dir",
+		Short: "Return a range of the records of a split file.",
+		Long: `Return a range of the records of a directory produced by put-file --split.
+Records are numbered from 0 across all of the directory's files.
+
+Examples:
+
+` + codestart + `# Return records 1000 to 1099 of "data" on branch "master" in repo "foo"
+$ pachctl get-records foo master data --offset 1000 --number 100
+` + codeend,
+		Run: cmdutil.RunFixedArgs(3, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			return client.GetRecords(args[0], args[1], args[2], offsetRecords, numRecords, os.Stdout)
+		}),
+	}
+	getRecords.Flags().Int64Var(&offsetRecords, "offset", 0, "The index of the first record to return.")
+	getRecords.Flags().Int64VarP(&numRecords, "number", "n", 0, "The number of records to return, 0 returns all of the records from --offset on.")
+
+	var grepLimit int64
+	grepFile := &cobra.Command{
+		Use:   "grep-file repo-name commit-id pattern regex",
+		Short: "Return the lines of files that match a regular expression.",
+		Long: `Return the lines of the files that match a glob pattern which match a regular
+expression, as path:line-number:line. The files are searched by pachd, so only
+the matching lines are downloaded.
+
+Examples:
+
+` + codestart + `# Return the first 10 lines that contain "timeout" in the logs in repo "foo" on branch "master"
+$ pachctl grep-file foo master "logs/*" timeout --limit 10
+` + codeend,
+		Run: cmdutil.RunFixedArgs(4, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			return client.GrepFile(args[0], args[1], args[2], args[3], grepLimit, func(match *pfsclient.GrepMatch) error {
+				if raw {
+					return marshaller.Marshal(os.Stdout, match)
+				}
+				fmt.Printf("%s:%d:%s\n", match.File.Path, match.LineNumber, match.Line)
+				return nil
+			})
+		}),
+	}
+	grepFile.Flags().Int64Var(&grepLimit, "limit", 0, "Stop after this many matching lines.")
+	rawFlag(grepFile)
+
+	var regex string
+	var jmesPath string
+	filterFile := &cobra.Command{
+		Use:   "filter-file repo-name commit-id pattern",
+		Short: "Return the records of files that match a regex or JMESPath expression.",
+		Long: `Return the records of the files that match a glob pattern which match a regex
+or JMESPath expression. The files are filtered by pachd, so only the matching
+records are downloaded.
+
+With --regex the records are lines. With --jmespath they're JSON values, and
+the values for which the expression is truthy are returned, one per line.
+
+Examples:
+
+` + codestart + `# Return the lines of the logs in repo "foo" on branch "master" that contain "ERROR"
+$ pachctl filter-file foo master "logs/*" --regex ERROR
+
+# Return the events in repo "foo" on branch "master" with a level of "error"
+$ pachctl filter-file foo master "events/*.json" --jmespath "level == 'error'"
+` + codeend,
+		Run: cmdutil.RunFixedArgs(3, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			if (regex == "") == (jmesPath == "") {
+				return fmt.Errorf("exactly one of --regex and --jmespath must be set")
+			}
+			if jmesPath != "" {
+				return client.FilterFileJSON(args[0], args[1], args[2], jmesPath, os.Stdout)
+			}
+			return client.FilterFile(args[0], args[1], args[2], regex, os.Stdout)
+		}),
+	}
+	filterFile.Flags().StringVarP(&regex, "regex", "e", "", "Return the lines that match this regular expression.")
+	filterFile.Flags().StringVar(&jmesPath, "jmespath", "", "Return the JSON values for which this JMESPath expression is truthy.")
 
 	inspectFile := &cobra.Command{
 		Use:   "inspect-file repo-name commit-id path/to/file",
@@ -786,184 +2116,969 @@ $ pachctl get-file foo master^2 XXX
 			if err != nil {
 				return err
 			}
-			fileInfo, err := client.InspectFile(args[0], args[1], args[2])
+			fileInfo, err := client.InspectFile(args[0], args[1], args[2])
+			if err != nil {
+				return err
+			}
+			if fileInfo == nil {
+				return fmt.Errorf("file %s not found", args[2])
+			}
+			if raw {
+				return marshaller.Marshal(os.Stdout, fileInfo)
+			}
+			return pretty.PrintDetailedFileInfo(fileInfo)
+		}),
+	}
+	rawFlag(inspectFile)
+
+	var limit int64
+	var pageToken string
+	var fast bool
+	listFile := &cobra.Command{
+		Use:   "list-file repo-name commit-id path/to/dir",
+		Short: "Return the files in a directory.",
+		Long: `Return the files in a directory.
+
+Examples:
+
+` + codestart + `# list top-level files on branch "master" in repo "foo"
+$ pachctl list-file foo master
+
+# list files under path XXX on branch "master" in repo "foo"
+$ pachctl list-file foo master XXX
+
+# list top-level files in the parent commit of the current head of "master"
+# in repo "foo"
+$ pachctl list-file foo master^
+
+# list top-level files in the grandparent of the current head of "master"
+# in repo "foo"
+$ pachctl list-file foo master^2
+
+# list every file under path XXX on branch "master" in repo "foo"
+$ pachctl list-file foo master XXX --recursive
+
+# list every file on branch "master" in repo "foo" as JSON Lines
+$ pachctl list-file foo master --recursive --output jsonl
+` + codeend,
+		Run: cmdutil.RunBoundedArgs(2, 3, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			var path string
+			if len(args) == 3 {
+				path = args[2]
+			}
+			mode := pfsclient.ListFileMode_ListFile_NORMAL
+			if fast && recursive {
+				return fmt.Errorf("only one of --fast and --recursive can be set")
+			} else if fast {
+				mode = pfsclient.ListFileMode_ListFile_FAST
+			} else if recursive {
+				mode = pfsclient.ListFileMode_ListFile_RECURSE
+			}
+			if output != "" && raw {
+				return fmt.Errorf("only one of --output and --raw can be set")
+			}
+			fileInfos, nextPageToken, err := client.ListFilePageMode(args[0], args[1], path, followSymlinks, limit, pageToken, mode)
+			if err != nil {
+				return err
+			}
+			printNextPageToken(nextPageToken)
+			if output != "" {
+				encoder, err := pretty.NewListingEncoder(output, os.Stdout)
+				if err != nil {
+					return err
+				}
+				for _, fileInfo := range fileInfos {
+					if err := encoder.EncodeFileInfo(fileInfo); err != nil {
+						return err
+					}
+				}
+				return encoder.Flush()
+			}
+			if raw {
+				for _, fileInfo := range fileInfos {
+					if err := marshaller.Marshal(os.Stdout, fileInfo); err != nil {
+						return err
+					}
+				}
+			}
+			writer := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
+			pretty.PrintFileInfoHeader(writer)
+			for _, fileInfo := range fileInfos {
+				pretty.PrintFileInfo(writer, fileInfo)
+			}
+			return writer.Flush()
+		}),
+	}
+	listFile.Flags().BoolVarP(&followSymlinks, "follow-symlinks", "L", false, "Resolve the symlinks in the path, and list each symlink with the type and size of the file it points to.")
+	listFile.Flags().Int64Var(&limit, "limit", 0, "List at most this many files, the rest can be listed with --page-token.")
+	listFile.Flags().StringVar(&pageToken, "page-token", "", "List the files after the last file of a previous --limit listing.")
+	listFile.Flags().BoolVar(&fast, "fast", false, "Omit the files' sizes, which makes listing large open commits faster.")
+	listFile.Flags().BoolVarP(&recursive, "recursive", "r", false, "List every file and directory under the path, rather than only the ones in it.")
+	rawFlag(listFile)
+	outputFlag(listFile)
+
+	var directoriesOnly bool
+	globFile := &cobra.Command{
+		Use:   "glob-file repo-name commit-id pattern",
+		Short: "Return files that match a glob pattern in a commit.",
+		Long: `Return files that match a glob pattern in a commit (that is, match a glob pattern
+in a repo at the state represented by a commit). Glob patterns are
+documented [here](https://golang.org/pkg/path/filepath/#Match).
+
+Examples:
+
+` + codestart + `# Return files in repo "foo" on branch "master" that start
+# with the character "A".  Note how the double quotation marks around "A*" are
+# necessary because otherwise your shell might interpret the "*".
+$ pachctl glob-file foo master "A*"
+
+# Return files in repo "foo" on branch "master" under directory "data".
+$ pachctl glob-file foo master "data/*"
+
+# Return the size of each top-level directory in repo "foo" on branch "master".
+$ pachctl glob-file foo master "*/"
+` + codeend,
+		Run: cmdutil.RunFixedArgs(3, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			pattern := args[2]
+			if directoriesOnly && !strings.HasSuffix(pattern, "/") {
+				// a trailing "/" only matches directories
+				pattern += "/"
+			}
+			fileInfos, nextPageToken, err := client.GlobFilePage(args[0], args[1], pattern, limit, pageToken)
+			if err != nil {
+				return err
+			}
+			printNextPageToken(nextPageToken)
+			if raw {
+				for _, fileInfo := range fileInfos {
+					if err := marshaller.Marshal(os.Stdout, fileInfo); err != nil {
+						return err
+					}
+				}
+			}
+			writer := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
+			pretty.PrintFileInfoHeader(writer)
+			for _, fileInfo := range fileInfos {
+				pretty.PrintFileInfo(writer, fileInfo)
+			}
+			return writer.Flush()
+		}),
+	}
+	globFile.Flags().Int64Var(&limit, "limit", 0, "Return at most this many files, the rest can be returned with --page-token.")
+	globFile.Flags().StringVar(&pageToken, "page-token", "", "Return the files after the last file of a previous --limit call.")
+	globFile.Flags().BoolVarP(&directoriesOnly, "directories", "d", false, "Only match directories, as a pattern ending in \"/\" does.")
+	rawFlag(globFile)
+
+	searchFile := &cobra.Command{
+		Use:   "search-file repo-name commit-id regex",
+		Short: "Return files whose paths match a regular expression in a commit.",
+		Long: `Return the files and directories in a commit whose paths match a regular
+expression. Paths start with "/" and the expression isn't anchored. The syntax
+is documented [here](https://golang.org/pkg/regexp/syntax/).
+
+Examples:
+
+` + codestart + `# Return the CSV files in repo "foo" on branch "master" whose names are dates
+$ pachctl search-file foo master '/[0-9]{4}-[0-9]{2}-[0-9]{2}\.csv$'
+` + codeend,
+		Run: cmdutil.RunFixedArgs(3, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			if raw {
+				return client.SearchFile(args[0], args[1], args[2], func(fileInfo *pfsclient.FileInfo) error {
+					return marshaller.Marshal(os.Stdout, fileInfo)
+				})
+			}
+			writer := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
+			pretty.PrintFileInfoHeader(writer)
+			if err := client.SearchFile(args[0], args[1], args[2], func(fileInfo *pfsclient.FileInfo) error {
+				pretty.PrintFileInfo(writer, fileInfo)
+				return nil
+			}); err != nil {
+				return err
+			}
+			return writer.Flush()
+		}),
+	}
+	rawFlag(searchFile)
+
+	walkFile := &cobra.Command{
+		Use:   "walk-file repo-name commit-id [path/in/pfs]",
+		Short: "Return a file or directory and everything under it.",
+		Long: `Return a file or directory (the root directory if no path is given) and
+every file and directory under it, depth-first in path order. The files are
+returned as they're found, so it's quick to start listing even huge trees.
+
+Examples:
+
+` + codestart + `# Return everything in repo "foo" on branch "master"
+$ pachctl walk-file foo master
+
+# Return everything under directory "dir" as JSON
+$ pachctl walk-file foo master dir --raw
+` + codeend,
+		Run: cmdutil.RunBoundedArgs(2, 3, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			var path string
+			if len(args) == 3 {
+				path = args[2]
+			}
+			if raw {
+				return client.WalkFile(args[0], args[1], path, func(fileInfo *pfsclient.FileInfo) error {
+					return marshaller.Marshal(os.Stdout, fileInfo)
+				})
+			}
+			writer := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
+			pretty.PrintFileInfoHeader(writer)
+			if err := client.WalkFile(args[0], args[1], path, func(fileInfo *pfsclient.FileInfo) error {
+				pretty.PrintFileInfo(writer, fileInfo)
+				return nil
+			}); err != nil {
+				return err
+			}
+			return writer.Flush()
+		}),
+	}
+	rawFlag(walkFile)
+
+	getManifest := &cobra.Command{
+		Use:   "get-manifest repo-name commit-id [glob...]",
+		Short: "Return the manifest of the files in a commit that match globs.",
+		Long: `Return the path, size and objects of each file in a finished commit that
+matches one of the glob patterns, or is under a directory that does, or of
+every file if no patterns are given. The manifest is taken from the commit that
+commit-id resolves to, so it stays valid as branches move, and its files can be
+read without asking pfs about them again.
+
+Examples:
+
+` + codestart + `# Return the manifest of the CSV files under "data" in repo "foo" on branch "master"
+$ pachctl get-manifest foo master "data/*.csv"
+
+# Return the manifest of everything in repo "foo" on branch "master" as JSON
+$ pachctl get-manifest foo master --raw
+` + codeend,
+		Run: cmdutil.Run(func(args []string) error {
+			if len(args) < 2 {
+				return fmt.Errorf("must provide a repo and a commit")
+			}
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			manifest, err := client.GetManifest(args[0], args[1], args[2:]...)
+			if err != nil {
+				return err
+			}
+			if raw {
+				return marshaller.Marshal(os.Stdout, manifest)
+			}
+			writer := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
+			pretty.PrintManifestEntryHeader(writer)
+			for _, entry := range manifest.Entries {
+				pretty.PrintManifestEntry(writer, entry)
+			}
+			return writer.Flush()
+		}),
+	}
+	rawFlag(getManifest)
+
+	var manifestPath string
+	var overwriteManifest bool
+	putFilesFromManifest := &cobra.Command{
+		Use:   "put-files-from-manifest repo-name commit-id -f manifest.json",
+		Short: "Put the files in a manifest into a commit.",
+		Long: `Put the files in a manifest, in the JSON format that 'get-manifest --raw'
+prints, into a commit atomically. Files whose objects are already in the object
+store aren't copied, so a manifest can be put into another commit, or repo,
+without streaming any data. An entry can also have a "url" instead of objects,
+whose data pachd fetches.
+
+Examples:
+
+` + codestart + `# Put the CSV files in repo "foo" into repo "bar" without copying them
+$ pachctl get-manifest foo master "*.csv" --raw > manifest.json
+$ pachctl put-files-from-manifest bar master -f manifest.json
+` + codeend,
+		Run: cmdutil.RunFixedArgs(2, func(args []string) (retErr error) {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			var manifestReader io.Reader
+			if manifestPath == "-" {
+				manifestReader = os.Stdin
+				fmt.Print("Reading from stdin.\n")
+			} else {
+				manifestFile, err := os.Open(manifestPath)
+				if err != nil {
+					return err
+				}
+				defer func() {
+					if err := manifestFile.Close(); err != nil && retErr == nil {
+						retErr = err
+					}
+				}()
+				manifestReader = manifestFile
+			}
+			manifest := &pfsclient.Manifest{}
+			if err := jsonpb.Unmarshal(manifestReader, manifest); err != nil {
+				return err
+			}
+			return client.PutFilesFromManifest(args[0], args[1], manifest.Entries, overwriteManifest)
+		}),
+	}
+	putFilesFromManifest.Flags().StringVarP(&manifestPath, "file", "f", "-", "The file containing the manifest, \"-\" reads from stdin.")
+	putFilesFromManifest.Flags().BoolVarP(&overwriteManifest, "overwrite", "o", false, "Overwrite the existing files at the manifest's paths rather than appending to them.")
+
+	var shallow bool
+	var summary bool
+	diffFile := &cobra.Command{
+		Use:   "diff-file new-repo-name new-commit-id new-path [old-repo-name old-commit-id old-path]",
+		Short: "Return a diff of two file trees.",
+		Long: `Return a diff of two file trees.
+
+Examples:
+
+` + codestart + `# Return the diff between foo master path and its parent.
+$ pachctl diff-file foo master path
+
+# Return the diff between foo master path1 and bar master path2.
+$ pachctl diff-file foo master path1 bar master path2
+
+# Return the number of files added, deleted and modified in foo master, and
+# their sizes.
+$ pachctl diff-file foo master / --summary
+` + codeend,
+		Run: cmdutil.RunBoundedArgs(3, 6, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			if len(args) != 3 && len(args) != 6 {
+				return fmt.Errorf("diff-file expects either 3 or 6 args, got %d", len(args))
+			}
+			if summary {
+				var diffSummary *pfsclient.DiffFileSummary
+				if len(args) == 3 {
+					diffSummary, err = client.DiffFileSummary(args[0], args[1], args[2], "", "", "", shallow)
+				} else {
+					diffSummary, err = client.DiffFileSummary(args[0], args[1], args[2], args[3], args[4], args[5], shallow)
+				}
+				if err != nil {
+					return err
+				}
+				pretty.PrintDiffFileSummary(diffSummary)
+				return nil
+			}
+			var newFiles []*pfsclient.FileInfo
+			var oldFiles []*pfsclient.FileInfo
+			switch {
+			case len(args) == 3:
+				newFiles, oldFiles, err = client.DiffFile(args[0], args[1], args[2], "", "", "", shallow)
+			case len(args) == 6:
+				newFiles, oldFiles, err = client.DiffFile(args[0], args[1], args[2], args[3], args[4], args[5], shallow)
+			default:
+				return fmt.Errorf("diff-file expects either 3 or 6 args, got %d", len(args))
+			}
+			if err != nil {
+				return err
+			}
+			if len(newFiles) > 0 {
+				fmt.Println("New Files:")
+				writer := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
+				pretty.PrintFileInfoHeader(writer)
+				for _, fileInfo := range newFiles {
+					pretty.PrintFileInfo(writer, fileInfo)
+				}
+				if err := writer.Flush(); err != nil {
+					return err
+				}
+			}
+			if len(oldFiles) > 0 {
+				fmt.Println("Old Files:")
+				writer := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
+				pretty.PrintFileInfoHeader(writer)
+				for _, fileInfo := range oldFiles {
+					pretty.PrintFileInfo(writer, fileInfo)
+				}
+				if err := writer.Flush(); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	}
+	diffFile.Flags().BoolVarP(&shallow, "shallow", "s", false, "Specifies whether or not to diff subdirectories")
+	diffFile.Flags().BoolVar(&summary, "summary", false, "Only return the number of files added, deleted and modified, and their sizes.")
+
+	previewMerge := &cobra.Command{
+		Use:   "preview-merge repo-name ours theirs",
+		Short: "Preview merging two commits or branches.",
+		Long: `Preview merging two commits or branches, without creating any commit. The changes that theirs made since the last commit that both have in common are applied to ours, unless ours changed the same paths differently, in which case they conflict.
+
+Examples:
+
+` + codestart + `# preview merging branch "feature" into branch "master" of repo "foo"
+$ pachctl preview-merge foo master feature
+` + codeend,
+		Run: cmdutil.RunFixedArgs(3, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			response, err := c.PreviewMerge(args[0], args[1], args[2])
+			if err != nil {
+				return err
+			}
+			if raw {
+				return marshaller.Marshal(os.Stdout, response)
+			}
+			pretty.PrintPreviewMergeResponse(response)
+			return nil
+		}),
+	}
+	rawFlag(previewMerge)
+
+	merge := &cobra.Command{
+		Use:   "merge repo-name branch theirs",
+		Short: "Merge a commit or branch into a branch.",
+		Long: `Merge a commit or branch into a branch. The changes that theirs made since the last commit that both have in common are applied to the head of the branch, in a new commit. If the branch changed the same paths differently, they conflict, and the new commit isn't put on the branch. Instead, the versions of each conflicting path are written to path.ours and path.theirs, and the conflict must be resolved with resolve-conflict.
+
+Examples:
+
+` + codestart + `# merge branch "feature" into branch "master" of repo "foo"
+$ pachctl merge foo master feature
+` + codeend,
+		Run: cmdutil.RunFixedArgs(3, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			response, err := c.Merge(args[0], args[1], args[2])
+			if err != nil {
+				return err
+			}
+			if raw {
+				return marshaller.Marshal(os.Stdout, response)
+			}
+			pretty.PrintMergeResponse(response)
+			return nil
+		}),
+	}
+	rawFlag(merge)
+
+	var take string
+	resolveConflict := &cobra.Command{
+		Use:   "resolve-conflict repo-name commit-id path",
+		Short: "Resolve a conflict of a merge.",
+		Long: `Resolve a conflict of a merge by keeping either version of the conflicting path, which makes a new commit. Once every conflict is resolved, the commit is put on the branch that was merged into.
+
+Examples:
+
+` + codestart + `# keep the version of "foo.json" from the merged branch
+$ pachctl resolve-conflict foo XXX foo.json --take theirs
+` + codeend,
+		Run: cmdutil.RunFixedArgs(3, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			resolution, ok := pfsclient.ConflictResolution_value[strings.ToUpper(take)]
+			if !ok {
+				return fmt.Errorf("unrecognized version %s, it must be ours or theirs", take)
+			}
+			response, err := c.ResolveConflict(args[0], args[1], args[2], pfsclient.ConflictResolution(resolution))
+			if err != nil {
+				return err
+			}
+			if raw {
+				return marshaller.Marshal(os.Stdout, response)
+			}
+			pretty.PrintMergeResponse(response)
+			return nil
+		}),
+	}
+	resolveConflict.Flags().StringVar(&take, "take", "", "the version to keep: ours or theirs")
+	rawFlag(resolveConflict)
+
+	var deleteGlob bool
+	deleteFile := &cobra.Command{
+		Use:   "delete-file repo-name commit-id path/to/file",
+		Short: "Delete a file.",
+		Long: `Delete a file, or with --glob every file and directory that a glob pattern matches.
+
+Examples:
+
+` + codestart + `# Delete the logs from 2016 in repo "foo" on branch "master"
+$ pachctl delete-file foo master "logs/2016-*" --glob
+` + codeend,
+		Run: cmdutil.RunFixedArgs(3, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			if deleteGlob {
+				return client.DeleteFileGlob(args[0], args[1], args[2])
+			}
+			return client.DeleteFile(args[0], args[1], args[2])
+		}),
+	}
+	deleteFile.Flags().BoolVar(&deleteGlob, "glob", false, "Treat the path as a glob pattern and delete everything that it matches, in a single write.")
+
+	var schemaType string
+	var schemaURL string
+	setSchema := &cobra.Command{
+		Use:   "set-schema repo-name [path/to/dir]",
+		Short: "Set the schema for a repo or directory.",
+		Long: `Set the schema for a repo or directory. The schema applies to every file under the path it's set on, unless a schema is set closer to the file, and is returned by inspect-file. A commit can't be finished if the files it writes don't match their schemas: files under a json_schema schema have to be JSON values that match it, and files under an avro schema have to be Avro object container files. Files under a protobuf schema aren't checked.
+
+Examples:
+
+` + codestart + `# Set the schema for repo "foo" to a JSON Schema in a schema registry
+$ pachctl set-schema foo --type json_schema --url http://registry/schemas/foo.json
+
+# Set the schema for the "users" directory in repo "foo" to an Avro schema
+$ pachctl set-schema foo users --type avro --url http://registry/subjects/users/versions/1
+
+# Remove the schema set on the "users" directory in repo "foo"
+$ pachctl set-schema foo users
+` + codeend,
+		Run: cmdutil.RunBoundedArgs(1, 2, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			var path string
+			if len(args) == 2 {
+				path = args[1]
+			}
+			var schema *pfsclient.Schema
+			if schemaURL != "" {
+				t, ok := pfsclient.SchemaType_value[strings.ToUpper(schemaType)]
+				if !ok {
+					return fmt.Errorf("unrecognized schema type %s", schemaType)
+				}
+				schema = &pfsclient.Schema{
+					Type: pfsclient.SchemaType(t),
+					Url:  schemaURL,
+				}
+			}
+			return client.SetSchema(args[0], path, schema)
+		}),
+	}
+	setSchema.Flags().StringVar(&schemaType, "type", "json_schema", "the type of the schema: avro, json_schema or protobuf")
+	setSchema.Flags().StringVar(&schemaURL, "url", "", "the url of the schema; if unset, the schema set on the path is removed")
+
+	inspectFeatureUsage := &cobra.Command{
+		Use:   "inspect-feature-usage",
+		Short: "Show the feature usage reported by the opt-in feature telemetry.",
+		Long: `Show the feature usage reported by the opt-in feature telemetry, exactly as it would be sent. It holds aggregate counts only: the number of repos and the number of times each PFS feature, such as put-file --split, has been used.
+
+Feature telemetry is disabled unless pachd is started with FEATURE_TELEMETRY=true, "enabled" shows whether it is.`,
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			usage, err := client.InspectFeatureUsage()
+			if err != nil {
+				return err
+			}
+			return marshaller.Marshal(os.Stdout, usage)
+		}),
+	}
+
+	var adminJobType string
+	listAdminJobs := &cobra.Command{
+		Use:   "list-admin-jobs",
+		Short: "Return the long-running admin operations.",
+		Long:  "Return the long-running admin operations, such as rebuilding object ref counts or compacting a commit, that are running or finished in the last day, oldest first.",
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			jobInfos, err := client.ListAdminJobs(adminJobType)
+			if err != nil {
+				return err
+			}
+			if raw {
+				for _, jobInfo := range jobInfos {
+					if err := marshaller.Marshal(os.Stdout, jobInfo); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+			writer := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
+			pretty.PrintAdminJobInfoHeader(writer)
+			for _, jobInfo := range jobInfos {
+				pretty.PrintAdminJobInfo(writer, jobInfo)
+			}
+			return writer.Flush()
+		}),
+	}
+	listAdminJobs.Flags().StringVar(&adminJobType, "type", "", "Only return the jobs of this type, e.g. rebuild_object_ref_counts.")
+	rawFlag(listAdminJobs)
+
+	inspectAdminJob := &cobra.Command{
+		Use:   "inspect-admin-job job-id",
+		Short: "Return the status of an admin operation.",
+		Long:  "Return the status of an admin operation: its phase, its progress through the phase, and when the phase is expected to end.",
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			jobInfo, err := client.InspectAdminJob(args[0])
 			if err != nil {
 				return err
 			}
-			if fileInfo == nil {
-				return fmt.Errorf("file %s not found", args[2])
-			}
 			if raw {
-				return marshaller.Marshal(os.Stdout, fileInfo)
+				return marshaller.Marshal(os.Stdout, jobInfo)
 			}
-			return pretty.PrintDetailedFileInfo(fileInfo)
+			return pretty.PrintDetailedAdminJobInfo(jobInfo)
 		}),
 	}
-	rawFlag(inspectFile)
-
-	listFile := &cobra.Command{
-		Use:   "list-file repo-name commit-id path/to/dir",
-		Short: "Return the files in a directory.",
-		Long: `Return the files in a directory.
+	rawFlag(inspectAdminJob)
 
-Examples:
+	cancelAdminJob := &cobra.Command{
+		Use:   "cancel-admin-job job-id",
+		Short: "Stop a running admin operation.",
+		Long:  "Stop a running admin operation. Only cluster admins and the user who started it can stop it.",
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			return client.CancelAdminJob(args[0])
+		}),
+	}
 
-` + codestart + `# list top-level files on branch "master" in repo "foo"
-$ pachctl list-file foo master
+	var quota pfsclient.RepoQuota
+	var deleteQuota bool
+	setRepoQuota := &cobra.Command{
+		Use:   "set-repo-quota <repo-name>",
+		Short: "Limit the rate of a repo's operations.",
+		Long: `Limit the rate of a repo's operations, so that one busy repo can't starve the others. Operations over the limit fail, and each pachd enforces the limits on the operations that it serves. Limits that are 0 aren't enforced. Only cluster admins can set quotas, and setting one replaces the repo's existing quota.
 
-# list files under path XXX on branch "master" in repo "foo"
-$ pachctl list-file foo master XXX
+Examples:
 
-# list top-level files in the parent commit of the current head of "master"
-# in repo "foo"
-$ pachctl list-file foo master^
+` + codestart + `# Limit repo "foo" to 100 put-files and 20 list-files per second
+$ pachctl set-repo-quota foo --put-file-rate 100 --list-file-rate 20
 
-# list top-level files in the grandparent of the current head of "master"
-# in repo "foo"
-$ pachctl list-file foo master^2
+# Remove repo "foo"'s quota
+$ pachctl set-repo-quota foo --delete
 ` + codeend,
-		Run: cmdutil.RunBoundedArgs(2, 3, func(args []string) error {
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
 			client, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
-			var path string
-			if len(args) == 3 {
-				path = args[2]
+			if deleteQuota {
+				return client.SetRepoQuota(args[0], nil)
+			}
+			return client.SetRepoQuota(args[0], &quota)
+		}),
+	}
+	setRepoQuota.Flags().Float64Var(&quota.PutFilePerSecond, "put-file-rate", 0, "The maximum number of put-files per second, including each file of a batch.")
+	setRepoQuota.Flags().Float64Var(&quota.ListFilePerSecond, "list-file-rate", 0, "The maximum number of list-files per second.")
+	setRepoQuota.Flags().Int64Var(&quota.MaxWatches, "max-watches", 0, "The maximum number of subscriptions to the repo's commits at once.")
+	setRepoQuota.Flags().BoolVar(&deleteQuota, "delete", false, "Remove the repo's quota.")
+
+	listRepoUsage := &cobra.Command{
+		Use:   "list-repo-usage",
+		Short: "Return the rate of each repo's operations.",
+		Long:  "Return the rate of each repo's operations over the last minute, on the pachd that serves the request, busiest first, with the number that were rejected for exceeding the repo's quota. Only cluster admins can list repo usage.",
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
 			}
-			fileInfos, err := client.ListFile(args[0], args[1], path)
+			usage, err := client.ListRepoUsage()
 			if err != nil {
 				return err
 			}
 			if raw {
-				for _, fileInfo := range fileInfos {
-					if err := marshaller.Marshal(os.Stdout, fileInfo); err != nil {
+				for _, u := range usage {
+					if err := marshaller.Marshal(os.Stdout, u); err != nil {
 						return err
 					}
 				}
+				return nil
 			}
 			writer := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
-			pretty.PrintFileInfoHeader(writer)
-			for _, fileInfo := range fileInfos {
-				pretty.PrintFileInfo(writer, fileInfo)
+			pretty.PrintRepoUsageHeader(writer)
+			for _, u := range usage {
+				pretty.PrintRepoUsage(writer, u)
 			}
 			return writer.Flush()
 		}),
 	}
-	rawFlag(listFile)
+	rawFlag(listRepoUsage)
 
-	globFile := &cobra.Command{
-		Use:   "glob-file repo-name commit-id pattern",
-		Short: "Return files that match a glob pattern in a commit.",
-		Long: `Return files that match a glob pattern in a commit (that is, match a glob pattern
-in a repo at the state represented by a commit). Glob patterns are
-documented [here](https://golang.org/pkg/path/filepath/#Match).
+	var exportInterval int64
+	var deleteExport bool
+	setMetadataExport := &cobra.Command{
+		Use:   "set-metadata-export <repo-name>",
+		Short: "Export the cluster's metadata to a repo on a schedule.",
+		Long: `Export the metadata of every repo, that's the repos, their commits, branches and commits' provenance but none of their files' data, to a repo as Parquet files on a schedule, so that it can be queried with SQL without reading it from PFS. Each export is a commit on the repo's master branch, with the files repos.parquet, commits.parquet, branches.parquet and provenance.parquet.
+
+Exports are run with your credentials. Only cluster admins can set the export, and setting it replaces the existing one.
 
 Examples:
 
-` + codestart + `# Return files in repo "foo" on branch "master" that start
-# with the character "A".  Note how the double quotation marks around "A*" are
-# necessary because otherwise your shell might interpret the "*".
-$ pachctl glob-file foo master "A*"
+` + codestart + `# Export metadata to repo "metadata" every 6 hours
+$ pachctl set-metadata-export metadata --interval 21600
 
-# Return files in repo "foo" on branch "master" under directory "data".
-$ pachctl glob-file foo master "data/*"
+# Stop exporting metadata
+$ pachctl set-metadata-export --delete
 ` + codeend,
-		Run: cmdutil.RunFixedArgs(3, func(args []string) error {
+		Run: cmdutil.RunBoundedArgs(0, 1, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			if deleteExport {
+				return client.SetMetadataExport(nil)
+			}
+			if len(args) != 1 {
+				return fmt.Errorf("set-metadata-export takes the name of the repo to export to, unless --delete is given")
+			}
+			return client.SetMetadataExport(&pfsclient.MetadataExport{
+				Repo:            &pfsclient.Repo{Name: args[0]},
+				IntervalSeconds: exportInterval,
+			})
+		}),
+	}
+	setMetadataExport.Flags().Int64Var(&exportInterval, "interval", 0, "The number of seconds between exports, 3600 if it isn't set.")
+	setMetadataExport.Flags().BoolVar(&deleteExport, "delete", false, "Stop exporting metadata.")
+
+	inspectMetadataExport := &cobra.Command{
+		Use:   "inspect-metadata-export",
+		Short: "Return the cluster's metadata export.",
+		Long:  "Return the repo that the cluster's metadata is exported to, how often it's exported, and its last export.",
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
 			client, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
-			fileInfos, err := client.GlobFile(args[0], args[1], args[2])
+			exportInfo, err := client.InspectMetadataExport()
 			if err != nil {
 				return err
 			}
 			if raw {
-				for _, fileInfo := range fileInfos {
-					if err := marshaller.Marshal(os.Stdout, fileInfo); err != nil {
+				return marshaller.Marshal(os.Stdout, exportInfo)
+			}
+			return pretty.PrintDetailedMetadataExportInfo(exportInfo)
+		}),
+	}
+	rawFlag(inspectMetadataExport)
+
+	exportMetadata := &cobra.Command{
+		Use:   "export-metadata",
+		Short: "Export the cluster's metadata now.",
+		Long:  "Export the cluster's metadata to the repo set with set-metadata-export now, rather than waiting for its next export, and print the commit that it's exported to. Only cluster admins can export metadata.",
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			commit, err := client.ExportMetadata()
+			if err != nil {
+				return err
+			}
+			fmt.Println(commit.ID)
+			return nil
+		}),
+	}
+
+	listFeatureFlags := &cobra.Command{
+		Use:   "list-feature-flags",
+		Short: "Return the feature flags of PFS and where they're set.",
+		Long: `Return the feature flags of PFS and where they're set.
+
+Feature flags turn on behaviors of PFS that are being rolled out gradually. A flag's setting for a repo takes precedence over its setting for the cluster, which takes precedence over its default. USES is the number of times the flag has changed the behavior of an operation since pachd started.`,
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			flags, err := client.ListFeatureFlags()
+			if err != nil {
+				return err
+			}
+			if raw {
+				for _, flag := range flags {
+					if err := marshaller.Marshal(os.Stdout, flag); err != nil {
 						return err
 					}
 				}
+				return nil
 			}
 			writer := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
-			pretty.PrintFileInfoHeader(writer)
-			for _, fileInfo := range fileInfos {
-				pretty.PrintFileInfo(writer, fileInfo)
+			pretty.PrintFeatureFlagHeader(writer)
+			for _, flag := range flags {
+				pretty.PrintFeatureFlag(writer, flag)
 			}
 			return writer.Flush()
 		}),
 	}
-	rawFlag(globFile)
+	rawFlag(listFeatureFlags)
 
-	var shallow bool
-	diffFile := &cobra.Command{
-		Use:   "diff-file new-repo-name new-commit-id new-path [old-repo-name old-commit-id old-path]",
-		Short: "Return a diff of two file trees.",
-		Long: `Return a diff of two file trees.
+	var flagRepo string
+	setFeatureFlag := &cobra.Command{
+		Use:   "set-feature-flag flag-name on|off|unset",
+		Short: "Turn a feature flag on or off for the cluster or a repo.",
+		Long: `Turn a feature flag on or off for the cluster or, with --repo, for a repo. "unset" removes the setting, so that the repo gets the cluster's setting, and the cluster the flag's default.
+
+Only cluster admins can set flags for the cluster, and only a repo's owners can set flags for the repo.
 
 Examples:
 
-` + codestart + `# Return the diff between foo master path and its parent.
-$ pachctl diff-file foo master path
+` + codestart + `# make put-file overwrite files by default in repo "foo"
+$ pachctl set-feature-flag overwrite_by_default on --repo foo
 
-# Return the diff between foo master path1 and bar master path2.
-$ pachctl diff-file foo master path1 bar master path2
-` + codeend,
-		Run: cmdutil.RunBoundedArgs(3, 6, func(args []string) error {
+# go back to the cluster's setting in repo "foo"
+$ pachctl set-feature-flag overwrite_by_default unset --repo foo` + codeend,
+		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
 			client, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
-			var newFiles []*pfsclient.FileInfo
-			var oldFiles []*pfsclient.FileInfo
-			switch {
-			case len(args) == 3:
-				newFiles, oldFiles, err = client.DiffFile(args[0], args[1], args[2], "", "", "", shallow)
-			case len(args) == 6:
-				newFiles, oldFiles, err = client.DiffFile(args[0], args[1], args[2], args[3], args[4], args[5], shallow)
-			default:
-				return fmt.Errorf("diff-file expects either 3 or 6 args, got %d", len(args))
+			setting, ok := pfsclient.FeatureFlagSetting_value[strings.ToUpper(args[1])]
+			if !ok {
+				return fmt.Errorf("unrecognized setting %s, it must be on, off or unset", args[1])
 			}
+			return client.SetFeatureFlag(args[0], flagRepo, pfsclient.FeatureFlagSetting(setting))
+		}),
+	}
+	setFeatureFlag.Flags().StringVar(&flagRepo, "repo", "", "The repo to set the flag for, rather than the cluster.")
+
+	var specPath string
+	var prune bool
+	var dryRun bool
+	apply := &cobra.Command{
+		Use:   "apply",
+		Short: "Reconcile repos and branches with a spec of their desired state.",
+		Long: `Reconcile repos and branches with a spec of their desired state.
+
+The spec is JSON, it lists each repo with its description, immediate
+provenance, branches (and the commits they point to) and, optionally, its ACL
+and compaction policy. "pachctl export" writes the spec of the current repos:
+
+` + codestart + `{
+  "repos": [
+    {"repo": {"name": "raw"}, "description": "raw data", "branches": [{"name": "prod", "head": "master"}]},
+    {"repo": {"name": "clean"}, "provenance": [{"name": "raw"}]}
+  ]
+}
+` + codeend + `
+The repos that don't exist are created, and those that differ from the spec are
+updated. Nothing is deleted unless --prune is given.
+
+Examples:
+
+` + codestart + `# Show what applying spec.json would change
+$ pachctl apply -f spec.json --dry-run
+
+# Apply spec.json, deleting the repos and branches that aren't in it
+$ pachctl apply -f spec.json --prune
+` + codeend,
+		Run: cmdutil.RunFixedArgs(0, func(args []string) (retErr error) {
+			client, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
-			if len(newFiles) > 0 {
-				fmt.Println("New Files:")
-				writer := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
-				pretty.PrintFileInfoHeader(writer)
-				for _, fileInfo := range newFiles {
-					pretty.PrintFileInfo(writer, fileInfo)
-				}
-				if err := writer.Flush(); err != nil {
+			var specReader io.Reader
+			if specPath == "-" {
+				specReader = os.Stdin
+				fmt.Print("Reading from stdin.\n")
+			} else {
+				specFile, err := os.Open(specPath)
+				if err != nil {
 					return err
 				}
+				defer func() {
+					if err := specFile.Close(); err != nil && retErr == nil {
+						retErr = err
+					}
+				}()
+				specReader = specFile
 			}
-			if len(oldFiles) > 0 {
-				fmt.Println("Old Files:")
-				writer := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
-				pretty.PrintFileInfoHeader(writer)
-				for _, fileInfo := range oldFiles {
-					pretty.PrintFileInfo(writer, fileInfo)
-				}
-				if err := writer.Flush(); err != nil {
-					return err
+			spec := &pfsclient.ApplySpec{}
+			if err := jsonpb.Unmarshal(specReader, spec); err != nil {
+				return err
+			}
+			actions, err := client.Apply(spec, prune, dryRun)
+			if err != nil {
+				return err
+			}
+			if raw {
+				for _, action := range actions {
+					if err := marshaller.Marshal(os.Stdout, action); err != nil {
+						return err
+					}
 				}
+				return nil
 			}
-			return nil
+			writer := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
+			pretty.PrintApplyActionHeader(writer)
+			for _, action := range actions {
+				pretty.PrintApplyAction(writer, action)
+			}
+			return writer.Flush()
 		}),
 	}
-	diffFile.Flags().BoolVarP(&shallow, "shallow", "s", false, "Specifies whether or not to diff subdirectories")
+	apply.Flags().StringVarP(&specPath, "file", "f", "-", "The JSON file containing the spec, \"-\" reads from stdin.")
+	apply.Flags().BoolVar(&prune, "prune", false, "Delete the repos that aren't in the spec, and the branches that aren't in their repo's spec.")
+	apply.Flags().BoolVar(&dryRun, "dry-run", false, "Only show what would be changed.")
+	rawFlag(apply)
+
+	export := &cobra.Command{
+		Use:   "export [repo...]",
+		Short: "Return the spec of the current state of repos.",
+		Long: `Return the spec of the current state of repos, their branches, ACLs and
+compaction policies, in the format that "pachctl apply" takes. Every repo is
+exported if none are given.
 
-	deleteFile := &cobra.Command{
-		Use:   "delete-file repo-name commit-id path/to/file",
-		Short: "Delete a file.",
-		Long:  "Delete a file.",
-		Run: cmdutil.RunFixedArgs(3, func(args []string) error {
+Examples:
+
+` + codestart + `# Clone the repos of one cluster to another
+$ pachctl export > spec.json
+$ ADDRESS=staging:650 pachctl apply -f spec.json
+
+# Show how repos have drifted since they were exported
+$ pachctl apply -f spec.json --dry-run
+` + codeend,
+		Run: cmdutil.Run(func(args []string) error {
 			client, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
-			return client.DeleteFile(args[0], args[1], args[2])
+			spec, err := client.Export(args...)
+			if err != nil {
+				return err
+			}
+			if err := marshaller.Marshal(os.Stdout, spec); err != nil {
+				return err
+			}
+			fmt.Println()
+			return nil
 		}),
 	}
 
@@ -1079,27 +3194,86 @@ $ pachctl diff-file foo master path1 bar master path2
 	result = append(result, updateRepo)
 	result = append(result, inspectRepo)
 	result = append(result, listRepo)
+	result = append(result, archiveRepo)
+	result = append(result, unarchiveRepo)
+	result = append(result, exportRepo)
+	result = append(result, importRepo)
 	result = append(result, deleteRepo)
+	result = append(result, renewRepo)
+	result = append(result, exportProvenanceGraph)
+	result = append(result, setProtectedPaths)
+	result = append(result, setReadFilter)
+	result = append(result, setResidency)
+	result = append(result, setStorageClass)
+	result = append(result, updateRepoMetadata)
 	result = append(result, commit)
 	result = append(result, startCommit)
 	result = append(result, finishCommit)
+	result = append(result, publishCommit)
+	result = append(result, approveCommit)
+	result = append(result, rejectCommit)
+	result = append(result, listPendingApprovals)
 	result = append(result, inspectCommit)
 	result = append(result, listCommit)
+	result = append(result, bisectCommits)
+	result = append(result, searchDataCards)
 	result = append(result, flushCommit)
 	result = append(result, subscribeCommit)
 	result = append(result, deleteCommit)
 	result = append(result, listBranch)
 	result = append(result, setBranch)
 	result = append(result, deleteBranch)
+	result = append(result, setCompactInPlace)
+	result = append(result, setCompactionPolicy)
+	result = append(result, inspectCompactionPolicy)
+	result = append(result, setRetentionPolicy)
+	result = append(result, inspectRetentionPolicy)
+	result = append(result, planRetention)
+	result = append(result, createCommitHook)
+	result = append(result, listCommitHook)
+	result = append(result, deleteCommitHook)
+	result = append(result, acquireCommitLock)
+	result = append(result, renewCommitLock)
+	result = append(result, releaseCommitLock)
+	result = append(result, listCommitLock)
 	result = append(result, file)
 	result = append(result, putFile)
+	result = append(result, uploadFileCmd)
 	result = append(result, copyFile)
+	result = append(result, moveFile)
+	result = append(result, compactFile)
+	result = append(result, compactCommit)
+	result = append(result, putSymlink)
 	result = append(result, getFile)
+	result = append(result, getRecords)
+	result = append(result, filterFile)
+	result = append(result, grepFile)
 	result = append(result, inspectFile)
 	result = append(result, listFile)
 	result = append(result, globFile)
+	result = append(result, searchFile)
+	result = append(result, walkFile)
+	result = append(result, getManifest)
+	result = append(result, putFilesFromManifest)
 	result = append(result, diffFile)
+	result = append(result, previewMerge)
+	result = append(result, merge)
+	result = append(result, resolveConflict)
 	result = append(result, deleteFile)
+	result = append(result, setSchema)
+	result = append(result, inspectFeatureUsage)
+	result = append(result, listFeatureFlags)
+	result = append(result, listAdminJobs)
+	result = append(result, inspectAdminJob)
+	result = append(result, cancelAdminJob)
+	result = append(result, setRepoQuota)
+	result = append(result, listRepoUsage)
+	result = append(result, setMetadataExport)
+	result = append(result, inspectMetadataExport)
+	result = append(result, exportMetadata)
+	result = append(result, setFeatureFlag)
+	result = append(result, apply)
+	result = append(result, export)
 	result = append(result, getObject)
 	result = append(result, getTag)
 	result = append(result, mount)
@@ -1125,13 +3299,61 @@ func parseCommitMounts(args []string) []*fuse.CommitMount {
 	return result
 }
 
-func putFileHelper(client *client.APIClient, repo, commit, path, source string,
-	recursive bool, overwrite bool, limiter limit.ConcurrencyLimiter, split string,
-	targetFileDatums uint, targetFileBytes uint) (retErr error) {
+func putFileHelper(c *client.APIClient, repo, commit, path, source string,
+	recursive bool, overwrite bool, createOnly bool, byHash bool, limiter limit.ConcurrencyLimiter, split string,
+	targetFileDatums uint, targetFileBytes uint, targetFileCount uint, stats bool, divertErrors bool, headerLines uint, footerLines uint,
+	separator string, separatorRegex string, jsonSchema []byte, ttl int64, checkLocks bool, lockID string) (retErr error) {
+	if overwrite && createOnly {
+		return fmt.Errorf("--overwrite and --create-only are mutually exclusive")
+	}
+	if ttl != 0 && (split != "" || overwrite || createOnly) {
+		return fmt.Errorf("--ttl can't be used with --split, --overwrite or --create-only")
+	}
+	if checkLocks && (split != "" || overwrite || createOnly || ttl != 0) {
+		return fmt.Errorf("--check-locks can't be used with --split, --overwrite, --create-only or --ttl")
+	}
+	if byHash && (split != "" || ttl != 0 || checkLocks) {
+		return fmt.Errorf("--by-hash can't be used with --split, --ttl or --check-locks")
+	}
+	if byHash && source == "-" {
+		return fmt.Errorf("--by-hash can't be used with the standard input, as it's read twice")
+	}
 	putFile := func(reader io.ReadSeeker) error {
 		if split == "" {
+			if stats {
+				return fmt.Errorf("--stats needs to be used with --split")
+			}
+			if divertErrors {
+				return fmt.Errorf("--divert-errors needs to be used with --split")
+			}
+			if headerLines > 0 || footerLines > 0 {
+				return fmt.Errorf("--header-lines and --footer-lines need to be used with --split line")
+			}
+			if targetFileCount > 0 {
+				return fmt.Errorf("--target-file-count needs to be used with --split")
+			}
+			if separator != "" || separatorRegex != "" {
+				return fmt.Errorf("--separator and --separator-regex need to be used with --split separator")
+			}
+			if jsonSchema != nil {
+				return fmt.Errorf("--json-schema needs to be used with --split json")
+			}
+			if byHash {
+				mode := pfsclient.PutFileMode_APPEND
+				if overwrite {
+					mode = pfsclient.PutFileMode_OVERWRITE
+				} else if createOnly {
+					mode = pfsclient.PutFileMode_CREATE_ONLY
+				}
+				_, err := c.PutFileByHash(repo, commit, path, mode, reader)
+				return err
+			}
+			if createOnly {
+				_, err := c.PutFileWithMode(repo, commit, path, pfsclient.PutFileMode_CREATE_ONLY, reader)
+				return err
+			}
 			if overwrite {
-				return sync.PushFile(client, &pfsclient.File{
+				return sync.PushFile(c, &pfsclient.File{
 					Commit: &pfsclient.Commit{
 						Repo: &pfsclient.Repo{repo},
 						ID:   commit,
@@ -1139,21 +3361,91 @@ func putFileHelper(client *client.APIClient, repo, commit, path, source string,
 					Path: path,
 				}, reader)
 			}
-			_, err := client.PutFile(repo, commit, path, reader)
+			if ttl != 0 {
+				_, err := c.PutFileWithTTL(repo, commit, path, ttl, reader)
+				return err
+			}
+			if checkLocks {
+				_, err := c.PutFileWithCommitLock(repo, commit, path, lockID, reader)
+				return err
+			}
+			_, err := c.PutFile(repo, commit, path, reader)
 			return err
 		}
 
+		if createOnly {
+			return fmt.Errorf("--create-only can't be used with --split")
+		}
 		var delimiter pfsclient.Delimiter
 		switch split {
 		case "line":
 			delimiter = pfsclient.Delimiter_LINE
 		case "json":
 			delimiter = pfsclient.Delimiter_JSON
+		case "separator":
+			delimiter = pfsclient.Delimiter_SEPARATOR
+		case "parquet":
+			delimiter = pfsclient.Delimiter_PARQUET
+		case "tar":
+			delimiter = pfsclient.Delimiter_TAR
 		default:
-			return fmt.Errorf("unrecognized delimiter '%s'; only accepts 'json' or 'line'", split)
+			return fmt.Errorf("unrecognized delimiter '%s'; only accepts 'json', 'line', 'separator', 'parquet' or 'tar'", split)
 		}
-		_, err := client.PutFileSplit(repo, commit, path, delimiter, int64(targetFileDatums), int64(targetFileBytes), overwrite, reader)
-		return err
+		options := client.PutFileSplitOptions{
+			Delimiter:        delimiter,
+			TargetFileDatums: int64(targetFileDatums),
+			TargetFileBytes:  int64(targetFileBytes),
+			TargetFileCount:  int64(targetFileCount),
+			Overwrite:        overwrite,
+			ComputeStats:     stats,
+			DivertErrors:     divertErrors,
+			HeaderLines:      int64(headerLines),
+			FooterLines:      int64(footerLines),
+			SeparatorRegex:   separatorRegex,
+			JSONSchema:       jsonSchema,
+		}
+		if delimiter == pfsclient.Delimiter_SEPARATOR {
+			if stats || divertErrors || headerLines > 0 || footerLines > 0 || targetFileCount > 0 || jsonSchema != nil {
+				return fmt.Errorf("--split separator can't be used with --stats, --divert-errors, --header-lines, --footer-lines, --target-file-count or --json-schema")
+			}
+			// the separator is unescaped like a Go string, so that it can
+			// hold newlines and other control characters
+			separatorBytes, err := strconv.Unquote(`"` + strings.Replace(separator, `"`, `\"`, -1) + `"`)
+			if err != nil {
+				return fmt.Errorf("error parsing --separator: %v", err)
+			}
+			options.Separator = []byte(separatorBytes)
+		} else if separator != "" || separatorRegex != "" {
+			return fmt.Errorf("--separator and --separator-regex need to be used with --split separator")
+		}
+		if stats && divertErrors {
+			return fmt.Errorf("--stats and --divert-errors can't be used together")
+		}
+		reason := "couldn't be parsed"
+		if jsonSchema != nil {
+			if delimiter != pfsclient.Delimiter_JSON {
+				return fmt.Errorf("--json-schema needs to be used with --split json")
+			}
+			if stats || headerLines > 0 || footerLines > 0 || targetFileCount > 0 {
+				return fmt.Errorf("--json-schema can't be used with --stats, --header-lines, --footer-lines or --target-file-count")
+			}
+			reason = "couldn't be parsed or didn't match the schema"
+		}
+		if targetFileCount > 0 && (stats || divertErrors || headerLines > 0 || footerLines > 0) {
+			return fmt.Errorf("--target-file-count can't be used with --stats, --divert-errors, --header-lines or --footer-lines")
+		}
+		if (headerLines > 0 || footerLines > 0) && (stats || divertErrors) {
+			return fmt.Errorf("--header-lines and --footer-lines can't be used with --stats or --divert-errors")
+		}
+		response, err := c.PutFileSplitWithOptions(repo, commit, path, options, reader)
+		if err != nil {
+			return err
+		}
+		if response.RecordsDiverted > 0 {
+			fmt.Fprintf(os.Stderr, "%d of %d records of %s %s, they were written to %s\n",
+				response.RecordsDiverted, response.RecordsWritten+response.RecordsDiverted, path, reason, response.ErrorsPath)
+		}
+		return nil
 	}
 
 	if source == "-" {
@@ -1164,9 +3456,12 @@ func putFileHelper(client *client.APIClient, repo, commit, path, source string,
 	}
 	// try parsing the filename as a url, if it is one do a PutFileURL
 	if url, err := url.Parse(source); err == nil && url.Scheme != "" {
+		if createOnly || byHash {
+			return fmt.Errorf("--create-only and --by-hash can't be used with urls")
+		}
 		limiter.Acquire()
 		defer limiter.Release()
-		return client.PutFileURL(repo, commit, path, url.String(), recursive, overwrite)
+		return c.PutFileURL(repo, commit, path, url.String(), recursive, overwrite)
 	}
 	if recursive {
 		var eg errgroup.Group
@@ -1179,7 +3474,7 @@ func putFileHelper(client *client.APIClient, repo, commit, path, source string,
 				return nil
 			}
 			eg.Go(func() error {
-				return putFileHelper(client, repo, commit, filepath.Join(path, strings.TrimPrefix(filePath, source)), filePath, false, overwrite, limiter, split, targetFileDatums, targetFileBytes)
+				return putFileHelper(c, repo, commit, filepath.Join(path, strings.TrimPrefix(filePath, source)), filePath, false, overwrite, createOnly, byHash, limiter, split, targetFileDatums, targetFileBytes, targetFileCount, stats, divertErrors, headerLines, footerLines, separator, separatorRegex, jsonSchema, ttl, checkLocks, lockID)
 			})
 			return nil
 		}); err != nil {
@@ -1201,6 +3496,23 @@ func putFileHelper(client *client.APIClient, repo, commit, path, source string,
 	return putFile(f)
 }
 
+// printNextPageToken tells the user how to list the next page of a listing,
+// on stderr so that it doesn't get mixed up with the listing itself.
+func printNextPageToken(nextPageToken string) {
+	if nextPageToken != "" {
+		fmt.Fprintf(os.Stderr, "More files match, list them with --page-token %q\n", nextPageToken)
+	}
+}
+
+func printCompactResponse(response *pfsclient.CompactResponse) {
+	if response.Commit == nil {
+		fmt.Println("No files needed compacting.")
+		return
+	}
+	fmt.Printf("Compacted %d files from %d objects into %d in commit %s\n",
+		response.FilesCompacted, response.ObjectsBefore, response.ObjectsAfter, response.Commit.ID)
+}
+
 func joinPaths(prefix, filePath string) string {
 	if url, err := url.Parse(filePath); err == nil && url.Scheme != "" {
 		if url.Scheme == "pfs" {
@@ -1216,3 +3528,50 @@ func joinPaths(prefix, filePath string) string {
 	}
 	return filepath.Join(prefix, filePath)
 }
+
+// newThrottle returns the throttle of a download at no more than rate bytes
+// per second, e.g. "10M", within windows (see throttle.ParseWindows). It
+// returns nil if neither is set.
+func newThrottle(rate string, windows string) (*throttle.Throttle, error) {
+	var bytesPerSecond int64
+	if rate != "" {
+		var err error
+		if bytesPerSecond, err = units.RAMInBytes(rate); err != nil {
+			return nil, fmt.Errorf("error parsing rate: %v", err)
+		}
+	}
+	parsedWindows, err := throttle.ParseWindows(windows)
+	if err != nil {
+		return nil, err
+	}
+	return throttle.New(bytesPerSecond, parsedWindows), nil
+}
+
+// parseKeyValues parses the key=value pairs given to a repeated flag, such
+// as --label.
+func parseKeyValues(args []string) (map[string]string, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	result := make(map[string]string)
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%q isn't of the form key=value", arg)
+		}
+		result[parts[0]] = parts[1]
+	}
+	return result, nil
+}
+
+// parseCompression parses the name of a codec, as given to --compression.
+func parseCompression(s string) (pfsclient.Compression, error) {
+	switch strings.ToLower(s) {
+	case "", "none":
+		return pfsclient.Compression_UNCOMPRESSED, nil
+	case "gzip":
+		return pfsclient.Compression_GZIP, nil
+	default:
+		return 0, fmt.Errorf("unrecognized compression %s, it must be none or gzip", s)
+	}
+}
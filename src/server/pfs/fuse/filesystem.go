@@ -469,6 +469,35 @@ func (h *handle) Release(ctx context.Context, req *fuse.ReleaseRequest) error {
 	return nil
 }
 
+// symlink is a PFS symlink, which is exposed as a real symlink. Absolute
+// targets are paths in the same commit, so they're made relative to the
+// symlink, which keeps them inside the mount.
+type symlink struct {
+	directory
+	target string
+}
+
+func (s *symlink) Attr(ctx context.Context, a *fuse.Attr) (retErr error) {
+	defer func() {
+		if retErr == nil {
+			log.Debug(&FileAttr{&s.Node, &Attr{uint32(a.Mode)}, errorToString(retErr)})
+		} else {
+			log.Error(&FileAttr{&s.Node, &Attr{uint32(a.Mode)}, errorToString(retErr)})
+		}
+	}()
+	a.Mode = os.ModeSymlink | 0777
+	a.Inode = s.fs.inode(s.File)
+	a.Mtime, _ = types.TimestampFromProto(s.Modified)
+	return nil
+}
+
+func (s *symlink) Readlink(ctx context.Context, req *fuse.ReadlinkRequest) (string, error) {
+	if !strings.HasPrefix(s.target, "/") {
+		return s.target, nil
+	}
+	return filepath.Rel(path.Dir(path.Join("/", s.File.Path)), s.target)
+}
+
 func (d *directory) copy() *directory {
 	return &directory{
 		fs: d.fs,
@@ -611,6 +640,11 @@ func (d *directory) lookUpFile(ctx context.Context, name string) (fs.Node, error
 		}, nil
 	case pfsclient.FileType_DIR:
 		return directory, nil
+	case pfsclient.FileType_SYMLINK:
+		return &symlink{
+			directory: *directory,
+			target:    fileInfo.SymlinkTarget,
+		}, nil
 	default:
 		return nil, fmt.Errorf("unrecognized file type")
 	}
@@ -678,6 +712,8 @@ func (d *directory) readFiles(ctx context.Context) ([]fuse.Dirent, error) {
 			result = append(result, fuse.Dirent{Name: shortPath, Type: fuse.DT_File})
 		case pfsclient.FileType_DIR:
 			result = append(result, fuse.Dirent{Name: shortPath, Type: fuse.DT_Dir})
+		case pfsclient.FileType_SYMLINK:
+			result = append(result, fuse.Dirent{Name: shortPath, Type: fuse.DT_Link})
 		default:
 			continue
 		}
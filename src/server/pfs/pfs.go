@@ -11,6 +11,11 @@ type ErrFileNotFound struct {
 	File *pfs.File
 }
 
+// ErrFileExists represents an error where the file already exists.
+type ErrFileExists struct {
+	File *pfs.File
+}
+
 // ErrRepoNotFound represents a repo-not-found error.
 type ErrRepoNotFound struct {
 	Repo *pfs.Repo
@@ -41,10 +46,67 @@ type ErrParentCommitNotFound struct {
 	Commit *pfs.Commit
 }
 
+// ErrProvenanceCycle represents an error where a repo would be in its own
+// provenance.
+type ErrProvenanceCycle struct {
+	Repo *pfs.Repo
+}
+
+// ErrProvenanceTooDeep represents an error where a repo's provenance would
+// be chained deeper than the configured maximum.
+type ErrProvenanceTooDeep struct {
+	Repo  *pfs.Repo
+	Depth int64
+	Max   int64
+}
+
+// ErrCommitLocked represents an error where a path of an open commit is
+// locked by another lock.
+type ErrCommitLocked struct {
+	File  *pfs.File
+	Owner string
+}
+
+// ErrCommitLockNotFound represents an error where a commit lock doesn't
+// exist, or has expired.
+type ErrCommitLockNotFound struct {
+	Commit *pfs.Commit
+	ID     string
+}
+
+// ErrUploadNotFound represents an error where a resumable upload doesn't
+// exist, or has been completed or dropped.
+type ErrUploadNotFound struct {
+	ID string
+}
+
+// ErrAdminJobNotFound represents an error where an admin job doesn't exist,
+// or finished long enough ago that it's been forgotten.
+type ErrAdminJobNotFound struct {
+	ID string
+}
+
+// ErrRepoArchived represents an error where a repo can't be written to, or
+// watched, because it's archived.
+type ErrRepoArchived struct {
+	Repo *pfs.Repo
+}
+
+// ErrRepoQuotaExceeded represents an error where an operation on a repo is
+// rejected because the repo's quota doesn't allow any more of them.
+type ErrRepoQuotaExceeded struct {
+	Repo  *pfs.Repo
+	Limit string
+}
+
 func (e ErrFileNotFound) Error() string {
 	return fmt.Sprintf("file %v not found in repo %v at commit %v", e.File.Path, e.File.Commit.Repo.Name, e.File.Commit.ID)
 }
 
+func (e ErrFileExists) Error() string {
+	return fmt.Sprintf("file %v already exists in repo %v at commit %v", e.File.Path, e.File.Commit.Repo.Name, e.File.Commit.ID)
+}
+
 func (e ErrRepoNotFound) Error() string {
 	return fmt.Sprintf("repo %v not found", e.Repo.Name)
 }
@@ -69,6 +131,38 @@ func (e ErrParentCommitNotFound) Error() string {
 	return fmt.Sprintf("parent commit %v not found in repo %v", e.Commit.ID, e.Commit.Repo.Name)
 }
 
+func (e ErrProvenanceCycle) Error() string {
+	return fmt.Sprintf("repo %v would be in its own provenance", e.Repo.Name)
+}
+
+func (e ErrProvenanceTooDeep) Error() string {
+	return fmt.Sprintf("the provenance of repo %v would be %d repos deep, the maximum is %d", e.Repo.Name, e.Depth, e.Max)
+}
+
+func (e ErrCommitLocked) Error() string {
+	return fmt.Sprintf("path %v of commit %v in repo %v is locked by %q", e.File.Path, e.File.Commit.ID, e.File.Commit.Repo.Name, e.Owner)
+}
+
+func (e ErrCommitLockNotFound) Error() string {
+	return fmt.Sprintf("lock %v not found on commit %v in repo %v, it may have expired", e.ID, e.Commit.ID, e.Commit.Repo.Name)
+}
+
+func (e ErrUploadNotFound) Error() string {
+	return fmt.Sprintf("upload %v not found, it may have been completed or dropped", e.ID)
+}
+
+func (e ErrAdminJobNotFound) Error() string {
+	return fmt.Sprintf("admin job %v not found, it may have finished too long ago", e.ID)
+}
+
+func (e ErrRepoArchived) Error() string {
+	return fmt.Sprintf("repo %v is archived, it must be unarchived to be written to", e.Repo.Name)
+}
+
+func (e ErrRepoQuotaExceeded) Error() string {
+	return fmt.Sprintf("repo %v has exceeded its quota of %v", e.Repo.Name, e.Limit)
+}
+
 // ByteRangeSize returns byteRange.Upper - byteRange.Lower.
 func ByteRangeSize(byteRange *pfs.ByteRange) uint64 {
 	return byteRange.Upper - byteRange.Lower
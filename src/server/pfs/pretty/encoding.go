@@ -0,0 +1,230 @@
+package pretty
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+)
+
+const (
+	// FormatJSONLines encodes each item of a listing as a JSON object on a
+	// line of its own.
+	FormatJSONLines = "jsonl"
+	// FormatCSV encodes a listing as CSV, with a header row naming the
+	// columns.
+	FormatCSV = "csv"
+)
+
+// ListingFormats are the formats that a ListingEncoder can encode in.
+var ListingFormats = []string{FormatJSONLines, FormatCSV}
+
+var (
+	commitInfoColumns = []string{"repo", "commit", "parent", "started", "finished", "size_bytes"}
+	fileInfoColumns   = []string{"repo", "commit", "path", "type", "size_bytes", "symlink_target"}
+)
+
+// ListingEncoder encodes the items of a listing, such as the result of
+// ListCommit or ListFile, in a format that tools that don't know about
+// protobufs can read. Items are encoded as they're given, so a listing can be
+// streamed, and all of them have to be of the same kind.
+type ListingEncoder struct {
+	format      string
+	w           io.Writer
+	marshaler   *jsonpb.Marshaler
+	csv         *csv.Writer
+	wroteHeader bool
+}
+
+// NewListingEncoder returns a ListingEncoder that writes to w in format, one
+// of ListingFormats.
+func NewListingEncoder(format string, w io.Writer) (*ListingEncoder, error) {
+	e := &ListingEncoder{format: format, w: w}
+	switch format {
+	case FormatJSONLines:
+		e.marshaler = &jsonpb.Marshaler{}
+	case FormatCSV:
+		e.csv = csv.NewWriter(w)
+	default:
+		return nil, fmt.Errorf("unknown listing format %q, it must be one of %v", format, ListingFormats)
+	}
+	return e, nil
+}
+
+// ContentType returns the MIME type of what e writes.
+func (e *ListingEncoder) ContentType() string {
+	if e.format == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// EncodeCommitInfo encodes commitInfo.
+func (e *ListingEncoder) EncodeCommitInfo(commitInfo *pfs.CommitInfo) error {
+	if e.csv == nil {
+		return e.encodeJSON(commitInfo)
+	}
+	parent := ""
+	if commitInfo.ParentCommit != nil {
+		parent = commitInfo.ParentCommit.ID
+	}
+	// open commits don't have a meaningful size, so it's left empty
+	size := ""
+	if commitInfo.Finished != nil {
+		size = strconv.FormatUint(commitInfo.SizeBytes, 10)
+	}
+	return e.encodeCSV(commitInfoColumns, []string{
+		commitInfo.Commit.Repo.Name,
+		commitInfo.Commit.ID,
+		parent,
+		csvTimestamp(commitInfo.Started),
+		csvTimestamp(commitInfo.Finished),
+		size,
+	})
+}
+
+// EncodeFileInfo encodes fileInfo.
+func (e *ListingEncoder) EncodeFileInfo(fileInfo *pfs.FileInfo) error {
+	if e.csv == nil {
+		return e.encodeJSON(fileInfo)
+	}
+	return e.encodeCSV(fileInfoColumns, []string{
+		fileInfo.File.Commit.Repo.Name,
+		fileInfo.File.Commit.ID,
+		fileInfo.File.Path,
+		fileType(fileInfo.FileType),
+		strconv.FormatUint(fileInfo.SizeBytes, 10),
+		fileInfo.SymlinkTarget,
+	})
+}
+
+// Flush writes any of the listing that's buffered, and returns the first
+// error that happened writing it.
+func (e *ListingEncoder) Flush() error {
+	if e.csv == nil {
+		return nil
+	}
+	e.csv.Flush()
+	return e.csv.Error()
+}
+
+func (e *ListingEncoder) encodeJSON(item proto.Message) error {
+	if err := e.marshaler.Marshal(e.w, item); err != nil {
+		return err
+	}
+	_, err := io.WriteString(e.w, "\n")
+	return err
+}
+
+func (e *ListingEncoder) encodeCSV(columns []string, record []string) error {
+	if !e.wroteHeader {
+		if err := e.csv.Write(columns); err != nil {
+			return err
+		}
+		e.wroteHeader = true
+	}
+	return e.csv.Write(record)
+}
+
+// csvTimestamp formats t as RFC 3339, or as "" if it's nil.
+func csvTimestamp(t *types.Timestamp) string {
+	if t == nil {
+		return ""
+	}
+	goTime, err := types.TimestampFromProto(t)
+	if err != nil {
+		return ""
+	}
+	return goTime.UTC().Format(time.RFC3339Nano)
+}
+
+const (
+	// GraphFormatDOT is Graphviz's DOT language.
+	GraphFormatDOT = "dot"
+	// GraphFormatJSON is the JSON Graph Format, see
+	// http://jsongraphformat.info.
+	GraphFormatJSON = "json"
+)
+
+// GraphFormats are the formats that EncodeProvenanceGraph can encode in.
+var GraphFormats = []string{GraphFormatDOT, GraphFormatJSON}
+
+// EncodeProvenanceGraph writes graph to w in format, one of GraphFormats.
+// Repos and commits are told apart by their nodes' "type", and commits are
+// labelled with their ID and grouped with their repo.
+func EncodeProvenanceGraph(w io.Writer, graph *pfs.ProvenanceGraph, format string) error {
+	switch format {
+	case GraphFormatDOT:
+		return encodeProvenanceGraphDOT(w, graph)
+	case GraphFormatJSON:
+		return encodeProvenanceGraphJSON(w, graph)
+	}
+	return fmt.Errorf("unknown graph format %q, it must be one of %v", format, GraphFormats)
+}
+
+func encodeProvenanceGraphDOT(w io.Writer, graph *pfs.ProvenanceGraph) error {
+	var buf bytes.Buffer
+	buf.WriteString("digraph provenance {\n")
+	for _, node := range graph.Nodes {
+		if node.Commit == nil {
+			fmt.Fprintf(&buf, "  %s [shape=box, label=%s];\n", strconv.Quote(node.Id), strconv.Quote(node.Repo.Name))
+		} else {
+			fmt.Fprintf(&buf, "  %s [shape=ellipse, label=%s, group=%s];\n", strconv.Quote(node.Id), strconv.Quote(node.Commit.ID), strconv.Quote(node.Repo.Name))
+		}
+	}
+	for _, edge := range graph.Edges {
+		fmt.Fprintf(&buf, "  %s -> %s;\n", strconv.Quote(edge.From), strconv.Quote(edge.To))
+	}
+	buf.WriteString("}\n")
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+type jsonGraphNode struct {
+	Label    string            `json:"label"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+type jsonGraphEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+func encodeProvenanceGraphJSON(w io.Writer, graph *pfs.ProvenanceGraph) error {
+	nodes := make(map[string]jsonGraphNode)
+	for _, node := range graph.Nodes {
+		if node.Commit == nil {
+			nodes[node.Id] = jsonGraphNode{
+				Label:    node.Repo.Name,
+				Metadata: map[string]string{"type": "repo"},
+			}
+		} else {
+			nodes[node.Id] = jsonGraphNode{
+				Label:    node.Commit.ID,
+				Metadata: map[string]string{"type": "commit", "repo": node.Repo.Name},
+			}
+		}
+	}
+	edges := []jsonGraphEdge{}
+	for _, edge := range graph.Edges {
+		edges = append(edges, jsonGraphEdge{Source: edge.From, Target: edge.To})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(map[string]interface{}{
+		"graph": map[string]interface{}{
+			"directed": true,
+			"label":    "provenance",
+			"nodes":    nodes,
+			"edges":    edges,
+		},
+	})
+}
@@ -5,8 +5,12 @@ import (
 	"html/template"
 	"io"
 	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/docker/go-units"
+	"github.com/gogo/protobuf/types"
 	"github.com/pachyderm/pachyderm/src/client/pfs"
 	"github.com/pachyderm/pachyderm/src/server/pkg/pretty"
 )
@@ -40,9 +44,21 @@ func PrintDetailedRepoInfo(repoInfo *pfs.RepoInfo) error {
 	template, err := template.New("RepoInfo").Funcs(funcMap).Parse(
 		`Name: {{.Repo.Name}}{{if .Description}}
 Description: {{.Description}}{{end}}
-Created: {{prettyAgo .Created}}
+Created: {{prettyAgo .Created}}{{if .Archived}}
+Archived: {{prettyAgo .Archived}}{{end}}{{if .LastCommitTime}}
+Last Commit: {{prettyAgo .LastCommitTime}}{{end}}
 Size: {{prettySize .SizeBytes}}{{if .Provenance}}
-Provenance: {{range .Provenance}} {{.Name}} {{end}}{{end}}{{if .AuthInfo}}
+Provenance: {{range .Provenance}} {{.Name}} {{end}}{{end}}{{if .Subvenance}}
+Subvenance: {{range .Subvenance}} {{.Name}} {{end}}{{end}}{{if .Expires}}
+Expires: {{prettyUntil .Expires}}{{end}}{{if .Remote}}
+Proxy of: {{.Remote.Address}}/{{.Remote.Repo}}{{end}}{{if .Compression}}
+Compression: {{.Compression}}{{end}}{{if .ProtectedPaths}}
+Protected paths: {{range .ProtectedPaths}} {{.}} {{end}}{{end}}{{if .Residency}}
+Residency: {{range .Residency}} {{.}} {{end}}{{end}}{{if .StorageClass}}
+Storage class: {{.StorageClass}}{{end}}{{if .Labels}}
+Labels: {{range $key, $value := .Labels}} {{$key}}={{$value}} {{end}}{{end}}{{if .Annotations}}
+Annotations: {{range $key, $value := .Annotations}} {{$key}}={{$value}} {{end}}{{end}}{{with .ReadFilter}}
+Read filter: {{if .Regex}}regex {{.Regex}}{{else}}jmes path {{.JmesPath}}{{end}} (below {{.ExemptScope}}){{end}}{{if .AuthInfo}}
 Access level: {{ .AuthInfo.AccessLevel.String }}{{end}}
 `)
 	if err != nil {
@@ -66,6 +82,100 @@ func PrintBranch(w io.Writer, branch *pfs.BranchInfo) {
 	fmt.Fprintf(w, "%s\t\n", branch.Head.ID)
 }
 
+// PrintCommitHookHeader prints a commit hook header.
+func PrintCommitHookHeader(w io.Writer) {
+	fmt.Fprint(w, "NAME\tURL\tBRANCH\tDELIVERED\tSEQUENCE\tATTEMPTS\tLAST ERROR\t\n")
+}
+
+// PrintCommitHookInfo pretty-prints a commit hook, with one line for its
+// delivery state on each branch.
+func PrintCommitHookInfo(w io.Writer, hookInfo *pfs.CommitHookInfo) {
+	if len(hookInfo.Branches) == 0 {
+		fmt.Fprintf(w, "%s\t%s\t-\t-\t-\t-\t-\t\n", hookInfo.Name, hookInfo.Url)
+		return
+	}
+	for _, state := range hookInfo.Branches {
+		fmt.Fprintf(w, "%s\t%s\t%s\t", hookInfo.Name, hookInfo.Url, state.Branch)
+		if state.Delivered != nil {
+			fmt.Fprintf(w, "%s\t", state.Delivered.ID)
+		} else {
+			fmt.Fprint(w, "<none>\t")
+		}
+		fmt.Fprintf(w, "%d\t", state.Sequence)
+		fmt.Fprintf(w, "%d\t", state.Attempts)
+		if state.LastError != "" {
+			fmt.Fprintf(w, "%s\t\n", state.LastError)
+		} else {
+			fmt.Fprint(w, "-\t\n")
+		}
+	}
+}
+
+// PrintDetailedCompactionPolicyInfo pretty-prints a repo's compaction
+// policy and the state of its automatic compactions on each branch.
+func PrintDetailedCompactionPolicyInfo(policyInfo *pfs.CompactionPolicyInfo) error {
+	template, err := template.New("CompactionPolicyInfo").Funcs(funcMap).Parse(
+		`Repo: {{.Repo.Name}}
+Branches: {{if .Policy.Branches}}{{range .Policy.Branches}} {{.}} {{end}}{{else}}all{{end}}
+Min objects: {{if .Policy.MinObjects}}{{.Policy.MinObjects}}{{else}}default{{end}}
+Max average object size: {{if .Policy.MaxAverageObjectBytes}}{{.Policy.MaxAverageObjectBytes}} bytes{{else}}default{{end}}
+Max compactions per hour: {{if .Policy.MaxCompactionsPerHour}}{{.Policy.MaxCompactionsPerHour}}{{else}}default{{end}}
+In place: {{.Policy.InPlace}}
+Compactions in the last hour: {{len .RecentCompactions}}{{range .Branches}}
+Branch {{.Branch}}: checked {{if .Checked}}{{.Checked.ID}}{{else}}<none>{{end}}{{if .LastError}}, error: {{.LastError}}{{end}}{{end}}
+`)
+	if err != nil {
+		return err
+	}
+	return template.Execute(os.Stdout, policyInfo)
+}
+
+// PrintDetailedRetentionPolicyInfo pretty-prints a repo's retention policy,
+// the heads of deleted branches that it keeps and its latest plans.
+func PrintDetailedRetentionPolicyInfo(policyInfo *pfs.RetentionPolicyInfo) error {
+	template, err := template.New("RetentionPolicyInfo").Funcs(funcMap).Parse(
+		`Repo: {{.Repo.Name}}
+Branches: {{if .Policy.Branches}}{{range .Policy.Branches}} {{.}} {{end}}{{else}}all{{end}}
+Expire idle branches after: {{if .Policy.ExpireIdleBranchSeconds}}{{.Policy.ExpireIdleBranchSeconds}}s{{else}}never{{end}}
+Keep deleted heads for: {{if .Policy.KeepDeletedHeadSeconds}}{{.Policy.KeepDeletedHeadSeconds}}s{{else}}no time{{end}}
+Dry run: {{.Policy.DryRun}}{{range .DeletedHeads}}
+Deleted branch {{.Branch}}: head {{.Head.ID}}, deleted {{prettyAgo .Deleted}}{{end}}{{range .Plans}}
+Plan {{prettyAgo .Planned}}{{if not .Executed}} (dry run){{end}}:{{range .Actions}}
+  {{.Type}} {{.Branch}} ({{.Head.ID}}): {{.Reason}}{{if .Error}}, error: {{.Error}}{{end}}{{end}}{{end}}
+`)
+	if err != nil {
+		return err
+	}
+	return template.Execute(os.Stdout, policyInfo)
+}
+
+// PrintDetailedRetentionPlan pretty-prints a plan that enforces a repo's
+// retention policy.
+func PrintDetailedRetentionPlan(plan *pfs.RetentionPlan) error {
+	template, err := template.New("RetentionPlan").Funcs(funcMap).Parse(
+		`{{if not .Actions}}Nothing to do
+{{end}}{{range .Actions}}{{.Type}} {{.Branch}} ({{.Head.ID}}): {{.Reason}}
+{{end}}`)
+	if err != nil {
+		return err
+	}
+	return template.Execute(os.Stdout, plan)
+}
+
+// PrintDetailedMetadataExportInfo pretty-prints the cluster's metadata export.
+func PrintDetailedMetadataExportInfo(exportInfo *pfs.MetadataExportInfo) error {
+	template, err := template.New("MetadataExportInfo").Funcs(funcMap).Parse(
+		`Repo: {{.Export.Repo.Name}}
+Interval: {{if .Export.IntervalSeconds}}{{.Export.IntervalSeconds}}s{{else}}default{{end}}
+Last export: {{if .LastExport}}{{prettyAgo .LastExport}}, commit {{.LastCommit.ID}}{{else}}<none>{{end}}{{if .LastError}}
+Last error: {{.LastError}}{{end}}
+`)
+	if err != nil {
+		return err
+	}
+	return template.Execute(os.Stdout, exportInfo)
+}
+
 // PrintCommitInfoHeader prints a commit info header.
 func PrintCommitInfoHeader(w io.Writer) {
 	fmt.Fprint(w, "REPO\tID\tPARENT\tSTARTED\tDURATION\tSIZE\t\n")
@@ -99,11 +209,26 @@ func PrintCommitInfo(w io.Writer, commitInfo *pfs.CommitInfo) {
 func PrintDetailedCommitInfo(commitInfo *pfs.CommitInfo) error {
 	template, err := template.New("CommitInfo").Funcs(funcMap).Parse(
 		`Commit: {{.Commit.Repo.Name}}/{{.Commit.ID}}{{if .ParentCommit}}
-Parent: {{.ParentCommit.ID}} {{end}}
+Parent: {{.ParentCommit.ID}} {{end}}{{if .Base}}
+Base: {{.Base.ID}} {{end}}
 Started: {{prettyAgo .Started}}{{if .Finished}}
-Finished: {{prettyAgo .Finished}} {{end}}
+Finished: {{prettyAgo .Finished}} {{end}}{{if .Staged}}
+Staged: {{range .StagedBranches}} {{.}} {{end}} {{end}}{{if .Reviews}}
+Reviews:{{range .Reviews}}
+  {{.Decision}} by {{if .User}}{{.User}}{{else}}<unknown>{{end}} {{prettyAgo .Time}}{{if .Comment}}: {{.Comment}}{{end}}{{end}}{{end}}{{if .Merge}}
+Merge: {{.Merge.Theirs.ID}} into {{.Merge.Branch}}{{if .Merge.Conflicts}}
+Conflicts:{{range .Merge.Conflicts}}
+  {{.}}{{end}}{{end}}{{end}}
 Size: {{prettySize .SizeBytes}}{{if .Provenance}}
-Provenance: {{range .Provenance}} {{.Repo.Name}}/{{.ID}} {{end}} {{end}}
+Provenance: {{range .Provenance}} {{.Repo.Name}}/{{.ID}} {{end}} {{end}}{{if .Subvenance}}
+Subvenance: {{range .Subvenance}} {{.Repo.Name}}/{{.ID}} {{end}} {{end}}{{if .Progress}}
+Progress: {{.Progress.RecordsApplied}}/{{.Progress.RecordsTotal}} records applied, {{prettySize .Progress.BytesUploaded}}/{{prettySize .Progress.BytesSerialized}} of tree uploaded {{end}}{{if .DataCard}}
+Data Card:{{if .DataCard.Description}}
+  Description: {{.DataCard.Description}}{{end}}{{if .DataCard.License}}
+  License: {{.DataCard.License}}{{end}}
+  Rows: {{.DataCard.RowCount}}{{if .DataCard.Schema}}
+  Schema:{{range .DataCard.Schema}}
+    {{.Name}} {{.Type}}{{if .Description}} - {{.Description}}{{end}}{{end}}{{end}}{{end}}
 `)
 	if err != nil {
 		return err
@@ -124,22 +249,47 @@ func PrintFileInfoHeader(w io.Writer) {
 // If recurse is false and directory size is 0, display "-" instead
 // If fast is true and file size is 0, display "-" instead
 func PrintFileInfo(w io.Writer, fileInfo *pfs.FileInfo) {
-	fmt.Fprintf(w, "%s\t", fileInfo.File.Path)
-	if fileInfo.FileType == pfs.FileType_FILE {
-		fmt.Fprint(w, "file\t")
+	if fileInfo.SymlinkTarget != "" {
+		fmt.Fprintf(w, "%s -> %s\t", fileInfo.File.Path, fileInfo.SymlinkTarget)
 	} else {
-		fmt.Fprint(w, "dir\t")
+		fmt.Fprintf(w, "%s\t", fileInfo.File.Path)
 	}
+	fmt.Fprintf(w, "%s\t", fileType(fileInfo.FileType))
 	fmt.Fprintf(w, "%s\t\n", units.BytesSize(float64(fileInfo.SizeBytes)))
 }
 
+// PrintManifestEntryHeader prints a manifest entry header.
+func PrintManifestEntryHeader(w io.Writer) {
+	fmt.Fprint(w, "NAME\tTYPE\tSIZE\tOBJECTS\t\n")
+}
+
+// PrintManifestEntry pretty-prints a manifest entry.
+func PrintManifestEntry(w io.Writer, entry *pfs.ManifestEntry) {
+	if entry.SymlinkTarget != "" {
+		fmt.Fprintf(w, "%s -> %s\t", entry.Path, entry.SymlinkTarget)
+	} else {
+		fmt.Fprintf(w, "%s\t", entry.Path)
+	}
+	fmt.Fprintf(w, "%s\t", fileType(entry.FileType))
+	fmt.Fprintf(w, "%s\t", units.BytesSize(float64(entry.SizeBytes)))
+	fmt.Fprintf(w, "%d\t\n", len(entry.Objects))
+}
+
 // PrintDetailedFileInfo pretty-prints detailed file info.
 func PrintDetailedFileInfo(fileInfo *pfs.FileInfo) error {
 	template, err := template.New("FileInfo").Funcs(funcMap).Parse(
 		`Path: {{.File.Path}}
-Type: {{fileType .FileType}}
-Size: {{prettySize .SizeBytes}}
-Children: {{range .Children}} {{.}} {{end}}
+Type: {{fileType .FileType}}{{if .SymlinkTarget}}
+Target: {{.SymlinkTarget}}{{end}}
+Size: {{prettySize .SizeBytes}}{{if .CommitModified}}
+Modified in: {{.CommitModified.ID}}{{end}}{{if .RecordCount}}
+Records: {{.RecordCount}}{{end}}{{if .Sha256}}
+SHA-256: {{printf "%x" .Sha256}}{{end}}
+Children: {{range .Children}} {{.}} {{end}}{{if .Schema}}
+Schema: {{.Schema.Type}} {{.Schema.Url}}{{end}}{{if .Stats}}
+Rows: {{.Stats.RowCount}}
+Columns:{{range .Stats.Columns}}
+  {{.Name}}: min {{.Min}}, max {{.Max}}{{end}}{{end}}
 `)
 	if err != nil {
 		return err
@@ -147,6 +297,167 @@ Children: {{range .Children}} {{.}} {{end}}
 	return template.Execute(os.Stdout, fileInfo)
 }
 
+// PrintCommitLockHeader prints a commit lock header.
+func PrintCommitLockHeader(w io.Writer) {
+	fmt.Fprint(w, "PATH\tOWNER\tID\tEXPIRES\t\n")
+}
+
+// PrintCommitLock pretty-prints a commit lock.
+func PrintCommitLock(w io.Writer, lock *pfs.CommitLock) {
+	expires := "-"
+	if t, err := types.TimestampFromProto(lock.Expires); err == nil {
+		expires = fmt.Sprintf("in %s", units.HumanDuration(time.Until(t)))
+	}
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", lock.File.Path, lock.Owner, lock.ID, expires)
+}
+
+// PrintApplyActionHeader prints an apply action header.
+func PrintApplyActionHeader(w io.Writer) {
+	fmt.Fprint(w, "ACTION\tREPO\tBRANCH\tDETAIL\t\n")
+}
+
+// PrintApplyAction pretty-prints an apply action.
+func PrintApplyAction(w io.Writer, action *pfs.ApplyAction) {
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", action.Type, action.Repo.Name, action.Branch, action.Detail)
+}
+
+// PrintFeatureFlagHeader prints a feature flag header.
+func PrintFeatureFlagHeader(w io.Writer) {
+	fmt.Fprint(w, "NAME\tDEFAULT\tCLUSTER\tREPOS\tUSES\t\n")
+}
+
+// PrintFeatureFlag pretty-prints a feature flag.
+func PrintFeatureFlag(w io.Writer, flag *pfs.FeatureFlag) {
+	var repos []string
+	for repo, setting := range flag.Repos {
+		repos = append(repos, fmt.Sprintf("%s=%s", repo, strings.ToLower(setting.String())))
+	}
+	sort.Strings(repos)
+	defaultSetting := "off"
+	if flag.Default {
+		defaultSetting = "on"
+	}
+	cluster := "-"
+	if flag.Cluster != pfs.FeatureFlagSetting_UNSET {
+		cluster = strings.ToLower(flag.Cluster.String())
+	}
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t\n", flag.Name, defaultSetting, cluster, strings.Join(repos, ","), flag.Uses)
+}
+
+// PrintAdminJobInfoHeader prints an admin job info header.
+func PrintAdminJobInfoHeader(w io.Writer) {
+	fmt.Fprint(w, "ID\tTYPE\tSTATE\tSTARTED\tPHASE\tPROGRESS\t\n")
+}
+
+// PrintAdminJobInfo pretty-prints admin job info.
+func PrintAdminJobInfo(w io.Writer, jobInfo *pfs.AdminJobInfo) {
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t\n", jobInfo.Id, jobInfo.Type, strings.ToLower(jobInfo.State.String()), pretty.Ago(jobInfo.Started), jobInfo.Phase, adminJobProgress(jobInfo))
+}
+
+// PrintDetailedAdminJobInfo pretty-prints detailed admin job info.
+func PrintDetailedAdminJobInfo(jobInfo *pfs.AdminJobInfo) error {
+	template, err := template.New("AdminJobInfo").Funcs(funcMap).Parse(
+		`ID: {{.Id}}
+Type: {{.Type}}{{if .User}}
+User: {{.User}}{{end}}
+State: {{.State}}{{if .CancelRequested}} (cancel requested){{end}}
+Started: {{prettyAgo .Started}}{{if .Finished}}
+Finished: {{prettyAgo .Finished}}{{end}}{{if .Phase}}
+Phase: {{.Phase}}
+Progress: {{adminJobProgress .}}{{end}}{{if .Eta}}
+ETA: {{prettyUntil .Eta}}{{end}}{{if .Error}}
+Error: {{.Error}}{{end}}
+`)
+	if err != nil {
+		return err
+	}
+	return template.Execute(os.Stdout, jobInfo)
+}
+
+func adminJobProgress(jobInfo *pfs.AdminJobInfo) string {
+	if jobInfo.Total > 0 {
+		return fmt.Sprintf("%d/%d", jobInfo.Done, jobInfo.Total)
+	}
+	return fmt.Sprintf("%d", jobInfo.Done)
+}
+
+// PrintRepoUsageHeader prints a repo usage header.
+func PrintRepoUsageHeader(w io.Writer) {
+	fmt.Fprint(w, "REPO\tPUT FILE/S\tLIST FILE/S\tWATCHES\tREJECTED\tQUOTA\t\n")
+}
+
+// PrintRepoUsage pretty-prints repo usage.
+func PrintRepoUsage(w io.Writer, usage *pfs.RepoUsage) {
+	fmt.Fprintf(w, "%s\t%.2f\t%.2f\t%d\t%d\t%s\t\n", usage.Repo.Name, usage.PutFilePerSecond, usage.ListFilePerSecond, usage.Watches, usage.Rejected, repoQuota(usage.Quota))
+}
+
+func repoQuota(quota *pfs.RepoQuota) string {
+	var limits []string
+	if quota != nil && quota.PutFilePerSecond > 0 {
+		limits = append(limits, fmt.Sprintf("%g put-file/s", quota.PutFilePerSecond))
+	}
+	if quota != nil && quota.ListFilePerSecond > 0 {
+		limits = append(limits, fmt.Sprintf("%g list-file/s", quota.ListFilePerSecond))
+	}
+	if quota != nil && quota.MaxWatches > 0 {
+		limits = append(limits, fmt.Sprintf("%d watches", quota.MaxWatches))
+	}
+	if len(limits) == 0 {
+		return "none"
+	}
+	return strings.Join(limits, ", ")
+}
+
+// PrintDiffFileSummary pretty-prints a DiffFileSummary.
+func PrintDiffFileSummary(summary *pfs.DiffFileSummary) {
+	fmt.Printf("Added: %d files, %s\n", summary.FilesAdded, pretty.Size(uint64(summary.BytesAdded)))
+	fmt.Printf("Deleted: %d files, %s\n", summary.FilesDeleted, pretty.Size(uint64(summary.BytesDeleted)))
+	fmt.Printf("Modified: %d files, %s\n", summary.FilesModified, pretty.Size(uint64(summary.BytesModified)))
+}
+
+// PrintPreviewMergeResponse pretty-prints a PreviewMergeResponse.
+func PrintPreviewMergeResponse(response *pfs.PreviewMergeResponse) {
+	if response.Base != nil {
+		fmt.Printf("Base: %s\n", response.Base.ID)
+	} else {
+		fmt.Println("Base: none")
+	}
+	if response.FastForward {
+		fmt.Println("Fast-forward")
+	}
+	PrintDiffFileSummary(response.Summary)
+	if len(response.Conflicts) == 0 {
+		fmt.Println("Conflicts: none")
+		return
+	}
+	fmt.Printf("Conflicts: %d\n", len(response.Conflicts))
+	for _, path := range response.Conflicts {
+		fmt.Printf("  %s\n", path)
+	}
+}
+
+// PrintMergeResponse pretty-prints a MergeResponse.
+func PrintMergeResponse(response *pfs.MergeResponse) {
+	fmt.Printf("Commit: %s\n", response.Commit.ID)
+	if len(response.Conflicts) == 0 {
+		return
+	}
+	fmt.Printf("Conflicts: %d\n", len(response.Conflicts))
+	for _, path := range response.Conflicts {
+		fmt.Printf("  %s\n", path)
+	}
+}
+
+// PrintBisectCommitsResponse pretty-prints a BisectCommitsResponse.
+func PrintBisectCommitsResponse(response *pfs.BisectCommitsResponse) {
+	if response.FirstBad != nil {
+		fmt.Printf("%s is the first bad commit\n", response.FirstBad.ID)
+		return
+	}
+	fmt.Printf("Test commit %s next\n", response.Next.Commit.ID)
+	fmt.Printf("Remaining: %d commits, at most %d to test\n", response.Remaining, response.Steps)
+}
+
 type uint64Slice []uint64
 
 func (s uint64Slice) Len() int           { return len(s) }
@@ -154,14 +465,20 @@ func (s uint64Slice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 func (s uint64Slice) Less(i, j int) bool { return s[i] < s[j] }
 
 func fileType(fileType pfs.FileType) string {
-	if fileType == pfs.FileType_FILE {
+	switch fileType {
+	case pfs.FileType_FILE:
 		return "file"
+	case pfs.FileType_SYMLINK:
+		return "symlink"
+	default:
+		return "dir"
 	}
-	return "dir"
 }
 
 var funcMap = template.FuncMap{
-	"prettyAgo":  pretty.Ago,
-	"prettySize": pretty.Size,
-	"fileType":   fileType,
+	"prettyAgo":        pretty.Ago,
+	"prettyUntil":      pretty.Until,
+	"prettySize":       pretty.Size,
+	"fileType":         fileType,
+	"adminJobProgress": adminJobProgress,
 }
@@ -0,0 +1,294 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
+	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// adminJobTTL is how long, in seconds, a running job's status lasts
+	// without being refreshed, so that the jobs of a pachd that's gone away
+	// don't look like they're running forever.
+	adminJobTTL = 5 * 60
+	// adminJobRetention is how long, in seconds, a finished job's status is
+	// kept.
+	adminJobRetention = 24 * 60 * 60
+	// adminJobPollInterval is how often a running job's status is written, if
+	// it's changed, and checked for a cancellation.
+	adminJobPollInterval = time.Second
+	// adminJobRefreshInterval is how often a running job's status is written
+	// even if it hasn't changed, it must be well under adminJobTTL.
+	adminJobRefreshInterval = time.Minute
+)
+
+// adminJob is the status of a running admin operation, see runAdminJob. Its
+// methods can be called on a nil job, which does nothing, so that the
+// operations can also be run without one.
+type adminJob struct {
+	mu           sync.Mutex
+	info         *pfs.AdminJobInfo
+	phaseStarted time.Time
+	// changed is set when info changes, until it's written
+	changed   bool
+	cancelled bool
+}
+
+// setPhase starts the phase of the job called phase, which has total units
+// of work, or 0 if that isn't known.
+func (j *adminJob) setPhase(phase string, total int64) {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.info.Phase = phase
+	j.info.Done = 0
+	j.info.Total = total
+	j.info.Eta = nil
+	j.phaseStarted = time.Now()
+	j.changed = true
+}
+
+// progress records that n more units of work of the phase are done.
+func (j *adminJob) progress(n int64) {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.info.Done += n
+	if j.info.Total > 0 && j.info.Done > 0 && j.info.Done <= j.info.Total {
+		elapsed := time.Since(j.phaseStarted)
+		remaining := time.Duration(float64(elapsed) * float64(j.info.Total-j.info.Done) / float64(j.info.Done))
+		eta, err := types.TimestampProto(time.Now().Add(remaining))
+		if err == nil {
+			j.info.Eta = eta
+		}
+	}
+	j.changed = true
+}
+
+// snapshot returns a copy of the job's status, and clears changed.
+func (j *adminJob) snapshot() *pfs.AdminJobInfo {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.changed = false
+	return proto.Clone(j.info).(*pfs.AdminJobInfo)
+}
+
+// runAdminJob runs f as an admin job of type jobType, whose status can be
+// inspected, and which can be cancelled, through any pachd while it runs. f
+// reports its progress through the job it's given, and must stop when its
+// context is cancelled.
+func (d *driver) runAdminJob(ctx context.Context, jobType string, f func(ctx context.Context, job *adminJob) error) error {
+	d.initializePachConn()
+	job := &adminJob{
+		info: &pfs.AdminJobInfo{
+			Id:      uuid.NewWithoutDashes(),
+			Type:    jobType,
+			State:   pfs.AdminJobState_RUNNING,
+			Started: now(),
+		},
+		phaseStarted: time.Now(),
+	}
+	whoAmI, err := d.pachClient.AuthAPIClient.WhoAmI(auth.In2Out(ctx), &auth.WhoAmIRequest{})
+	if err != nil && !auth.IsNotActivatedError(err) {
+		return grpcutil.ScrubGRPC(err)
+	} else if err == nil {
+		job.info.User = whoAmI.Username
+	}
+	if _, err := d.writeAdminJob(ctx, job, adminJobTTL); err != nil {
+		return err
+	}
+	d.featureUsage.inc("admin_job_" + jobType)
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		d.watchAdminJob(jobCtx, job, cancel, done)
+	}()
+	err = f(jobCtx, job)
+	close(done)
+	<-stopped
+
+	job.mu.Lock()
+	cancelled := job.cancelled
+	job.info.Finished = now()
+	job.info.Eta = nil
+	switch {
+	case err == nil:
+		job.info.State = pfs.AdminJobState_SUCCEEDED
+	case cancelled:
+		job.info.State = pfs.AdminJobState_CANCELLED
+		job.info.Error = err.Error()
+	default:
+		job.info.State = pfs.AdminJobState_FAILED
+		job.info.Error = err.Error()
+	}
+	job.mu.Unlock()
+	// ctx may be why the job stopped, so the final status is written
+	// regardless of it
+	if _, werr := d.writeAdminJob(context.Background(), job, adminJobRetention); werr != nil {
+		log.Errorf("error writing the status of admin job %s: %v", job.info.Id, werr)
+	}
+	if err != nil && cancelled {
+		return fmt.Errorf("admin job %s was cancelled", job.info.Id)
+	}
+	return err
+}
+
+// watchAdminJob writes job's status while it's running, and cancels it if
+// it's asked to, until done is closed.
+func (d *driver) watchAdminJob(ctx context.Context, job *adminJob, cancel func(), done chan struct{}) {
+	ticker := time.NewTicker(adminJobPollInterval)
+	defer ticker.Stop()
+	lastWrite := time.Now()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		var cancelRequested bool
+		job.mu.Lock()
+		changed := job.changed
+		job.mu.Unlock()
+		if changed || time.Since(lastWrite) > adminJobRefreshInterval {
+			var err error
+			if cancelRequested, err = d.writeAdminJob(ctx, job, adminJobTTL); err != nil {
+				log.Errorf("error writing the status of admin job %s: %v", job.info.Id, err)
+				job.mu.Lock()
+				job.changed = true
+				job.mu.Unlock()
+				continue
+			}
+			lastWrite = time.Now()
+		} else {
+			info := &pfs.AdminJobInfo{}
+			if err := d.adminJobs.ReadOnly(ctx).Get(job.info.Id, info); err != nil {
+				continue
+			}
+			cancelRequested = info.CancelRequested
+		}
+		if cancelRequested {
+			job.mu.Lock()
+			job.cancelled = true
+			job.mu.Unlock()
+			cancel()
+		}
+	}
+}
+
+// writeAdminJob writes job's status, which is kept for ttl seconds, and
+// returns whether it's been asked to cancel. Cancellations are only written
+// by CancelAdminJob, so they're kept from what's stored.
+func (d *driver) writeAdminJob(ctx context.Context, job *adminJob, ttl int64) (bool, error) {
+	info := job.snapshot()
+	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		adminJobs := d.adminJobs.ReadWrite(stm)
+		stored := &pfs.AdminJobInfo{}
+		if err := adminJobs.Get(info.Id, stored); err != nil && !col.IsErrNotFound(err) {
+			return err
+		}
+		info.CancelRequested = info.CancelRequested || stored.CancelRequested
+		return adminJobs.PutTTL(info.Id, info, ttl)
+	})
+	return info.CancelRequested, err
+}
+
+// listAdminJobs returns the admin jobs of type jobType, or of every type if
+// it's empty, oldest first.
+func (d *driver) listAdminJobs(ctx context.Context, jobType string) ([]*pfs.AdminJobInfo, error) {
+	iter, err := d.adminJobs.ReadOnly(ctx).List()
+	if err != nil {
+		return nil, err
+	}
+	var result []*pfs.AdminJobInfo
+	for {
+		var id string
+		info := &pfs.AdminJobInfo{}
+		ok, err := iter.Next(&id, info)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		if jobType != "" && info.Type != jobType {
+			continue
+		}
+		result = append(result, info)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i].Started, result[j].Started
+		if a.Seconds != b.Seconds {
+			return a.Seconds < b.Seconds
+		}
+		if a.Nanos != b.Nanos {
+			return a.Nanos < b.Nanos
+		}
+		return result[i].Id < result[j].Id
+	})
+	return result, nil
+}
+
+// inspectAdminJob returns the status of the admin job id.
+func (d *driver) inspectAdminJob(ctx context.Context, id string) (*pfs.AdminJobInfo, error) {
+	info := &pfs.AdminJobInfo{}
+	if err := d.adminJobs.ReadOnly(ctx).Get(id, info); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil, pfsserver.ErrAdminJobNotFound{id}
+		}
+		return nil, err
+	}
+	return info, nil
+}
+
+// cancelAdminJob asks the admin job id to stop. Only cluster admins and the
+// user who started the job can cancel it. Cancelling a job that's finished
+// does nothing.
+func (d *driver) cancelAdminJob(ctx context.Context, id string) error {
+	info, err := d.inspectAdminJob(ctx, id)
+	if err != nil {
+		return err
+	}
+	d.initializePachConn()
+	whoAmI, err := d.pachClient.AuthAPIClient.WhoAmI(auth.In2Out(ctx), &auth.WhoAmIRequest{})
+	if err != nil && !auth.IsNotActivatedError(err) {
+		return grpcutil.ScrubGRPC(err)
+	} else if err == nil && !whoAmI.IsAdmin && whoAmI.Username != info.User {
+		return fmt.Errorf("only cluster admins and %s can cancel admin job %s", info.User, id)
+	}
+	_, err = col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		adminJobs := d.adminJobs.ReadWrite(stm)
+		info := &pfs.AdminJobInfo{}
+		if err := adminJobs.Get(id, info); err != nil {
+			if col.IsErrNotFound(err) {
+				return pfsserver.ErrAdminJobNotFound{id}
+			}
+			return err
+		}
+		if info.State != pfs.AdminJobState_RUNNING {
+			return nil
+		}
+		info.CancelRequested = true
+		return adminJobs.PutTTL(id, info, adminJobTTL)
+	})
+	return err
+}
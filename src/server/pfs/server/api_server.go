@@ -2,13 +2,11 @@ package server
 
 import (
 	"bytes"
+	"encoding/hex"
 	"fmt"
 	"io"
-	"net/http"
-	"net/url"
+	"io/ioutil"
 	"path"
-	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/gogo/protobuf/types"
@@ -16,12 +14,13 @@ import (
 	"github.com/pachyderm/pachyderm/src/client/pfs"
 	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
 	"github.com/pachyderm/pachyderm/src/server/pkg/log"
-	"github.com/pachyderm/pachyderm/src/server/pkg/obj"
+	"github.com/pachyderm/pachyderm/src/server/pkg/metrics"
+	"github.com/pachyderm/pachyderm/src/server/pkg/throttle"
 
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
-	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 var (
@@ -38,17 +37,28 @@ func newLocalAPIServer(address string, etcdPrefix string) (*apiServer, error) {
 	if err != nil {
 		return nil, err
 	}
+	d.startBackground()
 	return &apiServer{
 		Logger: log.NewLogger("pfs.API"),
 		driver: d,
 	}, nil
 }
 
-func newAPIServer(address string, etcdAddresses []string, etcdPrefix string, cacheSize int64) (*apiServer, error) {
-	d, err := newDriver(address, etcdAddresses, etcdPrefix, cacheSize)
+func newAPIServer(address string, etcdAddresses []string, etcdPrefix string, cacheSize int64, maxProvenanceDepth int64, transferThrottle *throttle.Throttle, interactiveLoad int64, batchConcurrency int64, featureReporter *metrics.FeatureReporter, residency []string) (*apiServer, error) {
+	d, err := newDriver(address, etcdAddresses, etcdPrefix, cacheSize, maxProvenanceDepth)
 	if err != nil {
 		return nil, err
 	}
+	d.transferThrottle = transferThrottle
+	d.scheduler = newScheduler(interactiveLoad, batchConcurrency)
+	d.residency = residency
+	d.featureReporter = featureReporter
+	d.startBackground()
+	if featureReporter != nil {
+		go featureReporter.Report(func() (*pfs.FeatureUsage, error) {
+			return d.inspectFeatureUsage(context.Background())
+		})
+	}
 	return &apiServer{
 		Logger: log.NewLogger("pfs.API"),
 		driver: d,
@@ -59,7 +69,7 @@ func (a *apiServer) CreateRepo(ctx context.Context, request *pfs.CreateRepoReque
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
 
-	if err := a.driver.createRepo(ctx, request.Repo, request.Provenance, request.Description, request.Update); err != nil {
+	if err := a.driver.createRepo(ctx, request.Repo, request.Provenance, request.Description, request.Update, request.TtlSeconds, request.Remote, request.Compression); err != nil {
 		return nil, err
 	}
 	return &types.Empty{}, nil
@@ -69,15 +79,43 @@ func (a *apiServer) InspectRepo(ctx context.Context, request *pfs.InspectRepoReq
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
 
-	return a.driver.inspectRepo(ctx, request.Repo, true)
+	repoInfo, err := a.driver.inspectRepo(ctx, request.Repo, true)
+	if err != nil {
+		return nil, err
+	}
+	if request.Subvenance {
+		if repoInfo.Subvenance, err = a.driver.repoSubvenance(ctx, repoInfo.Repo); err != nil {
+			return nil, err
+		}
+	}
+	return repoInfo, nil
 }
 
 func (a *apiServer) ListRepo(ctx context.Context, request *pfs.ListRepoRequest) (response *pfs.ListRepoResponse, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
 
-	repoInfos, err := a.driver.listRepo(ctx, request.Provenance, true)
-	return repoInfos, err
+	return a.driver.listRepoPage(ctx, request)
+}
+
+func (a *apiServer) ArchiveRepo(ctx context.Context, request *pfs.ArchiveRepoRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.archiveRepo(ctx, request.Repo); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) UnarchiveRepo(ctx context.Context, request *pfs.UnarchiveRepoRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.unarchiveRepo(ctx, request.Repo); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
 }
 
 func (a *apiServer) DeleteRepo(ctx context.Context, request *pfs.DeleteRepoRequest) (response *types.Empty, retErr error) {
@@ -97,11 +135,82 @@ func (a *apiServer) DeleteRepo(ctx context.Context, request *pfs.DeleteRepoReque
 	return &types.Empty{}, nil
 }
 
+func (a *apiServer) RenewRepo(ctx context.Context, request *pfs.RenewRepoRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.renewRepo(ctx, request.Repo, request.TtlSeconds); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) ExportProvenanceGraph(ctx context.Context, request *pfs.ExportProvenanceGraphRequest) (response *pfs.ProvenanceGraph, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	return a.driver.exportProvenanceGraph(ctx, request.Repos, request.Commits)
+}
+
+func (a *apiServer) ExportRepo(request *pfs.ExportRepoRequest, apiExportRepoServer pfs.API_ExportRepoServer) (retErr error) {
+	ctx := apiExportRepoServer.Context()
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
+
+	ctx, done, err := a.driver.schedule(ctx, pfs.Priority_BATCH)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	bundle, err := a.driver.exportRepo(ctx, request.Repo)
+	if err != nil {
+		return err
+	}
+	defer bundle.Close()
+	return grpcutil.WriteToStreamingBytesServer(bundle, apiExportRepoServer)
+}
+
+func (a *apiServer) ImportRepo(importRepoServer pfs.API_ImportRepoServer) (retErr error) {
+	ctx := importRepoServer.Context()
+	defer drainImportRepoServer(importRepoServer)
+	request, err := importRepoServer.Recv()
+	if err != nil {
+		return err
+	}
+	// We remove request.Value from the logs otherwise they would be too big.
+	func() {
+		requestValue := request.Value
+		request.Value = nil
+		a.Log(request, nil, nil, 0)
+		request.Value = requestValue
+	}()
+	defer func(start time.Time) {
+		request.Value = nil
+		a.Log(request, nil, retErr, time.Since(start))
+	}(time.Now())
+
+	ctx, done, err := a.driver.schedule(ctx, pfs.Priority_BATCH)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	reader := &importRepoReader{
+		server: importRepoServer,
+	}
+	reader.buffer.Write(request.Value)
+	if err := a.driver.importRepo(ctx, request.Repo, reader); err != nil {
+		return err
+	}
+	return importRepoServer.SendAndClose(&types.Empty{})
+}
+
 func (a *apiServer) StartCommit(ctx context.Context, request *pfs.StartCommitRequest) (response *pfs.Commit, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
 
-	commit, err := a.driver.startCommit(ctx, request.Parent, request.Branch, request.Provenance)
+	commit, err := a.driver.startCommit(ctx, request.Parent, request.Branch, request.Provenance, request.Base)
 	if err != nil {
 		return nil, err
 	}
@@ -123,32 +232,137 @@ func (a *apiServer) FinishCommit(ctx context.Context, request *pfs.FinishCommitR
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
 
-	if err := a.driver.finishCommit(ctx, request.Commit); err != nil {
+	if err := a.driver.finishCommit(ctx, request.Commit, request.DataCard, request.Stage); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) PublishCommit(ctx context.Context, request *pfs.PublishCommitRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.publishCommit(ctx, request.Commit); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) ReviewCommit(ctx context.Context, request *pfs.ReviewCommitRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.reviewCommit(ctx, request.Commit, request.Decision, request.Comment); err != nil {
 		return nil, err
 	}
 	return &types.Empty{}, nil
 }
 
+func (a *apiServer) ListPendingApprovals(ctx context.Context, request *pfs.ListPendingApprovalsRequest) (response *pfs.CommitInfos, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	commitInfos, err := a.driver.listPendingApprovals(ctx, request.Repo, request.Branch)
+	if err != nil {
+		return nil, err
+	}
+	return &pfs.CommitInfos{
+		CommitInfo: commitInfos,
+	}, nil
+}
+
 func (a *apiServer) InspectCommit(ctx context.Context, request *pfs.InspectCommitRequest) (response *pfs.CommitInfo, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
 
-	return a.driver.inspectCommit(ctx, request.Commit)
+	project, err := commitInfoProjection(request.Fields, request.OmitFields)
+	if err != nil {
+		return nil, err
+	}
+	commitInfo, err := a.driver.inspectCommit(ctx, request.Commit)
+	if err != nil {
+		return nil, err
+	}
+	if request.Subvenance {
+		if commitInfo.Subvenance, err = a.driver.commitSubvenance(ctx, commitInfo.Commit); err != nil {
+			return nil, err
+		}
+	}
+	if project != nil {
+		commitInfo = project(commitInfo)
+	}
+	return commitInfo, nil
 }
 
 func (a *apiServer) ListCommit(ctx context.Context, request *pfs.ListCommitRequest) (response *pfs.CommitInfos, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
 
+	project, err := commitInfoProjection(request.Fields, request.OmitFields)
+	if err != nil {
+		return nil, err
+	}
 	commitInfos, err := a.driver.listCommit(ctx, request.Repo, request.To, request.From, request.Number)
 	if err != nil {
 		return nil, err
 	}
+	if project != nil {
+		for i, commitInfo := range commitInfos {
+			commitInfos[i] = project(commitInfo)
+		}
+	}
+	return &pfs.CommitInfos{
+		CommitInfo: commitInfos,
+	}, nil
+}
+
+func (a *apiServer) SearchDataCards(ctx context.Context, request *pfs.SearchDataCardsRequest) (response *pfs.CommitInfos, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	commitInfos, err := a.driver.searchDataCards(ctx, request.Repo, request.Query, request.License)
+	if err != nil {
+		return nil, err
+	}
 	return &pfs.CommitInfos{
 		CommitInfo: commitInfos,
 	}, nil
 }
 
+func (a *apiServer) CreateCommitHook(ctx context.Context, request *pfs.CreateCommitHookRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.createCommitHook(ctx, request.Repo, request.Name, request.Url); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) BisectCommits(ctx context.Context, request *pfs.BisectCommitsRequest) (response *pfs.BisectCommitsResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	return a.driver.bisectCommits(ctx, request.Repo, request.Branch, request.Good, request.Bad)
+}
+
+func (a *apiServer) ListCommitHook(ctx context.Context, request *pfs.ListCommitHookRequest) (response *pfs.CommitHookInfos, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	return a.driver.listCommitHook(ctx, request.Repo)
+}
+
+func (a *apiServer) DeleteCommitHook(ctx context.Context, request *pfs.DeleteCommitHookRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.deleteCommitHook(ctx, request.Repo, request.Name); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
 func (a *apiServer) ListBranch(ctx context.Context, request *pfs.ListBranchRequest) (response *pfs.BranchInfos, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
@@ -174,7 +388,7 @@ func (a *apiServer) DeleteBranch(ctx context.Context, request *pfs.DeleteBranchR
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
 
-	if err := a.driver.deleteBranch(ctx, request.Repo, request.Branch); err != nil {
+	if err := a.driver.deleteBranch(ctx, request.Repo, request.Branch, nil); err != nil {
 		return nil, err
 	}
 	return &types.Empty{}, nil
@@ -222,7 +436,7 @@ func (a *apiServer) SubscribeCommit(request *pfs.SubscribeCommitRequest, stream
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
 
-	commitStream, err := a.driver.subscribeCommit(ctx, request.Repo, request.Branch, request.From)
+	commitStream, err := a.driver.subscribeCommit(ctx, request.Repo, request.Branch, request.From, request.InvalidationHints)
 	if err != nil {
 		return err
 	}
@@ -251,8 +465,9 @@ func (a *apiServer) SubscribeCommit(request *pfs.SubscribeCommitRequest, stream
 func (a *apiServer) PutFile(putFileServer pfs.API_PutFileServer) (retErr error) {
 	ctx := putFileServer.Context()
 	defer drainFileServer(putFileServer)
+	response := &pfs.PutFileResponse{}
 	defer func() {
-		if err := putFileServer.SendAndClose(&types.Empty{}); err != nil && retErr == nil {
+		if err := putFileServer.SendAndClose(response); err != nil && retErr == nil {
 			retErr = err
 		}
 	}()
@@ -280,213 +495,631 @@ func (a *apiServer) PutFile(putFileServer pfs.API_PutFileServer) (retErr error)
 	// not cleaning the path can result in weird effects like files called
 	// ./foo which won't display correctly when the filesystem is mounted
 	request.File.Path = path.Clean(request.File.Path)
-	var r io.Reader
-	if request.Url != "" {
-		url, err := url.Parse(request.Url)
-		if err != nil {
+	ctx, done, err := a.driver.schedule(ctx, pfs.Priority_INTERACTIVE)
+	if err != nil {
+		return err
+	}
+	defer done()
+	if request.CheckCommitLocks {
+		if err := a.driver.checkCommitLocks(ctx, request.File, request.CommitLockID); err != nil {
+			return err
+		}
+	}
+	var expiring *pfs.File
+	if request.TtlSeconds != 0 {
+		if expiring, err = a.driver.expiringFile(ctx, request.File, request.TtlSeconds); err != nil {
 			return err
 		}
-		switch url.Scheme {
-		case "http":
-			fallthrough
-		case "https":
-			resp, err := http.Get(request.Url)
+	}
+	if request.Url != "" {
+		if err := a.driver.putFileURL(ctx, request.File, request.Url, request.Recursive, func(file *pfs.File, r io.Reader) error {
+			putFileResponse, err := a.driver.putFile(ctx, file, newPutFileOptions(request), r)
 			if err != nil {
 				return err
 			}
-			defer func() {
-				if err := resp.Body.Close(); err != nil && retErr == nil {
-					retErr = err
-				}
-			}()
-			r = resp.Body
-		case "pfs":
-			return a.putFilePfs(ctx, request, url)
-		default:
-			url, err := obj.ParseURL(request.Url)
-			if err != nil {
-				return fmt.Errorf("error parsing url %v: %v", request.Url, err)
-			}
-			objClient, err := obj.NewClientFromURLAndSecret(putFileServer.Context(), url)
-			if err != nil {
-				return err
+			if !request.Recursive {
+				response = putFileResponse
 			}
-			return a.putFileObj(ctx, objClient, request, url.Object)
+			return nil
+		}); err != nil {
+			return err
 		}
 	} else {
 		reader := putFileReader{
 			server: putFileServer,
 		}
-		_, err = reader.buffer.Write(request.Value)
+		if _, err := reader.buffer.Write(request.Value); err != nil {
+			return err
+		}
+		putFileResponse, err := a.driver.putFile(ctx, request.File, newPutFileOptions(request), &reader)
 		if err != nil {
 			return err
 		}
-		r = &reader
+		response = putFileResponse
+	}
+	if expiring != nil {
+		return a.driver.expirePath(ctx, expiring, request.TtlSeconds)
+	}
+	if overwritesFile(request.Mode, request.OverwriteIndex) {
+		return a.driver.clearPathExpiration(ctx, request.File)
 	}
-	return a.driver.putFile(ctx, request.File, request.Delimiter, request.TargetFileDatums, request.TargetFileBytes, request.OverwriteIndex, r)
+	return nil
 }
 
-func (a *apiServer) putFilePfs(ctx context.Context, request *pfs.PutFileRequest, url *url.URL) error {
-	pClient, err := client.NewFromAddress(url.Host)
+func (a *apiServer) PutFiles(putFilesServer pfs.API_PutFilesServer) (retErr error) {
+	ctx := putFilesServer.Context()
+	defer drainFilesServer(putFilesServer)
+	defer func() {
+		if err := putFilesServer.SendAndClose(&types.Empty{}); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	func() { a.Log(nil, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(nil, nil, retErr, time.Since(start)) }(time.Now())
+
+	ctx, done, err := a.driver.schedule(ctx, pfs.Priority_INTERACTIVE)
 	if err != nil {
 		return err
 	}
-	put := func(outPath string, inRepo string, inCommit string, inFile string) (retErr error) {
-		r, err := pClient.GetFileReader(inRepo, inCommit, inFile, 0, 0)
+	defer done()
+	batch := a.driver.newPutFilesBatch(ctx)
+	// the files put with a ttl only expire once the batch is committed, and
+	// the files overwritten without one stop expiring then
+	var expiring []*pfs.File
+	var ttls []int64
+	var overwritten []*pfs.File
+	reader := &putFilesReader{
+		server: putFilesServer,
+	}
+	for {
+		request, err := reader.nextRequest()
 		if err != nil {
 			return err
 		}
-		return a.driver.putFile(ctx, client.NewFile(request.File.Commit.Repo.Name, request.File.Commit.ID, outPath), request.Delimiter, request.TargetFileDatums, request.TargetFileBytes, request.OverwriteIndex, r)
-	}
-	splitPath := strings.Split(strings.TrimPrefix(url.Path, "/"), "/")
-	if len(splitPath) < 2 {
-		return fmt.Errorf("pfs put-file path must be of form repo/commit[/path/to/file] got: %s", url.Path)
+		if request == nil {
+			break
+		}
+		switch {
+		case request.DeleteFile != nil:
+			a.Log(request.DeleteFile, nil, nil, 0)
+			request.DeleteFile.File.Path = path.Clean(request.DeleteFile.File.Path)
+			if request.DeleteFile.Glob {
+				if err := batch.deleteFileGlob(request.DeleteFile.File); err != nil {
+					return err
+				}
+			} else if err := batch.deleteFile(request.DeleteFile.File); err != nil {
+				return err
+			}
+		case request.PutFile != nil && request.PutFile.File != nil:
+			putFile := request.PutFile
+			if putFile.Url != "" {
+				return fmt.Errorf("PutFiles doesn't support urls (got %s)", putFile.Url)
+			}
+			// We remove putFile.Value from the logs otherwise they would be
+			// too big.
+			func() {
+				value := putFile.Value
+				putFile.Value = nil
+				a.Log(putFile, nil, nil, 0)
+				putFile.Value = value
+			}()
+			putFile.File.Path = path.Clean(putFile.File.Path)
+			if putFile.CheckCommitLocks {
+				if err := a.driver.checkCommitLocks(ctx, putFile.File, putFile.CommitLockID); err != nil {
+					return err
+				}
+			}
+			if putFile.TtlSeconds != 0 {
+				file, err := a.driver.expiringFile(ctx, putFile.File, putFile.TtlSeconds)
+				if err != nil {
+					return err
+				}
+				expiring = append(expiring, file)
+				ttls = append(ttls, putFile.TtlSeconds)
+			} else if overwritesFile(putFile.Mode, putFile.OverwriteIndex) {
+				overwritten = append(overwritten, putFile.File)
+			}
+			reader.buffer.Write(putFile.Value)
+			if err := batch.putFile(putFile.File, newPutFileOptions(putFile), reader); err != nil {
+				return err
+			}
+			// make sure all of the file's data has been read, so that the
+			// next request starts a new write
+			if _, err := io.Copy(ioutil.Discard, reader); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("PutFiles request must start a new file or delete one")
+		}
 	}
-	repo := splitPath[0]
-	commit := splitPath[1]
-	file := ""
-	if len(splitPath) >= 3 {
-		file = filepath.Join(splitPath[2:]...)
+	if err := batch.commit(); err != nil {
+		return err
 	}
-	if request.Recursive {
-		var eg errgroup.Group
-		if err := pClient.Walk(splitPath[0], commit, file, func(fileInfo *pfs.FileInfo) error {
-			if fileInfo.FileType != pfs.FileType_FILE {
-				return nil
-			}
-			eg.Go(func() error {
-				return put(filepath.Join(request.File.Path, strings.TrimPrefix(fileInfo.File.Path, file)), repo, commit, fileInfo.File.Path)
-			})
-			return nil
-		}); err != nil {
+	for i, file := range expiring {
+		if err := a.driver.expirePath(ctx, file, ttls[i]); err != nil {
 			return err
 		}
-		return eg.Wait()
 	}
-	return put(request.File.Path, repo, commit, file)
-}
-
-func (a *apiServer) putFileObj(ctx context.Context, objClient obj.Client, request *pfs.PutFileRequest, object string) (retErr error) {
-	put := func(ctx context.Context, filePath string, objPath string) error {
-		logRequest := &pfs.PutFileRequest{
-			Delimiter: request.Delimiter,
-			Url:       objPath,
-			File: &pfs.File{
-				Path: filePath,
-			},
-			Recursive: request.Recursive,
-		}
-		a.Log(logRequest, nil, nil, 0)
-		defer func(start time.Time) {
-			a.Log(logRequest, nil, retErr, time.Since(start))
-		}(time.Now())
-		r, err := objClient.Reader(objPath, 0, 0)
-		if err != nil {
+	for _, file := range overwritten {
+		if err := a.driver.clearPathExpiration(ctx, file); err != nil {
 			return err
 		}
-		defer func() {
-			if err := r.Close(); err != nil && retErr == nil {
-				retErr = err
-			}
-		}()
-		return a.driver.putFile(ctx, client.NewFile(request.File.Commit.Repo.Name, request.File.Commit.ID, filePath),
-			request.Delimiter, request.TargetFileDatums, request.TargetFileBytes, request.OverwriteIndex, r)
-	}
-	if request.Recursive {
-		eg, egContext := errgroup.WithContext(ctx)
-		path := strings.TrimPrefix(object, "/")
-		sem := make(chan struct{}, client.DefaultMaxConcurrentStreams)
-		objClient.Walk(path, func(name string) error {
-			eg.Go(func() error {
-				sem <- struct{}{}
-				defer func() {
-					<-sem
-				}()
-
-				if strings.HasSuffix(name, "/") {
-					// Amazon S3 supports objs w keys that end in a '/'
-					// PFS needs to treat such a key as a directory.
-					// In this case, we rely on the driver PutFile to
-					// construct the 'directory' diffs from the file prefix
-					logrus.Warnf("ambiguous key %v, not creating a directory or putting this entry as a file", name)
-					return nil
-				}
-				return put(egContext, filepath.Join(request.File.Path, strings.TrimPrefix(name, path)), name)
-			})
-			return nil
-		})
-		return eg.Wait()
 	}
-	// Joining Host and Path to retrieve the full path after "scheme://"
-	return put(ctx, request.File.Path, object)
+	return nil
 }
 
-func (a *apiServer) CopyFile(ctx context.Context, request *pfs.CopyFileRequest) (response *types.Empty, retErr error) {
+func (a *apiServer) PutFilesFromManifest(ctx context.Context, request *pfs.PutFilesFromManifestRequest) (response *types.Empty, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
-	if err := a.driver.copyFile(ctx, request.Src, request.Dst, request.Overwrite); err != nil {
+
+	ctx, done, err := a.driver.schedule(ctx, pfs.Priority_INTERACTIVE)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	if err := a.driver.putFilesFromManifest(ctx, request.Commit, request.Entries, request.Overwrite); err != nil {
 		return nil, err
 	}
 	return &types.Empty{}, nil
 }
 
-func (a *apiServer) GetFile(request *pfs.GetFileRequest, apiGetFileServer pfs.API_GetFileServer) (retErr error) {
-	ctx := apiGetFileServer.Context()
+func (a *apiServer) PutFileByHash(ctx context.Context, request *pfs.PutFileByHashRequest) (response *pfs.PutFileByHashResponse, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
-	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
 
-	file, err := a.driver.getFile(ctx, request.File, request.OffsetBytes, request.SizeBytes)
+	ctx, done, err := a.driver.schedule(ctx, pfs.Priority_INTERACTIVE)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return grpcutil.WriteToStreamingBytesServer(file, apiGetFileServer)
+	defer done()
+	request.File.Path = path.Clean(request.File.Path)
+	written, err := a.driver.putFileByHash(ctx, request.File, request.ObjectHashes, request.Mode, request.Compression, request.SizeBytes)
+	if err != nil {
+		return nil, err
+	}
+	return &pfs.PutFileByHashResponse{Written: written}, nil
 }
 
-func (a *apiServer) InspectFile(ctx context.Context, request *pfs.InspectFileRequest) (response *pfs.FileInfo, retErr error) {
+func (a *apiServer) CopyFile(ctx context.Context, request *pfs.CopyFileRequest) (response *types.Empty, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
-
-	return a.driver.inspectFile(ctx, request.File)
+	if err := a.driver.copyFile(ctx, request.Src, request.Dst, request.Overwrite, request.OverwriteIndex); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
 }
 
-func (a *apiServer) ListFile(ctx context.Context, request *pfs.ListFileRequest) (response *pfs.FileInfos, retErr error) {
+func (a *apiServer) MoveFile(ctx context.Context, request *pfs.MoveFileRequest) (response *types.Empty, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
-	defer func(start time.Time) {
-		if response != nil && len(response.FileInfo) > client.MaxListItemsLog {
-			logrus.Infof("Response contains %d objects; logging the first %d", len(response.FileInfo), client.MaxListItemsLog)
-			a.Log(request, &pfs.FileInfos{response.FileInfo[:client.MaxListItemsLog]}, retErr, time.Since(start))
-		} else {
-			a.Log(request, response, retErr, time.Since(start))
-		}
-	}(time.Now())
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
 
-	fileInfos, err := a.driver.listFile(ctx, request.File, request.Full)
-	if err != nil {
+	if err := a.driver.moveFile(ctx, request.Src, request.Dst); err != nil {
 		return nil, err
 	}
-	return &pfs.FileInfos{
-		FileInfo: fileInfos,
-	}, nil
+	return &types.Empty{}, nil
 }
 
-func (a *apiServer) GlobFile(ctx context.Context, request *pfs.GlobFileRequest) (response *pfs.FileInfos, retErr error) {
+func (a *apiServer) CompactFile(ctx context.Context, request *pfs.CompactFileRequest) (response *pfs.CompactResponse, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
-	defer func(start time.Time) {
-		if response != nil && len(response.FileInfo) > client.MaxListItemsLog {
-			logrus.Infof("Response contains %d objects; logging the first %d", len(response.FileInfo), client.MaxListItemsLog)
-			a.Log(request, &pfs.FileInfos{response.FileInfo[:client.MaxListItemsLog]}, retErr, time.Since(start))
-		} else {
-			a.Log(request, response, retErr, time.Since(start))
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	return a.driver.compact(ctx, request.File, request.InPlace)
+}
+
+func (a *apiServer) CompactCommit(ctx context.Context, request *pfs.CompactCommitRequest) (response *pfs.CompactResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	return a.driver.compact(ctx, &pfs.File{Commit: request.Commit, Path: "/"}, request.InPlace)
+}
+
+func (a *apiServer) SetCompactInPlace(ctx context.Context, request *pfs.SetCompactInPlaceRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.setCompactInPlace(ctx, request.Repo, request.Branch, request.Allow); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) SetProtectedPaths(ctx context.Context, request *pfs.SetProtectedPathsRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.setProtectedPaths(ctx, request.Repo, request.Paths); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) SetReadFilter(ctx context.Context, request *pfs.SetReadFilterRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.setReadFilter(ctx, request.Repo, request.Filter); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) SetResidency(ctx context.Context, request *pfs.SetResidencyRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.setResidency(ctx, request.Repo, request.Residency); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) SetStorageClass(ctx context.Context, request *pfs.SetStorageClassRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.setStorageClass(ctx, request.Repo, request.StorageClass); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) SetCompactionPolicy(ctx context.Context, request *pfs.SetCompactionPolicyRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.setCompactionPolicy(ctx, request.Repo, request.Policy); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) InspectCompactionPolicy(ctx context.Context, request *pfs.InspectCompactionPolicyRequest) (response *pfs.CompactionPolicyInfo, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	return a.driver.inspectCompactionPolicy(ctx, request.Repo)
+}
+
+func (a *apiServer) SetRetentionPolicy(ctx context.Context, request *pfs.SetRetentionPolicyRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.setRetentionPolicy(ctx, request.Repo, request.Policy); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) InspectRetentionPolicy(ctx context.Context, request *pfs.InspectRetentionPolicyRequest) (response *pfs.RetentionPolicyInfo, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	return a.driver.inspectRetentionPolicy(ctx, request.Repo)
+}
+
+func (a *apiServer) PlanRetention(ctx context.Context, request *pfs.PlanRetentionRequest) (response *pfs.RetentionPlan, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	return a.driver.planRetention(ctx, request.Repo)
+}
+
+func (a *apiServer) AcquireCommitLock(ctx context.Context, request *pfs.AcquireCommitLockRequest) (response *pfs.CommitLock, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	return a.driver.acquireCommitLock(ctx, request.File, request.Owner, request.TtlSeconds)
+}
+
+func (a *apiServer) RenewCommitLock(ctx context.Context, request *pfs.RenewCommitLockRequest) (response *pfs.CommitLock, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	return a.driver.renewCommitLock(ctx, request.Commit, request.ID, request.TtlSeconds)
+}
+
+func (a *apiServer) ReleaseCommitLock(ctx context.Context, request *pfs.ReleaseCommitLockRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.releaseCommitLock(ctx, request.Commit, request.ID); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) ListCommitLock(ctx context.Context, request *pfs.ListCommitLockRequest) (response *pfs.ListCommitLockResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	locks, err := a.driver.listCommitLock(ctx, request.Commit)
+	if err != nil {
+		return nil, err
+	}
+	return &pfs.ListCommitLockResponse{Locks: locks}, nil
+}
+
+func (a *apiServer) StartUpload(ctx context.Context, request *pfs.StartUploadRequest) (response *pfs.UploadSession, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	return a.driver.startUpload(ctx, request.File, request.Mode)
+}
+
+func (a *apiServer) PutChunk(putChunkServer pfs.API_PutChunkServer) (retErr error) {
+	ctx := putChunkServer.Context()
+	defer drainChunkServer(putChunkServer)
+	request, err := putChunkServer.Recv()
+	if err != nil {
+		return err
+	}
+	// We remove request.Value from the logs otherwise they would be too big.
+	func() {
+		requestValue := request.Value
+		request.Value = nil
+		a.Log(request, nil, nil, 0)
+		request.Value = requestValue
+	}()
+	var response *pfs.UploadSession
+	defer func(start time.Time) {
+		request.Value = nil
+		a.Log(request, response, retErr, time.Since(start))
+	}(time.Now())
+	reader := &putChunkReader{
+		server: putChunkServer,
+	}
+	reader.buffer.Write(request.Value)
+	response, err = a.driver.putChunk(ctx, request.UploadID, request.Index, reader)
+	if err != nil {
+		return err
+	}
+	return putChunkServer.SendAndClose(response)
+}
+
+func (a *apiServer) InspectUpload(ctx context.Context, request *pfs.InspectUploadRequest) (response *pfs.UploadSession, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	return a.driver.inspectUpload(ctx, request.UploadID)
+}
+
+func (a *apiServer) CompleteUpload(ctx context.Context, request *pfs.CompleteUploadRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.completeUpload(ctx, request.UploadID); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) PutSymlink(ctx context.Context, request *pfs.PutSymlinkRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.putSymlink(ctx, request.File, request.Target); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) GetFile(request *pfs.GetFileRequest, apiGetFileServer pfs.API_GetFileServer) (retErr error) {
+	ctx := apiGetFileServer.Context()
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
+
+	ctx, done, err := a.driver.schedule(ctx, pfs.Priority_INTERACTIVE)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	if request.Verify {
+		file, digest, err := a.driver.getFileVerified(ctx, request.File, request.OffsetBytes, request.SizeBytes, request.FollowSymlinks)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if len(digest) > 0 {
+			if err := apiGetFileServer.SendHeader(metadata.Pairs(pfs.DigestHeader, hex.EncodeToString(digest))); err != nil {
+				return err
+			}
+		}
+		return grpcutil.WriteToStreamingBytesServer(file, apiGetFileServer)
+	}
+	file, err := a.driver.getFile(ctx, request.File, request.OffsetBytes, request.SizeBytes, request.FollowSymlinks)
+	if err != nil {
+		return err
+	}
+	return grpcutil.WriteToStreamingBytesServer(file, apiGetFileServer)
+}
+
+func (a *apiServer) GetRecords(request *pfs.GetRecordsRequest, apiGetRecordsServer pfs.API_GetRecordsServer) (retErr error) {
+	ctx := apiGetRecordsServer.Context()
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
+
+	ctx, done, err := a.driver.schedule(ctx, pfs.Priority_INTERACTIVE)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	records, err := a.driver.getRecords(ctx, request.File, request.OffsetRecords, request.NumRecords)
+	if err != nil {
+		return err
+	}
+	defer records.Close()
+	return grpcutil.WriteToStreamingBytesServer(records, apiGetRecordsServer)
+}
+
+func (a *apiServer) GetFileTar(request *pfs.GetFileTarRequest, apiGetFileTarServer pfs.API_GetFileTarServer) (retErr error) {
+	ctx := apiGetFileTarServer.Context()
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
+
+	ctx, done, err := a.driver.schedule(ctx, pfs.Priority_BATCH)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	archive, err := a.driver.getFileTar(ctx, request.File)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	return grpcutil.WriteToStreamingBytesServer(archive, apiGetFileTarServer)
+}
+
+func (a *apiServer) GrepFile(request *pfs.GrepFileRequest, apiGrepFileServer pfs.API_GrepFileServer) (retErr error) {
+	ctx := apiGrepFileServer.Context()
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
+
+	ctx, done, err := a.driver.schedule(ctx, pfs.Priority_BATCH)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	return a.driver.grepFile(ctx, request.Commit, request.Pattern, request.Regex, request.Limit, func(match *pfs.GrepMatch) error {
+		return apiGrepFileServer.Send(match)
+	})
+}
+
+func (a *apiServer) FilterFile(request *pfs.FilterFileRequest, apiFilterFileServer pfs.API_FilterFileServer) (retErr error) {
+	ctx := apiFilterFileServer.Context()
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
+
+	ctx, done, err := a.driver.schedule(ctx, pfs.Priority_INTERACTIVE)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	filtered, err := a.driver.filterFile(ctx, request.File, request.Regex, request.JmesPath)
+	if err != nil {
+		return err
+	}
+	defer filtered.Close()
+	return grpcutil.WriteToStreamingBytesServer(filtered, apiFilterFileServer)
+}
+
+func (a *apiServer) InspectFile(ctx context.Context, request *pfs.InspectFileRequest) (response *pfs.FileInfo, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	ctx, done, err := a.driver.schedule(ctx, pfs.Priority_INTERACTIVE)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	return a.driver.inspectFile(ctx, request.File)
+}
+
+func (a *apiServer) ListFile(ctx context.Context, request *pfs.ListFileRequest) (response *pfs.FileInfos, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) {
+		if response != nil && len(response.FileInfo) > client.MaxListItemsLog {
+			logrus.Infof("Response contains %d objects; logging the first %d", len(response.FileInfo), client.MaxListItemsLog)
+			a.Log(request, &pfs.FileInfos{FileInfo: response.FileInfo[:client.MaxListItemsLog], NextPageToken: response.NextPageToken}, retErr, time.Since(start))
+		} else {
+			a.Log(request, response, retErr, time.Since(start))
+		}
+	}(time.Now())
+
+	ctx, done, err := a.driver.schedule(ctx, listPriority(request.Limit))
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	fileInfos, nextPageToken, err := a.driver.listFile(ctx, request.File, request.Full, request.FollowSymlinks, request.Limit, request.PageToken, request.Mode)
+	if err != nil {
+		return nil, err
+	}
+	return &pfs.FileInfos{
+		FileInfo:      fileInfos,
+		NextPageToken: nextPageToken,
+	}, nil
+}
+
+func (a *apiServer) GlobFile(ctx context.Context, request *pfs.GlobFileRequest) (response *pfs.FileInfos, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) {
+		if response != nil && len(response.FileInfo) > client.MaxListItemsLog {
+			logrus.Infof("Response contains %d objects; logging the first %d", len(response.FileInfo), client.MaxListItemsLog)
+			a.Log(request, &pfs.FileInfos{FileInfo: response.FileInfo[:client.MaxListItemsLog], NextPageToken: response.NextPageToken}, retErr, time.Since(start))
+		} else {
+			a.Log(request, response, retErr, time.Since(start))
+		}
+	}(time.Now())
+
+	ctx, done, err := a.driver.schedule(ctx, listPriority(request.Limit))
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	fileInfos, nextPageToken, err := a.driver.globFile(ctx, request.Commit, request.Pattern, request.DirectoriesOnly, request.Limit, request.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	return &pfs.FileInfos{
+		FileInfo:      fileInfos,
+		NextPageToken: nextPageToken,
+	}, nil
+}
+
+func (a *apiServer) SearchFile(request *pfs.SearchFileRequest, apiSearchFileServer pfs.API_SearchFileServer) (retErr error) {
+	ctx := apiSearchFileServer.Context()
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
+
+	ctx, done, err := a.driver.schedule(ctx, pfs.Priority_BATCH)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	return a.driver.searchFile(ctx, request.Commit, request.Regex, func(fileInfo *pfs.FileInfo) error {
+		return apiSearchFileServer.Send(fileInfo)
+	})
+}
+
+func (a *apiServer) WalkFile(request *pfs.WalkFileRequest, apiWalkFileServer pfs.API_WalkFileServer) (retErr error) {
+	ctx := apiWalkFileServer.Context()
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
+
+	ctx, done, err := a.driver.schedule(ctx, pfs.Priority_BATCH)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	return a.driver.walkFile(ctx, request.File, func(fileInfo *pfs.FileInfo) error {
+		return apiWalkFileServer.Send(fileInfo)
+	})
+}
+
+func (a *apiServer) GetManifest(ctx context.Context, request *pfs.GetManifestRequest) (response *pfs.Manifest, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) {
+		if response != nil && len(response.Entries) > client.MaxListItemsLog {
+			logrus.Infof("Response contains %d entries; logging the first %d", len(response.Entries), client.MaxListItemsLog)
+			a.Log(request, &pfs.Manifest{Commit: response.Commit, Entries: response.Entries[:client.MaxListItemsLog], SizeBytes: response.SizeBytes}, retErr, time.Since(start))
+		} else {
+			a.Log(request, response, retErr, time.Since(start))
 		}
 	}(time.Now())
 
-	fileInfos, err := a.driver.globFile(ctx, request.Commit, request.Pattern)
+	ctx, done, err := a.driver.schedule(ctx, pfs.Priority_BATCH)
 	if err != nil {
 		return nil, err
 	}
-	return &pfs.FileInfos{
-		FileInfo: fileInfos,
-	}, nil
+	defer done()
+	return a.driver.getManifest(ctx, request.Commit, request.Globs)
 }
 
 func (a *apiServer) DiffFile(ctx context.Context, request *pfs.DiffFileRequest) (response *pfs.DiffFileResponse, retErr error) {
@@ -502,6 +1135,18 @@ func (a *apiServer) DiffFile(ctx context.Context, request *pfs.DiffFileRequest)
 			a.Log(request, response, retErr, time.Since(start))
 		}
 	}(time.Now())
+	ctx, done, err := a.driver.schedule(ctx, pfs.Priority_BATCH)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	if request.Summary {
+		summary, err := a.driver.diffFileSummary(ctx, request.NewFile, request.OldFile, request.Shallow)
+		if err != nil {
+			return nil, err
+		}
+		return &pfs.DiffFileResponse{Summary: summary}, nil
+	}
 	newFileInfos, oldFileInfos, err := a.driver.diffFile(ctx, request.NewFile, request.OldFile, request.Shallow)
 	if err != nil {
 		return nil, err
@@ -512,10 +1157,44 @@ func (a *apiServer) DiffFile(ctx context.Context, request *pfs.DiffFileRequest)
 	}, nil
 }
 
+func (a *apiServer) PreviewMerge(ctx context.Context, request *pfs.PreviewMergeRequest) (response *pfs.PreviewMergeResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	ctx, done, err := a.driver.schedule(ctx, pfs.Priority_BATCH)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	return a.driver.previewMerge(ctx, request.Ours, request.Theirs)
+}
+
+func (a *apiServer) Merge(ctx context.Context, request *pfs.MergeRequest) (response *pfs.MergeResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	ctx, done, err := a.driver.schedule(ctx, pfs.Priority_BATCH)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	return a.driver.mergeBranch(ctx, request.Repo, request.Branch, request.Theirs)
+}
+
+func (a *apiServer) ResolveConflict(ctx context.Context, request *pfs.ResolveConflictRequest) (response *pfs.MergeResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	return a.driver.resolveConflict(ctx, request.Commit, request.Path, request.Resolution)
+}
+
 func (a *apiServer) DeleteFile(ctx context.Context, request *pfs.DeleteFileRequest) (response *types.Empty, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
 
+	if request.Glob {
+		if err := a.driver.deleteFileGlob(ctx, request.File); err != nil {
+			return nil, err
+		}
+		return &types.Empty{}, nil
+	}
 	err := a.driver.deleteFile(ctx, request.File)
 	if err != nil {
 		return nil, err
@@ -523,6 +1202,62 @@ func (a *apiServer) DeleteFile(ctx context.Context, request *pfs.DeleteFileReque
 	return &types.Empty{}, nil
 }
 
+func (a *apiServer) SetSchema(ctx context.Context, request *pfs.SetSchemaRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.setSchema(ctx, request.Repo, request.Path, request.Schema); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) InspectFeatureUsage(ctx context.Context, request *types.Empty) (response *pfs.FeatureUsage, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	return a.driver.inspectFeatureUsage(ctx)
+}
+
+func (a *apiServer) ListFeatureFlags(ctx context.Context, request *pfs.ListFeatureFlagsRequest) (response *pfs.ListFeatureFlagsResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	flags, err := a.driver.listFeatureFlags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pfs.ListFeatureFlagsResponse{Flags: flags}, nil
+}
+
+func (a *apiServer) SetFeatureFlag(ctx context.Context, request *pfs.SetFeatureFlagRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.setFeatureFlag(ctx, request.Name, request.Repo, request.Setting); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) Apply(ctx context.Context, request *pfs.ApplyRequest) (response *pfs.ApplyResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	actions, err := a.driver.apply(ctx, request.Spec, request.Prune, request.DryRun)
+	if err != nil {
+		return nil, err
+	}
+	return &pfs.ApplyResponse{Actions: actions}, nil
+}
+
+func (a *apiServer) Export(ctx context.Context, request *pfs.ExportRequest) (response *pfs.ApplySpec, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	return a.driver.export(ctx, request.Repos)
+}
+
 func (a *apiServer) DeleteAll(ctx context.Context, request *types.Empty) (response *types.Empty, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
@@ -533,6 +1268,139 @@ func (a *apiServer) DeleteAll(ctx context.Context, request *types.Empty) (respon
 	return &types.Empty{}, nil
 }
 
+func (a *apiServer) RebuildObjectRefCounts(ctx context.Context, request *types.Empty) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.rebuildObjectRefCounts(ctx); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) RebuildProvenance(ctx context.Context, request *types.Empty) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.rebuildProvenance(ctx); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) RecomputeRepoSize(ctx context.Context, request *pfs.RecomputeRepoSizeRequest) (response *pfs.RecomputeRepoSizeResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	return a.driver.recomputeRepoSize(ctx, request.Repo)
+}
+
+func (a *apiServer) FsckProvenance(ctx context.Context, request *pfs.FsckProvenanceRequest) (response *pfs.FsckProvenanceResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	inconsistencies, err := a.driver.fsckProvenance(ctx, request.Fix)
+	if err != nil {
+		return nil, err
+	}
+	return &pfs.FsckProvenanceResponse{Inconsistencies: inconsistencies}, nil
+}
+
+func (a *apiServer) InspectAudit(ctx context.Context, request *types.Empty) (response *pfs.AuditReport, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	return a.driver.inspectAudit(ctx)
+}
+
+func (a *apiServer) ListAdminJobs(ctx context.Context, request *pfs.ListAdminJobsRequest) (response *pfs.AdminJobInfos, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	jobInfos, err := a.driver.listAdminJobs(ctx, request.Type)
+	if err != nil {
+		return nil, err
+	}
+	return &pfs.AdminJobInfos{JobInfo: jobInfos}, nil
+}
+
+func (a *apiServer) InspectAdminJob(ctx context.Context, request *pfs.InspectAdminJobRequest) (response *pfs.AdminJobInfo, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	return a.driver.inspectAdminJob(ctx, request.Id)
+}
+
+func (a *apiServer) CancelAdminJob(ctx context.Context, request *pfs.CancelAdminJobRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.cancelAdminJob(ctx, request.Id); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) UpdateRepoMetadata(ctx context.Context, request *pfs.UpdateRepoMetadataRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.updateRepoMetadata(ctx, request.Repo, request.Labels, request.Annotations, request.RemoveLabels, request.RemoveAnnotations); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) SetRepoQuota(ctx context.Context, request *pfs.SetRepoQuotaRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.setRepoQuota(ctx, request.Repo, request.Quota); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) ListRepoUsage(ctx context.Context, request *pfs.ListRepoUsageRequest) (response *pfs.RepoUsages, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	usage, err := a.driver.listRepoUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pfs.RepoUsages{Usage: usage}, nil
+}
+
+func (a *apiServer) SetMetadataExport(ctx context.Context, request *pfs.SetMetadataExportRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.setMetadataExport(ctx, request.Export); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) InspectMetadataExport(ctx context.Context, request *types.Empty) (response *pfs.MetadataExportInfo, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	return a.driver.inspectMetadataExport(ctx)
+}
+
+func (a *apiServer) ExportMetadata(ctx context.Context, request *types.Empty) (response *pfs.Commit, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	ctx, done, err := a.driver.schedule(ctx, pfs.Priority_BATCH)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	return a.driver.exportMetadata(ctx)
+}
+
 type putFileReader struct {
 	server pfs.API_PutFileServer
 	buffer bytes.Buffer
@@ -550,6 +1418,103 @@ func (r *putFileReader) Read(p []byte) (int, error) {
 	return r.buffer.Read(p)
 }
 
+// putChunkReader reads the data of a chunk from a PutChunk stream.
+type putChunkReader struct {
+	server pfs.API_PutChunkServer
+	buffer bytes.Buffer
+}
+
+func (r *putChunkReader) Read(p []byte) (int, error) {
+	if r.buffer.Len() == 0 {
+		request, err := r.server.Recv()
+		if err != nil {
+			return 0, err
+		}
+		//buffer.Write cannot error
+		r.buffer.Write(request.Value)
+	}
+	return r.buffer.Read(p)
+}
+
+type importRepoReader struct {
+	server pfs.API_ImportRepoServer
+	buffer bytes.Buffer
+}
+
+func (r *importRepoReader) Read(p []byte) (int, error) {
+	if r.buffer.Len() == 0 {
+		request, err := r.server.Recv()
+		if err != nil {
+			return 0, err
+		}
+		//buffer.Write cannot error
+		r.buffer.Write(request.Value)
+	}
+	return r.buffer.Read(p)
+}
+
+// putFilesReader reads the contents of the file currently being written by a
+// PutFiles stream. It returns io.EOF when it reaches a request that starts a
+// new write, which is then returned by nextRequest.
+type putFilesReader struct {
+	server pfs.API_PutFilesServer
+	buffer bytes.Buffer
+	next   *pfs.PutFilesRequest
+	done   bool
+}
+
+func (r *putFilesReader) Read(p []byte) (int, error) {
+	for r.buffer.Len() == 0 {
+		if r.next != nil || r.done {
+			return 0, io.EOF
+		}
+		request, err := r.server.Recv()
+		if err == io.EOF {
+			r.done = true
+			return 0, io.EOF
+		}
+		if err != nil {
+			return 0, err
+		}
+		if request.PutFile == nil || request.PutFile.File != nil {
+			r.next = request
+			return 0, io.EOF
+		}
+		//buffer.Write cannot error
+		r.buffer.Write(request.PutFile.Value)
+	}
+	return r.buffer.Read(p)
+}
+
+// nextRequest returns the request that starts the next write in the stream,
+// or nil if there are no more writes.
+func (r *putFilesReader) nextRequest() (*pfs.PutFilesRequest, error) {
+	if r.next != nil {
+		request := r.next
+		r.next = nil
+		return request, nil
+	}
+	if r.done {
+		return nil, nil
+	}
+	request, err := r.server.Recv()
+	if err == io.EOF {
+		r.done = true
+		return nil, nil
+	}
+	return request, err
+}
+
+func drainFilesServer(putFilesServer interface {
+	Recv() (*pfs.PutFilesRequest, error)
+}) {
+	for {
+		if _, err := putFilesServer.Recv(); err != nil {
+			break
+		}
+	}
+}
+
 func drainFileServer(putFileServer interface {
 	Recv() (*pfs.PutFileRequest, error)
 }) {
@@ -560,6 +1525,26 @@ func drainFileServer(putFileServer interface {
 	}
 }
 
+func drainChunkServer(putChunkServer interface {
+	Recv() (*pfs.PutChunkRequest, error)
+}) {
+	for {
+		if _, err := putChunkServer.Recv(); err != nil {
+			break
+		}
+	}
+}
+
+func drainImportRepoServer(importRepoServer interface {
+	Recv() (*pfs.ImportRepoRequest, error)
+}) {
+	for {
+		if _, err := importRepoServer.Recv(); err != nil {
+			break
+		}
+	}
+}
+
 func truncateFiles(fileInfos []*pfs.FileInfo) []*pfs.FileInfo {
 	if len(fileInfos) > client.MaxListItemsLog {
 		return fileInfos[:client.MaxListItemsLog]
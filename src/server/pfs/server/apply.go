@@ -0,0 +1,509 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+)
+
+// applyStep is an action planned by apply, along with the function that
+// takes it.
+type applyStep struct {
+	action *pfs.ApplyAction
+	take   func() error
+}
+
+// apply reconciles the repos in spec, and their branches, ACLs, residency
+// tags and compaction policies, with the cluster.
+// The actions are planned up front and then taken one at a time, so if one
+// fails the ones before it have already been taken; applying the same spec
+// again picks up where it left off.
+func (d *driver) apply(ctx context.Context, spec *pfs.ApplySpec, prune bool, dryRun bool) ([]*pfs.ApplyAction, error) {
+	steps, err := d.planApply(ctx, spec, prune)
+	if err != nil {
+		return nil, err
+	}
+	d.featureUsage.inc("apply")
+	var actions []*pfs.ApplyAction
+	for _, step := range steps {
+		if !dryRun {
+			if err := step.take(); err != nil {
+				return nil, fmt.Errorf("error applying %s: %v", describeApplyAction(step.action), err)
+			}
+		}
+		actions = append(actions, step.action)
+	}
+	return actions, nil
+}
+
+func (d *driver) planApply(ctx context.Context, spec *pfs.ApplySpec, prune bool) ([]*applyStep, error) {
+	if spec == nil {
+		spec = &pfs.ApplySpec{}
+	}
+	repoInfos, err := d.listRepo(ctx, nil, "", true, !includeAuth)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]*pfs.RepoInfo)
+	for _, repoInfo := range repoInfos.RepoInfo {
+		existing[repoInfo.Repo.Name] = repoInfo
+	}
+	specs := make(map[string]*pfs.RepoSpec)
+	for _, repoSpec := range spec.Repos {
+		if repoSpec.Repo == nil {
+			return nil, fmt.Errorf("every repo in the spec needs a name")
+		}
+		if err := ValidateRepoName(repoSpec.Repo.Name); err != nil {
+			return nil, err
+		}
+		if specs[repoSpec.Repo.Name] != nil {
+			return nil, fmt.Errorf("repo %s is in the spec more than once", repoSpec.Repo.Name)
+		}
+		if err := d.checkResidency("repo "+repoSpec.Repo.Name, repoSpec.Residency); err != nil {
+			return nil, err
+		}
+		specs[repoSpec.Repo.Name] = repoSpec
+	}
+	for _, repoSpec := range spec.Repos {
+		for _, prov := range repoSpec.Provenance {
+			if specs[prov.Name] == nil && (prune || existing[prov.Name] == nil) {
+				return nil, fmt.Errorf("the provenance of repo %s, %s, isn't in the spec", repoSpec.Repo.Name, prov.Name)
+			}
+		}
+	}
+
+	var steps []*applyStep
+	// new repos are created once their provenance has been
+	created := make(map[string]bool)
+	var toCreate []*pfs.RepoSpec
+	for _, repoSpec := range spec.Repos {
+		if existing[repoSpec.Repo.Name] == nil {
+			toCreate = append(toCreate, repoSpec)
+		}
+	}
+	for len(toCreate) > 0 {
+		var next []*pfs.RepoSpec
+	nextRepo:
+		for _, repoSpec := range toCreate {
+			for _, prov := range repoSpec.Provenance {
+				if existing[prov.Name] == nil && !created[prov.Name] {
+					next = append(next, repoSpec)
+					continue nextRepo
+				}
+			}
+			repoSpec := repoSpec
+			created[repoSpec.Repo.Name] = true
+			steps = append(steps, &applyStep{
+				action: &pfs.ApplyAction{
+					Type:   pfs.ApplyAction_CREATE,
+					Repo:   repoSpec.Repo,
+					Detail: fmt.Sprintf("provenance: [%s]", repoNames(repoSpec.Provenance)),
+				},
+				take: func() error {
+					return d.createRepo(ctx, repoSpec.Repo, repoSpec.Provenance, repoSpec.Description, false, 0, nil, pfs.Compression_UNCOMPRESSED)
+				},
+			})
+		}
+		if len(next) == len(toCreate) {
+			return nil, fmt.Errorf("the provenance of the new repos in the spec is cyclic")
+		}
+		toCreate = next
+	}
+
+	for _, repoSpec := range spec.Repos {
+		repoSpec := repoSpec
+		repoInfo := existing[repoSpec.Repo.Name]
+		if repoInfo == nil {
+			if len(repoSpec.Branches) > 0 {
+				return nil, fmt.Errorf("repo %s is new, so it has no commits for its branches to point to", repoSpec.Repo.Name)
+			}
+			settingSteps, err := d.planApplySettings(ctx, repoSpec, true, prune)
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, settingSteps...)
+			continue
+		}
+		var changes []string
+		if repoInfo.Description != repoSpec.Description {
+			changes = append(changes, fmt.Sprintf("description: %q -> %q", repoInfo.Description, repoSpec.Description))
+		}
+		oldProvenance := repoNames(immediateProvenance(repoInfo))
+		newProvenance := repoNames(repoSpec.Provenance)
+		if oldProvenance != newProvenance {
+			changes = append(changes, fmt.Sprintf("provenance: [%s] -> [%s]", oldProvenance, newProvenance))
+		}
+		if len(changes) > 0 {
+			steps = append(steps, &applyStep{
+				action: &pfs.ApplyAction{
+					Type:   pfs.ApplyAction_UPDATE,
+					Repo:   repoSpec.Repo,
+					Detail: strings.Join(changes, ", "),
+				},
+				take: func() error {
+					return d.createRepo(ctx, repoSpec.Repo, repoSpec.Provenance, repoSpec.Description, true, 0, nil, repoInfo.Compression)
+				},
+			})
+		}
+
+		settingSteps, err := d.planApplySettings(ctx, repoSpec, false, prune)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, settingSteps...)
+
+		branchSteps, err := d.planApplyBranches(ctx, repoSpec, prune)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, branchSteps...)
+	}
+
+	if prune {
+		// a repo is deleted before its provenance, whose full provenance is
+		// a strict subset of its own
+		var toDelete []*pfs.RepoInfo
+		for name, repoInfo := range existing {
+			if specs[name] == nil {
+				toDelete = append(toDelete, repoInfo)
+			}
+		}
+		sort.Slice(toDelete, func(i, j int) bool {
+			if len(toDelete[i].Provenance) != len(toDelete[j].Provenance) {
+				return len(toDelete[i].Provenance) > len(toDelete[j].Provenance)
+			}
+			return toDelete[i].Repo.Name < toDelete[j].Repo.Name
+		})
+		for _, repoInfo := range toDelete {
+			repo := repoInfo.Repo
+			steps = append(steps, &applyStep{
+				action: &pfs.ApplyAction{
+					Type: pfs.ApplyAction_DELETE,
+					Repo: repo,
+				},
+				take: func() error {
+					return d.deleteRepo(ctx, repo, false)
+				},
+			})
+		}
+	}
+	return steps, nil
+}
+
+// planApplyBranches plans the actions that reconcile the branches of an
+// existing repo with repoSpec.
+func (d *driver) planApplyBranches(ctx context.Context, repoSpec *pfs.RepoSpec, prune bool) ([]*applyStep, error) {
+	repo := repoSpec.Repo
+	branchInfos, err := d.listBranch(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	heads := make(map[string]string)
+	for _, branchInfo := range branchInfos {
+		heads[branchInfo.Name] = branchInfo.Head.ID
+	}
+	var steps []*applyStep
+	inSpec := make(map[string]bool)
+	for _, branchSpec := range repoSpec.Branches {
+		if inSpec[branchSpec.Name] {
+			return nil, fmt.Errorf("branch %s of repo %s is in the spec more than once", branchSpec.Name, repo.Name)
+		}
+		inSpec[branchSpec.Name] = true
+		commitInfo, err := d.inspectCommit(ctx, &pfs.Commit{Repo: repo, ID: branchSpec.Head})
+		if err != nil {
+			return nil, fmt.Errorf("error resolving the head of branch %s of repo %s: %v", branchSpec.Name, repo.Name, err)
+		}
+		commit := commitInfo.Commit
+		name := branchSpec.Name
+		action := &pfs.ApplyAction{
+			Repo:   repo,
+			Branch: name,
+		}
+		head, ok := heads[name]
+		switch {
+		case !ok:
+			action.Type = pfs.ApplyAction_CREATE
+			action.Detail = fmt.Sprintf("head: %s", commit.ID)
+		case head != commit.ID:
+			action.Type = pfs.ApplyAction_UPDATE
+			action.Detail = fmt.Sprintf("head: %s -> %s", head, commit.ID)
+		default:
+			continue
+		}
+		steps = append(steps, &applyStep{
+			action: action,
+			take: func() error {
+				return d.setBranch(ctx, commit, name)
+			},
+		})
+	}
+	if prune {
+		for _, branchInfo := range branchInfos {
+			if inSpec[branchInfo.Name] {
+				continue
+			}
+			name := branchInfo.Name
+			steps = append(steps, &applyStep{
+				action: &pfs.ApplyAction{
+					Type:   pfs.ApplyAction_DELETE,
+					Repo:   repo,
+					Branch: name,
+				},
+				take: func() error {
+					return d.deleteBranch(ctx, repo, name, nil)
+				},
+			})
+		}
+	}
+	return steps, nil
+}
+
+// planApplySettings plans the actions that reconcile the ACL, residency tags,
+// storage class and compaction policy of a repo with repoSpec. A new repo has
+// no policy, tags or class yet, and its ACL is the one it's created with.
+func (d *driver) planApplySettings(ctx context.Context, repoSpec *pfs.RepoSpec, isNew bool, prune bool) ([]*applyStep, error) {
+	repo := repoSpec.Repo
+	var steps []*applyStep
+	if len(repoSpec.Residency) > 0 || prune {
+		var oldResidency []string
+		if !isNew {
+			repoInfo := new(pfs.RepoInfo)
+			if err := d.repos.ReadOnly(ctx).Get(repo.Name, repoInfo); err != nil {
+				return nil, err
+			}
+			oldResidency = repoInfo.Residency
+		}
+		residency, err := normalizeResidency(repoSpec.Residency)
+		if err != nil {
+			return nil, err
+		}
+		if strings.Join(oldResidency, ",") != strings.Join(residency, ",") {
+			steps = append(steps, &applyStep{
+				action: &pfs.ApplyAction{
+					Type:   pfs.ApplyAction_UPDATE,
+					Repo:   repo,
+					Detail: fmt.Sprintf("residency: [%s] -> [%s]", strings.Join(oldResidency, " "), strings.Join(residency, " ")),
+				},
+				take: func() error {
+					return d.setResidency(ctx, repo, residency)
+				},
+			})
+		}
+	}
+	if repoSpec.StorageClass != "" || prune {
+		var oldStorageClass string
+		if !isNew {
+			repoInfo := new(pfs.RepoInfo)
+			if err := d.repos.ReadOnly(ctx).Get(repo.Name, repoInfo); err != nil {
+				return nil, err
+			}
+			oldStorageClass = repoInfo.StorageClass
+		}
+		if err := checkStorageClass(repoSpec.StorageClass); err != nil {
+			return nil, err
+		}
+		if oldStorageClass != repoSpec.StorageClass {
+			storageClass := repoSpec.StorageClass
+			steps = append(steps, &applyStep{
+				action: &pfs.ApplyAction{
+					Type:   pfs.ApplyAction_UPDATE,
+					Repo:   repo,
+					Detail: fmt.Sprintf("storage class: %q -> %q", oldStorageClass, storageClass),
+				},
+				take: func() error {
+					return d.setStorageClass(ctx, repo, storageClass)
+				},
+			})
+		}
+	}
+	if repoSpec.ACL != nil {
+		var oldACL *auth.ACL
+		if !isNew {
+			var err error
+			oldACL, err = d.repoACL(ctx, repo)
+			if err != nil {
+				return nil, err
+			}
+			if oldACL == nil {
+				return nil, fmt.Errorf("repo %s has an ACL in the spec, but auth isn't activated", repo.Name)
+			}
+		}
+		if isNew || aclEntries(oldACL) != aclEntries(repoSpec.ACL) {
+			acl := repoSpec.ACL
+			steps = append(steps, &applyStep{
+				action: &pfs.ApplyAction{
+					Type:   pfs.ApplyAction_UPDATE,
+					Repo:   repo,
+					Detail: fmt.Sprintf("acl: [%s] -> [%s]", aclEntries(oldACL), aclEntries(acl)),
+				},
+				take: func() error {
+					_, err := d.pachClient.AuthAPIClient.SetACL(auth.In2Out(ctx), &auth.SetACLRequest{
+						Repo:   repo.Name,
+						NewACL: acl,
+					})
+					return grpcutil.ScrubGRPC(err)
+				},
+			})
+		}
+	}
+
+	if repoSpec.CompactionPolicy == nil && !prune {
+		return steps, nil
+	}
+	var oldPolicy *pfs.CompactionPolicy
+	if !isNew {
+		var err error
+		oldPolicy, err = d.repoCompactionPolicy(ctx, repo)
+		if err != nil {
+			return nil, err
+		}
+	}
+	policy := repoSpec.CompactionPolicy
+	if (policy == nil && oldPolicy == nil) || (policy != nil && oldPolicy != nil && proto.Equal(policy, oldPolicy)) {
+		return steps, nil
+	}
+	steps = append(steps, &applyStep{
+		action: &pfs.ApplyAction{
+			Type:   pfs.ApplyAction_UPDATE,
+			Repo:   repo,
+			Detail: fmt.Sprintf("compaction policy: {%s} -> {%s}", describePolicy(oldPolicy), describePolicy(policy)),
+		},
+		take: func() error {
+			return d.setCompactionPolicy(ctx, repo, policy)
+		},
+	})
+	return steps, nil
+}
+
+// export returns the spec of repos, or of every repo if there are none, in
+// the format that apply takes. The provenance of a repo is its immediate
+// provenance, and its branches point to the IDs of their heads.
+func (d *driver) export(ctx context.Context, repos []*pfs.Repo) (*pfs.ApplySpec, error) {
+	var repoInfos []*pfs.RepoInfo
+	if len(repos) == 0 {
+		resp, err := d.listRepo(ctx, nil, "", true, !includeAuth)
+		if err != nil {
+			return nil, err
+		}
+		repoInfos = resp.RepoInfo
+	}
+	for _, repo := range repos {
+		repoInfo, err := d.inspectRepo(ctx, repo, !includeAuth)
+		if err != nil {
+			return nil, err
+		}
+		repoInfos = append(repoInfos, repoInfo)
+	}
+	d.featureUsage.inc("export")
+
+	spec := &pfs.ApplySpec{}
+	for _, repoInfo := range repoInfos {
+		repo := repoInfo.Repo
+		repoSpec := &pfs.RepoSpec{
+			Repo:         repo,
+			Description:  repoInfo.Description,
+			Provenance:   immediateProvenance(repoInfo),
+			Residency:    repoInfo.Residency,
+			StorageClass: repoInfo.StorageClass,
+		}
+		branchInfos, err := d.listBranch(ctx, repo)
+		if err != nil {
+			return nil, err
+		}
+		for _, branchInfo := range branchInfos {
+			repoSpec.Branches = append(repoSpec.Branches, &pfs.BranchSpec{
+				Name: branchInfo.Name,
+				Head: branchInfo.Head.ID,
+			})
+		}
+		sort.Slice(repoSpec.Branches, func(i, j int) bool {
+			return repoSpec.Branches[i].Name < repoSpec.Branches[j].Name
+		})
+		if repoSpec.ACL, err = d.repoACL(ctx, repo); err != nil {
+			return nil, err
+		}
+		if repoSpec.CompactionPolicy, err = d.repoCompactionPolicy(ctx, repo); err != nil {
+			return nil, err
+		}
+		spec.Repos = append(spec.Repos, repoSpec)
+	}
+	sort.Slice(spec.Repos, func(i, j int) bool {
+		return spec.Repos[i].Repo.Name < spec.Repos[j].Repo.Name
+	})
+	return spec, nil
+}
+
+// repoACL returns the ACL of repo, or nil if auth isn't activated.
+func (d *driver) repoACL(ctx context.Context, repo *pfs.Repo) (*auth.ACL, error) {
+	resp, err := d.pachClient.AuthAPIClient.GetACL(auth.In2Out(ctx), &auth.GetACLRequest{
+		Repo: repo.Name,
+	})
+	if err != nil {
+		if auth.IsNotActivatedError(err) {
+			return nil, nil
+		}
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	if resp.ACL == nil {
+		return &auth.ACL{}, nil
+	}
+	return resp.ACL, nil
+}
+
+// repoCompactionPolicy returns the compaction policy of repo, or nil if it
+// doesn't have one.
+func (d *driver) repoCompactionPolicy(ctx context.Context, repo *pfs.Repo) (*pfs.CompactionPolicy, error) {
+	policyInfo, err := d.inspectCompactionPolicy(ctx, repo)
+	if err != nil {
+		if col.IsErrNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return policyInfo.Policy, nil
+}
+
+// aclEntries returns the sorted entries of acl, as user:scope separated by
+// spaces.
+func aclEntries(acl *auth.ACL) string {
+	var entries []string
+	if acl != nil {
+		for user, scope := range acl.Entries {
+			entries = append(entries, fmt.Sprintf("%s:%s", user, scope))
+		}
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, " ")
+}
+
+func describePolicy(policy *pfs.CompactionPolicy) string {
+	if policy == nil {
+		return "none"
+	}
+	return proto.CompactTextString(policy)
+}
+
+// repoNames returns the sorted names of repos, separated by spaces.
+func repoNames(repos []*pfs.Repo) string {
+	var names []string
+	for _, repo := range repos {
+		names = append(names, repo.Name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, " ")
+}
+
+func describeApplyAction(action *pfs.ApplyAction) string {
+	verb := strings.ToLower(action.Type.String())
+	if action.Branch != "" {
+		return fmt.Sprintf("%s branch %s of repo %s", verb, action.Branch, action.Repo.Name)
+	}
+	return fmt.Sprintf("%s repo %s", verb, action.Repo.Name)
+}
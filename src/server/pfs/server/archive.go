@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+)
+
+// archiveRepo archives repo, see RepoInfo.archived. Only its owners can
+// archive it, and it can't have any open commits, as they couldn't be written
+// to any more.
+func (d *driver) archiveRepo(ctx context.Context, repo *pfs.Repo) error {
+	if err := d.checkIsAuthorized(ctx, repo, auth.Scope_OWNER); err != nil {
+		return err
+	}
+	iter, err := d.openCommits.ReadOnly(ctx).List()
+	if err != nil {
+		return err
+	}
+	for {
+		var commitID string
+		commit := &pfs.Commit{}
+		ok, err := iter.Next(&commitID, commit)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		if commit.Repo.Name == repo.Name {
+			return fmt.Errorf("repo %s can't be archived while commit %s is open", repo.Name, commit.ID)
+		}
+	}
+	d.featureUsage.inc("archive_repo")
+	_, err = col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		repos := d.repos.ReadWrite(stm)
+		repoInfo := new(pfs.RepoInfo)
+		if err := repos.Get(repo.Name, repoInfo); err != nil {
+			return err
+		}
+		if repoInfo.Archived != nil {
+			return nil
+		}
+		repoInfo.Archived = now()
+		return repos.Put(repo.Name, repoInfo)
+	})
+	return err
+}
+
+// unarchiveRepo makes repo, which is archived, writable and listed again.
+func (d *driver) unarchiveRepo(ctx context.Context, repo *pfs.Repo) error {
+	if err := d.checkIsAuthorized(ctx, repo, auth.Scope_OWNER); err != nil {
+		return err
+	}
+	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		repos := d.repos.ReadWrite(stm)
+		repoInfo := new(pfs.RepoInfo)
+		if err := repos.Get(repo.Name, repoInfo); err != nil {
+			return err
+		}
+		if repoInfo.Archived == nil {
+			return nil
+		}
+		repoInfo.Archived = nil
+		return repos.Put(repo.Name, repoInfo)
+	})
+	return err
+}
+
+// checkNotArchived returns ErrRepoArchived if repo is archived.
+func (d *driver) checkNotArchived(ctx context.Context, repo *pfs.Repo) error {
+	repoInfo := new(pfs.RepoInfo)
+	if err := d.repos.ReadOnly(ctx).Get(repo.Name, repoInfo); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil // the operation fails later, with a clearer error
+		}
+		return err
+	}
+	if repoInfo.Archived != nil {
+		return pfsserver.ErrRepoArchived{Repo: repo}
+	}
+	return nil
+}
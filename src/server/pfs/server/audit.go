@@ -0,0 +1,340 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// auditTimeout bounds how long the startup audit runs for
+	auditTimeout = 5 * time.Minute
+
+	// auditMaxReads bounds the number of items that the startup audit reads
+	// from etcd
+	auditMaxReads = 100000
+
+	// auditMaxFindings bounds the number of findings that the startup audit
+	// reports
+	auditMaxFindings = 1000
+)
+
+// errAuditBounds is returned when the startup audit reaches its bounds.
+var errAuditBounds = errors.New("the audit reached its bounds")
+
+// startupAudit holds the report of the startup audit. Reports aren't changed
+// once they're set, a running audit sets a new one when it's done.
+type startupAudit struct {
+	mu     sync.Mutex
+	report *pfs.AuditReport
+}
+
+func (a *startupAudit) set(report *pfs.AuditReport) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.report = report
+}
+
+func (a *startupAudit) get() *pfs.AuditReport {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.report
+}
+
+// findingCounts returns the number of findings of each type, by the type's
+// name in lower case, for feature telemetry.
+func (a *startupAudit) findingCounts() map[string]int64 {
+	report := a.get()
+	if report == nil || len(report.Findings) == 0 {
+		return nil
+	}
+	result := make(map[string]int64)
+	for _, finding := range report.Findings {
+		result[strings.ToLower(finding.Type.String())]++
+	}
+	return result
+}
+
+func (d *driver) inspectAudit(ctx context.Context) (*pfs.AuditReport, error) {
+	whoAmI, err := d.pachClient.AuthAPIClient.WhoAmI(auth.In2Out(ctx), &auth.WhoAmIRequest{})
+	if err != nil && !auth.IsNotActivatedError(err) {
+		return nil, grpcutil.ScrubGRPC(err)
+	} else if err == nil && !whoAmI.IsAdmin {
+		return nil, fmt.Errorf("only cluster admins can inspect the audit")
+	}
+	report := d.audit.get()
+	if report == nil {
+		return nil, fmt.Errorf("the audit hasn't started yet")
+	}
+	return report, nil
+}
+
+// runStartupAudit checks that branch heads are commits that exist and can be
+// finished, that the commits tracked as open are, and that the repo ref
+// counts are sane, reading everything at one etcd revision. It's run by every
+// pachd when it starts, within auditTimeout and auditMaxReads, so that
+// inconsistencies are surfaced before users run into them.
+func (d *driver) runStartupAudit(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, auditTimeout)
+	defer cancel()
+	a := &auditRun{
+		d:      d,
+		ctx:    ctx,
+		report: &pfs.AuditReport{Started: now()},
+	}
+	d.audit.set(&pfs.AuditReport{Started: a.report.Started})
+	err := a.run()
+	if err == errAuditBounds || ctx.Err() != nil {
+		a.report.Truncated = true
+	} else if err != nil {
+		log.Errorf("error running the startup audit: %v", err)
+		a.report.Error = err.Error()
+	}
+	a.report.Finished = now()
+	d.audit.set(a.report)
+	if len(a.report.Findings) > 0 {
+		log.Warnf("the startup audit found %d inconsistencies, see InspectAudit", len(a.report.Findings))
+	}
+}
+
+// auditRun is a run of the startup audit.
+type auditRun struct {
+	d      *driver
+	ctx    context.Context
+	report *pfs.AuditReport
+	reads  int
+	// rev is the etcd revision that everything is read at, so that the
+	// audit doesn't report inconsistencies between reads made at different
+	// times, e.g. a branch whose head was deleted after it was read
+	rev int64
+}
+
+// read counts a read from etcd, failing once auditMaxReads are counted.
+func (a *auditRun) read() error {
+	a.reads++
+	if a.reads > auditMaxReads {
+		return errAuditBounds
+	}
+	return nil
+}
+
+// find adds a finding to the report, failing once it has auditMaxFindings.
+func (a *auditRun) find(finding *pfs.AuditFinding) error {
+	if len(a.report.Findings) >= auditMaxFindings {
+		return errAuditBounds
+	}
+	a.report.Findings = append(a.report.Findings, finding)
+	return nil
+}
+
+func (a *auditRun) run() error {
+	resp, err := a.d.etcdClient.Get(a.ctx, a.d.prefix, etcd.WithPrefix(), etcd.WithCountOnly())
+	if err != nil {
+		return err
+	}
+	a.rev = resp.Header.Revision
+	repoInfos := make(map[string]*pfs.RepoInfo)
+	iter, err := a.d.repos.ReadOnlyAt(a.ctx, a.rev).List()
+	if err != nil {
+		return err
+	}
+	for {
+		var name string
+		repoInfo := new(pfs.RepoInfo)
+		ok, err := iter.Next(&name, repoInfo)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		if err := a.read(); err != nil {
+			return err
+		}
+		repoInfos[repoInfo.Repo.Name] = repoInfo
+	}
+	for _, repoInfo := range repoInfos {
+		if err := a.auditBranches(repoInfo.Repo); err != nil {
+			return err
+		}
+	}
+	if err := a.auditOpenCommits(repoInfos); err != nil {
+		return err
+	}
+	return a.auditRefCounts(repoInfos)
+}
+
+// auditBranches checks that the head of each branch of repo exists and, if
+// it's open, that it's tracked as open.
+func (a *auditRun) auditBranches(repo *pfs.Repo) error {
+	iter, err := a.d.branches(repo.Name).ReadOnlyAt(a.ctx, a.rev).List()
+	if err != nil {
+		return err
+	}
+	for {
+		var branch string
+		head := new(pfs.Commit)
+		ok, err := iter.Next(&branch, head)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		branch = path.Base(branch)
+		if err := a.read(); err != nil {
+			return err
+		}
+		commitInfo := new(pfs.CommitInfo)
+		if err := a.d.commits(repo.Name).ReadOnlyAt(a.ctx, a.rev).Get(head.ID, commitInfo); err != nil {
+			if !col.IsErrNotFound(err) {
+				return err
+			}
+			if err := a.find(&pfs.AuditFinding{
+				Type:   pfs.AuditFinding_MISSING_BRANCH_HEAD,
+				Repo:   repo,
+				Branch: branch,
+				Commit: head,
+				Repair: "point the branch at a commit that exists with SetBranch, or delete it",
+			}); err != nil {
+				return err
+			}
+			continue
+		}
+		if commitInfo.Finished != nil {
+			continue
+		}
+		if err := a.read(); err != nil {
+			return err
+		}
+		if err := a.d.openCommits.ReadOnlyAt(a.ctx, a.rev).Get(head.ID, new(pfs.Commit)); err != nil {
+			if !col.IsErrNotFound(err) {
+				return err
+			}
+			if err := a.find(&pfs.AuditFinding{
+				Type:   pfs.AuditFinding_UNTRACKED_OPEN_HEAD,
+				Repo:   repo,
+				Branch: branch,
+				Commit: head,
+				Repair: "delete the commit with DeleteCommit, its branches move back to its parent",
+			}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// auditOpenCommits checks that the commits tracked as open exist, in repos
+// that exist, and are open.
+func (a *auditRun) auditOpenCommits(repoInfos map[string]*pfs.RepoInfo) error {
+	iter, err := a.d.openCommits.ReadOnlyAt(a.ctx, a.rev).List()
+	if err != nil {
+		return err
+	}
+	for {
+		var key string
+		commit := new(pfs.Commit)
+		ok, err := iter.Next(&key, commit)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		if err := a.read(); err != nil {
+			return err
+		}
+		var detail string
+		if _, ok := repoInfos[commit.Repo.Name]; !ok {
+			detail = "its repo doesn't exist"
+		}
+		if detail == "" {
+			if err := a.read(); err != nil {
+				return err
+			}
+			commitInfo := new(pfs.CommitInfo)
+			if err := a.d.commits(commit.Repo.Name).ReadOnlyAt(a.ctx, a.rev).Get(commit.ID, commitInfo); err != nil {
+				if !col.IsErrNotFound(err) {
+					return err
+				}
+				detail = "it doesn't exist"
+			} else if commitInfo.Finished != nil {
+				detail = "it's finished"
+			}
+		}
+		if detail == "" {
+			continue
+		}
+		if err := a.find(&pfs.AuditFinding{
+			Type:   pfs.AuditFinding_ORPHANED_OPEN_COMMIT,
+			Repo:   commit.Repo,
+			Commit: commit,
+			Detail: detail,
+			Repair: fmt.Sprintf("nothing refers to the entry, it can be removed from etcd at %s", a.d.openCommits.Path(key)),
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+// auditRefCounts checks the ref count of each repo against the full
+// provenance of the others.
+func (a *auditRun) auditRefCounts(repoInfos map[string]*pfs.RepoInfo) error {
+	expected := make(map[string]int)
+	for _, repoInfo := range repoInfos {
+		for _, prov := range repoInfo.Provenance {
+			expected[prov.Name]++
+		}
+	}
+	refCounts := make(map[string]int)
+	missing := make(map[string]bool)
+	for name, repoInfo := range repoInfos {
+		if err := a.read(); err != nil {
+			return err
+		}
+		// ref counts are stored as plain integers, which collections can
+		// only read in a transaction, at the latest revision
+		resp, err := a.d.etcdClient.Get(a.ctx, a.d.repoRefCounts.Path(name), etcd.WithRev(a.rev))
+		if err != nil {
+			return err
+		}
+		if len(resp.Kvs) == 0 {
+			missing[name] = true
+		} else if refCounts[name], err = strconv.Atoi(string(resp.Kvs[0].Value)); err != nil {
+			return err
+		}
+		var detail string
+		switch {
+		case missing[name]:
+			detail = "it's missing"
+		case refCounts[name] < 0:
+			detail = fmt.Sprintf("it's %d", refCounts[name])
+		case refCounts[name] != expected[name]:
+			detail = fmt.Sprintf("it's %d, but %d repos have the repo in their provenance", refCounts[name], expected[name])
+		default:
+			continue
+		}
+		if err := a.find(&pfs.AuditFinding{
+			Type:   pfs.AuditFinding_BAD_REF_COUNT,
+			Repo:   repoInfo.Repo,
+			Detail: detail,
+			Repair: "run FsckProvenance with fix set",
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package server
+
+import (
+	"context"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+
+	"github.com/gogo/protobuf/types"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	autoCompactionLockPath = "_auto_compaction_lock"
+
+	// autoCompactionPollInterval is how often branch heads are checked for
+	// fragmented files
+	autoCompactionPollInterval = 10 * time.Second
+
+	// Defaults for the fields of a CompactionPolicy that are 0
+	defaultCompactionMinObjects            = 16
+	defaultCompactionMaxAverageObjectBytes = 1024 * 1024
+	defaultCompactionMaxCompactionsPerHour = 6
+)
+
+func (d *driver) setCompactionPolicy(ctx context.Context, repo *pfs.Repo, policy *pfs.CompactionPolicy) error {
+	// compactions are run with the caller's credentials, so only owners can
+	// set a policy
+	if err := d.checkIsAuthorized(ctx, repo, auth.Scope_OWNER); err != nil {
+		return err
+	}
+	var capability string
+	if policy != nil {
+		d.featureUsage.inc("compaction_policy")
+		resp, err := d.pachClient.AuthAPIClient.GetCapability(auth.In2Out(ctx), &auth.GetCapabilityRequest{})
+		if err != nil && !auth.IsNotActivatedError(err) {
+			return grpcutil.ScrubGRPC(err)
+		} else if err == nil {
+			capability = resp.Capability
+		}
+	}
+	var oldCapability string
+	if _, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		oldCapability = ""
+		// Make sure that the repo exists
+		if err := d.repos.ReadWrite(stm).Get(repo.Name, &pfs.RepoInfo{}); err != nil {
+			return err
+		}
+		policies := d.compactionPolicies.ReadWrite(stm)
+		policyInfo := &pfs.CompactionPolicyInfo{}
+		if err := policies.Get(repo.Name, policyInfo); err != nil && !col.IsErrNotFound(err) {
+			return err
+		}
+		oldCapability = policyInfo.Capability
+		if policy == nil {
+			if err := policies.Delete(repo.Name); err != nil && !col.IsErrNotFound(err) {
+				return err
+			}
+			return nil
+		}
+		// the rate limit carries over, but heads are checked again against the
+		// new policy
+		return policies.Put(repo.Name, &pfs.CompactionPolicyInfo{
+			Repo:              repo,
+			Policy:            policy,
+			RecentCompactions: policyInfo.RecentCompactions,
+			Capability:        capability,
+		})
+	}); err != nil {
+		// the new capability is unused, revoking it is best effort as err
+		// is the more useful error to return
+		d.revokeCapability(ctx, capability)
+		return err
+	}
+	return d.revokeCapability(ctx, oldCapability)
+}
+
+func (d *driver) inspectCompactionPolicy(ctx context.Context, repo *pfs.Repo) (*pfs.CompactionPolicyInfo, error) {
+	if err := d.checkIsAuthorized(ctx, repo, auth.Scope_READER); err != nil {
+		return nil, err
+	}
+	policyInfo := &pfs.CompactionPolicyInfo{}
+	if err := d.compactionPolicies.ReadOnly(ctx).Get(repo.Name, policyInfo); err != nil {
+		return nil, err
+	}
+	policyInfo.Capability = ""
+	return policyInfo, nil
+}
+
+// revokeCapability revokes an auth token that was issued for background
+// work, if there is one.
+func (d *driver) revokeCapability(ctx context.Context, capability string) error {
+	if capability == "" {
+		return nil
+	}
+	if _, err := d.pachClient.AuthAPIClient.RevokeAuthToken(auth.In2Out(ctx), &auth.RevokeAuthTokenRequest{
+		Token: capability,
+	}); err != nil && !auth.IsNotActivatedError(err) {
+		return grpcutil.ScrubGRPC(err)
+	}
+	return nil
+}
+
+// autoCompact makes one pass over every repo's compaction policy.
+func (d *driver) autoCompact(ctx context.Context) error {
+	iter, err := d.compactionPolicies.ReadOnly(ctx).List()
+	if err != nil {
+		return err
+	}
+	var policyInfos []*pfs.CompactionPolicyInfo
+	for {
+		var repo string
+		policyInfo := &pfs.CompactionPolicyInfo{}
+		ok, err := iter.Next(&repo, policyInfo)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		policyInfos = append(policyInfos, policyInfo)
+	}
+	for _, policyInfo := range policyInfos {
+		if err := d.autoCompactRepo(ctx, policyInfo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// autoCompactRepo compacts the heads of the branches that policyInfo applies
+// to which haven't been checked yet, until the policy's rate limit is
+// reached. Failed compactions are recorded in the branch's state rather than
+// returned, so that one broken branch doesn't hold up the others.
+func (d *driver) autoCompactRepo(ctx context.Context, policyInfo *pfs.CompactionPolicyInfo) error {
+	repo := policyInfo.Repo
+	policy := policyInfo.Policy
+	maxCompactions := policy.MaxCompactionsPerHour
+	if maxCompactions <= 0 {
+		maxCompactions = defaultCompactionMaxCompactionsPerHour
+	}
+	var recent []*types.Timestamp
+	for _, t := range policyInfo.RecentCompactions {
+		compactedAt, err := types.TimestampFromProto(t)
+		if err != nil {
+			return err
+		}
+		if time.Since(compactedAt) < time.Hour {
+			recent = append(recent, t)
+		}
+	}
+
+	heads := make(map[string]*pfs.Commit)
+	iter, err := d.branches(repo.Name).ReadOnly(ctx).List()
+	if err != nil {
+		return err
+	}
+	for {
+		var branch string
+		head := &pfs.Commit{}
+		ok, err := iter.Next(&branch, head)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		heads[path.Base(branch)] = head
+	}
+	var branches []string
+	for branch := range heads {
+		if len(policy.Branches) == 0 || containsString(policy.Branches, branch) {
+			branches = append(branches, branch)
+		}
+	}
+	sort.Strings(branches)
+
+	// compactions run with the credentials of the user that set the policy
+	userCtx := ctx
+	if policyInfo.Capability != "" {
+		userCtx = metadata.NewIncomingContext(ctx, metadata.Pairs(auth.ContextTokenKey, policyInfo.Capability))
+	}
+	states := make(map[string]*pfs.CompactionBranchState)
+	for _, branch := range branches {
+		if int64(len(recent)) >= maxCompactions {
+			break
+		}
+		state := compactionBranchState(policyInfo, branch)
+		if state.Checked != nil && state.Checked.ID == heads[branch].ID {
+			continue
+		}
+		response, err := d.compactFiles(userCtx, client.NewFile(repo.Name, branch, "/"), policy.InPlace, shouldAutoCompact(policy), nil)
+		state.Checked = heads[branch]
+		state.LastError = ""
+		if err != nil {
+			// the head isn't retried, as it'd most likely fail again
+			log.Errorf("error compacting branch %s of repo %s: %v", branch, repo.Name, err)
+			state.LastError = err.Error()
+		} else if response.Commit != nil {
+			// the commit holding the compacted files doesn't need checking
+			state.Checked = response.Commit
+			recent = append(recent, now())
+		}
+		states[branch] = state
+	}
+	if len(states) == 0 && len(recent) == len(policyInfo.RecentCompactions) {
+		return nil
+	}
+
+	// The policy may have been changed while the branches were compacted, so
+	// only the state is written back
+	_, err = col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		policies := d.compactionPolicies.ReadWrite(stm)
+		current := &pfs.CompactionPolicyInfo{}
+		if err := policies.Get(repo.Name, current); err != nil {
+			if col.IsErrNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		current.RecentCompactions = recent
+		for branch, state := range states {
+			*compactionBranchState(current, branch) = *state
+		}
+		return policies.Put(repo.Name, current)
+	})
+	return err
+}
+
+// shouldAutoCompact returns a function that returns true for the fragmented
+// files that policy says should be compacted.
+func shouldAutoCompact(policy *pfs.CompactionPolicy) func(*hashtree.NodeProto) bool {
+	minObjects := policy.MinObjects
+	if minObjects <= 0 {
+		minObjects = defaultCompactionMinObjects
+	}
+	maxAverageObjectBytes := policy.MaxAverageObjectBytes
+	if maxAverageObjectBytes <= 0 {
+		maxAverageObjectBytes = defaultCompactionMaxAverageObjectBytes
+	}
+	return func(node *hashtree.NodeProto) bool {
+		objects := int64(len(node.FileNode.Objects))
+		return objects >= minObjects &&
+			node.SubtreeSize/objects <= maxAverageObjectBytes &&
+			isFragmented(node)
+	}
+}
+
+// compactionBranchState returns policyInfo's state for branch, adding an
+// empty one if the branch hasn't been checked before.
+func compactionBranchState(policyInfo *pfs.CompactionPolicyInfo, branch string) *pfs.CompactionBranchState {
+	for _, state := range policyInfo.Branches {
+		if state.Branch == branch {
+			return state
+		}
+	}
+	state := &pfs.CompactionBranchState{Branch: branch}
+	policyInfo.Branches = append(policyInfo.Branches, state)
+	return state
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
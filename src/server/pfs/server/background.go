@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"path"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
+	"github.com/pachyderm/pachyderm/src/server/pkg/dlock"
+	log "github.com/sirupsen/logrus"
+)
+
+// startBackground starts the work that every pachd does in the background.
+// It's called once every field of the driver is set, and the work runs until
+// stopBackground is called.
+func (d *driver) startBackground() {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.stopBackground = cancel
+	go d.watchTreeCache(ctx)
+	go d.runStartupAudit(ctx)
+	go d.runLeaderLoop(ctx, commitHookLockPath, commitHookPollInterval, "delivering commit hooks", d.deliverCommitHooks)
+	go d.runLeaderLoop(ctx, autoCompactionLockPath, autoCompactionPollInterval, "compacting branches", d.autoCompact)
+	go d.runLeaderLoop(ctx, tempRepoLockPath, tempRepoPollInterval, "deleting expired repos", d.deleteExpiredRepos)
+	go d.runLeaderLoop(ctx, pathExpirationLockPath, pathExpirationPollInterval, "deleting expired paths", d.deleteExpiredPaths)
+	go d.runLeaderLoop(ctx, repoSizeLockPath, repoSizeInterval, "recomputing repo sizes", d.recomputeFlaggedRepoSizes)
+	go d.runLeaderLoop(ctx, metadataExportLockPath, metadataExportPollInterval, "exporting metadata", d.exportMetadataIfDue)
+	go d.runLeaderLoop(ctx, retentionLockPath, retentionPollInterval, "enforcing retention policies", d.enforceRetention)
+	go d.runLeaderLoop(ctx, objectSweepLockPath, objectSweepInterval, "sweeping unreferenced objects", d.sweepUnreferencedObjects)
+}
+
+// runLeaderLoop calls fn every interval until ctx is done. It's run by every
+// pachd, but only the one holding the lock at lockPath calls fn, so that its
+// work isn't done twice. If fn fails, the error is logged as an error what,
+// e.g. "delivering commit hooks", and the loop starts over with backoff.
+func (d *driver) runLeaderLoop(ctx context.Context, lockPath string, interval time.Duration, what string, fn func(ctx context.Context) error) {
+	lock := dlock.NewDLock(d.etcdClient, path.Join(d.prefix, lockPath))
+	backoff.RetryNotify(func() error {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		ctx, err := lock.Lock(ctx)
+		if err != nil {
+			return err
+		}
+		defer lock.Unlock(ctx)
+
+		for {
+			if err := fn(ctx); err != nil {
+				return err
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(interval):
+			}
+		}
+	}, backoff.NewInfiniteBackOff(), func(err error, retryIn time.Duration) error {
+		if ctx.Err() != nil {
+			return ctx.Err() // the driver has been stopped
+		}
+		log.Errorf("error %s: %v; retrying in %v", what, err, retryIn)
+		return nil
+	})
+}
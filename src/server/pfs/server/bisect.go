@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+)
+
+// bisectCommits returns the commit halfway between good and bad, see
+// BisectCommitsRequest. The commits that could be the first bad one are the
+// ancestors of bad, bad included, that come after good.
+func (d *driver) bisectCommits(ctx context.Context, repo *pfs.Repo, branch string, good *pfs.Commit, bad *pfs.Commit) (*pfs.BisectCommitsResponse, error) {
+	if bad == nil {
+		if branch == "" {
+			return nil, fmt.Errorf("either the bad commit or a branch must be set")
+		}
+		bad = &pfs.Commit{Repo: repo, ID: branch}
+	}
+	// listCommit resolves good and bad in place
+	candidates, err := d.listCommit(ctx, repo, bad, good, 0)
+	if err != nil {
+		return nil, err
+	}
+	if good != nil {
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("the good and bad commits are both %s", good.ID)
+		}
+		last := candidates[len(candidates)-1]
+		if last.ParentCommit == nil || last.ParentCommit.ID != good.ID {
+			return nil, fmt.Errorf("commit %s isn't an ancestor of commit %s", good.ID, bad.ID)
+		}
+	}
+	d.featureUsage.inc("bisect_commits")
+	// candidates are newest first, so bad is the first of them, and the rest
+	// are untested
+	response := &pfs.BisectCommitsResponse{
+		Remaining: uint64(len(candidates)),
+		Steps:     uint64(bits.Len(uint(len(candidates) - 1))),
+		Good:      good,
+		Bad:       bad,
+	}
+	if len(candidates) == 1 {
+		response.FirstBad = bad
+		return response, nil
+	}
+	response.Next = candidates[len(candidates)/2]
+	return response, nil
+}
@@ -0,0 +1,426 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/types"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	commitHookLockPath = "_commit_hook_lock"
+
+	// commitHookPollInterval is how often branches are checked for commits
+	// that haven't been delivered to their repo's hooks
+	commitHookPollInterval = time.Second
+	// commitHookTimeout is how long a hook has to respond to an event
+	commitHookTimeout = 30 * time.Second
+	// commitHookMaxRetryInterval caps the time between two attempts to deliver
+	// an event to a failing hook
+	commitHookMaxRetryInterval = 5 * time.Minute
+)
+
+func (d *driver) createCommitHook(ctx context.Context, repo *pfs.Repo, name string, hookURL string) error {
+	d.featureUsage.inc("commit_hook")
+	// hooks send commit metadata outside of the cluster, so only owners can
+	// create them
+	if err := d.checkIsAuthorized(ctx, repo, auth.Scope_OWNER); err != nil {
+		return err
+	}
+	if name == "" {
+		return fmt.Errorf("commit hooks must have a name")
+	}
+	u, err := url.Parse(hookURL)
+	if err != nil {
+		return fmt.Errorf("invalid commit hook url %q: %v", hookURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid commit hook url %q: must be an http or https url", hookURL)
+	}
+	_, err = col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		// Make sure that the repo exists
+		if err := d.repos.ReadWrite(stm).Get(repo.Name, &pfs.RepoInfo{}); err != nil {
+			return err
+		}
+		hooks := d.commitHooks.ReadWrite(stm)
+		hookInfos := &pfs.CommitHookInfos{}
+		if err := hooks.Get(repo.Name, hookInfos); err != nil && !col.IsErrNotFound(err) {
+			return err
+		}
+		for _, hookInfo := range hookInfos.CommitHookInfo {
+			if hookInfo.Name == name {
+				return fmt.Errorf("commit hook %s already exists in repo %s", name, repo.Name)
+			}
+		}
+		hookInfos.CommitHookInfo = append(hookInfos.CommitHookInfo, &pfs.CommitHookInfo{
+			Name:    name,
+			Url:     hookURL,
+			Created: now(),
+			ID:      uuid.NewWithoutDashes(),
+		})
+		return hooks.Put(repo.Name, hookInfos)
+	})
+	return err
+}
+
+func (d *driver) listCommitHook(ctx context.Context, repo *pfs.Repo) (*pfs.CommitHookInfos, error) {
+	if err := d.checkIsAuthorized(ctx, repo, auth.Scope_READER); err != nil {
+		return nil, err
+	}
+	hookInfos := &pfs.CommitHookInfos{}
+	if err := d.commitHooks.ReadOnly(ctx).Get(repo.Name, hookInfos); err != nil && !col.IsErrNotFound(err) {
+		return nil, err
+	}
+	return hookInfos, nil
+}
+
+func (d *driver) deleteCommitHook(ctx context.Context, repo *pfs.Repo, name string) error {
+	if err := d.checkIsAuthorized(ctx, repo, auth.Scope_OWNER); err != nil {
+		return err
+	}
+	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		hooks := d.commitHooks.ReadWrite(stm)
+		hookInfos := &pfs.CommitHookInfos{}
+		if err := hooks.Get(repo.Name, hookInfos); err != nil && !col.IsErrNotFound(err) {
+			return err
+		}
+		var remaining []*pfs.CommitHookInfo
+		for _, hookInfo := range hookInfos.CommitHookInfo {
+			if hookInfo.Name != name {
+				remaining = append(remaining, hookInfo)
+			}
+		}
+		if len(remaining) == len(hookInfos.CommitHookInfo) {
+			return fmt.Errorf("commit hook %s not found in repo %s", name, repo.Name)
+		}
+		hookInfos.CommitHookInfo = remaining
+		return hooks.Put(repo.Name, hookInfos)
+	})
+	return err
+}
+
+// deliverCommitHooks makes one pass over every repo's commit hooks. It's only
+// run by the pachd holding the commit hook lock, so each branch's commits are
+// delivered to each hook in order. A commit is recorded as delivered after
+// its hook acknowledges it, so if pachd dies in between, or the hook's
+// response is lost, its event is sent again, with the same sequence, which
+// lets the hook process it exactly once.
+func (d *driver) deliverCommitHooks(ctx context.Context) error {
+	iter, err := d.commitHooks.ReadOnly(ctx).List()
+	if err != nil {
+		return err
+	}
+	for {
+		var repo string
+		hookInfos := &pfs.CommitHookInfos{}
+		ok, err := iter.Next(&repo, hookInfos)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		if err := d.deliverRepoCommitHooks(ctx, repo, hookInfos); err != nil {
+			return err
+		}
+	}
+}
+
+// deliverRepoCommitHooks delivers the commits on each of repo's branches to
+// its hooks. A hook is only sent the commits that the hook before it has
+// acknowledged, which makes the hooks an ordered pipeline.
+func (d *driver) deliverRepoCommitHooks(ctx context.Context, repo string, hookInfos *pfs.CommitHookInfos) error {
+	heads := make(map[string]*pfs.Commit)
+	iter, err := d.branches(repo).ReadOnly(ctx).List()
+	if err != nil {
+		return err
+	}
+	for {
+		var branch string
+		head := &pfs.Commit{}
+		ok, err := iter.Next(&branch, head)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		heads[branch] = head
+	}
+	var branches []string
+	for branch := range heads {
+		branches = append(branches, branch)
+	}
+	sort.Strings(branches)
+
+	for _, branch := range branches {
+		var until *pfs.Commit
+		for i, hookInfo := range hookInfos.CommitHookInfo {
+			if i > 0 && until == nil {
+				// the previous hook hasn't acknowledged anything on this branch
+				break
+			}
+			state := commitHookBranchState(hookInfo, branch)
+			if err := d.deliverCommitHook(ctx, repo, hookInfo, heads[branch], state, until); err != nil {
+				return err
+			}
+			until = state.Delivered
+		}
+	}
+	return nil
+}
+
+// commitHookBranchState returns hookInfo's state for branch, adding an empty
+// one if the hook hasn't been notified about the branch before.
+func commitHookBranchState(hookInfo *pfs.CommitHookInfo, branch string) *pfs.CommitHookBranchState {
+	for _, state := range hookInfo.Branches {
+		if state.Branch == branch {
+			return state
+		}
+	}
+	state := &pfs.CommitHookBranchState{Branch: branch}
+	hookInfo.Branches = append(hookInfo.Branches, state)
+	return state
+}
+
+// deliverCommitHook sends the commits on state's branch that hookInfo hasn't
+// acknowledged to it, oldest first, stopping at the first failure. If until
+// is set, commits after it aren't sent. state is updated in place and
+// written to etcd after each attempt.
+func (d *driver) deliverCommitHook(ctx context.Context, repo string, hookInfo *pfs.CommitHookInfo, head *pfs.Commit, state *pfs.CommitHookBranchState, until *pfs.Commit) error {
+	if state.NextAttempt != nil {
+		nextAttempt, err := types.TimestampFromProto(state.NextAttempt)
+		if err != nil {
+			return err
+		}
+		if time.Now().Before(nextAttempt) {
+			return nil
+		}
+	}
+	created, err := types.TimestampFromProto(hookInfo.Created)
+	if err != nil {
+		return err
+	}
+	commitInfos, newest, err := d.undeliveredCommits(ctx, repo, head, state.Delivered, created, until)
+	if err != nil {
+		return err
+	}
+	if commitInfos == nil && newest != nil {
+		// the branch was moved off of the commits that were delivered, the
+		// history it was moved to isn't sent, only the commits after newest
+		log.Warnf("branch %s of repo %s was moved to %s, which isn't a descendant of %s, the last commit delivered to commit hook %s; resuming delivery after %s",
+			state.Branch, repo, head.ID, state.Delivered.ID, hookInfo.Name, newest.ID)
+		state.ResetFrom = state.Delivered
+		state.Delivered = newest
+		state.Attempts = 0
+		state.LastError = ""
+		state.NextAttempt = nil
+		return d.putCommitHookBranchState(ctx, repo, hookInfo, state)
+	}
+	for _, commitInfo := range commitInfos {
+		sequence := state.Sequence + 1
+		if err := postCommitHookEvent(ctx, hookInfo.Url, &pfs.CommitHookEvent{
+			Hook:       hookInfo.Name,
+			Branch:     state.Branch,
+			CommitInfo: commitInfo,
+			ID:         fmt.Sprintf("%s/%s/%d", hookInfo.ID, state.Branch, sequence),
+			Sequence:   sequence,
+		}); err != nil {
+			state.Attempts++
+			state.LastError = err.Error()
+			state.NextAttempt, err = types.TimestampProto(time.Now().Add(commitHookRetryInterval(state.Attempts)))
+			if err != nil {
+				return err
+			}
+			return d.putCommitHookBranchState(ctx, repo, hookInfo, state)
+		}
+		state.Delivered = commitInfo.Commit
+		state.Sequence = sequence
+		state.Attempts = 0
+		state.LastError = ""
+		state.NextAttempt = nil
+		if err := d.putCommitHookBranchState(ctx, repo, hookInfo, state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// undeliveredCommits returns the finished commits between delivered and
+// head, oldest first. Commits that finished before the hook was created are
+// never returned, and neither are the commits after the first unfinished
+// one, so that commits aren't delivered out of order. If until is set, only
+// the commits up to and including it are returned, and none are returned if
+// it's not between delivered and head. If delivered is set but head isn't a
+// descendant of it, no commits are returned, along with the commit that
+// delivery should resume after: the newest commit that both head and
+// delivered descend from, or if they have none that finished after the hook
+// was created, the newest finished commit that head descends from.
+func (d *driver) undeliveredCommits(ctx context.Context, repo string, head *pfs.Commit, delivered *pfs.Commit, created time.Time, until *pfs.Commit) ([]*pfs.CommitInfo, *pfs.Commit, error) {
+	commits := d.commits(repo).ReadOnly(ctx)
+	var newestFirst []*pfs.CommitInfo
+	// newest is the newest finished commit that's been walked over
+	var newest *pfs.Commit
+	foundDelivered := delivered == nil
+	for commit := head; commit != nil; {
+		if delivered != nil && commit.ID == delivered.ID {
+			foundDelivered = true
+			break
+		}
+		commitInfo := &pfs.CommitInfo{}
+		if err := commits.Get(commit.ID, commitInfo); err != nil {
+			return nil, nil, err
+		}
+		if commitInfo.Finished != nil {
+			if newest == nil {
+				newest = commitInfo.Commit
+			}
+			finished, err := types.TimestampFromProto(commitInfo.Finished)
+			if err != nil {
+				return nil, nil, err
+			}
+			if finished.Before(created) {
+				break
+			}
+		}
+		newestFirst = append(newestFirst, commitInfo)
+		commit = commitInfo.ParentCommit
+	}
+	if !foundDelivered {
+		onHead := make(map[string]bool)
+		for _, commitInfo := range newestFirst {
+			onHead[commitInfo.Commit.ID] = true
+		}
+		base, err := d.commitHookResetBase(ctx, repo, delivered, onHead, created)
+		if err != nil {
+			return nil, nil, err
+		}
+		if base != nil {
+			return nil, base, nil
+		}
+		return nil, newest, nil
+	}
+	var result []*pfs.CommitInfo
+	for i := len(newestFirst) - 1; i >= 0; i-- {
+		commitInfo := newestFirst[i]
+		if commitInfo.Finished == nil {
+			break
+		}
+		result = append(result, commitInfo)
+		if until != nil && commitInfo.Commit.ID == until.ID {
+			return result, nil, nil
+		}
+	}
+	if until != nil {
+		return nil, nil, nil
+	}
+	return result, nil, nil
+}
+
+// commitHookResetBase returns the newest of delivered's ancestors whose ID is
+// in onHead, looking only at the ones that finished after the hook was
+// created, or nil if there isn't one.
+func (d *driver) commitHookResetBase(ctx context.Context, repo string, delivered *pfs.Commit, onHead map[string]bool, created time.Time) (*pfs.Commit, error) {
+	commits := d.commits(repo).ReadOnly(ctx)
+	for commit := delivered; commit != nil; {
+		commitInfo := &pfs.CommitInfo{}
+		if err := commits.Get(commit.ID, commitInfo); err != nil {
+			if col.IsErrNotFound(err) {
+				// the delivered commit has been deleted
+				return nil, nil
+			}
+			return nil, err
+		}
+		if commitInfo.Finished == nil {
+			return nil, nil
+		}
+		finished, err := types.TimestampFromProto(commitInfo.Finished)
+		if err != nil {
+			return nil, err
+		}
+		if finished.Before(created) {
+			return nil, nil
+		}
+		if onHead[commit.ID] {
+			return commitInfo.Commit, nil
+		}
+		commit = commitInfo.ParentCommit
+	}
+	return nil, nil
+}
+
+// putCommitHookBranchState writes state to hookInfo in etcd. It does nothing
+// if the hook has been deleted, including if it's been deleted and then
+// recreated with the same name.
+func (d *driver) putCommitHookBranchState(ctx context.Context, repo string, hookInfo *pfs.CommitHookInfo, state *pfs.CommitHookBranchState) error {
+	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		hooks := d.commitHooks.ReadWrite(stm)
+		hookInfos := &pfs.CommitHookInfos{}
+		if err := hooks.Get(repo, hookInfos); err != nil {
+			if col.IsErrNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		for _, storedInfo := range hookInfos.CommitHookInfo {
+			if storedInfo.Name != hookInfo.Name || !storedInfo.Created.Equal(hookInfo.Created) {
+				continue
+			}
+			storedState := commitHookBranchState(storedInfo, state.Branch)
+			*storedState = *state
+			return hooks.Put(repo, hookInfos)
+		}
+		return nil
+	})
+	return err
+}
+
+// commitHookRetryInterval returns how long to wait before the next attempt
+// to deliver an event to a hook that's failed attempts times in a row.
+func commitHookRetryInterval(attempts int64) time.Duration {
+	if attempts > 16 {
+		return commitHookMaxRetryInterval
+	}
+	interval := time.Second << uint(attempts)
+	if interval > commitHookMaxRetryInterval {
+		return commitHookMaxRetryInterval
+	}
+	return interval
+}
+
+// postCommitHookEvent sends event to hookURL, it succeeds if the hook
+// responds with a 2xx status.
+func postCommitHookEvent(ctx context.Context, hookURL string, event *pfs.CommitHookEvent) error {
+	body, err := (&jsonpb.Marshaler{}).MarshalToString(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", hookURL, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	ctx, cancel := context.WithTimeout(ctx, commitHookTimeout)
+	defer cancel()
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s responded with %s", hookURL, resp.Status)
+	}
+	return nil
+}
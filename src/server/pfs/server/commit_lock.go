@@ -0,0 +1,268 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
+	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+)
+
+// defaultCommitLockTTL is the lease of a commit lock, in seconds, if the
+// request doesn't set one.
+const defaultCommitLockTTL = 60
+
+// acquireCommitLock locks the paths under file.Path in file.Commit, which
+// must be open, for owner, until the lock is released or ttl seconds pass
+// without it being renewed. The lock is advisory: it only keeps other locks
+// on the same paths from being acquired.
+func (d *driver) acquireCommitLock(ctx context.Context, file *pfs.File, owner string, ttl int64) (*pfs.CommitLock, error) {
+	if err := d.checkIsAuthorized(ctx, file.Commit.Repo, auth.Scope_WRITER); err != nil {
+		return nil, err
+	}
+	ttl, err := commitLockTTL(ttl)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := d.openCommit(ctx, file.Commit)
+	if err != nil {
+		return nil, err
+	}
+	d.featureUsage.inc("commit_lock")
+	lock := &pfs.CommitLock{
+		File:  client.NewFile(commit.Repo.Name, commit.ID, path.Clean("/"+file.Path)),
+		ID:    uuid.NewWithoutDashes(),
+		Owner: owner,
+	}
+	if _, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		locks, err := d.getCommitLocks(stm, commit)
+		if err != nil {
+			return err
+		}
+		for _, held := range locks.Locks {
+			if pathsOverlap(held.File.Path, lock.File.Path) {
+				return pfsserver.ErrCommitLocked{
+					File:  held.File,
+					Owner: held.Owner,
+				}
+			}
+		}
+		if lock.Expires, err = types.TimestampProto(time.Now().Add(time.Duration(ttl) * time.Second)); err != nil {
+			return err
+		}
+		locks.Locks = append(locks.Locks, lock)
+		return d.commitLocks.ReadWrite(stm).Put(commit.ID, locks)
+	}); err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// renewCommitLock extends the lease of the lock id on commit to ttl seconds
+// from now.
+func (d *driver) renewCommitLock(ctx context.Context, commit *pfs.Commit, id string, ttl int64) (*pfs.CommitLock, error) {
+	if err := d.checkIsAuthorized(ctx, commit.Repo, auth.Scope_WRITER); err != nil {
+		return nil, err
+	}
+	ttl, err := commitLockTTL(ttl)
+	if err != nil {
+		return nil, err
+	}
+	commit, err = d.openCommit(ctx, commit)
+	if err != nil {
+		return nil, err
+	}
+	var lock *pfs.CommitLock
+	if _, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		locks, err := d.getCommitLocks(stm, commit)
+		if err != nil {
+			return err
+		}
+		lock = nil
+		for _, held := range locks.Locks {
+			if held.ID == id {
+				lock = held
+			}
+		}
+		if lock == nil {
+			return pfsserver.ErrCommitLockNotFound{
+				Commit: commit,
+				ID:     id,
+			}
+		}
+		if lock.Expires, err = types.TimestampProto(time.Now().Add(time.Duration(ttl) * time.Second)); err != nil {
+			return err
+		}
+		return d.commitLocks.ReadWrite(stm).Put(commit.ID, locks)
+	}); err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// releaseCommitLock releases the lock id on commit.
+func (d *driver) releaseCommitLock(ctx context.Context, commit *pfs.Commit, id string) error {
+	if err := d.checkIsAuthorized(ctx, commit.Repo, auth.Scope_WRITER); err != nil {
+		return err
+	}
+	commit, err := d.openCommit(ctx, commit)
+	if err != nil {
+		return err
+	}
+	_, err = col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		locks, err := d.getCommitLocks(stm, commit)
+		if err != nil {
+			return err
+		}
+		var rest []*pfs.CommitLock
+		for _, held := range locks.Locks {
+			if held.ID != id {
+				rest = append(rest, held)
+			}
+		}
+		if len(rest) == len(locks.Locks) {
+			return pfsserver.ErrCommitLockNotFound{
+				Commit: commit,
+				ID:     id,
+			}
+		}
+		if len(rest) == 0 {
+			return d.commitLocks.ReadWrite(stm).Delete(commit.ID)
+		}
+		locks.Locks = rest
+		return d.commitLocks.ReadWrite(stm).Put(commit.ID, locks)
+	})
+	return err
+}
+
+// listCommitLock returns the locks held on commit that haven't expired.
+func (d *driver) listCommitLock(ctx context.Context, commit *pfs.Commit) ([]*pfs.CommitLock, error) {
+	if err := d.checkIsAuthorized(ctx, commit.Repo, auth.Scope_READER); err != nil {
+		return nil, err
+	}
+	commitInfo, err := d.inspectCommit(ctx, commit)
+	if err != nil {
+		return nil, err
+	}
+	locks := &pfs.CommitLocks{}
+	if err := d.commitLocks.ReadOnly(ctx).Get(commitInfo.Commit.ID, locks); err != nil && !col.IsErrNotFound(err) {
+		return nil, err
+	}
+	return liveCommitLocks(locks.Locks)
+}
+
+// checkCommitLocks returns ErrCommitLocked if file.Path overlaps a lock on
+// file.Commit, which must be open, other than the lock id. id is "" if the
+// caller holds no lock, otherwise it has to be held, so that a writer whose
+// lock has expired doesn't carry on as if it still had it.
+func (d *driver) checkCommitLocks(ctx context.Context, file *pfs.File, id string) error {
+	commit, err := d.openCommit(ctx, file.Commit)
+	if err != nil {
+		return err
+	}
+	locks := &pfs.CommitLocks{}
+	if err := d.commitLocks.ReadOnly(ctx).Get(commit.ID, locks); err != nil && !col.IsErrNotFound(err) {
+		return err
+	}
+	live, err := liveCommitLocks(locks.Locks)
+	if err != nil {
+		return err
+	}
+	p := path.Clean("/" + file.Path)
+	held := id == ""
+	for _, lock := range live {
+		if lock.ID == id {
+			held = true
+		} else if pathsOverlap(lock.File.Path, p) {
+			return pfsserver.ErrCommitLocked{
+				File:  lock.File,
+				Owner: lock.Owner,
+			}
+		}
+	}
+	if !held {
+		return pfsserver.ErrCommitLockNotFound{
+			Commit: commit,
+			ID:     id,
+		}
+	}
+	return nil
+}
+
+// openCommit resolves commit, which may be a branch, to the ID of a commit
+// that's open.
+func (d *driver) openCommit(ctx context.Context, commit *pfs.Commit) (*pfs.Commit, error) {
+	commitInfo, err := d.inspectCommit(ctx, commit)
+	if err != nil {
+		return nil, err
+	}
+	if commitInfo.Finished != nil {
+		return nil, pfsserver.ErrCommitFinished{commitInfo.Commit}
+	}
+	return commitInfo.Commit, nil
+}
+
+// getCommitLocks returns the locks on commit that haven't expired, or an
+// error if commit has been finished since it was checked.
+func (d *driver) getCommitLocks(stm col.STM, commit *pfs.Commit) (*pfs.CommitLocks, error) {
+	if err := d.openCommits.ReadWrite(stm).Get(commit.ID, &pfs.Commit{}); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil, pfsserver.ErrCommitFinished{commit}
+		}
+		return nil, err
+	}
+	locks := &pfs.CommitLocks{}
+	if err := d.commitLocks.ReadWrite(stm).Get(commit.ID, locks); err != nil && !col.IsErrNotFound(err) {
+		return nil, err
+	}
+	live, err := liveCommitLocks(locks.Locks)
+	if err != nil {
+		return nil, err
+	}
+	locks.Locks = live
+	return locks, nil
+}
+
+// liveCommitLocks returns the locks that haven't expired. Expired locks are
+// left in etcd until the next change to their commit's locks.
+func liveCommitLocks(locks []*pfs.CommitLock) ([]*pfs.CommitLock, error) {
+	now := time.Now()
+	var live []*pfs.CommitLock
+	for _, lock := range locks {
+		expires, err := types.TimestampFromProto(lock.Expires)
+		if err != nil {
+			return nil, err
+		}
+		if now.Before(expires) {
+			live = append(live, lock)
+		}
+	}
+	return live, nil
+}
+
+func commitLockTTL(ttl int64) (int64, error) {
+	if ttl < 0 {
+		return 0, fmt.Errorf("the ttl of a commit lock can't be negative")
+	}
+	if ttl == 0 {
+		return defaultCommitLockTTL, nil
+	}
+	return ttl, nil
+}
+
+// pathsOverlap returns true if a and b, which must be clean absolute paths,
+// are the same or one of them is under the other.
+func pathsOverlap(a string, b string) bool {
+	under := func(p string, dir string) bool {
+		return strings.HasPrefix(p, strings.TrimSuffix(dir, "/")+"/")
+	}
+	return a == b || under(a, b) || under(b, a)
+}
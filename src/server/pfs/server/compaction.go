@@ -0,0 +1,289 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+
+	"github.com/gogo/protobuf/proto"
+)
+
+// isFragmented returns true if the file at node is made up of more objects
+// than PutFile would have split its content into.
+func isFragmented(node *hashtree.NodeProto) bool {
+	optimal := (node.SubtreeSize + pfs.ChunkSize - 1) / pfs.ChunkSize
+	if optimal < 1 {
+		optimal = 1
+	}
+	return int64(len(node.FileNode.Objects)) > optimal
+}
+
+// compact rewrites the fragmented files at or under file.Path into as few
+// objects as possible. The result is committed as a new child of file.Commit
+// unless inPlace is set, in which case file.Commit must be a branch that
+// allows it and the tree of its head is replaced. It's run as an admin job.
+func (d *driver) compact(ctx context.Context, file *pfs.File, inPlace bool) (*pfs.CompactResponse, error) {
+	if err := d.checkIsAuthorized(ctx, file.Commit.Repo, auth.Scope_WRITER); err != nil {
+		return nil, err
+	}
+	d.featureUsage.inc("compact")
+	var response *pfs.CompactResponse
+	if err := d.runAdminJob(ctx, "compact", func(ctx context.Context, job *adminJob) error {
+		var err error
+		response, err = d.compactFiles(ctx, file, inPlace, isFragmented, job)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// compactFiles is compact, except that it only rewrites the files that
+// shouldCompact returns true for, which must all be fragmented. It's batch
+// work, so it yields to interactive operations. Its progress is reported to
+// job, if it's set.
+func (d *driver) compactFiles(ctx context.Context, file *pfs.File, inPlace bool, shouldCompact func(*hashtree.NodeProto) bool, job *adminJob) (*pfs.CompactResponse, error) {
+	ctx, done, err := d.schedule(ctx, pfs.Priority_BATCH)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	branch, err := d.branchName(ctx, file.Commit)
+	if err != nil {
+		return nil, err
+	}
+	if inPlace {
+		repoInfo, err := d.inspectRepo(ctx, file.Commit.Repo, !includeAuth)
+		if err != nil {
+			return nil, err
+		}
+		if branch == "" || !compactInPlaceAllowed(repoInfo, branch) {
+			return nil, fmt.Errorf("%s is not a branch of %s that allows compaction in place", file.Commit.ID, file.Commit.Repo.Name)
+		}
+	}
+	commitInfo, err := d.inspectCommit(ctx, file.Commit)
+	if err != nil {
+		return nil, err
+	}
+	if commitInfo.Finished == nil {
+		return nil, fmt.Errorf("commit %s has not been finished", commitInfo.Commit.FullID())
+	}
+	tree, err := d.getTreeForCommit(ctx, commitInfo.Commit)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	nodes := make(map[string]*hashtree.NodeProto)
+	if err := tree.Walk(file.Path, func(walkPath string, node *hashtree.NodeProto) error {
+		if node.FileNode != nil && shouldCompact(node) {
+			paths = append(paths, walkPath)
+			nodes[walkPath] = node
+		}
+		return nil
+	}); err != nil {
+		if hashtree.Code(err) == hashtree.PathNotFound {
+			return nil, pfsserver.ErrFileNotFound{file}
+		}
+		return nil, err
+	}
+	response := &pfs.CompactResponse{}
+	if len(paths) == 0 {
+		return response, nil
+	}
+
+	compression, storageClass, err := d.repoCompression(ctx, commitInfo.Commit.Repo)
+	if err != nil {
+		return nil, err
+	}
+	job.setPhase("compacting files", int64(len(paths)))
+	openTree := tree.Open()
+	for _, filePath := range paths {
+		node := nodes[filePath]
+		objects, err := d.compactObjects(ctx, node.FileNode.Objects, compression, storageClass)
+		if err != nil {
+			return nil, err
+		}
+		// the content hasn't changed, so the rest of the file's node still
+		// holds
+		compacted := proto.Clone(node.FileNode).(*hashtree.FileNodeProto)
+		compacted.Objects = objects
+		if err := openTree.PutFileNode(filePath, compacted, node.SubtreeSize); err != nil {
+			return nil, err
+		}
+		response.FilesCompacted++
+		response.ObjectsBefore += uint64(len(node.FileNode.Objects))
+		response.ObjectsAfter += uint64(len(objects))
+		job.progress(1)
+	}
+	job.setPhase("committing the compacted files", 0)
+	finishedTree, err := openTree.Finish()
+	if err != nil {
+		return nil, err
+	}
+	data, chunks, err := d.serializeTree(finishedTree)
+	if err != nil {
+		return nil, err
+	}
+	treeRef, _, err := d.pachClient.PutObject(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if inPlace {
+		if err := d.replaceTree(ctx, commitInfo, branch, treeRef, chunks, tree, finishedTree); err != nil {
+			return nil, err
+		}
+		response.Commit = commitInfo.Commit
+		return response, nil
+	}
+	// commits made to the branch while the files were being rewritten aren't
+	// overwritten
+	var expectedHead *pfs.Commit
+	if branch != "" {
+		expectedHead = commitInfo.Commit
+	}
+	parent := &pfs.Commit{Repo: commitInfo.Commit.Repo, ID: commitInfo.Commit.ID}
+	commit, err := d.makeCommit(ctx, parent, branch, commitInfo.Provenance, treeRef, nil, expectedHead)
+	if err != nil {
+		return nil, err
+	}
+	response.Commit = commit
+	return response, nil
+}
+
+// compactObjects copies the content of objects into new objects of
+// pfs.ChunkSize bytes each, compressed with compression and in storageClass.
+func (d *driver) compactObjects(ctx context.Context, objects []*pfs.Object, compression pfs.Compression, storageClass string) ([]*pfs.Object, error) {
+	r, err := d.objectsReader(ctx, objects, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	compacted, _, err := d.putObjectSplit(compression, storageClass, r)
+	if err != nil {
+		return nil, err
+	}
+	return compacted, nil
+}
+
+// replaceTree makes treeRef, whose chunks are newChunks, the tree of the
+// finished commit in commitInfo, which must still be the head of branch. The
+// refs to the objects that only the old tree used are removed, they're only
+// deleted after a grace period, by when other pachds have stopped serving the
+// old tree from their caches (see sweepUnreferencedObjects).
+func (d *driver) replaceTree(ctx context.Context, commitInfo *pfs.CommitInfo, branch string, treeRef *pfs.Object, newChunks []*pfs.Object, oldTree hashtree.HashTree, newTree hashtree.HashTree) error {
+	oldHashes, err := treeObjects(commitInfo.Tree, hashtree.ChunkObjects(oldTree), oldTree)
+	if err != nil {
+		return err
+	}
+	newHashes, err := treeObjects(treeRef, newChunks, newTree)
+	if err != nil {
+		return err
+	}
+	// The commit already holds refs to the objects that both trees use, so
+	// only the new ones are counted, and only the old ones released
+	oldHashSet := make(map[string]bool)
+	for _, hash := range oldHashes {
+		oldHashSet[hash] = true
+	}
+	newHashSet := make(map[string]bool)
+	var added []string
+	for _, hash := range newHashes {
+		newHashSet[hash] = true
+		if !oldHashSet[hash] {
+			added = append(added, hash)
+		}
+	}
+	removed := make(map[string]int)
+	for _, hash := range oldHashes {
+		if !newHashSet[hash] {
+			removed[hash] = 1
+		}
+	}
+	if err := d.addObjectRefs(ctx, added); err != nil {
+		return err
+	}
+
+	commit := commitInfo.Commit
+	if _, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		head := new(pfs.Commit)
+		if err := d.branches(commit.Repo.Name).ReadWrite(stm).Get(branch, head); err != nil {
+			return err
+		}
+		if head.ID != commit.ID {
+			return fmt.Errorf("branch %s moved while it was being compacted", branch)
+		}
+		commits := d.commits(commit.Repo.Name).ReadWrite(stm)
+		currentInfo := new(pfs.CommitInfo)
+		if err := commits.Get(commit.ID, currentInfo); err != nil {
+			return err
+		}
+		if currentInfo.Tree == nil || currentInfo.Tree.Hash != commitInfo.Tree.Hash {
+			return fmt.Errorf("commit %s was modified while it was being compacted", commit.FullID())
+		}
+		currentInfo.Tree = treeRef
+		return commits.Put(commit.ID, currentInfo)
+	}); err != nil {
+		return err
+	}
+	d.invalidateTree(commit)
+	return d.removeObjectRefs(ctx, removed)
+}
+
+// branchName returns commit.ID if it's the name of a branch of commit.Repo,
+// and "" if it isn't.
+func (d *driver) branchName(ctx context.Context, commit *pfs.Commit) (string, error) {
+	head := new(pfs.Commit)
+	if err := d.branches(commit.Repo.Name).ReadOnly(ctx).Get(commit.ID, head); err != nil {
+		if col.IsErrNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return commit.ID, nil
+}
+
+func compactInPlaceAllowed(repoInfo *pfs.RepoInfo, branch string) bool {
+	for _, b := range repoInfo.CompactInPlaceBranches {
+		if b == branch {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *driver) setCompactInPlace(ctx context.Context, repo *pfs.Repo, branch string, allow bool) error {
+	// rewriting a branch's history in place is riskier than adding a commit
+	// to it, so only owners can allow it
+	if err := d.checkIsAuthorized(ctx, repo, auth.Scope_OWNER); err != nil {
+		return err
+	}
+	if branch == "" {
+		return fmt.Errorf("branch cannot be empty")
+	}
+	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		repos := d.repos.ReadWrite(stm)
+		repoInfo := new(pfs.RepoInfo)
+		if err := repos.Get(repo.Name, repoInfo); err != nil {
+			return err
+		}
+		var branches []string
+		for _, b := range repoInfo.CompactInPlaceBranches {
+			if b != branch {
+				branches = append(branches, b)
+			}
+		}
+		if allow {
+			branches = append(branches, branch)
+		}
+		repoInfo.CompactInPlaceBranches = branches
+		return repos.Put(repo.Name, repoInfo)
+	})
+	return err
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+)
+
+// checkCompression returns an error if compression isn't a codec that
+// objects can be compressed with.
+func checkCompression(compression pfs.Compression) error {
+	if _, ok := pfs.Compression_name[int32(compression)]; !ok {
+		return fmt.Errorf("unrecognized compression %s", compression)
+	}
+	return nil
+}
+
+// repoCompression returns the codec that data written to repo is compressed
+// with, and the storage class that it's written in.
+func (d *driver) repoCompression(ctx context.Context, repo *pfs.Repo) (pfs.Compression, string, error) {
+	repoInfo := &pfs.RepoInfo{}
+	if err := d.repos.ReadOnly(ctx).Get(repo.Name, repoInfo); err != nil {
+		return pfs.Compression_UNCOMPRESSED, "", err
+	}
+	return repoInfo.Compression, repoInfo.StorageClass, nil
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// putObject puts the data in r into the object store, compressed with
+// compression and in storageClass, and returns the object along with the size
+// of the data before it was compressed.
+func (d *driver) putObject(compression pfs.Compression, storageClass string, r io.Reader) (*pfs.Object, int64, error) {
+	if compression == pfs.Compression_UNCOMPRESSED {
+		return d.pachClient.PutObjectWithStorageClass(r, storageClass)
+	}
+	counted := &countingReader{r: r}
+	pr, pw := io.Pipe()
+	go func() {
+		w, err := pfs.NewCompressWriter(compression, pw)
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		if _, err := io.Copy(w, counted); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(w.Close())
+	}()
+	object, _, err := d.pachClient.PutObjectWithStorageClass(pr, storageClass)
+	pr.CloseWithError(err)
+	if err != nil {
+		return nil, 0, err
+	}
+	object.Compression = compression
+	return object, counted.n, nil
+}
+
+// putObjectSplit is like PutObjectSplit, but compresses each object with
+// compression, and writes it in storageClass. Every object but the last holds
+// ChunkSize bytes of data before it's compressed, as they do when they aren't
+// compressed.
+func (d *driver) putObjectSplit(compression pfs.Compression, storageClass string, r io.Reader) ([]*pfs.Object, int64, error) {
+	if compression == pfs.Compression_UNCOMPRESSED {
+		return d.pachClient.PutObjectSplitWithStorageClass(r, storageClass)
+	}
+	bufioR := bufio.NewReader(r)
+	var objects []*pfs.Object
+	var size int64
+	for {
+		object, n, err := d.putObject(compression, storageClass, io.LimitReader(bufioR, pfs.ChunkSize))
+		if err != nil {
+			return nil, 0, err
+		}
+		objects = append(objects, object)
+		size += n
+		if n < pfs.ChunkSize {
+			return objects, size, nil
+		}
+		if _, err := bufioR.Peek(1); err == io.EOF {
+			return objects, size, nil
+		}
+	}
+}
+
+// objectsReader returns a reader of the file made up of objects, starting at
+// offset and limited to size bytes unless it's 0. Compressed objects are
+// decompressed, which is done here rather than by GetObjects as the object
+// store doesn't know which objects are compressed.
+func (d *driver) objectsReader(ctx context.Context, objects []*pfs.Object, offset uint64, size uint64) (io.Reader, error) {
+	if !pfs.Compressed(objects) {
+		getObjectsClient, err := d.pachClient.ObjectAPIClient.GetObjects(
+			ctx,
+			&pfs.GetObjectsRequest{
+				Objects:     objects,
+				OffsetBytes: offset,
+				SizeBytes:   size,
+			})
+		if err != nil {
+			return nil, err
+		}
+		return grpcutil.NewStreamingBytesReader(getObjectsClient), nil
+	}
+	r, w := io.Pipe()
+	go func() {
+		w.CloseWithError(d.pachClient.WithCtx(ctx).GetFileObjects(objects, offset, size, w))
+	}()
+	return r, nil
+}
@@ -1,16 +1,22 @@
 package server
 
 import (
+	"archive/tar"
 	"bufio"
 	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math"
 	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -26,10 +32,14 @@ import (
 	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
 	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
 	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+	"github.com/pachyderm/pachyderm/src/server/pkg/metrics"
 	"github.com/pachyderm/pachyderm/src/server/pkg/pfsdb"
+	pfssync "github.com/pachyderm/pachyderm/src/server/pkg/sync"
+	"github.com/pachyderm/pachyderm/src/server/pkg/throttle"
 	"github.com/pachyderm/pachyderm/src/server/pkg/watch"
 
 	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/mvcc/mvccpb"
 	"github.com/gogo/protobuf/types"
 	"github.com/hashicorp/golang-lru"
 	"google.golang.org/grpc"
@@ -55,18 +65,6 @@ func ValidateRepoName(name string) error {
 	return nil
 }
 
-// ListFileMode specifies how ListFile executes.
-type ListFileMode int
-
-const (
-	// ListFileNORMAL computes sizes for files but not for directories
-	ListFileNORMAL ListFileMode = iota
-	// ListFileFAST does not compute sizes for files or directories
-	ListFileFAST
-	// ListFileRECURSE computes sizes for files and directories
-	ListFileRECURSE
-)
-
 // IsPermissionError returns true if a given error is a permission error.
 func IsPermissionError(err error) bool {
 	return strings.Contains(err.Error(), "has already finished")
@@ -103,14 +101,68 @@ type driver struct {
 	prefix     string
 
 	// collections
-	repos         col.Collection
-	repoRefCounts col.Collection
-	commits       collectionFactory
-	branches      collectionFactory
-	openCommits   col.Collection
+	repos              col.Collection
+	repoRefCounts      col.Collection
+	commits            collectionFactory
+	branches           collectionFactory
+	openCommits        col.Collection
+	commitProgress     collectionFactory
+	schemas            col.Collection
+	commitHooks        col.Collection
+	objectRefCounts    col.Collection
+	unreferenced       col.Collection
+	compactionPolicies col.Collection
+	commitLocks        col.Collection
+	repoLeases         col.Collection
+	uploadSessions     col.Collection
+	pathExpirations    col.Collection
+	expirationOwners   col.Collection
+	featureFlags       col.Collection
+	adminJobs          col.Collection
+	repoQuotas         col.Collection
+	metadataExports    col.Collection
+	retentionPolicies  col.Collection
 
 	// a cache for hashtrees
 	treeCache *lru.Cache
+	// treeCacheGen is incremented, under treeCacheMu, whenever trees are
+	// invalidated, so that a tree read before then isn't cached, see addTree
+	treeCacheMu  sync.Mutex
+	treeCacheGen uint64
+	// a cache of the commit that last modified each path, keyed by the
+	// commit the path was inspected in, see commitModified
+	commitModifiedCache *lru.Cache
+
+	// featureUsage counts the uses of driver features, featureReporter
+	// reports them and is nil unless feature telemetry is enabled
+	featureUsage    *featureUsage
+	featureReporter *metrics.FeatureReporter
+
+	// audit holds the report of the startup audit, see runStartupAudit
+	audit startupAudit
+
+	// stopBackground stops the work started by startBackground
+	stopBackground context.CancelFunc
+
+	// repoUsage tracks the rate of each repo's operations, which their
+	// quotas limit, see checkRepoQuota
+	repoUsage *repoUsageTracker
+
+	// maxProvenanceDepth is the length of the longest chain of provenance
+	// that a repo can have, see checkProvenance
+	maxProvenanceDepth int64
+
+	// transferThrottle limits the rate at which proxy repos fetch data from
+	// their remotes, it's nil if the rate isn't limited
+	transferThrottle *throttle.Throttle
+
+	// scheduler makes batch operations yield to interactive ones, it's nil
+	// if they don't
+	scheduler *scheduler
+
+	// residency are the residency tags that this cluster satisfies, see
+	// checkResidency
+	residency []string
 }
 
 const (
@@ -119,10 +171,22 @@ const (
 
 const (
 	defaultTreeCacheSize = 128
+
+	defaultMaxProvenanceDepth = 64
+
+	commitModifiedCacheSize = 64 * 1024
+
+	// treeChunkSize is the number of nodes in each chunk of the trees of
+	// commits with more nodes than that, see hashtree.SerializeChunked
+	treeChunkSize = 10000
+
+	// commitProgressInterval is the minimum amount of time between two writes
+	// of a commit's progress to etcd while the commit is being finished
+	commitProgressInterval = time.Second
 )
 
 // newDriver is used to create a new Driver instance
-func newDriver(address string, etcdAddresses []string, etcdPrefix string, treeCacheSize int64) (*driver, error) {
+func newDriver(address string, etcdAddresses []string, etcdPrefix string, treeCacheSize int64, maxProvenanceDepth int64) (*driver, error) {
 	etcdClient, err := etcd.New(etcd.Config{
 		Endpoints:   etcdAddresses,
 		DialOptions: client.EtcdDialOptions(),
@@ -133,10 +197,17 @@ func newDriver(address string, etcdAddresses []string, etcdPrefix string, treeCa
 	if treeCacheSize <= 0 {
 		treeCacheSize = defaultTreeCacheSize
 	}
+	if maxProvenanceDepth <= 0 {
+		maxProvenanceDepth = defaultMaxProvenanceDepth
+	}
 	treeCache, err := lru.New(int(treeCacheSize))
 	if err != nil {
 		return nil, fmt.Errorf("could not initialize treeCache: %v", err)
 	}
+	commitModifiedCache, err := lru.New(commitModifiedCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize commitModifiedCache: %v", err)
+	}
 
 	d := &driver{
 		address:       address,
@@ -151,16 +222,81 @@ func newDriver(address string, etcdAddresses []string, etcdPrefix string, treeCa
 			return pfsdb.Branches(etcdClient, etcdPrefix, repo)
 		},
 		openCommits: pfsdb.OpenCommits(etcdClient, etcdPrefix),
-		treeCache:   treeCache,
+		commitProgress: func(repo string) col.Collection {
+			return pfsdb.CommitProgress(etcdClient, etcdPrefix, repo)
+		},
+		schemas:             pfsdb.Schemas(etcdClient, etcdPrefix),
+		commitHooks:         pfsdb.CommitHooks(etcdClient, etcdPrefix),
+		objectRefCounts:     pfsdb.ObjectRefCounts(etcdClient, etcdPrefix),
+		unreferenced:        pfsdb.UnreferencedObjects(etcdClient, etcdPrefix),
+		compactionPolicies:  pfsdb.CompactionPolicies(etcdClient, etcdPrefix),
+		commitLocks:         pfsdb.CommitLocks(etcdClient, etcdPrefix),
+		repoLeases:          pfsdb.RepoLeases(etcdClient, etcdPrefix),
+		uploadSessions:      pfsdb.UploadSessions(etcdClient, etcdPrefix),
+		pathExpirations:     pfsdb.PathExpirations(etcdClient, etcdPrefix),
+		expirationOwners:    pfsdb.PathExpirationOwners(etcdClient, etcdPrefix),
+		featureFlags:        pfsdb.FeatureFlags(etcdClient, etcdPrefix),
+		adminJobs:           pfsdb.AdminJobs(etcdClient, etcdPrefix),
+		repoQuotas:          pfsdb.RepoQuotas(etcdClient, etcdPrefix),
+		metadataExports:     pfsdb.MetadataExports(etcdClient, etcdPrefix),
+		retentionPolicies:   pfsdb.RetentionPolicies(etcdClient, etcdPrefix),
+		treeCache:           treeCache,
+		commitModifiedCache: commitModifiedCache,
+		featureUsage:        newFeatureUsage(),
+		repoUsage:           newRepoUsageTracker(),
+		maxProvenanceDepth:  maxProvenanceDepth,
 	}
 	go func() { d.initializePachConn() }() // Begin dialing connection on startup
 	return d, nil
 }
 
+// featureUsage counts how many times each driver feature has been used since
+// pachd started, see inspectFeatureUsage.
+type featureUsage struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func newFeatureUsage() *featureUsage {
+	return &featureUsage{counts: make(map[string]int64)}
+}
+
+func (f *featureUsage) inc(feature string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.counts[feature]++
+}
+
+// putFile counts the features used by a PutFile.
+func (f *featureUsage) putFile(options putFileOptions) {
+	if options.delimiter != pfs.Delimiter_NONE {
+		f.inc("split_" + strings.ToLower(options.delimiter.String()))
+	}
+	if options.overwriteIndex != nil {
+		f.inc("overwrite")
+	}
+	if options.mode != pfs.PutFileMode_APPEND {
+		f.inc("mode_" + strings.ToLower(options.mode.String()))
+	}
+	if options.computeStats {
+		f.inc("compute_stats")
+	}
+}
+
+func (f *featureUsage) snapshot() map[string]int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	counts := make(map[string]int64)
+	for feature, count := range f.counts {
+		counts[feature] = count
+	}
+	return counts
+}
+
 // newLocalDriver creates a driver using an local etcd instance.  This
 // function is intended for testing purposes
 func newLocalDriver(blockAddress string, etcdPrefix string) (*driver, error) {
-	return newDriver(blockAddress, []string{"localhost:32379"}, etcdPrefix, defaultTreeCacheSize)
+	return newDriver(blockAddress, []string{"localhost:32379"}, etcdPrefix, defaultTreeCacheSize, defaultMaxProvenanceDepth)
 }
 
 // initializePachConn initializes the connects that the pfs driver has with the
@@ -190,7 +326,13 @@ func (d *driver) checkIsAuthorized(ctx context.Context, r *pfs.Repo, s auth.Scop
 		Scope: s,
 	})
 	if err == nil && !resp.Authorized {
-		return &auth.NotAuthorizedError{Repo: r.Name, Required: s}
+		return &auth.NotAuthorizedError{
+			Repo:        r.Name,
+			Required:    s,
+			Actual:      resp.Scope,
+			ACLRevision: resp.ACLRevision,
+			Cached:      resp.Cached,
+		}
 	} else if err != nil && !auth.IsNotActivatedError(err) {
 		return fmt.Errorf("error during authorization check for operation on \"%s\": %v",
 			r.Name, grpcutil.ScrubGRPC(err))
@@ -214,13 +356,46 @@ func absent(key string) etcd.Cmp {
 	return etcd.Compare(etcd.CreateRevision(key), "=", 0)
 }
 
-func (d *driver) createRepo(ctx context.Context, repo *pfs.Repo, provenance []*pfs.Repo, description string, update bool) error {
+// createRepo creates repo, or updates it if update is set. If ttl is set
+// the repo is temporary, see CreateRepoRequest.ttl_seconds.
+func (d *driver) createRepo(ctx context.Context, repo *pfs.Repo, provenance []*pfs.Repo, description string, update bool, ttl int64, remote *pfs.RemoteRepo, compression pfs.Compression) error {
 	if err := ValidateRepoName(repo.Name); err != nil {
 		return err
 	}
+	if err := checkCompression(compression); err != nil {
+		return err
+	}
 	d.initializePachConn()
+	if compression != pfs.Compression_UNCOMPRESSED {
+		d.featureUsage.inc("compression")
+	}
 	if update {
-		return d.updateRepo(ctx, repo, provenance, description)
+		if ttl != 0 {
+			return fmt.Errorf("a repo can't be made temporary by updating it")
+		}
+		if remote != nil {
+			return fmt.Errorf("a repo can't be made a proxy by updating it")
+		}
+		return d.updateRepo(ctx, repo, provenance, description, compression)
+	}
+	if remote != nil {
+		if len(provenance) > 0 {
+			return fmt.Errorf("a proxy repo can't have provenance")
+		}
+		if compression != pfs.Compression_UNCOMPRESSED {
+			return fmt.Errorf("a proxy repo stores data as its remote does, so it can't be compressed")
+		}
+		if err := d.checkRemote(ctx, remote); err != nil {
+			return err
+		}
+		d.featureUsage.inc("proxy_repo")
+	}
+	var lease *pfs.RepoLease
+	if ttl != 0 {
+		var err error
+		if lease, err = d.newRepoLease(ctx, repo, ttl); err != nil {
+			return err
+		}
 	}
 
 	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
@@ -262,45 +437,46 @@ func (d *driver) createRepo(ctx context.Context, repo *pfs.Repo, provenance []*p
 			}
 		}
 
-		// compute the full provenance of this repo
-		fullProv := make(map[string]bool)
-		for _, prov := range provenance {
-			fullProv[prov.Name] = true
-			provRepo := new(pfs.RepoInfo)
-			if err := repos.Get(prov.Name, provRepo); err != nil {
-				return err
-			}
-			// the provenance of my provenance is my provenance
-			for _, prov := range provRepo.Provenance {
-				fullProv[prov.Name] = true
-			}
+		if err := checkProvenance(repo.Name, provenance, d.maxProvenanceDepth, repoProvenance(repos)); err != nil {
+			return err
 		}
 
-		var fullProvRepos []*pfs.Repo
-		for prov := range fullProv {
-			fullProvRepos = append(fullProvRepos, &pfs.Repo{prov})
-			if err := repoRefCounts.Increment(prov); err != nil {
-				return err
-			}
-		}
 		if err := repoRefCounts.Create(repo.Name, 0); err != nil {
 			return err
 		}
 		repoInfo := &pfs.RepoInfo{
 			Repo:        repo,
 			Created:     now(),
-			Provenance:  fullProvRepos,
 			Description: description,
+			Remote:      remote,
+			Compression: compression,
+		}
+		if lease != nil {
+			repoInfo.Expires = lease.Expires
+			if err := d.repoLeases.ReadWrite(stm).Put(repo.Name, lease); err != nil {
+				return err
+			}
+		}
+		if err := repos.Create(repo.Name, repoInfo); err != nil {
+			return err
 		}
-		return repos.Create(repo.Name, repoInfo)
+		// the full provenance of this repo is derived from provenance
+		return d.setProvenance(stm, repo.Name, provenance, nil)
 	})
+	if err != nil && lease != nil {
+		// the lease's capability is unused, revoking it is best effort as
+		// err is the more useful error to return
+		d.revokeCapability(ctx, lease.Capability)
+	}
 	return err
 }
 
-func (d *driver) updateRepo(ctx context.Context, repo *pfs.Repo, provenance []*pfs.Repo, description string) error {
+// updateRepo sets the provenance, description and compression of repo.
+// Changing the compression only affects data written afterwards, the data
+// that's already stored stays as it is.
+func (d *driver) updateRepo(ctx context.Context, repo *pfs.Repo, provenance []*pfs.Repo, description string, compression pfs.Compression) error {
 	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
 		repos := d.repos.ReadWrite(stm)
-		repoRefCounts := d.repoRefCounts.ReadWriteInt(stm)
 
 		repoInfo := new(pfs.RepoInfo)
 		if err := repos.Get(repo.Name, repoInfo); err != nil {
@@ -312,75 +488,30 @@ func (d *driver) updateRepo(ctx context.Context, repo *pfs.Repo, provenance []*p
 			return err
 		}
 
-		provToAdd := make(map[string]bool)
-		provToRemove := make(map[string]bool)
-		for _, newProv := range provenance {
-			provToAdd[newProv.Name] = true
-		}
-		for _, oldProv := range repoInfo.Provenance {
-			delete(provToAdd, oldProv.Name)
-			provToRemove[oldProv.Name] = true
-		}
-		for _, newProv := range provenance {
-			delete(provToRemove, newProv.Name)
-		}
-
-		// For each new provenance repo, we increase its ref count
-		// by N where N is this repo's ref count.
-		// For each old provenance repo we do the opposite.
-		myRefCount, err := repoRefCounts.Get(repo.Name)
-		if err != nil {
+		if err := checkProvenance(repo.Name, provenance, d.maxProvenanceDepth, repoProvenance(repos)); err != nil {
 			return err
 		}
-		// +1 because we need to include ourselves.
-		myRefCount++
-
-		for newProv := range provToAdd {
-			if err := repoRefCounts.IncrementBy(newProv, myRefCount); err != nil {
-				return err
-			}
-		}
-
-		for oldProv := range provToRemove {
-			if err := repoRefCounts.DecrementBy(oldProv, myRefCount); err != nil {
-				return err
-			}
-		}
 
-		// We also add the new provenance repos to the provenance
-		// of all downstream repos, and remove the old provenance
-		// repos from their provenance.
-		downstreamRepos, err := d.listRepo(ctx, []*pfs.Repo{repo}, !includeAuth)
+		// The full provenance of the downstream repos, which have this repo
+		// in their full provenance, changes along with this repo's.
+		downstreamRepos, err := d.listRepo(ctx, []*pfs.Repo{repo}, "", true, !includeAuth)
 		if err != nil {
 			return err
 		}
-
+		var downstream []string
 		for _, repoInfo := range downstreamRepos.RepoInfo {
-		nextNewProv:
-			for newProv := range provToAdd {
-				for _, prov := range repoInfo.Provenance {
-					if newProv == prov.Name {
-						continue nextNewProv
-					}
-				}
-				repoInfo.Provenance = append(repoInfo.Provenance, &pfs.Repo{newProv})
-			}
-		nextOldProv:
-			for oldProv := range provToRemove {
-				for i, prov := range repoInfo.Provenance {
-					if oldProv == prov.Name {
-						repoInfo.Provenance = append(repoInfo.Provenance[:i], repoInfo.Provenance[i+1:]...)
-						continue nextOldProv
-					}
-				}
-			}
-			repos.Put(repoInfo.Repo.Name, repoInfo)
+			downstream = append(downstream, repoInfo.Repo.Name)
 		}
 
+		if repoInfo.Remote != nil && compression != pfs.Compression_UNCOMPRESSED {
+			return fmt.Errorf("a proxy repo stores data as its remote does, so it can't be compressed")
+		}
 		repoInfo.Description = description
-		repoInfo.Provenance = provenance
-		repos.Put(repo.Name, repoInfo)
-		return nil
+		repoInfo.Compression = compression
+		if err := repos.Put(repo.Name, repoInfo); err != nil {
+			return err
+		}
+		return d.setProvenance(stm, repo.Name, provenance, downstream)
 	})
 	return err
 }
@@ -425,8 +556,34 @@ func (d *driver) getAccessLevel(ctx context.Context, repo *pfs.Repo) (auth.Scope
 	return resp.Scopes[0], nil
 }
 
-func (d *driver) listRepo(ctx context.Context, provenance []*pfs.Repo, includeAuth bool) (*pfs.ListRepoResponse, error) {
+func (d *driver) listRepo(ctx context.Context, provenance []*pfs.Repo, labelSelector string, includeArchived bool, includeAuth bool) (*pfs.ListRepoResponse, error) {
+	repoInfos, err := d.listRepoInfos(ctx, provenance, labelSelector, includeArchived, 0)
+	if err != nil {
+		return nil, err
+	}
+	if includeAuth {
+		if err := d.addRepoAuthInfo(ctx, repoInfos); err != nil {
+			return nil, err
+		}
+	}
+	return &pfs.ListRepoResponse{RepoInfo: repoInfos}, nil
+}
+
+// listRepoInfos returns the repos that listRepo lists, without their auth
+// info, most recently updated first. They're read at the etcd revision rev,
+// or the current one if it's 0.
+func (d *driver) listRepoInfos(ctx context.Context, provenance []*pfs.Repo, labelSelector string, includeArchived bool, rev int64) ([]*pfs.RepoInfo, error) {
+	selector, err := parseLabelSelector(labelSelector)
+	if err != nil {
+		return nil, err
+	}
+	if len(selector) > 0 {
+		d.featureUsage.inc("label_selector")
+	}
 	repos := d.repos.ReadOnly(ctx)
+	if rev != 0 {
+		repos = d.repos.ReadOnlyAt(ctx, rev)
+	}
 	// Ensure that all provenance repos exist
 	for _, prov := range provenance {
 		repoInfo := &pfs.RepoInfo{}
@@ -439,8 +596,7 @@ func (d *driver) listRepo(ctx context.Context, provenance []*pfs.Repo, includeAu
 	if err != nil {
 		return nil, err
 	}
-	result := new(pfs.ListRepoResponse)
-	authSeemsActive := true
+	var result []*pfs.RepoInfo
 nextRepo:
 	for {
 		repoName, repoInfo := "", new(pfs.RepoInfo)
@@ -464,27 +620,62 @@ nextRepo:
 				continue nextRepo
 			}
 		}
-		if includeAuth && authSeemsActive {
-			accessLevel, err := d.getAccessLevel(ctx, repoInfo.Repo)
-			if err == nil {
-				repoInfo.AuthInfo = &pfs.RepoAuthInfo{AccessLevel: accessLevel}
-			} else if auth.IsNotActivatedError(err) {
-				authSeemsActive = false
-			} else {
-				return nil, fmt.Errorf("error getting access level for \"%s\": %v",
-					repoName, grpcutil.ScrubGRPC(err))
-			}
+		if !selector.matches(repoInfo.Labels) || (repoInfo.Archived != nil && !includeArchived) {
+			continue
 		}
-		result.RepoInfo = append(result.RepoInfo, repoInfo)
+		result = append(result, repoInfo)
 	}
 	return result, nil
 }
 
+// addRepoAuthInfo sets the auth info of repoInfos, if auth is active.
+func (d *driver) addRepoAuthInfo(ctx context.Context, repoInfos []*pfs.RepoInfo) error {
+	for _, repoInfo := range repoInfos {
+		accessLevel, err := d.getAccessLevel(ctx, repoInfo.Repo)
+		if err != nil {
+			if auth.IsNotActivatedError(err) {
+				return nil
+			}
+			return fmt.Errorf("error getting access level for \"%s\": %v",
+				repoInfo.Repo.Name, grpcutil.ScrubGRPC(err))
+		}
+		repoInfo.AuthInfo = &pfs.RepoAuthInfo{AccessLevel: accessLevel}
+	}
+	return nil
+}
+
 func (d *driver) deleteRepo(ctx context.Context, repo *pfs.Repo, force bool) error {
 	if err := d.checkIsAuthorized(ctx, repo, auth.Scope_OWNER); err != nil {
 		return err
 	}
-	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+	// The refs that the repo's commits hold on objects are removed once the
+	// repo is gone
+	objectRefs := make(map[string]int)
+	commitIter, err := d.commits(repo.Name).ReadOnly(ctx).List()
+	if err != nil {
+		return err
+	}
+	for {
+		var commitID string
+		commitInfo := new(pfs.CommitInfo)
+		ok, err := commitIter.Next(&commitID, commitInfo)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		hashes, err := d.commitObjects(ctx, commitInfo)
+		if err != nil {
+			return err
+		}
+		for _, hash := range hashes {
+			objectRefs[hash]++
+		}
+	}
+	var compactionCapability, retentionCapability, leaseCapability string
+	_, err = col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		compactionCapability, retentionCapability, leaseCapability = "", "", ""
 		repos := d.repos.ReadWrite(stm)
 		repoRefCounts := d.repoRefCounts.ReadWriteInt(stm)
 		commits := d.commits(repo.Name).ReadWrite(stm)
@@ -521,50 +712,160 @@ func (d *driver) deleteRepo(ctx context.Context, repo *pfs.Repo, force bool) err
 		}
 		commits.DeleteAll()
 		branches.DeleteAll()
+		d.commitProgress(repo.Name).ReadWrite(stm).DeleteAll()
+		if err := d.schemas.ReadWrite(stm).Delete(repo.Name); err != nil && !col.IsErrNotFound(err) {
+			return err
+		}
+		if err := d.commitHooks.ReadWrite(stm).Delete(repo.Name); err != nil && !col.IsErrNotFound(err) {
+			return err
+		}
+		if err := d.featureFlags.ReadWrite(stm).Delete(repo.Name); err != nil && !col.IsErrNotFound(err) {
+			return err
+		}
+		if err := d.repoQuotas.ReadWrite(stm).Delete(repo.Name); err != nil && !col.IsErrNotFound(err) {
+			return err
+		}
+		policies := d.compactionPolicies.ReadWrite(stm)
+		policyInfo := new(pfs.CompactionPolicyInfo)
+		if err := policies.Get(repo.Name, policyInfo); err != nil {
+			if !col.IsErrNotFound(err) {
+				return err
+			}
+		} else {
+			compactionCapability = policyInfo.Capability
+			if err := policies.Delete(repo.Name); err != nil {
+				return err
+			}
+		}
+		retentionPolicies := d.retentionPolicies.ReadWrite(stm)
+		retentionInfo := new(pfs.RetentionPolicyInfo)
+		if err := retentionPolicies.Get(repo.Name, retentionInfo); err != nil {
+			if !col.IsErrNotFound(err) {
+				return err
+			}
+		} else {
+			retentionCapability = retentionInfo.Capability
+			if err := retentionPolicies.Delete(repo.Name); err != nil {
+				return err
+			}
+		}
+		leases := d.repoLeases.ReadWrite(stm)
+		lease := new(pfs.RepoLease)
+		if err := leases.Get(repo.Name, lease); err != nil {
+			if !col.IsErrNotFound(err) {
+				return err
+			}
+		} else {
+			leaseCapability = lease.Capability
+			if err := leases.Delete(repo.Name); err != nil {
+				return err
+			}
+		}
 		return nil
 	})
 	if err != nil {
 		return err
 	}
+	d.invalidateRepoTrees(repo)
+	if err := d.removeObjectRefs(ctx, objectRefs); err != nil {
+		return err
+	}
+	if err := d.revokeCapability(ctx, compactionCapability); err != nil {
+		return err
+	}
+	if err := d.revokeCapability(ctx, retentionCapability); err != nil {
+		return err
+	}
 
 	if _, err = d.pachClient.AuthAPIClient.SetACL(auth.In2Out(ctx), &auth.SetACLRequest{
 		Repo: repo.Name, // NewACL is unset, so this will clear the acl for 'repo'
 	}); err != nil && !auth.IsNotActivatedError(err) {
 		return grpcutil.ScrubGRPC(err)
 	}
-	return nil
+	// an expired repo is deleted with its lease's capability, so it's only
+	// revoked once it's no longer needed
+	return d.revokeCapability(ctx, leaseCapability)
 }
 
-func (d *driver) startCommit(ctx context.Context, parent *pfs.Commit, branch string, provenance []*pfs.Commit) (*pfs.Commit, error) {
-	return d.makeCommit(ctx, parent, branch, provenance, nil)
+func (d *driver) startCommit(ctx context.Context, parent *pfs.Commit, branch string, provenance []*pfs.Commit, base *pfs.Commit) (*pfs.Commit, error) {
+	return d.makeCommit(ctx, parent, branch, provenance, nil, base, nil)
 }
 
 func (d *driver) buildCommit(ctx context.Context, parent *pfs.Commit, branch string, provenance []*pfs.Commit, tree *pfs.Object) (*pfs.Commit, error) {
-	return d.makeCommit(ctx, parent, branch, provenance, tree)
+	d.featureUsage.inc("build_commit")
+	return d.makeCommit(ctx, parent, branch, provenance, tree, nil, nil)
 }
 
-func (d *driver) makeCommit(ctx context.Context, parent *pfs.Commit, branch string, provenance []*pfs.Commit, treeRef *pfs.Object) (*pfs.Commit, error) {
+// makeCommit makes a commit in parent.Repo, which is finished with the tree
+// treeRef if it's set, or else open, starting with the content of base if
+// it's set, or else of its parent. If expectedHead is set, the commit is only
+// made if it's still the head of branch, in the same transaction that moves
+// the branch.
+func (d *driver) makeCommit(ctx context.Context, parent *pfs.Commit, branch string, provenance []*pfs.Commit, treeRef *pfs.Object, base *pfs.Commit, expectedHead *pfs.Commit) (*pfs.Commit, error) {
 	if err := d.checkIsAuthorized(ctx, parent.Repo, auth.Scope_WRITER); err != nil {
 		return nil, err
 	}
 	if parent == nil {
 		return nil, fmt.Errorf("parent cannot be nil")
 	}
+	if err := d.checkNotProxy(ctx, parent.Repo); err != nil {
+		return nil, err
+	}
 	commit := &pfs.Commit{
 		Repo: parent.Repo,
 		ID:   uuid.NewWithoutDashes(),
 	}
-	var tree hashtree.HashTree
+	var strictProvenance bool
+	if len(provenance) > 0 {
+		var err error
+		if strictProvenance, err = d.flagEnabled(ctx, parent.Repo, flagStrictProvenance); err != nil {
+			return nil, err
+		}
+	}
+	if base != nil {
+		if base.Repo == nil || base.Repo.Name != parent.Repo.Name {
+			return nil, fmt.Errorf("the base of a commit in %s must be in the same repo", parent.Repo.Name)
+		}
+		if treeRef != nil {
+			return nil, fmt.Errorf("a commit built from a tree can't have a base")
+		}
+		baseInfo, err := d.inspectCommit(ctx, base)
+		if err != nil {
+			return nil, err
+		}
+		if baseInfo.Finished == nil {
+			return nil, fmt.Errorf("base commit %s has not been finished", baseInfo.Commit.ID)
+		}
+		base = baseInfo.Commit
+		d.featureUsage.inc("commit_base")
+	}
+	// tree is the commit's tree if it's made from one, and newTree is the
+	// tree it starts with, which is checked against its parent's
+	var tree, newTree hashtree.HashTree
+	if base != nil {
+		baseTree, err := d.getTreeForCommit(ctx, base)
+		if err != nil {
+			return nil, err
+		}
+		newTree = baseTree
+	}
 	if treeRef != nil {
 		var buf bytes.Buffer
 		if err := d.pachClient.GetObject(treeRef.Hash, &buf); err != nil {
 			return nil, err
 		}
-		_tree, err := hashtree.Deserialize(buf.Bytes())
+		_tree, err := hashtree.DeserializeLazy(buf.Bytes(), d.getTreeChunk)
+		if err != nil {
+			return nil, err
+		}
+		tree, newTree = _tree, _tree
+		hashes, err := treeObjects(treeRef, hashtree.ChunkObjects(tree), tree)
 		if err != nil {
 			return nil, err
 		}
-		tree = _tree
+		if err := d.addObjectRefs(ctx, hashes); err != nil {
+			return nil, err
+		}
 	}
 	if _, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
 		repos := d.repos.ReadWrite(stm)
@@ -576,10 +877,27 @@ func (d *driver) makeCommit(ctx context.Context, parent *pfs.Commit, branch stri
 		if err := repos.Get(parent.Repo.Name, repoInfo); err != nil {
 			return err
 		}
+		if repoInfo.Archived != nil {
+			return pfsserver.ErrRepoArchived{Repo: parent.Repo}
+		}
 
 		commitInfo := &pfs.CommitInfo{
 			Commit:  commit,
 			Started: now(),
+			Base:    base,
+		}
+
+		var provRepos []*pfs.Repo
+		for _, prov := range provenance {
+			provRepos = append(provRepos, prov.Repo)
+		}
+		if err := checkProvenance(parent.Repo.Name, provRepos, d.maxProvenanceDepth, repoProvenance(repos)); err != nil {
+			return err
+		}
+		if strictProvenance {
+			if err := checkCommitProvenance(repoInfo, provenance); err != nil {
+				return err
+			}
 		}
 
 		// Use a map to de-dup provenance
@@ -606,6 +924,15 @@ func (d *driver) makeCommit(ctx context.Context, parent *pfs.Commit, branch stri
 		}
 
 		if branch != "" {
+			if expectedHead != nil {
+				head := new(pfs.Commit)
+				if err := branches.Get(branch, head); err != nil && !col.IsErrNotFound(err) {
+					return err
+				}
+				if head.ID != expectedHead.ID {
+					return fmt.Errorf("branch %s moved from %s", branch, expectedHead.ID)
+				}
+			}
 			// If we don't have an explicit parent we use the previous head of
 			// branch as the parent, if it exists.
 			if parent.ID == "" {
@@ -638,11 +965,17 @@ func (d *driver) makeCommit(ctx context.Context, parent *pfs.Commit, branch stri
 		if err != nil {
 			return err
 		}
+		if newTree != nil {
+			if err := d.checkTreeIsWritable(ctx, repoInfo, newTree, parentTree); err != nil {
+				return err
+			}
+		}
 		if treeRef != nil {
 			commitInfo.Tree = treeRef
 			commitInfo.SizeBytes = uint64(tree.FSSize())
 			commitInfo.Finished = now()
 			repoInfo.SizeBytes += sizeChange(tree, parentTree)
+			repoInfo.LastCommitTime = commitInfo.Finished
 			repos.Put(parent.Repo.Name, repoInfo)
 		} else {
 			d.openCommits.ReadWrite(stm).Put(commit.ID, commit)
@@ -655,10 +988,16 @@ func (d *driver) makeCommit(ctx context.Context, parent *pfs.Commit, branch stri
 	return commit, nil
 }
 
-func (d *driver) finishCommit(ctx context.Context, commit *pfs.Commit) error {
+func (d *driver) finishCommit(ctx context.Context, commit *pfs.Commit, dataCard *pfs.DataCard, stage bool) (retErr error) {
 	if err := d.checkIsAuthorized(ctx, commit.Repo, auth.Scope_WRITER); err != nil {
 		return err
 	}
+	if dataCard != nil {
+		d.featureUsage.inc("data_card")
+	}
+	if stage {
+		d.featureUsage.inc("stage_commit")
+	}
 	commitInfo, err := d.inspectCommit(ctx, commit)
 	if err != nil {
 		return err
@@ -673,7 +1012,7 @@ func (d *driver) finishCommit(ctx context.Context, commit *pfs.Commit) error {
 	}
 
 	// Read everything under the scratch space for this commit
-	resp, err := d.etcdClient.Get(ctx, prefix, etcd.WithPrefix(), etcd.WithSort(etcd.SortByModRevision, etcd.SortAscend))
+	resp, err := d.readScratch(ctx, commit, prefix)
 	if err != nil {
 		return err
 	}
@@ -682,9 +1021,32 @@ func (d *driver) finishCommit(ctx context.Context, commit *pfs.Commit) error {
 	if err != nil {
 		return err
 	}
-	tree := parentTree.Open()
+	baseTree := parentTree
+	if commitInfo.Base != nil {
+		if baseTree, err = d.getTreeForCommit(ctx, commitInfo.Base); err != nil {
+			return err
+		}
+	}
+	tree := baseTree.Open()
 
-	if err := d.applyWrites(resp, tree); err != nil {
+	progress := &progressReporter{
+		ctx:    ctx,
+		d:      d,
+		commit: commit,
+	}
+	defer func() {
+		// a commit that's finished has its progress deleted with its other
+		// bookkeeping, one that fails to finish would otherwise keep
+		// reporting it
+		if retErr != nil {
+			progress.clear()
+		}
+	}()
+	progress.progress.RecordsTotal = uint64(len(resp.Kvs))
+	if err := d.applyWrites(resp, tree, progress); err != nil {
+		return err
+	}
+	if err := d.validateSchemas(ctx, commit.Repo, tree, baseTree); err != nil {
 		return err
 	}
 
@@ -693,33 +1055,92 @@ func (d *driver) finishCommit(ctx context.Context, commit *pfs.Commit) error {
 		return err
 	}
 	// Serialize the tree
-	data, err := hashtree.Serialize(finishedTree)
+	data, chunks, err := d.serializeTree(finishedTree)
 	if err != nil {
 		return err
 	}
+	if err := progress.update(func(p *pfs.CommitProgress) {
+		p.BytesSerialized = uint64(len(data))
+	}); err != nil {
+		return err
+	}
 
 	if len(data) > 0 {
 		// Put the tree into the blob store
-		obj, _, err := d.pachClient.PutObject(bytes.NewReader(data))
+		obj, _, err := d.pachClient.PutObject(&progressReader{
+			r:        bytes.NewReader(data),
+			progress: progress,
+		})
 		if err != nil {
 			return err
 		}
 
 		commitInfo.Tree = obj
 	}
+	hashes, err := treeObjects(commitInfo.Tree, chunks, finishedTree)
+	if err != nil {
+		return err
+	}
+	if err := d.addObjectRefs(ctx, hashes); err != nil {
+		return err
+	}
 
 	commitInfo.SizeBytes = uint64(finishedTree.FSSize())
 	commitInfo.Finished = now()
+	commitInfo.DataCard = dataCard
+
+	var branchInfos []*pfs.BranchInfo
+	if stage {
+		branchInfos, err = d.listBranch(ctx, commit.Repo)
+		if err != nil {
+			return err
+		}
+	}
 
 	sizeChange := sizeChange(finishedTree, parentTree)
 	_, err = col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
 		commits := d.commits(commit.Repo.Name).ReadWrite(stm)
 		repos := d.repos.ReadWrite(stm)
 
+		if stage {
+			// Move the branches that the commit is the head of back to its
+			// parent, PublishCommit moves them forward again
+			branches := d.branches(commit.Repo.Name).ReadWrite(stm)
+			commitInfo.Staged = true
+			commitInfo.StagedBranches = nil
+			for _, branchInfo := range branchInfos {
+				head := new(pfs.Commit)
+				if err := branches.Get(branchInfo.Name, head); err != nil {
+					if col.IsErrNotFound(err) {
+						continue
+					}
+					return err
+				}
+				if head.ID != commit.ID {
+					continue
+				}
+				commitInfo.StagedBranches = append(commitInfo.StagedBranches, branchInfo.Name)
+				if commitInfo.ParentCommit != nil {
+					if err := branches.Put(branchInfo.Name, commitInfo.ParentCommit); err != nil {
+						return err
+					}
+				} else if err := branches.Delete(branchInfo.Name); err != nil {
+					return err
+				}
+			}
+		}
 		commits.Put(commit.ID, commitInfo)
 		if err := d.openCommits.ReadWrite(stm).Delete(commit.ID); err != nil {
 			return fmt.Errorf("could not confirm that commit %s is open; this is likely a bug. err: %v", commit.ID, err)
 		}
+		// Locks are only written for commits that external writers share
+		if err := d.commitLocks.ReadWrite(stm).Delete(commit.ID); err != nil && !col.IsErrNotFound(err) {
+			return err
+		}
+		// Progress is only written for commits that take a while to finish
+		if err := d.commitProgress(commit.Repo.Name).ReadWrite(stm).Delete(commit.ID); err != nil && !col.IsErrNotFound(err) {
+			return err
+		}
 		// update repo size
 		repoInfo := new(pfs.RepoInfo)
 		if err := repos.Get(commit.Repo.Name, repoInfo); err != nil {
@@ -729,6 +1150,7 @@ func (d *driver) finishCommit(ctx context.Context, commit *pfs.Commit) error {
 		// Increment the repo sizes by the sizes of the files that have
 		// been added in this commit.
 		repoInfo.SizeBytes += sizeChange
+		repoInfo.LastCommitTime = commitInfo.Finished
 		repos.Put(commit.Repo.Name, repoInfo)
 		return nil
 	})
@@ -741,23 +1163,239 @@ func (d *driver) finishCommit(ctx context.Context, commit *pfs.Commit) error {
 	return err
 }
 
-func sizeChange(tree hashtree.HashTree, parentTree hashtree.HashTree) uint64 {
-	if parentTree == nil {
-		return uint64(tree.FSSize())
+// publishCommit makes a staged commit the head of the branches that it was
+// staged from. Publishing only fast-forwards: if a branch's head isn't the
+// commit's parent anymore, publishing would drop the commits made on it
+// since, so it fails instead.
+func (d *driver) publishCommit(ctx context.Context, commit *pfs.Commit) error {
+	// publishing is how a staged commit is approved, so it's restricted to
+	// owners while writers can stage
+	if err := d.checkIsAuthorized(ctx, commit.Repo, auth.Scope_OWNER); err != nil {
+		return err
 	}
-	var result uint64
-	tree.Diff(parentTree, "", "", -1, func(path string, node *hashtree.NodeProto, new bool) error {
-		if node.FileNode != nil && new {
-			result += uint64(node.SubtreeSize)
-		}
-		return nil
-	})
-	return result
-}
+	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		commits := d.commits(commit.Repo.Name).ReadWrite(stm)
+		branches := d.branches(commit.Repo.Name).ReadWrite(stm)
 
-// inspectCommit takes a Commit and returns the corresponding CommitInfo.
-//
-// As a side effect, this function also replaces the ID in the given commit
+		commitInfo := new(pfs.CommitInfo)
+		if err := commits.Get(commit.ID, commitInfo); err != nil {
+			if col.IsErrNotFound(err) {
+				return pfsserver.ErrCommitNotFound{commit}
+			}
+			return err
+		}
+		if !commitInfo.Staged {
+			return fmt.Errorf("commit %s isn't staged", commit.FullID())
+		}
+		if isRejected(commitInfo) {
+			return fmt.Errorf("commit %s has been rejected by a review", commit.FullID())
+		}
+		for _, name := range commitInfo.StagedBranches {
+			head := new(pfs.Commit)
+			if err := branches.Get(name, head); err != nil {
+				if !col.IsErrNotFound(err) {
+					return err
+				}
+				head = nil
+			}
+			if (head == nil) != (commitInfo.ParentCommit == nil) ||
+				(head != nil && head.ID != commitInfo.ParentCommit.ID) {
+				return fmt.Errorf("branch %s has moved since commit %s was staged", name, commit.FullID())
+			}
+			if err := branches.Put(name, commitInfo.Commit); err != nil {
+				return err
+			}
+		}
+		commitInfo.Staged = false
+		commitInfo.StagedBranches = nil
+		return commits.Put(commit.ID, commitInfo)
+	})
+	return err
+}
+
+// reviewCommit records the caller's review of a finished commit.
+func (d *driver) reviewCommit(ctx context.Context, commit *pfs.Commit, decision pfs.ReviewDecision, comment string) error {
+	if err := d.checkIsAuthorized(ctx, commit.Repo, auth.Scope_WRITER); err != nil {
+		return err
+	}
+	if decision != pfs.ReviewDecision_APPROVE && decision != pfs.ReviewDecision_REJECT {
+		return fmt.Errorf("a review must approve or reject the commit")
+	}
+	d.featureUsage.inc("review_commit")
+	review := &pfs.CommitReview{
+		Time:     now(),
+		Decision: decision,
+		Comment:  comment,
+	}
+	whoAmI, err := d.pachClient.AuthAPIClient.WhoAmI(auth.In2Out(ctx), &auth.WhoAmIRequest{})
+	if err != nil && !auth.IsNotActivatedError(err) {
+		return grpcutil.ScrubGRPC(err)
+	} else if err == nil {
+		review.User = whoAmI.Username
+	}
+	// resolve branch names
+	commitInfo, err := d.inspectCommit(ctx, commit)
+	if err != nil {
+		return err
+	}
+	commit = commitInfo.Commit
+	_, err = col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		commits := d.commits(commit.Repo.Name).ReadWrite(stm)
+		commitInfo := new(pfs.CommitInfo)
+		if err := commits.Get(commit.ID, commitInfo); err != nil {
+			return err
+		}
+		if commitInfo.Finished == nil {
+			return fmt.Errorf("commit %s can't be reviewed until it's finished", commit.FullID())
+		}
+		commitInfo.Reviews = append(commitInfo.Reviews, review)
+		return commits.Put(commit.ID, commitInfo)
+	})
+	return err
+}
+
+// isRejected returns true if any reviewer's latest review of commitInfo
+// rejects it. A reviewer can withdraw their rejection by approving it later.
+func isRejected(commitInfo *pfs.CommitInfo) bool {
+	decisions := make(map[string]pfs.ReviewDecision)
+	for _, review := range commitInfo.Reviews {
+		decisions[review.User] = review.Decision
+	}
+	for _, decision := range decisions {
+		if decision == pfs.ReviewDecision_REJECT {
+			return true
+		}
+	}
+	return false
+}
+
+// listPendingApprovals returns the staged commits in repo that haven't been
+// rejected, oldest first. If branch isn't "" only the commits staged from it
+// are returned.
+func (d *driver) listPendingApprovals(ctx context.Context, repo *pfs.Repo, branch string) ([]*pfs.CommitInfo, error) {
+	if err := d.checkIsAuthorized(ctx, repo, auth.Scope_READER); err != nil {
+		return nil, err
+	}
+	iterator, err := d.commits(repo.Name).ReadOnly(ctx).List()
+	if err != nil {
+		return nil, err
+	}
+	var commitInfos []*pfs.CommitInfo
+	for {
+		var commitID string
+		commitInfo := new(pfs.CommitInfo)
+		ok, err := iterator.Next(&commitID, commitInfo)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		if !commitInfo.Staged || isRejected(commitInfo) {
+			continue
+		}
+		if branch != "" {
+			stagedFromBranch := false
+			for _, stagedBranch := range commitInfo.StagedBranches {
+				if stagedBranch == branch {
+					stagedFromBranch = true
+				}
+			}
+			if !stagedFromBranch {
+				continue
+			}
+		}
+		commitInfos = append(commitInfos, commitInfo)
+	}
+	sort.SliceStable(commitInfos, func(i, j int) bool {
+		return commitInfos[i].Started.Compare(commitInfos[j].Started) < 0
+	})
+	return commitInfos, nil
+}
+
+// progressReporter tracks the progress of a FinishCommit and periodically
+// writes it to etcd, so that it can be returned by InspectCommit. A nil
+// progressReporter discards all updates.
+type progressReporter struct {
+	ctx      context.Context
+	d        *driver
+	commit   *pfs.Commit
+	mu       sync.Mutex
+	progress pfs.CommitProgress
+	written  time.Time
+}
+
+// update applies f to the progress of the commit, and writes the result to
+// etcd if it hasn't been written within the last commitProgressInterval.
+func (p *progressReporter) update(f func(*pfs.CommitProgress)) error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f(&p.progress)
+	if time.Since(p.written) < commitProgressInterval {
+		return nil
+	}
+	p.written = time.Now()
+	_, err := col.NewSTM(p.ctx, p.d.etcdClient, func(stm col.STM) error {
+		return p.d.commitProgress(p.commit.Repo.Name).ReadWrite(stm).Put(p.commit.ID, &p.progress)
+	})
+	return err
+}
+
+// clear deletes the progress of the commit from etcd, if it was written. It's
+// done even if p.ctx is done, so that the progress of a commit that was
+// cancelled while it was being finished is cleared too.
+func (p *progressReporter) clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.written.IsZero() {
+		return
+	}
+	col.NewSTM(context.Background(), p.d.etcdClient, func(stm col.STM) error {
+		err := p.d.commitProgress(p.commit.Repo.Name).ReadWrite(stm).Delete(p.commit.ID)
+		if col.IsErrNotFound(err) {
+			return nil
+		}
+		return err
+	})
+}
+
+// progressReader counts the bytes read through it as uploaded bytes of a
+// commit's tree.
+type progressReader struct {
+	r        io.Reader
+	progress *progressReporter
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err := r.progress.update(func(p *pfs.CommitProgress) {
+		p.BytesUploaded += uint64(n)
+	}); err != nil {
+		return n, err
+	}
+	return n, err
+}
+
+func sizeChange(tree hashtree.HashTree, parentTree hashtree.HashTree) uint64 {
+	if parentTree == nil {
+		return uint64(tree.FSSize())
+	}
+	var result uint64
+	tree.Diff(parentTree, "", "", -1, func(path string, node *hashtree.NodeProto, new bool) error {
+		if node.FileNode != nil && new {
+			result += uint64(node.SubtreeSize)
+		}
+		return nil
+	})
+	return result
+}
+
+// inspectCommit takes a Commit and returns the corresponding CommitInfo.
+//
+// As a side effect, this function also replaces the ID in the given commit
 // with a real commit ID.
 func (d *driver) inspectCommit(ctx context.Context, commit *pfs.Commit) (*pfs.CommitInfo, error) {
 	if commit == nil {
@@ -766,6 +1404,13 @@ func (d *driver) inspectCommit(ctx context.Context, commit *pfs.Commit) (*pfs.Co
 	if err := d.checkIsAuthorized(ctx, commit.Repo, auth.Scope_READER); err != nil {
 		return nil, err
 	}
+	// commits of proxy repos are mirrored from their remote as they're
+	// inspected
+	if id, err := d.mirrorCommit(ctx, commit); err != nil {
+		return nil, err
+	} else if id != "" {
+		commit.ID = id
+	}
 
 	commitID, ancestryLength := parseCommitID(commit.ID)
 
@@ -807,6 +1452,14 @@ func (d *driver) inspectCommit(ctx context.Context, commit *pfs.Commit) (*pfs.Co
 	}
 
 	commit.ID = commitInfo.Commit.ID
+	if commitInfo.Finished == nil {
+		progress := new(pfs.CommitProgress)
+		if err := d.commitProgress(commit.Repo.Name).ReadOnly(ctx).Get(commit.ID, progress); err == nil {
+			commitInfo.Progress = progress
+		} else if !col.IsErrNotFound(err) {
+			return nil, err
+		}
+	}
 	return commitInfo, nil
 }
 
@@ -919,6 +1572,62 @@ func (d *driver) listCommit(ctx context.Context, repo *pfs.Repo, to *pfs.Commit,
 	return commitInfos, nil
 }
 
+func (d *driver) searchDataCards(ctx context.Context, repo *pfs.Repo, query string, license string) ([]*pfs.CommitInfo, error) {
+	var repos []*pfs.Repo
+	if repo != nil {
+		repos = append(repos, repo)
+	} else {
+		repoInfos, err := d.listRepo(ctx, nil, "", true, !includeAuth)
+		if err != nil {
+			return nil, err
+		}
+		for _, repoInfo := range repoInfos.RepoInfo {
+			repos = append(repos, repoInfo.Repo)
+		}
+	}
+	query = strings.ToLower(query)
+	var result []*pfs.CommitInfo
+	for _, r := range repos {
+		commitInfos, err := d.listCommit(ctx, r, nil, nil, 0)
+		if err != nil {
+			// a search of every repo skips the repos that the caller
+			// can't read
+			if repo == nil && auth.IsNotAuthorizedError(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, commitInfo := range commitInfos {
+			if dataCardMatches(commitInfo.DataCard, query, license) {
+				result = append(result, commitInfo)
+			}
+		}
+	}
+	return result, nil
+}
+
+// dataCardMatches returns true if card contains query (which must already be
+// lower case) and has the given license. An empty query or license matches
+// any data card, but a nil data card never matches.
+func dataCardMatches(card *pfs.DataCard, query string, license string) bool {
+	if card == nil {
+		return false
+	}
+	if license != "" && card.License != license {
+		return false
+	}
+	if query == "" || strings.Contains(strings.ToLower(card.Description), query) {
+		return true
+	}
+	for _, field := range card.Schema {
+		if strings.Contains(strings.ToLower(field.Name), query) ||
+			strings.Contains(strings.ToLower(field.Description), query) {
+			return true
+		}
+	}
+	return false
+}
+
 type commitStream struct {
 	stream chan CommitEvent
 	done   chan struct{}
@@ -932,11 +1641,21 @@ func (c *commitStream) Close() {
 	close(c.done)
 }
 
-func (d *driver) subscribeCommit(ctx context.Context, repo *pfs.Repo, branch string, from *pfs.Commit) (CommitStream, error) {
+// subscribeCommit streams the finished commits of branch, oldest first, as
+// they come in. If invalidationHints is set, each commit is sent with its
+// invalidation hint, compared to the commit sent before it.
+func (d *driver) subscribeCommit(ctx context.Context, repo *pfs.Repo, branch string, from *pfs.Commit, invalidationHints bool) (CommitStream, error) {
 	d.initializePachConn()
 	if from != nil && from.Repo.Name != repo.Name {
 		return nil, fmt.Errorf("the `from` commit needs to be from repo %s", repo.Name)
 	}
+	if err := d.checkNotArchived(ctx, repo); err != nil {
+		return nil, err
+	}
+	stopWatch, err := d.startRepoWatch(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
 
 	// We need to watch for new commits before we start listing commits,
 	// because otherwise we might miss some commits in between when we
@@ -944,6 +1663,7 @@ func (d *driver) subscribeCommit(ctx context.Context, repo *pfs.Repo, branch str
 	branches := d.branches(repo.Name).ReadOnly(ctx)
 	newCommitWatcher, err := branches.WatchOne(branch)
 	if err != nil {
+		stopWatch()
 		return nil, err
 	}
 
@@ -951,6 +1671,7 @@ func (d *driver) subscribeCommit(ctx context.Context, repo *pfs.Repo, branch str
 	done := make(chan struct{})
 
 	go func() (retErr error) {
+		defer stopWatch()
 		defer newCommitWatcher.Close()
 		defer func() {
 			if retErr != nil {
@@ -965,6 +1686,20 @@ func (d *driver) subscribeCommit(ctx context.Context, repo *pfs.Repo, branch str
 		}()
 		// keep track of the commits that have been sent
 		seen := make(map[string]bool)
+		// last is the commit sent last, which invalidation hints compare to
+		last := from
+		addHint := func(commitInfo *pfs.CommitInfo) error {
+			if !invalidationHints {
+				return nil
+			}
+			hint, err := d.invalidationHint(ctx, commitInfo, last)
+			if err != nil {
+				return err
+			}
+			commitInfo.InvalidationHint = hint
+			last = commitInfo.Commit
+			return nil
+		}
 		// include all commits that are currently on the given branch,
 		// but only the ones that have been finished
 		commitInfos, err := d.listCommit(ctx, repo, &pfs.Commit{
@@ -985,6 +1720,9 @@ func (d *driver) subscribeCommit(ctx context.Context, repo *pfs.Repo, branch str
 		for i := range commitInfos {
 			commitInfo := commitInfos[len(commitInfos)-i-1]
 			if commitInfo.Finished != nil {
+				if err := addHint(commitInfo); err != nil {
+					return err
+				}
 				select {
 				case stream <- CommitEvent{
 					Value: commitInfo,
@@ -1048,7 +1786,15 @@ func (d *driver) subscribeCommit(ctx context.Context, repo *pfs.Repo, branch str
 						// to get a new commit
 						return nil
 					}
+					if commitInfo.Staged {
+						// the commit was moved off of the branch when it
+						// was staged, it's sent when it's published
+						return nil
+					}
 					if commitInfo.Finished != nil {
+						if err := addHint(commitInfo); err != nil {
+							return err
+						}
 						select {
 						case stream <- CommitEvent{
 							Value: commitInfo,
@@ -1086,6 +1832,12 @@ func (d *driver) flushCommit(ctx context.Context, fromCommits []*pfs.Commit, toR
 
 	var repos []*pfs.Repo
 	if toRepos != nil {
+		// archived repos can't get any new commits to wait for
+		for _, repo := range toRepos {
+			if err := d.checkNotArchived(ctx, repo); err != nil {
+				return nil, err
+			}
+		}
 		repos = toRepos
 	} else {
 		var downstreamRepos []*pfs.Repo
@@ -1244,14 +1996,23 @@ func (d *driver) flushRepo(ctx context.Context, repo *pfs.Repo) ([]*pfs.RepoInfo
 		if err != nil {
 			return nil, err
 		}
+		// archived repos don't get any new commits, so they aren't flushed
+		if repoInfo.Archived != nil {
+			continue
+		}
 		repoInfos = append(repoInfos, repoInfo)
 	}
 }
 
+// deleteCommit deletes an open commit. Open commits don't hold refs on
+// objects (see addObjectRefs), so deleting one doesn't free any.
 func (d *driver) deleteCommit(ctx context.Context, commit *pfs.Commit) error {
 	if err := d.checkIsAuthorized(ctx, commit.Repo, auth.Scope_WRITER); err != nil {
 		return err
 	}
+	if err := d.checkNotArchived(ctx, commit.Repo); err != nil {
+		return err
+	}
 	commitInfo, err := d.inspectCommit(ctx, commit)
 	if err != nil {
 		return err
@@ -1286,7 +2047,7 @@ func (d *driver) deleteCommit(ctx context.Context, commit *pfs.Commit) error {
 				}
 			} else {
 				// If this commit doesn't have a parent, delete the branch
-				if err := d.deleteBranch(ctx, commit.Repo, branch.Name); err != nil {
+				if err := d.deleteBranch(ctx, commit.Repo, branch.Name, nil); err != nil {
 					return err
 				}
 			}
@@ -1304,17 +2065,32 @@ func (d *driver) deleteCommit(ctx context.Context, commit *pfs.Commit) error {
 		repoInfo.SizeBytes -= commitInfo.SizeBytes
 		repos.Put(commit.Repo.Name, repoInfo)
 
+		// Locks are only written for commits that external writers share
+		if err := d.commitLocks.ReadWrite(stm).Delete(commitInfo.Commit.ID); err != nil && !col.IsErrNotFound(err) {
+			return err
+		}
+		// Progress is only written for commits that take a while to finish
+		if err := d.commitProgress(commit.Repo.Name).ReadWrite(stm).Delete(commitInfo.Commit.ID); err != nil && !col.IsErrNotFound(err) {
+			return err
+		}
 		commits := d.commits(commit.Repo.Name).ReadWrite(stm)
 		return commits.Delete(commit.ID)
 	})
-
-	return err
+	if err != nil {
+		return err
+	}
+	d.invalidateTree(commitInfo.Commit)
+	return nil
 }
 
 func (d *driver) listBranch(ctx context.Context, repo *pfs.Repo) ([]*pfs.BranchInfo, error) {
 	if err := d.checkIsAuthorized(ctx, repo, auth.Scope_READER); err != nil {
 		return nil, err
 	}
+	repoInfo, err := d.inspectRepo(ctx, repo, !includeAuth)
+	if err != nil {
+		return nil, err
+	}
 	branches := d.branches(repo.Name).ReadOnly(ctx)
 	iterator, err := branches.List()
 	if err != nil {
@@ -1333,8 +2109,9 @@ func (d *driver) listBranch(ctx context.Context, repo *pfs.Repo) ([]*pfs.BranchI
 			break
 		}
 		res = append(res, &pfs.BranchInfo{
-			Name: path.Base(branchName),
-			Head: head,
+			Name:           path.Base(branchName),
+			Head:           head,
+			CompactInPlace: compactInPlaceAllowed(repoInfo, path.Base(branchName)),
 		})
 	}
 	return res, nil
@@ -1344,6 +2121,9 @@ func (d *driver) setBranch(ctx context.Context, commit *pfs.Commit, name string)
 	if err := d.checkIsAuthorized(ctx, commit.Repo, auth.Scope_WRITER); err != nil {
 		return err
 	}
+	if err := d.checkNotArchived(ctx, commit.Repo); err != nil {
+		return err
+	}
 	if _, err := d.inspectCommit(ctx, commit); err != nil {
 		return err
 	}
@@ -1356,18 +2136,36 @@ func (d *driver) setBranch(ctx context.Context, commit *pfs.Commit, name string)
 		if err := commits.Get(commit.ID, &commitInfo); err != nil {
 			return err
 		}
+		if commitInfo.Staged {
+			return fmt.Errorf("commit %s is staged, it can only be added to a branch by publishing it", commit.FullID())
+		}
 
 		return branches.Put(name, commit)
 	})
 	return err
 }
 
-func (d *driver) deleteBranch(ctx context.Context, repo *pfs.Repo, name string) error {
+// deleteBranch deletes the branch name of repo. If expectedHead is set, it's
+// only deleted if expectedHead is still its head.
+func (d *driver) deleteBranch(ctx context.Context, repo *pfs.Repo, name string, expectedHead *pfs.Commit) error {
 	if err := d.checkIsAuthorized(ctx, repo, auth.Scope_WRITER); err != nil {
 		return err
 	}
+	if err := d.checkNotArchived(ctx, repo); err != nil {
+		return err
+	}
 	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
 		branches := d.branches(repo.Name).ReadWrite(stm)
+		head := new(pfs.Commit)
+		if err := branches.Get(name, head); err != nil {
+			return err
+		}
+		if expectedHead != nil && head.ID != expectedHead.ID {
+			return fmt.Errorf("branch %s moved from %s", name, expectedHead.ID)
+		}
+		if err := d.keepDeletedHead(stm, repo, name, head); err != nil {
+			return err
+		}
 		return branches.Delete(name)
 	})
 	return err
@@ -1394,6 +2192,101 @@ func (d *driver) scratchFilePrefix(ctx context.Context, file *pfs.File) (string,
 	return path.Join(d.scratchPrefix(), file.Commit.Repo.Name, file.Commit.ID, file.Path), nil
 }
 
+// scratchBatchDir is the directory of a commit's scratch space that batches of
+// writes are stored in, see pfs.ScratchBatch. Paths can't contain "\x00" (see
+// checkPath), so it's never the scratch prefix of a file.
+const scratchBatchDir = "\x00batch"
+
+// scratchBatchMaxCommits is the most commits that a PutFiles batch can write
+// to, as it's written in one etcd transaction with an operation per commit
+// (etcd allows 128 operations per transaction by default)
+const scratchBatchMaxCommits = 100
+
+// scratchBatchPrefix returns the etcd prefix that the batches of writes to
+// commit are stored under.
+func (d *driver) scratchBatchPrefix(commit *pfs.Commit) string {
+	return path.Join(d.scratchPrefix(), commit.Repo.Name, commit.ID, scratchBatchDir)
+}
+
+// putScratchBatch stores writes, which are all to commit, as a single batch
+// record, provided that commit is still open.
+func (d *driver) putScratchBatch(ctx context.Context, commit *pfs.Commit, writes []*pfs.ScratchWrite) error {
+	if len(writes) == 0 {
+		return nil
+	}
+	value, err := (&pfs.ScratchBatch{Writes: writes}).Marshal()
+	if err != nil {
+		return err
+	}
+	txnResp, err := etcd.NewKV(d.etcdClient).Txn(ctx).
+		If(etcd.Compare(etcd.CreateRevision(d.openCommits.Path(commit.ID)), ">", 0)).
+		Then(etcd.OpPut(path.Join(d.scratchBatchPrefix(commit), uuid.NewWithoutDashes()), string(value))).Commit()
+	if err != nil {
+		return err
+	}
+	if !txnResp.Succeeded {
+		return fmt.Errorf("commit %v is not open", commit.ID)
+	}
+	return nil
+}
+
+// readScratch reads the writes under prefix in commit's scratch space,
+// including those in batches, sorted by ModRevision.
+func (d *driver) readScratch(ctx context.Context, commit *pfs.Commit, prefix string) (*etcd.GetResponse, error) {
+	resp, err := d.etcdClient.Get(ctx, prefix, etcd.WithPrefix(), etcd.WithSort(etcd.SortByModRevision, etcd.SortAscend))
+	if err != nil {
+		return nil, err
+	}
+	batchPrefix := d.scratchBatchPrefix(commit)
+	if !strings.HasPrefix(batchPrefix, prefix) {
+		batchResp, err := d.etcdClient.Get(ctx, batchPrefix, etcd.WithPrefix(), etcd.WithRev(resp.Header.Revision))
+		if err != nil {
+			return nil, err
+		}
+		resp.Kvs = append(resp.Kvs, batchResp.Kvs...)
+	}
+	if resp.Kvs, err = d.expandScratchBatches(resp.Kvs, prefix); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(resp.Kvs, func(i, j int) bool {
+		return resp.Kvs[i].ModRevision < resp.Kvs[j].ModRevision
+	})
+	return resp, nil
+}
+
+// expandScratchBatches replaces each batch in kvs with the writes in it that
+// are under prefix, as if each had been stored under its own key. The writes
+// keep their batch's ModRevision, and their keys are suffixed with their index
+// in it, so applyWrites applies them in order.
+func (d *driver) expandScratchBatches(kvs []*mvccpb.KeyValue, prefix string) ([]*mvccpb.KeyValue, error) {
+	var result []*mvccpb.KeyValue
+	for _, kv := range kvs {
+		key := string(kv.Key)
+		dir := path.Dir(key)
+		if path.Base(dir) != scratchBatchDir {
+			result = append(result, kv)
+			continue
+		}
+		batch := &pfs.ScratchBatch{}
+		if err := batch.Unmarshal(kv.Value); err != nil {
+			return nil, err
+		}
+		for i, write := range batch.Writes {
+			writeKey := path.Join(path.Dir(dir), write.Path, fmt.Sprintf("%s%08d", path.Base(key), i))
+			if !strings.HasPrefix(writeKey, prefix) {
+				continue
+			}
+			result = append(result, &mvccpb.KeyValue{
+				Key:            []byte(writeKey),
+				Value:          write.Value,
+				CreateRevision: kv.CreateRevision,
+				ModRevision:    kv.ModRevision,
+			})
+		}
+	}
+	return result, nil
+}
+
 func (d *driver) filePathFromEtcdPath(etcdPath string) string {
 	trimmed := strings.TrimPrefix(etcdPath, d.scratchPrefix())
 	// trimmed looks like /repo/commit/path/to/file
@@ -1403,19 +2296,39 @@ func (d *driver) filePathFromEtcdPath(etcdPath string) string {
 	return path.Join(split[3:]...)
 }
 
-// checkPath checks if a file path is legal
+// checkPath checks if a file path is legal, and that it can be pulled
 func checkPath(path string) error {
 	if strings.Contains(path, "\x00") {
 		return fmt.Errorf("filename cannot contain null character: %s", path)
 	}
-	return nil
+	return pfssync.CheckPullable(path)
 }
 
-func (d *driver) putFile(ctx context.Context, file *pfs.File, delimiter pfs.Delimiter,
-	targetFileDatums int64, targetFileBytes int64, overwriteIndex *pfs.OverwriteIndex, reader io.Reader) error {
+func (d *driver) putFile(ctx context.Context, file *pfs.File, options putFileOptions, reader io.Reader) (*pfs.PutFileResponse, error) {
 	if err := d.checkIsAuthorized(ctx, file.Commit.Repo, auth.Scope_WRITER); err != nil {
-		return err
+		return nil, err
+	}
+	if err := d.checkRepoQuota(ctx, file.Commit.Repo, repoOpPutFile); err != nil {
+		return nil, err
+	}
+	if err := d.checkPathIsWritable(ctx, file, false); err != nil {
+		return nil, err
+	}
+	if err := options.check(); err != nil {
+		return nil, err
+	}
+	split, err := newSeparatorSplit(options.delimiter, options.separator, options.separatorRegex)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := parseJSONSchema(options.delimiter, options.jsonSchema)
+	if err != nil {
+		return nil, err
+	}
+	if options.mode, err = d.putFileMode(ctx, file.Commit.Repo, options.mode, options.overwriteIndex); err != nil {
+		return nil, err
 	}
+	d.featureUsage.putFile(options)
 	// Check if the commit ID is a branch name.  If so, we have to
 	// get the real commit ID in order to check if the commit does exist
 	// and is open.
@@ -1424,59 +2337,368 @@ func (d *driver) putFile(ctx context.Context, file *pfs.File, delimiter pfs.Deli
 	if len(file.Commit.ID) != uuid.UUIDWithoutDashesLength || file.Commit.ID[12] != '4' {
 		commitInfo, err := d.inspectCommit(ctx, file.Commit)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		file.Commit = commitInfo.Commit
 	}
 
-	if overwriteIndex != nil && overwriteIndex.Index == 0 {
+	if options.overwriteIndex != nil && options.overwriteIndex.Index == 0 {
 		if err := d.deleteFile(ctx, file); err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	records := &pfs.PutFileRecords{}
 	if err := checkPath(file.Path); err != nil {
-		return err
+		return nil, err
+	}
+	if options.mode == pfs.PutFileMode_CREATE_ONLY && options.delimiter != pfs.Delimiter_TAR {
+		if err := d.checkFileNotExists(ctx, file); err != nil {
+			return nil, err
+		}
 	}
 	prefix, err := d.scratchFilePrefix(ctx, file)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	compression, storageClass, err := d.repoCompression(ctx, file.Commit.Repo)
+	if err != nil {
+		return nil, err
 	}
 
-	// Put the tree into the blob store
-	// Only write the records to etcd if the commit does exist and is open.
+	response := &pfs.PutFileResponse{}
+	if options.delimiter == pfs.Delimiter_TAR {
+		// an archive can have more entries than etcd allows operations in a
+		// transaction, so they're written as one batch
+		var writes []*pfs.ScratchWrite
+		if options.mode == pfs.PutFileMode_OVERWRITE {
+			// the archive replaces everything under file.Path, the tombstone
+			// is written in the same batch as the entries
+			writes = append(writes, &pfs.ScratchWrite{Path: file.Path, Value: []byte(tombstone)})
+			options.mode = pfs.PutFileMode_APPEND
+		}
+		if err := d.putFileTar(file, options.mode, compression, storageClass, reader, func(entry *pfs.File, records *pfs.PutFileRecords) error {
+			marshalledRecords, err := records.Marshal()
+			if err != nil {
+				return err
+			}
+			writes = append(writes, &pfs.ScratchWrite{Path: entry.Path, Value: marshalledRecords})
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		if err := d.putScratchBatch(ctx, file.Commit, writes); err != nil {
+			return nil, err
+		}
+		return response, nil
+	}
+
+	var ops []etcd.Op
+	var errs *splitErrors
+	if options.divertErrors {
+		errs = &splitErrors{}
+	}
+	strict, err := d.newStrictSplitReader(ctx, file.Commit.Repo, options.delimiter, reader)
+	if err != nil {
+		return nil, err
+	}
+	if strict != nil {
+		reader = strict
+	}
+	records, err := d.putFileRecords(options, compression, storageClass, errs, schema, split, reader)
+	if err != nil {
+		return nil, err
+	}
+	if strict != nil {
+		if err := strict.check(); err != nil {
+			return nil, err
+		}
+	}
+	marshalledRecords, err := records.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	ops = append(ops, etcd.OpPut(path.Join(prefix, uuid.NewWithoutDashes()), string(marshalledRecords)))
+	response.RecordsWritten = recordsWritten(records)
+	if errs != nil && errs.diverted > 0 {
+		errorsFile := client.NewFile(file.Commit.Repo.Name, file.Commit.ID, splitErrorsPath(file.Path))
+		errorRecords, err := d.putFileRecords(putFileOptions{}, compression, storageClass, nil, nil, nil, &errs.buffer)
+		if err != nil {
+			return nil, err
+		}
+		marshalledRecords, err := errorRecords.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		errorsPrefix, err := d.scratchFilePrefix(ctx, errorsFile)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, etcd.OpPut(path.Join(errorsPrefix, uuid.NewWithoutDashes()), string(marshalledRecords)))
+		response.RecordsDiverted = errs.diverted
+		response.ErrorsPath = errorsFile.Path
+	}
+
+	// Only write the records to etcd if the commit does exist and is open.
 	// To check that a key exists in etcd, we assert that its CreateRevision
 	// is greater than zero.
-	putRecords := func() error {
-		marshalledRecords, err := records.Marshal()
+	kvc := etcd.NewKV(d.etcdClient)
+	txnResp, err := kvc.Txn(ctx).
+		If(etcd.Compare(etcd.CreateRevision(d.openCommits.Path(file.Commit.ID)), ">", 0)).Then(ops...).Commit()
+	if err != nil {
+		return nil, err
+	}
+	if !txnResp.Succeeded {
+		return nil, fmt.Errorf("commit %v is not open", file.Commit.ID)
+	}
+	return response, nil
+}
+
+// putFileTar extracts the tar archive in reader, which may be gzipped, under
+// file.Path. It puts each regular file in the archive into the blob store,
+// compressed with compression and in storageClass, and calls write with its
+// records, other entries, such as directories and links, are skipped.
+func (d *driver) putFileTar(file *pfs.File, mode pfs.PutFileMode, compression pfs.Compression, storageClass string, reader io.Reader, write func(*pfs.File, *pfs.PutFileRecords) error) error {
+	bufioR := bufio.NewReader(reader)
+	var r io.Reader = bufioR
+	if magic, err := bufioR.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzipR, err := gzip.NewReader(bufioR)
 		if err != nil {
 			return err
 		}
-		kvc := etcd.NewKV(d.etcdClient)
-
-		txnResp, err := kvc.Txn(ctx).
-			If(etcd.Compare(etcd.CreateRevision(d.openCommits.Path(file.Commit.ID)), ">", 0)).Then(etcd.OpPut(path.Join(prefix, uuid.NewWithoutDashes()), string(marshalledRecords))).Commit()
+		defer gzipR.Close()
+		r = gzipR
+	}
+	tarR := tar.NewReader(r)
+	for {
+		hdr, err := tarR.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+			continue
+		}
+		// Cleaning the name as an absolute path keeps entries like
+		// "../foo" inside file.Path
+		entry := client.NewFile(file.Commit.Repo.Name, file.Commit.ID, path.Join(file.Path, path.Clean("/"+hdr.Name)))
+		if err := checkPath(entry.Path); err != nil {
+			return err
+		}
+		records, err := d.putFileRecords(putFileOptions{mode: mode}, compression, storageClass, nil, nil, nil, tarR)
 		if err != nil {
 			return err
 		}
-		if !txnResp.Succeeded {
-			return fmt.Errorf("commit %v is not open", file.Commit.ID)
+		if err := write(entry, records); err != nil {
+			return err
 		}
+	}
+}
+
+// putFileOptions say how the data of a PutFile is split and written, they're
+// the fields of the PutFileRequest that do. The zero value puts the data as
+// one file, appending to it.
+type putFileOptions struct {
+	delimiter        pfs.Delimiter
+	targetFileDatums int64
+	targetFileBytes  int64
+	targetFileCount  int64
+	overwriteIndex   *pfs.OverwriteIndex
+	mode             pfs.PutFileMode
+	computeStats     bool
+	divertErrors     bool
+	headerLines      int64
+	footerLines      int64
+	separator        []byte
+	separatorRegex   string
+	jsonSchema       []byte
+}
+
+func newPutFileOptions(request *pfs.PutFileRequest) putFileOptions {
+	return putFileOptions{
+		delimiter:        request.Delimiter,
+		targetFileDatums: request.TargetFileDatums,
+		targetFileBytes:  request.TargetFileBytes,
+		targetFileCount:  request.TargetFileCount,
+		overwriteIndex:   request.OverwriteIndex,
+		mode:             request.Mode,
+		computeStats:     request.ComputeStats,
+		divertErrors:     request.DivertErrors,
+		headerLines:      request.HeaderLines,
+		footerLines:      request.FooterLines,
+		separator:        request.Separator,
+		separatorRegex:   request.SeparatorRegex,
+		jsonSchema:       request.JsonSchema,
+	}
+}
+
+// check returns an error if the options can't be used together.
+func (o putFileOptions) check() error {
+	if err := checkComputeStats(o.delimiter, o.computeStats); err != nil {
+		return err
+	}
+	if err := checkDivertErrors(o.delimiter, o.divertErrors); err != nil {
+		return err
+	}
+	if err := checkSplitFrame(o.delimiter, o.headerLines, o.footerLines); err != nil {
+		return err
+	}
+	if err := checkTargetFileCount(o.delimiter, o.targetFileDatums, o.targetFileBytes, o.targetFileCount); err != nil {
+		return err
+	}
+	return checkPutFileMode(o.mode, o.overwriteIndex)
+}
+
+// checkComputeStats returns an error if stats can't be computed for data
+// split with delimiter.
+func checkComputeStats(delimiter pfs.Delimiter, computeStats bool) error {
+	if computeStats && delimiter != pfs.Delimiter_JSON && delimiter != pfs.Delimiter_LINE {
+		return fmt.Errorf("stats can only be computed for data split by JSON or LINE, not %s", delimiter)
+	}
+	return nil
+}
+
+// checkPutFileMode returns an error if mode can't be used with
+// overwriteIndex, which only makes sense when appending.
+func checkPutFileMode(mode pfs.PutFileMode, overwriteIndex *pfs.OverwriteIndex) error {
+	if mode != pfs.PutFileMode_APPEND && overwriteIndex != nil {
+		return fmt.Errorf("an overwrite index can't be used with the %s put file mode", mode)
+	}
+	return nil
+}
+
+// checkFileNotExists returns ErrFileExists if file exists in its commit,
+// including if it's been written to the commit while it's open.
+func (d *driver) checkFileNotExists(ctx context.Context, file *pfs.File) error {
+	tree, err := d.getTreeForFile(ctx, file)
+	if err != nil {
+		return err
+	}
+	if _, err := tree.Get(file.Path); err == nil {
+		return pfsserver.ErrFileExists{file}
+	} else if hashtree.Code(err) != hashtree.PathNotFound {
+		return err
+	}
+	return nil
+}
+
+// tableStats accumulates the stats of the rows of a split file. NDJSON rows
+// contribute their top level scalar fields, CSV rows are named by the header
+// on the first line.
+type tableStats struct {
+	delimiter pfs.Delimiter
+	header    []string
+	stats     *pfs.TableStats
+	columns   map[string]*pfs.ColumnStats
+}
+
+func newTableStats(delimiter pfs.Delimiter) *tableStats {
+	s := &tableStats{delimiter: delimiter}
+	s.reset()
+	return s
+}
+
+func (s *tableStats) reset() {
+	s.stats = &pfs.TableStats{}
+	s.columns = make(map[string]*pfs.ColumnStats)
+}
+
+// flush returns the stats of the rows added since the last call to flush.
+func (s *tableStats) flush() *pfs.TableStats {
+	stats := s.stats
+	s.reset()
+	return stats
+}
+
+func (s *tableStats) add(value []byte) error {
+	if len(bytes.TrimSpace(value)) == 0 {
 		return nil
 	}
+	switch s.delimiter {
+	case pfs.Delimiter_JSON:
+		var row map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(value))
+		decoder.UseNumber()
+		if err := decoder.Decode(&row); err != nil {
+			return fmt.Errorf("cannot compute stats for JSON value that isn't an object: %v", err)
+		}
+		var names []string
+		for name := range row {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			switch v := row[name].(type) {
+			case json.Number:
+				s.observe(name, v.String())
+			case string:
+				s.observe(name, v)
+			case bool:
+				s.observe(name, strconv.FormatBool(v))
+			}
+		}
+	case pfs.Delimiter_LINE:
+		reader := csv.NewReader(bytes.NewReader(value))
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+		record, err := reader.Read()
+		if err != nil {
+			return fmt.Errorf("cannot compute stats for malformed CSV line: %v", err)
+		}
+		if s.header == nil {
+			s.header = record
+			return nil
+		}
+		for i, v := range record {
+			name := strconv.Itoa(i)
+			if i < len(s.header) {
+				name = s.header[i]
+			}
+			s.observe(name, v)
+		}
+	}
+	s.stats.RowCount++
+	return nil
+}
+
+func (s *tableStats) observe(name string, value string) {
+	column, ok := s.columns[name]
+	if !ok {
+		column = &pfs.ColumnStats{Name: name}
+		s.columns[name] = column
+		s.stats.Columns = append(s.stats.Columns, column)
+	}
+	if value != "" {
+		column.Observe(value)
+	}
+}
 
-	if delimiter == pfs.Delimiter_NONE {
-		objects, size, err := d.pachClient.PutObjectSplit(reader)
+// putFileRecords puts the data in reader into the blob store and returns the
+// records that should be written to etcd for it. If errs isn't nil, the
+// records that can't be parsed are diverted to it instead of failing the
+// write. The data is split as options say, see putFileOptions: the header
+// and footer lines of data split by LINE are put in their own objects, which
+// every split file starts and ends with, and if a target file count is set,
+// finding the number of datums in each file takes a pass over the data to
+// count its records first. Data split by SEPARATOR is split into records by
+// split, and data split by PARQUET is split on its row groups. The objects
+// that the data is put in are compressed with compression, and written in
+// storageClass.
+func (d *driver) putFileRecords(options putFileOptions, compression pfs.Compression, storageClass string, errs *splitErrors, schema *jsonSchema, split bufio.SplitFunc, reader io.Reader) (*pfs.PutFileRecords, error) {
+	records := &pfs.PutFileRecords{Mode: options.mode}
+	if options.delimiter == pfs.Delimiter_NONE {
+		objects, size, err := d.putObjectSplit(compression, storageClass, reader)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		// Here we use the invariant that every one but the last object
 		// should have a size of ChunkSize.
 		for i, object := range objects {
 			record := &pfs.PutFileRecord{
-				ObjectHash: object.Hash,
+				ObjectHash:  object.Hash,
+				Compression: compression,
 			}
 
 			if size > pfs.ChunkSize {
@@ -1487,169 +2709,686 @@ func (d *driver) putFile(ctx context.Context, file *pfs.File, delimiter pfs.Deli
 			size -= pfs.ChunkSize
 
 			// The first record takes care of the overwriting
-			if i == 0 && overwriteIndex != nil && overwriteIndex.Index != 0 {
-				record.OverwriteIndex = overwriteIndex
+			if i == 0 && options.overwriteIndex != nil && options.overwriteIndex.Index != 0 {
+				record.OverwriteIndex = options.overwriteIndex
 			}
 
 			records.Records = append(records.Records, record)
 		}
 
-		return putRecords()
+		return records, nil
+	}
+	if options.delimiter == pfs.Delimiter_PARQUET {
+		var err error
+		if records.Records, err = d.putParquetRecords(compression, storageClass, options.targetFileDatums, options.targetFileBytes, reader); err != nil {
+			return nil, err
+		}
+		records.Split = true
+		records.Delimiter = options.delimiter
+		return records, nil
+	}
+	// targets are the number of datums in each file, when the number of files
+	// is set
+	var targets []int64
+	if options.targetFileCount > 0 {
+		var keep func(record []byte) bool
+		if errs != nil {
+			// records that are diverted don't end up in any of the files
+			keep = func(record []byte) bool {
+				return errs.check(options.delimiter, record, schema, nil) == nil
+			}
+		}
+		spooled, count, err := spoolRecords(options.delimiter, options.headerLines, options.footerLines, keep, reader)
+		if err != nil {
+			return nil, err
+		}
+		defer spooled.Close()
+		reader = spooled
+		targets = fileDatums(count, options.targetFileCount)
+		if len(targets) > 0 {
+			options.targetFileDatums = targets[0]
+		}
 	}
 	buffer := &bytes.Buffer{}
 	var datumsWritten int64
 	var bytesWritten int64
+	var recordsWritten int64
 	var filesPut int
 	EOF := false
 	var eg errgroup.Group
 	decoder := json.NewDecoder(reader)
 	bufioR := bufio.NewReader(reader)
+	var scanner *bufio.Scanner
+	if split != nil {
+		scanner = newSeparatorScanner(bufioR, split)
+	}
 
 	indexToRecord := make(map[int]*pfs.PutFileRecord)
 	var mu sync.Mutex
+	var stats *tableStats
+	if options.computeStats {
+		stats = newTableStats(options.delimiter)
+	}
+	var lines lineReader = bufioR
+	var framed *framedLineReader
+	if options.headerLines > 0 || options.footerLines > 0 {
+		framed = newFramedLineReader(bufioR, options.footerLines)
+		lines = framed
+		header, lineCount, err := framed.readHeader(options.headerLines)
+		if err != nil {
+			return nil, err
+		}
+		if lineCount > 0 {
+			object, size, err := d.putObject(compression, storageClass, bytes.NewReader(header))
+			if err != nil {
+				return nil, err
+			}
+			records.Header = &pfs.PutFileRecord{
+				SizeBytes:   size,
+				ObjectHash:  object.Hash,
+				RecordCount: lineCount,
+				Compression: compression,
+			}
+		}
+		if stats != nil {
+			// the header names the columns, but its lines aren't rows
+			for _, line := range bytes.SplitAfter(header, []byte{'\n'}) {
+				if err := stats.add(line); err != nil {
+					return nil, err
+				}
+			}
+			stats.reset()
+		}
+		if errs != nil {
+			errs.line = lineCount
+		}
+	}
 	for !EOF {
 		var err error
 		var value []byte
-		switch delimiter {
-		case pfs.Delimiter_JSON:
+		switch {
+		case options.delimiter == pfs.Delimiter_JSON && errs != nil:
+			// the decoder can't carry on after a malformed value, so when
+			// errors are diverted each value has to be on its own line
+			value, err = bufioR.ReadBytes('\n')
+		case options.delimiter == pfs.Delimiter_JSON:
 			var jsonValue json.RawMessage
 			err = decoder.Decode(&jsonValue)
 			value = jsonValue
-		case pfs.Delimiter_LINE:
-			value, err = bufioR.ReadBytes('\n')
+		case options.delimiter == pfs.Delimiter_LINE:
+			value, err = lines.ReadBytes('\n')
+		case options.delimiter == pfs.Delimiter_SEPARATOR:
+			if scanner.Scan() {
+				value = scanner.Bytes()
+			} else if err = scanner.Err(); err == nil {
+				err = io.EOF
+			}
 		default:
-			return fmt.Errorf("unrecognized delimiter %s", delimiter.String())
+			return nil, fmt.Errorf("unrecognized delimiter %s", options.delimiter.String())
 		}
 		if err != nil {
 			if err == io.EOF {
 				EOF = true
 			} else {
-				return err
+				return nil, err
+			}
+		}
+		if errs != nil && len(value) > 0 {
+			errs.line++
+			if options.delimiter == pfs.Delimiter_JSON {
+				value = bytes.TrimSpace(value)
+			}
+			if reason := errs.check(options.delimiter, value, schema, stats); reason != nil {
+				if err := errs.divert(value, reason); err != nil {
+					return nil, err
+				}
+				if !EOF {
+					continue
+				}
+				value = nil
+			}
+		} else {
+			if schema != nil && len(value) > 0 {
+				if err := schema.validate(value); err != nil {
+					return nil, fmt.Errorf("record %d %v", recordsWritten+1, err)
+				}
+			}
+			if stats != nil {
+				if err := stats.add(value); err != nil {
+					return nil, err
+				}
 			}
 		}
 		buffer.Write(value)
 		bytesWritten += int64(len(value))
 		datumsWritten++
+		if len(value) > 0 {
+			recordsWritten++
+		}
 		if buffer.Len() != 0 &&
-			((targetFileBytes != 0 && bytesWritten >= targetFileBytes) ||
-				(targetFileDatums != 0 && datumsWritten >= targetFileDatums) ||
-				(targetFileBytes == 0 && targetFileDatums == 0) ||
+			((options.targetFileBytes != 0 && bytesWritten >= options.targetFileBytes) ||
+				(options.targetFileDatums != 0 && datumsWritten >= options.targetFileDatums) ||
+				(options.targetFileBytes == 0 && options.targetFileDatums == 0) ||
 				EOF) {
 			_buffer := buffer
 			index := filesPut
+			recordCount := recordsWritten
+			var chunkStats *pfs.TableStats
+			if stats != nil {
+				chunkStats = stats.flush()
+			}
 			eg.Go(func() error {
-				object, size, err := d.pachClient.PutObject(_buffer)
+				object, size, err := d.putObject(compression, storageClass, _buffer)
 				if err != nil {
 					return err
 				}
 				mu.Lock()
 				defer mu.Unlock()
 				indexToRecord[index] = &pfs.PutFileRecord{
-					SizeBytes:  size,
-					ObjectHash: object.Hash,
+					SizeBytes:   size,
+					ObjectHash:  object.Hash,
+					Stats:       chunkStats,
+					RecordCount: recordCount,
+					Compression: compression,
 				}
 				return nil
 			})
 			datumsWritten = 0
 			bytesWritten = 0
+			recordsWritten = 0
 			buffer = &bytes.Buffer{}
 			filesPut++
+			if filesPut < len(targets) {
+				options.targetFileDatums = targets[filesPut]
+			}
 		}
 	}
 	if err := eg.Wait(); err != nil {
-		return err
+		return nil, err
+	}
+	if framed != nil {
+		if footer, lineCount := framed.footer(); lineCount > 0 {
+			object, size, err := d.putObject(compression, storageClass, bytes.NewReader(footer))
+			if err != nil {
+				return nil, err
+			}
+			records.Footer = &pfs.PutFileRecord{
+				SizeBytes:   size,
+				ObjectHash:  object.Hash,
+				RecordCount: lineCount,
+				Compression: compression,
+			}
+		}
 	}
 
 	records.Split = true
+	records.Delimiter = options.delimiter
 	for i := 0; i < len(indexToRecord); i++ {
 		records.Records = append(records.Records, indexToRecord[i])
 	}
+	return records, nil
+}
 
-	return putRecords()
+// putFilesBatch accumulates the writes made by a PutFiles call so that they
+// can be written to etcd in a single transaction. Its methods mirror putFile
+// and deleteFile, but nothing is visible in any commit until commit is
+// called.
+type putFilesBatch struct {
+	ctx context.Context
+	d   *driver
+	// writes are the batch's writes to each commit, keyed by commit ID,
+	// they're stored as one pfs.ScratchBatch per commit so that the batch
+	// takes one operation per commit however many writes it has
+	writes map[string][]*pfs.ScratchWrite
+	// commits are the commits the batch writes to, keyed by repo and ID
+	commits map[string]*pfs.Commit
 }
 
-func (d *driver) copyFile(ctx context.Context, src *pfs.File, dst *pfs.File, overwrite bool) error {
-	if err := d.checkIsAuthorized(ctx, src.Commit.Repo, auth.Scope_READER); err != nil {
+func (d *driver) newPutFilesBatch(ctx context.Context) *putFilesBatch {
+	d.featureUsage.inc("put_files")
+	return &putFilesBatch{
+		ctx:     ctx,
+		d:       d,
+		writes:  make(map[string][]*pfs.ScratchWrite),
+		commits: make(map[string]*pfs.Commit),
+	}
+}
+
+// resolveCommit checks that the batch can write to file's commit and
+// replaces its ID with a real commit ID if it's a branch name.
+func (b *putFilesBatch) resolveCommit(file *pfs.File) error {
+	key := path.Join(file.Commit.Repo.Name, file.Commit.ID)
+	if commit, ok := b.commits[key]; ok {
+		file.Commit = commit
+		return nil
+	}
+	if err := b.d.checkIsAuthorized(b.ctx, file.Commit.Repo, auth.Scope_WRITER); err != nil {
 		return err
 	}
-	if err := d.checkIsAuthorized(ctx, dst.Commit.Repo, auth.Scope_WRITER); err != nil {
+	commitInfo, err := b.d.inspectCommit(b.ctx, file.Commit)
+	if err != nil {
 		return err
 	}
-	if err := checkPath(dst.Path); err != nil {
+	if commitInfo.Finished != nil {
+		return pfsserver.ErrCommitFinished{file.Commit}
+	}
+	b.commits[key] = commitInfo.Commit
+	file.Commit = commitInfo.Commit
+	return nil
+}
+
+func (b *putFilesBatch) write(file *pfs.File, value string) error {
+	b.writes[file.Commit.ID] = append(b.writes[file.Commit.ID], &pfs.ScratchWrite{
+		Path:  file.Path,
+		Value: []byte(value),
+	})
+	return nil
+}
+
+func (b *putFilesBatch) putFile(file *pfs.File, options putFileOptions, reader io.Reader) error {
+	if err := b.resolveCommit(file); err != nil {
 		return err
 	}
-	// Check if the commit ID is a branch name.  If so, we have to
-	// get the real commit ID in order to check if the commit does exist
-	// and is open.
-	// Since we use UUIDv4 for commit IDs, the 13th character would be 4 if
-	// this is a commit ID.
-	if len(dst.Commit.ID) != uuid.UUIDWithoutDashesLength || dst.Commit.ID[12] != '4' {
-		commitInfo, err := d.inspectCommit(ctx, dst.Commit)
-		if err != nil {
+	if err := b.d.checkRepoQuota(b.ctx, file.Commit.Repo, repoOpPutFile); err != nil {
+		return err
+	}
+	if err := b.d.checkPathIsWritable(b.ctx, file, false); err != nil {
+		return err
+	}
+	if err := options.check(); err != nil {
+		return err
+	}
+	split, err := newSeparatorSplit(options.delimiter, options.separator, options.separatorRegex)
+	if err != nil {
+		return err
+	}
+	schema, err := parseJSONSchema(options.delimiter, options.jsonSchema)
+	if err != nil {
+		return err
+	}
+	if options.mode, err = b.d.putFileMode(b.ctx, file.Commit.Repo, options.mode, options.overwriteIndex); err != nil {
+		return err
+	}
+	b.d.featureUsage.putFile(options)
+	if err := checkPath(file.Path); err != nil {
+		return err
+	}
+	if options.mode == pfs.PutFileMode_CREATE_ONLY && options.delimiter != pfs.Delimiter_TAR {
+		if err := b.d.checkFileNotExists(b.ctx, file); err != nil {
 			return err
 		}
-		dst.Commit = commitInfo.Commit
 	}
-	if overwrite {
-		if err := d.deleteFile(ctx, dst); err != nil {
+	if options.overwriteIndex != nil && options.overwriteIndex.Index == 0 {
+		if err := b.write(file, tombstone); err != nil {
 			return err
 		}
 	}
-	srcTree, err := d.getTreeForFile(ctx, src)
+	compression, storageClass, err := b.d.repoCompression(b.ctx, file.Commit.Repo)
 	if err != nil {
 		return err
 	}
-	// This is necessary so we can call filepath.Rel below
-	if !strings.HasPrefix(src.Path, "/") {
-		src.Path = "/" + src.Path
-	}
-	var eg errgroup.Group
-	if err := srcTree.Walk(src.Path, func(walkPath string, node *hashtree.NodeProto) error {
-		if node.FileNode == nil {
-			return nil
-		}
-		eg.Go(func() error {
-			relPath, err := filepath.Rel(src.Path, walkPath)
-			if err != nil {
-				// This shouldn't be possible
-				return fmt.Errorf("error from filepath.Rel: %+v (this is likely a bug)", err)
-			}
-			records := &pfs.PutFileRecords{}
-			file := client.NewFile(dst.Commit.Repo.Name, dst.Commit.ID, path.Clean(path.Join(dst.Path, relPath)))
-			prefix, err := d.scratchFilePrefix(ctx, file)
-			if err != nil {
+	if options.delimiter == pfs.Delimiter_TAR {
+		if options.mode == pfs.PutFileMode_OVERWRITE {
+			// the archive replaces everything under file.Path
+			if err := b.write(file, tombstone); err != nil {
 				return err
 			}
-			for i, object := range node.FileNode.Objects {
-				var size int64
-				if i == 0 {
-					size = node.SubtreeSize
-				}
-				records.Records = append(records.Records, &pfs.PutFileRecord{
-					SizeBytes:  size,
-					ObjectHash: object.Hash,
-				})
-			}
+			options.mode = pfs.PutFileMode_APPEND
+		}
+		return b.d.putFileTar(file, options.mode, compression, storageClass, reader, func(entry *pfs.File, records *pfs.PutFileRecords) error {
 			marshalledRecords, err := records.Marshal()
 			if err != nil {
 				return err
 			}
-			kvc := etcd.NewKV(d.etcdClient)
-			txnResp, err := kvc.Txn(ctx).
-				If(etcd.Compare(etcd.CreateRevision(d.openCommits.Path(file.Commit.ID)), ">", 0)).Then(etcd.OpPut(path.Join(prefix, uuid.NewWithoutDashes()), string(marshalledRecords))).Commit()
-			if err != nil {
-				return err
-			}
-			if !txnResp.Succeeded {
-				return fmt.Errorf("commit %v is not open", file.Commit.ID)
-			}
-			return nil
+			return b.write(entry, string(marshalledRecords))
 		})
-		return nil
-	}); err != nil {
+	}
+	var errs *splitErrors
+	if options.divertErrors {
+		errs = &splitErrors{}
+	}
+	strict, err := b.d.newStrictSplitReader(b.ctx, file.Commit.Repo, options.delimiter, reader)
+	if err != nil {
 		return err
 	}
-	return eg.Wait()
+	if strict != nil {
+		reader = strict
+	}
+	records, err := b.d.putFileRecords(options, compression, storageClass, errs, schema, split, reader)
+	if err != nil {
+		return err
+	}
+	if strict != nil {
+		if err := strict.check(); err != nil {
+			return err
+		}
+	}
+	marshalledRecords, err := records.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := b.write(file, string(marshalledRecords)); err != nil {
+		return err
+	}
+	if errs != nil && errs.diverted > 0 {
+		errorRecords, err := b.d.putFileRecords(putFileOptions{}, compression, storageClass, nil, nil, nil, &errs.buffer)
+		if err != nil {
+			return err
+		}
+		marshalledRecords, err := errorRecords.Marshal()
+		if err != nil {
+			return err
+		}
+		return b.write(client.NewFile(file.Commit.Repo.Name, file.Commit.ID, splitErrorsPath(file.Path)), string(marshalledRecords))
+	}
+	return nil
+}
+
+func (b *putFilesBatch) deleteFile(file *pfs.File) error {
+	if err := b.resolveCommit(file); err != nil {
+		return err
+	}
+	if err := b.d.checkPathIsWritable(b.ctx, file, true); err != nil {
+		return err
+	}
+	return b.write(file, tombstone)
+}
+
+// deleteFileGlob adds a delete of the files that file.Path, a glob pattern,
+// matches to the batch, see driver.deleteFileGlob.
+func (b *putFilesBatch) deleteFileGlob(file *pfs.File) error {
+	if err := b.resolveCommit(file); err != nil {
+		return err
+	}
+	if err := b.d.checkGlobIsDeletable(b.ctx, file); err != nil {
+		return err
+	}
+	b.d.featureUsage.inc("delete_file_glob")
+	records, err := deleteGlobRecords(file.Path)
+	if err != nil {
+		return err
+	}
+	return b.write(file, records)
+}
+
+// commit writes all of the batch's records to etcd, provided that every
+// commit it writes to is still open.
+func (b *putFilesBatch) commit() error {
+	if len(b.writes) == 0 {
+		return nil
+	}
+	if len(b.commits) > scratchBatchMaxCommits {
+		return fmt.Errorf("a batch can write to at most %d commits, this one writes to %d", scratchBatchMaxCommits, len(b.commits))
+	}
+	id := uuid.NewWithoutDashes()
+	var cmps []etcd.Cmp
+	var ops []etcd.Op
+	for _, commit := range b.commits {
+		cmps = append(cmps, etcd.Compare(etcd.CreateRevision(b.d.openCommits.Path(commit.ID)), ">", 0))
+		writes := b.writes[commit.ID]
+		if len(writes) == 0 {
+			continue
+		}
+		value, err := (&pfs.ScratchBatch{Writes: writes}).Marshal()
+		if err != nil {
+			return err
+		}
+		ops = append(ops, etcd.OpPut(path.Join(b.d.scratchBatchPrefix(commit), id), string(value)))
+	}
+	txnResp, err := etcd.NewKV(b.d.etcdClient).Txn(b.ctx).If(cmps...).Then(ops...).Commit()
+	if err != nil {
+		return err
+	}
+	if !txnResp.Succeeded {
+		var ids []string
+		for _, commit := range b.commits {
+			ids = append(ids, commit.ID)
+		}
+		return fmt.Errorf("not all of commits %v are open", ids)
+	}
+	return nil
+}
+
+// copyFile copies the file or directory at src to dst. If overwriteIndex is
+// set, each copied file's objects are written to dst starting at that object
+// index, replacing the ones from it on, as they are by putFile.
+func (d *driver) copyFile(ctx context.Context, src *pfs.File, dst *pfs.File, overwrite bool, overwriteIndex *pfs.OverwriteIndex) error {
+	d.featureUsage.inc("copy_file")
+	if overwrite && overwriteIndex != nil {
+		return fmt.Errorf("an overwrite index can't be used with overwrite")
+	}
+	if overwriteIndex != nil {
+		d.featureUsage.inc("overwrite")
+	}
+	if err := d.checkIsAuthorized(ctx, src.Commit.Repo, auth.Scope_READER); err != nil {
+		return err
+	}
+	// the copy reuses src's objects, which would make them readable
+	// unfiltered in dst
+	if err := d.checkReadIsUnfiltered(ctx, src.Commit.Repo); err != nil {
+		return err
+	}
+	if err := d.checkIsAuthorized(ctx, dst.Commit.Repo, auth.Scope_WRITER); err != nil {
+		return err
+	}
+	// an overwrite index of 0 replaces the whole file, like overwrite
+	if overwriteIndex != nil && overwriteIndex.Index == 0 {
+		overwrite, overwriteIndex = true, nil
+	}
+	if err := d.checkPathIsWritable(ctx, dst, overwrite); err != nil {
+		return err
+	}
+	if err := checkPath(dst.Path); err != nil {
+		return err
+	}
+	// Check if the commit ID is a branch name.  If so, we have to
+	// get the real commit ID in order to check if the commit does exist
+	// and is open.
+	// Since we use UUIDv4 for commit IDs, the 13th character would be 4 if
+	// this is a commit ID.
+	if len(dst.Commit.ID) != uuid.UUIDWithoutDashesLength || dst.Commit.ID[12] != '4' {
+		commitInfo, err := d.inspectCommit(ctx, dst.Commit)
+		if err != nil {
+			return err
+		}
+		dst.Commit = commitInfo.Commit
+	}
+	if overwrite {
+		if err := d.deleteFile(ctx, dst); err != nil {
+			return err
+		}
+	}
+	srcTree, err := d.getTreeForFile(ctx, src)
+	if err != nil {
+		return err
+	}
+	// This is necessary so we can call filepath.Rel below
+	if !strings.HasPrefix(src.Path, "/") {
+		src.Path = "/" + src.Path
+	}
+	var eg errgroup.Group
+	if err := srcTree.Walk(src.Path, func(walkPath string, node *hashtree.NodeProto) error {
+		if node.DirNode != nil {
+			return nil
+		}
+		eg.Go(func() error {
+			relPath, err := filepath.Rel(src.Path, walkPath)
+			if err != nil {
+				// This shouldn't be possible
+				return fmt.Errorf("error from filepath.Rel: %+v (this is likely a bug)", err)
+			}
+			records := &pfs.PutFileRecords{}
+			file := client.NewFile(dst.Commit.Repo.Name, dst.Commit.ID, path.Clean(path.Join(dst.Path, relPath)))
+			prefix, err := d.scratchFilePrefix(ctx, file)
+			if err != nil {
+				return err
+			}
+			if node.SymlinkNode != nil {
+				records.SymlinkTarget = node.SymlinkNode.Target
+			} else {
+				if err := d.cacheObjects(ctx, src.Commit.Repo, node.FileNode.Objects); err != nil {
+					return err
+				}
+				for i, object := range node.FileNode.Objects {
+					record := &pfs.PutFileRecord{
+						ObjectHash:  object.Hash,
+						Compression: object.Compression,
+					}
+					// The first record carries the file's size and takes
+					// care of the overwriting
+					if i == 0 {
+						record.SizeBytes = node.SubtreeSize
+						record.OverwriteIndex = overwriteIndex
+					}
+					records.Records = append(records.Records, record)
+				}
+			}
+			marshalledRecords, err := records.Marshal()
+			if err != nil {
+				return err
+			}
+			kvc := etcd.NewKV(d.etcdClient)
+			txnResp, err := kvc.Txn(ctx).
+				If(etcd.Compare(etcd.CreateRevision(d.openCommits.Path(file.Commit.ID)), ">", 0)).Then(etcd.OpPut(path.Join(prefix, uuid.NewWithoutDashes()), string(marshalledRecords))).Commit()
+			if err != nil {
+				return err
+			}
+			if !txnResp.Succeeded {
+				return fmt.Errorf("commit %v is not open", file.Commit.ID)
+			}
+			return nil
+		})
+		return nil
+	}); err != nil {
+		return err
+	}
+	return eg.Wait()
+}
+
+// moveFile moves the file or directory at src to dst, replacing whatever is at
+// dst. src and dst must be in the same open commit. The move is recorded in the
+// commit's scratch space and applied by applyWrites, which reuses src's
+// objects, so none of the moved content is copied.
+func (d *driver) moveFile(ctx context.Context, src *pfs.File, dst *pfs.File) error {
+	if err := d.checkIsAuthorized(ctx, dst.Commit.Repo, auth.Scope_WRITER); err != nil {
+		return err
+	}
+	d.featureUsage.inc("move_file")
+	if src.Commit.Repo.Name != dst.Commit.Repo.Name {
+		return fmt.Errorf("files can't be moved between repos")
+	}
+	if err := d.checkPathIsWritable(ctx, src, true); err != nil {
+		return err
+	}
+	if err := d.checkPathIsWritable(ctx, dst, false); err != nil {
+		return err
+	}
+	if err := checkPath(dst.Path); err != nil {
+		return err
+	}
+	srcCommitInfo, err := d.inspectCommit(ctx, src.Commit)
+	if err != nil {
+		return err
+	}
+	dstCommitInfo, err := d.inspectCommit(ctx, dst.Commit)
+	if err != nil {
+		return err
+	}
+	if srcCommitInfo.Commit.ID != dstCommitInfo.Commit.ID {
+		return fmt.Errorf("files can only be moved within a commit")
+	}
+	if dstCommitInfo.Finished != nil {
+		return pfsserver.ErrCommitFinished{dstCommitInfo.Commit}
+	}
+	src.Commit = srcCommitInfo.Commit
+	dst.Commit = dstCommitInfo.Commit
+	srcPath := path.Clean("/" + src.Path)
+	dstPath := path.Clean("/" + dst.Path)
+	if srcPath == "/" {
+		return fmt.Errorf("the root directory can't be moved")
+	}
+	if dstPath == srcPath || strings.HasPrefix(dstPath, srcPath+"/") {
+		return fmt.Errorf("%s can't be moved to %s, which is inside it", srcPath, dstPath)
+	}
+	if dstPath == "/" || strings.HasPrefix(srcPath, dstPath+"/") {
+		return fmt.Errorf("%s can't be moved to %s, which contains it", srcPath, dstPath)
+	}
+	tree, err := d.getTreeForFile(ctx, src)
+	if err != nil {
+		return err
+	}
+	if _, err := tree.Get(srcPath); err != nil {
+		return pfsserver.ErrFileNotFound{src}
+	}
+
+	prefix, err := d.scratchFilePrefix(ctx, dst)
+	if err != nil {
+		return err
+	}
+	records := &pfs.PutFileRecords{
+		MoveFrom: srcPath,
+	}
+	marshalledRecords, err := records.Marshal()
+	if err != nil {
+		return err
+	}
+	kvc := etcd.NewKV(d.etcdClient)
+	txnResp, err := kvc.Txn(ctx).
+		If(etcd.Compare(etcd.CreateRevision(d.openCommits.Path(dst.Commit.ID)), ">", 0)).Then(etcd.OpPut(path.Join(prefix, uuid.NewWithoutDashes()), string(marshalledRecords))).Commit()
+	if err != nil {
+		return err
+	}
+	if !txnResp.Succeeded {
+		return fmt.Errorf("commit %v is not open", dst.Commit.ID)
+	}
+	return nil
+}
+
+// putSymlink creates a symlink at file.Path that points to target, replacing
+// whatever is at file.Path.
+func (d *driver) putSymlink(ctx context.Context, file *pfs.File, target string) error {
+	if err := d.checkIsAuthorized(ctx, file.Commit.Repo, auth.Scope_WRITER); err != nil {
+		return err
+	}
+	d.featureUsage.inc("put_symlink")
+	if err := d.checkPathIsWritable(ctx, file, false); err != nil {
+		return err
+	}
+	if err := checkPath(file.Path); err != nil {
+		return err
+	}
+	if target == "" {
+		return fmt.Errorf("symlink %s must have a target", file.Path)
+	}
+	if err := checkPath(target); err != nil {
+		return err
+	}
+	commitInfo, err := d.inspectCommit(ctx, file.Commit)
+	if err != nil {
+		return err
+	}
+	file.Commit = commitInfo.Commit
+	prefix, err := d.scratchFilePrefix(ctx, file)
+	if err != nil {
+		return err
+	}
+	records := &pfs.PutFileRecords{
+		SymlinkTarget: target,
+	}
+	marshalledRecords, err := records.Marshal()
+	if err != nil {
+		return err
+	}
+	kvc := etcd.NewKV(d.etcdClient)
+	txnResp, err := kvc.Txn(ctx).
+		If(etcd.Compare(etcd.CreateRevision(d.openCommits.Path(file.Commit.ID)), ">", 0)).Then(etcd.OpPut(path.Join(prefix, uuid.NewWithoutDashes()), string(marshalledRecords))).Commit()
+	if err != nil {
+		return err
+	}
+	if !txnResp.Succeeded {
+		return fmt.Errorf("commit %v is not open", file.Commit.ID)
+	}
+	return nil
 }
 
 func (d *driver) getTreeForCommit(ctx context.Context, commit *pfs.Commit) (hashtree.HashTree, error) {
@@ -1661,7 +3400,9 @@ func (d *driver) getTreeForCommit(ctx context.Context, commit *pfs.Commit) (hash
 		return t, nil
 	}
 
-	tree, ok := d.treeCache.Get(commit.ID)
+	// trees invalidated after this point may be read below, and aren't cached
+	gen := d.treeCacheGeneration()
+	tree, ok := d.treeCache.Get(treeCacheKey(commit))
 	if ok {
 		h, ok := tree.(hashtree.HashTree)
 		if ok {
@@ -1698,20 +3439,55 @@ func (d *driver) getTreeForCommit(ctx context.Context, commit *pfs.Commit) (hash
 		return nil, err
 	}
 
-	h, err := hashtree.Deserialize(buf.Bytes())
+	h, err := hashtree.DeserializeLazy(buf.Bytes(), d.getTreeChunk)
 	if err != nil {
 		return nil, err
 	}
 
-	d.treeCache.Add(commit.ID, h)
+	d.addTree(commit, h, gen)
 
 	return h, nil
 }
 
+// serializeTree serializes tree, putting its chunks into the object store if
+// it's big enough to be chunked. It also returns the chunks, if any.
+func (d *driver) serializeTree(tree hashtree.HashTree) ([]byte, []*pfs.Object, error) {
+	var chunks []*pfs.Object
+	data, err := hashtree.SerializeChunked(tree, treeChunkSize, func(chunk []byte) (*pfs.Object, error) {
+		object, _, err := d.pachClient.PutObject(bytes.NewReader(chunk))
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, object)
+		return object, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, chunks, nil
+}
+
+// getTreeChunk reads a chunk of a chunked tree from the object store, it's
+// how the trees returned by getTreeForCommit read their nodes.
+func (d *driver) getTreeChunk(object *pfs.Object) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := d.pachClient.GetObject(object.Hash, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 // getTreeForFile is like getTreeForCommit except that it can handle open commits.
 // It takes a file instead of a commit so that it can apply the changes for
 // that path to the tree before it returns it.
 func (d *driver) getTreeForFile(ctx context.Context, file *pfs.File) (hashtree.HashTree, error) {
+	return d.getTreeForFileFinished(ctx, file, true)
+}
+
+// getTreeForFileFinished is getTreeForFile, except that if finish isn't set
+// the tree of an open commit is returned unfinished, so the hashes of its
+// nodes are stale (their sizes are kept up to date as writes are applied).
+func (d *driver) getTreeForFileFinished(ctx context.Context, file *pfs.File, finish bool) (hashtree.HashTree, error) {
 	if file.Commit == nil {
 		t, err := hashtree.NewHashTree().Finish()
 		if err != nil {
@@ -1735,165 +3511,859 @@ func (d *driver) getTreeForFile(ctx context.Context, file *pfs.File) (hashtree.H
 		return nil, err
 	}
 	// Read everything under the scratch space for this commit
-	resp, err := d.etcdClient.Get(ctx, prefix, etcd.WithPrefix(), etcd.WithSort(etcd.SortByModRevision, etcd.SortAscend))
+	resp, err := d.readScratch(ctx, file.Commit, prefix)
 	if err != nil {
 		return nil, err
 	}
 
-	parentTree, err := d.getTreeForCommit(ctx, commitInfo.ParentCommit)
+	baseCommit := commitInfo.ParentCommit
+	if commitInfo.Base != nil {
+		baseCommit = commitInfo.Base
+	}
+	baseTree, err := d.getTreeForCommit(ctx, baseCommit)
 	if err != nil {
 		return nil, err
 	}
-	openTree := parentTree.Open()
-	if err := d.applyWrites(resp, openTree); err != nil {
+	openTree := baseTree.Open()
+	if err := d.applyWrites(resp, openTree, nil); err != nil {
 		return nil, err
 	}
+	if !finish {
+		return openTree, nil
+	}
 	tree, err := openTree.Finish()
 	if err != nil {
 		return nil, err
 	}
-	return tree, nil
+	return tree, nil
+}
+
+func (d *driver) getFile(ctx context.Context, file *pfs.File, offset int64, size int64, followSymlinks bool) (io.Reader, error) {
+	node, err := d.getFileNode(ctx, file, followSymlinks)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := d.readFilter(ctx, file.Commit.Repo)
+	if err != nil {
+		return nil, err
+	}
+	if filter == nil {
+		return d.objectsReader(ctx, node.FileNode.Objects, uint64(offset), uint64(size))
+	}
+	// offset and size are applied to the filtered content, as they would be
+	// meaningless to the caller otherwise
+	r, err := d.objectsReader(ctx, node.FileNode.Objects, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	filtered := filterReader(r, filter)
+	if _, err := io.CopyN(ioutil.Discard, filtered, offset); err != nil && err != io.EOF {
+		filtered.Close()
+		return nil, err
+	}
+	if size > 0 {
+		return io.LimitReader(filtered, size), nil
+	}
+	return filtered, nil
+}
+
+// getFileNode returns the node of the regular file at file.Path, after
+// checking that the caller can read it and caching its objects.
+func (d *driver) getFileNode(ctx context.Context, file *pfs.File, followSymlinks bool) (*hashtree.NodeProto, error) {
+	if err := d.checkIsAuthorized(ctx, file.Commit.Repo, auth.Scope_READER); err != nil {
+		return nil, err
+	}
+	tree, err := d.getTreeForFile(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := getNode(tree, file, followSymlinks)
+	if err != nil {
+		return nil, err
+	}
+
+	if node.SymlinkNode != nil {
+		return nil, fmt.Errorf("%s is a symlink to %s", file.Path, node.SymlinkNode.Target)
+	}
+	if node.DirNode != nil {
+		// a directory written by a split is read as the concatenation of
+		// its files, in the order they were split in
+		splitNode, err := splitDirNode(tree, file.Path)
+		if err != nil {
+			return nil, err
+		}
+		if splitNode == nil {
+			return nil, fmt.Errorf("%s is a directory", file.Path)
+		}
+		node = splitNode
+	}
+	if err := d.cacheObjects(ctx, file.Commit.Repo, node.FileNode.Objects); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// splitDirNode returns a file node made of the objects of the files in the
+// directory at dirPath, in name order, if the directory was written by a
+// split (its files are all named by their index, see splitSuffixFmt). It
+// returns nil for other directories.
+func splitDirNode(tree hashtree.HashTree, dirPath string) (*hashtree.NodeProto, error) {
+	children, err := tree.List(dirPath)
+	if err != nil {
+		if hashtree.Code(err) == hashtree.PathNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(children) == 0 {
+		return nil, nil
+	}
+	node := &hashtree.NodeProto{
+		Name:     path.Base(dirPath),
+		FileNode: &hashtree.FileNodeProto{},
+	}
+	for _, child := range children {
+		if child.FileNode == nil || len(child.Name) != len(fmt.Sprintf(splitSuffixFmt, 0)) {
+			return nil, nil
+		}
+		if _, err := strconv.ParseUint(child.Name, splitSuffixBase, splitSuffixWidth); err != nil {
+			return nil, nil
+		}
+		node.FileNode.Objects = append(node.FileNode.Objects, child.FileNode.Objects...)
+		node.SubtreeSize += child.SubtreeSize
+	}
+	return node, nil
+}
+
+// getNode returns the node at file.Path in tree, following any symlinks in
+// file.Path if followSymlinks is set.
+func getNode(tree hashtree.HashTree, file *pfs.File, followSymlinks bool) (*hashtree.NodeProto, error) {
+	if !followSymlinks {
+		node, err := tree.Get(file.Path)
+		if err != nil {
+			return nil, pfsserver.ErrFileNotFound{file}
+		}
+		return node, nil
+	}
+	_, node, err := hashtree.Resolve(tree, file.Path)
+	if err != nil {
+		if hashtree.Code(err) == hashtree.PathNotFound {
+			return nil, pfsserver.ErrFileNotFound{file}
+		}
+		return nil, err
+	}
+	return node, nil
+}
+
+// getFileTar returns a tar archive of the file or directory at file.Path.
+// Entries are named relative to file.Path (a lone file is named by its base
+// name) and the archive is assembled lazily as the returned reader is read.
+// Closing the reader early aborts the archive.
+func (d *driver) getFileTar(ctx context.Context, file *pfs.File) (io.ReadCloser, error) {
+	if err := d.checkIsAuthorized(ctx, file.Commit.Repo, auth.Scope_READER); err != nil {
+		return nil, err
+	}
+	if err := d.checkReadIsUnfiltered(ctx, file.Commit.Repo); err != nil {
+		return nil, err
+	}
+	tree, err := d.getTreeForFile(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tree.Get(file.Path); err != nil {
+		return nil, pfsserver.ErrFileNotFound{file}
+	}
+
+	var paths []string
+	nodes := make(map[string]*hashtree.NodeProto)
+	if err := tree.Walk(file.Path, func(path string, node *hashtree.NodeProto) error {
+		paths = append(paths, path)
+		nodes[path] = node
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	root := path.Clean("/" + file.Path)
+	r, w := io.Pipe()
+	go func() {
+		var objects []*pfs.Object
+		for _, node := range nodes {
+			if node.FileNode != nil {
+				objects = append(objects, node.FileNode.Objects...)
+			}
+		}
+		if err := d.cacheObjects(ctx, file.Commit.Repo, objects); err != nil {
+			w.CloseWithError(err)
+			return
+		}
+		w.CloseWithError(d.writeTar(ctx, w, root, paths, nodes))
+	}()
+	return r, nil
+}
+
+func (d *driver) writeTar(ctx context.Context, w io.Writer, root string, paths []string, nodes map[string]*hashtree.NodeProto) error {
+	tw := tar.NewWriter(w)
+	for _, p := range paths {
+		node := nodes[p]
+		name := strings.TrimPrefix(strings.TrimPrefix(p, root), "/")
+		if name == "" {
+			if node.FileNode == nil {
+				// the requested directory itself
+				continue
+			}
+			name = path.Base(p)
+		}
+		if node.DirNode != nil {
+			if err := tw.WriteHeader(&tar.Header{
+				Name:     name + "/",
+				Typeflag: tar.TypeDir,
+				Mode:     0755,
+			}); err != nil {
+				return err
+			}
+			continue
+		}
+		if node.SymlinkNode != nil {
+			if err := tw.WriteHeader(&tar.Header{
+				Name:     name,
+				Typeflag: tar.TypeSymlink,
+				Linkname: node.SymlinkNode.Target,
+				Mode:     0777,
+			}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Typeflag: tar.TypeReg,
+			Mode:     0644,
+			Size:     node.SubtreeSize,
+		}); err != nil {
+			return err
+		}
+		if len(node.FileNode.Objects) == 0 {
+			continue
+		}
+		r, err := d.objectsReader(ctx, node.FileNode.Objects, 0, 0)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, r); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// If full is false, exclude potentially large fields such as `Objects`
+// and `Children`
+func nodeToFileInfo(commit *pfs.Commit, path string, node *hashtree.NodeProto, full bool) *pfs.FileInfo {
+	fileInfo := &pfs.FileInfo{
+		File: &pfs.File{
+			Commit: commit,
+			Path:   path,
+		},
+		SizeBytes: uint64(node.SubtreeSize),
+		Hash:      node.Hash,
+	}
+	if node.FileNode != nil {
+		fileInfo.FileType = pfs.FileType_FILE
+		fileInfo.Stats = node.FileNode.Stats
+		fileInfo.RecordCount = node.FileNode.RecordCount
+		fileInfo.Sha256 = node.Hash
+		if full {
+			fileInfo.Objects = node.FileNode.Objects
+		}
+	} else if node.DirNode != nil {
+		fileInfo.FileType = pfs.FileType_DIR
+		if full {
+			fileInfo.Children = node.DirNode.Children
+		}
+	} else if node.SymlinkNode != nil {
+		fileInfo.FileType = pfs.FileType_SYMLINK
+		fileInfo.SymlinkTarget = node.SymlinkNode.Target
+	}
+	return fileInfo
+}
+
+func (d *driver) inspectFile(ctx context.Context, file *pfs.File) (*pfs.FileInfo, error) {
+	if err := d.checkIsAuthorized(ctx, file.Commit.Repo, auth.Scope_READER); err != nil {
+		return nil, err
+	}
+	tree, err := d.getTreeForFile(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := tree.Get(file.Path)
+	if err != nil {
+		return nil, pfsserver.ErrFileNotFound{file}
+	}
+
+	fileInfo := nodeToFileInfo(file.Commit, file.Path, node, true)
+	if err := d.hideObjectsIfFiltered(ctx, file.Commit.Repo, []*pfs.FileInfo{fileInfo}); err != nil {
+		return nil, err
+	}
+	fileInfo.Schema, err = d.getSchema(ctx, file.Commit.Repo, file.Path)
+	if err != nil {
+		return nil, err
+	}
+	commitInfo, err := d.inspectCommit(ctx, file.Commit)
+	if err != nil {
+		return nil, err
+	}
+	fileInfo.CommitModified, err = d.commitModified(ctx, commitInfo, file.Path, node)
+	if err != nil {
+		return nil, err
+	}
+	if node.DirNode != nil {
+		// A directory's stats and record count cover all of the files beneath
+		// it, so they're only known if they're known for every one of those
+		// files.
+		first := true
+		recordsKnown := true
+		if err := tree.Walk(file.Path, func(path string, node *hashtree.NodeProto) error {
+			if node.FileNode == nil {
+				return nil
+			}
+			if first {
+				fileInfo.Stats = node.FileNode.Stats
+				first = false
+			} else {
+				fileInfo.Stats = pfs.MergeTableStats(fileInfo.Stats, node.FileNode.Stats)
+			}
+			if node.FileNode.RecordCount == 0 {
+				recordsKnown = false
+			}
+			fileInfo.RecordCount += node.FileNode.RecordCount
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		if !recordsKnown {
+			fileInfo.RecordCount = 0
+		}
+	}
+	return fileInfo, nil
+}
+
+// commitModified returns the commit that last modified the file or directory
+// at filePath, whose node in commitInfo's tree is node. That's the oldest
+// commit in commitInfo's ancestry that has the same node at filePath as
+// commitInfo, without a commit with a different node in between. The answer
+// for each finished commit on the way is cached, so later calls only walk the
+// commits made since.
+func (d *driver) commitModified(ctx context.Context, commitInfo *pfs.CommitInfo, filePath string, node *hashtree.NodeProto) (*pfs.Commit, error) {
+	filePath = path.Clean("/" + filePath)
+	var result *pfs.Commit
+	var visited []string
+	for {
+		if commitInfo.Finished != nil {
+			key := path.Join(commitInfo.Commit.Repo.Name, commitInfo.Commit.ID) + ":" + filePath
+			if cached, ok := d.commitModifiedCache.Get(key); ok {
+				result = cached.(*pfs.Commit)
+				break
+			}
+			visited = append(visited, key)
+		}
+		parent := commitInfo.ParentCommit
+		if parent == nil {
+			result = commitInfo.Commit
+			break
+		}
+		parentTree, err := d.getTreeForCommit(ctx, parent)
+		if err != nil {
+			return nil, err
+		}
+		parentNode, err := parentTree.Get(filePath)
+		if err != nil && hashtree.Code(err) != hashtree.PathNotFound {
+			return nil, err
+		}
+		if parentNode == nil || !bytes.Equal(parentNode.Hash, node.Hash) {
+			result = commitInfo.Commit
+			break
+		}
+		parentInfo := &pfs.CommitInfo{}
+		if err := d.commits(parent.Repo.Name).ReadOnly(ctx).Get(parent.ID, parentInfo); err != nil {
+			return nil, err
+		}
+		commitInfo = parentInfo
+	}
+	for _, key := range visited {
+		d.commitModifiedCache.Add(key, result)
+	}
+	return result, nil
+}
+
+func (d *driver) setSchema(ctx context.Context, repo *pfs.Repo, filePath string, schema *pfs.Schema) error {
+	d.featureUsage.inc("set_schema")
+	if err := d.checkIsAuthorized(ctx, repo, auth.Scope_WRITER); err != nil {
+		return err
+	}
+	if err := checkPath(filePath); err != nil {
+		return err
+	}
+	if schema != nil && (schema.Type == pfs.SchemaType_SCHEMA_TYPE_UNSPECIFIED || schema.Url == "") {
+		return fmt.Errorf("a schema needs a type and a url")
+	}
+	filePath = cleanSchemaPath(filePath)
+	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		// Make sure that the repo exists
+		if err := d.repos.ReadWrite(stm).Get(repo.Name, &pfs.RepoInfo{}); err != nil {
+			return err
+		}
+		schemas := d.schemas.ReadWrite(stm)
+		repoSchemas := &pfs.RepoSchemas{}
+		if err := schemas.Get(repo.Name, repoSchemas); err != nil && !col.IsErrNotFound(err) {
+			return err
+		}
+		var schemaInfos []*pfs.SchemaInfo
+		for _, schemaInfo := range repoSchemas.SchemaInfo {
+			if schemaInfo.Path != filePath {
+				schemaInfos = append(schemaInfos, schemaInfo)
+			}
+		}
+		if schema != nil {
+			schemaInfos = append(schemaInfos, &pfs.SchemaInfo{
+				Path:   filePath,
+				Schema: schema,
+			})
+		}
+		repoSchemas.SchemaInfo = schemaInfos
+		return schemas.Put(repo.Name, repoSchemas)
+	})
+	return err
+}
+
+// inspectFeatureUsage returns the feature usage that's reported by feature
+// telemetry, it's also returned when telemetry is disabled so that users can
+// see what would be sent.
+func (d *driver) inspectFeatureUsage(ctx context.Context) (*pfs.FeatureUsage, error) {
+	repos, err := d.repos.ReadOnly(ctx).Count()
+	if err != nil {
+		return nil, err
+	}
+	usage := &pfs.FeatureUsage{
+		Repos:         repos,
+		Features:      d.featureUsage.snapshot(),
+		AuditFindings: d.audit.findingCounts(),
+	}
+	if d.featureReporter != nil {
+		usage.Enabled = true
+		usage.ClusterID = d.featureReporter.ClusterID()
+	}
+	return usage, nil
+}
+
+// getSchema returns the schema that applies to filePath in repo, that's the
+// schema set on filePath or its closest ancestor, or nil if there isn't one.
+func (d *driver) getSchema(ctx context.Context, repo *pfs.Repo, filePath string) (*pfs.Schema, error) {
+	repoSchemas := &pfs.RepoSchemas{}
+	if err := d.schemas.ReadOnly(ctx).Get(repo.Name, repoSchemas); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return schemaFor(repoSchemas, filePath), nil
+}
+
+// schemaFor returns the schema in repoSchemas that applies to filePath, see
+// getSchema.
+func schemaFor(repoSchemas *pfs.RepoSchemas, filePath string) *pfs.Schema {
+	filePath = cleanSchemaPath(filePath)
+	var result *pfs.SchemaInfo
+	for _, schemaInfo := range repoSchemas.SchemaInfo {
+		if schemaInfo.Path != "/" && schemaInfo.Path != filePath && !strings.HasPrefix(filePath, schemaInfo.Path+"/") {
+			continue
+		}
+		if result == nil || len(schemaInfo.Path) > len(result.Path) {
+			result = schemaInfo
+		}
+	}
+	if result == nil {
+		return nil
+	}
+	return result.Schema
+}
+
+// cleanSchemaPath cleans filePath so that it can be compared to the paths
+// in RepoSchemas.
+func cleanSchemaPath(filePath string) string {
+	return path.Clean("/" + filePath)
 }
 
-func (d *driver) getFile(ctx context.Context, file *pfs.File, offset int64, size int64) (io.Reader, error) {
+// listFile lists the directory at file.Path. If followSymlinks is set, the
+// symlinks in file.Path are resolved, and each symlink in the directory is
+// reported with the type and size of the node it points to (symlinks that
+// don't resolve are reported as they are). If limit is greater than 0, at
+// most limit files are returned, starting after the last file of the page
+// whose token is pageToken, along with the token of the next page ("" if
+// there isn't one). The position in the token is the path of that file.
+func (d *driver) listFile(ctx context.Context, file *pfs.File, full bool, followSymlinks bool, limit int64, pageToken string, mode pfs.ListFileMode) ([]*pfs.FileInfo, string, error) {
 	if err := d.checkIsAuthorized(ctx, file.Commit.Repo, auth.Scope_READER); err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	tree, err := d.getTreeForFile(ctx, file)
+	pages, err := newPager(pageToken, pageFilter("ListFile", file.Commit.Repo.Name, file.Commit.ID,
+		path.Clean("/"+file.Path), strconv.FormatBool(followSymlinks), mode.String()))
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-
-	node, err := tree.Get(file.Path)
-	if err != nil {
-		return nil, pfsserver.ErrFileNotFound{file}
+	if err := d.checkRepoQuota(ctx, file.Commit.Repo, repoOpListFile); err != nil {
+		return nil, "", err
 	}
-
-	if node.FileNode == nil {
-		return nil, fmt.Errorf("%s is a directory", file.Path)
+	// a paginated listing reads every page from the same commit
+	treeFile := file
+	if limit > 0 || pageToken != "" {
+		commit, err := pages.commit(ctx, d, file.Commit)
+		if err != nil {
+			return nil, "", err
+		}
+		treeFile = &pfs.File{Commit: commit, Path: file.Path}
 	}
-
-	getObjectsClient, err := d.pachClient.ObjectAPIClient.GetObjects(
-		ctx,
-		&pfs.GetObjectsRequest{
-			Objects:     node.FileNode.Objects,
-			OffsetBytes: uint64(offset),
-			SizeBytes:   uint64(size),
-		})
+	// the hashes of an open commit's tree are computed when it's finished,
+	// which FAST listings skip as they omit them
+	tree, err := d.getTreeForFileFinished(ctx, treeFile, mode != pfs.ListFileMode_ListFile_FAST)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	return grpcutil.NewStreamingBytesReader(getObjectsClient), nil
-}
 
-// If full is false, exclude potentially large fields such as `Objects`
-// and `Children`
-func nodeToFileInfo(commit *pfs.Commit, path string, node *hashtree.NodeProto, full bool) *pfs.FileInfo {
-	fileInfo := &pfs.FileInfo{
-		File: &pfs.File{
-			Commit: commit,
-			Path:   path,
-		},
-		SizeBytes: uint64(node.SubtreeSize),
-		Hash:      node.Hash,
+	dirPath := file.Path
+	if followSymlinks {
+		dirPath, _, err = hashtree.Resolve(tree, file.Path)
+		if err != nil {
+			return nil, "", err
+		}
 	}
-	if node.FileNode != nil {
-		fileInfo.FileType = pfs.FileType_FILE
-		if full {
-			fileInfo.Objects = node.FileNode.Objects
+	// names are the paths of the nodes relative to dirPath
+	var names []string
+	var nodes []*hashtree.NodeProto
+	if mode == pfs.ListFileMode_ListFile_RECURSE {
+		var after string
+		if pages.position() != "" {
+			after = strings.TrimPrefix(strings.TrimPrefix(path.Clean("/"+pages.position()), path.Clean("/"+file.Path)), "/")
 		}
-	} else if node.DirNode != nil {
-		fileInfo.FileType = pfs.FileType_DIR
-		if full {
-			fileInfo.Children = node.DirNode.Children
+		if names, nodes, err = listRecursive(tree, dirPath, after, pageLimit(limit)); err != nil {
+			return nil, "", err
+		}
+	} else {
+		var after string
+		if pages.position() != "" {
+			after = path.Base(pages.position())
+		}
+		if nodes, err = tree.ListPage(dirPath, after, pageLimit(limit)); err != nil {
+			return nil, "", err
+		}
+		for _, node := range nodes {
+			names = append(names, node.Name)
 		}
 	}
-	return fileInfo
-}
+	var nextPageToken string
+	if limit > 0 && int64(len(nodes)) > limit {
+		nodes, names = nodes[:limit], names[:limit]
+		nextPageToken = pages.next(path.Join(file.Path, names[len(names)-1]), treeFile.Commit.ID)
+	}
 
-func (d *driver) inspectFile(ctx context.Context, file *pfs.File) (*pfs.FileInfo, error) {
-	if err := d.checkIsAuthorized(ctx, file.Commit.Repo, auth.Scope_READER); err != nil {
-		return nil, err
+	var fileInfos []*pfs.FileInfo
+	for i, node := range nodes {
+		nodePath := path.Join(file.Path, names[i])
+		fileInfo := nodeToFileInfo(file.Commit, nodePath, node, full)
+		if followSymlinks && node.SymlinkNode != nil {
+			if _, target, err := hashtree.Resolve(tree, path.Join(dirPath, names[i])); err == nil {
+				fileInfo = nodeToFileInfo(file.Commit, nodePath, target, full)
+				fileInfo.SymlinkTarget = node.SymlinkNode.Target
+			}
+		}
+		if mode == pfs.ListFileMode_ListFile_FAST {
+			fileInfo.SizeBytes = 0
+			fileInfo.Hash = nil
+		}
+		fileInfos = append(fileInfos, fileInfo)
 	}
-	tree, err := d.getTreeForFile(ctx, file)
-	if err != nil {
-		return nil, err
+	if full {
+		if err := d.hideObjectsIfFiltered(ctx, file.Commit.Repo, fileInfos); err != nil {
+			return nil, "", err
+		}
 	}
+	return fileInfos, nextPageToken, nil
+}
 
-	node, err := tree.Get(file.Path)
-	if err != nil {
-		return nil, pfsserver.ErrFileNotFound{file}
+// errListLimit stops the walk of listRecursive once it has found enough
+// nodes.
+var errListLimit = errors.New("list limit reached")
+
+// listRecursive returns the paths, relative to dirPath, and nodes of every
+// file and directory under dirPath in tree that come after the relative path
+// after in the order that tree.Walk visits them, at most limit of them if
+// limit is greater than 0.
+func listRecursive(tree hashtree.HashTree, dirPath string, after string, limit int) ([]string, []*hashtree.NodeProto, error) {
+	root := path.Clean("/" + dirPath)
+	var names []string
+	var nodes []*hashtree.NodeProto
+	if err := tree.Walk(dirPath, func(p string, node *hashtree.NodeProto) error {
+		name := strings.TrimPrefix(strings.TrimPrefix(p, root), "/")
+		if name == "" || (after != "" && !walkOrderLess(after, name)) {
+			return nil
+		}
+		if limit > 0 && len(nodes) >= limit {
+			return errListLimit
+		}
+		names = append(names, name)
+		nodes = append(nodes, node)
+		return nil
+	}); err != nil && err != errListLimit {
+		return nil, nil, err
 	}
+	return names, nodes, nil
+}
 
-	return nodeToFileInfo(file.Commit, file.Path, node, true), nil
+// walkOrderLess returns true if tree.Walk visits the relative path p before
+// q: their components are compared in order, so a directory comes before its
+// children, which come before its next sibling.
+func walkOrderLess(p string, q string) bool {
+	pParts, qParts := strings.Split(p, "/"), strings.Split(q, "/")
+	for i := 0; i < len(pParts) && i < len(qParts); i++ {
+		if pParts[i] != qParts[i] {
+			return pParts[i] < qParts[i]
+		}
+	}
+	return len(pParts) < len(qParts)
 }
 
-func (d *driver) listFile(ctx context.Context, file *pfs.File, full bool) ([]*pfs.FileInfo, error) {
-	if err := d.checkIsAuthorized(ctx, file.Commit.Repo, auth.Scope_READER); err != nil {
-		return nil, err
+// globFile returns the files that match pattern, ordered by path and paged
+// like listFile's.
+func (d *driver) globFile(ctx context.Context, commit *pfs.Commit, pattern string, directoriesOnly bool, limit int64, pageToken string) ([]*pfs.FileInfo, string, error) {
+	if err := d.checkIsAuthorized(ctx, commit.Repo, auth.Scope_READER); err != nil {
+		return nil, "", err
 	}
-	tree, err := d.getTreeForFile(ctx, file)
+	pages, err := newPager(pageToken, pageFilter("GlobFile", commit.Repo.Name, commit.ID, pattern, strconv.FormatBool(directoriesOnly)))
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-
-	nodes, err := tree.List(file.Path)
+	treeCommit := commit
+	if limit > 0 || pageToken != "" {
+		if treeCommit, err = pages.commit(ctx, d, commit); err != nil {
+			return nil, "", err
+		}
+	}
+	tree, err := d.getTreeForFile(ctx, client.NewFile(commit.Repo.Name, treeCommit.ID, ""))
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+
+	if strings.HasSuffix(pattern, "/") {
+		directoriesOnly = true
+	}
+	var nodes []*hashtree.NodeProto
+	if directoriesOnly {
+		// files are filtered out after globbing, so the limit can only be
+		// applied afterwards
+		matches, err := tree.GlobPage(pattern, pages.position(), 0)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, node := range matches {
+			if node.DirNode != nil {
+				nodes = append(nodes, node)
+			}
+		}
+	} else {
+		nodes, err = tree.GlobPage(pattern, pages.position(), pageLimit(limit))
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	var nextPageToken string
+	if limit > 0 && int64(len(nodes)) > limit {
+		nodes = nodes[:limit]
+		nextPageToken = pages.next(nodes[len(nodes)-1].Name, treeCommit.ID)
 	}
 
 	var fileInfos []*pfs.FileInfo
 	for _, node := range nodes {
-		fileInfos = append(fileInfos, nodeToFileInfo(file.Commit, path.Join(file.Path, node.Name), node, full))
+		fileInfos = append(fileInfos, nodeToFileInfo(commit, node.Name, node, false))
 	}
-	return fileInfos, nil
+	return fileInfos, nextPageToken, nil
 }
 
-func (d *driver) globFile(ctx context.Context, commit *pfs.Commit, pattern string) ([]*pfs.FileInfo, error) {
+// searchFile calls f with the info of each file and directory in commit whose
+// path matches regex, in path order.
+func (d *driver) searchFile(ctx context.Context, commit *pfs.Commit, regex string, f func(*pfs.FileInfo) error) error {
 	if err := d.checkIsAuthorized(ctx, commit.Repo, auth.Scope_READER); err != nil {
-		return nil, err
+		return err
+	}
+	re, err := regexp.Compile(regex)
+	if err != nil {
+		return err
 	}
+	d.featureUsage.inc("search_file")
 	tree, err := d.getTreeForFile(ctx, client.NewFile(commit.Repo.Name, commit.ID, ""))
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	nodes, err := tree.Glob(pattern)
+	var paths []string
+	nodes := make(map[string]*hashtree.NodeProto)
+	if err := tree.Walk("/", func(path string, node *hashtree.NodeProto) error {
+		if path != "/" && re.MatchString(path) {
+			paths = append(paths, path)
+			nodes[path] = node
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err := f(nodeToFileInfo(commit, path, nodes[path], false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkFile calls f with the FileInfo of the file or directory at file.Path,
+// and then with those of everything under it, depth-first in path order.
+// Nothing is buffered, so a walk of a huge tree starts returning right away.
+func (d *driver) walkFile(ctx context.Context, file *pfs.File, f func(*pfs.FileInfo) error) error {
+	if err := d.checkIsAuthorized(ctx, file.Commit.Repo, auth.Scope_READER); err != nil {
+		return err
+	}
+	d.featureUsage.inc("walk_file")
+	tree, err := d.getTreeForFile(ctx, file)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if err := tree.Walk(file.Path, func(path string, node *hashtree.NodeProto) error {
+		return f(nodeToFileInfo(file.Commit, path, node, false))
+	}); err != nil {
+		if hashtree.Code(err) == hashtree.PathNotFound {
+			return pfsserver.ErrFileNotFound{file}
+		}
+		return err
 	}
+	return nil
+}
 
-	var fileInfos []*pfs.FileInfo
-	for _, node := range nodes {
-		fileInfos = append(fileInfos, nodeToFileInfo(commit, node.Name, node, false))
+// pageLimit returns the number of nodes to read from a hashtree for a page of
+// limit files: one more than limit, to find out whether there's a next page.
+func pageLimit(limit int64) int {
+	if limit <= 0 {
+		return 0
 	}
-	return fileInfos, nil
+	return int(limit) + 1
 }
 
 func (d *driver) diffFile(ctx context.Context, newFile *pfs.File, oldFile *pfs.File, shallow bool) ([]*pfs.FileInfo, []*pfs.FileInfo, error) {
+	var newFileInfos []*pfs.FileInfo
+	var oldFileInfos []*pfs.FileInfo
+	if err := d.diff(ctx, newFile, oldFile, shallow, func(file *pfs.File, node *hashtree.NodeProto, new bool) error {
+		if new {
+			newFileInfos = append(newFileInfos, nodeToFileInfo(file.Commit, file.Path, node, false))
+		} else {
+			oldFileInfos = append(oldFileInfos, nodeToFileInfo(file.Commit, file.Path, node, false))
+		}
+		return nil
+	}); err != nil {
+		return nil, nil, err
+	}
+	return newFileInfos, oldFileInfos, nil
+}
+
+// diffSummarizer counts the files that were added, deleted and modified in a
+// diff, and their sizes, given the nodes that the diff reports in order.
+type diffSummarizer struct {
+	newPath string
+	oldPath string
+	summary pfs.DiffFileSummary
+	// A modified file is reported at its new path and then, right away, at
+	// its old one. So a file is new if it isn't followed by its old version,
+	// and modified if it is.
+	lastNew     string
+	lastNewSize int64
+	pending     bool
+}
+
+func (s *diffSummarizer) flush() {
+	if s.pending {
+		s.summary.FilesAdded++
+		s.summary.BytesAdded += s.lastNewSize
+		s.pending = false
+	}
+}
+
+func (s *diffSummarizer) add(path string, node *hashtree.NodeProto, new bool) {
+	if new {
+		s.flush()
+		s.lastNew = strings.TrimPrefix(path, s.newPath)
+		s.lastNewSize = node.SubtreeSize
+		s.pending = true
+		return
+	}
+	if s.pending && strings.TrimPrefix(path, s.oldPath) == s.lastNew {
+		s.summary.FilesModified++
+		s.summary.BytesModified += s.lastNewSize
+		s.pending = false
+		return
+	}
+	s.flush()
+	s.summary.FilesDeleted++
+	s.summary.BytesDeleted += node.SubtreeSize
+}
+
+func (s *diffSummarizer) finish() *pfs.DiffFileSummary {
+	s.flush()
+	return &s.summary
+}
+
+// diffFileSummary is like diffFile, but only counts the files that were
+// added, deleted and modified, and their sizes.
+func (d *driver) diffFileSummary(ctx context.Context, newFile *pfs.File, oldFile *pfs.File, shallow bool) (*pfs.DiffFileSummary, error) {
+	s := &diffSummarizer{newPath: newFile.Path, oldPath: newFile.Path}
+	if oldFile != nil {
+		s.oldPath = oldFile.Path
+	}
+	if err := d.diff(ctx, newFile, oldFile, shallow, func(file *pfs.File, node *hashtree.NodeProto, new bool) error {
+		s.add(file.Path, node, new)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	d.featureUsage.inc("diff_file_summary")
+	return s.finish(), nil
+}
+
+// diff calls f with the files that differ between newFile and oldFile (see
+// diffFile), new is true for the files under newFile.
+func (d *driver) diff(ctx context.Context, newFile *pfs.File, oldFile *pfs.File, shallow bool, f func(file *pfs.File, node *hashtree.NodeProto, new bool) error) error {
 	// Do READER authorization check for both newFile and oldFile
 	if oldFile != nil && oldFile.Commit != nil {
 		//	if oldFile != nil {
 		if err := d.checkIsAuthorized(ctx, oldFile.Commit.Repo, auth.Scope_READER); err != nil {
-			return nil, nil, err
+			return err
 		}
 	}
 	if newFile != nil && newFile.Commit != nil {
 		//	if newFile != nil {
 		if err := d.checkIsAuthorized(ctx, newFile.Commit.Repo, auth.Scope_READER); err != nil {
-			return nil, nil, err
+			return err
 		}
 	}
 	newTree, err := d.getTreeForFile(ctx, newFile)
 	if err != nil {
-		return nil, nil, err
+		return err
 	}
 	// if oldFile is new we use the parent of newFile
 	if oldFile == nil {
 		oldFile = &pfs.File{}
 		newCommitInfo, err := d.inspectCommit(ctx, newFile.Commit)
 		if err != nil {
-			return nil, nil, err
+			return err
 		}
 		// ParentCommit may be nil, that's fine because getTreeForCommit
 		// handles nil
@@ -1902,31 +4372,27 @@ func (d *driver) diffFile(ctx context.Context, newFile *pfs.File, oldFile *pfs.F
 	}
 	oldTree, err := d.getTreeForFile(ctx, oldFile)
 	if err != nil {
-		return nil, nil, err
+		return err
 	}
-	var newFileInfos []*pfs.FileInfo
-	var oldFileInfos []*pfs.FileInfo
 	recursiveDepth := -1
 	if shallow {
 		recursiveDepth = 1
 	}
-	if err := newTree.Diff(oldTree, newFile.Path, oldFile.Path, int64(recursiveDepth), func(path string, node *hashtree.NodeProto, new bool) error {
+	return newTree.Diff(oldTree, newFile.Path, oldFile.Path, int64(recursiveDepth), func(path string, node *hashtree.NodeProto, new bool) error {
 		if new {
-			newFileInfos = append(newFileInfos, nodeToFileInfo(newFile.Commit, path, node, false))
-		} else {
-			oldFileInfos = append(oldFileInfos, nodeToFileInfo(oldFile.Commit, path, node, false))
+			return f(&pfs.File{Commit: newFile.Commit, Path: path}, node, true)
 		}
-		return nil
-	}); err != nil {
-		return nil, nil, err
-	}
-	return newFileInfos, oldFileInfos, nil
+		return f(&pfs.File{Commit: oldFile.Commit, Path: path}, node, false)
+	})
 }
 
 func (d *driver) deleteFile(ctx context.Context, file *pfs.File) error {
 	if err := d.checkIsAuthorized(ctx, file.Commit.Repo, auth.Scope_WRITER); err != nil {
 		return err
 	}
+	if err := d.checkPathIsWritable(ctx, file, true); err != nil {
+		return err
+	}
 	commitInfo, err := d.inspectCommit(ctx, file.Commit)
 	if err != nil {
 		return err
@@ -1945,8 +4411,69 @@ func (d *driver) deleteFile(ctx context.Context, file *pfs.File) error {
 	return err
 }
 
+// deleteFileGlob deletes every file and directory that file.Path, a glob
+// pattern, matches. It's a single write, whose pattern is matched when the
+// write is applied.
+func (d *driver) deleteFileGlob(ctx context.Context, file *pfs.File) error {
+	if err := d.checkIsAuthorized(ctx, file.Commit.Repo, auth.Scope_WRITER); err != nil {
+		return err
+	}
+	if err := d.checkGlobIsDeletable(ctx, file); err != nil {
+		return err
+	}
+	d.featureUsage.inc("delete_file_glob")
+	records, err := deleteGlobRecords(file.Path)
+	if err != nil {
+		return err
+	}
+	commitInfo, err := d.inspectCommit(ctx, file.Commit)
+	if err != nil {
+		return err
+	}
+	if commitInfo.Finished != nil {
+		return pfsserver.ErrCommitFinished{file.Commit}
+	}
+	prefix, err := d.scratchFilePrefix(ctx, client.NewFile(file.Commit.Repo.Name, commitInfo.Commit.ID, file.Path))
+	if err != nil {
+		return err
+	}
+	_, err = d.etcdClient.Put(ctx, path.Join(prefix, uuid.NewWithoutDashes()), records)
+	return err
+}
+
+// deleteGlobRecords returns the marshalled records of a delete of the files
+// that pattern matches.
+func deleteGlobRecords(pattern string) (string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return "", fmt.Errorf("glob \"%s\" is malformed", pattern)
+	}
+	records := &pfs.PutFileRecords{
+		DeleteGlob: pattern,
+	}
+	marshalledRecords, err := records.Marshal()
+	if err != nil {
+		return "", err
+	}
+	return string(marshalledRecords), nil
+}
+
+// deleteGlob deletes the files and directories in tree that pattern matches.
+func deleteGlob(tree hashtree.OpenHashTree, pattern string) error {
+	matches, err := tree.Glob(pattern)
+	if err != nil {
+		return err
+	}
+	for _, match := range matches {
+		// the children of a deleted directory may have matched too
+		if err := tree.DeleteFile(match.Name); err != nil && hashtree.Code(err) != hashtree.PathNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
 func (d *driver) deleteAll(ctx context.Context) error {
-	repoInfos, err := d.listRepo(ctx, nil, !includeAuth)
+	repoInfos, err := d.listRepo(ctx, nil, "", true, !includeAuth)
 	if err != nil {
 		return err
 	}
@@ -1958,10 +4485,67 @@ func (d *driver) deleteAll(ctx context.Context) error {
 	return nil
 }
 
-func (d *driver) applyWrites(resp *etcd.GetResponse, tree hashtree.OpenHashTree) error {
+// moveNodes moves the node at src in tree, and every node under it, to dst,
+// replacing whatever is at dst. The moved files keep their objects.
+func moveNodes(tree hashtree.OpenHashTree, src string, dst string) error {
+	src = path.Clean("/" + src)
+	dst = path.Clean("/" + dst)
+	var srcPaths []string
+	srcNodes := make(map[string]*hashtree.NodeProto)
+	if err := tree.Walk(src, func(walkPath string, node *hashtree.NodeProto) error {
+		srcPaths = append(srcPaths, walkPath)
+		srcNodes[walkPath] = node
+		return nil
+	}); err != nil {
+		// src was deleted after the move was recorded, moving it is a no-op
+		if hashtree.Code(err) == hashtree.PathNotFound {
+			return nil
+		}
+		return err
+	}
+	if err := tree.DeleteFile(dst); err != nil && hashtree.Code(err) != hashtree.PathNotFound {
+		return err
+	}
+	for _, srcPath := range srcPaths {
+		node := srcNodes[srcPath]
+		dstPath := path.Join(dst, strings.TrimPrefix(srcPath, src))
+		switch {
+		case node.DirNode != nil:
+			if err := tree.PutDir(dstPath); err != nil {
+				return err
+			}
+		case node.SymlinkNode != nil:
+			if err := tree.PutSymlink(dstPath, node.SymlinkNode.Target); err != nil {
+				return err
+			}
+		case node.FileNode != nil:
+			if err := tree.PutFileNode(dstPath, node.FileNode, node.SubtreeSize); err != nil {
+				return err
+			}
+		}
+	}
+	return tree.DeleteFile(src)
+}
+
+func (d *driver) applyWrites(resp *etcd.GetResponse, tree hashtree.OpenHashTree, progress *progressReporter) error {
+	// resp is sorted by ModRevision, but the writes made by a PutFiles batch
+	// all share a ModRevision, so they're ordered by the index at the end of
+	// their keys.
+	sort.SliceStable(resp.Kvs, func(i, j int) bool {
+		if resp.Kvs[i].ModRevision != resp.Kvs[j].ModRevision {
+			return resp.Kvs[i].ModRevision < resp.Kvs[j].ModRevision
+		}
+		return path.Base(string(resp.Kvs[i].Key)) < path.Base(string(resp.Kvs[j].Key))
+	})
+	d.orderDeletesLeafFirst(resp.Kvs)
 	// a map that keeps track of the sizes of objects
 	sizeMap := make(map[string]int64)
 	for _, kv := range resp.Kvs {
+		if err := progress.update(func(p *pfs.CommitProgress) {
+			p.RecordsApplied++
+		}); err != nil {
+			return err
+		}
 		// fileStr is going to look like "some/path/UUID"
 		fileStr := d.filePathFromEtcdPath(string(kv.Key))
 		// the last element of `parts` is going to be UUID
@@ -1977,11 +4561,56 @@ func (d *driver) applyWrites(resp *etcd.GetResponse, tree hashtree.OpenHashTree)
 					return err
 				}
 			}
+			// nothing may be left at or under filePath, see Verify below
+			if _, err := tree.Get(filePath); err == nil {
+				return fmt.Errorf("\"%s\" survived being deleted (this is likely a bug)", filePath)
+			} else if hashtree.Code(err) != hashtree.PathNotFound {
+				return err
+			}
 		} else {
 			records := &pfs.PutFileRecords{}
 			if err := records.Unmarshal(kv.Value); err != nil {
 				return err
 			}
+			if records.DeleteGlob != "" {
+				if err := deleteGlob(tree, records.DeleteGlob); err != nil {
+					return err
+				}
+				continue
+			}
+			if records.MoveFrom != "" {
+				if err := moveNodes(tree, records.MoveFrom, filePath); err != nil {
+					return err
+				}
+				continue
+			}
+			if records.SymlinkTarget != "" {
+				// a symlink replaces whatever is at its path
+				if err := tree.DeleteFile(filePath); err != nil && hashtree.Code(err) != hashtree.PathNotFound {
+					return err
+				}
+				if err := tree.PutSymlink(filePath, records.SymlinkTarget); err != nil {
+					return err
+				}
+				continue
+			}
+			switch records.Mode {
+			case pfs.PutFileMode_OVERWRITE:
+				// the old objects are removed in the same pass that adds
+				// the new ones, so readers never see the file partly written
+				if err := tree.DeleteFile(filePath); err != nil && hashtree.Code(err) != hashtree.PathNotFound {
+					return err
+				}
+			case pfs.PutFileMode_CREATE_ONLY:
+				// PutFile checks that the file doesn't exist, but another
+				// write may have created it since, in which case the first
+				// write wins
+				if _, err := tree.Get(filePath); err == nil {
+					continue
+				} else if hashtree.Code(err) != hashtree.PathNotFound {
+					return err
+				}
+			}
 			if !records.Split {
 				if len(records.Records) == 0 {
 					return fmt.Errorf("unexpect %d length pfs.PutFileRecord (this is likely a bug)", len(records.Records))
@@ -1999,11 +4628,11 @@ func (d *driver) applyWrites(resp *etcd.GetResponse, tree hashtree.OpenHashTree)
 							}
 						}
 
-						if err := tree.PutFileOverwrite(filePath, []*pfs.Object{{Hash: record.ObjectHash}}, record.OverwriteIndex, delta); err != nil {
+						if err := tree.PutFileOverwrite(filePath, []*pfs.Object{{Hash: record.ObjectHash, Compression: record.Compression}}, record.OverwriteIndex, delta); err != nil {
 							return err
 						}
 					} else {
-						if err := tree.PutFile(filePath, []*pfs.Object{{Hash: record.ObjectHash}}, record.SizeBytes); err != nil {
+						if err := tree.PutFile(filePath, []*pfs.Object{{Hash: record.ObjectHash, Compression: record.Compression}}, record.SizeBytes); err != nil {
 							return err
 						}
 					}
@@ -2024,14 +4653,52 @@ func (d *driver) applyWrites(resp *etcd.GetResponse, tree hashtree.OpenHashTree)
 					indexOffset++ // start writing to the file after the last file
 				}
 				for i, record := range records.Records {
-					if err := tree.PutFile(path.Join(filePath, fmt.Sprintf(splitSuffixFmt, i+int(indexOffset))), []*pfs.Object{{Hash: record.ObjectHash}}, record.SizeBytes); err != nil {
+					splitPath := path.Join(filePath, fmt.Sprintf(splitSuffixFmt, i+int(indexOffset)))
+					objects, size := splitFrameObjects(records, record)
+					if err := tree.PutFile(splitPath, objects, size); err != nil {
+						return err
+					}
+					node, err := tree.GetOpen(splitPath)
+					if err != nil {
 						return err
 					}
+					node.FileNode.Stats = record.Stats
+					node.FileNode.RecordCount = record.RecordCount
+					node.FileNode.Delimiter = records.Delimiter
+					setSplitFrame(node.FileNode, records)
 				}
 			}
 		}
 	}
-	return nil
+	// the writes must never leave the tree in a state where a deleted
+	// directory's files survive it, or a file has no directory, so that
+	// isn't committed if it somehow happens
+	return tree.Verify()
+}
+
+// orderDeletesLeafFirst reorders each run of deletes that were written
+// together, by a PutFiles batch, so that the deepest paths are deleted first.
+// Deletes commute, so this doesn't change the result, but a directory is
+// never deleted before the files in it that are deleted with it, which means
+// no delete of the run is skipped for its path being gone already. kvs must
+// already be in the order that they're applied in.
+func (d *driver) orderDeletesLeafFirst(kvs []*mvccpb.KeyValue) {
+	depth := func(kv *mvccpb.KeyValue) int {
+		return strings.Count(path.Dir(d.filePathFromEtcdPath(string(kv.Key))), "/")
+	}
+	for start := 0; start < len(kvs); {
+		end := start + 1
+		if string(kvs[start].Value) == tombstone {
+			for end < len(kvs) && string(kvs[end].Value) == tombstone && kvs[end].ModRevision == kvs[start].ModRevision {
+				end++
+			}
+			run := kvs[start:end]
+			sort.SliceStable(run, func(i, j int) bool {
+				return depth(run[i]) > depth(run[j])
+			})
+		}
+		start = end
+	}
 }
 
 func isNotFoundErr(err error) bool {
@@ -0,0 +1,269 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+)
+
+// clusterFlagsKey is the key of the cluster's flag settings in the
+// featureFlags collection, the other keys are repo names, which can't
+// contain "~".
+const clusterFlagsKey = "~cluster"
+
+const (
+	// flagOverwriteByDefault makes PutFile overwrite the file it writes to,
+	// unless an overwrite index or a mode other than APPEND is given.
+	flagOverwriteByDefault = "overwrite_by_default"
+	// flagStrictSplit makes a LINE split fail if its input doesn't end with
+	// a newline, rather than split the unterminated end as a line.
+	flagStrictSplit = "strict_split"
+	// flagStrictProvenance makes StartCommit fail if it's given provenance
+	// in a repo that isn't provenance of the commit's repo.
+	flagStrictProvenance = "strict_provenance"
+	// flagAutoRecomputeRepoSize makes the repo's size be recomputed in the
+	// background, see RecomputeRepoSize.
+	flagAutoRecomputeRepoSize = "auto_recompute_repo_size"
+)
+
+// featureFlag is a driver behavior that's behind a feature flag.
+type featureFlag struct {
+	description string
+	// on is whether the flag is on where it isn't set
+	on bool
+}
+
+// featureFlags are the flags that the driver consults, by name.
+var featureFlags = map[string]featureFlag{
+	flagOverwriteByDefault: {
+		description: "PutFile overwrites the file, unless it's given an overwrite index or a mode other than APPEND.",
+	},
+	flagStrictSplit: {
+		description: "put-file --split line fails if the input doesn't end with a newline.",
+	},
+	flagStrictProvenance: {
+		description: "StartCommit fails if it's given provenance in a repo that isn't provenance of the commit's repo.",
+	},
+	flagAutoRecomputeRepoSize: {
+		description: "The repo's size is recomputed from its branch heads every hour, correcting any drift.",
+	},
+}
+
+// flagEnabled returns whether the flag called name is on for repo, that's
+// its setting for repo if it has one, or else its setting for the cluster,
+// or else its default. Each time it's on, it's counted as a use of the flag.
+func (d *driver) flagEnabled(ctx context.Context, repo *pfs.Repo, name string) (bool, error) {
+	flag, ok := featureFlags[name]
+	if !ok {
+		return false, fmt.Errorf("unknown feature flag %s", name)
+	}
+	enabled := flag.on
+	for _, key := range []string{repo.Name, clusterFlagsKey} {
+		settings := &pfs.FeatureFlagSettings{}
+		if err := d.featureFlags.ReadOnly(ctx).Get(key, settings); err != nil {
+			if col.IsErrNotFound(err) {
+				continue
+			}
+			return false, err
+		}
+		if on, ok := settings.Flags[name]; ok {
+			enabled = on
+			break
+		}
+	}
+	if enabled {
+		d.featureUsage.inc("flag_" + name)
+	}
+	return enabled, nil
+}
+
+// listFeatureFlags returns the feature flags, sorted by name, with their
+// settings for the cluster and every repo that they're set for.
+func (d *driver) listFeatureFlags(ctx context.Context) ([]*pfs.FeatureFlag, error) {
+	var names []string
+	for name := range featureFlags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	usage := d.featureUsage.snapshot()
+	flags := make(map[string]*pfs.FeatureFlag)
+	var result []*pfs.FeatureFlag
+	for _, name := range names {
+		flag := &pfs.FeatureFlag{
+			Name:        name,
+			Description: featureFlags[name].description,
+			Default:     featureFlags[name].on,
+			Repos:       make(map[string]pfs.FeatureFlagSetting),
+			Uses:        usage["flag_"+name],
+		}
+		flags[name] = flag
+		result = append(result, flag)
+	}
+	iter, err := d.featureFlags.ReadOnly(ctx).List()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var key string
+		settings := &pfs.FeatureFlagSettings{}
+		ok, err := iter.Next(&key, settings)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		for name, on := range settings.Flags {
+			flag, ok := flags[name]
+			if !ok {
+				continue // a flag that's been removed since it was set
+			}
+			if key == clusterFlagsKey {
+				flag.Cluster = flagSetting(on)
+			} else {
+				flag.Repos[key] = flagSetting(on)
+			}
+		}
+	}
+	return result, nil
+}
+
+func flagSetting(on bool) pfs.FeatureFlagSetting {
+	if on {
+		return pfs.FeatureFlagSetting_ON
+	}
+	return pfs.FeatureFlagSetting_OFF
+}
+
+// setFeatureFlag sets the flag called name for repo, or for the cluster if
+// repo is nil. Only a repo's owners can set its flags, and only cluster
+// admins can set the cluster's.
+func (d *driver) setFeatureFlag(ctx context.Context, name string, repo *pfs.Repo, setting pfs.FeatureFlagSetting) error {
+	if _, ok := featureFlags[name]; !ok {
+		return fmt.Errorf("unknown feature flag %s", name)
+	}
+	key := clusterFlagsKey
+	if repo != nil {
+		if err := d.checkIsAuthorized(ctx, repo, auth.Scope_OWNER); err != nil {
+			return err
+		}
+		key = repo.Name
+	} else {
+		d.initializePachConn()
+		whoAmI, err := d.pachClient.AuthAPIClient.WhoAmI(auth.In2Out(ctx), &auth.WhoAmIRequest{})
+		if err != nil && !auth.IsNotActivatedError(err) {
+			return grpcutil.ScrubGRPC(err)
+		} else if err == nil && !whoAmI.IsAdmin {
+			return fmt.Errorf("only cluster admins can set feature flags for the cluster")
+		}
+	}
+	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		if repo != nil {
+			if err := d.repos.ReadWrite(stm).Get(repo.Name, &pfs.RepoInfo{}); err != nil {
+				return err
+			}
+		}
+		featureFlags := d.featureFlags.ReadWrite(stm)
+		settings := &pfs.FeatureFlagSettings{}
+		if err := featureFlags.Get(key, settings); err != nil && !col.IsErrNotFound(err) {
+			return err
+		}
+		if settings.Flags == nil {
+			settings.Flags = make(map[string]bool)
+		}
+		switch setting {
+		case pfs.FeatureFlagSetting_ON:
+			settings.Flags[name] = true
+		case pfs.FeatureFlagSetting_OFF:
+			settings.Flags[name] = false
+		default:
+			delete(settings.Flags, name)
+		}
+		if len(settings.Flags) == 0 {
+			if err := featureFlags.Delete(key); err != nil && !col.IsErrNotFound(err) {
+				return err
+			}
+			return nil
+		}
+		return featureFlags.Put(key, settings)
+	})
+	return err
+}
+
+// putFileMode returns the mode that a PutFile to repo, with mode and
+// overwriteIndex, writes in. That's OVERWRITE rather than APPEND if the
+// overwrite_by_default flag is on for repo.
+func (d *driver) putFileMode(ctx context.Context, repo *pfs.Repo, mode pfs.PutFileMode, overwriteIndex *pfs.OverwriteIndex) (pfs.PutFileMode, error) {
+	if mode != pfs.PutFileMode_APPEND || overwriteIndex != nil {
+		return mode, nil
+	}
+	overwrite, err := d.flagEnabled(ctx, repo, flagOverwriteByDefault)
+	if err != nil {
+		return mode, err
+	}
+	if overwrite {
+		return pfs.PutFileMode_OVERWRITE, nil
+	}
+	return mode, nil
+}
+
+// strictSplitReader reads the input of a LINE split, and remembers whether
+// the input ends with a newline, see flagStrictSplit.
+type strictSplitReader struct {
+	r    io.Reader
+	read bool
+	last byte
+}
+
+// newStrictSplitReader returns a strictSplitReader reading reader, if it's
+// the input of a delimiter split to repo and the strict_split flag is on for
+// repo. Otherwise it returns nil.
+func (d *driver) newStrictSplitReader(ctx context.Context, repo *pfs.Repo, delimiter pfs.Delimiter, reader io.Reader) (*strictSplitReader, error) {
+	if delimiter != pfs.Delimiter_LINE {
+		return nil, nil
+	}
+	strict, err := d.flagEnabled(ctx, repo, flagStrictSplit)
+	if err != nil || !strict {
+		return nil, err
+	}
+	return &strictSplitReader{r: reader}, nil
+}
+
+func (r *strictSplitReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.read = true
+		r.last = p[n-1]
+	}
+	return n, err
+}
+
+// check returns an error if the input that's been read is unterminated.
+func (r *strictSplitReader) check() error {
+	if r.read && r.last != '\n' {
+		return fmt.Errorf("the input of a line split must end with a newline, as %s is on", flagStrictSplit)
+	}
+	return nil
+}
+
+// checkCommitProvenance returns an error if provenance, the provenance of a
+// new commit in repoInfo's repo, has commits in repos that aren't provenance
+// of the repo, see flagStrictProvenance.
+func checkCommitProvenance(repoInfo *pfs.RepoInfo, provenance []*pfs.Commit) error {
+	repos := make(map[string]bool)
+	for _, prov := range repoInfo.Provenance {
+		repos[prov.Name] = true
+	}
+	for _, prov := range provenance {
+		if !repos[prov.Repo.Name] {
+			return fmt.Errorf("%s isn't provenance of %s, so its commits can't be provenance of the commits in %s, as %s is on", prov.Repo.Name, repoInfo.Repo.Name, repoInfo.Repo.Name, flagStrictProvenance)
+		}
+	}
+	return nil
+}
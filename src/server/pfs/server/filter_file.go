@@ -0,0 +1,241 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+
+	"github.com/jmespath/go-jmespath"
+)
+
+// recordFilter writes the records read from r that it matches to w.
+type recordFilter func(r io.Reader, w io.Writer) error
+
+// filterFile returns the records of the files matching the glob pattern
+// file.Path that match regex or jmesPath. Files are filtered in path order
+// as the returned reader is read, and closing the reader early aborts the
+// filtering.
+func (d *driver) filterFile(ctx context.Context, file *pfs.File, regex string, jmesPath string) (io.ReadCloser, error) {
+	if err := d.checkIsAuthorized(ctx, file.Commit.Repo, auth.Scope_READER); err != nil {
+		return nil, err
+	}
+	filter, err := newRecordFilter(regex, jmesPath)
+	if err != nil {
+		return nil, err
+	}
+	readFilter, err := d.readFilter(ctx, file.Commit.Repo)
+	if err != nil {
+		return nil, err
+	}
+	if readFilter != nil {
+		filter = chainFilters(readFilter, filter)
+	}
+	d.featureUsage.inc("filter_file")
+	tree, err := d.getTreeForFile(ctx, client.NewFile(file.Commit.Repo.Name, file.Commit.ID, ""))
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := tree.Glob(file.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	r, w := io.Pipe()
+	go func() {
+		w.CloseWithError(d.writeFiltered(ctx, w, nodes, filter))
+	}()
+	return r, nil
+}
+
+func (d *driver) writeFiltered(ctx context.Context, w io.Writer, nodes []*hashtree.NodeProto, filter recordFilter) error {
+	for _, node := range nodes {
+		if node.FileNode == nil || len(node.FileNode.Objects) == 0 {
+			continue
+		}
+		r, err := d.objectsReader(ctx, node.FileNode.Objects, 0, 0)
+		if err != nil {
+			return err
+		}
+		if err := filter(r, w); err != nil {
+			return fmt.Errorf("error filtering %s: %v", node.Name, err)
+		}
+	}
+	return nil
+}
+
+// grepFile calls f with each line of the files matching the glob pattern
+// that matches regex, in path order, until limit lines have matched (if
+// limit is greater than 0).
+func (d *driver) grepFile(ctx context.Context, commit *pfs.Commit, pattern string, regex string, limit int64, f func(*pfs.GrepMatch) error) error {
+	if err := d.checkIsAuthorized(ctx, commit.Repo, auth.Scope_READER); err != nil {
+		return err
+	}
+	re, err := regexp.Compile(regex)
+	if err != nil {
+		return err
+	}
+	readFilter, err := d.readFilter(ctx, commit.Repo)
+	if err != nil {
+		return err
+	}
+	d.featureUsage.inc("grep_file")
+	tree, err := d.getTreeForFile(ctx, client.NewFile(commit.Repo.Name, commit.ID, ""))
+	if err != nil {
+		return err
+	}
+	nodes, err := tree.Glob(pattern)
+	if err != nil {
+		return err
+	}
+
+	// cancelling ctx stops the object reads when the limit is reached
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var matches int64
+	for _, node := range nodes {
+		if node.FileNode == nil || len(node.FileNode.Objects) == 0 {
+			continue
+		}
+		r, err := d.objectsReader(ctx, node.FileNode.Objects, 0, 0)
+		if err != nil {
+			return err
+		}
+		if readFilter != nil {
+			// offsets and line numbers are of the filtered content
+			filtered := filterReader(r, readFilter)
+			defer filtered.Close()
+			r = filtered
+		}
+		br := bufio.NewReader(r)
+		var offset int64
+		for lineNumber := int64(1); ; lineNumber++ {
+			line, err := br.ReadBytes('\n')
+			if err != nil && err != io.EOF {
+				return fmt.Errorf("error reading %s: %v", node.Name, err)
+			}
+			text := strings.TrimSuffix(string(line), "\n")
+			if len(line) > 0 && re.MatchString(text) {
+				if err := f(&pfs.GrepMatch{
+					File:        &pfs.File{Commit: commit, Path: node.Name},
+					OffsetBytes: offset,
+					LineNumber:  lineNumber,
+					Line:        text,
+				}); err != nil {
+					return err
+				}
+				matches++
+				if limit > 0 && matches >= limit {
+					return nil
+				}
+			}
+			if err == io.EOF {
+				break
+			}
+			offset += int64(len(line))
+		}
+	}
+	return nil
+}
+
+func newRecordFilter(regex string, jmesPath string) (recordFilter, error) {
+	switch {
+	case regex != "" && jmesPath != "":
+		return nil, fmt.Errorf("only one of regex and jmes_path can be set")
+	case regex != "":
+		re, err := regexp.Compile(regex)
+		if err != nil {
+			return nil, err
+		}
+		return func(r io.Reader, w io.Writer) error {
+			return filterLines(r, w, re)
+		}, nil
+	case jmesPath != "":
+		jp, err := jmespath.Compile(jmesPath)
+		if err != nil {
+			return nil, err
+		}
+		return func(r io.Reader, w io.Writer) error {
+			return filterJSON(r, w, jp)
+		}, nil
+	default:
+		return nil, fmt.Errorf("one of regex and jmes_path must be set")
+	}
+}
+
+// filterLines writes the lines read from r that match re to w.
+func filterLines(r io.Reader, w io.Writer, re *regexp.Regexp) error {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 && re.Match(line) {
+			if line[len(line)-1] != '\n' {
+				line = append(line, '\n')
+			}
+			if _, err := w.Write(line); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// filterJSON writes the JSON values read from r for which jp evaluates to a
+// truthy value to w, one per line.
+func filterJSON(r io.Reader, w io.Writer, jp *jmespath.JMESPath) error {
+	decoder := json.NewDecoder(r)
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return err
+		}
+		result, err := jp.Search(value)
+		if err != nil {
+			return err
+		}
+		if !isTruthy(result) {
+			continue
+		}
+		if _, err := w.Write(append(raw, '\n')); err != nil {
+			return err
+		}
+	}
+}
+
+// isTruthy returns true unless value is false, null or empty, as defined by
+// JMESPath.
+func isTruthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	}
+	return true
+}
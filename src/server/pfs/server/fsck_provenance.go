@@ -0,0 +1,358 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+)
+
+// fsckProvenance checks the provenance of every repo and commit, and the
+// repo ref counts, see ProvenanceInconsistency. If fix is set the
+// inconsistencies are repaired as they're found. Like rebuilding object ref
+// counts, it's best run while no repos or commits are being created or
+// deleted. It's run as an admin job.
+func (d *driver) fsckProvenance(ctx context.Context, fix bool) ([]*pfs.ProvenanceInconsistency, error) {
+	whoAmI, err := d.pachClient.AuthAPIClient.WhoAmI(auth.In2Out(ctx), &auth.WhoAmIRequest{})
+	if err != nil && !auth.IsNotActivatedError(err) {
+		return nil, grpcutil.ScrubGRPC(err)
+	} else if err == nil && !whoAmI.IsAdmin {
+		return nil, fmt.Errorf("only cluster admins can check provenance")
+	}
+	d.featureUsage.inc("fsck_provenance")
+	var result []*pfs.ProvenanceInconsistency
+	if err := d.runAdminJob(ctx, "fsck_provenance", func(ctx context.Context, job *adminJob) error {
+		names, err := d.repoNames(ctx)
+		if err != nil {
+			return err
+		}
+		if result, err = d.fsckRepoProvenance(ctx, job, names, fix); err != nil {
+			return err
+		}
+		job.setPhase("checking the provenance of each repo's commits", int64(len(names)))
+		for _, name := range names {
+			inconsistencies, err := d.fsckCommitProvenance(ctx, name, fix)
+			if err != nil {
+				return err
+			}
+			result = append(result, inconsistencies...)
+			job.progress(1)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// fsckRepoProvenance checks the full provenance of the repos called names
+// against the one derived from their immediate provenance, and the ref count
+// of each repo against the number of full provenances it's in. It's checked
+// in a single transaction, so that the repos are consistent with each other,
+// which only writes to etcd if fix is set.
+func (d *driver) fsckRepoProvenance(ctx context.Context, job *adminJob, names []string, fix bool) ([]*pfs.ProvenanceInconsistency, error) {
+	var result []*pfs.ProvenanceInconsistency
+	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		// the phase restarts if the transaction is retried
+		job.setPhase("checking the provenance of each repo", int64(len(names)))
+		result = nil
+		repos := d.repos.ReadWrite(stm)
+		repoRefCounts := d.repoRefCounts.ReadWriteInt(stm)
+		repoInfos := make(map[string]*pfs.RepoInfo)
+		for _, name := range names {
+			repoInfo := new(pfs.RepoInfo)
+			if err := repos.Get(name, repoInfo); err != nil {
+				if col.IsErrNotFound(err) {
+					continue // the repo was deleted
+				}
+				return err
+			}
+			repoInfos[name] = repoInfo
+		}
+
+		// the immediate provenance of a repo that's been deleted with
+		// --force can still hold it
+		immediate := make(map[string][]*pfs.Repo)
+		changed := make(map[string]bool)
+		for _, name := range names {
+			repoInfo, ok := repoInfos[name]
+			if !ok {
+				continue
+			}
+			var dangling bool
+			for _, prov := range immediateProvenance(repoInfo) {
+				if _, ok := repoInfos[prov.Name]; ok {
+					immediate[name] = append(immediate[name], prov)
+					continue
+				}
+				dangling = true
+				result = append(result, &pfs.ProvenanceInconsistency{
+					Type:           pfs.ProvenanceInconsistency_DANGLING_REPO_PROVENANCE,
+					Repo:           repoInfo.Repo,
+					ProvenanceRepo: prov,
+					Fixed:          fix,
+				})
+			}
+			if dangling {
+				repoInfo.ImmediateProvenance = immediate[name]
+				changed[name] = true
+			}
+		}
+
+		full := make(map[string][]*pfs.Repo)
+		visiting := make(map[string]bool)
+		var derive func(name string) ([]*pfs.Repo, error)
+		derive = func(name string) ([]*pfs.Repo, error) {
+			if provenance, ok := full[name]; ok {
+				return provenance, nil
+			}
+			if visiting[name] {
+				return nil, pfsserver.ErrProvenanceCycle{&pfs.Repo{name}}
+			}
+			visiting[name] = true
+			defer delete(visiting, name)
+			provNames := make(map[string]bool)
+			for _, prov := range immediate[name] {
+				provNames[prov.Name] = true
+				provFull, err := derive(prov.Name)
+				if err != nil {
+					return nil, err
+				}
+				for _, prov := range provFull {
+					provNames[prov.Name] = true
+				}
+			}
+			var provenance []*pfs.Repo
+			for provName := range provNames {
+				provenance = append(provenance, &pfs.Repo{provName})
+			}
+			sort.Slice(provenance, func(i, j int) bool { return provenance[i].Name < provenance[j].Name })
+			full[name] = provenance
+			return provenance, nil
+		}
+
+		refCounts := make(map[string]int)
+		for _, name := range names {
+			repoInfo, ok := repoInfos[name]
+			if !ok {
+				continue
+			}
+			provenance, err := derive(name)
+			if err != nil {
+				return err
+			}
+			for _, prov := range provenance {
+				refCounts[prov.Name]++
+			}
+			if was, is := repoNamesString(repoInfo.Provenance), repoNamesString(provenance); was != is {
+				result = append(result, &pfs.ProvenanceInconsistency{
+					Type:   pfs.ProvenanceInconsistency_REPO_PROVENANCE,
+					Repo:   repoInfo.Repo,
+					Detail: fmt.Sprintf("full provenance: [%s] -> [%s]", was, is),
+					Fixed:  fix,
+				})
+				repoInfo.Provenance = provenance
+				changed[name] = true
+			}
+			if fix && changed[name] {
+				if err := repos.Put(name, repoInfo); err != nil {
+					return err
+				}
+			}
+			job.progress(1)
+		}
+
+		for _, name := range names {
+			repoInfo, ok := repoInfos[name]
+			if !ok {
+				continue
+			}
+			was := "missing"
+			refCount, err := repoRefCounts.Get(name)
+			if err != nil {
+				if !col.IsErrNotFound(err) {
+					return err
+				}
+			} else if refCount == refCounts[name] {
+				continue
+			} else {
+				was = fmt.Sprint(refCount)
+			}
+			result = append(result, &pfs.ProvenanceInconsistency{
+				Type:   pfs.ProvenanceInconsistency_REF_COUNT,
+				Repo:   repoInfo.Repo,
+				Detail: fmt.Sprintf("ref count: %s -> %d", was, refCounts[name]),
+				Fixed:  fix,
+			})
+			if fix {
+				if was == "missing" {
+					if err := repoRefCounts.Create(name, refCounts[name]); err != nil {
+						return err
+					}
+				} else if err := repoRefCounts.IncrementBy(name, refCounts[name]-refCount); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// repoNamesString returns the names of repos, sorted and separated by spaces.
+func repoNamesString(repos []*pfs.Repo) string {
+	var names []string
+	for _, repo := range repos {
+		names = append(names, repo.Name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, " ")
+}
+
+// fsckCommitProvenance checks that the provenance of each commit of the repo
+// called repoName only holds commits that exist, and that it holds the
+// provenance of each of them. Each commit whose provenance is inconsistent is
+// repaired in its own transaction if fix is set.
+func (d *driver) fsckCommitProvenance(ctx context.Context, repoName string, fix bool) ([]*pfs.ProvenanceInconsistency, error) {
+	// provenance holds the provenance of the commits that have been read,
+	// by full ID, and nil for the ones that don't exist
+	provenance := make(map[string][]*pfs.Commit)
+	exists := func(commit *pfs.Commit) (bool, error) {
+		if _, ok := provenance[commit.FullID()]; ok {
+			return provenance[commit.FullID()] != nil, nil
+		}
+		commitInfo := new(pfs.CommitInfo)
+		if err := d.commits(commit.Repo.Name).ReadOnly(ctx).Get(commit.ID, commitInfo); err != nil {
+			if col.IsErrNotFound(err) {
+				provenance[commit.FullID()] = nil
+				return false, nil
+			}
+			return false, err
+		}
+		// an empty provenance is kept as such, rather than nil
+		provenance[commit.FullID()] = append([]*pfs.Commit{}, commitInfo.Provenance...)
+		return true, nil
+	}
+
+	iter, err := d.commits(repoName).ReadOnly(ctx).List()
+	if err != nil {
+		return nil, err
+	}
+	var result []*pfs.ProvenanceInconsistency
+	for {
+		var commitID string
+		commitInfo := new(pfs.CommitInfo)
+		ok, err := iter.Next(&commitID, commitInfo)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return result, nil
+		}
+		commit := commitInfo.Commit
+		has := make(map[string]bool)
+		for _, prov := range commitInfo.Provenance {
+			has[prov.FullID()] = true
+		}
+		var dangling, missing []*pfs.Commit
+		// the provenance of the missing commits is checked too, so the
+		// repaired provenance is closed however the others are repaired
+		toCheck := append([]*pfs.Commit{}, commitInfo.Provenance...)
+		for len(toCheck) > 0 {
+			prov := toCheck[0]
+			toCheck = toCheck[1:]
+			ok, err := exists(prov)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				dangling = append(dangling, prov)
+				continue
+			}
+			for _, provProv := range provenance[prov.FullID()] {
+				if has[provProv.FullID()] {
+					continue
+				}
+				// a dangling commit is reported with the provenance that
+				// holds it, rather than copied to this commit's
+				ok, err := exists(provProv)
+				if err != nil {
+					return nil, err
+				}
+				if ok {
+					has[provProv.FullID()] = true
+					missing = append(missing, provProv)
+					toCheck = append(toCheck, provProv)
+				}
+			}
+		}
+		if len(dangling) == 0 && len(missing) == 0 {
+			continue
+		}
+		if fix {
+			if err := d.fixCommitProvenance(ctx, commit, dangling, missing); err != nil {
+				return nil, err
+			}
+		}
+		for _, prov := range dangling {
+			result = append(result, &pfs.ProvenanceInconsistency{
+				Type:             pfs.ProvenanceInconsistency_DANGLING_COMMIT_PROVENANCE,
+				Repo:             commit.Repo,
+				Commit:           commit,
+				ProvenanceCommit: prov,
+				Fixed:            fix,
+			})
+		}
+		for _, prov := range missing {
+			result = append(result, &pfs.ProvenanceInconsistency{
+				Type:             pfs.ProvenanceInconsistency_INCOMPLETE_COMMIT_PROVENANCE,
+				Repo:             commit.Repo,
+				Commit:           commit,
+				ProvenanceCommit: prov,
+				Fixed:            fix,
+			})
+		}
+	}
+}
+
+// fixCommitProvenance removes dangling from the provenance of commit, and adds
+// missing to it.
+func (d *driver) fixCommitProvenance(ctx context.Context, commit *pfs.Commit, dangling []*pfs.Commit, missing []*pfs.Commit) error {
+	remove := make(map[string]bool)
+	for _, prov := range dangling {
+		remove[prov.FullID()] = true
+	}
+	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		commits := d.commits(commit.Repo.Name).ReadWrite(stm)
+		commitInfo := new(pfs.CommitInfo)
+		if err := commits.Get(commit.ID, commitInfo); err != nil {
+			return err
+		}
+		has := make(map[string]bool)
+		var provenance []*pfs.Commit
+		for _, prov := range commitInfo.Provenance {
+			if !remove[prov.FullID()] && !has[prov.FullID()] {
+				has[prov.FullID()] = true
+				provenance = append(provenance, prov)
+			}
+		}
+		for _, prov := range missing {
+			if !has[prov.FullID()] {
+				has[prov.FullID()] = true
+				provenance = append(provenance, prov)
+			}
+		}
+		commitInfo.Provenance = provenance
+		return commits.Put(commit.ID, commitInfo)
+	})
+	return err
+}
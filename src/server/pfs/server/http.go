@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/pachyderm/pachyderm/src/client/auth"
 	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pfs/pretty"
 
 	"github.com/julienschmidt/httprouter"
 	"golang.org/x/net/context"
@@ -33,6 +36,9 @@ func (fw *flushWriter) Write(p []byte) (n int, err error) {
 
 // HTTPServer serves GetFile requests over HTTP
 // e.g. http://localhost:30652/v1/pfs/repos/foo/commits/b7a1923be56744f6a3f1525ec222dc3b/files/ttt.log
+// It also serves ListCommit and ListFile, as JSON Lines or CSV
+// e.g. http://localhost:30652/v1/pfs/repos/foo/commits?to=master&format=csv
+// e.g. http://localhost:30652/v1/pfs/repos/foo/commits/master/list/logs?format=jsonl
 type HTTPServer struct {
 	driver *driver
 	*httprouter.Router
@@ -40,7 +46,7 @@ type HTTPServer struct {
 }
 
 func newHTTPServer(address string, etcdAddresses []string, etcdPrefix string, cacheSize int64) (*HTTPServer, error) {
-	d, err := newDriver(address, etcdAddresses, etcdPrefix, cacheSize)
+	d, err := newDriver(address, etcdAddresses, etcdPrefix, cacheSize, defaultMaxProvenanceDepth)
 	if err != nil {
 		return nil, err
 	}
@@ -52,6 +58,8 @@ func newHTTPServer(address string, etcdAddresses []string, etcdPrefix string, ca
 	}
 
 	router.GET(fmt.Sprintf("/%v/pfs/repos/:repoName/commits/:commitID/files/*filePath", apiVersion), s.getFileHandler)
+	router.GET(fmt.Sprintf("/%v/pfs/repos/:repoName/commits", apiVersion), s.listCommitHandler)
+	router.GET(fmt.Sprintf("/%v/pfs/repos/:repoName/commits/:commitID/list/*filePath", apiVersion), s.listFileHandler)
 	router.POST(s.loginPath, s.authLoginHandler)
 	router.POST(fmt.Sprintf("/%v/auth/logout", apiVersion), s.authLogoutHandler)
 	// Debug method (to check login cookies):
@@ -72,17 +80,9 @@ func (s *HTTPServer) getFileHandler(w http.ResponseWriter, r *http.Request, ps h
 	}
 	filePaths := strings.Split(ps.ByName("filePath"), "/")
 	fileName := filePaths[len(filePaths)-1]
-	ctx := context.Background()
-	for _, cookie := range r.Cookies() {
-		if cookie.Name == auth.ContextTokenKey {
-			ctx = metadata.NewIncomingContext(
-				ctx,
-				metadata.Pairs(auth.ContextTokenKey, cookie.Value),
-			)
-		}
-	}
+	ctx := authContext(r)
 	// Since we can't seek, open a separate reader to sniff mimetype
-	mimeReader, err := s.driver.getFile(ctx, pfsFile, 0, 0)
+	mimeReader, err := s.driver.getFile(ctx, pfsFile, 0, 0, true)
 	if err != nil {
 		panic(err)
 	}
@@ -93,7 +93,7 @@ func (s *HTTPServer) getFileHandler(w http.ResponseWriter, r *http.Request, ps h
 	}
 	contentType := http.DetectContentType(buffer)
 
-	file, err := s.driver.getFile(ctx, pfsFile, 0, 0)
+	file, err := s.driver.getFile(ctx, pfsFile, 0, 0, true)
 	if err != nil {
 		panic(err)
 	}
@@ -109,6 +109,116 @@ func (s *HTTPServer) getFileHandler(w http.ResponseWriter, r *http.Request, ps h
 	io.Copy(&fw, file)
 }
 
+// listCommitHandler serves ListCommit. The query parameters "to", "from"
+// and "number" are those of ListCommit, and "format" is one of
+// pretty.ListingFormats, JSON Lines by default.
+func (s *HTTPServer) listCommitHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	repo := &pfs.Repo{Name: ps.ByName("repoName")}
+	query := r.URL.Query()
+	var to, from *pfs.Commit
+	if query.Get("to") != "" {
+		to = &pfs.Commit{Repo: repo, ID: query.Get("to")}
+	}
+	if query.Get("from") != "" {
+		from = &pfs.Commit{Repo: repo, ID: query.Get("from")}
+	}
+	var number uint64
+	if query.Get("number") != "" {
+		var err error
+		if number, err = strconv.ParseUint(query.Get("number"), 10, 64); err != nil {
+			http.Error(w, fmt.Sprintf("invalid number: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	encoder, err := listingEncoder(w, query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	commitInfos, err := s.driver.listCommit(authContext(r), repo, to, from, number)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("Content-Type", encoder.ContentType())
+	for _, commitInfo := range commitInfos {
+		if err := encoder.EncodeCommitInfo(commitInfo); err != nil {
+			return // the client has gone away
+		}
+	}
+	encoder.Flush()
+}
+
+// listFileHandler serves ListFile. The query parameters "limit" and
+// "page_token" are those of ListFile, and "format" is one of
+// pretty.ListingFormats, JSON Lines by default. If there are more files, the
+// token of the next page is in the Next-Page-Token header.
+func (s *HTTPServer) listFileHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	pfsFile := &pfs.File{
+		Commit: &pfs.Commit{
+			ID: ps.ByName("commitID"),
+			Repo: &pfs.Repo{
+				Name: ps.ByName("repoName"),
+			},
+		},
+		Path: ps.ByName("filePath"),
+	}
+	query := r.URL.Query()
+	var limit int64
+	if query.Get("limit") != "" {
+		var err error
+		if limit, err = strconv.ParseInt(query.Get("limit"), 10, 64); err != nil {
+			http.Error(w, fmt.Sprintf("invalid limit: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	encoder, err := listingEncoder(w, query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fileInfos, nextPageToken, err := s.driver.listFile(authContext(r), pfsFile, false, true, limit, query.Get("page_token"), pfs.ListFileMode_ListFile_NORMAL)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("Content-Type", encoder.ContentType())
+	if nextPageToken != "" {
+		w.Header().Add("Next-Page-Token", nextPageToken)
+	}
+	for _, fileInfo := range fileInfos {
+		if err := encoder.EncodeFileInfo(fileInfo); err != nil {
+			return // the client has gone away
+		}
+	}
+	encoder.Flush()
+}
+
+// listingEncoder returns an encoder writing to w in the format that's asked
+// for by query.
+func listingEncoder(w io.Writer, query url.Values) (*pretty.ListingEncoder, error) {
+	format := query.Get("format")
+	if format == "" {
+		format = pretty.FormatJSONLines
+	}
+	return pretty.NewListingEncoder(format, w)
+}
+
+// authContext returns a context with the auth token from r's cookie, if it
+// has one.
+func authContext(r *http.Request) context.Context {
+	ctx := context.Background()
+	for _, cookie := range r.Cookies() {
+		if cookie.Name == auth.ContextTokenKey {
+			ctx = metadata.NewIncomingContext(
+				ctx,
+				metadata.Pairs(auth.ContextTokenKey, cookie.Value),
+			)
+		}
+	}
+	return ctx
+}
+
 type loginRequestPayload struct {
 	Token string
 }
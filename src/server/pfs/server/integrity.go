@@ -0,0 +1,104 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+)
+
+// getFileVerified is like getFile, but each object of the file is checked
+// against its hash before any of it is returned, and the objects are checked
+// against the file's SHA-256 digest if all of it is read. It also returns the
+// digest. The reader fails with a *pfs.IntegrityError on a mismatch, and has
+// to be closed.
+func (d *driver) getFileVerified(ctx context.Context, file *pfs.File, offset int64, size int64, followSymlinks bool) (io.ReadCloser, []byte, error) {
+	node, err := d.getFileNode(ctx, file, followSymlinks)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := d.checkReadIsUnfiltered(ctx, file.Commit.Repo); err != nil {
+		return nil, nil, err
+	}
+	d.featureUsage.inc("verified_get_file")
+	digest := node.Hash
+	r, w := io.Pipe()
+	go func() {
+		w.CloseWithError(d.writeVerifiedObjects(ctx, file, node.FileNode.Objects, digest, uint64(offset), uint64(size), w))
+	}()
+	return r, digest, nil
+}
+
+// writeVerifiedObjects writes the content of the file made up of objects to
+// w, starting at offset and limited to size bytes unless it's 0. Objects are
+// named by the hash of what's stored, so each one is read whole and checked
+// before any of it is written, and then decompressed. digest, which is
+// computed over the objects' hashes like the file's hash in its hashtree, is
+// checked if it's set and all of the objects are written.
+func (d *driver) writeVerifiedObjects(ctx context.Context, file *pfs.File, objects []*pfs.Object, digest []byte, offset uint64, size uint64, w io.Writer) error {
+	whole := offset == 0 && size == 0
+	digestHash := sha256.New()
+	var buf bytes.Buffer
+	// read is the number of bytes of content before the current object
+	var read uint64
+	for _, object := range objects {
+		if size > 0 && read >= offset+size {
+			break
+		}
+		buf.Reset()
+		if err := d.pachClient.WithCtx(ctx).GetObject(object.Hash, &buf); err != nil {
+			return err
+		}
+		hash := pfs.NewHash()
+		hash.Write(buf.Bytes())
+		if actual := pfs.EncodeHash(hash.Sum(nil)); actual != object.Hash {
+			return &pfs.IntegrityError{
+				Path:     file.Path,
+				Object:   object.Hash,
+				Expected: object.Hash,
+				Actual:   actual,
+			}
+		}
+		digestHash.Write([]byte(object.Hash))
+		decompressed, err := pfs.NewDecompressReader(object.Compression, &buf)
+		if err != nil {
+			return err
+		}
+		content, err := ioutil.ReadAll(decompressed)
+		decompressed.Close()
+		if err != nil {
+			return err
+		}
+		// write the part of content that's between offset and offset+size
+		start, end := uint64(0), uint64(len(content))
+		if offset > read {
+			start = offset - read
+			if start > end {
+				start = end
+			}
+		}
+		if size > 0 && offset+size < read+end {
+			end = offset + size - read
+		}
+		read += uint64(len(content))
+		if start < end {
+			if _, err := w.Write(content[start:end]); err != nil {
+				return err
+			}
+		}
+	}
+	if whole && len(digest) > 0 {
+		if actual := digestHash.Sum(nil); !bytes.Equal(actual, digest) {
+			return &pfs.IntegrityError{
+				Path:     file.Path,
+				Expected: hex.EncodeToString(digest),
+				Actual:   hex.EncodeToString(actual),
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"path"
+	"sort"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+)
+
+// maxInvalidationPrefixes is the most changed path prefixes that an
+// invalidation hint has, see InvalidationHint.changed_prefixes.
+const maxInvalidationPrefixes = 100
+
+// invalidationHint returns the invalidation hint of commitInfo, a finished
+// commit, compared to since, or to its parent if since is nil.
+func (d *driver) invalidationHint(ctx context.Context, commitInfo *pfs.CommitInfo, since *pfs.Commit) (*pfs.InvalidationHint, error) {
+	tree, err := d.getTreeForCommit(ctx, commitInfo.Commit)
+	if err != nil {
+		return nil, err
+	}
+	if since == nil {
+		since = commitInfo.ParentCommit
+	}
+	hint := &pfs.InvalidationHint{
+		Since:    since,
+		TreeHash: treeHash(tree),
+	}
+	sinceTree, err := d.getTreeForCommit(ctx, since)
+	if err != nil {
+		if !isNotFoundErr(err) {
+			return nil, err
+		}
+		// since has been deleted, so there's no telling what changed
+		hint.ChangedPrefixes = []string{"/"}
+		return hint, nil
+	}
+	hint.ChangedPrefixes, err = changedPrefixes(tree, sinceTree, maxInvalidationPrefixes)
+	if err != nil {
+		return nil, err
+	}
+	return hint, nil
+}
+
+// treeHash returns the hex hash of tree's root, or "" if it doesn't have one.
+func treeHash(tree hashtree.HashTree) string {
+	root, err := tree.Get("/")
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(root.Hash)
+}
+
+// changedPrefixes returns the paths under which newTree differs from oldTree,
+// in path order, as deep as they can be while there are at most max of them.
+// They're found a level at a time, descending into the directories that both
+// trees have and that differ, so a file, or a directory that only one of the
+// trees has, is never descended into.
+func changedPrefixes(newTree hashtree.HashTree, oldTree hashtree.HashTree, max int) ([]string, error) {
+	get := func(tree hashtree.HashTree, p string) (*hashtree.NodeProto, error) {
+		node, err := tree.Get(p)
+		if err != nil {
+			if hashtree.Code(err) == hashtree.PathNotFound {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return node, nil
+	}
+	// children returns the children of p that differ, or nil if p can't be
+	// descended into
+	children := func(p string) ([]string, error) {
+		newNode, err := get(newTree, p)
+		if err != nil {
+			return nil, err
+		}
+		oldNode, err := get(oldTree, p)
+		if err != nil {
+			return nil, err
+		}
+		if newNode == nil || oldNode == nil || newNode.DirNode == nil || oldNode.DirNode == nil {
+			return nil, nil
+		}
+		names := make(map[string]bool)
+		for _, name := range newNode.DirNode.Children {
+			names[name] = true
+		}
+		for _, name := range oldNode.DirNode.Children {
+			names[name] = true
+		}
+		var result []string
+		for name := range names {
+			child := path.Join(p, name)
+			newChild, err := get(newTree, child)
+			if err != nil {
+				return nil, err
+			}
+			oldChild, err := get(oldTree, child)
+			if err != nil {
+				return nil, err
+			}
+			if newChild == nil || oldChild == nil || !bytes.Equal(newChild.Hash, oldChild.Hash) {
+				result = append(result, child)
+			}
+		}
+		sort.Strings(result)
+		return result, nil
+	}
+
+	newRoot, err := get(newTree, "/")
+	if err != nil {
+		return nil, err
+	}
+	oldRoot, err := get(oldTree, "/")
+	if err != nil {
+		return nil, err
+	}
+	if newRoot != nil && oldRoot != nil && bytes.Equal(newRoot.Hash, oldRoot.Hash) {
+		return nil, nil
+	}
+	prefixes := []string{"/"}
+	for {
+		var next []string
+		descended := false
+		for _, p := range prefixes {
+			c, err := children(p)
+			if err != nil {
+				return nil, err
+			}
+			if len(c) == 0 {
+				next = append(next, p)
+				continue
+			}
+			next = append(next, c...)
+			descended = true
+		}
+		if !descended || len(next) > max {
+			return prefixes, nil
+		}
+		prefixes = next
+	}
+}
@@ -0,0 +1,382 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// errAllObjectsFound stops the walk of commitObjectsByHash once every object
+// has been found.
+var errAllObjectsFound = errors.New("all objects found")
+
+// getManifest returns the manifest of the files in commit that match any of
+// globs, or of every file if there are no globs. Only finished commits have
+// manifests, as the objects of an open commit's files can still change.
+func (d *driver) getManifest(ctx context.Context, commit *pfs.Commit, globs []string) (*pfs.Manifest, error) {
+	if err := d.checkIsAuthorized(ctx, commit.Repo, auth.Scope_READER); err != nil {
+		return nil, err
+	}
+	// a manifest's objects can be read, or put into other repos, unfiltered
+	if err := d.checkReadIsUnfiltered(ctx, commit.Repo); err != nil {
+		return nil, err
+	}
+	d.featureUsage.inc("get_manifest")
+	commitInfo, err := d.inspectCommit(ctx, commit)
+	if err != nil {
+		return nil, err
+	}
+	if commitInfo.Finished == nil {
+		return nil, fmt.Errorf("commit %s has not been finished", commitInfo.Commit.FullID())
+	}
+	tree, err := d.getTreeForCommit(ctx, commitInfo.Commit)
+	if err != nil {
+		return nil, err
+	}
+
+	roots := []string{"/"}
+	if len(globs) > 0 {
+		roots = nil
+		for _, glob := range globs {
+			matches, err := tree.Glob(glob)
+			if err != nil {
+				return nil, err
+			}
+			for _, match := range matches {
+				roots = append(roots, match.Name)
+			}
+		}
+	}
+	// globs may overlap, so entries are keyed by path
+	entries := make(map[string]*pfs.ManifestEntry)
+	for _, root := range roots {
+		if err := tree.Walk(root, func(path string, node *hashtree.NodeProto) error {
+			if node.DirNode != nil || entries[path] != nil {
+				return nil
+			}
+			entries[path] = nodeToManifestEntry(path, node)
+			return nil
+		}); err != nil && hashtree.Code(err) != hashtree.PathNotFound {
+			return nil, err
+		}
+	}
+
+	manifest := &pfs.Manifest{Commit: commitInfo.Commit}
+	for _, entry := range entries {
+		manifest.Entries = append(manifest.Entries, entry)
+		manifest.SizeBytes += entry.SizeBytes
+	}
+	sort.Slice(manifest.Entries, func(i, j int) bool {
+		return manifest.Entries[i].Path < manifest.Entries[j].Path
+	})
+	return manifest, nil
+}
+
+func nodeToManifestEntry(path string, node *hashtree.NodeProto) *pfs.ManifestEntry {
+	entry := &pfs.ManifestEntry{
+		Path:      path,
+		SizeBytes: uint64(node.SubtreeSize),
+	}
+	if node.FileNode != nil {
+		entry.FileType = pfs.FileType_FILE
+		entry.Objects = node.FileNode.Objects
+		entry.Sha256 = node.Hash
+	} else if node.SymlinkNode != nil {
+		entry.FileType = pfs.FileType_SYMLINK
+		entry.SymlinkTarget = node.SymlinkNode.Target
+	}
+	return entry
+}
+
+// putFilesFromManifest puts the files in entries into commit atomically, in
+// one putFilesBatch, which is written as a single record however many entries
+// there are. Entries with objects reference objects that are already
+// in the object store, so their data isn't copied, entries with urls are
+// fetched by pachd.
+func (d *driver) putFilesFromManifest(ctx context.Context, commit *pfs.Commit, entries []*pfs.ManifestEntry, overwrite bool) error {
+	// the objects are only inspected for callers that can write the commit
+	if err := d.checkIsAuthorized(ctx, commit.Repo, auth.Scope_WRITER); err != nil {
+		return err
+	}
+	d.featureUsage.inc("put_files_from_manifest")
+	sizes, err := d.manifestObjectSizes(ctx, entries)
+	if err != nil {
+		return err
+	}
+	batch := d.newPutFilesBatch(ctx)
+	mode := pfs.PutFileMode_APPEND
+	if overwrite {
+		mode = pfs.PutFileMode_OVERWRITE
+	}
+	for _, entry := range entries {
+		file := client.NewFile(commit.Repo.Name, commit.ID, entry.Path)
+		if entry.Url != "" {
+			if len(entry.Objects) > 0 || entry.SymlinkTarget != "" {
+				return fmt.Errorf("manifest entry %s can't have both a url and objects or a symlink target", entry.Path)
+			}
+			if err := d.putFileURL(ctx, file, entry.Url, false, func(file *pfs.File, r io.Reader) error {
+				return batch.putFile(file, putFileOptions{mode: mode}, r)
+			}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := batch.resolveCommit(file); err != nil {
+			return err
+		}
+		if err := d.checkPathIsWritable(ctx, file, false); err != nil {
+			return err
+		}
+		if err := checkPath(file.Path); err != nil {
+			return err
+		}
+		records, err := manifestEntryRecords(entry, mode, sizes)
+		if err != nil {
+			return err
+		}
+		marshalledRecords, err := records.Marshal()
+		if err != nil {
+			return err
+		}
+		if err := batch.write(file, string(marshalledRecords)); err != nil {
+			return err
+		}
+	}
+	return batch.commit()
+}
+
+// putFileByHash puts file, whose content is the objects with hashes, which
+// are compressed with compression and hold size bytes of content, if they're
+// all objects of files in file's commit, or its parent (or base). It returns
+// false, without writing anything, if any of them isn't, or if the repo's
+// data isn't compressed with compression.
+func (d *driver) putFileByHash(ctx context.Context, file *pfs.File, hashes []string, mode pfs.PutFileMode, compression pfs.Compression, size int64) (bool, error) {
+	if err := d.checkIsAuthorized(ctx, file.Commit.Repo, auth.Scope_WRITER); err != nil {
+		return false, err
+	}
+	if len(hashes) == 0 {
+		return false, fmt.Errorf("at least one object hash must be given")
+	}
+	if err := checkPutFileMode(mode, nil); err != nil {
+		return false, err
+	}
+	mode, err := d.putFileMode(ctx, file.Commit.Repo, mode, nil)
+	if err != nil {
+		return false, err
+	}
+	batch := d.newPutFilesBatch(ctx)
+	if err := batch.resolveCommit(file); err != nil {
+		return false, err
+	}
+	if err := d.checkPathIsWritable(ctx, file, false); err != nil {
+		return false, err
+	}
+	if err := checkPath(file.Path); err != nil {
+		return false, err
+	}
+	if mode == pfs.PutFileMode_CREATE_ONLY {
+		if err := d.checkFileNotExists(ctx, file); err != nil {
+			return false, err
+		}
+	}
+	repoCompression, _, err := d.repoCompression(ctx, file.Commit.Repo)
+	if err != nil {
+		return false, err
+	}
+	objects, err := d.commitObjectsByHash(ctx, file.Commit, hashes)
+	if err != nil {
+		return false, err
+	}
+	entry := &pfs.ManifestEntry{Path: file.Path, SizeBytes: uint64(size)}
+	for _, hash := range hashes {
+		object, ok := objects[hash]
+		if !ok || compression != repoCompression || object.Compression != compression {
+			d.featureUsage.inc("put_file_by_hash_missing")
+			return false, nil
+		}
+		entry.Objects = append(entry.Objects, object)
+	}
+	if compression != pfs.Compression_UNCOMPRESSED && size == 0 {
+		// compressed objects' sizes aren't known from the objects
+		d.featureUsage.inc("put_file_by_hash_missing")
+		return false, nil
+	}
+	d.featureUsage.inc("put_file_by_hash")
+	sizes, err := d.manifestObjectSizes(ctx, []*pfs.ManifestEntry{entry})
+	if err != nil {
+		return false, err
+	}
+	records, err := manifestEntryRecords(entry, mode, sizes)
+	if err != nil {
+		return false, err
+	}
+	marshalledRecords, err := records.Marshal()
+	if err != nil {
+		return false, err
+	}
+	if err := batch.write(file, string(marshalledRecords)); err != nil {
+		return false, err
+	}
+	if err := batch.commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// commitObjectsByHash returns the objects among hashes that are objects of
+// files in commit, an open commit, either written to it or in its parent (or
+// base), keyed by hash, with their compression. The caller can read all of
+// them, unlike objects that are only in the object store.
+func (d *driver) commitObjectsByHash(ctx context.Context, commit *pfs.Commit, hashes []string) (map[string]*pfs.Object, error) {
+	wanted := make(map[string]bool)
+	for _, hash := range hashes {
+		wanted[hash] = true
+	}
+	objects := make(map[string]*pfs.Object)
+	found := func(object *pfs.Object) {
+		if wanted[object.Hash] && objects[object.Hash] == nil {
+			objects[object.Hash] = object
+		}
+	}
+	commitInfo, err := d.inspectCommit(ctx, commit)
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := d.scratchCommitPrefix(ctx, commitInfo.Commit)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := d.readScratch(ctx, commitInfo.Commit, prefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, kv := range resp.Kvs {
+		if string(kv.Value) == tombstone {
+			continue
+		}
+		records := &pfs.PutFileRecords{}
+		if err := records.Unmarshal(kv.Value); err != nil {
+			return nil, err
+		}
+		for _, record := range records.Records {
+			found(&pfs.Object{Hash: record.ObjectHash, Compression: record.Compression})
+		}
+	}
+	if len(objects) == len(wanted) {
+		return objects, nil
+	}
+	startCommit := commitInfo.ParentCommit
+	if commitInfo.Base != nil {
+		startCommit = commitInfo.Base
+	}
+	tree, err := d.getTreeForCommit(ctx, startCommit)
+	if err != nil {
+		return nil, err
+	}
+	if err := tree.Walk("/", func(path string, node *hashtree.NodeProto) error {
+		if node.FileNode != nil {
+			for _, object := range node.FileNode.Objects {
+				found(object)
+			}
+		}
+		if len(objects) == len(wanted) {
+			return errAllObjectsFound
+		}
+		return nil
+	}); err != nil && err != errAllObjectsFound && hashtree.Code(err) != hashtree.PathNotFound {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// manifestObjectSizes checks that the objects of entries are in the object
+// store, and returns the sizes of the uncompressed ones, keyed by hash.
+func (d *driver) manifestObjectSizes(ctx context.Context, entries []*pfs.ManifestEntry) (map[string]int64, error) {
+	var mu sync.Mutex
+	sizes := make(map[string]int64)
+	var eg errgroup.Group
+	sem := make(chan struct{}, client.DefaultMaxConcurrentStreams)
+	for _, entry := range entries {
+		for _, object := range entry.Objects {
+			object := object
+			mu.Lock()
+			_, ok := sizes[object.Hash]
+			sizes[object.Hash] = 0
+			mu.Unlock()
+			if ok {
+				continue
+			}
+			sem <- struct{}{}
+			eg.Go(func() error {
+				defer func() { <-sem }()
+				objectInfo, err := d.pachClient.WithCtx(ctx).InspectObject(object.Hash)
+				if err != nil {
+					return fmt.Errorf("error inspecting object %s: %v", object.Hash, err)
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				sizes[object.Hash] = int64(objectInfo.BlockRef.Range.Upper - objectInfo.BlockRef.Range.Lower)
+				return nil
+			})
+		}
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	return sizes, nil
+}
+
+// manifestEntryRecords returns the records that put entry. The size of a
+// file made of uncompressed objects is known from the objects, and checked
+// against entry.SizeBytes if it's set; it must be set for compressed ones.
+func manifestEntryRecords(entry *pfs.ManifestEntry, mode pfs.PutFileMode, sizes map[string]int64) (*pfs.PutFileRecords, error) {
+	if entry.SymlinkTarget != "" {
+		if len(entry.Objects) > 0 {
+			return nil, fmt.Errorf("manifest entry %s can't have both objects and a symlink target", entry.Path)
+		}
+		return &pfs.PutFileRecords{SymlinkTarget: entry.SymlinkTarget}, nil
+	}
+	if len(entry.Objects) == 0 {
+		return nil, fmt.Errorf("manifest entry %s must have objects, a url or a symlink target", entry.Path)
+	}
+	var size int64
+	compressed := false
+	for _, object := range entry.Objects {
+		if object.Compression != pfs.Compression_UNCOMPRESSED {
+			compressed = true
+		}
+		size += sizes[object.Hash]
+	}
+	switch {
+	case compressed && entry.SizeBytes == 0:
+		return nil, fmt.Errorf("manifest entry %s has compressed objects, so its size must be set", entry.Path)
+	case compressed:
+		size = int64(entry.SizeBytes)
+	case entry.SizeBytes != 0 && int64(entry.SizeBytes) != size:
+		return nil, fmt.Errorf("manifest entry %s has size %d, but its objects are %d bytes", entry.Path, entry.SizeBytes, size)
+	}
+	records := &pfs.PutFileRecords{Mode: mode}
+	for i, object := range entry.Objects {
+		// like CopyFile, the whole size is recorded on the first object
+		var recordSize int64
+		if i == 0 {
+			recordSize = size
+		}
+		records.Records = append(records.Records, &pfs.PutFileRecord{
+			SizeBytes:   recordSize,
+			ObjectHash:  object.Hash,
+			Compression: object.Compression,
+		})
+	}
+	return records, nil
+}
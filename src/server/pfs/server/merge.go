@@ -0,0 +1,454 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+)
+
+// The versions of a conflicting path in ours and theirs are written next to
+// it, with these suffixes, see MergeInfo.conflicts.
+const (
+	oursSuffix   = ".ours"
+	theirsSuffix = ".theirs"
+)
+
+// mergeConflict is a path that ours and theirs both changed since their
+// merge base, differently. A nil node means that the path was deleted.
+type mergeConflict struct {
+	path   string
+	ours   *hashtree.NodeProto
+	theirs *hashtree.NodeProto
+}
+
+// mergeResult is the three-way merge of two commits.
+type mergeResult struct {
+	// base is the merge base, nil if the commits have no common ancestor
+	base *pfs.Commit
+	// oursTree is the tree of ours, and tree is the merged tree, in which the
+	// conflicting paths are left as they are in ours
+	oursTree  hashtree.HashTree
+	tree      hashtree.HashTree
+	conflicts []mergeConflict
+}
+
+// mergeBase returns the most recent common ancestor of ours and theirs, which
+// are resolved in place, or nil if they don't have one.
+func (d *driver) mergeBase(ctx context.Context, ours *pfs.Commit, theirs *pfs.Commit) (*pfs.Commit, error) {
+	oursInfo, err := d.inspectCommit(ctx, ours)
+	if err != nil {
+		return nil, err
+	}
+	theirsInfo, err := d.inspectCommit(ctx, theirs)
+	if err != nil {
+		return nil, err
+	}
+	commits := d.commits(ours.Repo.Name).ReadOnly(ctx)
+	ancestors := make(map[string]bool)
+	for commitInfo := oursInfo; ; {
+		ancestors[commitInfo.Commit.ID] = true
+		if commitInfo.ParentCommit == nil {
+			break
+		}
+		parent := commitInfo.ParentCommit
+		commitInfo = &pfs.CommitInfo{}
+		if err := commits.Get(parent.ID, commitInfo); err != nil {
+			return nil, err
+		}
+	}
+	for commitInfo := theirsInfo; ; {
+		if ancestors[commitInfo.Commit.ID] {
+			return commitInfo.Commit, nil
+		}
+		if commitInfo.ParentCommit == nil {
+			return nil, nil
+		}
+		parent := commitInfo.ParentCommit
+		commitInfo = &pfs.CommitInfo{}
+		if err := commits.Get(parent.ID, commitInfo); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// merge merges theirs into ours, which must be finished commits of the same
+// repo: the changes that theirs made since the merge base are applied to
+// ours, unless ours changed the same paths differently, which conflict.
+func (d *driver) merge(ctx context.Context, ours *pfs.Commit, theirs *pfs.Commit) (*mergeResult, error) {
+	if ours == nil || theirs == nil {
+		return nil, fmt.Errorf("both commits of a merge must be set")
+	}
+	if ours.Repo.Name != theirs.Repo.Name {
+		return nil, fmt.Errorf("commits of different repos can't be merged")
+	}
+	if err := d.checkIsAuthorized(ctx, ours.Repo, auth.Scope_READER); err != nil {
+		return nil, err
+	}
+	base, err := d.mergeBase(ctx, ours, theirs)
+	if err != nil {
+		return nil, err
+	}
+	oursTree, err := d.getTreeForCommit(ctx, ours)
+	if err != nil {
+		return nil, err
+	}
+	theirsTree, err := d.getTreeForCommit(ctx, theirs)
+	if err != nil {
+		return nil, err
+	}
+	// getTreeForCommit returns an empty tree for a nil commit
+	baseTree, err := d.getTreeForCommit(ctx, base)
+	if err != nil {
+		return nil, err
+	}
+	oursChanges, err := treeChanges(oursTree, baseTree)
+	if err != nil {
+		return nil, err
+	}
+	theirsChanges, err := treeChanges(theirsTree, baseTree)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for path := range theirsChanges {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	result := &mergeResult{
+		base:     base,
+		oursTree: oursTree,
+	}
+	merged := oursTree.Open()
+	for _, path := range paths {
+		theirsNode := theirsChanges[path]
+		if oursNode, ok := oursChanges[path]; ok {
+			if !sameNode(oursNode, theirsNode) {
+				result.conflicts = append(result.conflicts, mergeConflict{path, oursNode, theirsNode})
+			}
+			continue
+		}
+		applied, err := applyChange(merged, path, theirsNode)
+		if err != nil {
+			return nil, err
+		}
+		if !applied {
+			result.conflicts = append(result.conflicts, mergeConflict{path, nil, theirsNode})
+		}
+	}
+	if result.tree, err = merged.Finish(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// treeChanges returns the files and symlinks that differ between tree and
+// base, by path, with a nil node for those that were deleted.
+func treeChanges(tree hashtree.HashTree, base hashtree.HashTree) (map[string]*hashtree.NodeProto, error) {
+	changes := make(map[string]*hashtree.NodeProto)
+	if err := tree.Diff(base, "/", "/", -1, func(path string, node *hashtree.NodeProto, new bool) error {
+		if new {
+			changes[path] = node
+		} else if _, ok := changes[path]; !ok {
+			changes[path] = nil
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// sameNode returns true if a and b are the same change: both deletions, or
+// nodes with the same content.
+func sameNode(a *hashtree.NodeProto, b *hashtree.NodeProto) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return bytes.Equal(a.Hash, b.Hash)
+}
+
+// applyChange makes path node in tree, deleting it if node is nil. It returns
+// false if it can't, because path is a directory in tree, or one of its
+// parents is a file.
+func applyChange(tree hashtree.OpenHashTree, path string, node *hashtree.NodeProto) (bool, error) {
+	existing, err := tree.Get(path)
+	if err != nil && hashtree.Code(err) != hashtree.PathNotFound {
+		return false, err
+	}
+	if existing != nil {
+		if existing.DirNode != nil {
+			return false, nil
+		}
+		if err := tree.DeleteFile(path); err != nil {
+			return false, err
+		}
+	}
+	if node == nil {
+		return true, nil
+	}
+	if err := putNode(tree, path, node); err != nil {
+		if hashtree.Code(err) == hashtree.PathConflict {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// putNode puts node, a file or symlink, at path in tree.
+func putNode(tree hashtree.OpenHashTree, path string, node *hashtree.NodeProto) error {
+	if node.SymlinkNode != nil {
+		return tree.PutSymlink(path, node.SymlinkNode.Target)
+	}
+	return tree.PutFileNode(path, node.FileNode, node.SubtreeSize)
+}
+
+// previewMerge returns what merging theirs into ours would do, without
+// changing either, see PreviewMergeResponse.
+func (d *driver) previewMerge(ctx context.Context, ours *pfs.Commit, theirs *pfs.Commit) (*pfs.PreviewMergeResponse, error) {
+	result, err := d.merge(ctx, ours, theirs)
+	if err != nil {
+		return nil, err
+	}
+	d.featureUsage.inc("preview_merge")
+	summarizer := &diffSummarizer{newPath: "/", oldPath: "/"}
+	if err := result.tree.Diff(result.oursTree, "/", "/", -1, func(path string, node *hashtree.NodeProto, new bool) error {
+		summarizer.add(path, node, new)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	response := &pfs.PreviewMergeResponse{
+		Base:        result.base,
+		Ours:        ours,
+		Theirs:      theirs,
+		Summary:     summarizer.finish(),
+		TreeHash:    treeHash(result.tree),
+		FastForward: result.base != nil && result.base.ID == ours.ID,
+	}
+	for _, conflict := range result.conflicts {
+		response.Conflicts = append(response.Conflicts, conflict.path)
+	}
+	return response, nil
+}
+
+// mergeBranch merges theirs into the head of branch, see MergeInfo.
+func (d *driver) mergeBranch(ctx context.Context, repo *pfs.Repo, branch string, theirs *pfs.Commit) (*pfs.MergeResponse, error) {
+	if branch == "" {
+		return nil, fmt.Errorf("branch cannot be empty")
+	}
+	if err := d.checkIsAuthorized(ctx, repo, auth.Scope_WRITER); err != nil {
+		return nil, err
+	}
+	ours := &pfs.Commit{Repo: repo, ID: branch}
+	result, err := d.merge(ctx, ours, theirs)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.conflicts) == 0 && treeHash(result.tree) == treeHash(result.oursTree) {
+		// theirs is already merged into ours
+		return &pfs.MergeResponse{Commit: ours}, nil
+	}
+	d.featureUsage.inc("merge")
+	tree := result.tree.Open()
+	mergeInfo := &pfs.MergeInfo{
+		Branch: branch,
+		Ours:   ours,
+		Theirs: theirs,
+		Base:   result.base,
+	}
+	for _, conflict := range result.conflicts {
+		if err := writeConflict(tree, conflict); err != nil {
+			return nil, err
+		}
+		mergeInfo.Conflicts = append(mergeInfo.Conflicts, conflict.path)
+	}
+	commit, err := d.makeMergeCommit(ctx, tree, mergeInfo)
+	if err != nil {
+		return nil, err
+	}
+	return &pfs.MergeResponse{
+		Commit:    commit,
+		Conflicts: mergeInfo.Conflicts,
+	}, nil
+}
+
+// writeConflict moves ours' version of the conflicting path, which is the one
+// in tree, to path.ours, and writes theirs' version to path.theirs.
+func writeConflict(tree hashtree.OpenHashTree, conflict mergeConflict) error {
+	get := func(p string) (*hashtree.NodeProto, error) {
+		node, err := tree.Get(p)
+		if err != nil {
+			if hashtree.Code(err) == hashtree.PathNotFound {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return node, nil
+	}
+	put := func(p string, node *hashtree.NodeProto) error {
+		existing, err := get(p)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return fmt.Errorf("conflict %s can't be written, as %s already exists", conflict.path, p)
+		}
+		if err := putNode(tree, p, node); err != nil {
+			if hashtree.Code(err) == hashtree.PathConflict {
+				return fmt.Errorf("conflict %s can't be written, as one of its parents is a file in ours", conflict.path)
+			}
+			return err
+		}
+		return nil
+	}
+	oursNode, err := get(conflict.path)
+	if err != nil {
+		return err
+	}
+	if oursNode != nil {
+		if oursNode.DirNode != nil {
+			return fmt.Errorf("conflict %s can't be written, as it's a directory in ours", conflict.path)
+		}
+		if err := tree.DeleteFile(conflict.path); err != nil {
+			return err
+		}
+		if err := put(conflict.path+oursSuffix, oursNode); err != nil {
+			return err
+		}
+	}
+	if conflict.theirs != nil {
+		if err := put(conflict.path+theirsSuffix, conflict.theirs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveConflict makes a new commit from commit, a commit with conflicts,
+// in which the conflicting filePath is resolved by keeping the version of it
+// given by resolution.
+func (d *driver) resolveConflict(ctx context.Context, commit *pfs.Commit, filePath string, resolution pfs.ConflictResolution) (*pfs.MergeResponse, error) {
+	if err := d.checkIsAuthorized(ctx, commit.Repo, auth.Scope_WRITER); err != nil {
+		return nil, err
+	}
+	commitInfo, err := d.inspectCommit(ctx, commit)
+	if err != nil {
+		return nil, err
+	}
+	if commitInfo.Merge == nil || len(commitInfo.Merge.Conflicts) == 0 {
+		return nil, fmt.Errorf("commit %s has no conflicts", commit.ID)
+	}
+	filePath = path.Clean("/" + filePath)
+	var conflicts []string
+	found := false
+	for _, conflict := range commitInfo.Merge.Conflicts {
+		if conflict == filePath {
+			found = true
+		} else {
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("%s isn't a conflict of commit %s", filePath, commit.ID)
+	}
+	tree, err := d.getTreeForCommit(ctx, commit)
+	if err != nil {
+		return nil, err
+	}
+	openTree := tree.Open()
+	versions := make(map[string]*hashtree.NodeProto)
+	for _, suffix := range []string{oursSuffix, theirsSuffix} {
+		node, err := openTree.Get(filePath + suffix)
+		if err != nil {
+			if hashtree.Code(err) == hashtree.PathNotFound {
+				continue
+			}
+			return nil, err
+		}
+		versions[suffix] = node
+		if err := openTree.DeleteFile(filePath + suffix); err != nil {
+			return nil, err
+		}
+	}
+	chosen := versions[oursSuffix]
+	if resolution == pfs.ConflictResolution_THEIRS {
+		chosen = versions[theirsSuffix]
+	}
+	// a missing version was deleted, so that's what resolving to it does
+	if chosen != nil {
+		if err := putNode(openTree, filePath, chosen); err != nil {
+			return nil, err
+		}
+	}
+	d.featureUsage.inc("resolve_conflict")
+	mergeInfo := &pfs.MergeInfo{
+		Branch:    commitInfo.Merge.Branch,
+		Ours:      commitInfo.Merge.Ours,
+		Theirs:    commitInfo.Merge.Theirs,
+		Base:      commitInfo.Merge.Base,
+		Conflicts: conflicts,
+	}
+	mergeInfo.Resolved = append(mergeInfo.Resolved, commitInfo.Merge.Resolved...)
+	mergeInfo.Resolved = append(mergeInfo.Resolved, &pfs.ResolvedConflict{
+		Path:       filePath,
+		Resolution: resolution,
+	})
+	newCommit, err := d.makeMergeCommit(ctx, openTree, mergeInfo)
+	if err != nil {
+		return nil, err
+	}
+	return &pfs.MergeResponse{
+		Commit:    newCommit,
+		Conflicts: conflicts,
+	}, nil
+}
+
+// makeMergeCommit makes a commit of tree with mergeInfo, whose parent is
+// mergeInfo.Ours. It's the new head of mergeInfo.Branch if there are no
+// conflicts left, which fails if ours isn't the head anymore.
+func (d *driver) makeMergeCommit(ctx context.Context, tree hashtree.OpenHashTree, mergeInfo *pfs.MergeInfo) (*pfs.Commit, error) {
+	repo := mergeInfo.Ours.Repo
+	var branch string
+	var expectedHead *pfs.Commit
+	if len(mergeInfo.Conflicts) == 0 {
+		branch, expectedHead = mergeInfo.Branch, mergeInfo.Ours
+	}
+	finishedTree, err := tree.Finish()
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := d.serializeTree(finishedTree)
+	if err != nil {
+		return nil, err
+	}
+	treeRef, _, err := d.pachClient.PutObject(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	parent := &pfs.Commit{Repo: repo, ID: mergeInfo.Ours.ID}
+	commit, err := d.makeCommit(ctx, parent, branch, nil, treeRef, nil, expectedHead)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		commits := d.commits(repo.Name).ReadWrite(stm)
+		commitInfo := new(pfs.CommitInfo)
+		if err := commits.Get(commit.ID, commitInfo); err != nil {
+			return err
+		}
+		commitInfo.Merge = mergeInfo
+		return commits.Put(commit.ID, commitInfo)
+	}); err != nil {
+		return nil, err
+	}
+	return commit, nil
+}
@@ -0,0 +1,325 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+
+	"github.com/gogo/protobuf/types"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	metadataExportLockPath = "_metadata_export_lock"
+
+	// metadataExportKey is the key of the cluster's metadata export in the
+	// metadataExports collection
+	metadataExportKey = "export"
+
+	// metadataExportPollInterval is how often the metadata export is checked
+	// to see if it's due
+	metadataExportPollInterval = time.Minute
+
+	// defaultMetadataExportInterval is how often metadata is exported if
+	// the export's interval is 0
+	defaultMetadataExportInterval = time.Hour
+
+	// metadataExportBranch is the branch of the export's repo that metadata
+	// is exported to
+	metadataExportBranch = "master"
+)
+
+func (d *driver) setMetadataExport(ctx context.Context, export *pfs.MetadataExport) error {
+	whoAmI, err := d.pachClient.AuthAPIClient.WhoAmI(auth.In2Out(ctx), &auth.WhoAmIRequest{})
+	if err != nil && !auth.IsNotActivatedError(err) {
+		return grpcutil.ScrubGRPC(err)
+	} else if err == nil && !whoAmI.IsAdmin {
+		return fmt.Errorf("only cluster admins can set the metadata export")
+	}
+	var capability string
+	if export != nil {
+		if export.Repo == nil {
+			return fmt.Errorf("the repo that metadata is exported to must be set")
+		}
+		if export.IntervalSeconds < 0 {
+			return fmt.Errorf("the interval of a metadata export can't be negative")
+		}
+		d.featureUsage.inc("metadata_export")
+		resp, err := d.pachClient.AuthAPIClient.GetCapability(auth.In2Out(ctx), &auth.GetCapabilityRequest{})
+		if err != nil && !auth.IsNotActivatedError(err) {
+			return grpcutil.ScrubGRPC(err)
+		} else if err == nil {
+			capability = resp.Capability
+		}
+	}
+	var oldCapability string
+	if _, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		oldCapability = ""
+		exports := d.metadataExports.ReadWrite(stm)
+		exportInfo := &pfs.MetadataExportInfo{}
+		if err := exports.Get(metadataExportKey, exportInfo); err != nil && !col.IsErrNotFound(err) {
+			return err
+		}
+		oldCapability = exportInfo.Capability
+		if export == nil {
+			if err := exports.Delete(metadataExportKey); err != nil && !col.IsErrNotFound(err) {
+				return err
+			}
+			return nil
+		}
+		// Make sure that the repo exists
+		if err := d.repos.ReadWrite(stm).Get(export.Repo.Name, &pfs.RepoInfo{}); err != nil {
+			return err
+		}
+		// the time of the last export carries over, so the new interval
+		// counts from it
+		return exports.Put(metadataExportKey, &pfs.MetadataExportInfo{
+			Export:     export,
+			LastExport: exportInfo.LastExport,
+			LastCommit: exportInfo.LastCommit,
+			Capability: capability,
+		})
+	}); err != nil {
+		// the new capability is unused, revoking it is best effort as err
+		// is what the caller needs to see
+		d.revokeCapability(ctx, capability)
+		return err
+	}
+	return d.revokeCapability(ctx, oldCapability)
+}
+
+func (d *driver) inspectMetadataExport(ctx context.Context) (*pfs.MetadataExportInfo, error) {
+	exportInfo := &pfs.MetadataExportInfo{}
+	if err := d.metadataExports.ReadOnly(ctx).Get(metadataExportKey, exportInfo); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil, fmt.Errorf("the cluster's metadata isn't exported")
+		}
+		return nil, err
+	}
+	exportInfo.Capability = ""
+	return exportInfo, nil
+}
+
+// exportMetadata exports the cluster's metadata now, with the caller's
+// credentials, rather than waiting for its next export. Only cluster admins
+// can export it, as it includes the metadata of every repo.
+func (d *driver) exportMetadata(ctx context.Context) (*pfs.Commit, error) {
+	whoAmI, err := d.pachClient.AuthAPIClient.WhoAmI(auth.In2Out(ctx), &auth.WhoAmIRequest{})
+	if err != nil && !auth.IsNotActivatedError(err) {
+		return nil, grpcutil.ScrubGRPC(err)
+	} else if err == nil && !whoAmI.IsAdmin {
+		return nil, fmt.Errorf("only cluster admins can export metadata")
+	}
+	exportInfo := &pfs.MetadataExportInfo{}
+	if err := d.metadataExports.ReadOnly(ctx).Get(metadataExportKey, exportInfo); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil, fmt.Errorf("the cluster's metadata isn't exported, see SetMetadataExport")
+		}
+		return nil, err
+	}
+	commit, err := d.exportMetadataTo(ctx, exportInfo.Export.Repo)
+	if recordErr := d.recordMetadataExport(ctx, commit, err); recordErr != nil && err == nil {
+		err = recordErr
+	}
+	return commit, err
+}
+
+// exportMetadataTo exports the metadata of every repo but repo to a new
+// commit on repo's metadataExportBranch, see MetadataExport, and returns the
+// commit. The metadata is read from etcd a repo at a time, not as of a single
+// moment, so a commit may be missing from an export that has its children if
+// it's made during the export.
+func (d *driver) exportMetadataTo(ctx context.Context, repo *pfs.Repo) (*pfs.Commit, error) {
+	repos := newParquetTable(
+		parquetStringColumn("repo"),
+		parquetTimestampColumn("created"),
+		parquetInt64Column("size_bytes"),
+		parquetStringColumn("description"),
+		parquetTimestampColumn("last_commit_time"),
+		parquetTimestampColumn("archived"),
+	)
+	commits := newParquetTable(
+		parquetStringColumn("repo"),
+		parquetStringColumn("commit"),
+		parquetOptionalStringColumn("parent_commit"),
+		parquetTimestampColumn("started"),
+		parquetTimestampColumn("finished"),
+		parquetInt64Column("size_bytes"),
+	)
+	branches := newParquetTable(
+		parquetStringColumn("repo"),
+		parquetStringColumn("branch"),
+		parquetStringColumn("head"),
+	)
+	provenance := newParquetTable(
+		parquetStringColumn("repo"),
+		parquetStringColumn("commit"),
+		parquetStringColumn("provenance_repo"),
+		parquetStringColumn("provenance_commit"),
+	)
+	repoInfos, err := d.listRepoInfos(ctx, nil, "", true, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, repoInfo := range repoInfos {
+		name := repoInfo.Repo.Name
+		if name == repo.Name {
+			continue
+		}
+		repos.append(name, timestampMillis(repoInfo.Created), int64(repoInfo.SizeBytes),
+			repoInfo.Description, timestampMillis(repoInfo.LastCommitTime), timestampMillis(repoInfo.Archived))
+		commitIter, err := d.commits(name).ReadOnly(ctx).List()
+		if err != nil {
+			return nil, err
+		}
+		for {
+			var commitID string
+			commitInfo := &pfs.CommitInfo{}
+			ok, err := commitIter.Next(&commitID, commitInfo)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				break
+			}
+			var parent interface{}
+			if commitInfo.ParentCommit != nil {
+				parent = commitInfo.ParentCommit.ID
+			}
+			commits.append(name, commitInfo.Commit.ID, parent, timestampMillis(commitInfo.Started),
+				timestampMillis(commitInfo.Finished), int64(commitInfo.SizeBytes))
+			for _, prov := range commitInfo.Provenance {
+				provenance.append(name, commitInfo.Commit.ID, prov.Repo.Name, prov.ID)
+			}
+		}
+		branchIter, err := d.branches(name).ReadOnly(ctx).List()
+		if err != nil {
+			return nil, err
+		}
+		for {
+			var branch string
+			head := &pfs.Commit{}
+			ok, err := branchIter.Next(&branch, head)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				break
+			}
+			branches.append(name, branch, head.ID)
+		}
+	}
+	tree := hashtree.NewHashTree()
+	for _, file := range []struct {
+		name  string
+		table *parquetTable
+	}{
+		{"repos.parquet", repos},
+		{"commits.parquet", commits},
+		{"branches.parquet", branches},
+		{"provenance.parquet", provenance},
+	} {
+		data, err := file.table.encode()
+		if err != nil {
+			return nil, err
+		}
+		object, size, err := d.pachClient.PutObject(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if err := tree.PutFile(file.name, []*pfs.Object{object}, size); err != nil {
+			return nil, err
+		}
+	}
+	finishedTree, err := tree.Finish()
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := d.serializeTree(finishedTree)
+	if err != nil {
+		return nil, err
+	}
+	treeRef, _, err := d.pachClient.PutObject(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return d.makeCommit(ctx, &pfs.Commit{Repo: repo}, metadataExportBranch, nil, treeRef, nil, nil)
+}
+
+// recordMetadataExport records the result of an export, which made commit
+// unless it failed with exportErr, in the metadata export's info. Failed
+// exports are retried at the next poll, so their time isn't recorded.
+func (d *driver) recordMetadataExport(ctx context.Context, commit *pfs.Commit, exportErr error) error {
+	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		exports := d.metadataExports.ReadWrite(stm)
+		exportInfo := &pfs.MetadataExportInfo{}
+		if err := exports.Get(metadataExportKey, exportInfo); err != nil {
+			if col.IsErrNotFound(err) {
+				return nil // the export was removed meanwhile
+			}
+			return err
+		}
+		exportInfo.LastError = ""
+		if exportErr != nil {
+			exportInfo.LastError = exportErr.Error()
+		} else {
+			exportInfo.LastExport = now()
+			exportInfo.LastCommit = commit
+		}
+		return exports.Put(metadataExportKey, exportInfo)
+	})
+	return err
+}
+
+// exportMetadataIfDue exports the cluster's metadata, with the credentials
+// of the admin who set the export, if its interval has passed since its last
+// export. A failed export is recorded in the export's info rather than
+// returned.
+func (d *driver) exportMetadataIfDue(ctx context.Context) error {
+	exportInfo := &pfs.MetadataExportInfo{}
+	if err := d.metadataExports.ReadOnly(ctx).Get(metadataExportKey, exportInfo); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	interval := defaultMetadataExportInterval
+	if exportInfo.Export.IntervalSeconds > 0 {
+		interval = time.Duration(exportInfo.Export.IntervalSeconds) * time.Second
+	}
+	if exportInfo.LastExport != nil {
+		lastExport, err := types.TimestampFromProto(exportInfo.LastExport)
+		if err != nil {
+			return err
+		}
+		if time.Since(lastExport) < interval {
+			return nil
+		}
+	}
+	userCtx := ctx
+	if exportInfo.Capability != "" {
+		userCtx = metadata.NewIncomingContext(ctx, metadata.Pairs(auth.ContextTokenKey, exportInfo.Capability))
+	}
+	commit, err := d.exportMetadataTo(userCtx, exportInfo.Export.Repo)
+	if err != nil {
+		log.Errorf("error exporting metadata to repo %s: %v", exportInfo.Export.Repo.Name, err)
+	}
+	return d.recordMetadataExport(ctx, commit, err)
+}
+
+// timestampMillis returns t in milliseconds since the epoch, or nil if it's
+// unset, as a value of a parquetTimestampColumn.
+func timestampMillis(t *types.Timestamp) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.Seconds*1000 + int64(t.Nanos)/1e6
+}
@@ -205,7 +205,11 @@ func (s *objBlockAPIServer) PutObject(server pfsclient.ObjectAPI_PutObjectServer
 	putObjectReader := &putObjectReader{
 		server: server,
 	}
-	object, err := s.putObject(server.Context(), putObjectReader, false)
+	// the storage class is read before the object's block is written
+	if err := putObjectReader.start(); err != nil {
+		return err
+	}
+	object, err := s.putObject(server.Context(), putObjectReader, false, putObjectReader.storageClass)
 	if err != nil {
 		return err
 	}
@@ -228,11 +232,19 @@ func (s *objBlockAPIServer) PutObjectSplit(server pfsclient.ObjectAPI_PutObjectS
 	defer func(start time.Time) { s.Log(nil, nil, retErr, time.Since(start)) }(time.Now())
 	defer drainObjectServer(server)
 	var objects []*pfsclient.Object
+	var storageClass string
 	for {
 		putObjectReader := &putObjectReader{
 			server: server,
 		}
-		object, err := s.putObject(server.Context(), putObjectReader, true)
+		if len(objects) == 0 {
+			// the storage class is read before the first block is written
+			if err := putObjectReader.start(); err != nil {
+				return err
+			}
+			storageClass = putObjectReader.storageClass
+		}
+		object, err := s.putObject(server.Context(), putObjectReader, true, storageClass)
 		if object != nil {
 			objects = append(objects, object)
 		}
@@ -246,14 +258,24 @@ func (s *objBlockAPIServer) PutObjectSplit(server pfsclient.ObjectAPI_PutObjectS
 	return server.SendAndClose(&pfsclient.Objects{objects})
 }
 
-func (s *objBlockAPIServer) putObject(ctx context.Context, dataReader io.Reader, split bool) (_ *pfsclient.Object, retErr error) {
+// putObject puts the data in dataReader, or its first ChunkSize bytes if
+// split is set, in a block of its own, in storageClass unless it's "". The
+// block is deleted if the object is already stored, so the object keeps the
+// storage class it has.
+func (s *objBlockAPIServer) putObject(ctx context.Context, dataReader io.Reader, split bool, storageClass string) (_ *pfsclient.Object, retErr error) {
 	hash := pfsclient.NewHash()
 	r := io.TeeReader(dataReader, hash)
 	block := &pfsclient.Block{Hash: uuid.NewWithoutDashes()}
 	var size int64
 	if err := func() (retErr error) {
 		blockPath := s.blockPath(block)
-		w, err := s.objClient.Writer(blockPath)
+		var w io.WriteCloser
+		var err error
+		if storageClass != "" {
+			w, err = s.objClient.WriterWithStorageClass(blockPath, storageClass)
+		} else {
+			w, err = s.objClient.Writer(blockPath)
+		}
 		if err != nil {
 			return err
 		}
@@ -451,7 +473,7 @@ func (s *objBlockAPIServer) ListObjects(request *pfsclient.ListObjectsRequest, l
 	defer func(start time.Time) { s.Log(request, nil, retErr, time.Since(start)) }(time.Now())
 
 	return s.objClient.Walk(s.objectDir(), func(key string) error {
-		return listObjectsServer.Send(&pfsclient.Object{filepath.Base(key)})
+		return listObjectsServer.Send(&pfsclient.Object{Hash: filepath.Base(key)})
 	})
 }
 
@@ -1020,10 +1042,34 @@ type putObjectReader struct {
 	server putObjectServer
 	buffer bytes.Buffer
 	tags   []*pfsclient.Tag
+	// storageClass is the storage class of the first request, see start
+	storageClass string
+	// err is the error that ended the stream, if start reached its end
+	err error
+}
+
+// start receives the first request of the stream, so that its storage class
+// is known before any data is read.
+func (r *putObjectReader) start() error {
+	request, err := r.server.Recv()
+	if err != nil {
+		if err == io.EOF {
+			r.err = err
+			return nil
+		}
+		return err
+	}
+	r.buffer.Write(request.Value)
+	r.tags = append(r.tags, request.Tags...)
+	r.storageClass = request.StorageClass
+	return nil
 }
 
 func (r *putObjectReader) Read(p []byte) (int, error) {
 	if r.buffer.Len() == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
 		request, err := r.server.Recv()
 		if err != nil {
 			return 0, err
@@ -0,0 +1,487 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/gogo/protobuf/types"
+)
+
+// Object ref counts track how many finished commits reference each object (a
+// commit references its tree and the objects of every file in it). They're
+// kept so that the objects a deleted repo was the last user of can be deleted
+// without waiting for a full garbage collection.
+//
+// The counts are updated outside of the transactions that finish and delete
+// commits, since a commit can reference more objects than etcd allows in a
+// transaction. Refs are added before a commit is recorded and removed after
+// it's deleted, so if pachd dies in between the counts are too high, which
+// only delays deletion until the next garbage collection.
+//
+// Objects aren't deleted as soon as they lose their last ref: objects are
+// uploaded before the writes that reference them are recorded, and PutFile
+// deduplicates content, so an object that no commit references may be about
+// to be. Instead they're marked as unreferenced, and swept once they've been
+// unreferenced for objectSweepGracePeriod, unless they've been referenced
+// again by then, by a finished commit, an open commit, a tag or a pipeline's
+// datums. Referencing an object from a finished commit removes its mark.
+//
+// Commits finished before the counts were introduced aren't counted, so
+// objects are only marked once RebuildObjectRefCounts has recomputed the
+// counts from every commit.
+
+const (
+	// objectRefCountBatchSize is the number of ref counts updated per etcd
+	// transaction (etcd allows 128 operations per transaction by default)
+	objectRefCountBatchSize = 100
+
+	// objectRefCountsRebuiltKey is set once the object ref counts have been
+	// rebuilt, after which they're trusted for deletes
+	objectRefCountsRebuiltKey = "objectRefCountsRebuilt"
+
+	objectSweepLockPath = "_object_sweep_lock"
+
+	// objectSweepInterval is how often unreferenced objects are swept
+	objectSweepInterval = 10 * time.Minute
+
+	// objectSweepGracePeriod is how long an object must have been
+	// unreferenced for before it's deleted, it bounds the time between an
+	// object being uploaded and the write that references it being recorded
+	objectSweepGracePeriod = time.Hour
+)
+
+// treeObjects returns the hashes of the objects referenced by a commit with
+// the given tree: the tree's object, its chunks if it's chunked, and the
+// objects of every file in it.
+func treeObjects(treeRef *pfs.Object, chunks []*pfs.Object, tree hashtree.HashTree) ([]string, error) {
+	hashes := make(map[string]bool)
+	if treeRef != nil {
+		hashes[treeRef.Hash] = true
+	}
+	for _, chunk := range chunks {
+		hashes[chunk.Hash] = true
+	}
+	if err := tree.Walk("/", func(path string, node *hashtree.NodeProto) error {
+		if node.FileNode != nil {
+			for _, object := range node.FileNode.Objects {
+				hashes[object.Hash] = true
+			}
+		}
+		return nil
+	}); err != nil && hashtree.Code(err) != hashtree.PathNotFound {
+		return nil, err
+	}
+	var result []string
+	for hash := range hashes {
+		result = append(result, hash)
+	}
+	// sorted so that concurrent updates touch keys in the same order
+	sort.Strings(result)
+	return result, nil
+}
+
+// commitObjects returns the hashes of the objects referenced by a finished
+// commit, see treeObjects.
+func (d *driver) commitObjects(ctx context.Context, commitInfo *pfs.CommitInfo) ([]string, error) {
+	if commitInfo.Finished == nil || commitInfo.Tree == nil {
+		return nil, nil
+	}
+	tree, err := d.getTreeForCommit(ctx, commitInfo.Commit)
+	if err != nil {
+		return nil, err
+	}
+	return treeObjects(commitInfo.Tree, hashtree.ChunkObjects(tree), tree)
+}
+
+// addObjectRefs adds a ref to each of the objects in hashes.
+func (d *driver) addObjectRefs(ctx context.Context, hashes []string) error {
+	for len(hashes) > 0 {
+		batch := hashes
+		if len(batch) > objectRefCountBatchSize {
+			batch = batch[:objectRefCountBatchSize]
+		}
+		hashes = hashes[len(batch):]
+		if _, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+			refCounts := d.objectRefCounts.ReadWriteInt(stm)
+			unreferenced := d.unreferenced.ReadWrite(stm)
+			for _, hash := range batch {
+				if err := refCounts.Increment(hash); err != nil {
+					if !col.IsErrNotFound(err) {
+						return err
+					}
+					if err := refCounts.Create(hash, 1); err != nil {
+						return err
+					}
+				}
+				if err := unreferenced.Delete(hash); err != nil && !col.IsErrNotFound(err) {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeObjectRefs removes refs[hash] refs from each object, and marks the
+// objects that are left without any as unreferenced, so that they're swept
+// once objectSweepGracePeriod has passed.
+func (d *driver) removeObjectRefs(ctx context.Context, refs map[string]int) error {
+	rebuilt, err := d.objectRefCountsRebuilt(ctx)
+	if err != nil {
+		return err
+	}
+	var hashes []string
+	for hash := range refs {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	for len(hashes) > 0 {
+		batch := hashes
+		if len(batch) > objectRefCountBatchSize/2 {
+			// each object takes up to two operations
+			batch = batch[:objectRefCountBatchSize/2]
+		}
+		hashes = hashes[len(batch):]
+		if _, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+			refCounts := d.objectRefCounts.ReadWriteInt(stm)
+			unreferenced := d.unreferenced.ReadWrite(stm)
+			for _, hash := range batch {
+				refCount, err := refCounts.Get(hash)
+				if err != nil {
+					// objects that were never counted are left to
+					// garbage collection
+					if col.IsErrNotFound(err) {
+						continue
+					}
+					return err
+				}
+				if refCount > refs[hash] {
+					if err := refCounts.DecrementBy(hash, refs[hash]); err != nil {
+						return err
+					}
+					continue
+				}
+				if err := refCounts.Delete(hash); err != nil {
+					return err
+				}
+				if rebuilt {
+					if err := unreferenced.Put(hash, &pfs.UnreferencedObject{
+						Object:       &pfs.Object{Hash: hash},
+						Unreferenced: now(),
+					}); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// objectRefCountsRebuilt returns true if the object ref counts have been
+// rebuilt, so that objects without refs can be deleted.
+func (d *driver) objectRefCountsRebuilt(ctx context.Context) (bool, error) {
+	resp, err := d.etcdClient.Get(ctx, path.Join(d.prefix, objectRefCountsRebuiltKey))
+	if err != nil {
+		return false, err
+	}
+	return len(resp.Kvs) > 0, nil
+}
+
+// sweepUnreferencedObjects deletes the objects that have been unreferenced
+// for objectSweepGracePeriod, unless they've been referenced since by an open
+// commit, a tag or a pipeline's datums. It's run every objectSweepInterval,
+// see startBackground.
+func (d *driver) sweepUnreferencedObjects(ctx context.Context) error {
+	rebuilt, err := d.objectRefCountsRebuilt(ctx)
+	if err != nil || !rebuilt {
+		return err
+	}
+	var due []*pfs.UnreferencedObject
+	iter, err := d.unreferenced.ReadOnly(ctx).List()
+	if err != nil {
+		return err
+	}
+	for {
+		var hash string
+		mark := &pfs.UnreferencedObject{}
+		ok, err := iter.Next(&hash, mark)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		unreferenced, err := types.TimestampFromProto(mark.Unreferenced)
+		if err != nil {
+			return err
+		}
+		if time.Since(unreferenced) >= objectSweepGracePeriod {
+			due = append(due, mark)
+		}
+	}
+	if len(due) == 0 {
+		return nil
+	}
+
+	// sweeping is batch work, which yields to interactive operations
+	ctx, done, err := d.schedule(ctx, pfs.Priority_BATCH)
+	if err != nil {
+		return err
+	}
+	defer done()
+	openRefs, err := d.openCommitObjects(ctx)
+	if err != nil {
+		return err
+	}
+	taggedRefs, err := d.taggedObjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	var objects []*pfs.Object
+	for len(due) > 0 {
+		batch := due
+		if len(batch) > objectRefCountBatchSize {
+			batch = batch[:objectRefCountBatchSize]
+		}
+		due = due[len(batch):]
+		var batchObjects []*pfs.Object
+		if _, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+			batchObjects = nil
+			refCounts := d.objectRefCounts.ReadWriteInt(stm)
+			unreferenced := d.unreferenced.ReadWrite(stm)
+			for _, mark := range batch {
+				hash := mark.Object.Hash
+				current := &pfs.UnreferencedObject{}
+				if err := unreferenced.Get(hash, current); err != nil {
+					if col.IsErrNotFound(err) {
+						continue // it's been referenced again
+					}
+					return err
+				}
+				if !current.Unreferenced.Equal(mark.Unreferenced) {
+					continue // it's lost its refs again since, and is due later
+				}
+				if _, err := refCounts.Get(hash); err == nil {
+					continue
+				} else if !col.IsErrNotFound(err) {
+					return err
+				}
+				if openRefs[hash] {
+					// it'll be counted, or marked again, once its commit is
+					// finished or deleted
+					continue
+				}
+				// tags and datums aren't counted, objects they reference are
+				// left to garbage collection
+				if err := unreferenced.Delete(hash); err != nil {
+					return err
+				}
+				if !taggedRefs[hash] {
+					batchObjects = append(batchObjects, mark.Object)
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		objects = append(objects, batchObjects...)
+	}
+	if len(objects) == 0 {
+		return nil
+	}
+	if _, err := d.pachClient.ObjectAPIClient.DeleteObjects(ctx, &pfs.DeleteObjectsRequest{
+		Objects: objects,
+	}); err != nil {
+		return fmt.Errorf("error deleting unreferenced objects: %v", grpcutil.ScrubGRPC(err))
+	}
+	return nil
+}
+
+// openCommitObjects returns the hashes of the objects referenced by the
+// writes to open commits, which are only counted once their commits are
+// finished.
+func (d *driver) openCommitObjects(ctx context.Context) (map[string]bool, error) {
+	refs := make(map[string]bool)
+	resp, err := d.etcdClient.Get(ctx, d.scratchPrefix(), etcd.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	kvs, err := d.expandScratchBatches(resp.Kvs, d.scratchPrefix())
+	if err != nil {
+		return nil, err
+	}
+	for _, kv := range kvs {
+		if string(kv.Value) == tombstone {
+			continue
+		}
+		records := &pfs.PutFileRecords{}
+		if err := records.Unmarshal(kv.Value); err != nil {
+			return nil, err
+		}
+		for _, record := range records.Records {
+			refs[record.ObjectHash] = true
+		}
+		if records.Header != nil {
+			refs[records.Header.ObjectHash] = true
+		}
+		if records.Footer != nil {
+			refs[records.Footer.ObjectHash] = true
+		}
+	}
+	return refs, nil
+}
+
+// taggedObjects returns the hashes of the objects that are tagged, and, for
+// tagged objects that are hash trees, such as the output of a pipeline's
+// datums, the objects they reference.
+func (d *driver) taggedObjects(ctx context.Context) (map[string]bool, error) {
+	tags, err := d.pachClient.ObjectAPIClient.ListTags(ctx, &pfs.ListTagsRequest{IncludeObject: true})
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	refs := make(map[string]bool)
+	for {
+		resp, err := tags.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, grpcutil.ScrubGRPC(err)
+		}
+		if resp.Object == nil || refs[resp.Object.Hash] {
+			continue
+		}
+		refs[resp.Object.Hash] = true
+		var buf bytes.Buffer
+		if err := d.pachClient.GetObject(resp.Object.Hash, &buf); err != nil {
+			return nil, err
+		}
+		tree, err := hashtree.DeserializeLazy(buf.Bytes(), d.getTreeChunk)
+		if err != nil {
+			continue // it isn't a hash tree
+		}
+		hashes, err := treeObjects(nil, hashtree.ChunkObjects(tree), tree)
+		if err != nil {
+			continue
+		}
+		for _, hash := range hashes {
+			refs[hash] = true
+		}
+	}
+	return refs, nil
+}
+
+// rebuildObjectRefCounts recomputes the object ref counts from every finished
+// commit. Like garbage collection, it must be run while no data is being
+// added or removed. It's run as an admin job.
+func (d *driver) rebuildObjectRefCounts(ctx context.Context) error {
+	whoAmI, err := d.pachClient.AuthAPIClient.WhoAmI(auth.In2Out(ctx), &auth.WhoAmIRequest{})
+	if err != nil && !auth.IsNotActivatedError(err) {
+		return grpcutil.ScrubGRPC(err)
+	} else if err == nil && !whoAmI.IsAdmin {
+		return fmt.Errorf("only cluster admins can rebuild object ref counts")
+	}
+	d.featureUsage.inc("rebuild_object_ref_counts")
+	return d.runAdminJob(ctx, "rebuild_object_ref_counts", d.rebuildObjectRefCountsJob)
+}
+
+func (d *driver) rebuildObjectRefCountsJob(ctx context.Context, job *adminJob) error {
+	var repoNames []string
+	repos, err := d.repos.ReadOnly(ctx).List()
+	if err != nil {
+		return err
+	}
+	for {
+		var repoName string
+		repoInfo := new(pfs.RepoInfo)
+		ok, err := repos.Next(&repoName, repoInfo)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		repoNames = append(repoNames, repoInfo.Repo.Name)
+	}
+
+	job.setPhase("counting the references of each repo's commits", int64(len(repoNames)))
+	refs := make(map[string]int)
+	for _, repoName := range repoNames {
+		commits, err := d.commits(repoName).ReadOnly(ctx).List()
+		if err != nil {
+			return err
+		}
+		for {
+			var commitID string
+			commitInfo := new(pfs.CommitInfo)
+			ok, err := commits.Next(&commitID, commitInfo)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				break
+			}
+			hashes, err := d.commitObjects(ctx, commitInfo)
+			if err != nil {
+				return err
+			}
+			for _, hash := range hashes {
+				refs[hash]++
+			}
+		}
+		job.progress(1)
+	}
+
+	if _, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		d.objectRefCounts.ReadWrite(stm).DeleteAll()
+		return nil
+	}); err != nil {
+		return err
+	}
+	var hashes []string
+	for hash := range refs {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	job.setPhase("writing the ref counts", int64(len(hashes)))
+	for len(hashes) > 0 {
+		batch := hashes
+		if len(batch) > objectRefCountBatchSize {
+			batch = batch[:objectRefCountBatchSize]
+		}
+		hashes = hashes[len(batch):]
+		if _, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+			refCounts := d.objectRefCounts.ReadWriteInt(stm)
+			for _, hash := range batch {
+				if err := refCounts.Create(hash, refs[hash]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		job.progress(int64(len(batch)))
+	}
+	_, err = d.etcdClient.Put(ctx, path.Join(d.prefix, objectRefCountsRebuiltKey), "true")
+	return err
+}
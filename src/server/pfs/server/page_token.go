@@ -0,0 +1,163 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+)
+
+// pageTokenVersion is the version of the page token format. Tokens of other
+// versions are rejected, so the format can change without old tokens being
+// misread.
+const pageTokenVersion = 3
+
+// pageToken is the position of a paginated listing, which is returned to
+// clients as an opaque string by encode. Every list API that's paginated
+// uses it, so that they all resume in the same way: the next page starts
+// after position, in the state that the listing is in when the page is read.
+type pageToken struct {
+	Version int `json:"v"`
+	// Position is the position of the last item of the page in the
+	// listing's order, e.g. its path. It's meaningful only to the listing
+	// that returned it.
+	Position string `json:"p"`
+	// Commit is the ID of the commit that the listing's first page was read
+	// from, which the pages after it are read from too, so that a listing of
+	// a branch doesn't mix commits if the branch moves. It's "" for listings
+	// that aren't of a commit's files.
+	Commit string `json:"c"`
+	// Revision is the etcd revision that the listing's first page was read
+	// at, which the pages after it are read at too, so that items created or
+	// deleted between pages don't shift the listing. It's 0 for listings
+	// that aren't read from etcd.
+	Revision int64 `json:"r"`
+	// Filter is the hash of the request parameters that select and order
+	// the listing's items, a token can only be used with the same ones, see
+	// pageFilter.
+	Filter string `json:"f"`
+}
+
+// pageFilter returns the hash of the request parameters that select and order
+// the items of a listing, which the listing's page tokens are tied to.
+func pageFilter(params ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(params, "\x00")))
+	return fmt.Sprintf("%x", sum[:8])
+}
+
+func (t *pageToken) encode() string {
+	t.Version = pageTokenVersion
+	data, err := json.Marshal(t)
+	if err != nil {
+		// a pageToken always marshals
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// parsePageToken parses a token returned by encode, checking that it was
+// returned by a listing with the parameters whose hash is filter.
+func parsePageToken(token string, filter string) (*pageToken, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page token %q", token)
+	}
+	t := &pageToken{}
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("invalid page token %q", token)
+	}
+	if t.Version != pageTokenVersion {
+		return nil, fmt.Errorf("unsupported page token version %d, restart the listing", t.Version)
+	}
+	if t.Filter != filter {
+		return nil, fmt.Errorf("page token %q is for a different listing, it must be used with the same parameters as the listing that returned it", token)
+	}
+	return t, nil
+}
+
+// pager tracks the pages of a listing: it parses the token of the page being
+// read, if there is one, and returns the token of the next page.
+type pager struct {
+	filter string
+	// after is the token of the previous page, nil on the first page
+	after *pageToken
+	// rev is the etcd revision that the page is read at, set by revision
+	rev int64
+}
+
+// newPager returns the pager of a listing whose parameters hash to filter,
+// reading the page after the one whose token is pageToken ("" for the first
+// page).
+func newPager(pageToken string, filter string) (*pager, error) {
+	p := &pager{filter: filter}
+	if pageToken != "" {
+		after, err := parsePageToken(pageToken, filter)
+		if err != nil {
+			return nil, err
+		}
+		p.after = after
+	}
+	return p, nil
+}
+
+// position returns the position of the last item of the previous page, ""
+// on the first page.
+func (p *pager) position() string {
+	if p.after == nil {
+		return ""
+	}
+	return p.after.Position
+}
+
+// commit returns the commit that the page is read from: on the first page
+// it's commit, with a branch name resolved to the branch's head, and on the
+// pages after it, the commit that the first page was read from.
+func (p *pager) commit(ctx context.Context, d *driver, commit *pfs.Commit) (*pfs.Commit, error) {
+	if p.after != nil && p.after.Commit != "" {
+		return &pfs.Commit{Repo: commit.Repo, ID: p.after.Commit}, nil
+	}
+	commitInfo, err := d.inspectCommit(ctx, commit)
+	if err != nil {
+		return nil, err
+	}
+	return commitInfo.Commit, nil
+}
+
+// revision returns the etcd revision that the page is read at: on the first
+// page it's the current revision, and on the pages after it, the revision
+// that the first page was read at. The next page's token keeps it.
+func (p *pager) revision(ctx context.Context, d *driver) (int64, error) {
+	if p.after != nil && p.after.Revision != 0 {
+		p.rev = p.after.Revision
+		return p.rev, nil
+	}
+	resp, err := d.etcdClient.Get(ctx, d.prefix, etcd.WithCountOnly())
+	if err != nil {
+		return 0, err
+	}
+	p.rev = resp.Header.Revision
+	return p.rev, nil
+}
+
+// checkCompacted returns the error to return for err, which was returned by
+// reading a page at the token's revision: if etcd has compacted the
+// revision away, the listing can't be resumed.
+func (p *pager) checkCompacted(err error) error {
+	if err == rpctypes.ErrCompacted && p.after != nil {
+		return fmt.Errorf("page token has expired, restart the listing")
+	}
+	return err
+}
+
+// next returns the token of the page after the one that ends at position,
+// which is read from the commit with ID commitID ("" if the listing isn't of
+// a commit's files), at the revision returned by revision, if it was called.
+func (p *pager) next(position string, commitID string) string {
+	return (&pageToken{Position: position, Commit: commitID, Revision: p.rev, Filter: p.filter}).encode()
+}
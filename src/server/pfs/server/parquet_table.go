@@ -0,0 +1,246 @@
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// The Parquet types, repetitions, converted types and encodings that
+// parquetTable writes, see parquet.thrift.
+const (
+	parquetInt64     int64 = 2
+	parquetByteArray int64 = 6
+
+	parquetRequired int64 = 0
+	parquetOptional int64 = 1
+
+	parquetUTF8            int64 = 0
+	parquetTimestampMillis int64 = 9
+	// parquetNoConvertedType marks a column whose values aren't converted
+	parquetNoConvertedType int64 = -1
+
+	parquetPlain int64 = 0
+	parquetRLE   int64 = 3
+
+	parquetDataPage     int64 = 0
+	parquetUncompressed int64 = 0
+)
+
+// The ids of the fields of Parquet's metadata that parquetTable writes, other
+// than the ones that splitting reads, see parquet.thrift.
+const (
+	fileMetaDataVersion   = 1
+	fileMetaDataSchema    = 2
+	fileMetaDataCreatedBy = 6
+
+	schemaElementType           = 1
+	schemaElementRepetitionType = 3
+	schemaElementName           = 4
+	schemaElementNumChildren    = 5
+	schemaElementConvertedType  = 6
+
+	rowGroupTotalByteSize = 2
+
+	columnMetaDataType                  = 1
+	columnMetaDataEncodings             = 2
+	columnMetaDataPathInSchema          = 3
+	columnMetaDataCodec                 = 4
+	columnMetaDataNumValues             = 5
+	columnMetaDataTotalUncompressedSize = 6
+
+	pageHeaderType                 = 1
+	pageHeaderUncompressedPageSize = 2
+	pageHeaderCompressedPageSize   = 3
+	pageHeaderDataPageHeader       = 5
+
+	dataPageHeaderNumValues               = 1
+	dataPageHeaderEncoding                = 2
+	dataPageHeaderDefinitionLevelEncoding = 3
+	dataPageHeaderRepetitionLevelEncoding = 4
+)
+
+// parquetRowGroupRows is the number of rows in each of the row groups that
+// parquetTable writes.
+const parquetRowGroupRows = 64 * 1024
+
+// parquetColumn is a column of a parquetTable. The values of a parquetInt64
+// column are int64s, and of a parquetByteArray column strings. Only optional
+// columns can have nil values, which are nulls.
+type parquetColumn struct {
+	name          string
+	typ           int64
+	convertedType int64
+	optional      bool
+}
+
+func parquetStringColumn(name string) parquetColumn {
+	return parquetColumn{name: name, typ: parquetByteArray, convertedType: parquetUTF8}
+}
+
+func parquetOptionalStringColumn(name string) parquetColumn {
+	return parquetColumn{name: name, typ: parquetByteArray, convertedType: parquetUTF8, optional: true}
+}
+
+func parquetInt64Column(name string) parquetColumn {
+	return parquetColumn{name: name, typ: parquetInt64, convertedType: parquetNoConvertedType}
+}
+
+// parquetTimestampColumn is an optional column of times, in milliseconds
+// since the epoch.
+func parquetTimestampColumn(name string) parquetColumn {
+	return parquetColumn{name: name, typ: parquetInt64, convertedType: parquetTimestampMillis, optional: true}
+}
+
+// parquetTable is a table that's encoded as a Parquet file, with a flat
+// schema. It's held in memory, and it's encoded with uncompressed, PLAIN
+// encoded data pages, one per column of each row group, so it's meant for
+// tables of modest size whose readers don't need it to be compact.
+type parquetTable struct {
+	columns []parquetColumn
+	rows    [][]interface{}
+}
+
+func newParquetTable(columns ...parquetColumn) *parquetTable {
+	return &parquetTable{columns: columns}
+}
+
+// append adds a row to t, whose values are in the order of t's columns.
+func (t *parquetTable) append(values ...interface{}) {
+	t.rows = append(t.rows, values)
+}
+
+// encode returns t as a Parquet file.
+func (t *parquetTable) encode() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.Write(parquetMagic)
+	var rowGroups []interface{}
+	for start := 0; start < len(t.rows); start += parquetRowGroupRows {
+		end := start + parquetRowGroupRows
+		if end > len(t.rows) {
+			end = len(t.rows)
+		}
+		rows := t.rows[start:end]
+		var columns []interface{}
+		var rowGroupSize int64
+		for i, column := range t.columns {
+			offset := int64(buf.Len())
+			page, err := column.page(rows, i)
+			if err != nil {
+				return nil, err
+			}
+			header := &thriftWriter{}
+			header.writeStruct(thriftStruct{
+				{id: pageHeaderType, typ: thriftI32, value: parquetDataPage},
+				{id: pageHeaderUncompressedPageSize, typ: thriftI32, value: int64(len(page))},
+				{id: pageHeaderCompressedPageSize, typ: thriftI32, value: int64(len(page))},
+				{id: pageHeaderDataPageHeader, typ: thriftStructType, value: thriftStruct{
+					{id: dataPageHeaderNumValues, typ: thriftI32, value: int64(len(rows))},
+					{id: dataPageHeaderEncoding, typ: thriftI32, value: parquetPlain},
+					{id: dataPageHeaderDefinitionLevelEncoding, typ: thriftI32, value: parquetRLE},
+					{id: dataPageHeaderRepetitionLevelEncoding, typ: thriftI32, value: parquetRLE},
+				}},
+			})
+			size := int64(header.Len() + len(page))
+			buf.Write(header.Bytes())
+			buf.Write(page)
+			rowGroupSize += size
+			columns = append(columns, thriftStruct{
+				{id: columnChunkFileOffset, typ: thriftI64, value: offset},
+				{id: columnChunkMetaData, typ: thriftStructType, value: thriftStruct{
+					{id: columnMetaDataType, typ: thriftI32, value: column.typ},
+					{id: columnMetaDataEncodings, typ: thriftList, value: &thriftListValue{
+						elemType: thriftI32,
+						elems:    []interface{}{parquetPlain, parquetRLE},
+					}},
+					{id: columnMetaDataPathInSchema, typ: thriftList, value: &thriftListValue{
+						elemType: thriftBinary,
+						elems:    []interface{}{[]byte(column.name)},
+					}},
+					{id: columnMetaDataCodec, typ: thriftI32, value: parquetUncompressed},
+					{id: columnMetaDataNumValues, typ: thriftI64, value: int64(len(rows))},
+					{id: columnMetaDataTotalUncompressedSize, typ: thriftI64, value: size},
+					{id: columnMetaDataTotalCompressedSize, typ: thriftI64, value: size},
+					{id: columnMetaDataDataPageOffset, typ: thriftI64, value: offset},
+				}},
+			})
+		}
+		rowGroups = append(rowGroups, thriftStruct{
+			{id: rowGroupColumns, typ: thriftList, value: &thriftListValue{elemType: thriftStructType, elems: columns}},
+			{id: rowGroupTotalByteSize, typ: thriftI64, value: rowGroupSize},
+			{id: rowGroupNumRows, typ: thriftI64, value: int64(len(rows))},
+		})
+	}
+	schema := []interface{}{thriftStruct{
+		{id: schemaElementName, typ: thriftBinary, value: []byte("schema")},
+		{id: schemaElementNumChildren, typ: thriftI32, value: int64(len(t.columns))},
+	}}
+	for _, column := range t.columns {
+		repetition := parquetRequired
+		if column.optional {
+			repetition = parquetOptional
+		}
+		element := thriftStruct{
+			{id: schemaElementType, typ: thriftI32, value: column.typ},
+			{id: schemaElementRepetitionType, typ: thriftI32, value: repetition},
+			{id: schemaElementName, typ: thriftBinary, value: []byte(column.name)},
+		}
+		if column.convertedType != parquetNoConvertedType {
+			element = element.with(schemaElementConvertedType, thriftI32, column.convertedType)
+		}
+		schema = append(schema, element)
+	}
+	footer := &thriftWriter{}
+	footer.writeStruct(thriftStruct{
+		{id: fileMetaDataVersion, typ: thriftI32, value: int64(1)},
+		{id: fileMetaDataSchema, typ: thriftList, value: &thriftListValue{elemType: thriftStructType, elems: schema}},
+		{id: fileMetaDataNumRows, typ: thriftI64, value: int64(len(t.rows))},
+		{id: fileMetaDataRowGroups, typ: thriftList, value: &thriftListValue{elemType: thriftStructType, elems: rowGroups}},
+		{id: fileMetaDataCreatedBy, typ: thriftBinary, value: []byte("pachyderm")},
+	})
+	buf.Write(footer.Bytes())
+	if err := binary.Write(buf, binary.LittleEndian, uint32(footer.Len())); err != nil {
+		return nil, err
+	}
+	buf.Write(parquetMagic)
+	return buf.Bytes(), nil
+}
+
+// page returns the data page of the values of rows in column i. An optional
+// column's definition levels, which say which of its values aren't null, are
+// written as a single bit-packed run, and only its values that aren't null
+// are written after them.
+func (c parquetColumn) page(rows [][]interface{}, i int) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if c.optional {
+		levels := make([]byte, (len(rows)+7)/8)
+		for j, row := range rows {
+			if row[i] != nil {
+				levels[j/8] |= 1 << uint(j%8)
+			}
+		}
+		run := &thriftWriter{}
+		// the header of a bit-packed run is its number of groups of 8
+		// values, shifted left, with the low bit set
+		run.writeUvarint(uint64(len(levels))<<1 | 1)
+		run.Write(levels)
+		if err := binary.Write(buf, binary.LittleEndian, uint32(run.Len())); err != nil {
+			return nil, err
+		}
+		buf.Write(run.Bytes())
+	}
+	for _, row := range rows {
+		switch value := row[i].(type) {
+		case nil:
+		case int64:
+			if err := binary.Write(buf, binary.LittleEndian, value); err != nil {
+				return nil, err
+			}
+		case string:
+			if err := binary.Write(buf, binary.LittleEndian, uint32(len(value))); err != nil {
+				return nil, err
+			}
+			buf.WriteString(value)
+		}
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,400 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+
+	"github.com/gogo/protobuf/types"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	pathExpirationLockPath = "_path_expiration_lock"
+
+	// pathExpirationPollInterval is how often paths are checked for expiry
+	pathExpirationPollInterval = 10 * time.Second
+)
+
+// pathExpirationKey is the key of the expiration of file, whose commit is a
+// branch. Paths contain slashes, which keys can't, so it's a digest.
+func pathExpirationKey(file *pfs.File) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(path.Join(file.Commit.Repo.Name, file.Commit.ID, path.Clean("/"+file.Path)))))
+}
+
+// expirationOwnerKey is the key of owner's PathExpirationOwner. Usernames
+// can contain slashes too, so it's a digest.
+func expirationOwnerKey(owner string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(owner)))
+}
+
+// errNoCapability is returned by the transaction of expirePath when the
+// owner of the path doesn't have a capability that it can be deleted with.
+var errNoCapability = errors.New("no capability for the owner")
+
+// expiringFile returns the file that expires if file is put with a ttl,
+// which is file in the branch that file.Commit is, or is the head of. It's
+// called before the file is put, as putFile resolves file.Commit.
+func (d *driver) expiringFile(ctx context.Context, file *pfs.File, ttl int64) (*pfs.File, error) {
+	if ttl < 0 {
+		return nil, fmt.Errorf("the ttl of a file can't be negative")
+	}
+	expiring, err := d.fileInBranch(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	if expiring == nil {
+		return nil, fmt.Errorf("files can only be put with a ttl in a branch, or in the head of one, and %s is neither", file.Commit.ID)
+	}
+	return expiring, nil
+}
+
+// fileInBranch returns file in the branch that file.Commit is, or is the
+// head of, or nil if it's neither.
+func (d *driver) fileInBranch(ctx context.Context, file *pfs.File) (*pfs.File, error) {
+	branch, err := d.branchName(ctx, file.Commit)
+	if err != nil {
+		return nil, err
+	}
+	if branch == "" {
+		iter, err := d.branches(file.Commit.Repo.Name).ReadOnly(ctx).List()
+		if err != nil {
+			return nil, err
+		}
+		for {
+			var name string
+			head := &pfs.Commit{}
+			ok, err := iter.Next(&name, head)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				break
+			}
+			if head.ID == file.Commit.ID {
+				branch = name
+				break
+			}
+		}
+	}
+	if branch == "" {
+		return nil, nil
+	}
+	return client.NewFile(file.Commit.Repo.Name, branch, path.Clean("/"+file.Path)), nil
+}
+
+// expirePath sets file, see expiringFile, to expire ttl seconds from now.
+// If auth is active it's deleted with the capability of the caller's
+// PathExpirationOwner, which is only minted if they don't have one yet.
+func (d *driver) expirePath(ctx context.Context, file *pfs.File, ttl int64) error {
+	expires, err := types.TimestampProto(time.Now().Add(time.Duration(ttl) * time.Second))
+	if err != nil {
+		return err
+	}
+	expiration := &pfs.PathExpiration{
+		File:    file,
+		Expires: expires,
+	}
+	whoAmI, err := d.pachClient.AuthAPIClient.WhoAmI(auth.In2Out(ctx), &auth.WhoAmIRequest{})
+	if err != nil && !auth.IsNotActivatedError(err) {
+		return grpcutil.ScrubGRPC(err)
+	} else if err == nil {
+		expiration.Owner = whoAmI.Username
+	}
+	d.featureUsage.inc("path_ttl")
+	// the caller usually has other paths that expire, so their capability
+	// is only minted if the transaction finds that they don't
+	var capability string
+	for {
+		var capabilityUsed bool
+		var released []string
+		_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+			capabilityUsed, released = false, nil
+			expirations := d.pathExpirations.ReadWrite(stm)
+			owners := d.expirationOwners.ReadWrite(stm)
+			key := pathExpirationKey(file)
+			old := &pfs.PathExpiration{}
+			if err := expirations.Get(key, old); err != nil {
+				if !col.IsErrNotFound(err) {
+					return err
+				}
+				old = nil
+			}
+			if old == nil || old.Owner != expiration.Owner {
+				if expiration.Owner != "" {
+					owner := &pfs.PathExpirationOwner{}
+					if err := owners.Get(expirationOwnerKey(expiration.Owner), owner); err != nil {
+						if !col.IsErrNotFound(err) {
+							return err
+						}
+						if capability == "" {
+							return errNoCapability
+						}
+						owner = &pfs.PathExpirationOwner{Owner: expiration.Owner, Capability: capability}
+						capabilityUsed = true
+					}
+					owner.Paths++
+					if err := owners.Put(expirationOwnerKey(expiration.Owner), owner); err != nil {
+						return err
+					}
+				}
+				if old != nil {
+					oldCapability, err := releaseExpirationOwner(owners, old.Owner)
+					if err != nil {
+						return err
+					}
+					released = append(released, oldCapability)
+				}
+			}
+			return expirations.Put(key, expiration)
+		})
+		if err == errNoCapability {
+			resp, err := d.pachClient.AuthAPIClient.GetCapability(auth.In2Out(ctx), &auth.GetCapabilityRequest{})
+			if err != nil {
+				return grpcutil.ScrubGRPC(err)
+			}
+			capability = resp.Capability
+			continue
+		}
+		if !capabilityUsed {
+			// the capability is unused, revoking it is best effort
+			d.revokeCapability(ctx, capability)
+		}
+		if err != nil {
+			return err
+		}
+		return d.revokeCapabilities(ctx, released)
+	}
+}
+
+// clearPathExpiration removes the expiration of file, if it has one, as it's
+// been overwritten without a ttl.
+func (d *driver) clearPathExpiration(ctx context.Context, file *pfs.File) error {
+	// most repos have no paths that expire, which is cheap to check
+	count, err := d.pathExpirations.ReadOnly(ctx).Count()
+	if err != nil || count == 0 {
+		return err
+	}
+	expiring, err := d.fileInBranch(ctx, file)
+	if err != nil || expiring == nil {
+		return err
+	}
+	var released []string
+	if _, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		released = nil
+		expirations := d.pathExpirations.ReadWrite(stm)
+		key := pathExpirationKey(expiring)
+		expiration := &pfs.PathExpiration{}
+		if err := expirations.Get(key, expiration); err != nil {
+			if col.IsErrNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		if err := expirations.Delete(key); err != nil {
+			return err
+		}
+		capability, err := releaseExpirationOwner(d.expirationOwners.ReadWrite(stm), expiration.Owner)
+		if err != nil {
+			return err
+		}
+		released = append(released, capability)
+		return nil
+	}); err != nil {
+		return err
+	}
+	return d.revokeCapabilities(ctx, released)
+}
+
+// overwritesFile returns true if a put with mode and overwriteIndex replaces
+// the whole file, rather than adding to it.
+func overwritesFile(mode pfs.PutFileMode, overwriteIndex *pfs.OverwriteIndex) bool {
+	return mode != pfs.PutFileMode_APPEND || (overwriteIndex != nil && overwriteIndex.Index == 0)
+}
+
+// releaseExpirationOwner removes one of owner's paths from their
+// PathExpirationOwner, deleting it if it was their last. It returns the
+// capability to revoke once the transaction succeeds, if it was.
+func releaseExpirationOwner(owners col.ReadWriteCollection, owner string) (string, error) {
+	if owner == "" {
+		return "", nil
+	}
+	key := expirationOwnerKey(owner)
+	ownerInfo := &pfs.PathExpirationOwner{}
+	if err := owners.Get(key, ownerInfo); err != nil {
+		if col.IsErrNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	ownerInfo.Paths--
+	if ownerInfo.Paths > 0 {
+		return "", owners.Put(key, ownerInfo)
+	}
+	return ownerInfo.Capability, owners.Delete(key)
+}
+
+// revokeCapabilities revokes capabilities, see revokeCapability.
+func (d *driver) revokeCapabilities(ctx context.Context, capabilities []string) error {
+	for _, capability := range capabilities {
+		if err := d.revokeCapability(ctx, capability); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pathExpired(expiration *pfs.PathExpiration) (bool, error) {
+	expires, err := types.TimestampFromProto(expiration.Expires)
+	if err != nil {
+		return false, err
+	}
+	return !time.Now().Before(expires), nil
+}
+
+// deleteExpiredPaths makes one pass over the path expirations, deleting the
+// expired paths of each branch in one commit. Branches whose paths can't be
+// deleted, e.g. because the head of the branch is open, are tried again on
+// the next pass.
+func (d *driver) deleteExpiredPaths(ctx context.Context) error {
+	iter, err := d.pathExpirations.ReadOnly(ctx).List()
+	if err != nil {
+		return err
+	}
+	expired := make(map[string][]*pfs.PathExpiration)
+	for {
+		var key string
+		expiration := &pfs.PathExpiration{}
+		ok, err := iter.Next(&key, expiration)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		if ok, err := pathExpired(expiration); err != nil {
+			return err
+		} else if ok {
+			// each owner's paths are deleted with their credentials
+			branch := path.Join(expiration.File.Commit.Repo.Name, expiration.File.Commit.ID, expirationOwnerKey(expiration.Owner))
+			expired[branch] = append(expired[branch], expiration)
+		}
+	}
+	var branches []string
+	for branch := range expired {
+		branches = append(branches, branch)
+	}
+	sort.Strings(branches)
+	for _, branch := range branches {
+		if err := d.deleteExpiredBranchPaths(ctx, expired[branch]); err != nil {
+			log.Errorf("error deleting expired paths from %s: %v", path.Dir(branch), err)
+		}
+	}
+	return nil
+}
+
+// deleteExpiredBranchPaths deletes the paths of expirations, which all
+// expire from the same branch and have the same owner, in a new commit on
+// the branch. It's made with the owner's credentials. The expirations are
+// then removed, except for those that were moved in the meantime.
+func (d *driver) deleteExpiredBranchPaths(ctx context.Context, expirations []*pfs.PathExpiration) error {
+	sort.Slice(expirations, func(i, j int) bool {
+		return expirations[i].File.Path < expirations[j].File.Path
+	})
+	commit := expirations[0].File.Commit
+	userCtx := ctx
+	if owner := expirations[0].Owner; owner != "" {
+		ownerInfo := &pfs.PathExpirationOwner{}
+		if err := d.expirationOwners.ReadOnly(ctx).Get(expirationOwnerKey(owner), ownerInfo); err != nil {
+			return err
+		}
+		userCtx = metadata.NewIncomingContext(ctx, metadata.Pairs(auth.ContextTokenKey, ownerInfo.Capability))
+	}
+
+	// the branch, or its repo, may have been deleted since, in which case
+	// there's nothing left to delete
+	if err := d.branches(commit.Repo.Name).ReadOnly(ctx).Get(commit.ID, &pfs.Commit{}); err != nil && !col.IsErrNotFound(err) {
+		return err
+	} else if err == nil {
+		tree, err := d.getTreeForFile(userCtx, client.NewFile(commit.Repo.Name, commit.ID, ""))
+		if err != nil {
+			return err
+		}
+		var paths []string
+		for _, expiration := range expirations {
+			if _, err := tree.Get(expiration.File.Path); err == nil {
+				paths = append(paths, expiration.File.Path)
+			}
+		}
+		if len(paths) > 0 {
+			if err := d.deletePaths(userCtx, commit, paths); err != nil {
+				return err
+			}
+		}
+	}
+
+	var released []string
+	if _, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		released = nil
+		pathExpirations := d.pathExpirations.ReadWrite(stm)
+		owners := d.expirationOwners.ReadWrite(stm)
+		for _, expiration := range expirations {
+			key := pathExpirationKey(expiration.File)
+			current := &pfs.PathExpiration{}
+			if err := pathExpirations.Get(key, current); err != nil {
+				if col.IsErrNotFound(err) {
+					continue
+				}
+				return err
+			}
+			if !current.Expires.Equal(expiration.Expires) {
+				continue
+			}
+			if err := pathExpirations.Delete(key); err != nil {
+				return err
+			}
+			capability, err := releaseExpirationOwner(owners, current.Owner)
+			if err != nil {
+				return err
+			}
+			released = append(released, capability)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return d.revokeCapabilities(ctx, released)
+}
+
+// deletePaths deletes paths from branch, whose commit ID is the name of a
+// branch, in a new commit.
+func (d *driver) deletePaths(ctx context.Context, branch *pfs.Commit, paths []string) (retErr error) {
+	commit, err := d.startCommit(ctx, client.NewCommit(branch.Repo.Name, ""), branch.ID, nil, nil)
+	if err != nil {
+		return err
+	}
+	// the commit is finished even if a path couldn't be deleted, so that it
+	// doesn't block the branch
+	defer func() {
+		if err := d.finishCommit(ctx, commit, nil, false); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	for _, p := range paths {
+		if err := d.deleteFile(ctx, client.NewFile(commit.Repo.Name, commit.ID, p)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
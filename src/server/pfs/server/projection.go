@@ -0,0 +1,63 @@
+package server
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+)
+
+// commitInfoFields maps the names of CommitInfo's fields in pfs.proto to
+// their indexes in the generated struct.
+var commitInfoFields = protoFieldIndexes(reflect.TypeOf(pfs.CommitInfo{}))
+
+func protoFieldIndexes(t reflect.Type) map[string]int {
+	result := make(map[string]int)
+	for i := 0; i < t.NumField(); i++ {
+		for _, part := range strings.Split(t.Field(i).Tag.Get("protobuf"), ",") {
+			if strings.HasPrefix(part, "name=") {
+				result[strings.TrimPrefix(part, "name=")] = i
+			}
+		}
+	}
+	return result
+}
+
+// commitInfoProjection returns a function that projects a CommitInfo to
+// fields, if any are given, less omitFields, see ListCommitRequest.fields.
+// The commit is always kept. It returns nil if nothing is projected.
+func commitInfoProjection(fields []string, omitFields []string) (func(*pfs.CommitInfo) *pfs.CommitInfo, error) {
+	if len(fields) == 0 && len(omitFields) == 0 {
+		return nil, nil
+	}
+	keep := make(map[int]bool)
+	if len(fields) == 0 {
+		for _, i := range commitInfoFields {
+			keep[i] = true
+		}
+	}
+	for _, field := range fields {
+		i, ok := commitInfoFields[field]
+		if !ok {
+			return nil, fmt.Errorf("CommitInfo has no field %q", field)
+		}
+		keep[i] = true
+	}
+	for _, field := range omitFields {
+		i, ok := commitInfoFields[field]
+		if !ok {
+			return nil, fmt.Errorf("CommitInfo has no field %q", field)
+		}
+		delete(keep, i)
+	}
+	keep[commitInfoFields["commit"]] = true
+	return func(commitInfo *pfs.CommitInfo) *pfs.CommitInfo {
+		projected := &pfs.CommitInfo{}
+		from, to := reflect.ValueOf(commitInfo).Elem(), reflect.ValueOf(projected).Elem()
+		for i := range keep {
+			to.Field(i).Set(from.Field(i))
+		}
+		return projected
+	}, nil
+}
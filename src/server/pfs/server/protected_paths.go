@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+)
+
+// errProtectedPathChanged stops the diff of checkTreeIsWritable at the first
+// change under a protected path.
+var errProtectedPathChanged = errors.New("protected path changed")
+
+// normalizeProtectedPath returns p, a protected path prefix that may end in
+// "/**", as a clean absolute path without the "/**".
+func normalizeProtectedPath(p string) (string, error) {
+	trimmed := strings.TrimSuffix(strings.TrimSuffix(p, "**"), "/")
+	if strings.ContainsAny(trimmed, "*?[\\") {
+		return "", fmt.Errorf("protected path \"%s\" must be a path prefix, optionally ending in \"/**\"", p)
+	}
+	return path.Clean("/" + trimmed), nil
+}
+
+// isUnder returns true if p is prefix, or is in the directory prefix.
+func isUnder(p string, prefix string) bool {
+	return prefix == "/" || p == prefix || strings.HasPrefix(p, prefix+"/")
+}
+
+func (d *driver) setProtectedPaths(ctx context.Context, repo *pfs.Repo, paths []string) error {
+	if err := d.checkIsAuthorized(ctx, repo, auth.Scope_OWNER); err != nil {
+		return err
+	}
+	var protectedPaths []string
+	for _, p := range paths {
+		protectedPath, err := normalizeProtectedPath(p)
+		if err != nil {
+			return err
+		}
+		protectedPaths = append(protectedPaths, protectedPath)
+	}
+	d.featureUsage.inc("protected_paths")
+	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		repos := d.repos.ReadWrite(stm)
+		repoInfo := new(pfs.RepoInfo)
+		if err := repos.Get(repo.Name, repoInfo); err != nil {
+			return err
+		}
+		repoInfo.ProtectedPaths = protectedPaths
+		return repos.Put(repo.Name, repoInfo)
+	})
+	return err
+}
+
+// checkPathIsWritable returns an error if file is under one of its repo's
+// protected paths and the caller isn't an owner of the repo, or if the repo
+// is archived. If deletes is set, the write may delete what's under
+// file.Path, so file also can't be an ancestor of a protected path.
+func (d *driver) checkPathIsWritable(ctx context.Context, file *pfs.File, deletes bool) error {
+	p := path.Clean("/" + file.Path)
+	return d.checkProtectedPaths(ctx, file.Commit.Repo, func(protectedPath string) bool {
+		return isUnder(p, protectedPath) || (deletes && isUnder(protectedPath, p))
+	}, p)
+}
+
+// checkGlobIsDeletable is checkPathIsWritable for a delete of the files that
+// file.Path, a glob pattern, matches. The pattern is matched when the delete
+// is applied, so it's checked conservatively, on the part of the pattern
+// before its first wildcard.
+func (d *driver) checkGlobIsDeletable(ctx context.Context, file *pfs.File) error {
+	p := path.Clean("/" + file.Path)
+	if i := strings.IndexAny(p, "*?[\\"); i >= 0 {
+		p = p[:i]
+	}
+	return d.checkProtectedPaths(ctx, file.Commit.Repo, func(protectedPath string) bool {
+		return strings.HasPrefix(p, protectedPath) || strings.HasPrefix(protectedPath, p)
+	}, file.Path)
+}
+
+// checkProtectedPaths returns an error if any of repo's protected paths are
+// affected by a write to p and the caller isn't an owner of repo, or if repo
+// is archived, which no write is allowed to.
+func (d *driver) checkProtectedPaths(ctx context.Context, repo *pfs.Repo, affected func(string) bool, p string) error {
+	repoInfo := new(pfs.RepoInfo)
+	if err := d.repos.ReadOnly(ctx).Get(repo.Name, repoInfo); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil // the write fails later, with a clearer error
+		}
+		return err
+	}
+	if repoInfo.Archived != nil {
+		return pfsserver.ErrRepoArchived{Repo: repo}
+	}
+	for _, protectedPath := range repoInfo.ProtectedPaths {
+		if !affected(protectedPath) {
+			continue
+		}
+		if err := d.checkIsAuthorized(ctx, repo, auth.Scope_OWNER); err != nil {
+			if auth.IsNotAuthorizedError(err) {
+				// the message keeps its prefix, so that it's still recognized
+				return fmt.Errorf("%v, as \"%s\" is under its protected path \"%s\"", err, p, protectedPath)
+			}
+			return err
+		}
+		return nil
+	}
+	return nil
+}
+
+// checkTreeIsWritable returns an error if tree differs from parentTree under
+// any of repoInfo's protected paths and the caller isn't an owner of the repo.
+// It's checked for commits whose content isn't written by the calls that
+// check checkPathIsWritable: commits made from a tree, such as merges, and
+// commits started from a base.
+func (d *driver) checkTreeIsWritable(ctx context.Context, repoInfo *pfs.RepoInfo, tree hashtree.HashTree, parentTree hashtree.HashTree) error {
+	for _, protectedPath := range repoInfo.ProtectedPaths {
+		if err := tree.Diff(parentTree, protectedPath, protectedPath, -1, func(string, *hashtree.NodeProto, bool) error {
+			return errProtectedPathChanged
+		}); err == nil {
+			continue
+		} else if err != errProtectedPathChanged {
+			return err
+		}
+		if err := d.checkIsAuthorized(ctx, repoInfo.Repo, auth.Scope_OWNER); err != nil {
+			if auth.IsNotAuthorizedError(err) {
+				// the message keeps its prefix, so that it's still recognized
+				return fmt.Errorf("%v, as the commit changes its protected path \"%s\"", err, protectedPath)
+			}
+			return err
+		}
+		return nil
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+)
+
+// checkProvenance returns an ErrProvenanceCycle if giving repo the provenance
+// provenance would put it in its own provenance, and an ErrProvenanceTooDeep
+// if it would make the longest chain of provenance leading to repo longer
+// than maxDepth. getProvenance returns the provenance of the other repos.
+func checkProvenance(repo string, provenance []*pfs.Repo, maxDepth int64, getProvenance func(repo string) ([]*pfs.Repo, error)) error {
+	depths := make(map[string]int64)
+	// visiting holds the repos whose depth is being computed, reaching one
+	// of them again means there's a cycle
+	visiting := map[string]bool{repo: true}
+	var depth func(repo string) (int64, error)
+	depth = func(repo string) (int64, error) {
+		if visiting[repo] {
+			return 0, pfsserver.ErrProvenanceCycle{&pfs.Repo{repo}}
+		}
+		if d, ok := depths[repo]; ok {
+			return d, nil
+		}
+		visiting[repo] = true
+		defer delete(visiting, repo)
+		provenance, err := getProvenance(repo)
+		if err != nil {
+			return 0, err
+		}
+		var result int64
+		for _, prov := range provenance {
+			d, err := depth(prov.Name)
+			if err != nil {
+				return 0, err
+			}
+			if d+1 > result {
+				result = d + 1
+			}
+		}
+		depths[repo] = result
+		return result, nil
+	}
+
+	var result int64
+	for _, prov := range provenance {
+		d, err := depth(prov.Name)
+		if err != nil {
+			return err
+		}
+		if d+1 > result {
+			result = d + 1
+		}
+	}
+	if maxDepth > 0 && result > maxDepth {
+		return pfsserver.ErrProvenanceTooDeep{
+			Repo:  &pfs.Repo{repo},
+			Depth: result,
+			Max:   maxDepth,
+		}
+	}
+	return nil
+}
+
+// repoProvenance returns a function that gets the provenance of a repo from
+// repos, for checkProvenance.
+func repoProvenance(repos col.ReadWriteCollection) func(repo string) ([]*pfs.Repo, error) {
+	return func(repo string) ([]*pfs.Repo, error) {
+		repoInfo := new(pfs.RepoInfo)
+		if err := repos.Get(repo, repoInfo); err != nil {
+			return nil, err
+		}
+		return repoInfo.Provenance, nil
+	}
+}
+
+// immediateProvenance returns the provenance that repoInfo was created or
+// last updated with. Repos written before it was stored only have their full
+// provenance, which has the same full provenance as their immediate one.
+func immediateProvenance(repoInfo *pfs.RepoInfo) []*pfs.Repo {
+	if len(repoInfo.ImmediateProvenance) > 0 {
+		return repoInfo.ImmediateProvenance
+	}
+	return repoInfo.Provenance
+}
+
+// provenanceGraph derives the full provenance of repos from their immediate
+// provenance.
+type provenanceGraph struct {
+	repos col.ReadWriteCollection
+	// immediate holds the immediate provenance of the repos whose full
+	// provenance is derived, any other repo's full provenance is read from
+	// repos as it is
+	immediate map[string][]*pfs.Repo
+	full      map[string][]*pfs.Repo
+	visiting  map[string]bool
+}
+
+func newProvenanceGraph(repos col.ReadWriteCollection) *provenanceGraph {
+	return &provenanceGraph{
+		repos:     repos,
+		immediate: make(map[string][]*pfs.Repo),
+		full:      make(map[string][]*pfs.Repo),
+		visiting:  make(map[string]bool),
+	}
+}
+
+// fullProvenance returns the full provenance of repo, sorted by name.
+func (g *provenanceGraph) fullProvenance(repo string) ([]*pfs.Repo, error) {
+	if full, ok := g.full[repo]; ok {
+		return full, nil
+	}
+	immediate, ok := g.immediate[repo]
+	if !ok {
+		repoInfo := new(pfs.RepoInfo)
+		if err := g.repos.Get(repo, repoInfo); err != nil {
+			return nil, err
+		}
+		g.full[repo] = repoInfo.Provenance
+		return repoInfo.Provenance, nil
+	}
+	if g.visiting[repo] {
+		return nil, pfsserver.ErrProvenanceCycle{&pfs.Repo{repo}}
+	}
+	g.visiting[repo] = true
+	defer delete(g.visiting, repo)
+	names := make(map[string]bool)
+	for _, prov := range immediate {
+		names[prov.Name] = true
+		provFull, err := g.fullProvenance(prov.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, prov := range provFull {
+			names[prov.Name] = true
+		}
+	}
+	if names[repo] {
+		return nil, pfsserver.ErrProvenanceCycle{&pfs.Repo{repo}}
+	}
+	var full []*pfs.Repo
+	for name := range names {
+		full = append(full, &pfs.Repo{name})
+	}
+	sort.Slice(full, func(i, j int) bool { return full[i].Name < full[j].Name })
+	g.full[repo] = full
+	return full, nil
+}
+
+// setProvenance sets the immediate provenance of repo to provenance, and
+// then re-derives the full provenance of repo and of downstream, which must
+// hold every repo that has repo in its full provenance. The ref count of
+// each repo that's added to or removed from a full provenance is moved with
+// it. This is the only place that full provenance and repo ref counts are
+// changed, other than deleteRepo and rebuildProvenance.
+func (d *driver) setProvenance(stm col.STM, repo string, provenance []*pfs.Repo, downstream []string) error {
+	repos := d.repos.ReadWrite(stm)
+	repoRefCounts := d.repoRefCounts.ReadWriteInt(stm)
+	g := newProvenanceGraph(repos)
+	g.immediate[repo] = provenance
+	repoInfos := make(map[string]*pfs.RepoInfo)
+	for _, name := range append([]string{repo}, downstream...) {
+		repoInfo := new(pfs.RepoInfo)
+		if err := repos.Get(name, repoInfo); err != nil {
+			return err
+		}
+		repoInfos[name] = repoInfo
+		if name != repo {
+			g.immediate[name] = immediateProvenance(repoInfo)
+		}
+	}
+
+	for name, repoInfo := range repoInfos {
+		full, err := g.fullProvenance(name)
+		if err != nil {
+			return err
+		}
+		oldFull := make(map[string]bool)
+		for _, prov := range repoInfo.Provenance {
+			oldFull[prov.Name] = true
+		}
+		for _, prov := range full {
+			if oldFull[prov.Name] {
+				delete(oldFull, prov.Name)
+				continue
+			}
+			if err := repoRefCounts.Increment(prov.Name); err != nil {
+				return err
+			}
+		}
+		for prov := range oldFull {
+			if err := repoRefCounts.Decrement(prov); err != nil {
+				// Skip NotFound error, because it's possible that the
+				// provenance repo has been deleted via --force.
+				if col.IsErrNotFound(err) {
+					continue
+				}
+				return err
+			}
+			// verify the count, a negative one means that it had already
+			// drifted from the provenance
+			refCount, err := repoRefCounts.Get(prov)
+			if err != nil {
+				return err
+			}
+			if refCount < 0 {
+				return fmt.Errorf("the number of repos with %s as provenance is inconsistent, it must be rebuilt with RebuildProvenance", prov)
+			}
+		}
+		repoInfo.Provenance = full
+		if name == repo {
+			repoInfo.ImmediateProvenance = provenance
+		}
+		if err := repos.Put(name, repoInfo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rebuildProvenance re-derives the full provenance of every repo, and the
+// ref count of every repo, from their immediate provenance. It's run as an
+// admin job.
+func (d *driver) rebuildProvenance(ctx context.Context) error {
+	whoAmI, err := d.pachClient.AuthAPIClient.WhoAmI(auth.In2Out(ctx), &auth.WhoAmIRequest{})
+	if err != nil && !auth.IsNotActivatedError(err) {
+		return grpcutil.ScrubGRPC(err)
+	} else if err == nil && !whoAmI.IsAdmin {
+		return fmt.Errorf("only cluster admins can rebuild provenance")
+	}
+	d.featureUsage.inc("rebuild_provenance")
+	return d.runAdminJob(ctx, "rebuild_provenance", d.rebuildProvenanceJob)
+}
+
+func (d *driver) rebuildProvenanceJob(ctx context.Context, job *adminJob) error {
+	var names []string
+	iter, err := d.repos.ReadOnly(ctx).List()
+	if err != nil {
+		return err
+	}
+	for {
+		var name string
+		repoInfo := new(pfs.RepoInfo)
+		ok, err := iter.Next(&name, repoInfo)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		names = append(names, repoInfo.Repo.Name)
+	}
+
+	_, err = col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		// the phase restarts if the transaction is retried
+		job.setPhase("deriving the full provenance of each repo", int64(len(names)))
+		repos := d.repos.ReadWrite(stm)
+		repoRefCounts := d.repoRefCounts.ReadWriteInt(stm)
+		g := newProvenanceGraph(repos)
+		repoInfos := make(map[string]*pfs.RepoInfo)
+		for _, name := range names {
+			repoInfo := new(pfs.RepoInfo)
+			if err := repos.Get(name, repoInfo); err != nil {
+				return err
+			}
+			repoInfos[name] = repoInfo
+			g.immediate[name] = immediateProvenance(repoInfo)
+		}
+		refCounts := make(map[string]int)
+		for _, name := range names {
+			repoInfo := repoInfos[name]
+			full, err := g.fullProvenance(name)
+			if err != nil {
+				return err
+			}
+			for _, prov := range full {
+				refCounts[prov.Name]++
+			}
+			repoInfo.ImmediateProvenance = g.immediate[name]
+			repoInfo.Provenance = full
+			if err := repos.Put(name, repoInfo); err != nil {
+				return err
+			}
+			job.progress(1)
+		}
+		for _, name := range names {
+			refCount, err := repoRefCounts.Get(name)
+			if err != nil {
+				if !col.IsErrNotFound(err) {
+					return err
+				}
+				if err := repoRefCounts.Create(name, 0); err != nil {
+					return err
+				}
+			}
+			if err := repoRefCounts.IncrementBy(name, refCounts[name]-refCount); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+)
+
+// exportProvenanceGraph returns the provenance DAG of repos and the repos
+// upstream and downstream of them, or of every repo if repos is empty. If
+// commits is set, the commits of those repos are in it too, which requires
+// read access to each repo.
+func (d *driver) exportProvenanceGraph(ctx context.Context, repos []*pfs.Repo, commits bool) (*pfs.ProvenanceGraph, error) {
+	repoInfos := make(map[string]*pfs.RepoInfo)
+	if len(repos) == 0 {
+		iter, err := d.repos.ReadOnly(ctx).List()
+		if err != nil {
+			return nil, err
+		}
+		for {
+			var repoName string
+			repoInfo := &pfs.RepoInfo{}
+			ok, err := iter.Next(&repoName, repoInfo)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				break
+			}
+			repoInfos[repoInfo.Repo.Name] = repoInfo
+		}
+	} else {
+		// a repo's provenance is its full provenance, so the graph is the
+		// repos, their provenance and their subvenance
+		var names []string
+		for _, repo := range repos {
+			repoInfo := &pfs.RepoInfo{}
+			if err := d.repos.ReadOnly(ctx).Get(repo.Name, repoInfo); err != nil {
+				return nil, err
+			}
+			subvenance, err := d.repoSubvenance(ctx, repo)
+			if err != nil {
+				return nil, err
+			}
+			repoInfos[repo.Name] = repoInfo
+			for _, prov := range repoInfo.Provenance {
+				names = append(names, prov.Name)
+			}
+			for _, sub := range subvenance {
+				names = append(names, sub.Name)
+			}
+		}
+		for _, name := range names {
+			if repoInfos[name] != nil {
+				continue
+			}
+			repoInfo := &pfs.RepoInfo{}
+			if err := d.repos.ReadOnly(ctx).Get(name, repoInfo); err != nil {
+				return nil, err
+			}
+			repoInfos[name] = repoInfo
+		}
+	}
+	d.featureUsage.inc("export_provenance_graph")
+	var names []string
+	for name := range repoInfos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	graph := &pfs.ProvenanceGraph{}
+	for _, name := range names {
+		repoInfo := repoInfos[name]
+		graph.Nodes = append(graph.Nodes, &pfs.ProvenanceNode{
+			Id:   name,
+			Repo: repoInfo.Repo,
+		})
+		for _, prov := range immediateProvenance(repoInfo) {
+			if repoInfos[prov.Name] == nil {
+				continue
+			}
+			graph.Edges = append(graph.Edges, &pfs.ProvenanceEdge{
+				From: prov.Name,
+				To:   name,
+			})
+		}
+	}
+	if !commits {
+		return graph, nil
+	}
+	for _, name := range names {
+		repoInfo := repoInfos[name]
+		if err := d.checkIsAuthorized(ctx, repoInfo.Repo, auth.Scope_READER); err != nil {
+			return nil, err
+		}
+		// commits only have their full provenance, so edges are only drawn
+		// from the commits in the repo's immediate provenance
+		immediate := make(map[string]bool)
+		for _, prov := range immediateProvenance(repoInfo) {
+			immediate[prov.Name] = true
+		}
+		iter, err := d.commits(name).ReadOnly(ctx).List()
+		if err != nil {
+			return nil, err
+		}
+		for {
+			var commitID string
+			commitInfo := &pfs.CommitInfo{}
+			ok, err := iter.Next(&commitID, commitInfo)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				break
+			}
+			id := commitNodeID(commitInfo.Commit)
+			graph.Nodes = append(graph.Nodes, &pfs.ProvenanceNode{
+				Id:     id,
+				Repo:   commitInfo.Commit.Repo,
+				Commit: commitInfo.Commit,
+			})
+			for _, prov := range commitInfo.Provenance {
+				if !immediate[prov.Repo.Name] || repoInfos[prov.Repo.Name] == nil {
+					continue
+				}
+				graph.Edges = append(graph.Edges, &pfs.ProvenanceEdge{
+					From: commitNodeID(prov),
+					To:   id,
+				})
+			}
+		}
+	}
+	return graph, nil
+}
+
+// commitNodeID is the ID of commit's node in a ProvenanceGraph.
+func commitNodeID(commit *pfs.Commit) string {
+	return fmt.Sprintf("%s@%s", commit.Repo.Name, commit.ID)
+}
@@ -0,0 +1,237 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// remoteOf returns the remote of repo if it's a proxy repo, and nil
+// otherwise.
+func (d *driver) remoteOf(ctx context.Context, repo *pfs.Repo) (*pfs.RemoteRepo, error) {
+	repoInfo := &pfs.RepoInfo{}
+	if err := d.repos.ReadOnly(ctx).Get(repo.Name, repoInfo); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return repoInfo.Remote, nil
+}
+
+// checkNotProxy returns an error if repo is a proxy repo, which can't be
+// written to.
+func (d *driver) checkNotProxy(ctx context.Context, repo *pfs.Repo) error {
+	remote, err := d.remoteOf(ctx, repo)
+	if err != nil {
+		return err
+	}
+	if remote != nil {
+		return fmt.Errorf("repo %s is a proxy of %s/%s and can't be written to", repo.Name, remote.Address, remote.Repo)
+	}
+	return nil
+}
+
+// checkRemote checks that the remote repo of a proxy repo can be read, and
+// that its data can be placed on this cluster.
+func (d *driver) checkRemote(ctx context.Context, remote *pfs.RemoteRepo) error {
+	remoteClient, err := client.NewFromAddress(remote.Address)
+	if err != nil {
+		return err
+	}
+	defer remoteClient.Close()
+	return d.checkRemoteResidency(remoteClient.WithCtx(ctx), remote)
+}
+
+// checkRemoteResidency checks that the residency tags of remote, which are
+// read with remoteClient, are satisfied by this cluster.
+func (d *driver) checkRemoteResidency(remoteClient *client.APIClient, remote *pfs.RemoteRepo) error {
+	remoteInfo, err := remoteClient.InspectRepo(remote.Repo)
+	if err != nil {
+		return fmt.Errorf("error reading remote repo %s/%s: %v", remote.Address, remote.Repo, err)
+	}
+	return d.checkResidency(fmt.Sprintf("%s/%s", remote.Address, remote.Repo), remoteInfo.Residency)
+}
+
+// mirrorCommit resolves commit, which may be a branch or use ancestry
+// syntax, in the proxy repo's remote and mirrors the commit that it resolves
+// to into the proxy, returning the ID of that commit. Commits that were
+// mirrored already are returned without contacting the remote, as commits
+// don't change once finished, but branches are always resolved on the remote
+// unless it can't be reached, in which case the proxy's copy of the branch is
+// used. It returns "" if commit's repo isn't a proxy.
+func (d *driver) mirrorCommit(ctx context.Context, commit *pfs.Commit) (string, error) {
+	remote, err := d.remoteOf(ctx, commit.Repo)
+	if err != nil || remote == nil {
+		return "", err
+	}
+	commits := d.commits(commit.Repo.Name).ReadOnly(ctx)
+	if err := commits.Get(commit.ID, &pfs.CommitInfo{}); err == nil {
+		return commit.ID, nil
+	} else if !col.IsErrNotFound(err) {
+		return "", err
+	}
+
+	branch, ancestryLength := parseCommitID(commit.ID)
+	remoteClient, err := client.NewFromAddress(remote.Address)
+	if err != nil {
+		return "", d.mirrorCommitFailed(ctx, commit, err)
+	}
+	defer remoteClient.Close()
+	remoteClient = remoteClient.WithCtx(ctx)
+	// the remote's residency tags are checked before any of its data is
+	// fetched, as they may have changed since the proxy was created
+	remoteRepoInfo, err := remoteClient.InspectRepo(remote.Repo)
+	if err != nil {
+		return "", d.mirrorCommitFailed(ctx, commit, err)
+	}
+	if err := d.checkResidency(fmt.Sprintf("%s/%s", remote.Address, remote.Repo), remoteRepoInfo.Residency); err != nil {
+		return "", err
+	}
+	remoteInfo, err := remoteClient.InspectCommit(remote.Repo, commit.ID)
+	if err != nil {
+		return "", d.mirrorCommitFailed(ctx, commit, err)
+	}
+	if remoteInfo.Finished == nil {
+		return "", fmt.Errorf("commit %s of %s/%s isn't finished, so it can't be read through proxy repo %s",
+			remoteInfo.Commit.ID, remote.Address, remote.Repo, commit.Repo.Name)
+	}
+
+	// the commit's tree is cached when the commit is mirrored, the data it
+	// refers to is cached as it's read
+	var tree hashtree.HashTree
+	if remoteInfo.Tree != nil {
+		var buf bytes.Buffer
+		if err := remoteClient.GetObject(remoteInfo.Tree.Hash, d.transferThrottle.Writer(ctx, &buf)); err != nil {
+			return "", err
+		}
+		// the chunks of a chunked tree are read from the local cache, so
+		// they're cached along with the tree
+		if tree, err = hashtree.DeserializeLazy(buf.Bytes(), d.getTreeChunk); err != nil {
+			return "", err
+		}
+		if err := d.putCachedObject(remoteInfo.Tree, &buf); err != nil {
+			return "", err
+		}
+		for _, chunk := range hashtree.ChunkObjects(tree) {
+			var chunkBuf bytes.Buffer
+			if err := remoteClient.GetObject(chunk.Hash, d.transferThrottle.Writer(ctx, &chunkBuf)); err != nil {
+				return "", err
+			}
+			if err := d.putCachedObject(chunk, &chunkBuf); err != nil {
+				return "", err
+			}
+		}
+	}
+	commitInfo := &pfs.CommitInfo{
+		Commit:    client.NewCommit(commit.Repo.Name, remoteInfo.Commit.ID),
+		Started:   remoteInfo.Started,
+		Finished:  remoteInfo.Finished,
+		SizeBytes: remoteInfo.SizeBytes,
+		Tree:      remoteInfo.Tree,
+		DataCard:  remoteInfo.DataCard,
+	}
+	if remoteInfo.ParentCommit != nil {
+		commitInfo.ParentCommit = client.NewCommit(commit.Repo.Name, remoteInfo.ParentCommit.ID)
+	}
+	var created bool
+	if _, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		created = false
+		commits := d.commits(commit.Repo.Name).ReadWrite(stm)
+		if err := commits.Get(commitInfo.Commit.ID, &pfs.CommitInfo{}); err != nil {
+			if !col.IsErrNotFound(err) {
+				return err
+			}
+			if err := commits.Create(commitInfo.Commit.ID, commitInfo); err != nil {
+				return err
+			}
+			created = true
+		}
+		if ancestryLength == 0 && branch != commitInfo.Commit.ID {
+			return d.branches(commit.Repo.Name).ReadWrite(stm).Put(branch, commitInfo.Commit)
+		}
+		return nil
+	}); err != nil {
+		return "", err
+	}
+	if created && tree != nil {
+		// the objects are referenced before they're cached, so that the
+		// garbage collector keeps them once they are
+		hashes, err := treeObjects(commitInfo.Tree, hashtree.ChunkObjects(tree), tree)
+		if err != nil {
+			return "", err
+		}
+		if err := d.addObjectRefs(ctx, hashes); err != nil {
+			return "", err
+		}
+	}
+	return commitInfo.Commit.ID, nil
+}
+
+// mirrorCommitFailed returns err, the error contacting the remote to mirror
+// commit, unless commit is a branch that the proxy has a copy of, in which
+// case the copy is used.
+func (d *driver) mirrorCommitFailed(ctx context.Context, commit *pfs.Commit, err error) error {
+	branch, _ := parseCommitID(commit.ID)
+	if getErr := d.branches(commit.Repo.Name).ReadOnly(ctx).Get(branch, &pfs.Commit{}); getErr != nil {
+		return err
+	}
+	log.Warnf("error resolving %s in the remote of proxy repo %s, using the proxy's copy of the branch: %v", commit.ID, commit.Repo.Name, err)
+	return nil
+}
+
+// cacheObjects fetches the objects that aren't in the object store yet from
+// the remote of repo, if it's a proxy repo.
+func (d *driver) cacheObjects(ctx context.Context, repo *pfs.Repo, objects []*pfs.Object) error {
+	remote, err := d.remoteOf(ctx, repo)
+	if err != nil || remote == nil {
+		return err
+	}
+	var remoteClient *client.APIClient
+	for _, object := range objects {
+		if _, err := d.pachClient.WithCtx(ctx).InspectObject(object.Hash); err == nil {
+			continue
+		}
+		if remoteClient == nil {
+			if remoteClient, err = client.NewFromAddress(remote.Address); err != nil {
+				return err
+			}
+			defer remoteClient.Close()
+			remoteClient = remoteClient.WithCtx(ctx)
+			if err := d.checkRemoteResidency(remoteClient, remote); err != nil {
+				return err
+			}
+		}
+		r, w := io.Pipe()
+		go func(hash string) {
+			w.CloseWithError(remoteClient.GetObject(hash, d.transferThrottle.Writer(ctx, w)))
+		}(object.Hash)
+		err := d.putCachedObject(object, r)
+		r.CloseWithError(err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putCachedObject puts the content of object, read from r, into the object
+// store, checking that it has the hash it's cached under.
+func (d *driver) putCachedObject(object *pfs.Object, r io.Reader) error {
+	cached, _, err := d.pachClient.PutObject(r)
+	if err != nil {
+		return err
+	}
+	if cached.Hash != object.Hash {
+		return fmt.Errorf("content of remote object %s hashed to %s", object.Hash, cached.Hash)
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
+	"github.com/pachyderm/pachyderm/src/server/pkg/obj"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+// putFileURLTimeout is how long fetching a single url is retried for before
+// giving up.
+const putFileURLTimeout = 2 * time.Minute
+
+// httpStatusError is returned when an http(s) url responds with a status
+// that isn't 2xx.
+type httpStatusError struct {
+	url        string
+	statusCode int
+}
+
+func (e httpStatusError) Error() string {
+	return fmt.Sprintf("error fetching %s: %s", e.url, http.StatusText(e.statusCode))
+}
+
+// urlFetcher reads the data under a url. Files are named however the
+// url's scheme refers to them, e.g. by url for http(s) or by object path for
+// object stores.
+type urlFetcher interface {
+	// root returns the name of the file at the url itself
+	root() string
+	// open returns a reader of the file name
+	open(ctx context.Context, name string) (io.ReadCloser, error)
+	// walk calls fn with the name, and path relative to the url, of every
+	// file under the url
+	walk(fn func(name string, relPath string) error) error
+}
+
+// putFileURL puts the data at rawurl into file. The data is fetched by pachd
+// itself rather than going through the client, http(s), pfs and object store
+// (e.g. s3 and gs) urls are supported. With recursive, every file under
+// rawurl is put under file.Path, fetching up to
+// client.DefaultMaxConcurrentStreams of them at a time. Each file is fetched
+// and written by put, which is retried if it fails.
+func (d *driver) putFileURL(ctx context.Context, file *pfs.File, rawurl string, recursive bool, put func(*pfs.File, io.Reader) error) error {
+	if err := d.checkIsAuthorized(ctx, file.Commit.Repo, auth.Scope_WRITER); err != nil {
+		return err
+	}
+	fetcher, err := newURLFetcher(ctx, rawurl)
+	if err != nil {
+		return err
+	}
+	d.featureUsage.inc("put_file_url")
+	putURL := func(file *pfs.File, name string) error {
+		// only errors fetching the data are retried, the data isn't
+		// committed until put has read all of it, so put is safe to retry
+		var fetchErr bool
+		return backoff.RetryNotify(func() (retErr error) {
+			fetchErr = true
+			rc, err := fetcher.open(ctx, name)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if err := rc.Close(); err != nil && retErr == nil {
+					retErr = err
+				}
+			}()
+			r := &fetchReader{r: rc}
+			err = put(file, r)
+			fetchErr = r.err != nil
+			return err
+		}, putFileURLBackOff(), func(err error, next time.Duration) error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if !fetchErr {
+				return err
+			}
+			if statusErr, ok := err.(httpStatusError); ok && statusErr.statusCode < 500 {
+				return err
+			}
+			logrus.Warnf("error putting %s into %s, retrying in %v: %v", name, file.Path, next, err)
+			return nil
+		})
+	}
+	if !recursive {
+		return putURL(file, fetcher.root())
+	}
+
+	eg, ctx := errgroup.WithContext(ctx)
+	sem := make(chan struct{}, client.DefaultMaxConcurrentStreams)
+	if err := fetcher.walk(func(name string, relPath string) error {
+		if ctx.Err() != nil {
+			// another file failed, so stop walking
+			return ctx.Err()
+		}
+		sem <- struct{}{}
+		eg.Go(func() error {
+			defer func() { <-sem }()
+			return putURL(client.NewFile(file.Commit.Repo.Name, file.Commit.ID, path.Join(file.Path, relPath)), name)
+		})
+		return nil
+	}); err != nil {
+		eg.Wait()
+		return err
+	}
+	return eg.Wait()
+}
+
+// fetchReader remembers the error, other than io.EOF, that reading r
+// returned.
+type fetchReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *fetchReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
+
+func putFileURLBackOff() backoff.BackOff {
+	b := backoff.NewExponentialBackOff()
+	b.MaxElapsedTime = putFileURLTimeout
+	return b
+}
+
+func newURLFetcher(ctx context.Context, rawurl string) (urlFetcher, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return httpFetcher(rawurl), nil
+	case "pfs":
+		return newPfsFetcher(u)
+	default:
+		objURL, err := obj.ParseURL(rawurl)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing url %v: %v", rawurl, err)
+		}
+		objClient, err := obj.NewClientFromURLAndSecret(ctx, objURL)
+		if err != nil {
+			return nil, err
+		}
+		return &objFetcher{
+			client: objClient,
+			object: objURL.Object,
+		}, nil
+	}
+}
+
+// httpFetcher fetches an http(s) url.
+type httpFetcher string
+
+func (f httpFetcher) root() string {
+	return string(f)
+}
+
+func (httpFetcher) open(ctx context.Context, u string) (io.ReadCloser, error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, httpStatusError{
+			url:        u,
+			statusCode: resp.StatusCode,
+		}
+	}
+	return resp.Body, nil
+}
+
+func (f httpFetcher) walk(fn func(string, string) error) error {
+	return fmt.Errorf("http(s) urls can't be put recursively (got %s)", string(f))
+}
+
+// pfsFetcher fetches files from the pachd at the url's host, the path of the
+// url is repo/commit[/path/to/file].
+type pfsFetcher struct {
+	client *client.APIClient
+	repo   string
+	commit string
+	file   string
+}
+
+func newPfsFetcher(u *url.URL) (*pfsFetcher, error) {
+	splitPath := strings.Split(strings.TrimPrefix(u.Path, "/"), "/")
+	if len(splitPath) < 2 {
+		return nil, fmt.Errorf("pfs put-file path must be of form repo/commit[/path/to/file] got: %s", u.Path)
+	}
+	pClient, err := client.NewFromAddress(u.Host)
+	if err != nil {
+		return nil, err
+	}
+	return &pfsFetcher{
+		client: pClient,
+		repo:   splitPath[0],
+		commit: splitPath[1],
+		file:   path.Join(splitPath[2:]...),
+	}, nil
+}
+
+func (f *pfsFetcher) root() string {
+	return f.file
+}
+
+func (f *pfsFetcher) open(ctx context.Context, file string) (io.ReadCloser, error) {
+	r, err := f.client.WithCtx(ctx).GetFileReader(f.repo, f.commit, file, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(r), nil
+}
+
+func (f *pfsFetcher) walk(fn func(string, string) error) error {
+	return f.client.Walk(f.repo, f.commit, f.file, func(fileInfo *pfs.FileInfo) error {
+		if fileInfo.FileType != pfs.FileType_FILE {
+			return nil
+		}
+		return fn(fileInfo.File.Path, strings.TrimPrefix(fileInfo.File.Path, path.Clean("/"+f.file)))
+	})
+}
+
+// objFetcher fetches objects from an object store.
+type objFetcher struct {
+	client obj.Client
+	object string
+}
+
+func (f *objFetcher) root() string {
+	return f.object
+}
+
+func (f *objFetcher) open(ctx context.Context, object string) (io.ReadCloser, error) {
+	return f.client.Reader(object, 0, 0)
+}
+
+func (f *objFetcher) walk(fn func(string, string) error) error {
+	object := strings.TrimPrefix(f.object, "/")
+	return f.client.Walk(object, func(name string) error {
+		if strings.HasSuffix(name, "/") {
+			// Amazon S3 supports objs w keys that end in a '/'
+			// PFS needs to treat such a key as a directory.
+			// In this case, we rely on the driver PutFile to
+			// construct the 'directory' diffs from the file prefix
+			logrus.Warnf("ambiguous key %v, not creating a directory or putting this entry as a file", name)
+			return nil
+		}
+		return fn(name, strings.TrimPrefix(name, object))
+	})
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+)
+
+func (d *driver) setReadFilter(ctx context.Context, repo *pfs.Repo, filter *pfs.ReadFilter) error {
+	if err := d.checkIsAuthorized(ctx, repo, auth.Scope_OWNER); err != nil {
+		return err
+	}
+	if filter != nil {
+		if _, err := newRecordFilter(filter.Regex, filter.JmesPath); err != nil {
+			return err
+		}
+		if filter.ExemptScope == auth.Scope_NONE {
+			filter.ExemptScope = auth.Scope_WRITER
+		}
+		d.featureUsage.inc("read_filter")
+	}
+	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		repos := d.repos.ReadWrite(stm)
+		repoInfo := new(pfs.RepoInfo)
+		if err := repos.Get(repo.Name, repoInfo); err != nil {
+			return err
+		}
+		repoInfo.ReadFilter = filter
+		return repos.Put(repo.Name, repoInfo)
+	})
+	return err
+}
+
+// readFilter returns the filter that the caller reads the records of repo's
+// files through, or nil if they read them unfiltered.
+func (d *driver) readFilter(ctx context.Context, repo *pfs.Repo) (recordFilter, error) {
+	repoInfo := new(pfs.RepoInfo)
+	if err := d.repos.ReadOnly(ctx).Get(repo.Name, repoInfo); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil, nil // the read fails later, with a clearer error
+		}
+		return nil, err
+	}
+	if repoInfo.ReadFilter == nil {
+		return nil, nil
+	}
+	if err := d.checkIsAuthorized(ctx, repo, repoInfo.ReadFilter.ExemptScope); err != nil {
+		if !auth.IsNotAuthorizedError(err) {
+			return nil, err
+		}
+		return newRecordFilter(repoInfo.ReadFilter.Regex, repoInfo.ReadFilter.JmesPath)
+	}
+	return nil, nil
+}
+
+// checkReadIsUnfiltered returns an error if the caller reads repo through
+// a read filter, for reads that can't be filtered by record.
+func (d *driver) checkReadIsUnfiltered(ctx context.Context, repo *pfs.Repo) error {
+	filter, err := d.readFilter(ctx, repo)
+	if err != nil {
+		return err
+	}
+	if filter != nil {
+		return fmt.Errorf("repo %s has a read filter, so its files can only be read with GetFile, FilterFile or GrepFile", repo.Name)
+	}
+	return nil
+}
+
+// hideObjectsIfFiltered clears the objects of fileInfos, which are files in
+// repo, if the caller reads repo through a read filter, as their objects can
+// be read unfiltered.
+func (d *driver) hideObjectsIfFiltered(ctx context.Context, repo *pfs.Repo, fileInfos []*pfs.FileInfo) error {
+	filter, err := d.readFilter(ctx, repo)
+	if err != nil {
+		return err
+	}
+	if filter != nil {
+		for _, fileInfo := range fileInfos {
+			fileInfo.Objects = nil
+		}
+	}
+	return nil
+}
+
+// filterReader returns a reader of the records read from r that filter
+// matches. Closing it early aborts the filtering.
+func filterReader(r io.Reader, filter recordFilter) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(filter(r, pw))
+	}()
+	return pr
+}
+
+// chainFilters returns a recordFilter that writes the records that both
+// first and second match.
+func chainFilters(first recordFilter, second recordFilter) recordFilter {
+	return func(r io.Reader, w io.Writer) error {
+		filtered := filterReader(r, first)
+		err := second(filtered, w)
+		filtered.Close()
+		return err
+	}
+}
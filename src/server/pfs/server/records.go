@@ -0,0 +1,163 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+)
+
+// recordRange is the part of a split file that's read by getRecords.
+type recordRange struct {
+	path string
+	node *hashtree.NodeProto
+	// skip records are skipped before reading, and then count records are
+	// read, or all of them if count is -1
+	skip  int64
+	count int64
+}
+
+// getRecords returns num records, starting from the record at offset, of the
+// split directory (or split file) at file.Path. The record counts stored for
+// each file are used to find the files that hold the range, so only the first
+// and last of them have to be parsed. num 0 returns every record from offset
+// on.
+func (d *driver) getRecords(ctx context.Context, file *pfs.File, offset int64, num int64) (io.ReadCloser, error) {
+	if err := d.checkIsAuthorized(ctx, file.Commit.Repo, auth.Scope_READER); err != nil {
+		return nil, err
+	}
+	if err := d.checkReadIsUnfiltered(ctx, file.Commit.Repo); err != nil {
+		return nil, err
+	}
+	if offset < 0 || num < 0 {
+		return nil, fmt.Errorf("the offset and number of records can't be negative")
+	}
+	tree, err := d.getTreeForFile(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	node, err := getNode(tree, file, false)
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{file.Path}
+	nodes := []*hashtree.NodeProto{node}
+	if node.DirNode != nil {
+		// the children are ordered by name, which is the order they were
+		// split in
+		nodes, err = tree.List(file.Path)
+		if err != nil {
+			return nil, err
+		}
+		paths = nil
+		for _, child := range nodes {
+			paths = append(paths, path.Join(file.Path, child.Name))
+		}
+	}
+
+	var ranges []recordRange
+	var index int64
+	for i, node := range nodes {
+		if num > 0 && index >= offset+num {
+			break
+		}
+		if node.FileNode == nil || node.FileNode.RecordCount == 0 {
+			return nil, fmt.Errorf("the number of records in %s isn't known, it has to be put with a split delimiter to be read by record", paths[i])
+		}
+		end := index + node.FileNode.RecordCount
+		if end > offset {
+			r := recordRange{path: paths[i], node: node, count: -1}
+			if offset > index {
+				r.skip = offset - index
+			}
+			if num > 0 && offset+num < end {
+				r.count = offset + num - index - r.skip
+			}
+			ranges = append(ranges, r)
+		}
+		index = end
+	}
+
+	d.featureUsage.inc("get_records")
+	r, w := io.Pipe()
+	go func() {
+		w.CloseWithError(d.writeRecords(ctx, w, ranges))
+	}()
+	return r, nil
+}
+
+func (d *driver) writeRecords(ctx context.Context, w io.Writer, ranges []recordRange) error {
+	for _, r := range ranges {
+		reader, err := d.objectsReader(ctx, r.node.FileNode.Objects, 0, 0)
+		if err != nil {
+			return err
+		}
+		if fileNode := r.node.FileNode; fileNode.HeaderLines > 0 || fileNode.FooterLines > 0 {
+			// the lines that a split added to the start and end of the file
+			// aren't records
+			if r.count == -1 {
+				r.count = fileNode.RecordCount - r.skip
+			}
+			r.skip += fileNode.HeaderLines
+		}
+		if r.skip == 0 && r.count == -1 {
+			// the whole file is in the range, so it doesn't need parsing
+			if _, err := io.Copy(w, reader); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := copyRecords(w, reader, r.node.FileNode.Delimiter, r.skip, r.count); err != nil {
+			return fmt.Errorf("error reading the records of %s: %v", r.path, err)
+		}
+	}
+	return nil
+}
+
+// copyRecords skips skip records of r, which are split by delimiter, and
+// then copies count of them (or all of the rest if count is -1) to w.
+func copyRecords(w io.Writer, r io.Reader, delimiter pfs.Delimiter, skip int64, count int64) error {
+	var next func() ([]byte, error)
+	switch delimiter {
+	case pfs.Delimiter_LINE:
+		br := bufio.NewReader(r)
+		next = func() ([]byte, error) {
+			line, err := br.ReadBytes('\n')
+			if err == io.EOF && len(line) > 0 {
+				return line, nil
+			}
+			return line, err
+		}
+	case pfs.Delimiter_JSON:
+		decoder := json.NewDecoder(r)
+		next = func() ([]byte, error) {
+			var value json.RawMessage
+			err := decoder.Decode(&value)
+			return value, err
+		}
+	default:
+		return fmt.Errorf("records can't be read from files split by %s", delimiter)
+	}
+	for i := int64(0); count == -1 || i < skip+count; i++ {
+		record, err := next()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if i < skip {
+			continue
+		}
+		if _, err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
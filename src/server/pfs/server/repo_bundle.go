@@ -0,0 +1,408 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// bundleVersion is the version of the bundle format that exportRepo
+	// writes and importRepo reads, see BundleRecord.
+	bundleVersion = 1
+	// bundleChunkSize is the most object data that a record of a bundle
+	// holds.
+	bundleChunkSize = 4 * 1024 * 1024
+)
+
+// maxBundleRecordSize is the largest record that importRepo reads, a larger
+// one means that the bundle is corrupt.
+var maxBundleRecordSize = uint64(grpcutil.MaxMsgSize)
+
+// bundleWriter writes the records of a bundle. Writing to it writes data
+// records, so that an object's content can be copied into it.
+type bundleWriter struct {
+	w   io.Writer
+	buf [binary.MaxVarintLen64]byte
+}
+
+func (b *bundleWriter) write(record *pfs.BundleRecord) error {
+	data, err := record.Marshal()
+	if err != nil {
+		return err
+	}
+	if _, err := b.w.Write(b.buf[:binary.PutUvarint(b.buf[:], uint64(len(data)))]); err != nil {
+		return err
+	}
+	_, err = b.w.Write(data)
+	return err
+}
+
+func (b *bundleWriter) Write(p []byte) (int, error) {
+	for _, chunk := range grpcutil.Chunk(p, bundleChunkSize) {
+		if err := b.write(&pfs.BundleRecord{Data: chunk}); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// bundleReader reads the records of a bundle.
+type bundleReader struct {
+	r *bufio.Reader
+	// next is a record that's been read, but not returned yet
+	next *pfs.BundleRecord
+}
+
+func (b *bundleReader) read() (*pfs.BundleRecord, error) {
+	if b.next != nil {
+		record := b.next
+		b.next = nil
+		return record, nil
+	}
+	n, err := binary.ReadUvarint(b.r)
+	if err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("invalid bundle: it's truncated")
+		}
+		return nil, err
+	}
+	if n > maxBundleRecordSize {
+		return nil, fmt.Errorf("invalid bundle: a record is %d bytes, more than the most, %d", n, maxBundleRecordSize)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(b.r, data); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("invalid bundle: it's truncated")
+		}
+		return nil, err
+	}
+	record := &pfs.BundleRecord{}
+	if err := record.Unmarshal(data); err != nil {
+		return nil, fmt.Errorf("invalid bundle: %v", err)
+	}
+	return record, nil
+}
+
+// bundleObjectReader reads the content of an object of a bundle, from the
+// data records that follow it. It returns io.EOF when it reaches a record that
+// isn't data, which is then returned by the bundleReader.
+type bundleObjectReader struct {
+	b      *bundleReader
+	buffer []byte
+}
+
+func (r *bundleObjectReader) Read(p []byte) (int, error) {
+	for len(r.buffer) == 0 {
+		record, err := r.b.read()
+		if err != nil {
+			return 0, err
+		}
+		if len(record.Data) == 0 {
+			r.b.next = record
+			return 0, io.EOF
+		}
+		r.buffer = record.Data
+	}
+	n := copy(p, r.buffer)
+	r.buffer = r.buffer[n:]
+	return n, nil
+}
+
+// exportRepo returns a bundle of repo, see BundleRecord. Bundles hold every
+// file of the repo, so repos with a read filter can't be exported.
+func (d *driver) exportRepo(ctx context.Context, repo *pfs.Repo) (io.ReadCloser, error) {
+	if err := d.checkIsAuthorized(ctx, repo, auth.Scope_READER); err != nil {
+		return nil, err
+	}
+	if err := d.checkReadIsUnfiltered(ctx, repo); err != nil {
+		return nil, err
+	}
+	if err := d.checkNotProxy(ctx, repo); err != nil {
+		return nil, err
+	}
+	d.initializePachConn()
+	repoInfo, err := d.inspectRepo(ctx, repo, !includeAuth)
+	if err != nil {
+		return nil, err
+	}
+	commitInfos := make(map[string]*pfs.CommitInfo)
+	iter, err := d.commits(repo.Name).ReadOnly(ctx).List()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var commitID string
+		commitInfo := &pfs.CommitInfo{}
+		ok, err := iter.Next(&commitID, commitInfo)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		commitInfos[commitInfo.Commit.ID] = commitInfo
+	}
+	branchInfos, err := d.listBranch(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	// open commits aren't exported, so a branch whose head is open points to
+	// the head's parent instead
+	var branches []*pfs.BranchInfo
+	for _, branchInfo := range branchInfos {
+		head := commitInfos[branchInfo.Head.ID]
+		if head != nil && head.Finished == nil {
+			if head.ParentCommit == nil {
+				continue
+			}
+			head = commitInfos[head.ParentCommit.ID]
+		}
+		if head == nil {
+			continue
+		}
+		branches = append(branches, &pfs.BranchInfo{
+			Name: branchInfo.Name,
+			Head: head.Commit,
+		})
+	}
+	d.featureUsage.inc("export_repo")
+
+	r, w := io.Pipe()
+	go func() {
+		w.CloseWithError(d.writeBundle(ctx, w, repoInfo, bundleCommits(commitInfos), branches))
+	}()
+	return r, nil
+}
+
+// bundleCommits returns the finished commits in commitInfos, each after its
+// parent and its base.
+func bundleCommits(commitInfos map[string]*pfs.CommitInfo) []*pfs.CommitInfo {
+	// the commits are sorted first, so that bundles of the same repo are the
+	// same
+	var ids []string
+	for id := range commitInfos {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var result []*pfs.CommitInfo
+	added := make(map[string]bool)
+	var add func(commit *pfs.Commit)
+	add = func(commit *pfs.Commit) {
+		if commit == nil || added[commit.ID] {
+			return
+		}
+		commitInfo := commitInfos[commit.ID]
+		if commitInfo == nil || commitInfo.Finished == nil {
+			return
+		}
+		added[commit.ID] = true
+		add(commitInfo.ParentCommit)
+		add(commitInfo.Base)
+		result = append(result, commitInfo)
+	}
+	for _, id := range ids {
+		add(commitInfos[id].Commit)
+	}
+	return result
+}
+
+// writeBundle writes a bundle of the repo repoInfo, with commitInfos and
+// branches, to w.
+func (d *driver) writeBundle(ctx context.Context, w io.Writer, repoInfo *pfs.RepoInfo, commitInfos []*pfs.CommitInfo, branches []*pfs.BranchInfo) error {
+	b := &bundleWriter{w: w}
+	if err := b.write(&pfs.BundleRecord{
+		Version: bundleVersion,
+		Repo:    repoInfo,
+	}); err != nil {
+		return err
+	}
+	written := make(map[string]bool)
+	for _, commitInfo := range commitInfos {
+		hashes, err := d.commitObjects(ctx, commitInfo)
+		if err != nil {
+			return err
+		}
+		for _, hash := range hashes {
+			if written[hash] {
+				continue
+			}
+			if err := b.write(&pfs.BundleRecord{Object: &pfs.Object{Hash: hash}}); err != nil {
+				return err
+			}
+			if err := d.pachClient.WithCtx(ctx).GetObject(hash, b); err != nil {
+				return err
+			}
+			written[hash] = true
+		}
+		commitInfo.Progress = nil
+		if err := b.write(&pfs.BundleRecord{Commit: commitInfo}); err != nil {
+			return err
+		}
+	}
+	for _, branchInfo := range branches {
+		if err := b.write(&pfs.BundleRecord{Branch: branchInfo}); err != nil {
+			return err
+		}
+	}
+	return b.write(&pfs.BundleRecord{End: true})
+}
+
+// importRepo creates repo from the bundle read from r, or the bundle's repo
+// if repo is nil. The repo keeps its description, compression, labels and
+// annotations, and its commits keep their IDs, but not their provenance, as
+// a bundle only holds one repo. If the import fails, the repo is deleted, so
+// that it can be retried.
+func (d *driver) importRepo(ctx context.Context, repo *pfs.Repo, r io.Reader) (retErr error) {
+	b := &bundleReader{r: bufio.NewReader(r)}
+	header, err := b.read()
+	if err != nil {
+		return err
+	}
+	if header.Repo == nil {
+		return fmt.Errorf("invalid bundle: it doesn't start with a repo")
+	}
+	if header.Version != bundleVersion {
+		return fmt.Errorf("bundles of version %d can't be imported, only bundles of version %d", header.Version, bundleVersion)
+	}
+	repoInfo := header.Repo
+	if repo == nil {
+		repo = repoInfo.Repo
+	}
+	if err := d.createRepo(ctx, repo, nil, repoInfo.Description, false, 0, nil, repoInfo.Compression); err != nil {
+		return err
+	}
+	d.featureUsage.inc("import_repo")
+	defer func() {
+		if retErr != nil {
+			if err := d.deleteRepo(ctx, repo, true); err != nil {
+				log.Errorf("error deleting repo %s after it failed to be imported: %v", repo.Name, err)
+			}
+		}
+	}()
+	if len(repoInfo.Labels) > 0 || len(repoInfo.Annotations) > 0 {
+		if err := d.updateRepoMetadata(ctx, repo, repoInfo.Labels, repoInfo.Annotations, nil, nil); err != nil {
+			return err
+		}
+	}
+
+	objects := make(map[string]bool)
+	commits := make(map[string]bool)
+	for {
+		record, err := b.read()
+		if err != nil {
+			return err
+		}
+		switch {
+		case record.Object != nil:
+			object, _, err := d.pachClient.WithCtx(ctx).PutObject(&bundleObjectReader{b: b})
+			if err != nil {
+				return err
+			}
+			if object.Hash != record.Object.Hash {
+				return fmt.Errorf("invalid bundle: the content of object %s has the hash %s", record.Object.Hash, object.Hash)
+			}
+			objects[object.Hash] = true
+		case record.Commit != nil:
+			if err := d.importCommit(ctx, repo, record.Commit, objects, commits); err != nil {
+				return err
+			}
+			commits[record.Commit.Commit.ID] = true
+		case record.Branch != nil:
+			if record.Branch.Head == nil || !commits[record.Branch.Head.ID] {
+				return fmt.Errorf("invalid bundle: the head of branch %s isn't before it", record.Branch.Name)
+			}
+			if _, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+				return d.branches(repo.Name).ReadWrite(stm).Put(record.Branch.Name, &pfs.Commit{
+					Repo: repo,
+					ID:   record.Branch.Head.ID,
+				})
+			}); err != nil {
+				return err
+			}
+		case record.End:
+			// the repo has the same commits, so it's the same size
+			_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+				repos := d.repos.ReadWrite(stm)
+				imported := new(pfs.RepoInfo)
+				if err := repos.Get(repo.Name, imported); err != nil {
+					return err
+				}
+				imported.SizeBytes = repoInfo.SizeBytes
+				imported.LastCommitTime = repoInfo.LastCommitTime
+				return repos.Put(repo.Name, imported)
+			})
+			return err
+		case len(record.Data) > 0:
+			return fmt.Errorf("invalid bundle: it has data outside of an object")
+		default:
+			return fmt.Errorf("invalid bundle: it has an empty record")
+		}
+	}
+}
+
+// importCommit adds commitInfo, read from a bundle, to repo. Its parent and
+// every object it references must have been imported before it.
+func (d *driver) importCommit(ctx context.Context, repo *pfs.Repo, commitInfo *pfs.CommitInfo, objects map[string]bool, commits map[string]bool) error {
+	if commitInfo.Commit == nil || commitInfo.Finished == nil {
+		return fmt.Errorf("invalid bundle: it has a commit that isn't finished")
+	}
+	commitID := commitInfo.Commit.ID
+	if commitInfo.ParentCommit != nil && !commits[commitInfo.ParentCommit.ID] {
+		return fmt.Errorf("invalid bundle: the parent of commit %s isn't before it", commitID)
+	}
+	if commitInfo.Tree != nil {
+		if !objects[commitInfo.Tree.Hash] {
+			return fmt.Errorf("invalid bundle: the tree of commit %s isn't before it", commitID)
+		}
+		var buf bytes.Buffer
+		if err := d.pachClient.WithCtx(ctx).GetObject(commitInfo.Tree.Hash, &buf); err != nil {
+			return err
+		}
+		tree, err := hashtree.DeserializeLazy(buf.Bytes(), d.getTreeChunk)
+		if err != nil {
+			return err
+		}
+		hashes, err := treeObjects(commitInfo.Tree, hashtree.ChunkObjects(tree), tree)
+		if err != nil {
+			return err
+		}
+		for _, hash := range hashes {
+			if !objects[hash] {
+				return fmt.Errorf("invalid bundle: object %s of commit %s isn't before it", hash, commitID)
+			}
+		}
+		if err := d.addObjectRefs(ctx, hashes); err != nil {
+			return err
+		}
+	}
+	commitInfo.Commit = &pfs.Commit{Repo: repo, ID: commitID}
+	if commitInfo.ParentCommit != nil {
+		commitInfo.ParentCommit = &pfs.Commit{Repo: repo, ID: commitInfo.ParentCommit.ID}
+	}
+	if commitInfo.Base != nil {
+		if commits[commitInfo.Base.ID] {
+			commitInfo.Base = &pfs.Commit{Repo: repo, ID: commitInfo.Base.ID}
+		} else {
+			commitInfo.Base = nil
+		}
+	}
+	commitInfo.Provenance = nil
+	commitInfo.Progress = nil
+	commitInfo.Subvenance = nil
+	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		return d.commits(repo.Name).ReadWrite(stm).Create(commitID, commitInfo)
+	})
+	return err
+}
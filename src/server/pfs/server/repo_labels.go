@@ -0,0 +1,179 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+)
+
+// maxLabelLength is the longest that a label or annotation key, or a label
+// value, can be.
+const maxLabelLength = 63
+
+// checkLabelKey returns an error unless key can be the key of a label or an
+// annotation: it's made up of letters, digits, '.', '_', '-' and '/', and
+// starts with a letter or digit.
+func checkLabelKey(key string) error {
+	if key == "" || len(key) > maxLabelLength || !isLabelChars(key, "._-/") || !isAlphanumeric(key[0]) {
+		return fmt.Errorf("invalid label key %q: it must be 1-%d letters, digits, '.', '_', '-' or '/', starting with a letter or digit", key, maxLabelLength)
+	}
+	return nil
+}
+
+// checkLabelValue returns an error unless value can be the value of a label.
+// Values can be selected by, so they can't contain the selector's operators.
+func checkLabelValue(value string) error {
+	if len(value) > maxLabelLength || !isLabelChars(value, "._-") {
+		return fmt.Errorf("invalid label value %q: it must be at most %d letters, digits, '.', '_' or '-'", value, maxLabelLength)
+	}
+	return nil
+}
+
+func isAlphanumeric(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func isLabelChars(s string, punctuation string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isAlphanumeric(s[i]) && strings.IndexByte(punctuation, s[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// labelRequirement is one of the requirements of a label selector, see
+// ListRepoRequest.label_selector.
+type labelRequirement struct {
+	key string
+	// value is only compared if exists is true
+	value  string
+	equals bool
+	// exists is true for key=value, key!=value and key, and false for !key
+	exists bool
+	// any is true for key and !key, which don't compare the value
+	any bool
+}
+
+// labelSelector selects repos by their labels, it's empty if it selects
+// every repo.
+type labelSelector []labelRequirement
+
+// parseLabelSelector parses a label selector, see
+// ListRepoRequest.label_selector.
+func parseLabelSelector(s string) (labelSelector, error) {
+	var selector labelSelector
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		var r labelRequirement
+		switch {
+		case strings.Contains(part, "!="):
+			parts := strings.SplitN(part, "!=", 2)
+			r = labelRequirement{key: parts[0], value: parts[1], exists: true}
+		case strings.Contains(part, "=="):
+			parts := strings.SplitN(part, "==", 2)
+			r = labelRequirement{key: parts[0], value: parts[1], equals: true, exists: true}
+		case strings.Contains(part, "="):
+			parts := strings.SplitN(part, "=", 2)
+			r = labelRequirement{key: parts[0], value: parts[1], equals: true, exists: true}
+		case strings.HasPrefix(part, "!"):
+			r = labelRequirement{key: strings.TrimPrefix(part, "!"), any: true}
+		default:
+			r = labelRequirement{key: part, exists: true, any: true}
+		}
+		r.key, r.value = strings.TrimSpace(r.key), strings.TrimSpace(r.value)
+		if err := checkLabelKey(r.key); err != nil {
+			return nil, fmt.Errorf("invalid label selector %q: %v", s, err)
+		}
+		if err := checkLabelValue(r.value); err != nil {
+			return nil, fmt.Errorf("invalid label selector %q: %v", s, err)
+		}
+		selector = append(selector, r)
+	}
+	return selector, nil
+}
+
+// matches returns true if labels meet every requirement of s. A label that
+// isn't set doesn't equal any value, so it meets key!=value.
+func (s labelSelector) matches(labels map[string]string) bool {
+	for _, r := range s {
+		value, ok := labels[r.key]
+		switch {
+		case r.any:
+			if ok != r.exists {
+				return false
+			}
+		case r.equals:
+			if !ok || value != r.value {
+				return false
+			}
+		default:
+			if ok && value == r.value {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// updateRepoMetadata removes the labels and annotations of repo with the
+// keys in removeLabels and removeAnnotations, and then adds labels and
+// annotations to them, replacing those with the same keys.
+func (d *driver) updateRepoMetadata(ctx context.Context, repo *pfs.Repo, labels map[string]string, annotations map[string]string, removeLabels []string, removeAnnotations []string) error {
+	if err := d.checkIsAuthorized(ctx, repo, auth.Scope_WRITER); err != nil {
+		return err
+	}
+	for key, value := range labels {
+		if err := checkLabelKey(key); err != nil {
+			return err
+		}
+		if err := checkLabelValue(value); err != nil {
+			return err
+		}
+	}
+	for key := range annotations {
+		if err := checkLabelKey(key); err != nil {
+			return err
+		}
+	}
+	if len(labels) > 0 || len(annotations) > 0 {
+		d.featureUsage.inc("repo_metadata")
+	}
+	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		repos := d.repos.ReadWrite(stm)
+		repoInfo := new(pfs.RepoInfo)
+		if err := repos.Get(repo.Name, repoInfo); err != nil {
+			return err
+		}
+		repoInfo.Labels = updateMetadata(repoInfo.Labels, labels, removeLabels)
+		repoInfo.Annotations = updateMetadata(repoInfo.Annotations, annotations, removeAnnotations)
+		return repos.Put(repo.Name, repoInfo)
+	})
+	return err
+}
+
+// updateMetadata returns metadata without the keys in remove, and with add
+// added to it. It's nil if it's empty.
+func updateMetadata(metadata map[string]string, add map[string]string, remove []string) map[string]string {
+	result := make(map[string]string)
+	for key, value := range metadata {
+		result[key] = value
+	}
+	for _, key := range remove {
+		delete(result, key)
+	}
+	for key, value := range add {
+		result[key] = value
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
@@ -0,0 +1,299 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+)
+
+// repoRateSeconds is the number of seconds that the rate of a repo's
+// operations is averaged over.
+const repoRateSeconds = 60
+
+// repoOp is a kind of operation on a repo that its quota can limit.
+type repoOp int
+
+const (
+	repoOpPutFile repoOp = iota
+	repoOpListFile
+)
+
+// opRate counts a kind of operation, so that its rate over the last
+// repoRateSeconds can be measured, and limits the rate with a token bucket
+// that holds up to a second's worth of operations.
+type opRate struct {
+	total int64
+	// counts are the operations in each of the last repoRateSeconds, by the
+	// Unix time of the second, which is in seconds
+	counts  [repoRateSeconds]int64
+	seconds [repoRateSeconds]int64
+
+	tokens     float64
+	lastRefill time.Time
+}
+
+// allow counts an operation at now and returns true, unless the operation
+// would exceed limit operations per second, in which case it isn't counted.
+// limit is ignored if it's 0.
+func (r *opRate) allow(now time.Time, limit float64) bool {
+	if limit > 0 {
+		burst := math.Max(limit, 1)
+		if r.lastRefill.IsZero() {
+			r.tokens = burst
+		} else {
+			r.tokens = math.Min(burst, r.tokens+now.Sub(r.lastRefill).Seconds()*limit)
+		}
+		r.lastRefill = now
+		if r.tokens < 1 {
+			return false
+		}
+		r.tokens--
+	} else {
+		// the bucket starts full if a limit is set again
+		r.lastRefill = time.Time{}
+	}
+	second := now.Unix()
+	i := second % repoRateSeconds
+	if r.seconds[i] != second {
+		r.seconds[i] = second
+		r.counts[i] = 0
+	}
+	r.counts[i]++
+	r.total++
+	return true
+}
+
+// rate returns the average number of operations per second over the last
+// repoRateSeconds before now.
+func (r *opRate) rate(now time.Time) float64 {
+	second := now.Unix()
+	var n int64
+	for i, s := range r.seconds {
+		if second-s < repoRateSeconds {
+			n += r.counts[i]
+		}
+	}
+	return float64(n) / repoRateSeconds
+}
+
+// repoUsage is the usage of one repo.
+type repoUsage struct {
+	putFiles  opRate
+	listFiles opRate
+	watches   int64
+	rejected  int64
+}
+
+// repoUsageTracker tracks the operations on each repo that this pachd
+// serves, see checkRepoQuota. Quotas are enforced by each pachd on its own,
+// so they don't need any coordination.
+type repoUsageTracker struct {
+	mu    sync.Mutex
+	repos map[string]*repoUsage
+}
+
+func newRepoUsageTracker() *repoUsageTracker {
+	return &repoUsageTracker{repos: make(map[string]*repoUsage)}
+}
+
+// get returns repo's usage. t.mu must be held.
+func (t *repoUsageTracker) get(repo string) *repoUsage {
+	usage, ok := t.repos[repo]
+	if !ok {
+		usage = &repoUsage{}
+		t.repos[repo] = usage
+	}
+	return usage
+}
+
+// repoQuota returns repo's quota, which is empty if it doesn't have one.
+func (d *driver) repoQuota(ctx context.Context, repo *pfs.Repo) (*pfs.RepoQuota, error) {
+	quota := &pfs.RepoQuota{}
+	if err := d.repoQuotas.ReadOnly(ctx).Get(repo.Name, quota); err != nil && !col.IsErrNotFound(err) {
+		return nil, err
+	}
+	return quota, nil
+}
+
+// checkRepoQuota counts an operation of kind op on repo, or fails with
+// ErrRepoQuotaExceeded if repo's quota doesn't allow it.
+func (d *driver) checkRepoQuota(ctx context.Context, repo *pfs.Repo, op repoOp) error {
+	quota, err := d.repoQuota(ctx, repo)
+	if err != nil {
+		return err
+	}
+	t := d.repoUsage
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	usage := t.get(repo.Name)
+	now := time.Now()
+	var allowed bool
+	var limit string
+	switch op {
+	case repoOpPutFile:
+		allowed = usage.putFiles.allow(now, quota.PutFilePerSecond)
+		limit = fmt.Sprintf("%g PutFiles per second", quota.PutFilePerSecond)
+	case repoOpListFile:
+		allowed = usage.listFiles.allow(now, quota.ListFilePerSecond)
+		limit = fmt.Sprintf("%g ListFiles per second", quota.ListFilePerSecond)
+	}
+	if !allowed {
+		usage.rejected++
+		d.featureUsage.inc("repo_quota_exceeded")
+		return pfsserver.ErrRepoQuotaExceeded{Repo: repo, Limit: limit}
+	}
+	return nil
+}
+
+// startRepoWatch counts a watch of repo, or fails with ErrRepoQuotaExceeded
+// if repo's quota doesn't allow another one. It returns a function to call
+// once the watch stops.
+func (d *driver) startRepoWatch(ctx context.Context, repo *pfs.Repo) (func(), error) {
+	quota, err := d.repoQuota(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	t := d.repoUsage
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	usage := t.get(repo.Name)
+	if quota.MaxWatches > 0 && usage.watches >= quota.MaxWatches {
+		usage.rejected++
+		d.featureUsage.inc("repo_quota_exceeded")
+		return nil, pfsserver.ErrRepoQuotaExceeded{
+			Repo:  repo,
+			Limit: fmt.Sprintf("%d watches", quota.MaxWatches),
+		}
+	}
+	usage.watches++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			usage.watches--
+		})
+	}, nil
+}
+
+// setRepoQuota sets repo's quota, or removes it if quota is nil. Only
+// cluster admins can set quotas.
+func (d *driver) setRepoQuota(ctx context.Context, repo *pfs.Repo, quota *pfs.RepoQuota) error {
+	d.initializePachConn()
+	whoAmI, err := d.pachClient.AuthAPIClient.WhoAmI(auth.In2Out(ctx), &auth.WhoAmIRequest{})
+	if err != nil && !auth.IsNotActivatedError(err) {
+		return grpcutil.ScrubGRPC(err)
+	} else if err == nil && !whoAmI.IsAdmin {
+		return fmt.Errorf("only cluster admins can set repo quotas")
+	}
+	if quota != nil && (quota.PutFilePerSecond < 0 || quota.ListFilePerSecond < 0 || quota.MaxWatches < 0) {
+		return fmt.Errorf("repo quota limits can't be negative")
+	}
+	if quota != nil {
+		d.featureUsage.inc("repo_quota")
+	}
+	_, err = col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		// Make sure that the repo exists
+		if err := d.repos.ReadWrite(stm).Get(repo.Name, &pfs.RepoInfo{}); err != nil {
+			return err
+		}
+		quotas := d.repoQuotas.ReadWrite(stm)
+		if quota == nil {
+			if err := quotas.Delete(repo.Name); err != nil && !col.IsErrNotFound(err) {
+				return err
+			}
+			return nil
+		}
+		return quotas.Put(repo.Name, quota)
+	})
+	return err
+}
+
+// listRepoUsage returns the usage of each repo that this pachd has served,
+// or that has a quota, busiest first. Only cluster admins can list it, as
+// it's about every repo.
+func (d *driver) listRepoUsage(ctx context.Context) ([]*pfs.RepoUsage, error) {
+	d.initializePachConn()
+	whoAmI, err := d.pachClient.AuthAPIClient.WhoAmI(auth.In2Out(ctx), &auth.WhoAmIRequest{})
+	if err != nil && !auth.IsNotActivatedError(err) {
+		return nil, grpcutil.ScrubGRPC(err)
+	} else if err == nil && !whoAmI.IsAdmin {
+		return nil, fmt.Errorf("only cluster admins can list repo usage")
+	}
+	quotas := make(map[string]*pfs.RepoQuota)
+	iter, err := d.repoQuotas.ReadOnly(ctx).List()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var repoName string
+		quota := &pfs.RepoQuota{}
+		ok, err := iter.Next(&repoName, quota)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		quotas[repoName] = quota
+	}
+
+	var result []*pfs.RepoUsage
+	now := time.Now()
+	t := d.repoUsage
+	t.mu.Lock()
+	for repoName, usage := range t.repos {
+		result = append(result, &pfs.RepoUsage{
+			Repo:              &pfs.Repo{Name: repoName},
+			PutFilePerSecond:  usage.putFiles.rate(now),
+			ListFilePerSecond: usage.listFiles.rate(now),
+			Watches:           usage.watches,
+			PutFiles:          usage.putFiles.total,
+			ListFiles:         usage.listFiles.total,
+			Rejected:          usage.rejected,
+			Quota:             quotas[repoName],
+		})
+	}
+	for repoName, quota := range quotas {
+		if _, ok := t.repos[repoName]; !ok {
+			result = append(result, &pfs.RepoUsage{
+				Repo:  &pfs.Repo{Name: repoName},
+				Quota: quota,
+			})
+		}
+	}
+	t.mu.Unlock()
+
+	// usage is kept for repos that have since been deleted, which are left
+	// out
+	var existing []*pfs.RepoUsage
+	for _, usage := range result {
+		if err := d.repos.ReadOnly(ctx).Get(usage.Repo.Name, &pfs.RepoInfo{}); err != nil {
+			if col.IsErrNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		existing = append(existing, usage)
+	}
+	sort.Slice(existing, func(i, j int) bool {
+		a, b := existing[i], existing[j]
+		if rateA, rateB := a.PutFilePerSecond+a.ListFilePerSecond, b.PutFilePerSecond+b.ListFilePerSecond; rateA != rateB {
+			return rateA > rateB
+		}
+		if a.Watches != b.Watches {
+			return a.Watches > b.Watches
+		}
+		return a.Repo.Name < b.Repo.Name
+	})
+	return existing, nil
+}
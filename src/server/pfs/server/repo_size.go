@@ -0,0 +1,239 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	repoSizeLockPath = "_repo_size_lock"
+
+	// repoSizeInterval is how often the sizes of the repos with
+	// flagAutoRecomputeRepoSize on are recomputed
+	repoSizeInterval = time.Hour
+
+	// repoSizeRetries is how many times a repo's size is recomputed if it
+	// changes while it's being recomputed
+	repoSizeRetries = 3
+)
+
+// errRepoSizeChanged is returned when a repo's size, or one of its branch
+// heads, changes while its size is being recomputed.
+var errRepoSizeChanged = errors.New("the repo changed while its size was being recomputed")
+
+// recomputeRepoSize recomputes the size of repo, or of every repo if it's
+// nil, see RepoSizeChange. It's run as an admin job.
+func (d *driver) recomputeRepoSize(ctx context.Context, repo *pfs.Repo) (*pfs.RecomputeRepoSizeResponse, error) {
+	whoAmI, err := d.pachClient.AuthAPIClient.WhoAmI(auth.In2Out(ctx), &auth.WhoAmIRequest{})
+	if err != nil && !auth.IsNotActivatedError(err) {
+		return nil, grpcutil.ScrubGRPC(err)
+	} else if err == nil && !whoAmI.IsAdmin {
+		return nil, fmt.Errorf("only cluster admins can recompute repo sizes")
+	}
+	d.featureUsage.inc("recompute_repo_size")
+	var names []string
+	if repo != nil {
+		if err := d.repos.ReadOnly(ctx).Get(repo.Name, &pfs.RepoInfo{}); err != nil {
+			return nil, err
+		}
+		names = append(names, repo.Name)
+	} else if names, err = d.repoNames(ctx); err != nil {
+		return nil, err
+	}
+	response := &pfs.RecomputeRepoSizeResponse{}
+	if err := d.runAdminJob(ctx, "recompute_repo_size", func(ctx context.Context, job *adminJob) error {
+		job.setPhase("recomputing repo sizes", int64(len(names)))
+		for _, name := range names {
+			change, err := d.recomputeRepoSizeOf(ctx, name)
+			if err != nil {
+				return err
+			}
+			response.Repos = append(response.Repos, change)
+			job.progress(1)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// repoNames returns the names of every repo.
+func (d *driver) repoNames(ctx context.Context) ([]string, error) {
+	iter, err := d.repos.ReadOnly(ctx).List()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for {
+		var name string
+		repoInfo := new(pfs.RepoInfo)
+		ok, err := iter.Next(&name, repoInfo)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return names, nil
+		}
+		names = append(names, repoInfo.Repo.Name)
+	}
+}
+
+// recomputeRepoSizeOf resets the size of the repo called repoName. If its
+// size, or one of its branch heads, changes while it's being recomputed, it's
+// recomputed again. A branch that's created meanwhile isn't noticed, but it's
+// counted the next time the size is recomputed.
+func (d *driver) recomputeRepoSizeOf(ctx context.Context, repoName string) (*pfs.RepoSizeChange, error) {
+	for i := 0; ; i++ {
+		repoInfo := new(pfs.RepoInfo)
+		if err := d.repos.ReadOnly(ctx).Get(repoName, repoInfo); err != nil {
+			return nil, err
+		}
+		heads, size, err := d.repoSize(ctx, repoName)
+		if err != nil {
+			return nil, err
+		}
+		change := &pfs.RepoSizeChange{
+			Repo:         repoInfo.Repo,
+			OldSizeBytes: repoInfo.SizeBytes,
+			NewSizeBytes: size,
+		}
+		_, err = col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+			repos := d.repos.ReadWrite(stm)
+			current := new(pfs.RepoInfo)
+			if err := repos.Get(repoName, current); err != nil {
+				return err
+			}
+			if current.SizeBytes != change.OldSizeBytes {
+				return errRepoSizeChanged
+			}
+			branches := d.branches(repoName).ReadWrite(stm)
+			for branch, head := range heads {
+				commit := new(pfs.Commit)
+				if err := branches.Get(branch, commit); err != nil {
+					if col.IsErrNotFound(err) {
+						return errRepoSizeChanged
+					}
+					return err
+				}
+				if commit.ID != head {
+					return errRepoSizeChanged
+				}
+			}
+			current.SizeBytes = size
+			return repos.Put(repoName, current)
+		})
+		if err == errRepoSizeChanged && i < repoSizeRetries {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return change, nil
+	}
+}
+
+// repoSize returns the size of the repo called repoName, computed from its
+// branch heads (see RepoSizeChange), and the heads that it's computed from,
+// by branch.
+func (d *driver) repoSize(ctx context.Context, repoName string) (map[string]string, uint64, error) {
+	iter, err := d.branches(repoName).ReadOnly(ctx).List()
+	if err != nil {
+		return nil, 0, err
+	}
+	heads := make(map[string]string)
+	for {
+		var branch string
+		head := new(pfs.Commit)
+		ok, err := iter.Next(&branch, head)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !ok {
+			break
+		}
+		heads[branch] = head.ID
+	}
+	commits := d.commits(repoName).ReadOnly(ctx)
+	counted := make(map[string]bool)
+	seen := make(map[string]bool)
+	var size uint64
+	for _, head := range heads {
+		// an open head counts as its last finished ancestor
+		commitID := head
+		for commitID != "" {
+			commitInfo := new(pfs.CommitInfo)
+			if err := commits.Get(commitID, commitInfo); err != nil {
+				return nil, 0, err
+			}
+			if commitInfo.Finished != nil {
+				break
+			}
+			commitID = ""
+			if commitInfo.ParentCommit != nil {
+				commitID = commitInfo.ParentCommit.ID
+			}
+		}
+		if commitID == "" || counted[commitID] {
+			continue
+		}
+		counted[commitID] = true
+		tree, err := d.getTreeForCommit(ctx, &pfs.Commit{Repo: &pfs.Repo{Name: repoName}, ID: commitID})
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := tree.Walk("/", func(filePath string, node *hashtree.NodeProto) error {
+			if node.FileNode == nil {
+				return nil
+			}
+			key := filePath + "\x00" + string(node.Hash)
+			if !seen[key] {
+				seen[key] = true
+				size += uint64(node.SubtreeSize)
+			}
+			return nil
+		}); err != nil {
+			return nil, 0, err
+		}
+	}
+	return heads, size, nil
+}
+
+// recomputeFlaggedRepoSizes recomputes the sizes of the repos with
+// flagAutoRecomputeRepoSize on. It's run every repoSizeInterval, see
+// startBackground.
+func (d *driver) recomputeFlaggedRepoSizes(ctx context.Context) error {
+	names, err := d.repoNames(ctx)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		on, err := d.flagEnabled(ctx, &pfs.Repo{Name: name}, flagAutoRecomputeRepoSize)
+		if err != nil {
+			return err
+		}
+		if !on {
+			continue
+		}
+		change, err := d.recomputeRepoSizeOf(ctx, name)
+		if err != nil {
+			if col.IsErrNotFound(err) {
+				continue // the repo was deleted
+			}
+			return err
+		}
+		if change.OldSizeBytes != change.NewSizeBytes {
+			log.Infof("the size of repo %s was %d bytes, it's been recomputed as %d bytes", name, change.OldSizeBytes, change.NewSizeBytes)
+		}
+	}
+	return nil
+}
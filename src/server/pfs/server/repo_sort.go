@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+)
+
+// listRepoPage lists the repos selected by request in the order that it asks
+// for, a page at a time, see ListRepoRequest. Repos are sorted and paged
+// before their auth info is read, so that a page only reads its own. All of
+// the pages of a listing are read at the etcd revision of the first one.
+func (d *driver) listRepoPage(ctx context.Context, request *pfs.ListRepoRequest) (*pfs.ListRepoResponse, error) {
+	paginated := request.Limit > 0 || request.PageToken != ""
+	sortBy := request.SortBy
+	if sortBy == pfs.RepoSort_RepoSort_NONE && paginated {
+		sortBy = pfs.RepoSort_RepoSort_NAME
+	}
+	pages, err := newPager(request.PageToken, repoPageFilter(request, sortBy))
+	if err != nil {
+		return nil, err
+	}
+	var rev int64
+	if paginated {
+		if rev, err = pages.revision(ctx, d); err != nil {
+			return nil, err
+		}
+	}
+	repoInfos, err := d.listRepoInfos(ctx, request.Provenance, request.LabelSelector, request.IncludeArchived, rev)
+	if err != nil {
+		return nil, pages.checkCompacted(err)
+	}
+	if sortBy != pfs.RepoSort_RepoSort_NONE {
+		d.featureUsage.inc("list_repo_sort")
+		sortRepos(repoInfos, sortBy, request.Reverse)
+	}
+	response := &pfs.ListRepoResponse{}
+	if request.PageToken != "" {
+		key, name, err := parseRepoPosition(pages.position())
+		if err != nil {
+			return nil, err
+		}
+		// the repos after the token are the first that don't sort before
+		// it, or equal to it
+		i := sort.Search(len(repoInfos), func(i int) bool {
+			return repoLess(key, name, repoSortKey(repoInfos[i], sortBy), repoInfos[i].Repo.Name, request.Reverse)
+		})
+		repoInfos = repoInfos[i:]
+	}
+	if request.Limit > 0 && int64(len(repoInfos)) > request.Limit {
+		repoInfos = repoInfos[:request.Limit]
+		last := repoInfos[len(repoInfos)-1]
+		response.NextPageToken = pages.next(repoPosition(repoSortKey(last, sortBy), last.Repo.Name), "")
+	}
+	if err := d.addRepoAuthInfo(ctx, repoInfos); err != nil {
+		return nil, err
+	}
+	response.RepoInfo = repoInfos
+	return response, nil
+}
+
+// sortRepos sorts repoInfos by sortBy, and then by name.
+func sortRepos(repoInfos []*pfs.RepoInfo, sortBy pfs.RepoSort, reverse bool) {
+	sort.SliceStable(repoInfos, func(i, j int) bool {
+		return repoLess(repoSortKey(repoInfos[i], sortBy), repoInfos[i].Repo.Name,
+			repoSortKey(repoInfos[j], sortBy), repoInfos[j].Repo.Name, reverse)
+	})
+}
+
+// repoLess returns true if the repo with key a and name aName sorts before
+// the one with key b and name bName.
+func repoLess(a int64, aName string, b int64, bName string, reverse bool) bool {
+	if reverse {
+		a, aName, b, bName = b, bName, a, aName
+	}
+	if a != b {
+		return a < b
+	}
+	return aName < bName
+}
+
+// repoSortKey returns the key that repoInfo is sorted by before its name,
+// which is 0 when it's sorted by name alone.
+func repoSortKey(repoInfo *pfs.RepoInfo, sortBy pfs.RepoSort) int64 {
+	switch sortBy {
+	case pfs.RepoSort_RepoSort_SIZE:
+		return int64(repoInfo.SizeBytes)
+	case pfs.RepoSort_RepoSort_CREATED:
+		return timestampNanos(repoInfo.Created)
+	case pfs.RepoSort_RepoSort_LAST_COMMIT:
+		return timestampNanos(repoInfo.LastCommitTime)
+	default:
+		return 0
+	}
+}
+
+// timestampNanos returns t in nanoseconds since the epoch, 0 if it's unset.
+func timestampNanos(t *types.Timestamp) int64 {
+	if t == nil {
+		return 0
+	}
+	goTime, err := types.TimestampFromProto(t)
+	if err != nil {
+		return 0
+	}
+	return goTime.UnixNano()
+}
+
+// repoPageFilter returns the page filter of a ListRepo request whose repos
+// are sorted by sortBy.
+func repoPageFilter(request *pfs.ListRepoRequest, sortBy pfs.RepoSort) string {
+	var provenance []string
+	for _, prov := range request.Provenance {
+		provenance = append(provenance, prov.Name)
+	}
+	sort.Strings(provenance)
+	return pageFilter("ListRepo", strings.Join(provenance, ","), request.LabelSelector,
+		strconv.FormatBool(request.IncludeArchived), sortBy.String(), strconv.FormatBool(request.Reverse))
+}
+
+// The position of a repo in a page token is its sort key and name, so the
+// next page starts at the same place even if that repo is deleted.
+func repoPosition(key int64, name string) string {
+	return fmt.Sprintf("%d/%s", key, name)
+}
+
+func parseRepoPosition(position string) (int64, string, error) {
+	parts := strings.SplitN(position, "/", 2)
+	if len(parts) != 2 {
+		return 0, "", fmt.Errorf("invalid repo position %q in page token", position)
+	}
+	key, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid repo position %q in page token", position)
+	}
+	return key, parts[1], nil
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+)
+
+// normalizeResidency returns the residency tags in tags, sorted and without
+// duplicates.
+func normalizeResidency(tags []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var result []string
+	for _, tag := range tags {
+		if tag == "" || strings.IndexFunc(tag, func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		}) >= 0 {
+			return nil, fmt.Errorf("invalid residency tag %q: it must be non-empty, without commas or spaces", tag)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			result = append(result, tag)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// checkResidency returns an error unless this cluster satisfies every one of
+// tags, the residency tags of the data of what, e.g. a repo.
+func (d *driver) checkResidency(what string, tags []string) error {
+	satisfied := make(map[string]bool)
+	for _, tag := range d.residency {
+		satisfied[tag] = true
+	}
+	var unsatisfied []string
+	for _, tag := range tags {
+		if !satisfied[tag] {
+			unsatisfied = append(unsatisfied, tag)
+		}
+	}
+	if len(unsatisfied) > 0 {
+		return fmt.Errorf("the data of %s can't be placed on this cluster, as it's tagged [%s] and the cluster's residency is [%s] (see PFS_RESIDENCY)",
+			what, strings.Join(unsatisfied, ", "), strings.Join(d.residency, ", "))
+	}
+	return nil
+}
+
+func (d *driver) setResidency(ctx context.Context, repo *pfs.Repo, tags []string) error {
+	if err := d.checkIsAuthorized(ctx, repo, auth.Scope_OWNER); err != nil {
+		return err
+	}
+	residency, err := normalizeResidency(tags)
+	if err != nil {
+		return err
+	}
+	// the repo's data is already on this cluster, so it can't be given tags
+	// that the cluster doesn't satisfy
+	if err := d.checkResidency("repo "+repo.Name, residency); err != nil {
+		return err
+	}
+	if len(residency) > 0 {
+		d.featureUsage.inc("residency")
+	}
+	_, err = col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		repos := d.repos.ReadWrite(stm)
+		repoInfo := new(pfs.RepoInfo)
+		if err := repos.Get(repo.Name, repoInfo); err != nil {
+			return err
+		}
+		repoInfo.Residency = residency
+		return repos.Put(repo.Name, repoInfo)
+	})
+	return err
+}
@@ -0,0 +1,353 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+
+	"github.com/gogo/protobuf/types"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	retentionLockPath = "_retention_lock"
+
+	// retentionPollInterval is how often retention policies are enforced
+	retentionPollInterval = time.Minute
+
+	// retentionPlansKept is how many of the latest plans that had actions
+	// are kept in a RetentionPolicyInfo
+	retentionPlansKept = 10
+)
+
+func (d *driver) setRetentionPolicy(ctx context.Context, repo *pfs.Repo, policy *pfs.RetentionPolicy) error {
+	// plans are carried out with the caller's credentials, so only owners
+	// can set a policy
+	if err := d.checkIsAuthorized(ctx, repo, auth.Scope_OWNER); err != nil {
+		return err
+	}
+	var capability string
+	if policy != nil {
+		if policy.ExpireIdleBranchSeconds < 0 || policy.KeepDeletedHeadSeconds < 0 {
+			return fmt.Errorf("the durations of a retention policy can't be negative")
+		}
+		d.featureUsage.inc("retention_policy")
+		resp, err := d.pachClient.AuthAPIClient.GetCapability(auth.In2Out(ctx), &auth.GetCapabilityRequest{})
+		if err != nil && !auth.IsNotActivatedError(err) {
+			return grpcutil.ScrubGRPC(err)
+		} else if err == nil {
+			capability = resp.Capability
+		}
+	}
+	var oldCapability string
+	if _, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		oldCapability = ""
+		// Make sure that the repo exists
+		if err := d.repos.ReadWrite(stm).Get(repo.Name, &pfs.RepoInfo{}); err != nil {
+			return err
+		}
+		policies := d.retentionPolicies.ReadWrite(stm)
+		policyInfo := &pfs.RetentionPolicyInfo{}
+		if err := policies.Get(repo.Name, policyInfo); err != nil && !col.IsErrNotFound(err) {
+			return err
+		}
+		oldCapability = policyInfo.Capability
+		if policy == nil {
+			if err := policies.Delete(repo.Name); err != nil && !col.IsErrNotFound(err) {
+				return err
+			}
+			return nil
+		}
+		// the kept heads and the record of past plans carry over
+		return policies.Put(repo.Name, &pfs.RetentionPolicyInfo{
+			Repo:         repo,
+			Policy:       policy,
+			DeletedHeads: policyInfo.DeletedHeads,
+			Plans:        policyInfo.Plans,
+			Capability:   capability,
+		})
+	}); err != nil {
+		// the new capability is unused, revoking it is best effort as err
+		// is the more useful error to return
+		d.revokeCapability(ctx, capability)
+		return err
+	}
+	return d.revokeCapability(ctx, oldCapability)
+}
+
+func (d *driver) inspectRetentionPolicy(ctx context.Context, repo *pfs.Repo) (*pfs.RetentionPolicyInfo, error) {
+	if err := d.checkIsAuthorized(ctx, repo, auth.Scope_READER); err != nil {
+		return nil, err
+	}
+	policyInfo := &pfs.RetentionPolicyInfo{}
+	if err := d.retentionPolicies.ReadOnly(ctx).Get(repo.Name, policyInfo); err != nil {
+		return nil, err
+	}
+	policyInfo.Capability = ""
+	return policyInfo, nil
+}
+
+// planRetention returns the plan that would enforce repo's retention policy
+// now.
+func (d *driver) planRetention(ctx context.Context, repo *pfs.Repo) (*pfs.RetentionPlan, error) {
+	if err := d.checkIsAuthorized(ctx, repo, auth.Scope_READER); err != nil {
+		return nil, err
+	}
+	policyInfo := &pfs.RetentionPolicyInfo{}
+	if err := d.retentionPolicies.ReadOnly(ctx).Get(repo.Name, policyInfo); err != nil {
+		return nil, err
+	}
+	return d.retentionPlan(ctx, policyInfo)
+}
+
+// retentionPlan returns the actions that enforce policyInfo now: expiring
+// the branches that it applies to which have been idle for too long, and
+// dropping the deleted heads that have been kept for long enough.
+func (d *driver) retentionPlan(ctx context.Context, policyInfo *pfs.RetentionPolicyInfo) (*pfs.RetentionPlan, error) {
+	repo := policyInfo.Repo
+	policy := policyInfo.Policy
+	plan := &pfs.RetentionPlan{Planned: now()}
+	if policy.ExpireIdleBranchSeconds > 0 {
+		maxIdle := time.Duration(policy.ExpireIdleBranchSeconds) * time.Second
+		heads := make(map[string]*pfs.Commit)
+		iter, err := d.branches(repo.Name).ReadOnly(ctx).List()
+		if err != nil {
+			return nil, err
+		}
+		for {
+			var branch string
+			head := &pfs.Commit{}
+			ok, err := iter.Next(&branch, head)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				break
+			}
+			branch = path.Base(branch)
+			if len(policy.Branches) == 0 || containsString(policy.Branches, branch) {
+				heads[branch] = head
+			}
+		}
+		var branches []string
+		for branch := range heads {
+			branches = append(branches, branch)
+		}
+		sort.Strings(branches)
+		for _, branch := range branches {
+			commitInfo := &pfs.CommitInfo{}
+			if err := d.commits(repo.Name).ReadOnly(ctx).Get(heads[branch].ID, commitInfo); err != nil {
+				return nil, err
+			}
+			if commitInfo.Finished == nil {
+				continue
+			}
+			finished, err := types.TimestampFromProto(commitInfo.Finished)
+			if err != nil {
+				return nil, err
+			}
+			if idle := time.Since(finished); idle > maxIdle {
+				plan.Actions = append(plan.Actions, &pfs.RetentionAction{
+					Type:   pfs.RetentionAction_EXPIRE_BRANCH,
+					Branch: branch,
+					Head:   commitInfo.Commit,
+					Reason: fmt.Sprintf("its head was finished %v ago, the limit is %v", idle.Round(time.Second), maxIdle),
+				})
+			}
+		}
+	}
+	keep := time.Duration(policy.KeepDeletedHeadSeconds) * time.Second
+	for _, deletedHead := range policyInfo.DeletedHeads {
+		deleted, err := types.TimestampFromProto(deletedHead.Deleted)
+		if err != nil {
+			return nil, err
+		}
+		reason := fmt.Sprintf("it was deleted %v ago, heads are kept for %v", time.Since(deleted).Round(time.Second), keep)
+		if keep == 0 {
+			reason = "the policy doesn't keep deleted heads"
+		} else if time.Since(deleted) <= keep {
+			continue
+		}
+		plan.Actions = append(plan.Actions, &pfs.RetentionAction{
+			Type:   pfs.RetentionAction_DROP_DELETED_HEAD,
+			Branch: deletedHead.Branch,
+			Head:   deletedHead.Head,
+			Reason: reason,
+		})
+	}
+	return plan, nil
+}
+
+// keepDeletedHead keeps head, the head of branch, which is being deleted in
+// stm, if repo's retention policy keeps deleted heads.
+func (d *driver) keepDeletedHead(stm col.STM, repo *pfs.Repo, branch string, head *pfs.Commit) error {
+	policies := d.retentionPolicies.ReadWrite(stm)
+	policyInfo := &pfs.RetentionPolicyInfo{}
+	if err := policies.Get(repo.Name, policyInfo); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	policy := policyInfo.Policy
+	if policy.KeepDeletedHeadSeconds <= 0 || (len(policy.Branches) > 0 && !containsString(policy.Branches, branch)) {
+		return nil
+	}
+	policyInfo.DeletedHeads = append(policyInfo.DeletedHeads, &pfs.DeletedHead{
+		Branch:  branch,
+		Head:    head,
+		Deleted: now(),
+	})
+	return policies.Put(repo.Name, policyInfo)
+}
+
+// enforceRetention makes one pass over every repo's retention policy.
+func (d *driver) enforceRetention(ctx context.Context) error {
+	iter, err := d.retentionPolicies.ReadOnly(ctx).List()
+	if err != nil {
+		return err
+	}
+	var policyInfos []*pfs.RetentionPolicyInfo
+	for {
+		var repo string
+		policyInfo := &pfs.RetentionPolicyInfo{}
+		ok, err := iter.Next(&repo, policyInfo)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		policyInfos = append(policyInfos, policyInfo)
+	}
+	for _, policyInfo := range policyInfos {
+		if err := d.enforceRepoRetention(ctx, policyInfo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enforceRepoRetention plans the enforcement of policyInfo and, unless it's
+// a dry run, carries the plan out. The plan is recorded before any of its
+// actions are taken, and again with their errors once they have been. A
+// failed action is recorded rather than returned, so that one broken branch
+// doesn't hold up the others.
+func (d *driver) enforceRepoRetention(ctx context.Context, policyInfo *pfs.RetentionPolicyInfo) error {
+	repo := policyInfo.Repo
+	plan, err := d.retentionPlan(ctx, policyInfo)
+	if err != nil {
+		return err
+	}
+	if len(plan.Actions) == 0 {
+		return nil
+	}
+	// a dry run's plan is only recorded when it changes, so that the record
+	// isn't filled with the same plan every poll
+	if policyInfo.Policy.DryRun && len(policyInfo.Plans) > 0 && sameRetentionActions(policyInfo.Plans[len(policyInfo.Plans)-1], plan) {
+		return nil
+	}
+	plan.Executed = !policyInfo.Policy.DryRun
+	if err := d.recordRetentionPlan(ctx, repo, plan); err != nil {
+		return err
+	}
+	if !plan.Executed {
+		return nil
+	}
+
+	// plans are carried out with the credentials of the user that set the
+	// policy
+	userCtx := ctx
+	if policyInfo.Capability != "" {
+		userCtx = metadata.NewIncomingContext(ctx, metadata.Pairs(auth.ContextTokenKey, policyInfo.Capability))
+	}
+	for _, action := range plan.Actions {
+		var err error
+		switch action.Type {
+		case pfs.RetentionAction_EXPIRE_BRANCH:
+			err = d.expireBranch(userCtx, repo, action.Branch, action.Head)
+		case pfs.RetentionAction_DROP_DELETED_HEAD:
+			err = d.dropDeletedHead(ctx, repo, action.Branch, action.Head)
+		}
+		if err != nil {
+			log.Errorf("error enforcing the retention policy of repo %s on branch %s: %v", repo.Name, action.Branch, err)
+			action.Error = err.Error()
+		}
+	}
+	return d.recordRetentionPlan(ctx, repo, plan)
+}
+
+// recordRetentionPlan records plan in repo's retention policy, replacing the
+// plan made at the same time if it's already recorded.
+func (d *driver) recordRetentionPlan(ctx context.Context, repo *pfs.Repo, plan *pfs.RetentionPlan) error {
+	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		policies := d.retentionPolicies.ReadWrite(stm)
+		current := &pfs.RetentionPolicyInfo{}
+		if err := policies.Get(repo.Name, current); err != nil {
+			if col.IsErrNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		if n := len(current.Plans); n > 0 && current.Plans[n-1].Planned.Equal(plan.Planned) {
+			current.Plans[n-1] = plan
+		} else {
+			current.Plans = append(current.Plans, plan)
+		}
+		if len(current.Plans) > retentionPlansKept {
+			current.Plans = current.Plans[len(current.Plans)-retentionPlansKept:]
+		}
+		return policies.Put(repo.Name, current)
+	})
+	return err
+}
+
+// sameRetentionActions returns true if a and b plan the same actions.
+func sameRetentionActions(a *pfs.RetentionPlan, b *pfs.RetentionPlan) bool {
+	if len(a.Actions) != len(b.Actions) {
+		return false
+	}
+	for i := range a.Actions {
+		if a.Actions[i].Type != b.Actions[i].Type || a.Actions[i].Branch != b.Actions[i].Branch ||
+			a.Actions[i].Head.ID != b.Actions[i].Head.ID {
+			return false
+		}
+	}
+	return true
+}
+
+// expireBranch deletes branch, unless its head has moved from head since the
+// plan to expire it was made.
+func (d *driver) expireBranch(ctx context.Context, repo *pfs.Repo, branch string, head *pfs.Commit) error {
+	return d.deleteBranch(ctx, repo, branch, head)
+}
+
+// dropDeletedHead stops keeping head, the head of the deleted branch, so it
+// can't be restored from the retention policy anymore. Only the record of it
+// is dropped: like the head of any deleted branch, the commit stays in the
+// repo, and its objects stay referenced, until it's deleted.
+func (d *driver) dropDeletedHead(ctx context.Context, repo *pfs.Repo, branch string, head *pfs.Commit) error {
+	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		policies := d.retentionPolicies.ReadWrite(stm)
+		current := &pfs.RetentionPolicyInfo{}
+		if err := policies.Get(repo.Name, current); err != nil {
+			return err
+		}
+		for i, deletedHead := range current.DeletedHeads {
+			if deletedHead.Branch == branch && deletedHead.Head.ID == head.ID {
+				current.DeletedHeads = append(current.DeletedHeads[:i], current.DeletedHeads[i+1:]...)
+				return policies.Put(repo.Name, current)
+			}
+		}
+		return nil
+	})
+	return err
+}
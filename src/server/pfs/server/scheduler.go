@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// batchMaxWait is the longest that a batch operation yields to interactive
+// ones, after which it runs regardless, so that sustained load doesn't starve
+// it.
+const batchMaxWait = time.Minute
+
+// scheduler is shared by the driver's operations. Interactive operations
+// always run, but batch operations wait while interactiveLoad or more
+// interactive operations are running, and while batchConcurrency batch
+// operations are already running. Either limit is off if it's 0, and a nil
+// scheduler doesn't limit anything.
+type scheduler struct {
+	interactiveLoad  int64
+	batchConcurrency int64
+
+	mu          sync.Mutex
+	interactive int64
+	batch       int64
+	// changed is closed, and replaced, whenever an operation finishes
+	changed chan struct{}
+}
+
+func newScheduler(interactiveLoad int64, batchConcurrency int64) *scheduler {
+	if interactiveLoad <= 0 && batchConcurrency <= 0 {
+		return nil
+	}
+	return &scheduler{
+		interactiveLoad:  interactiveLoad,
+		batchConcurrency: batchConcurrency,
+		changed:          make(chan struct{}),
+	}
+}
+
+// start waits until an operation of priority can run, or ctx is done, and
+// returns a function to call once the operation is done.
+func (s *scheduler) start(ctx context.Context, priority pfs.Priority) (func(), error) {
+	if s == nil {
+		return func() {}, nil
+	}
+	var yielded <-chan time.Time
+	if priority == pfs.Priority_BATCH {
+		timer := time.NewTimer(batchMaxWait)
+		defer timer.Stop()
+		yielded = timer.C
+	}
+	yield := true
+	s.mu.Lock()
+	for priority == pfs.Priority_BATCH && s.batchMustWait(yield) {
+		changed := s.changed
+		s.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-yielded:
+			// the load hasn't let up in time, but the concurrency limit
+			// still holds
+			yield = false
+		case <-changed:
+		}
+		s.mu.Lock()
+	}
+	return s.run(priority), nil
+}
+
+// batchMustWait returns true if a batch operation can't run yet, because
+// batchConcurrency batch operations are running or, if it yields, because
+// pachd is under interactive load. s.mu must be held.
+func (s *scheduler) batchMustWait(yield bool) bool {
+	if s.batchConcurrency > 0 && s.batch >= s.batchConcurrency {
+		return true
+	}
+	return yield && s.interactiveLoad > 0 && s.interactive >= s.interactiveLoad
+}
+
+// run counts an operation of priority as running and returns the function
+// that stops counting it. s.mu must be held, and is released.
+func (s *scheduler) run(priority pfs.Priority) func() {
+	count := &s.interactive
+	if priority == pfs.Priority_BATCH {
+		count = &s.batch
+	}
+	*count++
+	s.mu.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			*count--
+			close(s.changed)
+			s.changed = make(chan struct{})
+		})
+	}
+}
+
+// scheduledKey is the context key of the priority of the operation that's
+// been scheduled, see schedule.
+type scheduledKey struct{}
+
+// schedule waits until an operation can run, see scheduler.start, and returns
+// the context to run it with. Operations run with that context don't wait
+// again, so that an operation which calls another doesn't wait on its own
+// slot. An operation's priority is defaultPriority, unless ctx's request is
+// tagged with another one, see requestPriority.
+func (d *driver) schedule(ctx context.Context, defaultPriority pfs.Priority) (context.Context, func(), error) {
+	if _, ok := ctx.Value(scheduledKey{}).(pfs.Priority); ok {
+		return ctx, func() {}, nil
+	}
+	priority, err := d.requestPriority(ctx, defaultPriority)
+	if err != nil {
+		return nil, nil, err
+	}
+	done, err := d.scheduler.start(ctx, priority)
+	if err != nil {
+		return nil, nil, err
+	}
+	return context.WithValue(ctx, scheduledKey{}, priority), done, nil
+}
+
+// requestPriority returns the priority that ctx's request is tagged with, or
+// defaultPriority if it isn't tagged. Any caller can lower the priority of
+// its requests, but only cluster admins can raise it, so that batch work
+// can't be passed off as interactive.
+func (d *driver) requestPriority(ctx context.Context, defaultPriority pfs.Priority) (pfs.Priority, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md[pfs.PriorityKey]) == 0 {
+		return defaultPriority, nil
+	}
+	p, ok := pfs.Priority_value[md[pfs.PriorityKey][0]]
+	if !ok || pfs.Priority(p) == defaultPriority {
+		return defaultPriority, nil
+	}
+	if pfs.Priority(p) == pfs.Priority_BATCH {
+		return pfs.Priority_BATCH, nil
+	}
+	whoAmI, err := d.pachClient.AuthAPIClient.WhoAmI(auth.In2Out(ctx), &auth.WhoAmIRequest{})
+	if err != nil && !auth.IsNotActivatedError(err) {
+		return 0, grpcutil.ScrubGRPC(err)
+	} else if err == nil && !whoAmI.IsAdmin {
+		return defaultPriority, nil
+	}
+	return pfs.Priority(p), nil
+}
+
+// listPriority returns the default priority of a listing with limit. Paged
+// listings are interactive, but listings of every file are batch work.
+func listPriority(limit int64) pfs.Priority {
+	if limit > 0 {
+		return pfs.Priority_INTERACTIVE
+	}
+	return pfs.Priority_BATCH
+}
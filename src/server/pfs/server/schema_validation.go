@@ -0,0 +1,150 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+)
+
+// avroMagic is the header that every Avro object container file starts with.
+var avroMagic = []byte{'O', 'b', 'j', 1}
+
+// validateSchemas checks the files in tree that were written since
+// parentTree against the schemas that apply to them (see SetSchema), so that
+// a commit can't be finished with files that don't match their schemas.
+// Files under a JSON_SCHEMA schema have to be streams of JSON values that
+// each match it, the schema is fetched from its url once per commit. Files
+// under an AVRO schema have to be Avro object container files, which hold
+// the schema they were written with, so only their header is checked.
+// Protobuf data isn't self-describing, so files under a PROTOBUF schema
+// aren't checked.
+func (d *driver) validateSchemas(ctx context.Context, repo *pfs.Repo, tree hashtree.OpenHashTree, parentTree hashtree.HashTree) error {
+	repoSchemas := &pfs.RepoSchemas{}
+	if err := d.schemas.ReadOnly(ctx).Get(repo.Name, repoSchemas); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if len(repoSchemas.SchemaInfo) == 0 {
+		return nil
+	}
+	jsonSchemas := make(map[string]*jsonSchema)
+	if err := tree.Walk("/", func(walkPath string, node *hashtree.NodeProto) error {
+		if node.FileNode == nil {
+			return nil
+		}
+		schema := schemaFor(repoSchemas, walkPath)
+		if schema == nil || schema.Type == pfs.SchemaType_PROTOBUF {
+			return nil
+		}
+		if parentNode, err := parentTree.Get(walkPath); err == nil && parentNode.FileNode != nil &&
+			sameObjects(parentNode.FileNode.Objects, node.FileNode.Objects) {
+			return nil
+		}
+		if err := d.validateFile(ctx, schema, node.FileNode.Objects, jsonSchemas); err != nil {
+			return fmt.Errorf("%s doesn't match its schema %s: %v", walkPath, schema.Url, err)
+		}
+		return nil
+	}); err != nil {
+		// the tree is empty
+		if hashtree.Code(err) == hashtree.PathNotFound {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// validateFile checks the file made up of objects against schema.
+// jsonSchemas caches the JSON schemas that have been fetched, by url.
+func (d *driver) validateFile(ctx context.Context, schema *pfs.Schema, objects []*pfs.Object, jsonSchemas map[string]*jsonSchema) error {
+	var s *jsonSchema
+	if schema.Type == pfs.SchemaType_JSON_SCHEMA {
+		var ok bool
+		if s, ok = jsonSchemas[schema.Url]; !ok {
+			var err error
+			if s, err = fetchJSONSchema(ctx, schema.Url); err != nil {
+				return err
+			}
+			jsonSchemas[schema.Url] = s
+		}
+	}
+	var r io.Reader = &bytes.Buffer{}
+	if len(objects) > 0 {
+		// the file may not be read to its end, cancelling ctx and closing r
+		// stops it from being fetched
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		var err error
+		if r, err = d.objectsReader(ctx, objects, 0, 0); err != nil {
+			return err
+		}
+		if closer, ok := r.(io.Closer); ok {
+			defer closer.Close()
+		}
+	}
+	switch schema.Type {
+	case pfs.SchemaType_AVRO:
+		magic := make([]byte, len(avroMagic))
+		if _, err := io.ReadFull(r, magic); err != nil || !bytes.Equal(magic, avroMagic) {
+			return fmt.Errorf("it isn't an Avro object container file")
+		}
+	case pfs.SchemaType_JSON_SCHEMA:
+		decoder := json.NewDecoder(r)
+		for i := 1; ; i++ {
+			var record json.RawMessage
+			if err := decoder.Decode(&record); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return fmt.Errorf("record %d is malformed JSON: %v", i, err)
+			}
+			if err := s.validate(record); err != nil {
+				return fmt.Errorf("record %d %v", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// fetchJSONSchema fetches and compiles the JSON Schema at url, which can be
+// any url that PutFile can put, see putFileURL.
+func fetchJSONSchema(ctx context.Context, url string) (*jsonSchema, error) {
+	fetcher, err := newURLFetcher(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := fetcher.open(ctx, fetcher.root())
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("the schema at %s is empty", url)
+	}
+	return parseJSONSchema(pfs.Delimiter_JSON, data)
+}
+
+func sameObjects(a []*pfs.Object, b []*pfs.Object) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Hash != b[i].Hash {
+			return false
+		}
+	}
+	return true
+}
@@ -2,6 +2,8 @@ package server
 
 import (
 	pfsclient "github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pkg/metrics"
+	"github.com/pachyderm/pachyderm/src/server/pkg/throttle"
 )
 
 // Valid object storage backends
@@ -25,8 +27,18 @@ type BlockAPIServer interface {
 
 // NewAPIServer creates an APIServer.
 // cacheSize is the number of commit trees which will be cached in the server.
-func NewAPIServer(address string, etcdAddresses []string, etcdPrefix string, cacheSize int64) (APIServer, error) {
-	return newAPIServer(address, etcdAddresses, etcdPrefix, cacheSize)
+// maxProvenanceDepth is the length of the longest chain of provenance a repo
+// can have, or 0 for the default.
+// transferThrottle limits the rate at which proxy repos fetch data from other
+// clusters, it may be nil, in which case the rate isn't limited.
+// interactiveLoad is the number of running interactive operations at which
+// batch operations yield to them, and batchConcurrency is the most batch
+// operations that run at once, either is unlimited if it's 0.
+// featureReporter may be nil, in which case feature usage isn't reported.
+// residency are the residency tags that the cluster satisfies, e.g.
+// "eu-only", the data of repos with other tags can't be placed on it.
+func NewAPIServer(address string, etcdAddresses []string, etcdPrefix string, cacheSize int64, maxProvenanceDepth int64, transferThrottle *throttle.Throttle, interactiveLoad int64, batchConcurrency int64, featureReporter *metrics.FeatureReporter, residency []string) (APIServer, error) {
+	return newAPIServer(address, etcdAddresses, etcdPrefix, cacheSize, maxProvenanceDepth, transferThrottle, interactiveLoad, batchConcurrency, featureReporter, residency)
 }
 
 // NewHTTPServer creates an APIServer.
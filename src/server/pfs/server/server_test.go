@@ -1,11 +1,19 @@
 package server
 
 import (
+	"archive/tar"
 	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
 	"path/filepath"
@@ -15,6 +23,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gogo/protobuf/jsonpb"
 	"github.com/gogo/protobuf/types"
 	pclient "github.com/pachyderm/pachyderm/src/client"
 	"github.com/pachyderm/pachyderm/src/client/auth"
@@ -24,11 +33,13 @@ import (
 	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
 	"github.com/pachyderm/pachyderm/src/client/version"
 	authtesting "github.com/pachyderm/pachyderm/src/server/auth/testing"
+	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
 	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
 	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
 	pfssync "github.com/pachyderm/pachyderm/src/server/pkg/sync"
 
 	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/hashicorp/golang-lru"
 	"golang.org/x/net/context"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
@@ -2115,6 +2126,30 @@ func TestPutFileNullCharacter(t *testing.T) {
 	require.YesError(t, err)
 }
 
+func TestPutFileUnpullableName(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+	c := getClient(t)
+
+	repo := uniqueString("TestPutFileUnpullableName")
+	require.NoError(t, c.CreateRepo(repo))
+
+	commit, err := c.StartCommit(repo, "")
+	require.NoError(t, err)
+
+	// names that can only be pulled once they're translated are fine
+	_, err = c.PutFile(repo, commit.ID, `dir/a\b:c`, strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	// names that are too long to be pulled at all aren't
+	_, err = c.PutFile(repo, commit.ID, "dir/"+strings.Repeat("a", 256), strings.NewReader("foo\n"))
+	require.YesError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "dir/"+strings.Repeat(":", 100), strings.NewReader("foo\n"))
+	require.YesError(t, err)
+	require.YesError(t, c.CopyFile(repo, commit.ID, "dir/a\\b:c", repo, commit.ID, strings.Repeat("b", 256), false))
+}
+
 func TestPutFileURL(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
@@ -2184,6 +2219,62 @@ func TestStartCommitLatestOnBranch(t *testing.T) {
 	require.Equal(t, commit3.ID, commitInfo.Commit.ID)
 }
 
+func TestStartCommitFromBase(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestStartCommitFromBase")
+	require.NoError(t, c.CreateRepo(repo))
+	commit1, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit1.ID, "a", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit1.ID))
+	commit2, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit2.ID, "b", strings.NewReader("bar\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit2.ID))
+
+	// the commit is on master, after commit2, but starts with commit1's
+	// content
+	commit3, err := c.StartCommitFromBase(repo, "master", "", commit1.ID)
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit3.ID, "c", strings.NewReader("baz\n"))
+	require.NoError(t, err)
+	fileInfos, err := c.ListFile(repo, commit3.ID, "")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(fileInfos))
+	require.NoError(t, c.FinishCommit(repo, commit3.ID))
+
+	commitInfo, err := c.InspectCommit(repo, "master")
+	require.NoError(t, err)
+	require.Equal(t, commit3.ID, commitInfo.Commit.ID)
+	require.Equal(t, commit2.ID, commitInfo.ParentCommit.ID)
+	require.Equal(t, commit1.ID, commitInfo.Base.ID)
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(repo, commit3.ID, "a", 0, 0, &buffer))
+	require.Equal(t, "foo\n", buffer.String())
+	_, err = c.InspectFile(repo, commit3.ID, "b")
+	require.YesError(t, err)
+
+	// the base must be a finished commit in the same repo
+	commit4, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.StartCommitFromBase(repo, "other", "", commit4.ID)
+	require.YesError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit4.ID))
+	_, err = c.PfsAPIClient.StartCommit(context.Background(), &pfs.StartCommitRequest{
+		Parent: pclient.NewCommit(repo, ""),
+		Branch: "other",
+		Base:   pclient.NewCommit(uniqueString("otherRepo"), "master"),
+	})
+	require.YesError(t, err)
+}
+
 func TestSetBranchTwice(t *testing.T) {
 	t.Parallel()
 	client := getClient(t)
@@ -2592,558 +2683,5142 @@ func TestFlushCommitWithNoDownstreamRepos(t *testing.T) {
 	require.Equal(t, 0, len(commitInfos))
 }
 
-func TestFlushOpenCommit(t *testing.T) {
+func TestRepoLabels(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
 	t.Parallel()
-	client := getClient(t)
-	require.NoError(t, client.CreateRepo("A"))
-	_, err := client.PfsAPIClient.CreateRepo(context.Background(), &pfs.CreateRepoRequest{
-		Repo:       pclient.NewRepo("B"),
-		Provenance: []*pfs.Repo{pclient.NewRepo("A")},
-	})
-	ACommit, err := client.StartCommit("A", "")
-	require.NoError(t, err)
 
-	// do the other commits in a goro so we can block for them
-	go func() {
-		time.Sleep(5 * time.Second)
-		require.NoError(t, client.FinishCommit("A", ACommit.ID))
-		BCommit, err := client.PfsAPIClient.StartCommit(
-			context.Background(),
-			&pfs.StartCommitRequest{
-				Parent:     pclient.NewCommit("B", ""),
-				Provenance: []*pfs.Commit{ACommit},
-			},
-		)
+	c := getClient(t)
+	// other tests' repos may be labelled too, so the team is unique
+	team := uniqueString("team")
+	repo1 := uniqueString("TestRepoLabels1")
+	repo2 := uniqueString("TestRepoLabels2")
+	require.NoError(t, c.CreateRepo(repo1))
+	require.NoError(t, c.CreateRepo(repo2))
+	require.NoError(t, c.UpdateRepoMetadata(repo1, map[string]string{"team": team, "tier": "gold"}, map[string]string{"owner": "jdoe@example.com"}, nil, nil))
+	require.NoError(t, c.UpdateRepoMetadata(repo2, map[string]string{"team": team, "tier": "bronze"}, nil, nil, nil))
+	require.YesError(t, c.UpdateRepoMetadata(repo2, map[string]string{"tier": "not gold"}, nil, nil, nil))
+	require.YesError(t, c.UpdateRepoMetadata(repo2, map[string]string{"": "gold"}, nil, nil, nil))
+
+	repoInfo, err := c.InspectRepo(repo1)
+	require.NoError(t, err)
+	require.Equal(t, "gold", repoInfo.Labels["tier"])
+	require.Equal(t, "jdoe@example.com", repoInfo.Annotations["owner"])
+
+	selected := func(selector string) []string {
+		repoInfos, err := c.ListRepoByLabels(selector, nil)
 		require.NoError(t, err)
-		require.NoError(t, client.FinishCommit("B", BCommit.ID))
-	}()
+		var names []string
+		for _, repoInfo := range repoInfos {
+			names = append(names, repoInfo.Repo.Name)
+		}
+		return names
+	}
+	require.Equal(t, 2, len(selected("team="+team)))
+	require.Equal(t, []string{repo1}, selected("team="+team+",tier=gold"))
+	require.Equal(t, []string{repo2}, selected("team="+team+",tier!=gold"))
+	require.Equal(t, 0, len(selected("team="+team+",!tier")))
+	_, err = c.ListRepoByLabels("tier=not gold", nil)
+	require.YesError(t, err)
 
-	// Flush ACommit
-	commitIter, err := client.FlushCommit([]*pfs.Commit{pclient.NewCommit("A", ACommit.ID)}, nil)
-	require.NoError(t, err)
-	commitInfos, err := collectCommitInfos(commitIter)
+	// labels are removed before any are added, and updating a repo keeps them
+	require.NoError(t, c.UpdateRepoMetadata(repo2, nil, nil, []string{"tier"}, nil))
+	_, err = c.PfsAPIClient.CreateRepo(context.Background(), &pfs.CreateRepoRequest{
+		Repo:        pclient.NewRepo(repo2),
+		Description: "description",
+		Update:      true,
+	})
 	require.NoError(t, err)
-	require.Equal(t, 1, len(commitInfos))
+	require.Equal(t, []string{repo2}, selected("team="+team+",!tier"))
 }
 
-func TestEmptyFlush(t *testing.T) {
+func TestArchiveRepo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
 	t.Parallel()
-	client := getClient(t)
-	commitIter, err := client.FlushCommit(nil, nil)
-	require.NoError(t, err)
-	_, err = collectCommitInfos(commitIter)
-	require.YesError(t, err)
-}
 
-func TestFlushNonExistentCommit(t *testing.T) {
-	t.Parallel()
 	c := getClient(t)
-	iter, err := c.FlushCommit([]*pfs.Commit{pclient.NewCommit("fake-repo", "fake-commit")}, nil)
-	require.NoError(t, err)
-	_, err = collectCommitInfos(iter)
-	require.YesError(t, err)
-	repo := "FlushNonExistentCommit"
+	repo := uniqueString("TestArchiveRepo")
 	require.NoError(t, c.CreateRepo(repo))
-	_, err = c.FlushCommit([]*pfs.Commit{pclient.NewCommit(repo, "fake-commit")}, nil)
+	_, err := c.PutFile(repo, "master", "file", strings.NewReader("foo"))
 	require.NoError(t, err)
-	_, err = collectCommitInfos(iter)
+
+	// a repo with an open commit can't be archived
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	require.YesError(t, c.ArchiveRepo(repo))
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+	require.NoError(t, c.ArchiveRepo(repo))
+
+	repoInfo, err := c.InspectRepo(repo)
+	require.NoError(t, err)
+	require.NotNil(t, repoInfo.Archived)
+	listed := func(includeArchived bool) bool {
+		response, err := c.PfsAPIClient.ListRepo(context.Background(), &pfs.ListRepoRequest{IncludeArchived: includeArchived})
+		require.NoError(t, err)
+		for _, repoInfo := range response.RepoInfo {
+			if repoInfo.Repo.Name == repo {
+				return true
+			}
+		}
+		return false
+	}
+	require.False(t, listed(false))
+	require.True(t, listed(true))
+
+	// archived repos can be read, but not written to or subscribed to
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(repo, "master", "file", 0, 0, &buffer))
+	require.Equal(t, "foo", buffer.String())
+	_, err = c.StartCommit(repo, "master")
+	require.YesError(t, err)
+	_, err = c.PutFile(repo, "master", "file2", strings.NewReader("bar"))
 	require.YesError(t, err)
+	require.YesError(t, c.SetBranch(repo, commit.ID, "other"))
+	iter, err := c.SubscribeCommit(repo, "master", "")
+	if err == nil {
+		_, err = iter.Next()
+		iter.Close()
+	}
+	require.YesError(t, err)
+
+	require.NoError(t, c.UnarchiveRepo(repo))
+	require.True(t, listed(false))
+	_, err = c.PutFile(repo, "master", "file2", strings.NewReader("bar"))
+	require.NoError(t, err)
 }
 
-func TestPutFileSplit(t *testing.T) {
+func TestExportImportRepo(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
 	}
 	t.Parallel()
 
 	c := getClient(t)
-	// create repos
-	repo := uniqueString("TestPutFileSplit")
+	repo := uniqueString("TestExportImportRepo")
 	require.NoError(t, c.CreateRepo(repo))
-	commit, err := c.StartCommit(repo, "master")
-	require.NoError(t, err)
-	_, err = c.PutFileSplit(repo, commit.ID, "none", pfs.Delimiter_NONE, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
-	require.NoError(t, err)
-	_, err = c.PutFileSplit(repo, commit.ID, "line", pfs.Delimiter_LINE, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
+	require.NoError(t, c.UpdateRepoMetadata(repo, map[string]string{"tier": "gold"}, nil, nil, nil))
+	commit1, err := c.StartCommit(repo, "master")
 	require.NoError(t, err)
-	_, err = c.PutFileSplit(repo, commit.ID, "line", pfs.Delimiter_LINE, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
-	require.NoError(t, err)
-	_, err = c.PutFileSplit(repo, commit.ID, "line2", pfs.Delimiter_LINE, 2, 0, false, strings.NewReader("foo\nbar\nbuz\nfiz\n"))
-	require.NoError(t, err)
-	_, err = c.PutFileSplit(repo, commit.ID, "line3", pfs.Delimiter_LINE, 0, 8, false, strings.NewReader("foo\nbar\nbuz\nfiz\n"))
+	_, err = c.PutFile(repo, commit1.ID, "a", strings.NewReader("foo"))
 	require.NoError(t, err)
-	_, err = c.PutFileSplit(repo, commit.ID, "json", pfs.Delimiter_JSON, 0, 0, false, strings.NewReader("{}{}{}{}{}{}{}{}{}{}"))
+	_, err = c.PutFile(repo, commit1.ID, "dir/b", strings.NewReader("bar"))
 	require.NoError(t, err)
-	_, err = c.PutFileSplit(repo, commit.ID, "json", pfs.Delimiter_JSON, 0, 0, false, strings.NewReader("{}{}{}{}{}{}{}{}{}{}"))
+	require.NoError(t, c.FinishCommit(repo, commit1.ID))
+	commit2, err := c.StartCommit(repo, "master")
 	require.NoError(t, err)
-	_, err = c.PutFileSplit(repo, commit.ID, "json2", pfs.Delimiter_JSON, 2, 0, false, strings.NewReader("{}{}{}{}"))
+	_, err = c.PutFile(repo, commit2.ID, "a", strings.NewReader("baz"))
 	require.NoError(t, err)
-	_, err = c.PutFileSplit(repo, commit.ID, "json3", pfs.Delimiter_JSON, 0, 4, false, strings.NewReader("{}{}{}{}"))
+	require.NoError(t, c.FinishCommit(repo, commit2.ID))
+	require.NoError(t, c.SetBranch(repo, commit1.ID, "old"))
+	// open commits aren't exported
+	_, err = c.StartCommit(repo, "master")
 	require.NoError(t, err)
 
-	files, err := c.ListFile(repo, commit.ID, "line2")
-	require.NoError(t, err)
-	require.Equal(t, 2, len(files))
-	for _, fileInfo := range files {
-		require.Equal(t, uint64(8), fileInfo.SizeBytes)
-	}
+	var bundle bytes.Buffer
+	require.NoError(t, c.ExportRepo(repo, &bundle))
 
-	require.NoError(t, c.FinishCommit(repo, commit.ID))
-	commit2, err := c.StartCommit(repo, "master")
+	imported := uniqueString("TestExportImportRepoImported")
+	require.NoError(t, c.ImportRepo(imported, bytes.NewReader(bundle.Bytes())))
+	repoInfo, err := c.InspectRepo(imported)
 	require.NoError(t, err)
-	_, err = c.PutFileSplit(repo, commit2.ID, "line", pfs.Delimiter_LINE, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
+	require.Equal(t, "gold", repoInfo.Labels["tier"])
+	commitInfos, err := c.ListCommit(imported, "", "", 0)
 	require.NoError(t, err)
-	_, err = c.PutFileSplit(repo, commit2.ID, "json", pfs.Delimiter_JSON, 0, 0, false, strings.NewReader("{}{}{}{}{}{}{}{}{}{}"))
+	require.Equal(t, 2, len(commitInfos))
+	commitInfo, err := c.InspectCommit(imported, "master")
 	require.NoError(t, err)
+	require.Equal(t, commit2.ID, commitInfo.Commit.ID)
+	require.Equal(t, commit1.ID, commitInfo.ParentCommit.ID)
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(imported, "master", "a", 0, 0, &buffer))
+	require.Equal(t, "baz", buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.GetFile(imported, "old", "a", 0, 0, &buffer))
+	require.Equal(t, "foo", buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.GetFile(imported, "old", "dir/b", 0, 0, &buffer))
+	require.Equal(t, "bar", buffer.String())
 
-	files, err = c.ListFile(repo, commit2.ID, "line")
+	// the imported repo can be written to
+	_, err = c.PutFile(imported, "master", "c", strings.NewReader("qux"))
 	require.NoError(t, err)
-	require.Equal(t, 9, len(files))
-	for _, fileInfo := range files {
-		require.Equal(t, uint64(4), fileInfo.SizeBytes)
-	}
 
-	require.NoError(t, c.FinishCommit(repo, commit2.ID))
-	fileInfo, err := c.InspectFile(repo, commit.ID, "none")
-	require.NoError(t, err)
-	require.Equal(t, pfs.FileType_FILE, fileInfo.FileType)
-	files, err = c.ListFile(repo, commit.ID, "line")
-	require.NoError(t, err)
-	require.Equal(t, 6, len(files))
-	for _, fileInfo := range files {
-		require.Equal(t, uint64(4), fileInfo.SizeBytes)
-	}
-	files, err = c.ListFile(repo, commit2.ID, "line")
-	require.NoError(t, err)
-	require.Equal(t, 9, len(files))
-	for _, fileInfo := range files {
-		require.Equal(t, uint64(4), fileInfo.SizeBytes)
+	// a repo can't be imported over an existing one, and a truncated bundle
+	// leaves no repo behind
+	require.YesError(t, c.ImportRepo(imported, bytes.NewReader(bundle.Bytes())))
+	truncated := uniqueString("TestExportImportRepoTruncated")
+	require.YesError(t, c.ImportRepo(truncated, bytes.NewReader(bundle.Bytes()[:bundle.Len()-1])))
+	_, err = c.InspectRepo(truncated)
+	require.YesError(t, err)
+}
+
+func TestChangedPrefixes(t *testing.T) {
+	object := func(hash string) []*pfs.Object {
+		return []*pfs.Object{{Hash: hash}}
 	}
-	files, err = c.ListFile(repo, commit.ID, "line2")
+	oldTree := hashtree.NewHashTree()
+	require.NoError(t, oldTree.PutFile("/a/b/c", object("c"), 1))
+	require.NoError(t, oldTree.PutFile("/a/b/d", object("d"), 1))
+	require.NoError(t, oldTree.PutFile("/a/e", object("e"), 1))
+	require.NoError(t, oldTree.PutFile("/f/g", object("g"), 1))
+	require.NoError(t, oldTree.PutFile("/h", object("h"), 1))
+	old, err := oldTree.Finish()
 	require.NoError(t, err)
-	require.Equal(t, 2, len(files))
-	for _, fileInfo := range files {
-		require.Equal(t, uint64(8), fileInfo.SizeBytes)
-	}
-	files, err = c.ListFile(repo, commit.ID, "line3")
+
+	newTree := hashtree.NewHashTree()
+	require.NoError(t, newTree.PutFile("/a/b/c", object("c2"), 1))
+	require.NoError(t, newTree.PutFile("/a/b/d", object("d2"), 1))
+	require.NoError(t, newTree.PutFile("/a/e", object("e"), 1))
+	require.NoError(t, newTree.PutFile("/h", object("h"), 1))
+	require.NoError(t, newTree.PutFile("/i/j", object("j"), 1))
+	new, err := newTree.Finish()
 	require.NoError(t, err)
-	require.Equal(t, 2, len(files))
-	for _, fileInfo := range files {
-		require.Equal(t, uint64(8), fileInfo.SizeBytes)
-	}
-	files, err = c.ListFile(repo, commit.ID, "json")
+
+	prefixes, err := changedPrefixes(new, old, 100)
 	require.NoError(t, err)
-	require.Equal(t, 20, len(files))
-	for _, fileInfo := range files {
-		require.Equal(t, uint64(2), fileInfo.SizeBytes)
-	}
-	files, err = c.ListFile(repo, commit2.ID, "json")
+	require.Equal(t, []string{"/a/b/c", "/a/b/d", "/f", "/i"}, prefixes)
+	// the prefixes stay shallower when there would be too many of them
+	prefixes, err = changedPrefixes(new, old, 3)
 	require.NoError(t, err)
-	require.Equal(t, 30, len(files))
-	for _, fileInfo := range files {
-		require.Equal(t, uint64(2), fileInfo.SizeBytes)
-	}
-	files, err = c.ListFile(repo, commit.ID, "json2")
+	require.Equal(t, []string{"/a/b", "/f", "/i"}, prefixes)
+	prefixes, err = changedPrefixes(new, old, 2)
 	require.NoError(t, err)
-	require.Equal(t, 2, len(files))
-	for _, fileInfo := range files {
-		require.Equal(t, uint64(4), fileInfo.SizeBytes)
-	}
-	files, err = c.ListFile(repo, commit.ID, "json3")
+	require.Equal(t, []string{"/"}, prefixes)
+	prefixes, err = changedPrefixes(new, new, 100)
 	require.NoError(t, err)
-	require.Equal(t, 2, len(files))
-	for _, fileInfo := range files {
-		require.Equal(t, uint64(4), fileInfo.SizeBytes)
-	}
+	require.Equal(t, 0, len(prefixes))
 }
 
-func TestPutFileSplitDelete(t *testing.T) {
+func TestSubscribeCommitInvalidationHints(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
 	}
 	t.Parallel()
 
 	c := getClient(t)
-	// create repos
-	repo := uniqueString("TestPutFileSplitDelete")
+	repo := uniqueString("TestSubscribeCommitInvalidationHints")
 	require.NoError(t, c.CreateRepo(repo))
-	commit, err := c.StartCommit(repo, "master")
-	require.NoError(t, err)
-	_, err = c.PutFileSplit(repo, commit.ID, "line", pfs.Delimiter_LINE, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
+	commit1, err := c.StartCommit(repo, "master")
 	require.NoError(t, err)
-	require.NoError(t, c.DeleteFile(repo, commit.ID, fmt.Sprintf("line/%016x", 0)))
-	_, err = c.PutFileSplit(repo, commit.ID, "line", pfs.Delimiter_LINE, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
+	_, err = c.PutFile(repo, commit1.ID, "dir/a", strings.NewReader("foo"))
 	require.NoError(t, err)
-	require.NoError(t, c.DeleteFile(repo, commit.ID, fmt.Sprintf("line/%016x", 5)))
-	_, err = c.PutFileSplit(repo, commit.ID, "line", pfs.Delimiter_LINE, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
+	_, err = c.PutFile(repo, commit1.ID, "dir/b", strings.NewReader("bar"))
 	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit1.ID))
 
-	files, err := c.ListFile(repo, commit.ID, "line")
+	iter, err := c.SubscribeCommitWithHints(repo, "master", "")
 	require.NoError(t, err)
-	require.Equal(t, 7, len(files))
-	for _, fileInfo := range files {
-		require.Equal(t, uint64(4), fileInfo.SizeBytes)
-	}
+	defer iter.Close()
+	commitInfo, err := iter.Next()
+	require.NoError(t, err)
+	require.Equal(t, commit1.ID, commitInfo.Commit.ID)
+	require.Nil(t, commitInfo.InvalidationHint.Since)
+	require.Equal(t, []string{"/dir"}, commitInfo.InvalidationHint.ChangedPrefixes)
+	treeHash := commitInfo.InvalidationHint.TreeHash
+	require.NotEqual(t, "", treeHash)
 
-	require.NoError(t, c.FinishCommit(repo, commit.ID))
+	commit2, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit2.ID, "dir/b", strings.NewReader("baz"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit2.ID))
+	commitInfo, err = iter.Next()
+	require.NoError(t, err)
+	require.Equal(t, commit2.ID, commitInfo.Commit.ID)
+	require.Equal(t, commit1.ID, commitInfo.InvalidationHint.Since.ID)
+	require.Equal(t, []string{"/dir/b"}, commitInfo.InvalidationHint.ChangedPrefixes)
+	require.NotEqual(t, treeHash, commitInfo.InvalidationHint.TreeHash)
 
-	files, err = c.ListFile(repo, commit.ID, "line")
+	// an empty commit changes nothing
+	commit3, err := c.StartCommit(repo, "master")
 	require.NoError(t, err)
-	require.Equal(t, 7, len(files))
-	for _, fileInfo := range files {
-		require.Equal(t, uint64(4), fileInfo.SizeBytes)
-	}
+	require.NoError(t, c.FinishCommit(repo, commit3.ID))
+	commitInfo, err = iter.Next()
+	require.NoError(t, err)
+	require.Equal(t, 0, len(commitInfo.InvalidationHint.ChangedPrefixes))
 }
 
-func TestPutFileSplitBig(t *testing.T) {
+func TestBisectCommits(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
 	}
 	t.Parallel()
 
 	c := getClient(t)
-	// create repos
-	repo := uniqueString("TestPutFileSplitBig")
+	repo := uniqueString("TestBisectCommits")
 	require.NoError(t, c.CreateRepo(repo))
-	commit, err := c.StartCommit(repo, "master")
-	require.NoError(t, err)
-	w, err := c.PutFileSplitWriter(repo, commit.ID, "line", pfs.Delimiter_LINE, 0, 0, false)
-	require.NoError(t, err)
-	for i := 0; i < 1000; i++ {
-		_, err = w.Write([]byte("foo\n"))
+	var commits []*pfs.Commit
+	for i := 0; i < 10; i++ {
+		commit, err := c.StartCommit(repo, "master")
 		require.NoError(t, err)
+		_, err = c.PutFile(repo, commit.ID, fmt.Sprintf("file%d", i), strings.NewReader("foo"))
+		require.NoError(t, err)
+		require.NoError(t, c.FinishCommit(repo, commit.ID))
+		commits = append(commits, commit)
 	}
-	require.NoError(t, w.Close())
-	require.NoError(t, c.FinishCommit(repo, commit.ID))
-	files, err := c.ListFile(repo, commit.ID, "line")
-	require.NoError(t, err)
-	require.Equal(t, 1000, len(files))
-	for _, fileInfo := range files {
-		require.Equal(t, uint64(4), fileInfo.SizeBytes)
+	// commits[6] is the first bad one
+	isBad := func(commit *pfs.Commit) bool {
+		for i, c := range commits {
+			if c.ID == commit.ID {
+				return i >= 6
+			}
+		}
+		t.Fatalf("commit %s isn't on master", commit.ID)
+		return false
+	}
+
+	good, bad := commits[0].ID, ""
+	var tested int
+	for {
+		response, err := c.BisectCommits(repo, "master", good, bad)
+		require.NoError(t, err)
+		if bad == "" {
+			// the bad commit is the head of master
+			require.Equal(t, commits[9].ID, response.Bad.ID)
+		}
+		if response.FirstBad != nil {
+			require.Equal(t, commits[6].ID, response.FirstBad.ID)
+			require.Equal(t, uint64(1), response.Remaining)
+			break
+		}
+		tested++
+		require.True(t, tested <= 4)
+		if next := response.Next.Commit; isBad(next) {
+			bad = next.ID
+		} else {
+			good = next.ID
+		}
 	}
+
+	// good must be an ancestor of bad
+	_, err := c.BisectCommits(repo, "master", commits[5].ID, commits[2].ID)
+	require.YesError(t, err)
+	_, err = c.BisectCommits(repo, "master", commits[5].ID, commits[5].ID)
+	require.YesError(t, err)
 }
 
-func TestDiff(t *testing.T) {
+func TestPreviewMerge(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
 	}
 	t.Parallel()
 
 	c := getClient(t)
-	repo := uniqueString("TestDiff")
+	repo := uniqueString("TestPreviewMerge")
 	require.NoError(t, c.CreateRepo(repo))
+	base, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	for _, file := range []string{"a", "b", "c"} {
+		_, err = c.PutFile(repo, base.ID, file, strings.NewReader(file))
+		require.NoError(t, err)
+	}
+	require.NoError(t, c.FinishCommit(repo, base.ID))
+	require.NoError(t, c.SetBranch(repo, base.ID, "feature"))
 
-	// Write foo
-	_, err := c.StartCommit(repo, "master")
+	// feature modifies a and b, and adds d
+	commit, err := c.StartCommit(repo, "feature")
 	require.NoError(t, err)
-	_, err = c.PutFile(repo, "master", "foo", strings.NewReader("foo\n"))
+	_, err = c.PutFile(repo, commit.ID, "a", strings.NewReader("feature"))
 	require.NoError(t, err)
-
-	newFiles, oldFiles, err := c.DiffFile(repo, "master", "", "", "", "", false)
+	_, err = c.PutFile(repo, commit.ID, "b", strings.NewReader("feature"))
 	require.NoError(t, err)
-	require.Equal(t, 1, len(newFiles))
-	require.Equal(t, "foo", newFiles[0].File.Path)
-	require.Equal(t, 0, len(oldFiles))
-
-	require.NoError(t, c.FinishCommit(repo, "master"))
+	_, err = c.PutFile(repo, commit.ID, "d", strings.NewReader("feature"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
 
-	newFiles, oldFiles, err = c.DiffFile(repo, "master", "", "", "", "", false)
+	// merging feature into its base is a fast-forward
+	response, err := c.PreviewMerge(repo, base.ID, "feature")
 	require.NoError(t, err)
-	require.Equal(t, 1, len(newFiles))
-	require.Equal(t, "foo", newFiles[0].File.Path)
-	require.Equal(t, 0, len(oldFiles))
+	require.Equal(t, base.ID, response.Base.ID)
+	require.True(t, response.FastForward)
+	require.Equal(t, 0, len(response.Conflicts))
+	require.Equal(t, int64(1), response.Summary.FilesAdded)
+	require.Equal(t, int64(2), response.Summary.FilesModified)
 
-	// Change the value of foo
-	_, err = c.StartCommit(repo, "master")
+	// master modifies a differently, and deletes c
+	commit, err = c.StartCommit(repo, "master")
 	require.NoError(t, err)
-	require.NoError(t, c.DeleteFile(repo, "master", "foo"))
-	_, err = c.PutFile(repo, "master", "foo", strings.NewReader("not foo\n"))
+	_, err = c.PutFile(repo, commit.ID, "a", strings.NewReader("master"))
 	require.NoError(t, err)
+	require.NoError(t, c.DeleteFile(repo, commit.ID, "c"))
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
 
-	newFiles, oldFiles, err = c.DiffFile(repo, "master", "", "", "", "", false)
+	response, err = c.PreviewMerge(repo, "master", "feature")
 	require.NoError(t, err)
-	require.Equal(t, 1, len(newFiles))
-	require.Equal(t, "foo", newFiles[0].File.Path)
-	require.Equal(t, 1, len(oldFiles))
-	require.Equal(t, "foo", oldFiles[0].File.Path)
-
-	require.NoError(t, c.FinishCommit(repo, "master"))
+	require.Equal(t, base.ID, response.Base.ID)
+	require.Equal(t, commit.ID, response.Ours.ID)
+	require.False(t, response.FastForward)
+	require.Equal(t, []string{"/a"}, response.Conflicts)
+	require.Equal(t, int64(1), response.Summary.FilesAdded)
+	require.Equal(t, int64(1), response.Summary.FilesModified)
+	require.Equal(t, int64(0), response.Summary.FilesDeleted)
+	require.NotEqual(t, "", response.TreeHash)
 
-	newFiles, oldFiles, err = c.DiffFile(repo, "master", "", "", "", "", false)
+	// previewing doesn't create any commits
+	commitInfos, err := c.ListCommit(repo, "", "", 0)
 	require.NoError(t, err)
-	require.Equal(t, 1, len(newFiles))
-	require.Equal(t, "foo", newFiles[0].File.Path)
-	require.Equal(t, 1, len(oldFiles))
-	require.Equal(t, "foo", oldFiles[0].File.Path)
+	require.Equal(t, 3, len(commitInfos))
 
-	// Write bar
-	_, err = c.StartCommit(repo, "master")
+	// commits without a common ancestor conflict wherever both have a file
+	orphan, err := c.StartCommit(repo, "orphan")
 	require.NoError(t, err)
-	_, err = c.PutFile(repo, "master", "bar", strings.NewReader("bar\n"))
+	_, err = c.PutFile(repo, orphan.ID, "b", strings.NewReader("orphan"))
 	require.NoError(t, err)
-
-	newFiles, oldFiles, err = c.DiffFile(repo, "master", "", "", "", "", false)
+	_, err = c.PutFile(repo, orphan.ID, "e", strings.NewReader("orphan"))
 	require.NoError(t, err)
-	require.Equal(t, 1, len(newFiles))
-	require.Equal(t, "bar", newFiles[0].File.Path)
-	require.Equal(t, 0, len(oldFiles))
+	require.NoError(t, c.FinishCommit(repo, orphan.ID))
+	response, err = c.PreviewMerge(repo, "master", "orphan")
+	require.NoError(t, err)
+	require.Nil(t, response.Base)
+	require.Equal(t, []string{"/b"}, response.Conflicts)
+	require.Equal(t, int64(1), response.Summary.FilesAdded)
+}
 
-	require.NoError(t, c.FinishCommit(repo, "master"))
+func TestMergeConflicts(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
 
-	newFiles, oldFiles, err = c.DiffFile(repo, "master", "", "", "", "", false)
+	c := getClient(t)
+	repo := uniqueString("TestMergeConflicts")
+	require.NoError(t, c.CreateRepo(repo))
+	base, err := c.StartCommit(repo, "master")
 	require.NoError(t, err)
-	require.Equal(t, 1, len(newFiles))
-	require.Equal(t, "bar", newFiles[0].File.Path)
-	require.Equal(t, 0, len(oldFiles))
+	for _, file := range []string{"a", "b", "c"} {
+		_, err = c.PutFile(repo, base.ID, file, strings.NewReader(file))
+		require.NoError(t, err)
+	}
+	require.NoError(t, c.FinishCommit(repo, base.ID))
+	require.NoError(t, c.SetBranch(repo, base.ID, "feature"))
 
-	// Delete bar
-	_, err = c.StartCommit(repo, "master")
+	commit, err := c.StartCommit(repo, "feature")
 	require.NoError(t, err)
-	require.NoError(t, c.DeleteFile(repo, "master", "bar"))
-
-	newFiles, oldFiles, err = c.DiffFile(repo, "master", "", "", "", "", false)
+	_, err = c.PutFileOverwrite(repo, commit.ID, "a", strings.NewReader("feature"), 0)
 	require.NoError(t, err)
-	require.Equal(t, 0, len(newFiles))
-	require.Equal(t, 1, len(oldFiles))
-	require.Equal(t, "bar", oldFiles[0].File.Path)
-
-	require.NoError(t, c.FinishCommit(repo, "master"))
-
-	newFiles, oldFiles, err = c.DiffFile(repo, "master", "", "", "", "", false)
+	_, err = c.PutFileOverwrite(repo, commit.ID, "b", strings.NewReader("feature"), 0)
 	require.NoError(t, err)
-	require.Equal(t, 0, len(newFiles))
-	require.Equal(t, 1, len(oldFiles))
-	require.Equal(t, "bar", oldFiles[0].File.Path)
+	require.NoError(t, c.DeleteFile(repo, commit.ID, "c"))
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
 
-	// Write dir/fizz and dir/buzz
-	_, err = c.StartCommit(repo, "master")
+	head, err := c.StartCommit(repo, "master")
 	require.NoError(t, err)
-	_, err = c.PutFile(repo, "master", "dir/fizz", strings.NewReader("fizz\n"))
+	_, err = c.PutFileOverwrite(repo, head.ID, "a", strings.NewReader("master"), 0)
 	require.NoError(t, err)
-	_, err = c.PutFile(repo, "master", "dir/buzz", strings.NewReader("buzz\n"))
+	_, err = c.PutFileOverwrite(repo, head.ID, "c", strings.NewReader("master"), 0)
 	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, head.ID))
 
-	newFiles, oldFiles, err = c.DiffFile(repo, "master", "", "", "", "", false)
+	response, err := c.Merge(repo, "master", "feature")
 	require.NoError(t, err)
-	require.Equal(t, 2, len(newFiles))
-	require.Equal(t, 0, len(oldFiles))
+	require.Equal(t, []string{"/a", "/c"}, response.Conflicts)
+	conflictCommit := response.Commit
+	// the conflict commit isn't on master
+	commitInfo, err := c.InspectCommit(repo, "master")
+	require.NoError(t, err)
+	require.Equal(t, head.ID, commitInfo.Commit.ID)
+	commitInfo, err = c.InspectCommit(repo, conflictCommit.ID)
+	require.NoError(t, err)
+	require.Equal(t, head.ID, commitInfo.ParentCommit.ID)
+	require.Equal(t, "master", commitInfo.Merge.Branch)
+	require.Equal(t, base.ID, commitInfo.Merge.Base.ID)
 
-	require.NoError(t, c.FinishCommit(repo, "master"))
+	getFile := func(commitID string, path string) string {
+		var buf bytes.Buffer
+		require.NoError(t, c.GetFile(repo, commitID, path, 0, 0, &buf))
+		return buf.String()
+	}
+	require.Equal(t, "master", getFile(conflictCommit.ID, "a.ours"))
+	require.Equal(t, "feature", getFile(conflictCommit.ID, "a.theirs"))
+	require.Equal(t, "master", getFile(conflictCommit.ID, "c.ours"))
+	require.Equal(t, "feature", getFile(conflictCommit.ID, "b"))
+	// feature deleted c, so there's no c.theirs
+	_, err = c.InspectFile(repo, conflictCommit.ID, "c.theirs")
+	require.YesError(t, err)
+	_, err = c.InspectFile(repo, conflictCommit.ID, "a")
+	require.YesError(t, err)
 
-	newFiles, oldFiles, err = c.DiffFile(repo, "master", "", "", "", "", false)
-	require.NoError(t, err)
-	require.Equal(t, 2, len(newFiles))
-	require.Equal(t, 0, len(oldFiles))
+	// a path that doesn't conflict can't be resolved
+	_, err = c.ResolveConflict(repo, conflictCommit.ID, "b", pfs.ConflictResolution_OURS)
+	require.YesError(t, err)
 
-	// Modify dir/fizz
-	_, err = c.StartCommit(repo, "master")
+	response, err = c.ResolveConflict(repo, conflictCommit.ID, "a", pfs.ConflictResolution_THEIRS)
 	require.NoError(t, err)
-	_, err = c.PutFile(repo, "master", "dir/fizz", strings.NewReader("fizz\n"))
+	require.Equal(t, []string{"/c"}, response.Conflicts)
+	require.Equal(t, "feature", getFile(response.Commit.ID, "a"))
+	_, err = c.InspectFile(repo, response.Commit.ID, "a.ours")
+	require.YesError(t, err)
+	commitInfo, err = c.InspectCommit(repo, "master")
 	require.NoError(t, err)
+	require.Equal(t, head.ID, commitInfo.Commit.ID)
 
-	newFiles, oldFiles, err = c.DiffFile(repo, "master", "", "", "", "", false)
+	// resolving the last conflict puts the merge on master
+	response, err = c.ResolveConflict(repo, response.Commit.ID, "c", pfs.ConflictResolution_THEIRS)
 	require.NoError(t, err)
-	require.Equal(t, 1, len(newFiles))
-	require.Equal(t, "dir/fizz", newFiles[0].File.Path)
-	require.Equal(t, 1, len(oldFiles))
-	require.Equal(t, "dir/fizz", oldFiles[0].File.Path)
-
-	require.NoError(t, c.FinishCommit(repo, "master"))
+	require.Equal(t, 0, len(response.Conflicts))
+	commitInfo, err = c.InspectCommit(repo, "master")
+	require.NoError(t, err)
+	require.Equal(t, response.Commit.ID, commitInfo.Commit.ID)
+	require.Equal(t, head.ID, commitInfo.ParentCommit.ID)
+	require.Equal(t, 2, len(commitInfo.Merge.Resolved))
+	require.Equal(t, "/c", commitInfo.Merge.Resolved[1].Path)
+	require.Equal(t, pfs.ConflictResolution_THEIRS, commitInfo.Merge.Resolved[1].Resolution)
+	require.Equal(t, "feature", getFile("master", "a"))
+	require.Equal(t, "feature", getFile("master", "b"))
+	_, err = c.InspectFile(repo, "master", "c")
+	require.YesError(t, err)
 
-	newFiles, oldFiles, err = c.DiffFile(repo, "master", "", "", "", "", false)
+	// feature is already merged into master now
+	response, err = c.Merge(repo, "master", "feature")
 	require.NoError(t, err)
-	require.Equal(t, 1, len(newFiles))
-	require.Equal(t, "dir/fizz", newFiles[0].File.Path)
-	require.Equal(t, 1, len(oldFiles))
-	require.Equal(t, "dir/fizz", oldFiles[0].File.Path)
+	require.Equal(t, commitInfo.Commit.ID, response.Commit.ID)
 }
 
-func TestGlob(t *testing.T) {
+func TestListRepoSortAndPage(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
 	}
 	t.Parallel()
 
 	c := getClient(t)
-	repo := uniqueString("TestGlob")
-	require.NoError(t, c.CreateRepo(repo))
-
-	// Write foo
-	numFiles := 100
-	_, err := c.StartCommit(repo, "master")
-	require.NoError(t, err)
-	for i := 0; i < numFiles; i++ {
-		_, err = c.PutFile(repo, "master", fmt.Sprintf("file%d", i), strings.NewReader(""))
-		require.NoError(t, err)
-		_, err = c.PutFile(repo, "master", fmt.Sprintf("dir1/file%d", i), strings.NewReader(""))
+	label := uniqueString("TestListRepoSortAndPage")
+	// repos[i] has i+1 bytes, and its last commit is made after repos[i+1]'s
+	var repos []string
+	for i := 0; i < 3; i++ {
+		repo := uniqueString("TestListRepoSortAndPage")
+		require.NoError(t, c.CreateRepo(repo))
+		require.NoError(t, c.UpdateRepoMetadata(repo, map[string]string{"test": label}, nil, nil, nil))
+		repos = append(repos, repo)
+	}
+	for i := len(repos) - 1; i >= 0; i-- {
+		_, err := c.PutFile(repos[i], "master", "file", strings.NewReader(strings.Repeat("x", i+1)))
 		require.NoError(t, err)
-		_, err = c.PutFile(repo, "master", fmt.Sprintf("dir2/dir3/file%d", i), strings.NewReader(""))
+	}
+	listRepo := func(sortBy pfs.RepoSort, reverse bool, limit int64, pageToken string) ([]string, string) {
+		response, err := c.PfsAPIClient.ListRepo(c.Ctx(), &pfs.ListRepoRequest{
+			LabelSelector: "test=" + label,
+			SortBy:        sortBy,
+			Reverse:       reverse,
+			Limit:         limit,
+			PageToken:     pageToken,
+		})
 		require.NoError(t, err)
+		var names []string
+		for _, repoInfo := range response.RepoInfo {
+			names = append(names, repoInfo.Repo.Name)
+		}
+		return names, response.NextPageToken
 	}
 
-	fileInfos, err := c.GlobFile(repo, "master", "*")
+	names, token := listRepo(pfs.RepoSort_RepoSort_SIZE, true, 2, "")
+	require.Equal(t, []string{repos[2], repos[1]}, names)
+	require.NotEqual(t, "", token)
+	names, token = listRepo(pfs.RepoSort_RepoSort_SIZE, true, 2, token)
+	require.Equal(t, []string{repos[0]}, names)
+	require.Equal(t, "", token)
+
+	names, _ = listRepo(pfs.RepoSort_RepoSort_LAST_COMMIT, false, 0, "")
+	require.Equal(t, []string{repos[2], repos[1], repos[0]}, names)
+	names, _ = listRepo(pfs.RepoSort_RepoSort_CREATED, false, 0, "")
+	require.Equal(t, repos, names)
+
+	// later pages are read as of the first, so repos created or deleted in
+	// between don't change them
+	names, token = listRepo(pfs.RepoSort_RepoSort_CREATED, false, 1, "")
+	require.Equal(t, []string{repos[0]}, names)
+	created := uniqueString("TestListRepoSortAndPage")
+	require.NoError(t, c.CreateRepo(created))
+	require.NoError(t, c.UpdateRepoMetadata(created, map[string]string{"test": label}, nil, nil, nil))
+	require.NoError(t, c.DeleteRepo(repos[2], false))
+	names, token = listRepo(pfs.RepoSort_RepoSort_CREATED, false, 1, token)
+	require.Equal(t, []string{repos[1]}, names)
+	names, token = listRepo(pfs.RepoSort_RepoSort_CREATED, false, 1, token)
+	require.Equal(t, []string{repos[2]}, names)
+	require.Equal(t, "", token)
+	names, _ = listRepo(pfs.RepoSort_RepoSort_CREATED, false, 0, "")
+	require.Equal(t, []string{repos[0], repos[1], created}, names)
+
+	repoInfo, err := c.InspectRepo(repos[0])
+	require.NoError(t, err)
+	require.NotNil(t, repoInfo.LastCommitTime)
+}
+
+func TestPageToken(t *testing.T) {
+	filter := pageFilter("ListFile", "repo", "master", "/dir")
+	token := (&pageToken{Position: "/dir/file04", Commit: "abc", Filter: filter}).encode()
+	parsed, err := parsePageToken(token, filter)
 	require.NoError(t, err)
-	require.Equal(t, numFiles+2, len(fileInfos))
-	fileInfos, err = c.GlobFile(repo, "master", "file*")
+	require.Equal(t, pageTokenVersion, parsed.Version)
+	require.Equal(t, "/dir/file04", parsed.Position)
+	require.Equal(t, "abc", parsed.Commit)
+
+	// tokens are tied to the parameters of their listing, and to the version
+	// of the format
+	_, err = parsePageToken(token, pageFilter("ListFile", "repo", "master", "/other"))
+	require.YesError(t, err)
+	require.NotEqual(t, pageFilter("a", "bc"), pageFilter("ab", "c"))
+	_, err = parsePageToken(base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"v":%d,"f":"%s"}`, pageTokenVersion+1, filter))), filter)
+	require.YesError(t, err)
+	_, err = parsePageToken("/dir/file04", filter)
+	require.YesError(t, err)
+
+	// later pages keep the commit of the first
+	pages, err := newPager(token, filter)
 	require.NoError(t, err)
-	require.Equal(t, numFiles, len(fileInfos))
-	fileInfos, err = c.GlobFile(repo, "master", "dir1/*")
+	require.Equal(t, "/dir/file04", pages.position())
+	commit, err := pages.commit(context.Background(), nil, pclient.NewCommit("repo", "master"))
 	require.NoError(t, err)
-	require.Equal(t, numFiles, len(fileInfos))
-	fileInfos, err = c.GlobFile(repo, "master", "dir2/dir3/*")
+	require.Equal(t, "abc", commit.ID)
+	parsed, err = parsePageToken(pages.next("/dir/file09", commit.ID), filter)
 	require.NoError(t, err)
-	require.Equal(t, numFiles, len(fileInfos))
-	fileInfos, err = c.GlobFile(repo, "master", "*/*")
+	require.Equal(t, "/dir/file09", parsed.Position)
+	require.Equal(t, "abc", parsed.Commit)
+
+	// and the etcd revision of the first
+	token = (&pageToken{Position: "1/repo", Revision: 42, Filter: filter}).encode()
+	pages, err = newPager(token, filter)
 	require.NoError(t, err)
-	require.Equal(t, numFiles+1, len(fileInfos))
+	rev, err := pages.revision(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), rev)
+	parsed, err = parsePageToken(pages.next("2/repo", ""), filter)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), parsed.Revision)
+}
 
-	require.NoError(t, c.FinishCommit(repo, "master"))
+func TestCommitInfoProjection(t *testing.T) {
+	commitInfo := &pfs.CommitInfo{
+		Commit:       pclient.NewCommit("repo", "commit"),
+		ParentCommit: pclient.NewCommit("repo", "parent"),
+		SizeBytes:    10,
+		Provenance:   []*pfs.Commit{pclient.NewCommit("upstream", "commit")},
+	}
+	project, err := commitInfoProjection(nil, nil)
+	require.NoError(t, err)
+	require.Nil(t, project)
 
-	fileInfos, err = c.GlobFile(repo, "master", "*")
+	project, err = commitInfoProjection(nil, []string{"provenance"})
 	require.NoError(t, err)
-	require.Equal(t, numFiles+2, len(fileInfos))
-	fileInfos, err = c.GlobFile(repo, "master", "file*")
+	projected := project(commitInfo)
+	require.Equal(t, commitInfo.Commit, projected.Commit)
+	require.Equal(t, commitInfo.ParentCommit, projected.ParentCommit)
+	require.Equal(t, uint64(10), projected.SizeBytes)
+	require.Equal(t, 0, len(projected.Provenance))
+	// the original isn't changed
+	require.Equal(t, 1, len(commitInfo.Provenance))
+
+	// the commit is always kept
+	project, err = commitInfoProjection([]string{"size_bytes"}, []string{"commit"})
 	require.NoError(t, err)
-	require.Equal(t, numFiles, len(fileInfos))
-	fileInfos, err = c.GlobFile(repo, "master", "dir1/*")
+	projected = project(commitInfo)
+	require.Equal(t, commitInfo.Commit, projected.Commit)
+	require.Nil(t, projected.ParentCommit)
+	require.Equal(t, uint64(10), projected.SizeBytes)
+
+	_, err = commitInfoProjection([]string{"SizeBytes"}, nil)
+	require.YesError(t, err)
+}
+
+func TestListCommitOmitProvenance(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	upstream := uniqueString("TestListCommitOmitProvenance")
+	downstream := uniqueString("TestListCommitOmitProvenance")
+	require.NoError(t, c.CreateRepo(upstream))
+	_, err := c.PfsAPIClient.CreateRepo(context.Background(), &pfs.CreateRepoRequest{
+		Repo:       pclient.NewRepo(downstream),
+		Provenance: []*pfs.Repo{pclient.NewRepo(upstream)},
+	})
 	require.NoError(t, err)
-	require.Equal(t, numFiles, len(fileInfos))
-	fileInfos, err = c.GlobFile(repo, "master", "dir2/dir3/*")
+	commit, err := c.StartCommit(upstream, "master")
 	require.NoError(t, err)
-	require.Equal(t, numFiles, len(fileInfos))
-	fileInfos, err = c.GlobFile(repo, "master", "*/*")
+	require.NoError(t, c.FinishCommit(upstream, commit.ID))
+	_, err = c.PfsAPIClient.StartCommit(context.Background(), &pfs.StartCommitRequest{
+		Parent:     pclient.NewCommit(downstream, ""),
+		Branch:     "master",
+		Provenance: []*pfs.Commit{commit},
+	})
 	require.NoError(t, err)
-	require.Equal(t, numFiles+1, len(fileInfos))
+	require.NoError(t, c.FinishCommit(downstream, "master"))
+
+	commitInfos, err := c.ListCommitFields(downstream, "", "", 0, nil, []string{"provenance"})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(commitInfos))
+	require.Equal(t, 0, len(commitInfos[0].Provenance))
+	require.NotNil(t, commitInfos[0].Finished)
+
+	commitInfo, err := c.InspectCommitFields(downstream, "master", []string{"provenance"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(commitInfo.Provenance))
+	require.Nil(t, commitInfo.Finished)
+
+	_, err = c.ListCommitFields(downstream, "", "", 0, []string{"no_such_field"}, nil)
+	require.YesError(t, err)
 }
 
-func TestOverwrite(t *testing.T) {
+func TestSubvenance(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
 	}
 	t.Parallel()
-
 	c := getClient(t)
-	repo := uniqueString("TestGlob")
-	require.NoError(t, c.CreateRepo(repo))
+	a, b, d := uniqueString("A"), uniqueString("B"), uniqueString("D")
+	require.NoError(t, c.CreateRepo(a))
+	_, err := c.PfsAPIClient.CreateRepo(context.Background(), &pfs.CreateRepoRequest{
+		Repo:       pclient.NewRepo(b),
+		Provenance: []*pfs.Repo{pclient.NewRepo(a)},
+	})
+	require.NoError(t, err)
+	_, err = c.PfsAPIClient.CreateRepo(context.Background(), &pfs.CreateRepoRequest{
+		Repo:       pclient.NewRepo(d),
+		Provenance: []*pfs.Repo{pclient.NewRepo(b)},
+	})
+	require.NoError(t, err)
 
-	// Write foo
-	_, err := c.StartCommit(repo, "master")
+	repoInfo, err := c.InspectRepoSubvenance(a)
 	require.NoError(t, err)
-	_, err = c.PutFile(repo, "master", "file1", strings.NewReader("foo"))
-	_, err = c.PutFileSplit(repo, "master", "file2", pfs.Delimiter_LINE, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
+	require.Equal(t, 2, len(repoInfo.Subvenance))
+	names := []string{repoInfo.Subvenance[0].Name, repoInfo.Subvenance[1].Name}
+	require.OneOfEquals(t, b, names)
+	require.OneOfEquals(t, d, names)
+	repoInfo, err = c.InspectRepoSubvenance(d)
 	require.NoError(t, err)
-	_, err = c.PutFileSplit(repo, "master", "file3", pfs.Delimiter_LINE, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
+	require.Equal(t, 0, len(repoInfo.Subvenance))
+	// without the option, subvenance isn't looked up
+	repoInfo, err = c.InspectRepo(a)
 	require.NoError(t, err)
-	require.NoError(t, c.FinishCommit(repo, "master"))
-	_, err = c.StartCommit(repo, "master")
+	require.Equal(t, 0, len(repoInfo.Subvenance))
+
+	aCommit, err := c.StartCommit(a, "master")
 	require.NoError(t, err)
-	_, err = c.PutFileOverwrite(repo, "master", "file1", strings.NewReader("bar"), 0)
+	require.NoError(t, c.FinishCommit(a, aCommit.ID))
+	bCommit, err := c.PfsAPIClient.StartCommit(context.Background(), &pfs.StartCommitRequest{
+		Parent:     pclient.NewCommit(b, ""),
+		Branch:     "master",
+		Provenance: []*pfs.Commit{aCommit},
+	})
 	require.NoError(t, err)
-	_, err = c.PutFileOverwrite(repo, "master", "file2", strings.NewReader("buzz"), 0)
+	require.NoError(t, c.FinishCommit(b, bCommit.ID))
+	dCommit, err := c.PfsAPIClient.StartCommit(context.Background(), &pfs.StartCommitRequest{
+		Parent:     pclient.NewCommit(d, ""),
+		Provenance: []*pfs.Commit{bCommit},
+	})
 	require.NoError(t, err)
-	_, err = c.PutFileSplit(repo, "master", "file3", pfs.Delimiter_LINE, 0, 0, true, strings.NewReader("0\n1\n2\n"))
+	require.NoError(t, c.FinishCommit(d, dCommit.ID))
+
+	// a branch name is resolved to its head
+	commitInfo, err := c.InspectCommitSubvenance(a, "master")
 	require.NoError(t, err)
-	require.NoError(t, c.FinishCommit(repo, "master"))
-	var buffer bytes.Buffer
-	require.NoError(t, c.GetFile(repo, "master", "file1", 0, 0, &buffer))
-	require.Equal(t, "bar", buffer.String())
-	buffer.Reset()
-	require.NoError(t, c.GetFile(repo, "master", "file2", 0, 0, &buffer))
-	require.Equal(t, "buzz", buffer.String())
-	fileInfos, err := c.ListFile(repo, "master", "file3")
+	require.Equal(t, 2, len(commitInfo.Subvenance))
+	ids := []string{commitInfo.Subvenance[0].ID, commitInfo.Subvenance[1].ID}
+	require.OneOfEquals(t, bCommit.ID, ids)
+	require.OneOfEquals(t, dCommit.ID, ids)
+	commitInfo, err = c.InspectCommitSubvenance(b, bCommit.ID)
 	require.NoError(t, err)
-	require.Equal(t, 3, len(fileInfos))
-	for i := 0; i < 3; i++ {
-		buffer.Reset()
-		require.NoError(t, c.GetFile(repo, "master", fmt.Sprintf("file3/%016x", i), 0, 0, &buffer))
-		require.Equal(t, fmt.Sprintf("%d\n", i), buffer.String())
-	}
+	require.Equal(t, 1, len(commitInfo.Subvenance))
+	require.Equal(t, dCommit.ID, commitInfo.Subvenance[0].ID)
+
+	// a newer commit in a has nothing downstream of it yet
+	aCommit2, err := c.StartCommit(a, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(a, aCommit2.ID))
+	commitInfo, err = c.InspectCommitSubvenance(a, aCommit2.ID)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(commitInfo.Subvenance))
 }
 
-func TestCopyFile(t *testing.T) {
+func TestExportProvenanceGraph(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
 	}
 	t.Parallel()
-
 	c := getClient(t)
-	repo := uniqueString("TestCopyFile")
-	require.NoError(t, c.CreateRepo(repo))
-	_, err := c.StartCommit(repo, "master")
+	a, b, d, other := uniqueString("A"), uniqueString("B"), uniqueString("D"), uniqueString("other")
+	require.NoError(t, c.CreateRepo(a))
+	require.NoError(t, c.CreateRepo(other))
+	_, err := c.PfsAPIClient.CreateRepo(context.Background(), &pfs.CreateRepoRequest{
+		Repo:       pclient.NewRepo(b),
+		Provenance: []*pfs.Repo{pclient.NewRepo(a)},
+	})
 	require.NoError(t, err)
-	numFiles := 5
-	for i := 0; i < numFiles; i++ {
-		_, err = c.PutFile(repo, "master", fmt.Sprintf("files/%d", i), strings.NewReader(fmt.Sprintf("foo %d\n", i)))
-		require.NoError(t, err)
-	}
-	require.NoError(t, c.FinishCommit(repo, "master"))
-	_, err = c.StartCommit(repo, "other")
+	_, err = c.PfsAPIClient.CreateRepo(context.Background(), &pfs.CreateRepoRequest{
+		Repo:       pclient.NewRepo(d),
+		Provenance: []*pfs.Repo{pclient.NewRepo(b)},
+	})
+	require.NoError(t, err)
+
+	// the graph of b has its provenance and subvenance, but not other, and
+	// only has edges for immediate provenance
+	graph, err := c.ExportProvenanceGraph([]string{b}, false)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(graph.Nodes))
+	require.Equal(t, a, graph.Nodes[0].Id)
+	require.Equal(t, b, graph.Nodes[1].Id)
+	require.Equal(t, d, graph.Nodes[2].Id)
+	require.Equal(t, 2, len(graph.Edges))
+	require.Equal(t, &pfs.ProvenanceEdge{From: a, To: b}, graph.Edges[0])
+	require.Equal(t, &pfs.ProvenanceEdge{From: b, To: d}, graph.Edges[1])
+
+	graph, err = c.ExportProvenanceGraph(nil, false)
+	require.NoError(t, err)
+	var ids []string
+	for _, node := range graph.Nodes {
+		ids = append(ids, node.Id)
+	}
+	require.OneOfEquals(t, other, ids)
+
+	aCommit, err := c.StartCommit(a, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(a, aCommit.ID))
+	bCommit, err := c.PfsAPIClient.StartCommit(context.Background(), &pfs.StartCommitRequest{
+		Parent:     pclient.NewCommit(b, ""),
+		Provenance: []*pfs.Commit{aCommit},
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(b, bCommit.ID))
+	dCommit, err := c.PfsAPIClient.StartCommit(context.Background(), &pfs.StartCommitRequest{
+		Parent:     pclient.NewCommit(d, ""),
+		Provenance: []*pfs.Commit{bCommit},
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(d, dCommit.ID))
+
+	graph, err = c.ExportProvenanceGraph([]string{d}, true)
+	require.NoError(t, err)
+	require.Equal(t, 6, len(graph.Nodes))
+	// d's commit has both a's and b's commits as provenance, but only b is
+	// d's immediate provenance
+	aNode, bNode, dNode := a+"@"+aCommit.ID, b+"@"+bCommit.ID, d+"@"+dCommit.ID
+	require.OneOfEquals(t, &pfs.ProvenanceEdge{From: aNode, To: bNode}, graph.Edges)
+	require.OneOfEquals(t, &pfs.ProvenanceEdge{From: bNode, To: dNode}, graph.Edges)
+	require.NoneEquals(t, &pfs.ProvenanceEdge{From: aNode, To: dNode}, graph.Edges)
+	require.Equal(t, 4, len(graph.Edges))
+}
+
+func TestFlushOpenCommit(t *testing.T) {
+	t.Parallel()
+	client := getClient(t)
+	require.NoError(t, client.CreateRepo("A"))
+	_, err := client.PfsAPIClient.CreateRepo(context.Background(), &pfs.CreateRepoRequest{
+		Repo:       pclient.NewRepo("B"),
+		Provenance: []*pfs.Repo{pclient.NewRepo("A")},
+	})
+	ACommit, err := client.StartCommit("A", "")
+	require.NoError(t, err)
+
+	// do the other commits in a goro so we can block for them
+	go func() {
+		time.Sleep(5 * time.Second)
+		require.NoError(t, client.FinishCommit("A", ACommit.ID))
+		BCommit, err := client.PfsAPIClient.StartCommit(
+			context.Background(),
+			&pfs.StartCommitRequest{
+				Parent:     pclient.NewCommit("B", ""),
+				Provenance: []*pfs.Commit{ACommit},
+			},
+		)
+		require.NoError(t, err)
+		require.NoError(t, client.FinishCommit("B", BCommit.ID))
+	}()
+
+	// Flush ACommit
+	commitIter, err := client.FlushCommit([]*pfs.Commit{pclient.NewCommit("A", ACommit.ID)}, nil)
+	require.NoError(t, err)
+	commitInfos, err := collectCommitInfos(commitIter)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(commitInfos))
+}
+
+func TestEmptyFlush(t *testing.T) {
+	t.Parallel()
+	client := getClient(t)
+	commitIter, err := client.FlushCommit(nil, nil)
+	require.NoError(t, err)
+	_, err = collectCommitInfos(commitIter)
+	require.YesError(t, err)
+}
+
+func TestFlushNonExistentCommit(t *testing.T) {
+	t.Parallel()
+	c := getClient(t)
+	iter, err := c.FlushCommit([]*pfs.Commit{pclient.NewCommit("fake-repo", "fake-commit")}, nil)
+	require.NoError(t, err)
+	_, err = collectCommitInfos(iter)
+	require.YesError(t, err)
+	repo := "FlushNonExistentCommit"
+	require.NoError(t, c.CreateRepo(repo))
+	_, err = c.FlushCommit([]*pfs.Commit{pclient.NewCommit(repo, "fake-commit")}, nil)
+	require.NoError(t, err)
+	_, err = collectCommitInfos(iter)
+	require.YesError(t, err)
+}
+
+func TestPutFileSplit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	// create repos
+	repo := uniqueString("TestPutFileSplit")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFileSplit(repo, commit.ID, "none", pfs.Delimiter_NONE, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
+	require.NoError(t, err)
+	_, err = c.PutFileSplit(repo, commit.ID, "line", pfs.Delimiter_LINE, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
+	require.NoError(t, err)
+	_, err = c.PutFileSplit(repo, commit.ID, "line", pfs.Delimiter_LINE, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
+	require.NoError(t, err)
+	_, err = c.PutFileSplit(repo, commit.ID, "line2", pfs.Delimiter_LINE, 2, 0, false, strings.NewReader("foo\nbar\nbuz\nfiz\n"))
+	require.NoError(t, err)
+	_, err = c.PutFileSplit(repo, commit.ID, "line3", pfs.Delimiter_LINE, 0, 8, false, strings.NewReader("foo\nbar\nbuz\nfiz\n"))
+	require.NoError(t, err)
+	_, err = c.PutFileSplit(repo, commit.ID, "json", pfs.Delimiter_JSON, 0, 0, false, strings.NewReader("{}{}{}{}{}{}{}{}{}{}"))
+	require.NoError(t, err)
+	_, err = c.PutFileSplit(repo, commit.ID, "json", pfs.Delimiter_JSON, 0, 0, false, strings.NewReader("{}{}{}{}{}{}{}{}{}{}"))
+	require.NoError(t, err)
+	_, err = c.PutFileSplit(repo, commit.ID, "json2", pfs.Delimiter_JSON, 2, 0, false, strings.NewReader("{}{}{}{}"))
+	require.NoError(t, err)
+	_, err = c.PutFileSplit(repo, commit.ID, "json3", pfs.Delimiter_JSON, 0, 4, false, strings.NewReader("{}{}{}{}"))
+	require.NoError(t, err)
+
+	files, err := c.ListFile(repo, commit.ID, "line2")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(files))
+	for _, fileInfo := range files {
+		require.Equal(t, uint64(8), fileInfo.SizeBytes)
+	}
+
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+	commit2, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFileSplit(repo, commit2.ID, "line", pfs.Delimiter_LINE, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
+	require.NoError(t, err)
+	_, err = c.PutFileSplit(repo, commit2.ID, "json", pfs.Delimiter_JSON, 0, 0, false, strings.NewReader("{}{}{}{}{}{}{}{}{}{}"))
+	require.NoError(t, err)
+
+	files, err = c.ListFile(repo, commit2.ID, "line")
+	require.NoError(t, err)
+	require.Equal(t, 9, len(files))
+	for _, fileInfo := range files {
+		require.Equal(t, uint64(4), fileInfo.SizeBytes)
+	}
+
+	require.NoError(t, c.FinishCommit(repo, commit2.ID))
+	fileInfo, err := c.InspectFile(repo, commit.ID, "none")
+	require.NoError(t, err)
+	require.Equal(t, pfs.FileType_FILE, fileInfo.FileType)
+	files, err = c.ListFile(repo, commit.ID, "line")
+	require.NoError(t, err)
+	require.Equal(t, 6, len(files))
+	for _, fileInfo := range files {
+		require.Equal(t, uint64(4), fileInfo.SizeBytes)
+	}
+	files, err = c.ListFile(repo, commit2.ID, "line")
+	require.NoError(t, err)
+	require.Equal(t, 9, len(files))
+	for _, fileInfo := range files {
+		require.Equal(t, uint64(4), fileInfo.SizeBytes)
+	}
+	files, err = c.ListFile(repo, commit.ID, "line2")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(files))
+	for _, fileInfo := range files {
+		require.Equal(t, uint64(8), fileInfo.SizeBytes)
+	}
+	files, err = c.ListFile(repo, commit.ID, "line3")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(files))
+	for _, fileInfo := range files {
+		require.Equal(t, uint64(8), fileInfo.SizeBytes)
+	}
+	files, err = c.ListFile(repo, commit.ID, "json")
+	require.NoError(t, err)
+	require.Equal(t, 20, len(files))
+	for _, fileInfo := range files {
+		require.Equal(t, uint64(2), fileInfo.SizeBytes)
+	}
+	files, err = c.ListFile(repo, commit2.ID, "json")
+	require.NoError(t, err)
+	require.Equal(t, 30, len(files))
+	for _, fileInfo := range files {
+		require.Equal(t, uint64(2), fileInfo.SizeBytes)
+	}
+	files, err = c.ListFile(repo, commit.ID, "json2")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(files))
+	for _, fileInfo := range files {
+		require.Equal(t, uint64(4), fileInfo.SizeBytes)
+	}
+	files, err = c.ListFile(repo, commit.ID, "json3")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(files))
+	for _, fileInfo := range files {
+		require.Equal(t, uint64(4), fileInfo.SizeBytes)
+	}
+}
+
+func TestPutFileSplitDelete(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	// create repos
+	repo := uniqueString("TestPutFileSplitDelete")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFileSplit(repo, commit.ID, "line", pfs.Delimiter_LINE, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.DeleteFile(repo, commit.ID, fmt.Sprintf("line/%016x", 0)))
+	_, err = c.PutFileSplit(repo, commit.ID, "line", pfs.Delimiter_LINE, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.DeleteFile(repo, commit.ID, fmt.Sprintf("line/%016x", 5)))
+	_, err = c.PutFileSplit(repo, commit.ID, "line", pfs.Delimiter_LINE, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
+	require.NoError(t, err)
+
+	files, err := c.ListFile(repo, commit.ID, "line")
+	require.NoError(t, err)
+	require.Equal(t, 7, len(files))
+	for _, fileInfo := range files {
+		require.Equal(t, uint64(4), fileInfo.SizeBytes)
+	}
+
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	files, err = c.ListFile(repo, commit.ID, "line")
+	require.NoError(t, err)
+	require.Equal(t, 7, len(files))
+	for _, fileInfo := range files {
+		require.Equal(t, uint64(4), fileInfo.SizeBytes)
+	}
+}
+
+func TestPutFileSplitBig(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	// create repos
+	repo := uniqueString("TestPutFileSplitBig")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	w, err := c.PutFileSplitWriter(repo, commit.ID, "line", pfs.Delimiter_LINE, 0, 0, false)
+	require.NoError(t, err)
+	for i := 0; i < 1000; i++ {
+		_, err = w.Write([]byte("foo\n"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+	files, err := c.ListFile(repo, commit.ID, "line")
+	require.NoError(t, err)
+	require.Equal(t, 1000, len(files))
+	for _, fileInfo := range files {
+		require.Equal(t, uint64(4), fileInfo.SizeBytes)
+	}
+}
+
+func TestPutFileSplitWithStats(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestPutFileSplitWithStats")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFileSplitWithOptions(repo, commit.ID, "csv", pclient.PutFileSplitOptions{Delimiter: pfs.Delimiter_LINE, TargetFileDatums: 2, ComputeStats: true},
+		strings.NewReader("name,age\nalice,31\nbob,9\ncarol,100\n"))
+	require.NoError(t, err)
+	_, err = c.PutFileSplitWithOptions(repo, commit.ID, "json", pclient.PutFileSplitOptions{Delimiter: pfs.Delimiter_JSON, ComputeStats: true},
+		strings.NewReader(`{"id": 10, "ok": true}`+"\n"+`{"id": 2, "tags": ["a"]}`))
+	require.NoError(t, err)
+	_, err = c.PutFileSplitWithOptions(repo, commit.ID, "raw", pclient.PutFileSplitOptions{Delimiter: pfs.Delimiter_NONE, ComputeStats: true}, strings.NewReader("foo"))
+	require.YesError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	// the header is on the first line, so the first file only has one row
+	fileInfo, err := c.InspectFile(repo, commit.ID, fmt.Sprintf("csv/%016x", 0))
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), fileInfo.Stats.RowCount)
+	fileInfo, err = c.InspectFile(repo, commit.ID, fmt.Sprintf("csv/%016x", 1))
+	require.NoError(t, err)
+	require.Equal(t, &pfs.TableStats{
+		RowCount: 2,
+		Columns: []*pfs.ColumnStats{
+			{Name: "name", Min: "bob", Max: "carol"},
+			{Name: "age", Min: "9", Max: "100"},
+		},
+	}, fileInfo.Stats)
+	fileInfo, err = c.InspectFile(repo, commit.ID, "csv")
+	require.NoError(t, err)
+	require.Equal(t, &pfs.TableStats{
+		RowCount: 3,
+		Columns: []*pfs.ColumnStats{
+			{Name: "name", Min: "alice", Max: "carol"},
+			{Name: "age", Min: "9", Max: "100"},
+		},
+	}, fileInfo.Stats)
+
+	fileInfo, err = c.InspectFile(repo, commit.ID, "json")
+	require.NoError(t, err)
+	require.Equal(t, &pfs.TableStats{
+		RowCount: 2,
+		Columns: []*pfs.ColumnStats{
+			{Name: "id", Min: "2", Max: "10"},
+			{Name: "ok", Min: "true", Max: "true"},
+		},
+	}, fileInfo.Stats)
+}
+
+func TestStageAndPublishCommit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestStageAndPublishCommit")
+	require.NoError(t, c.CreateRepo(repo))
+	commit1, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit1.ID))
+
+	commit2, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit2.ID, "file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.StageCommit(repo, commit2.ID, nil))
+
+	// the staged commit is finished, but master still points at its parent
+	commitInfo, err := c.InspectCommit(repo, commit2.ID)
+	require.NoError(t, err)
+	require.NotNil(t, commitInfo.Finished)
+	require.True(t, commitInfo.Staged)
+	require.Equal(t, []string{"master"}, commitInfo.StagedBranches)
+	commitInfo, err = c.InspectCommit(repo, "master")
+	require.NoError(t, err)
+	require.Equal(t, commit1.ID, commitInfo.Commit.ID)
+	require.YesError(t, c.SetBranch(repo, commit2.ID, "other"))
+
+	require.NoError(t, c.PublishCommit(repo, commit2.ID))
+	require.YesError(t, c.PublishCommit(repo, commit2.ID))
+	commitInfo, err = c.InspectCommit(repo, "master")
+	require.NoError(t, err)
+	require.Equal(t, commit2.ID, commitInfo.Commit.ID)
+	require.False(t, commitInfo.Staged)
+
+	// publishing fails if the branch has moved since the commit was staged
+	commit3, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.StageCommit(repo, commit3.ID, nil))
+	commit4, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit4.ID))
+	require.YesError(t, c.PublishCommit(repo, commit3.ID))
+}
+
+func TestReviewCommit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestReviewCommit")
+	require.NoError(t, c.CreateRepo(repo))
+	commit1, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	// open commits can't be reviewed
+	require.YesError(t, c.ApproveCommit(repo, commit1.ID, ""))
+	require.NoError(t, c.StageCommit(repo, commit1.ID, nil))
+	commit2, err := c.StartCommit(repo, "other")
+	require.NoError(t, err)
+	require.NoError(t, c.StageCommit(repo, commit2.ID, nil))
+
+	require.NoError(t, c.RejectCommit(repo, commit1.ID, "missing file"))
+	require.NoError(t, c.ApproveCommit(repo, commit1.ID, "looks good"))
+	commitInfo, err := c.InspectCommit(repo, commit1.ID)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(commitInfo.Reviews))
+	require.Equal(t, pfs.ReviewDecision_REJECT, commitInfo.Reviews[0].Decision)
+	require.Equal(t, "missing file", commitInfo.Reviews[0].Comment)
+	require.Equal(t, pfs.ReviewDecision_APPROVE, commitInfo.Reviews[1].Decision)
+	require.Equal(t, "looks good", commitInfo.Reviews[1].Comment)
+	require.NotNil(t, commitInfo.Reviews[1].Time)
+
+	commitInfos, err := c.ListPendingApprovals(repo, "")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(commitInfos))
+	require.Equal(t, commit1.ID, commitInfos[0].Commit.ID)
+	require.Equal(t, commit2.ID, commitInfos[1].Commit.ID)
+	commitInfos, err = c.ListPendingApprovals(repo, "master")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(commitInfos))
+	require.Equal(t, commit1.ID, commitInfos[0].Commit.ID)
+
+	// published commits are no longer pending
+	require.NoError(t, c.PublishCommit(repo, commit1.ID))
+	commitInfos, err = c.ListPendingApprovals(repo, "master")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(commitInfos))
+
+	// nor are rejected ones, which can't be published
+	require.NoError(t, c.RejectCommit(repo, commit2.ID, "wrong branch"))
+	commitInfos, err = c.ListPendingApprovals(repo, "")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(commitInfos))
+	require.YesError(t, c.PublishCommit(repo, commit2.ID))
+}
+
+func TestPutFileMode(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestPutFileMode")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFileWithMode(repo, commit.ID, "file", pfs.PutFileMode_CREATE_ONLY, strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	// the file exists in the open commit
+	_, err = c.PutFileWithMode(repo, commit.ID, "file", pfs.PutFileMode_CREATE_ONLY, strings.NewReader("bar\n"))
+	require.YesError(t, err)
+	_, err = c.PutFileWithMode(repo, commit.ID, "file", pfs.PutFileMode_APPEND, strings.NewReader("bar\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(repo, commit.ID, "file", 0, 0, &buffer))
+	require.Equal(t, "foo\nbar\n", buffer.String())
+
+	commit, err = c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	// the file exists in the parent commit
+	_, err = c.PutFileWithMode(repo, commit.ID, "file", pfs.PutFileMode_CREATE_ONLY, strings.NewReader("baz\n"))
+	require.YesError(t, err)
+	_, err = c.PutFileWithMode(repo, commit.ID, "file", pfs.PutFileMode_OVERWRITE, strings.NewReader("baz\n"))
+	require.NoError(t, err)
+	batch, err := c.NewPutFilesBatch()
+	require.NoError(t, err)
+	_, err = batch.PutFileWithMode(repo, commit.ID, "file", pfs.PutFileMode_OVERWRITE, strings.NewReader("buzz\n"))
+	require.NoError(t, err)
+	_, err = batch.PutFileWithMode(repo, commit.ID, "other", pfs.PutFileMode_CREATE_ONLY, strings.NewReader("other\n"))
+	require.NoError(t, err)
+	require.NoError(t, batch.Close())
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+	buffer.Reset()
+	require.NoError(t, c.GetFile(repo, commit.ID, "file", 0, 0, &buffer))
+	require.Equal(t, "buzz\n", buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.GetFile(repo, commit.ID, "other", 0, 0, &buffer))
+	require.Equal(t, "other\n", buffer.String())
+}
+
+func TestGetFileTar(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestGetFileTar")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "dir/foo", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "dir/sub/bar", strings.NewReader("bar\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "dirfoo", strings.NewReader("sibling\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	readTar := func(path string) map[string]string {
+		var buf bytes.Buffer
+		require.NoError(t, c.GetFileTar(repo, commit.ID, path, &buf))
+		entries := make(map[string]string)
+		tr := tar.NewReader(&buf)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			content, err := ioutil.ReadAll(tr)
+			require.NoError(t, err)
+			entries[hdr.Name] = string(content)
+		}
+		return entries
+	}
+	require.Equal(t, map[string]string{
+		"foo":     "foo\n",
+		"sub/":    "",
+		"sub/bar": "bar\n",
+	}, readTar("dir"))
+	require.Equal(t, map[string]string{"bar": "bar\n"}, readTar("dir/sub/bar"))
+	require.Equal(t, 5, len(readTar("")))
+
+	require.YesError(t, c.GetFileTar(repo, commit.ID, "nonexistent", ioutil.Discard))
+}
+
+func TestCommitHooks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestCommitHooks")
+	require.NoError(t, c.CreateRepo(repo))
+	// nothing listens on port 1, so deliveries to "first" fail
+	require.NoError(t, c.CreateCommitHook(repo, "first", "http://127.0.0.1:1/"))
+	require.NoError(t, c.CreateCommitHook(repo, "second", "http://127.0.0.1:1/"))
+	require.YesError(t, c.CreateCommitHook(repo, "first", "http://127.0.0.1:1/"))
+	require.YesError(t, c.CreateCommitHook(repo, "third", "not a url"))
+
+	_, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, "master"))
+
+	require.NoError(t, backoff.Retry(func() error {
+		hookInfos, err := c.ListCommitHook(repo)
+		if err != nil {
+			return err
+		}
+		require.Equal(t, 2, len(hookInfos))
+		require.Equal(t, "first", hookInfos[0].Name)
+		require.Equal(t, "second", hookInfos[1].Name)
+		if len(hookInfos[0].Branches) == 0 || hookInfos[0].Branches[0].Attempts == 0 {
+			return fmt.Errorf("commit hook hasn't been attempted yet")
+		}
+		state := hookInfos[0].Branches[0]
+		require.Equal(t, "master", state.Branch)
+		require.Nil(t, state.Delivered)
+		require.NotEqual(t, "", state.LastError)
+		// the second hook waits for the first
+		require.Equal(t, 0, len(hookInfos[1].Branches))
+		return nil
+	}, backoff.NewTestingBackOff()))
+
+	require.NoError(t, c.DeleteCommitHook(repo, "first"))
+	require.YesError(t, c.DeleteCommitHook(repo, "first"))
+	hookInfos, err := c.ListCommitHook(repo)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(hookInfos))
+	require.Equal(t, "second", hookInfos[0].Name)
+}
+
+func TestCommitHookEvents(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	// the hook fails the first request it gets, so that the first event is
+	// sent again
+	var mu sync.Mutex
+	var events []*pfs.CommitHookEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		event := &pfs.CommitHookEvent{}
+		require.NoError(t, jsonpb.Unmarshal(r.Body, event))
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+		if len(events) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	waitForEvents := func(n int) []*pfs.CommitHookEvent {
+		var result []*pfs.CommitHookEvent
+		require.NoError(t, backoff.Retry(func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			if len(events) < n {
+				return fmt.Errorf("got %d events, expected %d", len(events), n)
+			}
+			result = append([]*pfs.CommitHookEvent(nil), events...)
+			return nil
+		}, backoff.NewTestingBackOff()))
+		return result
+	}
+
+	repo := uniqueString("TestCommitHookEvents")
+	require.NoError(t, c.CreateRepo(repo))
+	require.NoError(t, c.CreateCommitHook(repo, "hook", server.URL))
+	hookInfos, err := c.ListCommitHook(repo)
+	require.NoError(t, err)
+	hookID := hookInfos[0].ID
+	commit1, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit1.ID))
+	commit2, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit2.ID))
+
+	// the first event is sent again, unchanged, after it fails
+	received := waitForEvents(3)
+	require.Equal(t, 3, len(received))
+	for i, expected := range []struct {
+		commit   *pfs.Commit
+		sequence uint64
+	}{{commit1, 1}, {commit1, 1}, {commit2, 2}} {
+		require.Equal(t, expected.commit.ID, received[i].CommitInfo.Commit.ID)
+		require.Equal(t, expected.sequence, received[i].Sequence)
+		require.Equal(t, fmt.Sprintf("%s/master/%d", hookID, expected.sequence), received[i].ID)
+		require.Equal(t, "master", received[i].Branch)
+	}
+
+	// moving the branch back resets its delivery state rather than sending
+	// its commits again
+	require.NoError(t, c.SetBranch(repo, commit1.ID, "master"))
+	commit3, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit3.ID))
+	received = waitForEvents(4)
+	require.Equal(t, 4, len(received))
+	require.Equal(t, commit3.ID, received[3].CommitInfo.Commit.ID)
+	require.Equal(t, uint64(3), received[3].Sequence)
+	hookInfos, err = c.ListCommitHook(repo)
+	require.NoError(t, err)
+	state := hookInfos[0].Branches[0]
+	require.Equal(t, commit3.ID, state.Delivered.ID)
+	require.Equal(t, uint64(3), state.Sequence)
+	require.Equal(t, commit2.ID, state.ResetFrom.ID)
+}
+
+func TestInspectFeatureUsage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestInspectFeatureUsage")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFileSplit(repo, commit.ID, "line", pfs.Delimiter_LINE, 0, 0, false, strings.NewReader("foo\nbar\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	// other tests share the server, so the counts are lower bounds
+	usage, err := c.InspectFeatureUsage()
+	require.NoError(t, err)
+	require.False(t, usage.Enabled)
+	require.Equal(t, "", usage.ClusterID)
+	require.True(t, usage.Repos >= 1)
+	require.True(t, usage.Features["split_line"] >= 1)
+}
+
+func TestFeatureFlags(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	upstream := uniqueString("TestFeatureFlagsUpstream")
+	other := uniqueString("TestFeatureFlagsOther")
+	repo := uniqueString("TestFeatureFlags")
+	require.NoError(t, c.CreateRepo(upstream))
+	require.NoError(t, c.CreateRepo(other))
+	_, err := c.PfsAPIClient.CreateRepo(context.Background(), &pfs.CreateRepoRequest{
+		Repo:       pclient.NewRepo(repo),
+		Provenance: []*pfs.Repo{pclient.NewRepo(upstream)},
+	})
+	require.NoError(t, err)
+	require.YesError(t, c.SetFeatureFlag("no_such_flag", repo, pfs.FeatureFlagSetting_ON))
+
+	// the flags are only set for repo, as other tests share the cluster
+	require.NoError(t, c.SetFeatureFlag("overwrite_by_default", repo, pfs.FeatureFlagSetting_ON))
+	require.NoError(t, c.SetFeatureFlag("strict_split", repo, pfs.FeatureFlagSetting_ON))
+	require.NoError(t, c.SetFeatureFlag("strict_provenance", repo, pfs.FeatureFlagSetting_ON))
+	flags, err := c.ListFeatureFlags()
+	require.NoError(t, err)
+	settings := make(map[string]pfs.FeatureFlagSetting)
+	for _, flag := range flags {
+		settings[flag.Name] = flag.Repos[repo]
+	}
+	require.Equal(t, pfs.FeatureFlagSetting_ON, settings["overwrite_by_default"])
+	require.Equal(t, pfs.FeatureFlagSetting_ON, settings["strict_split"])
+	require.Equal(t, pfs.FeatureFlagSetting_ON, settings["strict_provenance"])
+
+	_, err = c.PutFile(repo, "master", "file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, "master", "file", strings.NewReader("bar\n"))
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, c.GetFile(repo, "master", "file", 0, 0, &buf))
+	require.Equal(t, "bar\n", buf.String())
+	_, err = c.PutFileSplit(repo, "master", "lines", pfs.Delimiter_LINE, 0, 0, false, strings.NewReader("foo\nbar"))
+	require.YesError(t, err)
+
+	otherCommit, err := c.StartCommit(other, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(other, otherCommit.ID))
+	_, err = c.PfsAPIClient.StartCommit(context.Background(), &pfs.StartCommitRequest{
+		Parent:     pclient.NewCommit(repo, ""),
+		Provenance: []*pfs.Commit{otherCommit},
+	})
+	require.YesError(t, err)
+
+	// unsetting the flag falls back on the default, which appends
+	require.NoError(t, c.SetFeatureFlag("overwrite_by_default", repo, pfs.FeatureFlagSetting_UNSET))
+	_, err = c.PutFile(repo, "master", "file", strings.NewReader("baz\n"))
+	require.NoError(t, err)
+	buf.Reset()
+	require.NoError(t, c.GetFile(repo, "master", "file", 0, 0, &buf))
+	require.Equal(t, "bar\nbaz\n", buf.String())
+}
+
+func TestDiff(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestDiff")
+	require.NoError(t, c.CreateRepo(repo))
+
+	// Write foo
+	_, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, "master", "foo", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+
+	newFiles, oldFiles, err := c.DiffFile(repo, "master", "", "", "", "", false)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(newFiles))
+	require.Equal(t, "foo", newFiles[0].File.Path)
+	require.Equal(t, 0, len(oldFiles))
+
+	require.NoError(t, c.FinishCommit(repo, "master"))
+
+	newFiles, oldFiles, err = c.DiffFile(repo, "master", "", "", "", "", false)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(newFiles))
+	require.Equal(t, "foo", newFiles[0].File.Path)
+	require.Equal(t, 0, len(oldFiles))
+
+	// Change the value of foo
+	_, err = c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.DeleteFile(repo, "master", "foo"))
+	_, err = c.PutFile(repo, "master", "foo", strings.NewReader("not foo\n"))
+	require.NoError(t, err)
+
+	newFiles, oldFiles, err = c.DiffFile(repo, "master", "", "", "", "", false)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(newFiles))
+	require.Equal(t, "foo", newFiles[0].File.Path)
+	require.Equal(t, 1, len(oldFiles))
+	require.Equal(t, "foo", oldFiles[0].File.Path)
+
+	require.NoError(t, c.FinishCommit(repo, "master"))
+
+	newFiles, oldFiles, err = c.DiffFile(repo, "master", "", "", "", "", false)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(newFiles))
+	require.Equal(t, "foo", newFiles[0].File.Path)
+	require.Equal(t, 1, len(oldFiles))
+	require.Equal(t, "foo", oldFiles[0].File.Path)
+
+	// Write bar
+	_, err = c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, "master", "bar", strings.NewReader("bar\n"))
+	require.NoError(t, err)
+
+	newFiles, oldFiles, err = c.DiffFile(repo, "master", "", "", "", "", false)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(newFiles))
+	require.Equal(t, "bar", newFiles[0].File.Path)
+	require.Equal(t, 0, len(oldFiles))
+
+	require.NoError(t, c.FinishCommit(repo, "master"))
+
+	newFiles, oldFiles, err = c.DiffFile(repo, "master", "", "", "", "", false)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(newFiles))
+	require.Equal(t, "bar", newFiles[0].File.Path)
+	require.Equal(t, 0, len(oldFiles))
+
+	// Delete bar
+	_, err = c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.DeleteFile(repo, "master", "bar"))
+
+	newFiles, oldFiles, err = c.DiffFile(repo, "master", "", "", "", "", false)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(newFiles))
+	require.Equal(t, 1, len(oldFiles))
+	require.Equal(t, "bar", oldFiles[0].File.Path)
+
+	require.NoError(t, c.FinishCommit(repo, "master"))
+
+	newFiles, oldFiles, err = c.DiffFile(repo, "master", "", "", "", "", false)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(newFiles))
+	require.Equal(t, 1, len(oldFiles))
+	require.Equal(t, "bar", oldFiles[0].File.Path)
+
+	// Write dir/fizz and dir/buzz
+	_, err = c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, "master", "dir/fizz", strings.NewReader("fizz\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, "master", "dir/buzz", strings.NewReader("buzz\n"))
+	require.NoError(t, err)
+
+	newFiles, oldFiles, err = c.DiffFile(repo, "master", "", "", "", "", false)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(newFiles))
+	require.Equal(t, 0, len(oldFiles))
+
+	require.NoError(t, c.FinishCommit(repo, "master"))
+
+	newFiles, oldFiles, err = c.DiffFile(repo, "master", "", "", "", "", false)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(newFiles))
+	require.Equal(t, 0, len(oldFiles))
+
+	// Modify dir/fizz
+	_, err = c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, "master", "dir/fizz", strings.NewReader("fizz\n"))
+	require.NoError(t, err)
+
+	newFiles, oldFiles, err = c.DiffFile(repo, "master", "", "", "", "", false)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(newFiles))
+	require.Equal(t, "dir/fizz", newFiles[0].File.Path)
+	require.Equal(t, 1, len(oldFiles))
+	require.Equal(t, "dir/fizz", oldFiles[0].File.Path)
+
+	require.NoError(t, c.FinishCommit(repo, "master"))
+
+	newFiles, oldFiles, err = c.DiffFile(repo, "master", "", "", "", "", false)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(newFiles))
+	require.Equal(t, "dir/fizz", newFiles[0].File.Path)
+	require.Equal(t, 1, len(oldFiles))
+	require.Equal(t, "dir/fizz", oldFiles[0].File.Path)
+}
+
+func TestGlob(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestGlob")
+	require.NoError(t, c.CreateRepo(repo))
+
+	// Write foo
+	numFiles := 100
+	_, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	for i := 0; i < numFiles; i++ {
+		_, err = c.PutFile(repo, "master", fmt.Sprintf("file%d", i), strings.NewReader(""))
+		require.NoError(t, err)
+		_, err = c.PutFile(repo, "master", fmt.Sprintf("dir1/file%d", i), strings.NewReader(""))
+		require.NoError(t, err)
+		_, err = c.PutFile(repo, "master", fmt.Sprintf("dir2/dir3/file%d", i), strings.NewReader(""))
+		require.NoError(t, err)
+	}
+
+	fileInfos, err := c.GlobFile(repo, "master", "*")
+	require.NoError(t, err)
+	require.Equal(t, numFiles+2, len(fileInfos))
+	fileInfos, err = c.GlobFile(repo, "master", "file*")
+	require.NoError(t, err)
+	require.Equal(t, numFiles, len(fileInfos))
+	fileInfos, err = c.GlobFile(repo, "master", "dir1/*")
+	require.NoError(t, err)
+	require.Equal(t, numFiles, len(fileInfos))
+	fileInfos, err = c.GlobFile(repo, "master", "dir2/dir3/*")
+	require.NoError(t, err)
+	require.Equal(t, numFiles, len(fileInfos))
+	fileInfos, err = c.GlobFile(repo, "master", "*/*")
+	require.NoError(t, err)
+	require.Equal(t, numFiles+1, len(fileInfos))
+
+	require.NoError(t, c.FinishCommit(repo, "master"))
+
+	fileInfos, err = c.GlobFile(repo, "master", "*")
+	require.NoError(t, err)
+	require.Equal(t, numFiles+2, len(fileInfos))
+	fileInfos, err = c.GlobFile(repo, "master", "file*")
+	require.NoError(t, err)
+	require.Equal(t, numFiles, len(fileInfos))
+	fileInfos, err = c.GlobFile(repo, "master", "dir1/*")
+	require.NoError(t, err)
+	require.Equal(t, numFiles, len(fileInfos))
+	fileInfos, err = c.GlobFile(repo, "master", "dir2/dir3/*")
+	require.NoError(t, err)
+	require.Equal(t, numFiles, len(fileInfos))
+	fileInfos, err = c.GlobFile(repo, "master", "*/*")
+	require.NoError(t, err)
+	require.Equal(t, numFiles+1, len(fileInfos))
+}
+
+func TestOverwrite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestGlob")
+	require.NoError(t, c.CreateRepo(repo))
+
+	// Write foo
+	_, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, "master", "file1", strings.NewReader("foo"))
+	_, err = c.PutFileSplit(repo, "master", "file2", pfs.Delimiter_LINE, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
+	require.NoError(t, err)
+	_, err = c.PutFileSplit(repo, "master", "file3", pfs.Delimiter_LINE, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, "master"))
+	_, err = c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFileOverwrite(repo, "master", "file1", strings.NewReader("bar"), 0)
+	require.NoError(t, err)
+	_, err = c.PutFileOverwrite(repo, "master", "file2", strings.NewReader("buzz"), 0)
+	require.NoError(t, err)
+	_, err = c.PutFileSplit(repo, "master", "file3", pfs.Delimiter_LINE, 0, 0, true, strings.NewReader("0\n1\n2\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, "master"))
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(repo, "master", "file1", 0, 0, &buffer))
+	require.Equal(t, "bar", buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.GetFile(repo, "master", "file2", 0, 0, &buffer))
+	require.Equal(t, "buzz", buffer.String())
+	fileInfos, err := c.ListFile(repo, "master", "file3")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(fileInfos))
+	for i := 0; i < 3; i++ {
+		buffer.Reset()
+		require.NoError(t, c.GetFile(repo, "master", fmt.Sprintf("file3/%016x", i), 0, 0, &buffer))
+		require.Equal(t, fmt.Sprintf("%d\n", i), buffer.String())
+	}
+}
+
+func TestCopyFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestCopyFile")
+	require.NoError(t, c.CreateRepo(repo))
+	_, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	numFiles := 5
+	for i := 0; i < numFiles; i++ {
+		_, err = c.PutFile(repo, "master", fmt.Sprintf("files/%d", i), strings.NewReader(fmt.Sprintf("foo %d\n", i)))
+		require.NoError(t, err)
+	}
+	require.NoError(t, c.FinishCommit(repo, "master"))
+	_, err = c.StartCommit(repo, "other")
 	require.NoError(t, err)
 	require.NoError(t, c.CopyFile(repo, "master", "files", repo, "other", "files", false))
 	require.NoError(t, c.CopyFile(repo, "master", "files/0", repo, "other", "file0", false))
 	require.NoError(t, c.FinishCommit(repo, "other"))
-	for i := 0; i < numFiles; i++ {
-		var b bytes.Buffer
-		require.NoError(t, c.GetFile(repo, "other", fmt.Sprintf("files/%d", i), 0, 0, &b))
-		require.Equal(t, fmt.Sprintf("foo %d\n", i), b.String())
+	for i := 0; i < numFiles; i++ {
+		var b bytes.Buffer
+		require.NoError(t, c.GetFile(repo, "other", fmt.Sprintf("files/%d", i), 0, 0, &b))
+		require.Equal(t, fmt.Sprintf("foo %d\n", i), b.String())
+	}
+	var b bytes.Buffer
+	require.NoError(t, c.GetFile(repo, "other", "file0", 0, 0, &b))
+	require.Equal(t, "foo 0\n", b.String())
+	_, err = c.StartCommit(repo, "other")
+	require.NoError(t, c.CopyFile(repo, "other", "files/0", repo, "other", "files", true))
+	require.NoError(t, c.FinishCommit(repo, "other"))
+	b.Reset()
+	require.NoError(t, c.GetFile(repo, "other", "files", 0, 0, &b))
+	require.Equal(t, "foo 0\n", b.String())
+}
+
+func TestCopyFileOverwrite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestCopyFileOverwrite")
+	require.NoError(t, c.CreateRepo(repo))
+	for _, data := range []string{"foo\n", "bar\n"} {
+		_, err := c.StartCommit(repo, "master")
+		require.NoError(t, err)
+		_, err = c.PutFile(repo, "master", "file", strings.NewReader(data))
+		require.NoError(t, err)
+		_, err = c.PutFile(repo, "master", "src", strings.NewReader("buzz\n"))
+		require.NoError(t, err)
+		require.NoError(t, c.FinishCommit(repo, "master"))
+	}
+
+	// copying to index 1 replaces "bar\n" and keeps "foo\n"
+	_, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.CopyFileOverwrite(repo, "master", "src", repo, "master", "file", 1))
+	_, err = c.PutFile(repo, "master", "file", strings.NewReader("fizz\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, "master"))
+	var b bytes.Buffer
+	require.NoError(t, c.GetFile(repo, "master", "file", 0, 0, &b))
+	require.Equal(t, "foo\nbuzz\nbuzz\nfizz\n", b.String())
+
+	// copying to index 0 replaces the whole file
+	_, err = c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.CopyFileOverwrite(repo, "master", "src", repo, "master", "file", 0))
+	require.NoError(t, c.FinishCommit(repo, "master"))
+	b.Reset()
+	require.NoError(t, c.GetFile(repo, "master", "file", 0, 0, &b))
+	require.Equal(t, "buzz\nbuzz\n", b.String())
+}
+
+func TestBuildCommit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestBuildCommit")
+	require.NoError(t, c.CreateRepo(repo))
+
+	tree1 := hashtree.NewHashTree()
+	fooObj, fooSize, err := c.PutObject(strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, tree1.PutFile("foo", []*pfs.Object{fooObj}, fooSize))
+	tree1Finish, err := tree1.Finish()
+	require.NoError(t, err)
+	serialized, err := hashtree.Serialize(tree1Finish)
+	require.NoError(t, err)
+	tree1Obj, _, err := c.PutObject(bytes.NewReader(serialized))
+	_, err = c.BuildCommit(repo, "master", "", tree1Obj.Hash)
+	require.NoError(t, err)
+	repoInfo, err := c.InspectRepo(repo)
+	require.NoError(t, err)
+	require.Equal(t, uint64(fooSize), repoInfo.SizeBytes)
+	commitInfo, err := c.InspectCommit(repo, "master")
+	require.NoError(t, err)
+	require.Equal(t, uint64(fooSize), commitInfo.SizeBytes)
+
+	barObj, barSize, err := c.PutObject(strings.NewReader("bar\n"))
+	require.NoError(t, err)
+	require.NoError(t, tree1.PutFile("bar", []*pfs.Object{barObj}, barSize))
+	tree2Finish, err := tree1.Finish()
+	require.NoError(t, err)
+	serialized, err = hashtree.Serialize(tree2Finish)
+	require.NoError(t, err)
+	tree2Obj, _, err := c.PutObject(bytes.NewReader(serialized))
+	_, err = c.BuildCommit(repo, "master", "", tree2Obj.Hash)
+	require.NoError(t, err)
+	repoInfo, err = c.InspectRepo(repo)
+	require.NoError(t, err)
+	require.Equal(t, uint64(fooSize+barSize), repoInfo.SizeBytes)
+	commitInfo, err = c.InspectCommit(repo, "master")
+	require.NoError(t, err)
+	require.Equal(t, uint64(fooSize+barSize), commitInfo.SizeBytes)
+}
+
+func TestFinishCommitProgress(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	// the files are checked against a schema that's served by fetch, which
+	// holds FinishCommit up after the scratch records are applied until
+	// release is closed
+	fetch := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetch <- struct{}{}
+		<-release
+		fmt.Fprint(w, `{"type": "object"}`)
+	}))
+	defer server.Close()
+	repo := uniqueString("TestFinishCommitProgress")
+	require.NoError(t, c.CreateRepo(repo))
+	require.NoError(t, c.SetSchema(repo, "", &pfs.Schema{Type: pfs.SchemaType_JSON_SCHEMA, Url: server.URL}))
+
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		_, err = c.PutFile(repo, commit.ID, fmt.Sprintf("file%d", i), strings.NewReader("{}\n"))
+		require.NoError(t, err)
+	}
+	// No progress is reported for a commit that isn't being finished
+	commitInfo, err := c.InspectCommit(repo, commit.ID)
+	require.NoError(t, err)
+	require.Nil(t, commitInfo.Progress)
+
+	var eg errgroup.Group
+	eg.Go(func() error {
+		return c.FinishCommit(repo, commit.ID)
+	})
+	// Progress is reported while the commit is being finished
+	<-fetch
+	commitInfo, err = c.InspectCommit(repo, commit.ID)
+	require.NoError(t, err)
+	require.Nil(t, commitInfo.Finished)
+	require.NotNil(t, commitInfo.Progress)
+	require.Equal(t, uint64(100), commitInfo.Progress.RecordsTotal)
+	require.True(t, commitInfo.Progress.RecordsApplied > 0)
+	close(release)
+	require.NoError(t, eg.Wait())
+	// Progress is removed once the commit is finished
+	commitInfo, err = c.InspectCommit(repo, commit.ID)
+	require.NoError(t, err)
+	require.NotNil(t, commitInfo.Finished)
+	require.Nil(t, commitInfo.Progress)
+
+	// and if the commit fails to finish
+	commit, err = c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "bad", strings.NewReader("[]\n"))
+	require.NoError(t, err)
+	require.YesError(t, c.FinishCommit(repo, commit.ID))
+	<-fetch
+	commitInfo, err = c.InspectCommit(repo, commit.ID)
+	require.NoError(t, err)
+	require.Nil(t, commitInfo.Finished)
+	require.Nil(t, commitInfo.Progress)
+}
+
+func TestDataCard(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestDataCard")
+	require.NoError(t, c.CreateRepo(repo))
+
+	dataCard := &pfs.DataCard{
+		Description: "Signups per day",
+		License:     "MIT",
+		RowCount:    2,
+		Schema: []*pfs.DataCardField{
+			{Name: "date", Type: "string"},
+			{Name: "signups", Type: "int", Description: "number of new users"},
+		},
+	}
+	commit1, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit1.ID, "signups.csv", strings.NewReader("2018-01-01,10\n2018-01-02,12\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommitWithDataCard(repo, commit1.ID, dataCard))
+
+	// The data card is stored as metadata, not as a file
+	fileInfos, err := c.ListFile(repo, commit1.ID, "")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(fileInfos))
+	card, err := c.GetDataCard(repo, commit1.ID)
+	require.NoError(t, err)
+	require.Equal(t, dataCard, card)
+
+	// Commits finished without a data card don't have one
+	commit2, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit2.ID))
+	card, err = c.GetDataCard(repo, commit2.ID)
+	require.NoError(t, err)
+	require.Nil(t, card)
+
+	commitInfos, err := c.SearchDataCards(repo, "", "")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(commitInfos))
+	require.Equal(t, commit1.ID, commitInfos[0].Commit.ID)
+	commitInfos, err = c.SearchDataCards(repo, "NEW USERS", "MIT")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(commitInfos))
+	commitInfos, err = c.SearchDataCards("", "signups", "")
+	require.NoError(t, err)
+	require.True(t, len(commitInfos) >= 1)
+	commitInfos, err = c.SearchDataCards(repo, "signups", "Apache-2.0")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(commitInfos))
+	commitInfos, err = c.SearchDataCards(repo, "revenue", "")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(commitInfos))
+}
+
+func TestPutFiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestPutFiles")
+	require.NoError(t, c.CreateRepo(repo))
+
+	commit1, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit1.ID, "old", strings.NewReader("old\n"))
+	require.NoError(t, err)
+	batch, err := c.NewPutFilesBatch()
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		_, err = batch.PutFile(repo, commit1.ID, fmt.Sprintf("file%d", i), strings.NewReader(fmt.Sprintf("%d\n", i)))
+		require.NoError(t, err)
+	}
+	require.NoError(t, batch.DeleteFile(repo, commit1.ID, "old"))
+	_, err = batch.PutFile(repo, commit1.ID, "dir/file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	// writes in a batch are applied in order
+	_, err = batch.PutFileOverwrite(repo, commit1.ID, "dir/file", strings.NewReader("bar\n"))
+	require.NoError(t, err)
+	_, err = batch.PutFile(repo, commit1.ID, "dir/file", strings.NewReader("baz\n"))
+	require.NoError(t, err)
+	_, err = batch.PutFile(repo, commit1.ID, "empty", strings.NewReader(""))
+	require.NoError(t, err)
+	require.NoError(t, batch.Close())
+	require.NoError(t, c.FinishCommit(repo, commit1.ID))
+
+	fileInfos, err := c.ListFile(repo, commit1.ID, "")
+	require.NoError(t, err)
+	require.Equal(t, 12, len(fileInfos))
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(repo, commit1.ID, "file3", 0, 0, &buffer))
+	require.Equal(t, "3\n", buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.GetFile(repo, commit1.ID, "dir/file", 0, 0, &buffer))
+	require.Equal(t, "bar\nbaz\n", buffer.String())
+	_, err = c.InspectFile(repo, commit1.ID, "old")
+	require.YesError(t, err)
+
+	// If any write in the batch fails, none of them are made
+	commit2, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	batch, err = c.NewPutFilesBatch()
+	require.NoError(t, err)
+	_, err = batch.PutFile(repo, commit2.ID, "new", strings.NewReader("new\n"))
+	require.NoError(t, err)
+	// commit1 is finished, so this write fails
+	_, err = batch.PutFile(repo, commit1.ID, "new", strings.NewReader("new\n"))
+	require.NoError(t, err)
+	require.YesError(t, batch.Close())
+	require.NoError(t, c.FinishCommit(repo, commit2.ID))
+	fileInfos, err = c.ListFile(repo, commit2.ID, "")
+	require.NoError(t, err)
+	require.Equal(t, 12, len(fileInfos))
+	_, err = c.InspectFile(repo, commit2.ID, "new")
+	require.YesError(t, err)
+
+	// a batch can have more writes than etcd allows in one transaction
+	commit3, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	batch, err = c.NewPutFilesBatch()
+	require.NoError(t, err)
+	for i := 0; i < 500; i++ {
+		_, err = batch.PutFile(repo, commit3.ID, fmt.Sprintf("big/file%d", i), strings.NewReader(fmt.Sprintf("%d\n", i)))
+		require.NoError(t, err)
+	}
+	require.NoError(t, batch.Close())
+	require.NoError(t, c.FinishCommit(repo, commit3.ID))
+	fileInfos, err = c.ListFile(repo, commit3.ID, "big")
+	require.NoError(t, err)
+	require.Equal(t, 500, len(fileInfos))
+}
+
+func TestDeleteFileOrdering(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestDeleteFileOrdering")
+	require.NoError(t, c.CreateRepo(repo))
+
+	commit1, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	for _, p := range []string{"foo/bar", "foo/baz/buzz", "dir/file"} {
+		_, err = c.PutFile(repo, commit1.ID, p, strings.NewReader("foo\n"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, c.FinishCommit(repo, commit1.ID))
+
+	// deleting a directory and the files in it together, in either order,
+	// leaves nothing under it
+	commit2, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	batch, err := c.NewPutFilesBatch()
+	require.NoError(t, err)
+	require.NoError(t, batch.DeleteFile(repo, commit2.ID, "foo"))
+	require.NoError(t, batch.DeleteFile(repo, commit2.ID, "foo/baz/buzz"))
+	require.NoError(t, batch.DeleteFile(repo, commit2.ID, "dir/file"))
+	require.NoError(t, batch.DeleteFile(repo, commit2.ID, "dir"))
+	require.NoError(t, batch.Close())
+	require.NoError(t, c.FinishCommit(repo, commit2.ID))
+	fileInfos, err := c.ListFile(repo, commit2.ID, "")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(fileInfos))
+	_, err = c.InspectFile(repo, commit2.ID, "foo/bar")
+	require.YesError(t, err)
+
+	// a file put after its directory is deleted survives, and one put
+	// before doesn't
+	commit3, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit3.ID, "foo/old", strings.NewReader("old\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.DeleteFile(repo, commit3.ID, "foo"))
+	_, err = c.PutFile(repo, commit3.ID, "foo/new", strings.NewReader("new\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit3.ID))
+	fileInfos, err = c.ListFile(repo, commit3.ID, "foo")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(fileInfos))
+	require.Equal(t, "/foo/new", fileInfos[0].File.Path)
+}
+
+func TestSetSchema(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestSetSchema")
+	require.NoError(t, c.CreateRepo(repo))
+
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "users/1.json", strings.NewReader("{}\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "usersold/1.json", strings.NewReader("{}\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	fileInfo, err := c.InspectFile(repo, commit.ID, "users/1.json")
+	require.NoError(t, err)
+	require.Nil(t, fileInfo.Schema)
+
+	repoSchema := &pfs.Schema{Type: pfs.SchemaType_JSON_SCHEMA, Url: "http://registry/repo.json"}
+	usersSchema := &pfs.Schema{Type: pfs.SchemaType_AVRO, Url: "http://registry/users/1"}
+	require.NoError(t, c.SetSchema(repo, "", repoSchema))
+	require.NoError(t, c.SetSchema(repo, "/users", usersSchema))
+
+	// The closest schema applies
+	fileInfo, err = c.InspectFile(repo, commit.ID, "users/1.json")
+	require.NoError(t, err)
+	require.Equal(t, usersSchema, fileInfo.Schema)
+	fileInfo, err = c.InspectFile(repo, commit.ID, "users")
+	require.NoError(t, err)
+	require.Equal(t, usersSchema, fileInfo.Schema)
+	fileInfo, err = c.InspectFile(repo, commit.ID, "usersold/1.json")
+	require.NoError(t, err)
+	require.Equal(t, repoSchema, fileInfo.Schema)
+
+	// Removing a schema falls back to the schema of an ancestor
+	require.NoError(t, c.SetSchema(repo, "users", nil))
+	fileInfo, err = c.InspectFile(repo, commit.ID, "users/1.json")
+	require.NoError(t, err)
+	require.Equal(t, repoSchema, fileInfo.Schema)
+
+	require.YesError(t, c.SetSchema(uniqueString("TestSetSchema"), "", repoSchema))
+}
+
+func TestSchemaValidation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+	c := getClient(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"type": "object", "required": ["name"]}`)
+	}))
+	defer server.Close()
+
+	repo := uniqueString("TestSchemaValidation")
+	require.NoError(t, c.CreateRepo(repo))
+	require.YesError(t, c.SetSchema(repo, "users", &pfs.Schema{Url: server.URL}))
+	require.NoError(t, c.SetSchema(repo, "users", &pfs.Schema{Type: pfs.SchemaType_JSON_SCHEMA, Url: server.URL}))
+	require.NoError(t, c.SetSchema(repo, "events", &pfs.Schema{Type: pfs.SchemaType_AVRO, Url: "http://registry/events/1"}))
+	require.NoError(t, c.SetSchema(repo, "protos", &pfs.Schema{Type: pfs.SchemaType_PROTOBUF, Url: "http://registry/protos/1"}))
+
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "users/1.json", strings.NewReader(`{"name": "a"}`+"\n"+`{"name": "b"}`))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "events/1.avro", strings.NewReader("Obj\x01data"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "protos/1", strings.NewReader("foo"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "other", strings.NewReader("foo"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	// a commit with a file that doesn't match its schema can't be finished
+	// until the file is fixed
+	commit, err = c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "users/2.json", strings.NewReader(`{"name": "c"} {"age": 3}`))
+	require.NoError(t, err)
+	err = c.FinishCommit(repo, commit.ID)
+	require.YesError(t, err)
+	require.Matches(t, "users/2.json doesn't match its schema", err.Error())
+	require.Matches(t, "record 2", err.Error())
+	require.NoError(t, c.DeleteFile(repo, commit.ID, "users/2.json"))
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	commit, err = c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "events/2.avro", strings.NewReader("foo"))
+	require.NoError(t, err)
+	err = c.FinishCommit(repo, commit.ID)
+	require.YesError(t, err)
+	require.Matches(t, "Avro", err.Error())
+}
+
+func TestPutFileTar(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestPutFileTar")
+	require.NoError(t, c.CreateRepo(repo))
+
+	files := map[string]string{
+		"a":         "foo\n",
+		"dir/b":     "bar\n",
+		"dir/sub/c": "baz\n",
+		"../d":      "buzz\n",
+	}
+	writeTar := func(w io.Writer) {
+		tarW := tar.NewWriter(w)
+		require.NoError(t, tarW.WriteHeader(&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0755}))
+		for name, content := range files {
+			require.NoError(t, tarW.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}))
+			_, err := tarW.Write([]byte(content))
+			require.NoError(t, err)
+		}
+		require.NoError(t, tarW.Close())
+	}
+	var tarBuf bytes.Buffer
+	writeTar(&tarBuf)
+	var gzipBuf bytes.Buffer
+	gzipW := gzip.NewWriter(&gzipBuf)
+	writeTar(gzipW)
+	require.NoError(t, gzipW.Close())
+
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFileTar(repo, commit.ID, "tar", &tarBuf)
+	require.NoError(t, err)
+	_, err = c.PutFileTar(repo, commit.ID, "targz", &gzipBuf)
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	for _, dir := range []string{"tar", "targz"} {
+		for name, content := range files {
+			var buffer bytes.Buffer
+			require.NoError(t, c.GetFile(repo, commit.ID, path.Join(dir, path.Clean("/"+name)), 0, 0, &buffer))
+			require.Equal(t, content, buffer.String())
+		}
+	}
+	fileInfos, err := c.ListFile(repo, commit.ID, "")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(fileInfos))
+
+	// an archive can have more entries than etcd allows in one transaction
+	var bigBuf bytes.Buffer
+	tarW := tar.NewWriter(&bigBuf)
+	for i := 0; i < 500; i++ {
+		content := fmt.Sprintf("%d\n", i)
+		require.NoError(t, tarW.WriteHeader(&tar.Header{Name: fmt.Sprintf("file%d", i), Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}))
+		_, err := tarW.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tarW.Close())
+	commit, err = c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFileTar(repo, commit.ID, "big", &bigBuf)
+	require.NoError(t, err)
+	// the entries are visible in the open commit
+	fileInfos, err = c.ListFile(repo, commit.ID, "big")
+	require.NoError(t, err)
+	require.Equal(t, 500, len(fileInfos))
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+	fileInfos, err = c.ListFile(repo, commit.ID, "big")
+	require.NoError(t, err)
+	require.Equal(t, 500, len(fileInfos))
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(repo, commit.ID, "big/file499", 0, 0, &buffer))
+	require.Equal(t, "499\n", buffer.String())
+}
+
+func TestSymlink(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestSymlink")
+	require.NoError(t, c.CreateRepo(repo))
+	commit1, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit1.ID, "v1/data", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.PutSymlink(repo, commit1.ID, "latest", "v1"))
+	require.NoError(t, c.PutSymlink(repo, commit1.ID, "data", "/latest/data"))
+	require.YesError(t, c.PutSymlink(repo, commit1.ID, "empty", ""))
+	require.NoError(t, c.FinishCommit(repo, commit1.ID))
+
+	fileInfo, err := c.InspectFile(repo, commit1.ID, "data")
+	require.NoError(t, err)
+	require.Equal(t, pfs.FileType_SYMLINK, fileInfo.FileType)
+	require.Equal(t, "/latest/data", fileInfo.SymlinkTarget)
+
+	// getting a symlink is an error unless it's followed
+	var buffer bytes.Buffer
+	require.YesError(t, c.GetFile(repo, commit1.ID, "data", 0, 0, &buffer))
+	require.NoError(t, c.GetFileFollowSymlinks(repo, commit1.ID, "data", 0, 0, &buffer))
+	require.Equal(t, "foo\n", buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.GetFileFollowSymlinks(repo, commit1.ID, "latest/data", 0, 0, &buffer))
+	require.Equal(t, "foo\n", buffer.String())
+
+	fileInfos, err := c.ListFile(repo, commit1.ID, "")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(fileInfos))
+	fileInfos, err = c.ListFileFollowSymlinks(repo, commit1.ID, "")
+	require.NoError(t, err)
+	for _, fileInfo := range fileInfos {
+		switch fileInfo.File.Path {
+		case "/data":
+			require.Equal(t, pfs.FileType_FILE, fileInfo.FileType)
+			require.Equal(t, uint64(4), fileInfo.SizeBytes)
+		case "/latest":
+			require.Equal(t, pfs.FileType_DIR, fileInfo.FileType)
+			require.Equal(t, "v1", fileInfo.SymlinkTarget)
+		}
+	}
+	fileInfos, err = c.ListFileFollowSymlinks(repo, commit1.ID, "latest")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(fileInfos))
+	require.Equal(t, "/latest/data", fileInfos[0].File.Path)
+
+	// retargeting the symlink in a new commit leaves the old one intact
+	commit2, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit2.ID, "v2/data", strings.NewReader("bar\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.PutSymlink(repo, commit2.ID, "latest", "v2"))
+	require.NoError(t, c.FinishCommit(repo, commit2.ID))
+	buffer.Reset()
+	require.NoError(t, c.GetFileFollowSymlinks(repo, commit2.ID, "data", 0, 0, &buffer))
+	require.Equal(t, "bar\n", buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.GetFileFollowSymlinks(repo, commit1.ID, "data", 0, 0, &buffer))
+	require.Equal(t, "foo\n", buffer.String())
+}
+
+func TestMoveFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestMoveFile")
+	require.NoError(t, c.CreateRepo(repo))
+	commit1, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		_, err = c.PutFile(repo, commit1.ID, fmt.Sprintf("dir/file%d", i), strings.NewReader(fmt.Sprintf("%d\n", i)))
+		require.NoError(t, err)
+	}
+	_, err = c.PutFile(repo, commit1.ID, "other", strings.NewReader("other\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit1.ID))
+
+	commit2, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.MoveFile(repo, commit2.ID, "dir", "moved"))
+	// moves are applied in order with the commit's other writes
+	_, err = c.PutFile(repo, commit2.ID, "moved/file10", strings.NewReader("10\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.MoveFile(repo, commit2.ID, "moved/file0", "other"))
+	require.YesError(t, c.MoveFile(repo, commit2.ID, "moved", "moved/sub"))
+	require.YesError(t, c.MoveFile(repo, commit2.ID, "missing", "foo"))
+	require.NoError(t, c.FinishCommit(repo, commit2.ID))
+	require.YesError(t, c.MoveFile(repo, commit2.ID, "moved", "dir"))
+
+	_, err = c.InspectFile(repo, commit2.ID, "dir")
+	require.YesError(t, err)
+	fileInfos, err := c.ListFile(repo, commit2.ID, "moved")
+	require.NoError(t, err)
+	require.Equal(t, 10, len(fileInfos))
+	for i := 1; i <= 10; i++ {
+		var buffer bytes.Buffer
+		require.NoError(t, c.GetFile(repo, commit2.ID, fmt.Sprintf("moved/file%d", i), 0, 0, &buffer))
+		require.Equal(t, fmt.Sprintf("%d\n", i), buffer.String())
+	}
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(repo, commit2.ID, "other", 0, 0, &buffer))
+	require.Equal(t, "0\n", buffer.String())
+
+	// the moved file shares its objects with the original
+	fileInfo1, err := c.InspectFile(repo, commit1.ID, "dir/file1")
+	require.NoError(t, err)
+	fileInfo2, err := c.InspectFile(repo, commit2.ID, "moved/file1")
+	require.NoError(t, err)
+	require.Equal(t, fileInfo1.Objects, fileInfo2.Objects)
+}
+
+func TestObjectRefCounts(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo1 := uniqueString("TestObjectRefCounts1")
+	repo2 := uniqueString("TestObjectRefCounts2")
+	require.NoError(t, c.CreateRepo(repo1))
+	require.NoError(t, c.CreateRepo(repo2))
+	_, err := c.StartCommit(repo1, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo1, "master", "shared", strings.NewReader("shared\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo1, "master", "unique", strings.NewReader("unique\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo1, "master"))
+	_, err = c.StartCommit(repo2, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo2, "master", "shared", strings.NewReader("shared\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo2, "master"))
+
+	fileInfo, err := c.InspectFile(repo1, "master", "shared")
+	require.NoError(t, err)
+	sharedHash := fileInfo.Objects[0].Hash
+	fileInfo, err = c.InspectFile(repo1, "master", "unique")
+	require.NoError(t, err)
+	uniqueHash := fileInfo.Objects[0].Hash
+
+	// until the counts are rebuilt, deleting a repo doesn't delete objects
+	repo3 := uniqueString("TestObjectRefCounts3")
+	require.NoError(t, c.CreateRepo(repo3))
+	_, err = c.StartCommit(repo3, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo3, "master", "file", strings.NewReader("repo3\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo3, "master"))
+	fileInfo, err = c.InspectFile(repo3, "master", "file")
+	require.NoError(t, err)
+	repo3Hash := fileInfo.Objects[0].Hash
+	require.NoError(t, c.DeleteRepo(repo3, false))
+	_, err = c.InspectObject(repo3Hash)
+	require.NoError(t, err)
+
+	// once they're rebuilt, objects that lose their last ref are only swept
+	// after a grace period, so they're still there right after the delete
+	require.NoError(t, c.RebuildObjectRefCounts())
+	require.NoError(t, c.DeleteRepo(repo1, false))
+	_, err = c.InspectObject(uniqueHash)
+	require.NoError(t, err)
+	_, err = c.InspectObject(sharedHash)
+	require.NoError(t, err)
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(repo2, "master", "shared", 0, 0, &buffer))
+	require.Equal(t, "shared\n", buffer.String())
+
+	// objects of commits finished after the rebuild are counted too
+	_, err = c.StartCommit(repo2, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo2, "master", "new", strings.NewReader("new\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo2, "master"))
+	fileInfo, err = c.InspectFile(repo2, "master", "new")
+	require.NoError(t, err)
+	newHash := fileInfo.Objects[0].Hash
+	require.NoError(t, c.DeleteRepo(repo2, false))
+	_, err = c.InspectObject(newHash)
+	require.NoError(t, err)
+
+	// an object that's referenced again before it's swept is kept
+	repo4 := uniqueString("TestObjectRefCounts4")
+	require.NoError(t, c.CreateRepo(repo4))
+	_, err = c.StartCommit(repo4, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo4, "master", "new", strings.NewReader("new\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo4, "master"))
+	fileInfo, err = c.InspectFile(repo4, "master", "new")
+	require.NoError(t, err)
+	require.Equal(t, newHash, fileInfo.Objects[0].Hash)
+}
+
+func TestCompactFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestCompactFile")
+	require.NoError(t, c.CreateRepo(repo))
+	commit1, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	var expected bytes.Buffer
+	for i := 0; i < 5; i++ {
+		line := fmt.Sprintf("line %d\n", i)
+		_, err = c.PutFile(repo, commit1.ID, "dir/file", strings.NewReader(line))
+		require.NoError(t, err)
+		expected.WriteString(line)
+	}
+	_, err = c.PutFile(repo, commit1.ID, "other", strings.NewReader("other\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit1.ID))
+
+	fileInfo, err := c.InspectFile(repo, commit1.ID, "dir/file")
+	require.NoError(t, err)
+	require.Equal(t, 5, len(fileInfo.Objects))
+
+	response, err := c.CompactFile(repo, "master", "dir", false)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), response.FilesCompacted)
+	require.Equal(t, uint64(5), response.ObjectsBefore)
+	require.Equal(t, uint64(1), response.ObjectsAfter)
+	commitInfo, err := c.InspectCommit(repo, "master")
+	require.NoError(t, err)
+	require.Equal(t, response.Commit.ID, commitInfo.Commit.ID)
+	require.Equal(t, commit1.ID, commitInfo.ParentCommit.ID)
+	fileInfo, err = c.InspectFile(repo, "master", "dir/file")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(fileInfo.Objects))
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(repo, "master", "dir/file", 0, 0, &buffer))
+	require.Equal(t, expected.String(), buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.GetFile(repo, "master", "other", 0, 0, &buffer))
+	require.Equal(t, "other\n", buffer.String())
+	// the compacted commit is unchanged
+	fileInfo, err = c.InspectFile(repo, commit1.ID, "dir/file")
+	require.NoError(t, err)
+	require.Equal(t, 5, len(fileInfo.Objects))
+
+	// nothing is left to compact
+	response, err = c.CompactCommit(repo, "master", false)
+	require.NoError(t, err)
+	require.Nil(t, response.Commit)
+
+	_, err = c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, "master", "dir/file", strings.NewReader("more\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, "master"))
+	expected.WriteString("more\n")
+	head, err := c.InspectCommit(repo, "master")
+	require.NoError(t, err)
+
+	// in place compaction has to be allowed for the branch
+	_, err = c.CompactCommit(repo, "master", true)
+	require.YesError(t, err)
+	require.NoError(t, c.SetCompactInPlace(repo, "master", true))
+	branches, err := c.ListBranch(repo)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(branches))
+	require.True(t, branches[0].CompactInPlace)
+	_, err = c.CompactCommit(repo, head.Commit.ID, true)
+	require.YesError(t, err)
+
+	response, err = c.CompactCommit(repo, "master", true)
+	require.NoError(t, err)
+	require.Equal(t, head.Commit.ID, response.Commit.ID)
+	require.Equal(t, uint64(2), response.ObjectsBefore)
+	require.Equal(t, uint64(1), response.ObjectsAfter)
+	commitInfo, err = c.InspectCommit(repo, "master")
+	require.NoError(t, err)
+	require.Equal(t, head.Commit.ID, commitInfo.Commit.ID)
+	fileInfo, err = c.InspectFile(repo, "master", "dir/file")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(fileInfo.Objects))
+	buffer.Reset()
+	require.NoError(t, c.GetFile(repo, "master", "dir/file", 0, 0, &buffer))
+	require.Equal(t, expected.String(), buffer.String())
+
+	require.NoError(t, c.SetCompactInPlace(repo, "master", false))
+	_, err = c.CompactCommit(repo, "master", true)
+	require.YesError(t, err)
+}
+
+func TestAdminJobs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestAdminJobs")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = c.PutFile(repo, commit.ID, "file", strings.NewReader(fmt.Sprintf("line %d\n", i)))
+		require.NoError(t, err)
+	}
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+	response, err := c.CompactCommit(repo, "master", false)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), response.FilesCompacted)
+
+	// the compaction ran as an admin job, which is kept once it's finished
+	jobInfos, err := c.ListAdminJobs("compact")
+	require.NoError(t, err)
+	require.True(t, len(jobInfos) > 0)
+	var jobInfo *pfs.AdminJobInfo
+	for _, info := range jobInfos {
+		require.Equal(t, "compact", info.Type)
+		if info.Phase == "committing the compacted files" && info.State == pfs.AdminJobState_SUCCEEDED {
+			jobInfo = info
+		}
+	}
+	require.NotNil(t, jobInfo)
+	require.NotNil(t, jobInfo.Finished)
+	inspected, err := c.InspectAdminJob(jobInfo.Id)
+	require.NoError(t, err)
+	require.Equal(t, jobInfo.Id, inspected.Id)
+	require.Equal(t, pfs.AdminJobState_SUCCEEDED, inspected.State)
+
+	// cancelling a finished job does nothing
+	require.NoError(t, c.CancelAdminJob(jobInfo.Id))
+	inspected, err = c.InspectAdminJob(jobInfo.Id)
+	require.NoError(t, err)
+	require.Equal(t, pfs.AdminJobState_SUCCEEDED, inspected.State)
+	require.False(t, inspected.CancelRequested)
+
+	_, err = c.InspectAdminJob(uuid.NewWithoutDashes())
+	require.YesError(t, err)
+	require.YesError(t, c.CancelAdminJob(uuid.NewWithoutDashes()))
+}
+
+func TestRepoQuota(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestRepoQuota")
+	require.NoError(t, c.CreateRepo(repo))
+	require.YesError(t, c.SetRepoQuota(repo, &pfs.RepoQuota{PutFilePerSecond: -1}))
+	// the quota allows a burst of one operation of each kind, and then one
+	// every 100 seconds
+	require.NoError(t, c.SetRepoQuota(repo, &pfs.RepoQuota{
+		PutFilePerSecond:  0.01,
+		ListFilePerSecond: 0.01,
+	}))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "file1", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "file2", strings.NewReader("foo\n"))
+	require.YesError(t, err)
+	require.True(t, strings.Contains(err.Error(), "quota"))
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+	_, err = c.ListFile(repo, commit.ID, "")
+	require.NoError(t, err)
+	_, err = c.ListFile(repo, commit.ID, "")
+	require.YesError(t, err)
+
+	usage, err := c.ListRepoUsage()
+	require.NoError(t, err)
+	var repoUsage *pfs.RepoUsage
+	for _, u := range usage {
+		if u.Repo.Name == repo {
+			repoUsage = u
+		}
+	}
+	require.NotNil(t, repoUsage)
+	require.Equal(t, int64(1), repoUsage.PutFiles)
+	require.Equal(t, int64(1), repoUsage.ListFiles)
+	require.Equal(t, int64(2), repoUsage.Rejected)
+	require.Equal(t, 0.01, repoUsage.Quota.PutFilePerSecond)
+
+	// without a quota, nothing is rejected
+	require.NoError(t, c.SetRepoQuota(repo, nil))
+	for i := 0; i < 3; i++ {
+		_, err = c.ListFile(repo, commit.ID, "")
+		require.NoError(t, err)
+	}
+}
+
+func TestListFilePagination(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestListFilePagination")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		_, err = c.PutFile(repo, commit.ID, fmt.Sprintf("dir/file%02d", i), strings.NewReader("foo\n"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	var paths []string
+	var pages int
+	var pageToken string
+	for {
+		fileInfos, nextPageToken, err := c.ListFilePage(repo, commit.ID, "dir", false, 3, pageToken)
+		require.NoError(t, err)
+		require.True(t, len(fileInfos) <= 3)
+		for _, fileInfo := range fileInfos {
+			paths = append(paths, fileInfo.File.Path)
+		}
+		pages++
+		if nextPageToken == "" {
+			break
+		}
+		pageToken = nextPageToken
+	}
+	require.Equal(t, 4, pages)
+	require.Equal(t, 10, len(paths))
+	for i, p := range paths {
+		require.Equal(t, fmt.Sprintf("/dir/file%02d", i), p)
+	}
+
+	fileInfos, nextPageToken, err := c.GlobFilePage(repo, commit.ID, "dir/*", 5, "")
+	require.NoError(t, err)
+	require.Equal(t, 5, len(fileInfos))
+	require.Equal(t, "/dir/file04", fileInfos[4].File.Path)
+	require.NotEqual(t, "", nextPageToken)
+	// a page token can only be used with the listing that returned it
+	_, _, err = c.GlobFilePage(repo, commit.ID, "dir/file*", 5, nextPageToken)
+	require.YesError(t, err)
+	_, _, err = c.ListFilePage(repo, commit.ID, "dir", false, 5, nextPageToken)
+	require.YesError(t, err)
+	_, _, err = c.GlobFilePage(repo, commit.ID, "dir/*", 5, "/dir/file04")
+	require.YesError(t, err)
+	fileInfos, nextPageToken, err = c.GlobFilePage(repo, commit.ID, "dir/*", 5, nextPageToken)
+	require.NoError(t, err)
+	require.Equal(t, 5, len(fileInfos))
+	require.Equal(t, "/dir/file05", fileInfos[0].File.Path)
+	require.Equal(t, "", nextPageToken)
+
+	// without a limit everything is returned
+	fileInfos, nextPageToken, err = c.ListFilePage(repo, commit.ID, "dir", false, 0, "")
+	require.NoError(t, err)
+	require.Equal(t, 10, len(fileInfos))
+	require.Equal(t, "", nextPageToken)
+
+	// the pages of a listing of a branch are read from the commit that the
+	// first page was, even if the branch moves
+	_, nextPageToken, err = c.ListFilePage(repo, "master", "dir", false, 5, "")
+	require.NoError(t, err)
+	_, err = c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.DeleteFile(repo, "master", "dir/file09"))
+	require.NoError(t, c.FinishCommit(repo, "master"))
+	fileInfos, _, err = c.ListFilePage(repo, "master", "dir", false, 5, nextPageToken)
+	require.NoError(t, err)
+	require.Equal(t, 5, len(fileInfos))
+	require.Equal(t, "/dir/file09", fileInfos[4].File.Path)
+}
+
+func TestListFileModes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestListFileModes")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	for _, p := range []string{"a/b/c", "a/b/d", "a/e", "a-f", "g"} {
+		_, err = c.PutFile(repo, commit.ID, p, strings.NewReader("foo\n"))
+		require.NoError(t, err)
+	}
+
+	// FAST omits sizes, in open commits too
+	fileInfos, err := c.ListFileFast(repo, commit.ID, "")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(fileInfos))
+	for _, fileInfo := range fileInfos {
+		require.Equal(t, uint64(0), fileInfo.SizeBytes)
+	}
+	fileInfos, err = c.ListFile(repo, commit.ID, "")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(fileInfos))
+	require.Equal(t, uint64(12), fileInfos[0].SizeBytes)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	// RECURSE lists the whole subtree, depth-first
+	fileInfos, err = c.ListFileRecursive(repo, commit.ID, "")
+	require.NoError(t, err)
+	var paths []string
+	for _, fileInfo := range fileInfos {
+		paths = append(paths, fileInfo.File.Path)
+	}
+	require.Equal(t, []string{"/a", "/a/b", "/a/b/c", "/a/b/d", "/a/e", "/a-f", "/g"}, paths)
+	fileInfos, err = c.ListFileRecursive(repo, commit.ID, "a")
+	require.NoError(t, err)
+	require.Equal(t, 4, len(fileInfos))
+	require.Equal(t, uint64(8), fileInfos[0].SizeBytes)
+
+	// and is paged in the same order
+	paths = nil
+	var pageToken string
+	for {
+		fileInfos, nextPageToken, err := c.ListFilePageMode(repo, commit.ID, "", false, 2, pageToken, pfs.ListFileMode_ListFile_RECURSE)
+		require.NoError(t, err)
+		for _, fileInfo := range fileInfos {
+			paths = append(paths, fileInfo.File.Path)
+		}
+		if nextPageToken == "" {
+			break
+		}
+		pageToken = nextPageToken
+	}
+	require.Equal(t, []string{"/a", "/a/b", "/a/b/c", "/a/b/d", "/a/e", "/a-f", "/g"}, paths)
+}
+
+func TestAutoCompaction(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestAutoCompaction")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	for i := 0; i < 4; i++ {
+		_, err = c.PutFile(repo, commit.ID, "fragmented", strings.NewReader(fmt.Sprintf("line %d\n", i)))
+		require.NoError(t, err)
+	}
+	for i := 0; i < 2; i++ {
+		_, err = c.PutFile(repo, commit.ID, "few-objects", strings.NewReader(fmt.Sprintf("line %d\n", i)))
+		require.NoError(t, err)
+	}
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+	_, err = c.StartCommit(repo, "other")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, "other", "fragmented", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, "other", "fragmented", strings.NewReader("bar\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, "other"))
+
+	_, err = c.InspectCompactionPolicy(repo)
+	require.YesError(t, err)
+	require.NoError(t, c.SetCompactionPolicy(repo, &pfs.CompactionPolicy{
+		Branches:   []string{"master"},
+		MinObjects: 3,
+	}))
+
+	require.NoError(t, backoff.Retry(func() error {
+		policyInfo, err := c.InspectCompactionPolicy(repo)
+		if err != nil {
+			return err
+		}
+		if len(policyInfo.Branches) != 1 || policyInfo.Branches[0].Checked == nil {
+			return fmt.Errorf("master hasn't been checked yet")
+		}
+		return nil
+	}, backoff.NewTestingBackOff()))
+
+	policyInfo, err := c.InspectCompactionPolicy(repo)
+	require.NoError(t, err)
+	require.Equal(t, "", policyInfo.Branches[0].LastError)
+	require.Equal(t, "", policyInfo.Capability)
+	require.Equal(t, 1, len(policyInfo.RecentCompactions))
+	commitInfo, err := c.InspectCommit(repo, "master")
+	require.NoError(t, err)
+	require.Equal(t, commit.ID, commitInfo.ParentCommit.ID)
+	require.Equal(t, commitInfo.Commit.ID, policyInfo.Branches[0].Checked.ID)
+	fileInfo, err := c.InspectFile(repo, "master", "fragmented")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(fileInfo.Objects))
+	// files with fewer than MinObjects objects are left alone
+	fileInfo, err = c.InspectFile(repo, "master", "few-objects")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(fileInfo.Objects))
+	// and so are branches that the policy doesn't apply to
+	fileInfo, err = c.InspectFile(repo, "other", "fragmented")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(fileInfo.Objects))
+
+	require.NoError(t, c.SetCompactionPolicy(repo, nil))
+	_, err = c.InspectCompactionPolicy(repo)
+	require.YesError(t, err)
+}
+
+func TestCommitModified(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestCommitModified")
+	require.NoError(t, c.CreateRepo(repo))
+	putFile := func(path string, content string) *pfs.Commit {
+		commit, err := c.StartCommit(repo, "master")
+		require.NoError(t, err)
+		_, err = c.PutFile(repo, commit.ID, path, strings.NewReader(content))
+		require.NoError(t, err)
+		require.NoError(t, c.FinishCommit(repo, commit.ID))
+		return commit
+	}
+	commit1 := putFile("dir/a", "a\n")
+	commit2 := putFile("dir/b", "b\n")
+	commit3 := putFile("other", "other\n")
+
+	fileInfo, err := c.InspectFile(repo, "master", "dir/a")
+	require.NoError(t, err)
+	require.Equal(t, commit1.ID, fileInfo.CommitModified.ID)
+	fileInfo, err = c.InspectFile(repo, "master", "dir/b")
+	require.NoError(t, err)
+	require.Equal(t, commit2.ID, fileInfo.CommitModified.ID)
+	fileInfo, err = c.InspectFile(repo, "master", "dir")
+	require.NoError(t, err)
+	require.Equal(t, commit2.ID, fileInfo.CommitModified.ID)
+	fileInfo, err = c.InspectFile(repo, "master", "/")
+	require.NoError(t, err)
+	require.Equal(t, commit3.ID, fileInfo.CommitModified.ID)
+	// the answer is relative to the commit that's inspected
+	fileInfo, err = c.InspectFile(repo, commit1.ID, "dir")
+	require.NoError(t, err)
+	require.Equal(t, commit1.ID, fileInfo.CommitModified.ID)
+
+	// a file that's deleted and put back with the same content is modified
+	// by the commit that put it back
+	commit4, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.DeleteFile(repo, commit4.ID, "dir/a"))
+	require.NoError(t, c.FinishCommit(repo, commit4.ID))
+	commit5 := putFile("dir/a", "a\n")
+	fileInfo, err = c.InspectFile(repo, "master", "dir/a")
+	require.NoError(t, err)
+	require.Equal(t, commit5.ID, fileInfo.CommitModified.ID)
+
+	// in an open commit, files that have been written to are modified by it
+	commit6, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit6.ID, "dir/b", strings.NewReader("more\n"))
+	require.NoError(t, err)
+	fileInfo, err = c.InspectFile(repo, commit6.ID, "dir/b")
+	require.NoError(t, err)
+	require.Equal(t, commit6.ID, fileInfo.CommitModified.ID)
+	fileInfo, err = c.InspectFile(repo, commit6.ID, "other")
+	require.NoError(t, err)
+	require.Equal(t, commit3.ID, fileInfo.CommitModified.ID)
+}
+
+func TestFilterFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestFilterFile")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "logs/a", strings.NewReader("INFO a1\nERROR a2\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "logs/b", strings.NewReader("ERROR b1\nINFO b2\nERROR b3"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "events.json", strings.NewReader(`{"level": "error", "id": 1}
+{"level": "info", "id": 2}
+{"level": "error", "id": 3}`))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	var buffer bytes.Buffer
+	require.NoError(t, c.FilterFile(repo, commit.ID, "logs/*", "^ERROR", &buffer))
+	require.Equal(t, "ERROR a2\nERROR b1\nERROR b3\n", buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.FilterFile(repo, commit.ID, "logs/b", "INFO", &buffer))
+	require.Equal(t, "INFO b2\n", buffer.String())
+
+	buffer.Reset()
+	require.NoError(t, c.FilterFileJSON(repo, commit.ID, "events.json", "level == 'error'", &buffer))
+	require.Equal(t, "{\"level\": \"error\", \"id\": 1}\n{\"level\": \"error\", \"id\": 3}\n", buffer.String())
+
+	buffer.Reset()
+	require.YesError(t, c.FilterFile(repo, commit.ID, "logs/*", "(", &buffer))
+	require.YesError(t, c.FilterFileJSON(repo, commit.ID, "logs/*", "level == 'error'", &buffer))
+}
+
+func TestGlobDirectories(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestGlobDirectories")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "a/file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "a/sub/file", strings.NewReader("foobar\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "b/file", strings.NewReader("bar\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "file", strings.NewReader("top-level\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	fileInfos, err := c.GlobDirectories(repo, commit.ID, "*")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(fileInfos))
+	require.Equal(t, "/a", fileInfos[0].File.Path)
+	require.Equal(t, uint64(11), fileInfos[0].SizeBytes)
+	require.Equal(t, "/b", fileInfos[1].File.Path)
+	require.Equal(t, uint64(4), fileInfos[1].SizeBytes)
+
+	// a trailing "/" also only matches directories
+	fileInfos, err = c.GlobFile(repo, commit.ID, "a/*/")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(fileInfos))
+	require.Equal(t, "/a/sub", fileInfos[0].File.Path)
+	require.Equal(t, pfs.FileType_DIR, fileInfos[0].FileType)
+
+	fileInfos, nextPageToken, err := c.GlobFilePage(repo, commit.ID, "*/", 1, "")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(fileInfos))
+	require.Equal(t, "/a", fileInfos[0].File.Path)
+	require.NotEqual(t, "", nextPageToken)
+	fileInfos, nextPageToken, err = c.GlobFilePage(repo, commit.ID, "*/", 1, nextPageToken)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(fileInfos))
+	require.Equal(t, "/b", fileInfos[0].File.Path)
+	require.Equal(t, "", nextPageToken)
+}
+
+func TestGetRecords(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestGetRecords")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	var lines bytes.Buffer
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&lines, "%d\n", i)
+	}
+	_, err = c.PutFileSplit(repo, commit.ID, "lines", pfs.Delimiter_LINE, 3, 0, false, &lines)
+	require.NoError(t, err)
+	_, err = c.PutFileSplit(repo, commit.ID, "json", pfs.Delimiter_JSON, 2, 0, false,
+		strings.NewReader("{\"a\":0}\n{\"a\":1}\n{\"a\":2}\n{\"a\":3}\n{\"a\":4}\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "unsplit", strings.NewReader("foo\nbar\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetRecords(repo, commit.ID, "lines", 2, 5, &buffer))
+	require.Equal(t, "2\n3\n4\n5\n6\n", buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.GetRecords(repo, commit.ID, "lines", 0, 0, &buffer))
+	require.Equal(t, "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n", buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.GetRecords(repo, commit.ID, "lines", 8, 0, &buffer))
+	require.Equal(t, "8\n9\n", buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.GetRecords(repo, commit.ID, "lines", 20, 1, &buffer))
+	require.Equal(t, "", buffer.String())
+
+	buffer.Reset()
+	require.NoError(t, c.GetRecords(repo, commit.ID, "json", 1, 2, &buffer))
+	require.Equal(t, "{\"a\":1}{\"a\":2}", buffer.String())
+
+	// files that weren't split don't know how many records they have
+	require.YesError(t, c.GetRecords(repo, commit.ID, "unsplit", 0, 1, &buffer))
+}
+
+func TestGetFileSplitDirectory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestGetFileSplitDirectory")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	var lines bytes.Buffer
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&lines, "%d\n", i)
+	}
+	_, err = c.PutFileSplit(repo, commit.ID, "lines", pfs.Delimiter_LINE, 3, 0, false, &lines)
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "dir/file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	// the directory is read as the concatenation of its files, so ranges
+	// can span several of them
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(repo, commit.ID, "lines", 0, 0, &buffer))
+	require.Equal(t, "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n", buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.GetFile(repo, commit.ID, "lines", 4, 8, &buffer))
+	require.Equal(t, "2\n3\n4\n5\n", buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.GetFile(repo, commit.ID, "lines", 17, 0, &buffer))
+	require.Equal(t, "8\n9\n", buffer.String())
+	// other directories still can't be read
+	require.YesError(t, c.GetFile(repo, commit.ID, "dir", 0, 0, &buffer))
+}
+
+func TestRecordCount(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestRecordCount")
+	require.NoError(t, c.CreateRepo(repo))
+	commit1, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFileSplit(repo, commit1.ID, "data", pfs.Delimiter_LINE, 2, 0, false, strings.NewReader("a\nb\nc\nd\ne"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit1.ID))
+
+	fileInfo, err := c.InspectFile(repo, commit1.ID, "data")
+	require.NoError(t, err)
+	require.Equal(t, int64(5), fileInfo.RecordCount)
+	fileInfos, err := c.ListFile(repo, commit1.ID, "data")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(fileInfos))
+	require.Equal(t, int64(2), fileInfos[0].RecordCount)
+	require.Equal(t, int64(2), fileInfos[1].RecordCount)
+	require.Equal(t, int64(1), fileInfos[2].RecordCount)
+
+	// appending to a split directory adds to its record count
+	commit2, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFileSplit(repo, commit2.ID, "data", pfs.Delimiter_LINE, 0, 0, false, strings.NewReader("f\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit2.ID))
+	fileInfo, err = c.InspectFile(repo, commit2.ID, "data")
+	require.NoError(t, err)
+	require.Equal(t, int64(6), fileInfo.RecordCount)
+
+	// but a file that wasn't split makes it unknown
+	commit3, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit3.ID, "data/other", strings.NewReader("g\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit3.ID))
+	fileInfo, err = c.InspectFile(repo, commit3.ID, "data")
+	require.NoError(t, err)
+	require.Equal(t, int64(0), fileInfo.RecordCount)
+}
+
+func TestSearchFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestSearchFile")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	for _, p := range []string{"data/2018-01-02.csv", "data/2018-01-01.csv", "data/latest.csv", "logs/2018-01-01.log"} {
+		_, err = c.PutFile(repo, commit.ID, p, strings.NewReader("foo\n"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	search := func(regex string) []string {
+		var paths []string
+		require.NoError(t, c.SearchFile(repo, commit.ID, regex, func(fileInfo *pfs.FileInfo) error {
+			paths = append(paths, fileInfo.File.Path)
+			return nil
+		}))
+		return paths
+	}
+	require.Equal(t, []string{"/data/2018-01-01.csv", "/data/2018-01-02.csv"}, search(`/\d{4}-\d{2}-\d{2}\.csv$`))
+	require.Equal(t, []string{"/data", "/logs"}, search(`^/[a-z]+$`))
+	require.Equal(t, 0, len(search("missing")))
+
+	// errors returned by the callback stop the search
+	var calls int
+	err = c.SearchFile(repo, commit.ID, "2018", func(fileInfo *pfs.FileInfo) error {
+		calls++
+		return fmt.Errorf("stop")
+	})
+	require.YesError(t, err)
+	require.Equal(t, 1, calls)
+	require.YesError(t, c.SearchFile(repo, commit.ID, "(", func(*pfs.FileInfo) error { return nil }))
+}
+
+func TestGrepFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestGrepFile")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "logs/a", strings.NewReader("ok\ntimeout 1\nok\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "logs/b", strings.NewReader("timeout 2\nok\ntimeout 3"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "other", strings.NewReader("timeout 4\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	var matches []*pfs.GrepMatch
+	require.NoError(t, c.GrepFile(repo, commit.ID, "logs/*", "^timeout \\d$", 0, func(match *pfs.GrepMatch) error {
+		matches = append(matches, match)
+		return nil
+	}))
+	require.Equal(t, 3, len(matches))
+	require.Equal(t, "/logs/a", matches[0].File.Path)
+	require.Equal(t, int64(2), matches[0].LineNumber)
+	require.Equal(t, int64(3), matches[0].OffsetBytes)
+	require.Equal(t, "timeout 1", matches[0].Line)
+	require.Equal(t, "/logs/b", matches[1].File.Path)
+	require.Equal(t, int64(1), matches[1].LineNumber)
+	require.Equal(t, int64(0), matches[1].OffsetBytes)
+	require.Equal(t, "/logs/b", matches[2].File.Path)
+	require.Equal(t, int64(3), matches[2].LineNumber)
+	require.Equal(t, int64(13), matches[2].OffsetBytes)
+	require.Equal(t, "timeout 3", matches[2].Line)
+
+	matches = nil
+	require.NoError(t, c.GrepFile(repo, commit.ID, "*/*", "timeout", 2, func(match *pfs.GrepMatch) error {
+		matches = append(matches, match)
+		return nil
+	}))
+	require.Equal(t, 2, len(matches))
+	require.YesError(t, c.GrepFile(repo, commit.ID, "*", "(", 0, func(*pfs.GrepMatch) error { return nil }))
+}
+
+func TestDivertSplitErrors(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestDivertSplitErrors")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	data := "{\"a\": 1}\n{\"a\": \n{\"a\": 2}\nnot json\n{\"a\": 3}\n"
+	response, err := c.PutFileSplitWithOptions(repo, commit.ID, "data", pclient.PutFileSplitOptions{Delimiter: pfs.Delimiter_JSON, DivertErrors: true}, strings.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, int64(3), response.RecordsWritten)
+	require.Equal(t, int64(2), response.RecordsDiverted)
+	require.Equal(t, "/_errors/data", response.ErrorsPath)
+	// without diverting, a malformed record fails the put
+	_, err = c.PutFileSplit(repo, commit.ID, "strict", pfs.Delimiter_JSON, 0, 0, false, strings.NewReader(data))
+	require.YesError(t, err)
+	_, err = c.PutFileSplitWithOptions(repo, commit.ID, "raw", pclient.PutFileSplitOptions{Delimiter: pfs.Delimiter_NONE, DivertErrors: true}, strings.NewReader(data))
+	require.YesError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	fileInfos, err := c.ListFile(repo, commit.ID, "data")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(fileInfos))
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(repo, commit.ID, "data", 0, 0, &buffer))
+	require.Equal(t, `{"a": 1}{"a": 2}{"a": 3}`, buffer.String())
+
+	buffer.Reset()
+	require.NoError(t, c.GetFile(repo, commit.ID, response.ErrorsPath, 0, 0, &buffer))
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	require.Equal(t, 2, len(lines))
+	var splitErr struct {
+		Line   int64
+		Record string
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &splitErr))
+	require.Equal(t, int64(2), splitErr.Line)
+	require.Equal(t, `{"a":`, splitErr.Record)
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &splitErr))
+	require.Equal(t, int64(4), splitErr.Line)
+	require.Equal(t, "not json", splitErr.Record)
+}
+
+func TestSplitJSONSchema(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestSplitJSONSchema")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	schema := []byte(`{"type": "object", "required": ["a"], "properties": {"a": {"type": "integer", "minimum": 0}}}`)
+	data := "{\"a\": 1}\n{\"a\": -1}\n{\"b\": 2}\n{\"a\": 3}\n"
+	response, err := c.PutFileSplitWithOptions(repo, commit.ID, "data", pclient.PutFileSplitOptions{Delimiter: pfs.Delimiter_JSON, DivertErrors: true, JSONSchema: schema}, strings.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, int64(2), response.RecordsWritten)
+	require.Equal(t, int64(2), response.RecordsDiverted)
+	// without diverting, a record that doesn't match fails the put
+	_, err = c.PutFileSplitWithOptions(repo, commit.ID, "strict", pclient.PutFileSplitOptions{Delimiter: pfs.Delimiter_JSON, JSONSchema: schema}, strings.NewReader(data))
+	require.YesError(t, err)
+	// the schema has to be valid, and is only for JSON splits
+	_, err = c.PutFileSplitWithOptions(repo, commit.ID, "bad", pclient.PutFileSplitOptions{Delimiter: pfs.Delimiter_JSON, JSONSchema: []byte(`{"format": "date"}`)}, strings.NewReader(data))
+	require.YesError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(repo, commit.ID, "data", 0, 0, &buffer))
+	require.Equal(t, `{"a": 1}{"a": 3}`, buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.GetFile(repo, commit.ID, response.ErrorsPath, 0, 0, &buffer))
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	require.Equal(t, 2, len(lines))
+	var splitErr struct {
+		Line int64
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &splitErr))
+	require.Equal(t, int64(2), splitErr.Line)
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &splitErr))
+	require.Equal(t, int64(3), splitErr.Line)
+}
+
+func TestSplitHeaderFooter(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestSplitHeaderFooter")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	data := "name,value\na,1\nb,2\nc,3\n# end\n"
+	_, err = c.PutFileSplitWithOptions(repo, commit.ID, "data", pclient.PutFileSplitOptions{Delimiter: pfs.Delimiter_LINE, TargetFileDatums: 2, HeaderLines: 1, FooterLines: 1}, strings.NewReader(data))
+	require.NoError(t, err)
+	_, err = c.PutFileSplitWithOptions(repo, commit.ID, "json", pclient.PutFileSplitOptions{Delimiter: pfs.Delimiter_JSON, TargetFileDatums: 2, HeaderLines: 1}, strings.NewReader(data))
+	require.YesError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	fileInfos, err := c.ListFile(repo, commit.ID, "data")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(fileInfos))
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(repo, commit.ID, fileInfos[0].File.Path, 0, 0, &buffer))
+	require.Equal(t, "name,value\na,1\nb,2\n# end\n", buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.GetFile(repo, commit.ID, fileInfos[1].File.Path, 0, 0, &buffer))
+	require.Equal(t, "name,value\nc,3\n# end\n", buffer.String())
+	require.Equal(t, int64(2), fileInfos[0].RecordCount)
+
+	// the header and footer aren't records
+	buffer.Reset()
+	require.NoError(t, c.GetRecords(repo, commit.ID, "data", 0, 0, &buffer))
+	require.Equal(t, "a,1\nb,2\nc,3\n", buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.GetRecords(repo, commit.ID, "data", 1, 1, &buffer))
+	require.Equal(t, "b,2\n", buffer.String())
+}
+
+func TestSplitTargetFileCount(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestSplitTargetFileCount")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	var data bytes.Buffer
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&data, "line %d\n", i)
+	}
+	_, err = c.PutFileSplitWithOptions(repo, commit.ID, "lines", pclient.PutFileSplitOptions{Delimiter: pfs.Delimiter_LINE, TargetFileCount: 4}, bytes.NewReader(data.Bytes()))
+	require.NoError(t, err)
+	// there are fewer values than files, so each value gets its own file
+	_, err = c.PutFileSplitWithOptions(repo, commit.ID, "json", pclient.PutFileSplitOptions{Delimiter: pfs.Delimiter_JSON, TargetFileCount: 5}, strings.NewReader(`{"a": 1} {"a": 2} {"a": 3}`))
+	require.NoError(t, err)
+	_, err = c.PutFileSplitWithOptions(repo, commit.ID, "raw", pclient.PutFileSplitOptions{Delimiter: pfs.Delimiter_NONE, TargetFileCount: 4}, bytes.NewReader(data.Bytes()))
+	require.YesError(t, err)
+	// diverted records aren't counted, so the rest still make 3 files
+	response, err := c.PutFileSplitWithOptions(repo, commit.ID, "diverted", pclient.PutFileSplitOptions{Delimiter: pfs.Delimiter_JSON, TargetFileCount: 3, DivertErrors: true},
+		strings.NewReader("{\"a\": 1}\nnot json\n{\"a\": 2}\n{\"a\": 3}\n{\"a\": \n{\"a\": 4}\n{\"a\": 5}\nnope\n{\"a\": 6}\n"))
+	require.NoError(t, err)
+	require.Equal(t, int64(6), response.RecordsWritten)
+	require.Equal(t, int64(3), response.RecordsDiverted)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	fileInfos, err := c.ListFile(repo, commit.ID, "lines")
+	require.NoError(t, err)
+	require.Equal(t, 4, len(fileInfos))
+	for i, expected := range []int64{3, 3, 2, 2} {
+		require.Equal(t, expected, fileInfos[i].RecordCount)
+	}
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(repo, commit.ID, "lines", 0, 0, &buffer))
+	require.Equal(t, data.String(), buffer.String())
+
+	fileInfos, err = c.ListFile(repo, commit.ID, "json")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(fileInfos))
+
+	fileInfos, err = c.ListFile(repo, commit.ID, "diverted")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(fileInfos))
+	for _, fileInfo := range fileInfos {
+		require.Equal(t, int64(2), fileInfo.RecordCount)
+	}
+}
+
+func TestSplitSeparator(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestSplitSeparator")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	fasta := ">a\nACGT\nACGT\n>b\nGG\n>c\nTT\n"
+	_, err = c.PutFileSplitWithOptions(repo, commit.ID, "fasta", pclient.PutFileSplitOptions{Delimiter: pfs.Delimiter_SEPARATOR, TargetFileDatums: 1, SeparatorRegex: `\n(>)`}, strings.NewReader(fasta))
+	require.NoError(t, err)
+	_, err = c.PutFileSplitWithOptions(repo, commit.ID, "records", pclient.PutFileSplitOptions{Delimiter: pfs.Delimiter_SEPARATOR, TargetFileDatums: 2, Separator: []byte("\x1e")}, strings.NewReader("a\x1eb\x1ec"))
+	require.NoError(t, err)
+	_, err = c.PutFileSplitWithOptions(repo, commit.ID, "neither", pclient.PutFileSplitOptions{Delimiter: pfs.Delimiter_SEPARATOR, TargetFileDatums: 1}, strings.NewReader(fasta))
+	require.YesError(t, err)
+	_, err = c.PutFileSplitWithOptions(repo, commit.ID, "bad", pclient.PutFileSplitOptions{Delimiter: pfs.Delimiter_SEPARATOR, TargetFileDatums: 1, SeparatorRegex: "("}, strings.NewReader(fasta))
+	require.YesError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	fileInfos, err := c.ListFile(repo, commit.ID, "fasta")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(fileInfos))
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(repo, commit.ID, fileInfos[1].File.Path, 0, 0, &buffer))
+	require.Equal(t, ">b\nGG\n", buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.GetFile(repo, commit.ID, "fasta", 0, 0, &buffer))
+	require.Equal(t, fasta, buffer.String())
+
+	fileInfos, err = c.ListFile(repo, commit.ID, "records")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(fileInfos))
+	require.Equal(t, int64(2), fileInfos[0].RecordCount)
+}
+
+func TestSplitParquet(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestSplitParquet")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	// a Parquet file of 3 row groups, of 1, 2 and 3 rows, each with a single
+	// column chunk whose pages are opaque to splitting
+	data := &bytes.Buffer{}
+	data.Write(parquetMagic)
+	var chunks [][]byte
+	var rowGroups []interface{}
+	for i := 0; i < 3; i++ {
+		chunk := bytes.Repeat([]byte{byte('a' + i)}, 10+i)
+		metaData := thriftStruct{
+			{id: columnMetaDataTotalCompressedSize, typ: thriftI64, value: int64(len(chunk))},
+			{id: columnMetaDataDataPageOffset, typ: thriftI64, value: int64(data.Len())},
+		}
+		column := thriftStruct{{id: columnChunkMetaData, typ: thriftStructType, value: metaData}}
+		rowGroups = append(rowGroups, thriftStruct{
+			{id: rowGroupColumns, typ: thriftList, value: &thriftListValue{elemType: thriftStructType, elems: []interface{}{column}}},
+			{id: rowGroupNumRows, typ: thriftI64, value: int64(i + 1)},
+		})
+		data.Write(chunk)
+		chunks = append(chunks, chunk)
+	}
+	footer := &thriftWriter{}
+	footer.writeStruct(thriftStruct{
+		{id: fileMetaDataNumRows, typ: thriftI64, value: int64(6)},
+		{id: fileMetaDataRowGroups, typ: thriftList, value: &thriftListValue{elemType: thriftStructType, elems: rowGroups}},
+	})
+	data.Write(footer.Bytes())
+	require.NoError(t, binary.Write(data, binary.LittleEndian, uint32(footer.Len())))
+	data.Write(parquetMagic)
+
+	_, err = c.PutFileSplit(repo, commit.ID, "parquet", pfs.Delimiter_PARQUET, 2, 0, false, bytes.NewReader(data.Bytes()))
+	require.NoError(t, err)
+	_, err = c.PutFileSplit(repo, commit.ID, "bad", pfs.Delimiter_PARQUET, 2, 0, false, strings.NewReader("foo\n"))
+	require.YesError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	// each split file is a Parquet file of its own
+	fileInfos, err := c.ListFile(repo, commit.ID, "parquet")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(fileInfos))
+	require.Equal(t, int64(3), fileInfos[0].RecordCount)
+	require.Equal(t, int64(3), fileInfos[1].RecordCount)
+	var i int
+	for _, fileInfo := range fileInfos {
+		var buffer bytes.Buffer
+		require.NoError(t, c.GetFile(repo, commit.ID, fileInfo.File.Path, 0, 0, &buffer))
+		file := buffer.Bytes()
+		footer, err := readParquetFooter(int64(len(file)), func(offset int64, n int64) ([]byte, error) {
+			return file[offset : offset+n], nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, fileInfo.RecordCount, footer.fields.get(fileMetaDataNumRows))
+		for _, rowGroup := range footer.rowGroups {
+			column := rowGroup.columns[0]
+			require.Equal(t, chunks[i], file[column.offset:column.offset+column.length])
+			i++
+		}
+	}
+	require.Equal(t, 3, i)
+}
+
+func TestCommitLock(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestCommitLock")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+
+	lock, err := c.AcquireCommitLock(repo, "master", "/a/b", "writer-1", 0)
+	require.NoError(t, err)
+	require.Equal(t, commit.ID, lock.File.Commit.ID)
+	// overlapping paths can't be locked, others can
+	_, err = c.AcquireCommitLock(repo, commit.ID, "/a", "writer-2", 0)
+	require.YesError(t, err)
+	_, err = c.AcquireCommitLock(repo, commit.ID, "/a/b/c", "writer-2", 0)
+	require.YesError(t, err)
+	other, err := c.AcquireCommitLock(repo, commit.ID, "/a/bc", "writer-2", 0)
+	require.NoError(t, err)
+	locks, err := c.ListCommitLock(repo, commit.ID)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(locks))
+
+	renewed, err := c.RenewCommitLock(repo, commit.ID, lock.ID, 600)
+	require.NoError(t, err)
+	require.True(t, renewed.Expires.Seconds > lock.Expires.Seconds)
+	require.NoError(t, c.ReleaseCommitLock(repo, commit.ID, lock.ID))
+	require.YesError(t, c.ReleaseCommitLock(repo, commit.ID, lock.ID))
+	_, err = c.AcquireCommitLock(repo, commit.ID, "/a", "writer-3", 0)
+	require.YesError(t, err)
+	require.NoError(t, c.ReleaseCommitLock(repo, commit.ID, other.ID))
+	_, err = c.AcquireCommitLock(repo, commit.ID, "/", "writer-3", 1)
+	require.NoError(t, err)
+
+	// locks expire unless they're renewed
+	time.Sleep(2 * time.Second)
+	locks, err = c.ListCommitLock(repo, commit.ID)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(locks))
+
+	// and they're released when the commit is finished
+	_, err = c.AcquireCommitLock(repo, commit.ID, "/", "writer-4", 0)
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+	locks, err = c.ListCommitLock(repo, commit.ID)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(locks))
+	_, err = c.AcquireCommitLock(repo, commit.ID, "/", "writer-4", 0)
+	require.YesError(t, err)
+}
+
+func TestDeleteLockedCommit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	// the file is checked against a schema that's served by fetch, which
+	// holds FinishCommit up, with its progress recorded, until release is
+	// closed
+	fetch := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetch <- struct{}{}
+		<-release
+		fmt.Fprint(w, `{"type": "object"}`)
+	}))
+	defer server.Close()
+	repo := uniqueString("TestDeleteLockedCommit")
+	require.NoError(t, c.CreateRepo(repo))
+	require.NoError(t, c.SetSchema(repo, "", &pfs.Schema{Type: pfs.SchemaType_JSON_SCHEMA, Url: server.URL}))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "file", strings.NewReader("{}\n"))
+	require.NoError(t, err)
+	_, err = c.AcquireCommitLock(repo, commit.ID, "/", "writer", 0)
+	require.NoError(t, err)
+
+	var eg errgroup.Group
+	eg.Go(func() error {
+		return c.FinishCommit(repo, commit.ID)
+	})
+	<-fetch
+	commitInfo, err := c.InspectCommit(repo, commit.ID)
+	require.NoError(t, err)
+	require.Nil(t, commitInfo.Finished)
+	require.NotNil(t, commitInfo.Progress)
+	require.NoError(t, c.DeleteCommit(repo, commit.ID))
+
+	// the commit's lock and progress are deleted with it
+	etcdClient, err := etcd.New(etcd.Config{
+		Endpoints:   []string{"localhost:32379"},
+		DialOptions: pclient.EtcdDialOptions(),
+	})
+	require.NoError(t, err)
+	defer etcdClient.Close()
+	resp, err := etcdClient.Get(context.Background(), "", etcd.WithPrefix(), etcd.WithKeysOnly())
+	require.NoError(t, err)
+	for _, kv := range resp.Kvs {
+		key := string(kv.Key)
+		if strings.HasSuffix(key, "/"+commit.ID) {
+			require.False(t, strings.Contains(key, "/commitLocks/"), "lock %s wasn't deleted", key)
+			require.False(t, strings.Contains(key, "/commitProgress/"), "progress %s wasn't deleted", key)
+		}
+	}
+	close(release)
+	require.YesError(t, eg.Wait())
+}
+
+func TestPutFileCommitLocks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestPutFileCommitLocks")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	lock, err := c.AcquireCommitLock(repo, commit.ID, "/a", "writer-1", 0)
+	require.NoError(t, err)
+
+	// the lock's holder can write under it, other writers can't
+	_, err = c.PutFileWithCommitLock(repo, commit.ID, "a/foo", lock.ID, strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	_, err = c.PutFileWithCommitLock(repo, commit.ID, "a/foo", "", strings.NewReader("bar\n"))
+	require.YesError(t, err)
+	_, err = c.PutFileWithCommitLock(repo, commit.ID, "b", "", strings.NewReader("bar\n"))
+	require.NoError(t, err)
+	// writers that don't check locks aren't stopped by them
+	_, err = c.PutFile(repo, commit.ID, "a/foo", strings.NewReader("buzz\n"))
+	require.NoError(t, err)
+	// and a lock that's been released can't be written with
+	require.NoError(t, c.ReleaseCommitLock(repo, commit.ID, lock.ID))
+	_, err = c.PutFileWithCommitLock(repo, commit.ID, "a/foo", lock.ID, strings.NewReader("fizz\n"))
+	require.YesError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	var b bytes.Buffer
+	require.NoError(t, c.GetFile(repo, commit.ID, "a/foo", 0, 0, &b))
+	require.Equal(t, "foo\nbuzz\n", b.String())
+}
+
+func TestPutFileURLRetry(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+	c := getClient(t)
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/flaky":
+			// fail the first fetch, so that it has to be retried
+			if atomic.AddInt32(&requests, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			fmt.Fprint(w, "foo\n")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	repo := uniqueString("TestPutFileURLRetry")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.PutFileURL(repo, commit.ID, "flaky", server.URL+"/flaky", false, false))
+	require.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	// a missing url isn't retried, and doesn't create the file
+	require.YesError(t, c.PutFileURL(repo, commit.ID, "missing", server.URL+"/missing", false, false))
+	require.YesError(t, c.PutFileURL(repo, commit.ID, "dir", server.URL+"/flaky", true, false))
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(repo, commit.ID, "flaky", 0, 0, &buffer))
+	require.Equal(t, "foo\n", buffer.String())
+	_, err = c.InspectFile(repo, commit.ID, "missing")
+	require.YesError(t, err)
+}
+
+func TestTempRepo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestTempRepo")
+	require.NoError(t, c.CreateTempRepo(repo, 1))
+	_, err := c.PutFile(repo, "master", "file", strings.NewReader("foo"))
+	require.NoError(t, err)
+	repoInfo, err := c.InspectRepo(repo)
+	require.NoError(t, err)
+	require.NotNil(t, repoInfo.Expires)
+	// the expired repo is deleted once the lease is next checked
+	require.NoError(t, backoff.Retry(func() error {
+		if _, err := c.InspectRepo(repo); err == nil {
+			return fmt.Errorf("repo %s hasn't been deleted yet", repo)
+		}
+		return nil
+	}, backoff.NewTestingBackOff()))
+	require.YesError(t, c.RenewRepo(repo, 0))
+
+	permanent := uniqueString("TestTempRepoPermanent")
+	require.NoError(t, c.CreateRepo(permanent))
+	require.YesError(t, c.RenewRepo(permanent, 60))
+	repoInfo, err = c.InspectRepo(permanent)
+	require.NoError(t, err)
+	require.Nil(t, repoInfo.Expires)
+
+	session := uniqueString("TestTempRepoSession")
+	release, err := c.CreateSessionRepo(session, 3)
+	require.NoError(t, err)
+	// the lease is renewed, so the repo outlives its ttl
+	time.Sleep(15 * time.Second)
+	_, err = c.InspectRepo(session)
+	require.NoError(t, err)
+	require.NoError(t, release())
+	_, err = c.InspectRepo(session)
+	require.YesError(t, err)
+}
+
+func TestFileTTL(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestFileTTL")
+	require.NoError(t, c.CreateRepo(repo))
+	// overwriting a file without a ttl clears its ttl
+	_, err := c.PutFileWithTTL(repo, "master", "logs/kept", 1, strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	_, err = c.PutFileOverwrite(repo, "master", "logs/kept", strings.NewReader("bar\n"), 0)
+	require.NoError(t, err)
+	_, err = c.PutFileWithTTL(repo, "master", "logs/old", 1, strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, "master", "logs/new", strings.NewReader("bar\n"))
+	require.NoError(t, err)
+	// the ttl needs a branch to delete the file from
+	commit, err := c.StartCommit(repo, "")
+	require.NoError(t, err)
+	_, err = c.PutFileWithTTL(repo, commit.ID, "file", 1, strings.NewReader("foo\n"))
+	require.YesError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	// the expired file is deleted in a new commit on master, once expirations
+	// are next checked
+	require.NoError(t, backoff.Retry(func() error {
+		if _, err := c.InspectFile(repo, "master", "logs/old"); err == nil {
+			return fmt.Errorf("logs/old hasn't been deleted yet")
+		}
+		return nil
+	}, backoff.NewTestingBackOff()))
+	_, err = c.InspectFile(repo, "master", "logs/new")
+	require.NoError(t, err)
+	_, err = c.InspectFile(repo, "master", "logs/kept")
+	require.NoError(t, err)
+	commitInfos, err := c.ListCommit(repo, "master", "", 0)
+	require.NoError(t, err)
+	require.Equal(t, 5, len(commitInfos))
+	var buf bytes.Buffer
+	require.NoError(t, c.GetFile(repo, "master~1", "logs/old", 0, 0, &buf))
+	require.Equal(t, "foo\n", buf.String())
+}
+
+func TestUploadSession(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestUploadSession")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	data := []byte("0123456789abcdefghij")
+
+	session, err := c.StartUpload(repo, commit.ID, "file", false)
+	require.NoError(t, err)
+	_, err = c.PutChunk(session.ID, 0, bytes.NewReader(data[:8]))
+	require.NoError(t, err)
+	// a chunk that's already been acknowledged can't be put again
+	_, err = c.PutChunk(session.ID, 0, bytes.NewReader(data[:8]))
+	require.YesError(t, err)
+	session, err = c.InspectUpload(session.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), session.Chunks)
+	require.Equal(t, int64(8), session.SizeBytes)
+	// resume the upload from the chunk after the acknowledged one
+	require.NoError(t, c.PutFileResumable(session.ID, bytes.NewReader(data), 5))
+	_, err = c.InspectUpload(session.ID)
+	require.YesError(t, err)
+	require.YesError(t, c.CompleteUpload(session.ID))
+
+	unfinished, err := c.StartUpload(repo, commit.ID, "unfinished", false)
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+	require.YesError(t, c.CompleteUpload(unfinished.ID))
+	_, err = c.StartUpload(repo, commit.ID, "file", false)
+	require.YesError(t, err)
+
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(repo, commit.ID, "file", 0, 0, &buffer))
+	require.Equal(t, string(data), buffer.String())
+	_, err = c.InspectFile(repo, commit.ID, "unfinished")
+	require.YesError(t, err)
+}
+
+func TestFileSha256(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestFileSha256")
+	require.NoError(t, c.CreateRepo(repo))
+	// checkDigest checks that the digest of the file at path is computed over
+	// the hashes of its objects
+	checkDigest := func(commitID string, path string) []byte {
+		fileInfo, err := c.InspectFile(repo, commitID, path)
+		require.NoError(t, err)
+		hash := sha256.New()
+		for _, object := range fileInfo.Objects {
+			hash.Write([]byte(object.Hash))
+		}
+		require.Equal(t, hash.Sum(nil), fileInfo.Sha256)
+		fileInfos, err := c.ListFile(repo, commitID, path)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(fileInfos))
+		require.Equal(t, fileInfo.Sha256, fileInfos[0].Sha256)
+		return fileInfo.Sha256
+	}
+
+	commit1, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit1.ID, "file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit1.ID, "file", strings.NewReader("bar\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit1.ID))
+	digest := checkDigest(commit1.ID, "file")
+	// directories don't have a digest
+	fileInfo, err := c.InspectFile(repo, commit1.ID, "")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(fileInfo.Sha256))
+
+	// appending to a file changes its digest
+	require.NoError(t, c.SetBranch(repo, commit1.ID, "feature"))
+	commit2, err := c.StartCommit(repo, "feature")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit2.ID, "file", strings.NewReader("baz\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit2.ID))
+	require.NotEqual(t, digest, checkDigest(commit2.ID, "file"))
+
+	commit3, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit3.ID, "other", strings.NewReader("other\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit3.ID))
+
+	// merged and compacted commits are built from a tree, their files have a
+	// digest too
+	response, err := c.Merge(repo, "master", "feature")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(response.Conflicts))
+	require.NotEqual(t, commit2.ID, response.Commit.ID)
+	checkDigest(response.Commit.ID, "file")
+	checkDigest(response.Commit.ID, "other")
+	compacted, err := c.CompactFile(repo, "master", "file", false)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), compacted.FilesCompacted)
+	checkDigest(compacted.Commit.ID, "file")
+}
+
+func TestGetFileVerified(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestGetFileVerified")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	// each put is a separate object of the file
+	_, err = c.PutFile(repo, commit.ID, "file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "file", strings.NewReader("bar\n"))
+	require.NoError(t, err)
+	// files in open commits can be read verified too
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFileVerified(repo, commit.ID, "file", 0, 0, &buffer))
+	require.Equal(t, "foo\nbar\n", buffer.String())
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	buffer.Reset()
+	require.NoError(t, c.GetFileVerified(repo, commit.ID, "file", 0, 0, &buffer))
+	require.Equal(t, "foo\nbar\n", buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.GetFileVerified(repo, commit.ID, "file", 2, 4, &buffer))
+	require.Equal(t, "o\nba", buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.GetFileVerified(repo, commit.ID, "file", 5, 0, &buffer))
+	require.Equal(t, "ar\n", buffer.String())
+
+	require.True(t, pfs.IsIntegrityError(&pfs.IntegrityError{Path: "file"}))
+	require.False(t, pfs.IsIntegrityError(fmt.Errorf("file not found")))
+}
+
+func TestProxyRepo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	// the remote is a separate cluster, with its own object store
+	remote := getClient(t)
+	remoteRepo := uniqueString("TestProxyRepo_remote")
+	require.NoError(t, remote.CreateRepo(remoteRepo))
+	_, err := remote.PutFile(remoteRepo, "master", "file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+
+	c := getClient(t)
+	repo := uniqueString("TestProxyRepo")
+	require.YesError(t, c.CreateProxyRepo(repo, remote.GetAddress(), "nonexistent"))
+	require.NoError(t, c.CreateProxyRepo(repo, remote.GetAddress(), remoteRepo))
+	repoInfo, err := c.InspectRepo(repo)
+	require.NoError(t, err)
+	require.Equal(t, remoteRepo, repoInfo.Remote.Repo)
+
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(repo, "master", "file", 0, 0, &buffer))
+	require.Equal(t, "foo\n", buffer.String())
+	commitInfo, err := c.InspectCommit(repo, "master")
+	require.NoError(t, err)
+	remoteInfo, err := remote.InspectCommit(remoteRepo, "master")
+	require.NoError(t, err)
+	require.Equal(t, remoteInfo.Commit.ID, commitInfo.Commit.ID)
+
+	// the proxy's branches follow the remote's
+	_, err = remote.PutFile(remoteRepo, "master", "file", strings.NewReader("bar\n"))
+	require.NoError(t, err)
+	buffer.Reset()
+	require.NoError(t, c.GetFile(repo, "master", "file", 0, 0, &buffer))
+	require.Equal(t, "foo\nbar\n", buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.GetFile(repo, "master^", "file", 0, 0, &buffer))
+	require.Equal(t, "foo\n", buffer.String())
+
+	// proxy repos can't be written to
+	_, err = c.StartCommit(repo, "master")
+	require.YesError(t, err)
+}
+
+func TestCompressedRepo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestCompressedRepo")
+	require.NoError(t, c.CreateCompressedRepo(repo, pfs.Compression_GZIP))
+	repoInfo, err := c.InspectRepo(repo)
+	require.NoError(t, err)
+	require.Equal(t, pfs.Compression_GZIP, repoInfo.Compression)
+
+	commit1, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit1.ID, "file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit1.ID, "file", strings.NewReader("bar\n"))
+	require.NoError(t, err)
+	_, err = c.PutFileSplit(repo, commit1.ID, "split", pfs.Delimiter_LINE, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit1.ID))
+
+	fileInfo, err := c.InspectFile(repo, commit1.ID, "file")
+	require.NoError(t, err)
+	require.Equal(t, uint64(8), fileInfo.SizeBytes)
+	require.Equal(t, 2, len(fileInfo.Objects))
+	for _, object := range fileInfo.Objects {
+		require.Equal(t, pfs.Compression_GZIP, object.Compression)
+	}
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(repo, commit1.ID, "file", 0, 0, &buffer))
+	require.Equal(t, "foo\nbar\n", buffer.String())
+	// offsets are of the data before it's compressed, and span objects
+	buffer.Reset()
+	require.NoError(t, c.GetFile(repo, commit1.ID, "file", 2, 3, &buffer))
+	require.Equal(t, "o\nb", buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.GetFile(repo, commit1.ID, "split/0000000000000001", 0, 0, &buffer))
+	require.Equal(t, "bar\n", buffer.String())
+
+	// changing the compression only affects data written afterwards
+	_, err = c.PfsAPIClient.CreateRepo(context.Background(), &pfs.CreateRepoRequest{
+		Repo:   pclient.NewRepo(repo),
+		Update: true,
+	})
+	require.NoError(t, err)
+	commit2, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit2.ID, "file", strings.NewReader("buz\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit2.ID))
+	fileInfo, err = c.InspectFile(repo, commit2.ID, "file")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(fileInfo.Objects))
+	require.Equal(t, pfs.Compression_UNCOMPRESSED, fileInfo.Objects[2].Compression)
+	buffer.Reset()
+	require.NoError(t, c.GetFile(repo, commit2.ID, "file", 0, 0, &buffer))
+	require.Equal(t, "foo\nbar\nbuz\n", buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.GetFile(repo, commit2.ID, "file", 6, 0, &buffer))
+	require.Equal(t, "r\nbuz\n", buffer.String())
+
+	// compaction rewrites the file with the repo's current compression
+	response, err := c.CompactFile(repo, commit2.ID, "file", false)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), response.ObjectsAfter)
+	fileInfo, err = c.InspectFile(repo, response.Commit.ID, "file")
+	require.NoError(t, err)
+	require.Equal(t, pfs.Compression_UNCOMPRESSED, fileInfo.Objects[0].Compression)
+	buffer.Reset()
+	require.NoError(t, c.GetFile(repo, response.Commit.ID, "file", 0, 0, &buffer))
+	require.Equal(t, "foo\nbar\nbuz\n", buffer.String())
+}
+
+func TestScheduler(t *testing.T) {
+	t.Parallel()
+	require.True(t, newScheduler(0, 0) == nil)
+
+	s := newScheduler(1, 1)
+	ctx := context.Background()
+	interactiveDone, err := s.start(ctx, pfs.Priority_INTERACTIVE)
+	require.NoError(t, err)
+	// batch work yields while the interactive operation runs
+	started := make(chan struct{})
+	go func() {
+		batchDone, err := s.start(ctx, pfs.Priority_BATCH)
+		require.NoError(t, err)
+		close(started)
+		batchDone()
+	}()
+	select {
+	case <-started:
+		t.Fatal("batch operation started under interactive load")
+	case <-time.After(100 * time.Millisecond):
+	}
+	// interactive work doesn't wait for anything
+	secondDone, err := s.start(ctx, pfs.Priority_INTERACTIVE)
+	require.NoError(t, err)
+	secondDone()
+	interactiveDone()
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("batch operation didn't start once the load let up")
+	}
+
+	// batch operations are limited to batchConcurrency at once, and stop
+	// waiting when their context is done
+	batchDone, err := s.start(ctx, pfs.Priority_BATCH)
+	require.NoError(t, err)
+	cancelCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, err = s.start(cancelCtx, pfs.Priority_BATCH)
+	require.YesError(t, err)
+	batchDone()
+}
+
+func TestScheduleNested(t *testing.T) {
+	t.Parallel()
+	d := &driver{scheduler: newScheduler(0, 1)}
+	ctx, done, err := d.schedule(context.Background(), pfs.Priority_BATCH)
+	require.NoError(t, err)
+	defer done()
+	// an operation run by a scheduled one doesn't wait for a slot of its own
+	nestedCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, nestedDone, err := d.schedule(nestedCtx, pfs.Priority_BATCH)
+	require.NoError(t, err)
+	nestedDone()
+	// but an unrelated one does
+	otherCtx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, _, err = d.schedule(otherCtx, pfs.Priority_BATCH)
+	require.YesError(t, err)
+}
+
+func TestGetManifest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestGetManifest")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	for _, p := range []string{"b", "a/d", "a/c/e", "a.txt"} {
+		_, err = c.PutFile(repo, commit.ID, p, strings.NewReader(p+"\n"))
+		require.NoError(t, err)
+	}
+	// open commits don't have manifests
+	_, err = c.GetManifest(repo, commit.ID)
+	require.YesError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	manifest, err := c.GetManifest(repo, "master")
+	require.NoError(t, err)
+	require.Equal(t, commit.ID, manifest.Commit.ID)
+	var paths []string
+	for _, entry := range manifest.Entries {
+		paths = append(paths, entry.Path)
+	}
+	require.Equal(t, []string{"/a.txt", "/a/c/e", "/a/d", "/b"}, paths)
+	require.Equal(t, uint64(18), manifest.SizeBytes)
+
+	// overlapping globs only list each file once
+	manifest, err = c.GetManifest(repo, "master", "a/*", "a/c")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(manifest.Entries))
+	require.Equal(t, "/a/c/e", manifest.Entries[0].Path)
+	require.Equal(t, "/a/d", manifest.Entries[1].Path)
+
+	// the entries can be read without asking pfs about the files again
+	var buf bytes.Buffer
+	require.NoError(t, c.GetManifestEntry(manifest.Entries[0], 0, 0, &buf))
+	require.Equal(t, "a/c/e\n", buf.String())
+	buf.Reset()
+	require.NoError(t, c.GetManifestEntry(manifest.Entries[1], 1, 2, &buf))
+	require.Equal(t, "/d", buf.String())
+
+	manifest, err = c.GetManifest(repo, "master", "nothing*")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(manifest.Entries))
+}
+
+func TestPutFilesFromManifest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	src := uniqueString("TestPutFilesFromManifestSrc")
+	require.NoError(t, c.CreateRepo(src))
+	commit, err := c.StartCommit(src, "master")
+	require.NoError(t, err)
+	for _, p := range []string{"a", "dir/b"} {
+		_, err = c.PutFile(src, commit.ID, p, strings.NewReader(p+"\n"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, c.FinishCommit(src, commit.ID))
+	manifest, err := c.GetManifest(src, "master")
+	require.NoError(t, err)
+
+	// the files are put into another repo without copying their data
+	dst := uniqueString("TestPutFilesFromManifestDst")
+	require.NoError(t, c.CreateRepo(dst))
+	commit, err = c.StartCommit(dst, "master")
+	require.NoError(t, err)
+	entries := append(manifest.Entries, &pfs.ManifestEntry{Path: "link", SymlinkTarget: "dir/b"})
+	require.NoError(t, c.PutFilesFromManifest(dst, commit.ID, entries, false))
+	require.NoError(t, c.PutFilesFromManifest(dst, commit.ID, manifest.Entries[:1], true))
+	require.NoError(t, c.FinishCommit(dst, commit.ID))
+	var buf bytes.Buffer
+	require.NoError(t, c.GetFile(dst, "master", "a", 0, 0, &buf))
+	require.Equal(t, "a\n", buf.String())
+	fileInfo, err := c.InspectFile(dst, "master", "link")
+	require.NoError(t, err)
+	require.Equal(t, pfs.FileType_SYMLINK, fileInfo.FileType)
+	fileInfo, err = c.InspectFile(dst, "master", "dir/b")
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), fileInfo.SizeBytes)
+
+	// nothing is put if any entry is invalid
+	commit, err = c.StartCommit(dst, "master")
+	require.NoError(t, err)
+	require.YesError(t, c.PutFilesFromManifest(dst, commit.ID, []*pfs.ManifestEntry{
+		{Path: "c", Objects: manifest.Entries[0].Objects},
+		{Path: "d", Objects: []*pfs.Object{{Hash: "nonexistent"}}},
+	}, false))
+	require.YesError(t, c.PutFilesFromManifest(dst, commit.ID, []*pfs.ManifestEntry{
+		{Path: "c", Objects: manifest.Entries[0].Objects, SizeBytes: 100},
+	}, false))
+	require.NoError(t, c.FinishCommit(dst, commit.ID))
+	_, err = c.InspectFile(dst, "master", "c")
+	require.YesError(t, err)
+
+	// a manifest can have more entries than etcd allows in one transaction
+	var bigEntries []*pfs.ManifestEntry
+	for i := 0; i < 500; i++ {
+		bigEntries = append(bigEntries, &pfs.ManifestEntry{
+			Path:    fmt.Sprintf("big/file%d", i),
+			Objects: manifest.Entries[0].Objects,
+		})
+	}
+	commit, err = c.StartCommit(dst, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.PutFilesFromManifest(dst, commit.ID, bigEntries, false))
+	require.NoError(t, c.FinishCommit(dst, commit.ID))
+	fileInfos, err := c.ListFile(dst, "master", "big")
+	require.NoError(t, err)
+	require.Equal(t, 500, len(fileInfos))
+}
+
+func TestPutFileByHash(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestPutFileByHash")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	content := uniqueString("content") + "\n"
+	// the commit doesn't have the content yet, so it's sent
+	sent, err := c.PutFileByHash(repo, commit.ID, "a", pfs.PutFileMode_APPEND, strings.NewReader(content))
+	require.NoError(t, err)
+	require.True(t, sent)
+	// but after that it isn't
+	sent, err = c.PutFileByHash(repo, commit.ID, "b", pfs.PutFileMode_APPEND, strings.NewReader(content))
+	require.NoError(t, err)
+	require.False(t, sent)
+	sent, err = c.PutFileByHash(repo, commit.ID, "a", pfs.PutFileMode_OVERWRITE, strings.NewReader(content))
+	require.NoError(t, err)
+	require.False(t, sent)
+	_, err = c.PutFileByHash(repo, commit.ID, "b", pfs.PutFileMode_CREATE_ONLY, strings.NewReader(content))
+	require.YesError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	for _, p := range []string{"a", "b"} {
+		var buf bytes.Buffer
+		require.NoError(t, c.GetFile(repo, "master", p, 0, 0, &buf))
+		require.Equal(t, content, buf.String())
+		fileInfo, err := c.InspectFile(repo, "master", p)
+		require.NoError(t, err)
+		require.Equal(t, uint64(len(content)), fileInfo.SizeBytes)
+	}
+
+	// the content being in the object store doesn't make another repo's
+	// commits have it
+	otherRepo := uniqueString("TestPutFileByHashOther")
+	require.NoError(t, c.CreateRepo(otherRepo))
+	sent, err = c.PutFileByHash(otherRepo, "master", "a", pfs.PutFileMode_APPEND, strings.NewReader(content))
+	require.NoError(t, err)
+	require.True(t, sent)
+}
+
+func TestDeleteFileGlob(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestDeleteFileGlob")
+	require.NoError(t, c.CreateRepo(repo))
+	commit1, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	for _, p := range []string{"logs/2016-01", "logs/2016-02", "logs/2017-01", "other/2016-01"} {
+		_, err = c.PutFile(repo, commit1.ID, p, strings.NewReader("foo\n"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, c.FinishCommit(repo, commit1.ID))
+
+	// the pattern is matched against the files as of the delete, so a file
+	// written after it isn't deleted
+	commit2, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	require.YesError(t, c.DeleteFileGlob(repo, commit2.ID, "logs/[2016"))
+	require.NoError(t, c.DeleteFileGlob(repo, commit2.ID, "logs/2016-*"))
+	_, err = c.PutFile(repo, commit2.ID, "logs/2016-03", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit2.ID))
+	fileInfos, err := c.ListFile(repo, commit2.ID, "logs")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(fileInfos))
+	require.Equal(t, "/logs/2016-03", fileInfos[0].File.Path)
+	require.Equal(t, "/logs/2017-01", fileInfos[1].File.Path)
+	_, err = c.InspectFile(repo, commit2.ID, "other/2016-01")
+	require.NoError(t, err)
+
+	// directories are deleted with everything under them
+	commit3, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	batch, err := c.NewPutFilesBatch()
+	require.NoError(t, err)
+	require.NoError(t, batch.DeleteFileGlob(repo, commit3.ID, "*"))
+	require.NoError(t, batch.Close())
+	require.NoError(t, c.FinishCommit(repo, commit3.ID))
+	fileInfos, err = c.ListFile(repo, commit3.ID, "")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(fileInfos))
+}
+
+func TestWalkFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestWalkFile")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	for _, p := range []string{"b", "a/d", "a/c/e", "a.txt"} {
+		_, err = c.PutFile(repo, commit.ID, p, strings.NewReader("foo\n"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	var paths []string
+	require.NoError(t, c.WalkFile(repo, commit.ID, "", func(fileInfo *pfs.FileInfo) error {
+		paths = append(paths, fileInfo.File.Path)
+		return nil
+	}))
+	require.Equal(t, []string{"/", "/a", "/a/c", "/a/c/e", "/a/d", "/a.txt", "/b"}, paths)
+
+	var fileInfos []*pfs.FileInfo
+	require.NoError(t, c.WalkFile(repo, commit.ID, "a", func(fileInfo *pfs.FileInfo) error {
+		fileInfos = append(fileInfos, fileInfo)
+		return nil
+	}))
+	require.Equal(t, 4, len(fileInfos))
+	require.Equal(t, pfs.FileType_DIR, fileInfos[0].FileType)
+	require.Equal(t, uint64(8), fileInfos[0].SizeBytes)
+	require.Equal(t, pfs.FileType_FILE, fileInfos[3].FileType)
+
+	// the walk stops at the first error returned by walkFn
+	var n int
+	require.YesError(t, c.WalkFile(repo, commit.ID, "", func(fileInfo *pfs.FileInfo) error {
+		n++
+		return fmt.Errorf("stop")
+	}))
+	require.Equal(t, 1, n)
+	require.YesError(t, c.WalkFile(repo, commit.ID, "nonexistent", func(*pfs.FileInfo) error { return nil }))
+}
+
+func TestDiffFileSummary(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestDiffFileSummary")
+	require.NoError(t, c.CreateRepo(repo))
+	commit1, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit1.ID, "dir/modified", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit1.ID, "dir/deleted", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit1.ID, "same", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit1.ID))
+
+	commit2, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit2.ID, "dir/modified", strings.NewReader("bar\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.DeleteFile(repo, commit2.ID, "dir/deleted"))
+	_, err = c.PutFile(repo, commit2.ID, "dir/added1", strings.NewReader("foobar\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit2.ID, "added2", strings.NewReader("foobarbaz\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit2.ID))
+
+	summary, err := c.DiffFileSummary(repo, commit2.ID, "", "", "", "", false)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), summary.FilesAdded)
+	require.Equal(t, int64(17), summary.BytesAdded)
+	require.Equal(t, int64(1), summary.FilesDeleted)
+	require.Equal(t, int64(4), summary.BytesDeleted)
+	require.Equal(t, int64(1), summary.FilesModified)
+	require.Equal(t, int64(8), summary.BytesModified)
+
+	// the summary agrees with the full diff
+	newFiles, oldFiles, err := c.DiffFile(repo, commit2.ID, "dir", repo, commit1.ID, "dir", false)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(newFiles))
+	require.Equal(t, 2, len(oldFiles))
+	summary, err = c.DiffFileSummary(repo, commit2.ID, "dir", repo, commit1.ID, "dir", false)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), summary.FilesAdded)
+	require.Equal(t, int64(1), summary.FilesDeleted)
+	require.Equal(t, int64(1), summary.FilesModified)
+}
+
+func TestApply(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	upstream := uniqueString("TestApply")
+	downstream := uniqueString("TestApply")
+	spec := &pfs.ApplySpec{
+		Repos: []*pfs.RepoSpec{
+			{
+				Repo:       pclient.NewRepo(downstream),
+				Provenance: []*pfs.Repo{pclient.NewRepo(upstream)},
+			},
+			{
+				Repo:        pclient.NewRepo(upstream),
+				Description: "raw data",
+			},
+		},
 	}
-	var b bytes.Buffer
-	require.NoError(t, c.GetFile(repo, "other", "file0", 0, 0, &b))
-	require.Equal(t, "foo 0\n", b.String())
-	_, err = c.StartCommit(repo, "other")
-	require.NoError(t, c.CopyFile(repo, "other", "files/0", repo, "other", "files", true))
-	require.NoError(t, c.FinishCommit(repo, "other"))
-	b.Reset()
-	require.NoError(t, c.GetFile(repo, "other", "files", 0, 0, &b))
-	require.Equal(t, "foo 0\n", b.String())
+
+	// a dry run plans the creation of both repos, upstream first
+	actions, err := c.Apply(spec, false, true)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(actions))
+	require.Equal(t, pfs.ApplyAction_CREATE, actions[0].Type)
+	require.Equal(t, upstream, actions[0].Repo.Name)
+	require.Equal(t, downstream, actions[1].Repo.Name)
+	_, err = c.InspectRepo(upstream)
+	require.YesError(t, err)
+
+	actions, err = c.Apply(spec, false, false)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(actions))
+	repoInfo, err := c.InspectRepo(downstream)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(repoInfo.Provenance))
+	repoInfo, err = c.InspectRepo(upstream)
+	require.NoError(t, err)
+	require.Equal(t, "raw data", repoInfo.Description)
+
+	// applying the same spec again is a no-op
+	actions, err = c.Apply(spec, false, false)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(actions))
+
+	commit, err := c.StartCommit(upstream, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(upstream, commit.ID))
+	spec.Repos[1].Description = "cleaned data"
+	spec.Repos[1].Branches = []*pfs.BranchSpec{{Name: "prod", Head: "master"}}
+	actions, err = c.Apply(spec, false, false)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(actions))
+	require.Equal(t, pfs.ApplyAction_UPDATE, actions[0].Type)
+	require.Equal(t, pfs.ApplyAction_CREATE, actions[1].Type)
+	require.Equal(t, "prod", actions[1].Branch)
+	branches, err := c.ListBranch(upstream)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(branches))
+
+	// pruning would delete the master branch, and downstream once it's
+	// removed from the spec (along with every other test's repos, so it's
+	// only a dry run)
+	spec.Repos = spec.Repos[1:]
+	actions, err = c.Apply(spec, true, true)
+	require.NoError(t, err)
+	var ours []*pfs.ApplyAction
+	for _, action := range actions {
+		if action.Repo.Name == upstream || action.Repo.Name == downstream {
+			ours = append(ours, action)
+		}
+	}
+	require.Equal(t, 2, len(ours))
+	require.Equal(t, pfs.ApplyAction_DELETE, ours[0].Type)
+	require.Equal(t, "master", ours[0].Branch)
+	require.Equal(t, pfs.ApplyAction_DELETE, ours[1].Type)
+	require.Equal(t, downstream, ours[1].Repo.Name)
+	_, err = c.InspectRepo(downstream)
+	require.NoError(t, err)
+
+	// a spec can't drop a repo that's still the provenance of one in it
+	spec.Repos = []*pfs.RepoSpec{{
+		Repo:       pclient.NewRepo(downstream),
+		Provenance: []*pfs.Repo{pclient.NewRepo(upstream)},
+	}}
+	_, err = c.Apply(spec, true, true)
+	require.YesError(t, err)
 }
 
-func TestBuildCommit(t *testing.T) {
+func TestExport(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
 	}
 	t.Parallel()
 
 	c := getClient(t)
-	repo := uniqueString("TestBuildCommit")
-	require.NoError(t, c.CreateRepo(repo))
+	upstream := uniqueString("TestExport")
+	require.NoError(t, c.CreateRepo(upstream))
+	downstream := uniqueString("TestExport")
+	_, err := c.PfsAPIClient.CreateRepo(context.Background(), &pfs.CreateRepoRequest{
+		Repo:        pclient.NewRepo(downstream),
+		Provenance:  []*pfs.Repo{pclient.NewRepo(upstream)},
+		Description: "processed data",
+	})
+	require.NoError(t, err)
+	commit, err := c.StartCommit(upstream, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(upstream, commit.ID))
+	require.NoError(t, c.SetBranch(upstream, commit.ID, "prod"))
+	policy := &pfs.CompactionPolicy{MinObjects: 10, MaxCompactionsPerHour: 1}
+	require.NoError(t, c.SetCompactionPolicy(upstream, policy))
 
-	tree1 := hashtree.NewHashTree()
-	fooObj, fooSize, err := c.PutObject(strings.NewReader("foo\n"))
+	spec, err := c.Export(upstream, downstream)
 	require.NoError(t, err)
-	require.NoError(t, tree1.PutFile("foo", []*pfs.Object{fooObj}, fooSize))
-	tree1Finish, err := tree1.Finish()
+	require.Equal(t, 2, len(spec.Repos))
+	require.Equal(t, downstream, spec.Repos[0].Repo.Name)
+	require.Equal(t, "processed data", spec.Repos[0].Description)
+	require.Equal(t, 1, len(spec.Repos[0].Provenance))
+	require.Nil(t, spec.Repos[0].CompactionPolicy)
+	require.Equal(t, upstream, spec.Repos[1].Repo.Name)
+	require.Equal(t, 2, len(spec.Repos[1].Branches))
+	require.Equal(t, "master", spec.Repos[1].Branches[0].Name)
+	require.Equal(t, commit.ID, spec.Repos[1].Branches[0].Head)
+	require.Equal(t, "prod", spec.Repos[1].Branches[1].Name)
+	require.Equal(t, policy.MinObjects, spec.Repos[1].CompactionPolicy.MinObjects)
+
+	// the exported spec matches the repos, so applying it changes nothing
+	actions, err := c.Apply(spec, false, true)
 	require.NoError(t, err)
-	serialized, err := hashtree.Serialize(tree1Finish)
+	require.Equal(t, 0, len(actions))
+
+	// until they drift from it
+	require.NoError(t, c.SetCompactionPolicy(upstream, nil))
+	actions, err = c.Apply(spec, false, true)
 	require.NoError(t, err)
-	tree1Obj, _, err := c.PutObject(bytes.NewReader(serialized))
-	_, err = c.BuildCommit(repo, "master", "", tree1Obj.Hash)
+	require.Equal(t, 1, len(actions))
+	require.Equal(t, pfs.ApplyAction_UPDATE, actions[0].Type)
+	require.Equal(t, upstream, actions[0].Repo.Name)
+	actions, err = c.Apply(spec, false, false)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(actions))
+	policyInfo, err := c.InspectCompactionPolicy(upstream)
 	require.NoError(t, err)
+	require.Equal(t, policy.MinObjects, policyInfo.Policy.MinObjects)
+}
+
+func TestResidency(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	// the test cluster doesn't set PFS_RESIDENCY, so it satisfies no tags
+	c := getClient(t)
+	repo := uniqueString("TestResidency")
+	require.NoError(t, c.CreateRepo(repo))
+	require.YesError(t, c.SetResidency(repo, "bad tag"))
+	err := c.SetResidency(repo, "eu-only")
+	require.YesError(t, err)
+	require.Matches(t, "can't be placed on this cluster", err.Error())
+	require.NoError(t, c.SetResidency(repo))
 	repoInfo, err := c.InspectRepo(repo)
 	require.NoError(t, err)
-	require.Equal(t, uint64(fooSize), repoInfo.SizeBytes)
-	commitInfo, err := c.InspectCommit(repo, "master")
+	require.Equal(t, 0, len(repoInfo.Residency))
+
+	// a spec of repos tagged "eu-only" can't be applied to the cluster
+	spec, err := c.Export(repo)
 	require.NoError(t, err)
-	require.Equal(t, uint64(fooSize), commitInfo.SizeBytes)
+	spec.Repos[0].Residency = []string{"eu-only"}
+	_, err = c.Apply(spec, false, true)
+	require.YesError(t, err)
+	require.Matches(t, "eu-only", err.Error())
+	spec.Repos[0].Repo.Name = uniqueString("TestResidency")
+	_, err = c.Apply(spec, false, false)
+	require.YesError(t, err)
+	_, err = c.InspectRepo(spec.Repos[0].Repo.Name)
+	require.YesError(t, err)
+}
 
-	barObj, barSize, err := c.PutObject(strings.NewReader("bar\n"))
+func TestSetStorageClass(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	// the test cluster's local object store has no storage classes, so any
+	// class is written in the default one
+	c := getClient(t)
+	repo := uniqueString("TestSetStorageClass")
+	require.NoError(t, c.CreateRepo(repo))
+	require.YesError(t, c.SetStorageClass(repo, "bad class"))
+	require.NoError(t, c.SetStorageClass(repo, "STANDARD_IA"))
+	repoInfo, err := c.InspectRepo(repo)
 	require.NoError(t, err)
-	require.NoError(t, tree1.PutFile("bar", []*pfs.Object{barObj}, barSize))
-	tree2Finish, err := tree1.Finish()
+	require.Equal(t, "STANDARD_IA", repoInfo.StorageClass)
+
+	_, err = c.PutFile(repo, "master", "file", strings.NewReader("foo\n"))
 	require.NoError(t, err)
-	serialized, err = hashtree.Serialize(tree2Finish)
+	var buf bytes.Buffer
+	require.NoError(t, c.GetFile(repo, "master", "file", 0, 0, &buf))
+	require.Equal(t, "foo\n", buf.String())
+
+	// the class is exported, and applied with the repo
+	spec, err := c.Export(repo)
 	require.NoError(t, err)
-	tree2Obj, _, err := c.PutObject(bytes.NewReader(serialized))
-	_, err = c.BuildCommit(repo, "master", "", tree2Obj.Hash)
+	require.Equal(t, "STANDARD_IA", spec.Repos[0].StorageClass)
+	applied := uniqueString("TestSetStorageClass")
+	_, err = c.Apply(&pfs.ApplySpec{
+		Repos: []*pfs.RepoSpec{{Repo: pclient.NewRepo(applied), StorageClass: "NEARLINE"}},
+	}, false, false)
 	require.NoError(t, err)
+	repoInfo, err = c.InspectRepo(applied)
+	require.NoError(t, err)
+	require.Equal(t, "NEARLINE", repoInfo.StorageClass)
+
+	require.NoError(t, c.SetStorageClass(repo, ""))
 	repoInfo, err = c.InspectRepo(repo)
 	require.NoError(t, err)
-	require.Equal(t, uint64(fooSize+barSize), repoInfo.SizeBytes)
-	commitInfo, err = c.InspectCommit(repo, "master")
+	require.Equal(t, "", repoInfo.StorageClass)
+}
+
+func TestProvenanceCycle(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	upstream := uniqueString("TestProvenanceCycle")
+	require.NoError(t, c.CreateRepo(upstream))
+	downstream := uniqueString("TestProvenanceCycle")
+	_, err := c.PfsAPIClient.CreateRepo(context.Background(), &pfs.CreateRepoRequest{
+		Repo:       pclient.NewRepo(downstream),
+		Provenance: []*pfs.Repo{pclient.NewRepo(upstream)},
+	})
 	require.NoError(t, err)
-	require.Equal(t, uint64(fooSize+barSize), commitInfo.SizeBytes)
+
+	// a repo can't be its own provenance, directly or through another repo
+	_, err = c.PfsAPIClient.CreateRepo(context.Background(), &pfs.CreateRepoRequest{
+		Repo:       pclient.NewRepo(upstream),
+		Provenance: []*pfs.Repo{pclient.NewRepo(upstream)},
+		Update:     true,
+	})
+	require.YesError(t, err)
+	_, err = c.PfsAPIClient.CreateRepo(context.Background(), &pfs.CreateRepoRequest{
+		Repo:       pclient.NewRepo(upstream),
+		Provenance: []*pfs.Repo{pclient.NewRepo(downstream)},
+		Update:     true,
+	})
+	require.YesError(t, err)
+	require.Matches(t, "its own provenance", err.Error())
+	repoInfo, err := c.InspectRepo(upstream)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(repoInfo.Provenance))
+
+	// nor can a commit have a commit in its own repo as provenance
+	upstreamCommit, err := c.StartCommit(upstream, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(upstream, upstreamCommit.ID))
+	downstreamCommit, err := c.StartCommit(downstream, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(downstream, downstreamCommit.ID))
+	_, err = c.PfsAPIClient.StartCommit(context.Background(), &pfs.StartCommitRequest{
+		Parent:     pclient.NewCommit(upstream, ""),
+		Branch:     "master",
+		Provenance: []*pfs.Commit{downstreamCommit},
+	})
+	require.YesError(t, err)
+}
+
+func TestUpdateTransitiveProvenance(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	createRepo := func(repo string, update bool, provenance ...string) error {
+		request := &pfs.CreateRepoRequest{
+			Repo:   pclient.NewRepo(repo),
+			Update: update,
+		}
+		for _, prov := range provenance {
+			request.Provenance = append(request.Provenance, pclient.NewRepo(prov))
+		}
+		_, err := c.PfsAPIClient.CreateRepo(context.Background(), request)
+		return err
+	}
+	provenance := func(repo string) []string {
+		repoInfo, err := c.InspectRepo(repo)
+		require.NoError(t, err)
+		var result []string
+		for _, prov := range repoInfo.Provenance {
+			result = append(result, prov.Name)
+		}
+		return result
+	}
+	// a <- b <- c, and a <- d
+	a := uniqueString("a")
+	b := uniqueString("b")
+	cRepo := uniqueString("c")
+	d := uniqueString("d")
+	require.NoError(t, createRepo(a, false))
+	require.NoError(t, createRepo(b, false, a))
+	require.NoError(t, createRepo(cRepo, false, b))
+	require.NoError(t, createRepo(d, false, a))
+	require.Equal(t, []string{a, b}, provenance(cRepo))
+	repoInfo, err := c.InspectRepo(cRepo)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(repoInfo.ImmediateProvenance))
+	require.Equal(t, b, repoInfo.ImmediateProvenance[0].Name)
+
+	// cutting b loose from a takes a out of c's provenance too, but a is
+	// still d's provenance
+	require.NoError(t, createRepo(b, true))
+	require.Equal(t, 0, len(provenance(b)))
+	require.Equal(t, []string{b}, provenance(cRepo))
+	require.YesError(t, c.DeleteRepo(a, false))
+	require.NoError(t, createRepo(d, true))
+	require.NoError(t, c.RebuildProvenance())
+	require.Equal(t, []string{b}, provenance(cRepo))
+	require.NoError(t, c.DeleteRepo(a, false))
+	require.YesError(t, c.DeleteRepo(b, false))
+	require.NoError(t, c.DeleteRepo(cRepo, false))
+	require.NoError(t, c.DeleteRepo(b, false))
+}
+
+func TestCheckProvenanceDepth(t *testing.T) {
+	// chain[i] has chain[i-1] as its provenance
+	provenance := map[string][]*pfs.Repo{"chain0": nil}
+	for i := 1; i < 10; i++ {
+		provenance[fmt.Sprintf("chain%d", i)] = []*pfs.Repo{pclient.NewRepo(fmt.Sprintf("chain%d", i-1))}
+	}
+	provenance["cycle0"] = []*pfs.Repo{pclient.NewRepo("cycle1")}
+	provenance["cycle1"] = []*pfs.Repo{pclient.NewRepo("cycle0")}
+	getProvenance := func(repo string) ([]*pfs.Repo, error) {
+		return provenance[repo], nil
+	}
+
+	require.NoError(t, checkProvenance("repo", []*pfs.Repo{pclient.NewRepo("chain9")}, 10, getProvenance))
+	err := checkProvenance("repo", []*pfs.Repo{pclient.NewRepo("chain0"), pclient.NewRepo("chain9")}, 9, getProvenance)
+	require.YesError(t, err)
+	tooDeep, ok := err.(pfsserver.ErrProvenanceTooDeep)
+	require.True(t, ok)
+	require.Equal(t, int64(10), tooDeep.Depth)
+	require.NoError(t, checkProvenance("repo", []*pfs.Repo{pclient.NewRepo("chain9")}, 0, getProvenance))
+
+	err = checkProvenance("chain0", []*pfs.Repo{pclient.NewRepo("chain9")}, 0, getProvenance)
+	_, ok = err.(pfsserver.ErrProvenanceCycle)
+	require.True(t, ok)
+	err = checkProvenance("repo", []*pfs.Repo{pclient.NewRepo("cycle0")}, 0, getProvenance)
+	_, ok = err.(pfsserver.ErrProvenanceCycle)
+	require.True(t, ok)
 }
 
 func uniqueString(prefix string) string {
 	return prefix + "-" + uuid.NewWithoutDashes()[0:12]
 }
+
+func TestRecomputeRepoSize(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestRecomputeRepoSize")
+	require.NoError(t, c.CreateRepo(repo))
+	fileContent1 := "foo\n"
+	fileContent2 := "barbar\n"
+	commit1, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit1.ID, "foo", strings.NewReader(fileContent1))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit1.ID))
+	require.NoError(t, c.SetBranch(repo, commit1.ID, "other"))
+	commit2, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit2.ID, "bar", strings.NewReader(fileContent2))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit2.ID))
+
+	// foo is the same in both branch heads, so it's counted once
+	changes, err := c.RecomputeRepoSize(repo)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(changes))
+	require.Equal(t, repo, changes[0].Repo.Name)
+	require.Equal(t, len(fileContent1)+len(fileContent2), int(changes[0].NewSizeBytes))
+	repoInfo, err := c.InspectRepo(repo)
+	require.NoError(t, err)
+	require.Equal(t, len(fileContent1)+len(fileContent2), int(repoInfo.SizeBytes))
+
+	// an open head counts as its last finished ancestor
+	_, err = c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	changes, err = c.RecomputeRepoSize(repo)
+	require.NoError(t, err)
+	require.Equal(t, len(fileContent1)+len(fileContent2), int(changes[0].OldSizeBytes))
+	require.Equal(t, len(fileContent1)+len(fileContent2), int(changes[0].NewSizeBytes))
+}
+
+func TestRetentionPolicy(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestRetentionPolicy")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "idle")
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+	_, err = c.StartCommit(repo, "open")
+	require.NoError(t, err)
+
+	require.YesError(t, c.SetRetentionPolicy(repo, &pfs.RetentionPolicy{ExpireIdleBranchSeconds: -1}))
+	require.NoError(t, c.SetRetentionPolicy(repo, &pfs.RetentionPolicy{
+		ExpireIdleBranchSeconds: 1,
+		KeepDeletedHeadSeconds:  3600,
+		DryRun:                  true,
+	}))
+	time.Sleep(2 * time.Second)
+
+	// a branch with an open head isn't idle
+	plan, err := c.PlanRetention(repo)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(plan.Actions))
+	require.Equal(t, pfs.RetentionAction_EXPIRE_BRANCH, plan.Actions[0].Type)
+	require.Equal(t, "idle", plan.Actions[0].Branch)
+	require.Equal(t, commit.ID, plan.Actions[0].Head.ID)
+
+	// deleted heads are kept, and can be restored
+	require.NoError(t, c.DeleteBranch(repo, "idle"))
+	policyInfo, err := c.InspectRetentionPolicy(repo)
+	require.NoError(t, err)
+	require.Equal(t, "", policyInfo.Capability)
+	require.Equal(t, 1, len(policyInfo.DeletedHeads))
+	require.Equal(t, "idle", policyInfo.DeletedHeads[0].Branch)
+	require.Equal(t, commit.ID, policyInfo.DeletedHeads[0].Head.ID)
+	require.NoError(t, c.SetBranch(repo, policyInfo.DeletedHeads[0].Head.ID, "restored"))
+
+	// once they aren't kept, they're dropped
+	require.NoError(t, c.SetRetentionPolicy(repo, &pfs.RetentionPolicy{DryRun: true}))
+	plan, err = c.PlanRetention(repo)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(plan.Actions))
+	require.Equal(t, pfs.RetentionAction_DROP_DELETED_HEAD, plan.Actions[0].Type)
+
+	require.NoError(t, c.SetRetentionPolicy(repo, nil))
+	_, err = c.InspectRetentionPolicy(repo)
+	require.YesError(t, err)
+}
+
+func TestFsckProvenance(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	upstream := uniqueString("TestFsckProvenance")
+	downstream := uniqueString("TestFsckProvenance")
+	require.NoError(t, c.CreateRepo(upstream))
+	_, err := c.PfsAPIClient.CreateRepo(
+		context.Background(),
+		&pfs.CreateRepoRequest{
+			Repo:       pclient.NewRepo(downstream),
+			Provenance: []*pfs.Repo{pclient.NewRepo(upstream)},
+		},
+	)
+	require.NoError(t, err)
+	upstreamCommit, err := c.StartCommit(upstream, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(upstream, upstreamCommit.ID))
+	downstreamCommit, err := c.PfsAPIClient.StartCommit(
+		context.Background(),
+		&pfs.StartCommitRequest{
+			Parent:     pclient.NewCommit(downstream, "master"),
+			Provenance: []*pfs.Commit{upstreamCommit},
+		},
+	)
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(downstream, downstreamCommit.ID))
+
+	// other tests' repos may be inconsistent, so only these repos are checked
+	inconsistencies := func(fix bool) []*pfs.ProvenanceInconsistency {
+		all, err := c.FsckProvenance(fix)
+		require.NoError(t, err)
+		var result []*pfs.ProvenanceInconsistency
+		for _, inconsistency := range all {
+			if inconsistency.Repo.Name == upstream || inconsistency.Repo.Name == downstream {
+				result = append(result, inconsistency)
+			}
+		}
+		return result
+	}
+	require.Equal(t, 0, len(inconsistencies(false)))
+
+	// force deleting upstream leaves it in downstream's provenance
+	require.NoError(t, c.DeleteRepo(upstream, true))
+	found := inconsistencies(false)
+	types := make(map[pfs.ProvenanceInconsistency_Type]bool)
+	for _, inconsistency := range found {
+		require.False(t, inconsistency.Fixed)
+		types[inconsistency.Type] = true
+	}
+	require.True(t, types[pfs.ProvenanceInconsistency_DANGLING_REPO_PROVENANCE])
+	require.True(t, types[pfs.ProvenanceInconsistency_REPO_PROVENANCE])
+	require.True(t, types[pfs.ProvenanceInconsistency_DANGLING_COMMIT_PROVENANCE])
+	require.Equal(t, len(found), len(inconsistencies(false)))
+
+	fixed := inconsistencies(true)
+	require.Equal(t, len(found), len(fixed))
+	for _, inconsistency := range fixed {
+		require.True(t, inconsistency.Fixed)
+	}
+	require.Equal(t, 0, len(inconsistencies(false)))
+	repoInfo, err := c.InspectRepo(downstream)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(repoInfo.Provenance))
+	commitInfo, err := c.InspectCommit(downstream, downstreamCommit.ID)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(commitInfo.Provenance))
+}
+
+func TestInspectAudit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	report, err := c.InspectAudit()
+	require.NoError(t, err)
+	require.NotNil(t, report.Started)
+	require.Equal(t, "", report.Error)
+}
+
+func TestAuditFindingCounts(t *testing.T) {
+	var audit startupAudit
+	require.Equal(t, 0, len(audit.findingCounts()))
+	audit.set(&pfs.AuditReport{
+		Findings: []*pfs.AuditFinding{
+			{Type: pfs.AuditFinding_BAD_REF_COUNT},
+			{Type: pfs.AuditFinding_MISSING_BRANCH_HEAD},
+			{Type: pfs.AuditFinding_BAD_REF_COUNT},
+		},
+	})
+	counts := audit.findingCounts()
+	require.Equal(t, int64(2), counts["bad_ref_count"])
+	require.Equal(t, int64(1), counts["missing_branch_head"])
+}
+
+func TestExportMetadata(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getClient(t)
+	repo := uniqueString("TestExportMetadata")
+	exportRepo := uniqueString("TestExportMetadata_export")
+	require.NoError(t, c.CreateRepo(repo))
+	require.NoError(t, c.CreateRepo(exportRepo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "foo", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	_, err = c.ExportMetadata()
+	require.YesError(t, err)
+	require.NoError(t, c.SetMetadataExport(&pfs.MetadataExport{Repo: pclient.NewRepo(exportRepo)}))
+	defer func() {
+		require.NoError(t, c.SetMetadataExport(nil))
+	}()
+	exportCommit, err := c.ExportMetadata()
+	require.NoError(t, err)
+	exportInfo, err := c.InspectMetadataExport()
+	require.NoError(t, err)
+	require.Equal(t, exportCommit.ID, exportInfo.LastCommit.ID)
+	require.NotNil(t, exportInfo.LastExport)
+	require.Equal(t, "", exportInfo.LastError)
+
+	// each table is a Parquet file, other repos may be made while this test
+	// runs, so only this test's repo's rows are certain
+	for _, name := range []string{"repos.parquet", "commits.parquet", "branches.parquet", "provenance.parquet"} {
+		var buffer bytes.Buffer
+		require.NoError(t, c.GetFile(exportRepo, exportCommit.ID, name, 0, 0, &buffer))
+		file := buffer.Bytes()
+		footer, err := readParquetFooter(int64(len(file)), func(offset int64, n int64) ([]byte, error) {
+			return file[offset : offset+n], nil
+		})
+		require.NoError(t, err)
+		if name != "provenance.parquet" {
+			require.True(t, footer.fields.get(fileMetaDataNumRows).(int64) >= 1)
+		}
+	}
+}
+
+func TestParquetTable(t *testing.T) {
+	table := newParquetTable(parquetStringColumn("name"), parquetTimestampColumn("time"))
+	for i := 0; i < parquetRowGroupRows+1; i++ {
+		var when interface{}
+		if i%2 == 0 {
+			when = int64(i)
+		}
+		table.append(fmt.Sprintf("row %d", i), when)
+	}
+	file, err := table.encode()
+	require.NoError(t, err)
+	footer, err := readParquetFooter(int64(len(file)), func(offset int64, n int64) ([]byte, error) {
+		return file[offset : offset+n], nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(parquetRowGroupRows+1), footer.fields.get(fileMetaDataNumRows))
+	require.Equal(t, 2, len(footer.rowGroups))
+	schema, err := footer.fields.list(fileMetaDataSchema)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(schema))
+	for _, rowGroup := range footer.rowGroups {
+		require.Equal(t, 2, len(rowGroup.columns))
+	}
+}
+
+func TestTreeCacheWatchKeys(t *testing.T) {
+	commit := commitOfKey("repo/0123abc")
+	require.NotNil(t, commit)
+	require.Equal(t, "repo", commit.Repo.Name)
+	require.Equal(t, "0123abc", commit.ID)
+	require.Nil(t, commitOfKey("repo__index_Provenance/other/0123abc/0123abc"))
+	require.Nil(t, commitOfKey("repo"))
+
+	marshal := func(commitInfo *pfs.CommitInfo) []byte {
+		data, err := commitInfo.Marshal()
+		require.NoError(t, err)
+		return data
+	}
+	tree := marshal(&pfs.CommitInfo{Tree: &pfs.Object{Hash: "tree"}})
+	reviewed := marshal(&pfs.CommitInfo{Tree: &pfs.Object{Hash: "tree"}, Reviews: []*pfs.CommitReview{{}}})
+	compacted := marshal(&pfs.CommitInfo{Tree: &pfs.Object{Hash: "compacted"}})
+	require.True(t, sameTree(tree, reviewed))
+	require.False(t, sameTree(tree, compacted))
+	require.False(t, sameTree(tree, []byte("garbage")))
+}
+
+func TestTreeCacheStaleFill(t *testing.T) {
+	cache, err := lru.New(10)
+	require.NoError(t, err)
+	d := &driver{treeCache: cache}
+	commit := &pfs.Commit{Repo: &pfs.Repo{Name: "repo"}, ID: "0123abc"}
+	tree, err := hashtree.NewHashTree().Finish()
+	require.NoError(t, err)
+
+	// a tree read before its commit's tree was invalidated isn't cached
+	gen := d.treeCacheGeneration()
+	d.invalidateTree(commit)
+	d.addTree(commit, tree, gen)
+	_, ok := d.treeCache.Get(treeCacheKey(commit))
+	require.False(t, ok)
+
+	gen = d.treeCacheGeneration()
+	d.addTree(commit, tree, gen)
+	_, ok = d.treeCache.Get(treeCacheKey(commit))
+	require.True(t, ok)
+}
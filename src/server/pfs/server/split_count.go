@@ -0,0 +1,140 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+)
+
+// checkTargetFileCount returns an error if data split by delimiter can't be
+// split into targetFileCount files.
+func checkTargetFileCount(delimiter pfs.Delimiter, targetFileDatums int64, targetFileBytes int64, targetFileCount int64) error {
+	if targetFileCount < 0 {
+		return fmt.Errorf("the target file count can't be negative")
+	}
+	if targetFileCount == 0 {
+		return nil
+	}
+	if delimiter != pfs.Delimiter_JSON && delimiter != pfs.Delimiter_LINE {
+		return fmt.Errorf("only data split by JSON or LINE can be split into a target number of files, not %s", delimiter)
+	}
+	if targetFileDatums != 0 || targetFileBytes != 0 {
+		return fmt.Errorf("a target file count can't be used with a target number of datums or bytes per file")
+	}
+	return nil
+}
+
+// spoolRecords copies the data in reader to a local temporary file, so that
+// it can be split once its records have been counted, and returns a reader of
+// it along with the number of records, not counting the first headerLines
+// and last footerLines lines. If keep is set, records split by JSON are lines
+// as they are for LINE, and only the records that keep returns true for are
+// counted, so that records which are diverted aren't. The returned reader
+// must be closed, which removes the file.
+func spoolRecords(delimiter pfs.Delimiter, headerLines int64, footerLines int64, keep func(record []byte) bool, reader io.Reader) (io.ReadCloser, int64, error) {
+	f, err := ioutil.TempFile("", "pfs-split")
+	if err != nil {
+		return nil, 0, err
+	}
+	spooled := &spoolFile{f}
+	count, err := countRecords(delimiter, headerLines, footerLines, keep, io.TeeReader(reader, f))
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		spooled.Close()
+		return nil, 0, err
+	}
+	return spooled, count, nil
+}
+
+// spoolFile is a temporary file that's removed when it's closed.
+type spoolFile struct {
+	*os.File
+}
+
+func (f *spoolFile) Close() error {
+	err := f.File.Close()
+	if err := os.Remove(f.Name()); err != nil {
+		return err
+	}
+	return err
+}
+
+// countRecords returns the number of records in r, see spoolRecords.
+func countRecords(delimiter pfs.Delimiter, headerLines int64, footerLines int64, keep func(record []byte) bool, r io.Reader) (int64, error) {
+	var count int64
+	if delimiter == pfs.Delimiter_JSON && keep == nil {
+		decoder := json.NewDecoder(r)
+		for {
+			var value json.RawMessage
+			if err := decoder.Decode(&value); err != nil {
+				if err == io.EOF {
+					return count, nil
+				}
+				return 0, err
+			}
+			count++
+		}
+	}
+	// counted holds whether each of the last footerLines lines was counted,
+	// so that they can be uncounted if they turn out to be the footer
+	var counted []bool
+	var line int64
+	bufioR := bufio.NewReader(r)
+	for {
+		record, err := bufioR.ReadBytes('\n')
+		if len(record) > 0 {
+			line++
+			if line > headerLines {
+				if delimiter == pfs.Delimiter_JSON {
+					record = bytes.TrimSpace(record)
+				}
+				kept := keep == nil || keep(record)
+				if kept {
+					count++
+				}
+				if footerLines > 0 {
+					counted = append(counted, kept)
+					if int64(len(counted)) > footerLines {
+						counted = counted[1:]
+					}
+				}
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				for _, kept := range counted {
+					if kept {
+						count--
+					}
+				}
+				return count, nil
+			}
+			return 0, err
+		}
+	}
+}
+
+// fileDatums returns the number of datums in each of the files that datums
+// are split into to get count files, the first files take the remainder.
+func fileDatums(datums int64, count int64) []int64 {
+	if datums < count {
+		count = datums
+	}
+	var result []int64
+	for i := int64(0); i < count; i++ {
+		n := datums / count
+		if i < datums%count {
+			n++
+		}
+		result = append(result, n)
+	}
+	return result
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+)
+
+// splitErrorsDir is the directory that the records a split can't parse are
+// diverted to, see PutFileRequest.divert_errors.
+const splitErrorsDir = "/_errors"
+
+// splitErrors collects the records that a split couldn't parse, as NDJSON
+// that's written to the errors file of the split path.
+type splitErrors struct {
+	// line is the number of the line being split, counting from 1
+	line     int64
+	diverted int64
+	buffer   bytes.Buffer
+}
+
+// splitError is a line of an errors file.
+type splitError struct {
+	Line   int64  `json:"line"`
+	Error  string `json:"error"`
+	Record string `json:"record"`
+}
+
+func (e *splitErrors) divert(record []byte, reason error) error {
+	data, err := json.Marshal(&splitError{
+		Line:   e.line,
+		Error:  reason.Error(),
+		Record: strings.TrimSuffix(string(record), "\n"),
+	})
+	if err != nil {
+		return err
+	}
+	e.diverted++
+	e.buffer.Write(data)
+	e.buffer.WriteByte('\n')
+	return nil
+}
+
+// check returns an error if record can't be split by delimiter, or doesn't
+// match schema, if it's set. Records that are parsed to compute stats are
+// added to stats.
+func (e *splitErrors) check(delimiter pfs.Delimiter, record []byte, schema *jsonSchema, stats *tableStats) error {
+	if schema != nil && len(record) > 0 {
+		if err := schema.validate(record); err != nil {
+			return err
+		}
+	} else if delimiter == pfs.Delimiter_JSON && len(record) > 0 {
+		var value json.RawMessage
+		if err := json.Unmarshal(record, &value); err != nil {
+			return fmt.Errorf("malformed JSON: %v", err)
+		}
+	}
+	if stats != nil {
+		return stats.add(record)
+	}
+	return nil
+}
+
+// checkDivertErrors returns an error if records split by delimiter can't
+// have their errors diverted.
+func checkDivertErrors(delimiter pfs.Delimiter, divertErrors bool) error {
+	if divertErrors && delimiter != pfs.Delimiter_JSON && delimiter != pfs.Delimiter_LINE {
+		return fmt.Errorf("errors can only be diverted for the JSON and LINE delimiters, not %s", delimiter)
+	}
+	return nil
+}
+
+// splitErrorsPath returns the path of the errors file for a split to p.
+func splitErrorsPath(p string) string {
+	return path.Join(splitErrorsDir, p)
+}
+
+// recordsWritten returns the number of records in split records.
+func recordsWritten(records *pfs.PutFileRecords) int64 {
+	var n int64
+	for _, record := range records.Records {
+		n += record.RecordCount
+	}
+	return n
+}
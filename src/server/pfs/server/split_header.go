@@ -0,0 +1,122 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+)
+
+// lineReader reads the lines of data split by LINE.
+type lineReader interface {
+	ReadBytes(delim byte) ([]byte, error)
+}
+
+// framedLineReader reads lines while holding back the last footerLines of
+// them, which are the footer once the data runs out, see
+// PutFileRequest.footer_lines.
+type framedLineReader struct {
+	r           *bufio.Reader
+	footerLines int64
+	pending     [][]byte
+	eof         bool
+}
+
+func newFramedLineReader(r *bufio.Reader, footerLines int64) *framedLineReader {
+	return &framedLineReader{
+		r:           r,
+		footerLines: footerLines,
+	}
+}
+
+// readHeader reads the first n lines, and returns them along with the
+// number that were read, which is less than n if the data runs out first.
+func (r *framedLineReader) readHeader(n int64) ([]byte, int64, error) {
+	var header bytes.Buffer
+	var lines int64
+	for lines < n {
+		line, err := r.r.ReadBytes('\n')
+		if len(line) > 0 {
+			header.Write(line)
+			lines++
+		}
+		if err == io.EOF {
+			r.eof = true
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return header.Bytes(), lines, nil
+}
+
+// ReadBytes returns the next line that isn't part of the footer, or io.EOF
+// once only the footer is left.
+func (r *framedLineReader) ReadBytes(delim byte) ([]byte, error) {
+	for int64(len(r.pending)) <= r.footerLines && !r.eof {
+		line, err := r.r.ReadBytes(delim)
+		if len(line) > 0 {
+			r.pending = append(r.pending, line)
+		}
+		if err == io.EOF {
+			r.eof = true
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if int64(len(r.pending)) > r.footerLines {
+		line := r.pending[0]
+		r.pending = r.pending[1:]
+		return line, nil
+	}
+	return nil, io.EOF
+}
+
+// footer returns the lines held back once ReadBytes has returned io.EOF,
+// and the number of them.
+func (r *framedLineReader) footer() ([]byte, int64) {
+	return bytes.Join(r.pending, nil), int64(len(r.pending))
+}
+
+// checkSplitFrame returns an error if data split by delimiter can't have
+// headerLines and footerLines added to its files.
+func checkSplitFrame(delimiter pfs.Delimiter, headerLines int64, footerLines int64) error {
+	if headerLines < 0 || footerLines < 0 {
+		return fmt.Errorf("the number of header and footer lines can't be negative")
+	}
+	if (headerLines > 0 || footerLines > 0) && delimiter != pfs.Delimiter_LINE {
+		return fmt.Errorf("header and footer lines can only be added to data split by LINE, not %s", delimiter)
+	}
+	return nil
+}
+
+// setSplitFrame records the number of header and footer lines that the
+// records of a split added to fileNode.
+func setSplitFrame(fileNode *hashtree.FileNodeProto, records *pfs.PutFileRecords) {
+	if records.Header != nil {
+		fileNode.HeaderLines = records.Header.RecordCount
+	}
+	if records.Footer != nil {
+		fileNode.FooterLines = records.Footer.RecordCount
+	}
+}
+
+// splitFrameObjects returns the objects of a file written by a split, with
+// the header and footer of records around the object of record, and the
+// file's size.
+func splitFrameObjects(records *pfs.PutFileRecords, record *pfs.PutFileRecord) ([]*pfs.Object, int64) {
+	var objects []*pfs.Object
+	var size int64
+	for _, r := range []*pfs.PutFileRecord{records.Header, record, records.Footer} {
+		if r == nil {
+			continue
+		}
+		objects = append(objects, &pfs.Object{Hash: r.ObjectHash, Compression: r.Compression})
+		size += r.SizeBytes
+	}
+	return objects, size
+}
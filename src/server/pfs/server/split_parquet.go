@@ -0,0 +1,585 @@
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+)
+
+// parquetMagic starts and ends every Parquet file.
+var parquetMagic = []byte("PAR1")
+
+// The ids of the fields of Parquet's footer that splitting reads or rewrites,
+// see parquet.thrift.
+const (
+	fileMetaDataNumRows             = 3
+	fileMetaDataRowGroups           = 4
+	fileMetaDataEncryptionAlgorithm = 8
+
+	rowGroupColumns    = 1
+	rowGroupNumRows    = 3
+	rowGroupFileOffset = 5
+	rowGroupOrdinal    = 7
+
+	columnChunkFilePath          = 1
+	columnChunkFileOffset        = 2
+	columnChunkMetaData          = 3
+	columnChunkOffsetIndexOffset = 4
+	columnChunkOffsetIndexLength = 5
+	columnChunkColumnIndexOffset = 6
+	columnChunkColumnIndexLength = 7
+
+	columnMetaDataTotalCompressedSize  = 7
+	columnMetaDataDataPageOffset       = 9
+	columnMetaDataIndexPageOffset      = 10
+	columnMetaDataDictionaryPageOffset = 11
+	columnMetaDataBloomFilterOffset    = 14
+	columnMetaDataBloomFilterLength    = 15
+)
+
+// putParquetRecords splits the Parquet file in reader on its row groups, which
+// are its datums, and puts each split file, which is a standalone Parquet file
+// with a footer of its own, in the blob store. The file is spooled to the blob
+// store first, as its footer, which says where its row groups are, is at its
+// end. The page indexes and bloom filters of the row groups aren't copied.
+func (d *driver) putParquetRecords(compression pfs.Compression, storageClass string, targetFileDatums int64, targetFileBytes int64, reader io.Reader) ([]*pfs.PutFileRecord, error) {
+	spooled, size, err := d.pachClient.PutObject(reader)
+	if err != nil {
+		return nil, err
+	}
+	readAt := func(offset int64, n int64) ([]byte, error) {
+		if offset < 0 || n < 0 || offset+n > size {
+			return nil, fmt.Errorf("parquet file is corrupt, %d bytes at offset %d are out of range", n, offset)
+		}
+		if n == 0 {
+			return nil, nil
+		}
+		buf := &bytes.Buffer{}
+		if err := d.pachClient.GetObjects([]string{spooled.Hash}, uint64(offset), uint64(n), buf); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	footer, err := readParquetFooter(size, readAt)
+	if err != nil {
+		return nil, err
+	}
+	var files [][]*parquetRowGroup
+	var datums, bytesWritten int64
+	for i, rowGroup := range footer.rowGroups {
+		if datums == 0 {
+			files = append(files, nil)
+		}
+		files[len(files)-1] = append(files[len(files)-1], rowGroup)
+		datums++
+		bytesWritten += rowGroup.size()
+		if (targetFileBytes != 0 && bytesWritten >= targetFileBytes) ||
+			(targetFileDatums != 0 && datums >= targetFileDatums) ||
+			(targetFileBytes == 0 && targetFileDatums == 0) ||
+			i == len(footer.rowGroups)-1 {
+			datums, bytesWritten = 0, 0
+		}
+	}
+	var records []*pfs.PutFileRecord
+	for _, rowGroups := range files {
+		file, numRows, err := footer.file(rowGroups, readAt)
+		if err != nil {
+			return nil, err
+		}
+		object, size, err := d.putObject(compression, storageClass, bytes.NewReader(file))
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, &pfs.PutFileRecord{
+			SizeBytes:   size,
+			ObjectHash:  object.Hash,
+			RecordCount: numRows,
+			Compression: compression,
+		})
+	}
+	return records, nil
+}
+
+// parquetFooter is the decoded footer (FileMetaData) of a Parquet file.
+type parquetFooter struct {
+	fields    thriftStruct
+	rowGroups []*parquetRowGroup
+}
+
+// parquetRowGroup is a row group of a Parquet file, and the byte ranges of
+// its column chunks in the file.
+type parquetRowGroup struct {
+	fields  thriftStruct
+	columns []*parquetColumnChunk
+}
+
+type parquetColumnChunk struct {
+	fields   thriftStruct
+	metaData thriftStruct
+	offset   int64
+	length   int64
+}
+
+func (r *parquetRowGroup) size() int64 {
+	var size int64
+	for _, column := range r.columns {
+		size += column.length
+	}
+	return size
+}
+
+// readParquetFooter reads the footer of the Parquet file of the given size
+// with readAt, which reads n bytes at offset.
+func readParquetFooter(size int64, readAt func(offset int64, n int64) ([]byte, error)) (*parquetFooter, error) {
+	if size < int64(2*len(parquetMagic)+4) {
+		return nil, fmt.Errorf("data isn't a parquet file, it's too short")
+	}
+	head, err := readAt(0, int64(len(parquetMagic)))
+	if err != nil {
+		return nil, err
+	}
+	tail, err := readAt(size-int64(len(parquetMagic))-4, int64(len(parquetMagic))+4)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(tail[4:], []byte("PARE")) {
+		return nil, fmt.Errorf("parquet files with encrypted footers can't be split")
+	}
+	if !bytes.Equal(head, parquetMagic) || !bytes.Equal(tail[4:], parquetMagic) {
+		return nil, fmt.Errorf("data isn't a parquet file, it doesn't start and end with %q", parquetMagic)
+	}
+	footerLength := int64(binary.LittleEndian.Uint32(tail[:4]))
+	dataEnd := size - int64(len(parquetMagic)) - 4 - footerLength
+	if dataEnd < int64(len(parquetMagic)) {
+		return nil, fmt.Errorf("parquet file is corrupt, its footer is longer than the file")
+	}
+	encoded, err := readAt(dataEnd, footerLength)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := newThriftReader(encoded).readStruct()
+	if err != nil {
+		return nil, fmt.Errorf("error decoding parquet footer: %v", err)
+	}
+	if fields.get(fileMetaDataEncryptionAlgorithm) != nil {
+		return nil, fmt.Errorf("encrypted parquet files can't be split")
+	}
+	footer := &parquetFooter{fields: fields}
+	rowGroups, err := fields.list(fileMetaDataRowGroups)
+	if err != nil {
+		return nil, err
+	}
+	for _, value := range rowGroups {
+		rowGroupFields, ok := value.(thriftStruct)
+		if !ok {
+			return nil, fmt.Errorf("parquet file is corrupt, a row group isn't a struct")
+		}
+		rowGroup := &parquetRowGroup{fields: rowGroupFields}
+		columns, err := rowGroupFields.list(rowGroupColumns)
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range columns {
+			columnFields, ok := value.(thriftStruct)
+			if !ok {
+				return nil, fmt.Errorf("parquet file is corrupt, a column chunk isn't a struct")
+			}
+			if columnFields.get(columnChunkFilePath) != nil {
+				return nil, fmt.Errorf("parquet files with column chunks in other files can't be split")
+			}
+			metaData, ok := columnFields.get(columnChunkMetaData).(thriftStruct)
+			if !ok {
+				return nil, fmt.Errorf("parquet file is corrupt, a column chunk has no metadata")
+			}
+			column := &parquetColumnChunk{
+				fields:   columnFields,
+				metaData: metaData,
+			}
+			column.offset, _ = metaData.get(columnMetaDataDataPageOffset).(int64)
+			// the dictionary page, if there is one, comes before the data
+			// pages, some writers set its offset to 0 when there isn't
+			if dictionaryOffset, ok := metaData.get(columnMetaDataDictionaryPageOffset).(int64); ok && dictionaryOffset > 0 && dictionaryOffset < column.offset {
+				column.offset = dictionaryOffset
+			}
+			column.length, _ = metaData.get(columnMetaDataTotalCompressedSize).(int64)
+			if column.offset < int64(len(parquetMagic)) || column.length < 0 || column.offset+column.length > dataEnd {
+				return nil, fmt.Errorf("parquet file is corrupt, a column chunk is outside of its data")
+			}
+			rowGroup.columns = append(rowGroup.columns, column)
+		}
+		footer.rowGroups = append(footer.rowGroups, rowGroup)
+	}
+	return footer, nil
+}
+
+// file returns a Parquet file of rowGroups, whose column chunks are read with
+// readAt, and its number of rows. The offsets in the file's footer are moved
+// to where the column chunks are in it.
+func (f *parquetFooter) file(rowGroups []*parquetRowGroup, readAt func(offset int64, n int64) ([]byte, error)) ([]byte, int64, error) {
+	buf := &bytes.Buffer{}
+	buf.Write(parquetMagic)
+	var numRows int64
+	var encodedRowGroups []interface{}
+	for i, rowGroup := range rowGroups {
+		// a row group's column chunks are usually contiguous, so they're
+		// read at once
+		start, end := int64(-1), int64(-1)
+		for _, column := range rowGroup.columns {
+			if start == -1 || column.offset < start {
+				start = column.offset
+			}
+			if column.offset+column.length > end {
+				end = column.offset + column.length
+			}
+		}
+		data, err := readAt(start, end-start)
+		if err != nil {
+			return nil, 0, err
+		}
+		rowGroupStart := int64(buf.Len())
+		var columns []interface{}
+		for _, column := range rowGroup.columns {
+			delta := int64(buf.Len()) - column.offset
+			buf.Write(data[column.offset-start : column.offset-start+column.length])
+			metaData := column.metaData.without(columnMetaDataBloomFilterOffset, columnMetaDataBloomFilterLength)
+			for _, id := range []int16{columnMetaDataDataPageOffset, columnMetaDataIndexPageOffset, columnMetaDataDictionaryPageOffset} {
+				if offset, ok := metaData.get(id).(int64); ok && offset > 0 {
+					metaData = metaData.with(id, thriftI64, offset+delta)
+				}
+			}
+			fields := column.fields.without(columnChunkOffsetIndexOffset, columnChunkOffsetIndexLength, columnChunkColumnIndexOffset, columnChunkColumnIndexLength).
+				with(columnChunkMetaData, thriftStructType, metaData)
+			if offset, ok := fields.get(columnChunkFileOffset).(int64); ok && offset > 0 {
+				fields = fields.with(columnChunkFileOffset, thriftI64, offset+delta)
+			}
+			columns = append(columns, fields)
+		}
+		fields := rowGroup.fields.with(rowGroupColumns, thriftList, &thriftListValue{elemType: thriftStructType, elems: columns})
+		if fields.get(rowGroupFileOffset) != nil {
+			fields = fields.with(rowGroupFileOffset, thriftI64, rowGroupStart)
+		}
+		if fields.get(rowGroupOrdinal) != nil {
+			fields = fields.with(rowGroupOrdinal, thriftI16, int64(i))
+		}
+		rows, _ := fields.get(rowGroupNumRows).(int64)
+		numRows += rows
+		encodedRowGroups = append(encodedRowGroups, fields)
+	}
+	fields := f.fields.
+		with(fileMetaDataNumRows, thriftI64, numRows).
+		with(fileMetaDataRowGroups, thriftList, &thriftListValue{elemType: thriftStructType, elems: encodedRowGroups})
+	w := &thriftWriter{}
+	w.writeStruct(fields)
+	buf.Write(w.Bytes())
+	if err := binary.Write(buf, binary.LittleEndian, uint32(w.Len())); err != nil {
+		return nil, 0, err
+	}
+	buf.Write(parquetMagic)
+	return buf.Bytes(), numRows, nil
+}
+
+// The types of values in Thrift's compact protocol, which Parquet's footer is
+// encoded in.
+const (
+	thriftBoolTrue   byte = 1
+	thriftBoolFalse  byte = 2
+	thriftByte       byte = 3
+	thriftI16        byte = 4
+	thriftI32        byte = 5
+	thriftI64        byte = 6
+	thriftDouble     byte = 7
+	thriftBinary     byte = 8
+	thriftList       byte = 9
+	thriftSet        byte = 10
+	thriftMap        byte = 11
+	thriftStructType byte = 12
+)
+
+// thriftField is a field of a Thrift struct. Integers are decoded to int64,
+// bools to bool, binaries and doubles are kept as they're encoded, and lists,
+// sets, maps and structs are decoded recursively, so that a struct can be
+// changed and encoded again without knowing the whole of its schema.
+type thriftField struct {
+	id    int16
+	typ   byte
+	value interface{}
+}
+
+type thriftStruct []*thriftField
+
+type thriftListValue struct {
+	elemType byte
+	elems    []interface{}
+}
+
+type thriftMapValue struct {
+	keyType   byte
+	valueType byte
+	// elems alternate between keys and values
+	elems []interface{}
+}
+
+func (s thriftStruct) get(id int16) interface{} {
+	for _, field := range s {
+		if field.id == id {
+			return field.value
+		}
+	}
+	return nil
+}
+
+func (s thriftStruct) list(id int16) ([]interface{}, error) {
+	value := s.get(id)
+	if value == nil {
+		return nil, nil
+	}
+	list, ok := value.(*thriftListValue)
+	if !ok {
+		return nil, fmt.Errorf("parquet file is corrupt, field %d isn't a list", id)
+	}
+	return list.elems, nil
+}
+
+// with returns a copy of s with the field id set to value, fields are kept in
+// order of their ids.
+func (s thriftStruct) with(id int16, typ byte, value interface{}) thriftStruct {
+	var result thriftStruct
+	set := false
+	for _, field := range s {
+		if field.id == id {
+			result = append(result, &thriftField{id: id, typ: typ, value: value})
+			set = true
+			continue
+		}
+		if !set && field.id > id {
+			result = append(result, &thriftField{id: id, typ: typ, value: value})
+			set = true
+		}
+		result = append(result, field)
+	}
+	if !set {
+		result = append(result, &thriftField{id: id, typ: typ, value: value})
+	}
+	return result
+}
+
+// without returns a copy of s without the fields ids.
+func (s thriftStruct) without(ids ...int16) thriftStruct {
+	var result thriftStruct
+	for _, field := range s {
+		keep := true
+		for _, id := range ids {
+			if field.id == id {
+				keep = false
+			}
+		}
+		if keep {
+			result = append(result, field)
+		}
+	}
+	return result
+}
+
+type thriftReader struct {
+	r *bytes.Reader
+}
+
+func newThriftReader(data []byte) *thriftReader {
+	return &thriftReader{r: bytes.NewReader(data)}
+}
+
+func (r *thriftReader) readStruct() (thriftStruct, error) {
+	var fields thriftStruct
+	var lastID int16
+	for {
+		header, err := r.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if header == 0 {
+			return fields, nil
+		}
+		typ := header & 0x0f
+		id := lastID + int16(header>>4)
+		if header>>4 == 0 {
+			v, err := binary.ReadVarint(r.r)
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v)
+		}
+		lastID = id
+		var value interface{}
+		if typ == thriftBoolTrue || typ == thriftBoolFalse {
+			value = typ == thriftBoolTrue
+		} else if value, err = r.readValue(typ); err != nil {
+			return nil, err
+		}
+		fields = append(fields, &thriftField{id: id, typ: typ, value: value})
+	}
+}
+
+func (r *thriftReader) readValue(typ byte) (interface{}, error) {
+	switch typ {
+	case thriftBoolTrue, thriftBoolFalse:
+		b, err := r.r.ReadByte()
+		return b == thriftBoolTrue, err
+	case thriftByte:
+		b, err := r.r.ReadByte()
+		return int64(int8(b)), err
+	case thriftI16, thriftI32, thriftI64:
+		return binary.ReadVarint(r.r)
+	case thriftDouble:
+		return r.readBytes(8)
+	case thriftBinary:
+		n, err := binary.ReadUvarint(r.r)
+		if err != nil {
+			return nil, err
+		}
+		return r.readBytes(n)
+	case thriftList, thriftSet:
+		header, err := r.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		n := uint64(header >> 4)
+		if n == 15 {
+			if n, err = binary.ReadUvarint(r.r); err != nil {
+				return nil, err
+			}
+		}
+		list := &thriftListValue{elemType: header & 0x0f}
+		for i := uint64(0); i < n; i++ {
+			elem, err := r.readValue(list.elemType)
+			if err != nil {
+				return nil, err
+			}
+			list.elems = append(list.elems, elem)
+		}
+		return list, nil
+	case thriftMap:
+		n, err := binary.ReadUvarint(r.r)
+		if err != nil {
+			return nil, err
+		}
+		m := &thriftMapValue{}
+		if n == 0 {
+			return m, nil
+		}
+		types, err := r.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		m.keyType, m.valueType = types>>4, types&0x0f
+		for i := uint64(0); i < n; i++ {
+			key, err := r.readValue(m.keyType)
+			if err != nil {
+				return nil, err
+			}
+			value, err := r.readValue(m.valueType)
+			if err != nil {
+				return nil, err
+			}
+			m.elems = append(m.elems, key, value)
+		}
+		return m, nil
+	case thriftStructType:
+		return r.readStruct()
+	default:
+		return nil, fmt.Errorf("unknown thrift type %d", typ)
+	}
+}
+
+func (r *thriftReader) readBytes(n uint64) ([]byte, error) {
+	if n > uint64(r.r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := make([]byte, n)
+	_, err := io.ReadFull(r.r, b)
+	return b, err
+}
+
+type thriftWriter struct {
+	bytes.Buffer
+}
+
+func (w *thriftWriter) writeStruct(fields thriftStruct) {
+	var lastID int16
+	for _, field := range fields {
+		typ := field.typ
+		if typ == thriftBoolTrue || typ == thriftBoolFalse {
+			typ = thriftBoolFalse
+			if field.value.(bool) {
+				typ = thriftBoolTrue
+			}
+		}
+		if delta := field.id - lastID; delta > 0 && delta <= 15 {
+			w.WriteByte(byte(delta)<<4 | typ)
+		} else {
+			w.WriteByte(typ)
+			w.writeVarint(int64(field.id))
+		}
+		lastID = field.id
+		if typ != thriftBoolTrue && typ != thriftBoolFalse {
+			w.writeValue(typ, field.value)
+		}
+	}
+	w.WriteByte(0)
+}
+
+func (w *thriftWriter) writeValue(typ byte, value interface{}) {
+	switch typ {
+	case thriftBoolTrue, thriftBoolFalse:
+		if value.(bool) {
+			w.WriteByte(thriftBoolTrue)
+		} else {
+			w.WriteByte(thriftBoolFalse)
+		}
+	case thriftByte:
+		w.WriteByte(byte(value.(int64)))
+	case thriftI16, thriftI32, thriftI64:
+		w.writeVarint(value.(int64))
+	case thriftDouble:
+		w.Write(value.([]byte))
+	case thriftBinary:
+		b := value.([]byte)
+		w.writeUvarint(uint64(len(b)))
+		w.Write(b)
+	case thriftList, thriftSet:
+		list := value.(*thriftListValue)
+		if len(list.elems) < 15 {
+			w.WriteByte(byte(len(list.elems))<<4 | list.elemType)
+		} else {
+			w.WriteByte(0xf0 | list.elemType)
+			w.writeUvarint(uint64(len(list.elems)))
+		}
+		for _, elem := range list.elems {
+			w.writeValue(list.elemType, elem)
+		}
+	case thriftMap:
+		m := value.(*thriftMapValue)
+		w.writeUvarint(uint64(len(m.elems) / 2))
+		if len(m.elems) > 0 {
+			w.WriteByte(m.keyType<<4 | m.valueType)
+		}
+		for i := 0; i < len(m.elems); i += 2 {
+			w.writeValue(m.keyType, m.elems[i])
+			w.writeValue(m.valueType, m.elems[i+1])
+		}
+	case thriftStructType:
+		w.writeStruct(value.(thriftStruct))
+	}
+}
+
+func (w *thriftWriter) writeVarint(v int64) {
+	var buf [binary.MaxVarintLen64]byte
+	w.Write(buf[:binary.PutVarint(buf[:], v)])
+}
+
+func (w *thriftWriter) writeUvarint(v uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	w.Write(buf[:binary.PutUvarint(buf[:], v)])
+}
@@ -0,0 +1,381 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+)
+
+// jsonSchema is a compiled JSON Schema that the records of a JSON split are
+// validated against, see PutFileRequest.json_schema. Only the keywords in
+// jsonSchemaKeywords are supported, a schema with others is refused rather
+// than have them silently ignored.
+type jsonSchema struct {
+	types      []string
+	properties map[string]*jsonSchema
+	required   []string
+	// additional is the schema of the properties that aren't in properties,
+	// nil allows any, and noAdditional allows none.
+	additional   *jsonSchema
+	noAdditional bool
+	items        *jsonSchema
+	enum         []interface{}
+	minimum      *float64
+	maximum      *float64
+	exclusiveMin *float64
+	exclusiveMax *float64
+	minLength    *int
+	maxLength    *int
+	minItems     *int
+	maxItems     *int
+	pattern      *regexp.Regexp
+	allOf        []*jsonSchema
+	anyOf        []*jsonSchema
+	oneOf        []*jsonSchema
+	not          *jsonSchema
+}
+
+// jsonSchemaKeywords are the keywords that jsonSchema supports, the ones that
+// are only annotations are accepted and ignored.
+var jsonSchemaKeywords = map[string]bool{
+	"$schema": true, "$id": true, "id": true, "title": true, "description": true, "default": true, "examples": true,
+	"type": true, "properties": true, "required": true, "additionalProperties": true, "items": true,
+	"enum": true, "const": true, "minimum": true, "maximum": true, "exclusiveMinimum": true, "exclusiveMaximum": true,
+	"minLength": true, "maxLength": true, "minItems": true, "maxItems": true, "pattern": true,
+	"allOf": true, "anyOf": true, "oneOf": true, "not": true,
+}
+
+// parseJSONSchema compiles schema, the JSON Schema for the records of a
+// delimiter split. It returns nil if schema is empty.
+func parseJSONSchema(delimiter pfs.Delimiter, schema []byte) (*jsonSchema, error) {
+	if len(schema) == 0 {
+		return nil, nil
+	}
+	if delimiter != pfs.Delimiter_JSON {
+		return nil, fmt.Errorf("a JSON schema can only be used with the JSON delimiter, not %s", delimiter)
+	}
+	var value interface{}
+	if err := json.Unmarshal(schema, &value); err != nil {
+		return nil, fmt.Errorf("malformed JSON schema: %v", err)
+	}
+	s, err := compileJSONSchema(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %v", err)
+	}
+	return s, nil
+}
+
+func compileJSONSchema(value interface{}) (*jsonSchema, error) {
+	if b, ok := value.(bool); ok {
+		// true allows anything and false nothing
+		if b {
+			return &jsonSchema{}, nil
+		}
+		return &jsonSchema{not: &jsonSchema{}}, nil
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("a schema must be an object or a boolean")
+	}
+	s := &jsonSchema{}
+	for keyword, v := range m {
+		if !jsonSchemaKeywords[keyword] {
+			return nil, fmt.Errorf("unsupported keyword %q", keyword)
+		}
+		var err error
+		switch keyword {
+		case "type":
+			switch t := v.(type) {
+			case string:
+				s.types = []string{t}
+			case []interface{}:
+				for _, e := range t {
+					name, ok := e.(string)
+					if !ok {
+						return nil, fmt.Errorf("type must be a string or an array of strings")
+					}
+					s.types = append(s.types, name)
+				}
+			default:
+				return nil, fmt.Errorf("type must be a string or an array of strings")
+			}
+		case "properties":
+			properties, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("properties must be an object")
+			}
+			s.properties = make(map[string]*jsonSchema)
+			for name, property := range properties {
+				if s.properties[name], err = compileJSONSchema(property); err != nil {
+					return nil, fmt.Errorf("property %q: %v", name, err)
+				}
+			}
+		case "required":
+			required, ok := v.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("required must be an array of strings")
+			}
+			for _, e := range required {
+				name, ok := e.(string)
+				if !ok {
+					return nil, fmt.Errorf("required must be an array of strings")
+				}
+				s.required = append(s.required, name)
+			}
+		case "additionalProperties":
+			if b, ok := v.(bool); ok {
+				s.noAdditional = !b
+			} else if s.additional, err = compileJSONSchema(v); err != nil {
+				return nil, err
+			}
+		case "items":
+			if s.items, err = compileJSONSchema(v); err != nil {
+				return nil, err
+			}
+		case "enum":
+			enum, ok := v.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("enum must be an array")
+			}
+			s.enum = enum
+		case "const":
+			s.enum = []interface{}{v}
+		case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum":
+			n, ok := v.(float64)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a number", keyword)
+			}
+			switch keyword {
+			case "minimum":
+				s.minimum = &n
+			case "maximum":
+				s.maximum = &n
+			case "exclusiveMinimum":
+				s.exclusiveMin = &n
+			default:
+				s.exclusiveMax = &n
+			}
+		case "minLength", "maxLength", "minItems", "maxItems":
+			n, ok := v.(float64)
+			if !ok || n < 0 || n != float64(int(n)) {
+				return nil, fmt.Errorf("%s must be a non-negative integer", keyword)
+			}
+			i := int(n)
+			switch keyword {
+			case "minLength":
+				s.minLength = &i
+			case "maxLength":
+				s.maxLength = &i
+			case "minItems":
+				s.minItems = &i
+			default:
+				s.maxItems = &i
+			}
+		case "pattern":
+			pattern, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("pattern must be a string")
+			}
+			if s.pattern, err = regexp.Compile(pattern); err != nil {
+				return nil, err
+			}
+		case "allOf", "anyOf", "oneOf":
+			schemas, ok := v.([]interface{})
+			if !ok || len(schemas) == 0 {
+				return nil, fmt.Errorf("%s must be a non-empty array of schemas", keyword)
+			}
+			var compiled []*jsonSchema
+			for _, e := range schemas {
+				c, err := compileJSONSchema(e)
+				if err != nil {
+					return nil, err
+				}
+				compiled = append(compiled, c)
+			}
+			switch keyword {
+			case "allOf":
+				s.allOf = compiled
+			case "anyOf":
+				s.anyOf = compiled
+			default:
+				s.oneOf = compiled
+			}
+		case "not":
+			if s.not, err = compileJSONSchema(v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return s, nil
+}
+
+// validate returns an error if record, a JSON value, doesn't match s.
+func (s *jsonSchema) validate(record []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(record, &value); err != nil {
+		return fmt.Errorf("malformed JSON: %v", err)
+	}
+	if err := s.check(value, ""); err != nil {
+		return fmt.Errorf("doesn't match the JSON schema: %v", err)
+	}
+	return nil
+}
+
+// check returns an error if value, at path in the record, doesn't match s.
+func (s *jsonSchema) check(value interface{}, path string) error {
+	at := path
+	if at == "" {
+		at = "the record"
+	}
+	if len(s.types) > 0 {
+		matched := false
+		for _, t := range s.types {
+			if jsonType(value, t) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s must be of type %s", at, strings.Join(s.types, " or "))
+		}
+	}
+	if s.enum != nil {
+		matched := false
+		for _, e := range s.enum {
+			if reflect.DeepEqual(e, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s isn't one of the allowed values", at)
+		}
+	}
+	switch v := value.(type) {
+	case float64:
+		if s.minimum != nil && v < *s.minimum {
+			return fmt.Errorf("%s must be at least %v", at, *s.minimum)
+		}
+		if s.maximum != nil && v > *s.maximum {
+			return fmt.Errorf("%s must be at most %v", at, *s.maximum)
+		}
+		if s.exclusiveMin != nil && v <= *s.exclusiveMin {
+			return fmt.Errorf("%s must be greater than %v", at, *s.exclusiveMin)
+		}
+		if s.exclusiveMax != nil && v >= *s.exclusiveMax {
+			return fmt.Errorf("%s must be less than %v", at, *s.exclusiveMax)
+		}
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.minLength != nil && n < *s.minLength {
+			return fmt.Errorf("%s must be at least %d characters long", at, *s.minLength)
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			return fmt.Errorf("%s must be at most %d characters long", at, *s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			return fmt.Errorf("%s must match %q", at, s.pattern.String())
+		}
+	case []interface{}:
+		if s.minItems != nil && len(v) < *s.minItems {
+			return fmt.Errorf("%s must have at least %d items", at, *s.minItems)
+		}
+		if s.maxItems != nil && len(v) > *s.maxItems {
+			return fmt.Errorf("%s must have at most %d items", at, *s.maxItems)
+		}
+		if s.items != nil {
+			for i, item := range v {
+				if err := s.items.check(item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s is missing the required property %q", at, name)
+			}
+		}
+		// the properties are checked in order, so that errors are stable
+		var names []string
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			propertyPath := name
+			if path != "" {
+				propertyPath = path + "." + name
+			}
+			if property, ok := s.properties[name]; ok {
+				if err := property.check(v[name], propertyPath); err != nil {
+					return err
+				}
+			} else if s.noAdditional {
+				return fmt.Errorf("%s has the property %q, which isn't allowed", at, name)
+			} else if s.additional != nil {
+				if err := s.additional.check(v[name], propertyPath); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, sub := range s.allOf {
+		if err := sub.check(value, path); err != nil {
+			return err
+		}
+	}
+	if s.anyOf != nil {
+		matched := false
+		for _, sub := range s.anyOf {
+			if sub.check(value, path) == nil {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s doesn't match any of the schemas in anyOf", at)
+		}
+	}
+	if s.oneOf != nil {
+		matches := 0
+		for _, sub := range s.oneOf {
+			if sub.check(value, path) == nil {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return fmt.Errorf("%s matches %d of the schemas in oneOf, rather than exactly one", at, matches)
+		}
+	}
+	if s.not != nil && s.not.check(value, path) == nil {
+		return fmt.Errorf("%s matches a schema that it mustn't", at)
+	}
+	return nil
+}
+
+// jsonType returns true if value, as decoded by encoding/json, is of the JSON
+// Schema type t.
+func jsonType(value interface{}, t string) bool {
+	switch v := value.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case float64:
+		return t == "number" || (t == "integer" && v == float64(int64(v)))
+	case string:
+		return t == "string"
+	case []interface{}:
+		return t == "array"
+	case map[string]interface{}:
+		return t == "object"
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+)
+
+// newSeparatorSplit returns the function that splits the records of data
+// split by delimiter on separator or separatorRegex, see
+// PutFileRequest.separator. It returns nil if delimiter isn't SEPARATOR.
+func newSeparatorSplit(delimiter pfs.Delimiter, separator []byte, separatorRegex string) (bufio.SplitFunc, error) {
+	if delimiter != pfs.Delimiter_SEPARATOR {
+		if len(separator) > 0 || separatorRegex != "" {
+			return nil, fmt.Errorf("a separator can only be used to split data with the SEPARATOR delimiter, not %s", delimiter)
+		}
+		return nil, nil
+	}
+	switch {
+	case len(separator) > 0 && separatorRegex != "":
+		return nil, fmt.Errorf("only one of a separator and a separator regex can be used")
+	case len(separator) > 0:
+		return splitSeparator(separator), nil
+	case separatorRegex != "":
+		re, err := regexp.Compile(separatorRegex)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing separator regex: %v", err)
+		}
+		return splitSeparatorRegex(re), nil
+	default:
+		return nil, fmt.Errorf("data split by SEPARATOR needs a separator or a separator regex")
+	}
+}
+
+// newSeparatorScanner returns a scanner of the records in r that split
+// splits.
+func newSeparatorScanner(r *bufio.Reader, split bufio.SplitFunc) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	// records can be as big as the data, as they can for LINE
+	scanner.Buffer(nil, math.MaxInt32)
+	scanner.Split(split)
+	return scanner
+}
+
+func splitSeparator(separator []byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.Index(data, separator); i >= 0 {
+			end := i + len(separator)
+			return end, data[:end], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+func splitSeparatorRegex(re *regexp.Regexp) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		for offset := 0; offset < len(data); {
+			loc := re.FindSubmatchIndex(data[offset:])
+			if loc == nil || (offset+loc[1] == len(data) && !atEOF) {
+				// the match could be longer, or start sooner, with more data
+				break
+			}
+			end := loc[1]
+			if len(loc) > 2 && loc[2] >= 0 {
+				end = loc[2]
+			}
+			if offset+end > 0 {
+				return offset + end, data[:offset+end], nil
+			}
+			// the boundary is at the start of the record, so it's the one
+			// that ended the previous record, look for the next one
+			if loc[1] > 0 {
+				offset += loc[1]
+			} else {
+				offset++
+			}
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+)
+
+// checkStorageClass returns an error if storageClass can't be the name of an
+// object store's storage class. Classes are passed to the object store as is,
+// so it's up to the store to reject ones that it doesn't have.
+func checkStorageClass(storageClass string) error {
+	if strings.IndexFunc(storageClass, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-')
+	}) >= 0 {
+		return fmt.Errorf("invalid storage class %q: it must be made up of letters, digits, '_' and '-'", storageClass)
+	}
+	return nil
+}
+
+// setStorageClass sets the storage class that repo's data is written in.
+// Data that's already written keeps its class, as does data that's written
+// again but is already stored, e.g. by another repo.
+func (d *driver) setStorageClass(ctx context.Context, repo *pfs.Repo, storageClass string) error {
+	if err := d.checkIsAuthorized(ctx, repo, auth.Scope_OWNER); err != nil {
+		return err
+	}
+	if err := checkStorageClass(storageClass); err != nil {
+		return err
+	}
+	if storageClass != "" {
+		d.featureUsage.inc("storage_class")
+	}
+	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		repos := d.repos.ReadWrite(stm)
+		repoInfo := new(pfs.RepoInfo)
+		if err := repos.Get(repo.Name, repoInfo); err != nil {
+			return err
+		}
+		repoInfo.StorageClass = storageClass
+		return repos.Put(repo.Name, repoInfo)
+	})
+	return err
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pkg/pfsdb"
+)
+
+// repoSubvenance returns the repos that have repo in their provenance. As a
+// repo's provenance is its full provenance, that's every repo downstream of
+// it, and as repos are indexed by their provenance, it's found without
+// reading any other repo.
+func (d *driver) repoSubvenance(ctx context.Context, repo *pfs.Repo) ([]*pfs.Repo, error) {
+	iter, err := d.repos.ReadOnly(ctx).GetByIndex(pfsdb.ProvenanceIndex, repo)
+	if err != nil {
+		return nil, err
+	}
+	var result []*pfs.Repo
+	for {
+		var repoName string
+		repoInfo := &pfs.RepoInfo{}
+		ok, err := iter.Next(&repoName, repoInfo)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return result, nil
+		}
+		result = append(result, repoInfo.Repo)
+	}
+}
+
+// commitSubvenance returns the commits that have commit, which must be
+// resolved to a commit ID, in their provenance. Only the commits of the
+// repos downstream of commit's repo can, so only they are looked up, by
+// the commits' provenance index.
+func (d *driver) commitSubvenance(ctx context.Context, commit *pfs.Commit) ([]*pfs.Commit, error) {
+	repos, err := d.repoSubvenance(ctx, commit.Repo)
+	if err != nil {
+		return nil, err
+	}
+	var result []*pfs.Commit
+	for _, repo := range repos {
+		iter, err := d.commits(repo.Name).ReadOnly(ctx).GetByIndex(pfsdb.ProvenanceIndex, commit)
+		if err != nil {
+			return nil, err
+		}
+		for {
+			var commitID string
+			commitInfo := &pfs.CommitInfo{}
+			ok, err := iter.Next(&commitID, commitInfo)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				break
+			}
+			result = append(result, commitInfo.Commit)
+		}
+	}
+	return result, nil
+}
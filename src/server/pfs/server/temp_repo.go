@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+
+	"github.com/gogo/protobuf/types"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	tempRepoLockPath = "_temp_repo_lock"
+
+	// tempRepoPollInterval is how often the leases of temporary repos are
+	// checked for expiry
+	tempRepoPollInterval = 10 * time.Second
+)
+
+// newRepoLease returns the lease of a temporary repo that expires ttl
+// seconds from now. If auth is active the lease holds a capability for the
+// caller, which the repo is deleted with once the lease expires, and which
+// must be revoked if the lease isn't stored.
+func (d *driver) newRepoLease(ctx context.Context, repo *pfs.Repo, ttl int64) (*pfs.RepoLease, error) {
+	if ttl < 0 {
+		return nil, fmt.Errorf("the ttl of a repo can't be negative")
+	}
+	expires, err := types.TimestampProto(time.Now().Add(time.Duration(ttl) * time.Second))
+	if err != nil {
+		return nil, err
+	}
+	lease := &pfs.RepoLease{
+		Repo:       repo,
+		Expires:    expires,
+		TtlSeconds: ttl,
+	}
+	resp, err := d.pachClient.AuthAPIClient.GetCapability(auth.In2Out(ctx), &auth.GetCapabilityRequest{})
+	if err != nil && !auth.IsNotActivatedError(err) {
+		return nil, grpcutil.ScrubGRPC(err)
+	} else if err == nil {
+		lease.Capability = resp.Capability
+	}
+	d.featureUsage.inc("temp_repo")
+	return lease, nil
+}
+
+// renewRepo extends the lease of the temporary repo to ttl seconds from
+// now, or to the ttl it was created with if ttl is 0. Leases that have
+// already expired can't be renewed, as the repo may be being deleted.
+func (d *driver) renewRepo(ctx context.Context, repo *pfs.Repo, ttl int64) error {
+	if err := d.checkIsAuthorized(ctx, repo, auth.Scope_WRITER); err != nil {
+		return err
+	}
+	if ttl < 0 {
+		return fmt.Errorf("the ttl of a repo can't be negative")
+	}
+	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		leases := d.repoLeases.ReadWrite(stm)
+		lease := &pfs.RepoLease{}
+		if err := leases.Get(repo.Name, lease); err != nil {
+			if col.IsErrNotFound(err) {
+				return fmt.Errorf("repo %s isn't temporary", repo.Name)
+			}
+			return err
+		}
+		expired, err := leaseExpired(lease)
+		if err != nil {
+			return err
+		}
+		if expired {
+			return fmt.Errorf("the lease of repo %s has expired", repo.Name)
+		}
+		if ttl == 0 {
+			ttl = lease.TtlSeconds
+		}
+		if lease.Expires, err = types.TimestampProto(time.Now().Add(time.Duration(ttl) * time.Second)); err != nil {
+			return err
+		}
+		repos := d.repos.ReadWrite(stm)
+		repoInfo := &pfs.RepoInfo{}
+		if err := repos.Get(repo.Name, repoInfo); err != nil {
+			return err
+		}
+		repoInfo.Expires = lease.Expires
+		if err := repos.Put(repo.Name, repoInfo); err != nil {
+			return err
+		}
+		return leases.Put(repo.Name, lease)
+	})
+	return err
+}
+
+func leaseExpired(lease *pfs.RepoLease) (bool, error) {
+	expires, err := types.TimestampFromProto(lease.Expires)
+	if err != nil {
+		return false, err
+	}
+	return !time.Now().Before(expires), nil
+}
+
+// deleteExpiredRepos makes one pass over the leases of temporary repos,
+// deleting the repos whose leases have expired. Repos that can't be deleted,
+// e.g. because they're the provenance of other repos, are tried again on the
+// next pass.
+func (d *driver) deleteExpiredRepos(ctx context.Context) error {
+	iter, err := d.repoLeases.ReadOnly(ctx).List()
+	if err != nil {
+		return err
+	}
+	var expired []*pfs.RepoLease
+	for {
+		var repo string
+		lease := &pfs.RepoLease{}
+		ok, err := iter.Next(&repo, lease)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		if ok, err := leaseExpired(lease); err != nil {
+			return err
+		} else if ok {
+			expired = append(expired, lease)
+		}
+	}
+	for _, lease := range expired {
+		// the repo is deleted with the credentials of the user that created
+		// it
+		userCtx := ctx
+		if lease.Capability != "" {
+			userCtx = metadata.NewIncomingContext(ctx, metadata.Pairs(auth.ContextTokenKey, lease.Capability))
+		}
+		if err := d.deleteRepo(userCtx, lease.Repo, false); err != nil {
+			log.Errorf("error deleting expired repo %s: %v", lease.Repo.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+	"github.com/pachyderm/pachyderm/src/server/pkg/pfsdb"
+	log "github.com/sirupsen/logrus"
+)
+
+// treeCacheKey returns the key of commit's tree in treeCache. Commit IDs are
+// shared between repos, e.g. by proxy repos and imported bundles, so the key
+// includes the repo.
+func treeCacheKey(commit *pfs.Commit) string {
+	return commit.FullID()
+}
+
+// treeCacheGeneration returns the number of times that trees have been
+// invalidated, which is read before a commit's tree is, see addTree.
+func (d *driver) treeCacheGeneration() uint64 {
+	d.treeCacheMu.Lock()
+	defer d.treeCacheMu.Unlock()
+	return d.treeCacheGen
+}
+
+// addTree caches tree as commit's, unless trees have been invalidated since
+// the generation gen, when tree was read. The invalidation may have been of
+// commit's tree after tree was read, in which case tree is stale.
+func (d *driver) addTree(commit *pfs.Commit, tree hashtree.HashTree, gen uint64) {
+	d.treeCacheMu.Lock()
+	defer d.treeCacheMu.Unlock()
+	if d.treeCacheGen == gen {
+		d.treeCache.Add(treeCacheKey(commit), tree)
+	}
+}
+
+// invalidateTree removes commit's tree from treeCache. Anything that deletes
+// a finished commit, or replaces its tree, must call it once it's done so,
+// so that the old tree isn't served by this pachd. Other pachds invalidate
+// their trees as they see the change, see watchTreeCache.
+func (d *driver) invalidateTree(commit *pfs.Commit) {
+	d.treeCacheMu.Lock()
+	defer d.treeCacheMu.Unlock()
+	d.treeCacheGen++
+	d.treeCache.Remove(treeCacheKey(commit))
+}
+
+// invalidateRepoTrees removes the trees of every commit of repo from
+// treeCache, e.g. as the repo's deleted.
+func (d *driver) invalidateRepoTrees(repo *pfs.Repo) {
+	d.treeCacheMu.Lock()
+	defer d.treeCacheMu.Unlock()
+	d.treeCacheGen++
+	prefix := treeCacheKey(&pfs.Commit{Repo: repo}) // "<repo>/"
+	for _, key := range d.treeCache.Keys() {
+		if key, ok := key.(string); ok && strings.HasPrefix(key, prefix) {
+			d.treeCache.Remove(key)
+		}
+	}
+}
+
+// purgeTrees removes every tree from treeCache.
+func (d *driver) purgeTrees() {
+	d.treeCacheMu.Lock()
+	defer d.treeCacheMu.Unlock()
+	d.treeCacheGen++
+	d.treeCache.Purge()
+}
+
+// watchTreeCache watches the commits of every repo, and invalidates the
+// trees of the ones that are deleted or whose trees are replaced, so that
+// trees cached by this pachd don't outlive changes made by other pachds. It
+// runs on every pachd.
+func (d *driver) watchTreeCache(ctx context.Context) {
+	prefix := pfsdb.CommitsPrefix(d.prefix) + "/"
+	backoff.RetryNotify(func() error {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		// changes may have been missed since the last watch, so everything
+		// that's cached before the watch starts is dropped
+		resp, err := d.etcdClient.Get(ctx, prefix, etcd.WithPrefix(), etcd.WithCountOnly())
+		if err != nil {
+			return err
+		}
+		d.purgeTrees()
+		for resp := range d.etcdClient.Watch(ctx, prefix, etcd.WithPrefix(), etcd.WithPrevKV(), etcd.WithRev(resp.Header.Revision+1)) {
+			if err := resp.Err(); err != nil {
+				return err
+			}
+			for _, event := range resp.Events {
+				commit := commitOfKey(strings.TrimPrefix(string(event.Kv.Key), prefix))
+				if commit == nil {
+					continue
+				}
+				if event.Type == etcd.EventTypePut && event.PrevKv != nil && sameTree(event.PrevKv.Value, event.Kv.Value) {
+					continue
+				}
+				d.invalidateTree(commit)
+			}
+		}
+		return fmt.Errorf("tree cache watch stream closed unexpectedly")
+	}, backoff.NewInfiniteBackOff(), func(err error, retryIn time.Duration) error {
+		if ctx.Err() != nil {
+			return ctx.Err() // the driver has been stopped
+		}
+		log.Errorf("error watching commits for the tree cache: %v; retrying in %v", err, retryIn)
+		return nil
+	})
+}
+
+// commitOfKey returns the commit whose CommitInfo is stored at key, relative
+// to the commits of every repo, or nil if key is the key of an index entry.
+func commitOfKey(key string) *pfs.Commit {
+	parts := strings.Split(key, "/")
+	if len(parts) != 2 || strings.Contains(parts[0], "__index_") {
+		return nil
+	}
+	return &pfs.Commit{Repo: &pfs.Repo{Name: parts[0]}, ID: parts[1]}
+}
+
+// sameTree returns true if the serialized CommitInfos before and after have
+// the same tree. If either can't be read, it returns false, so that the tree
+// is invalidated.
+func sameTree(before []byte, after []byte) bool {
+	beforeInfo, afterInfo := new(pfs.CommitInfo), new(pfs.CommitInfo)
+	if beforeInfo.Unmarshal(before) != nil || afterInfo.Unmarshal(after) != nil {
+		return false
+	}
+	return beforeInfo.Tree.GetHash() == afterInfo.Tree.GetHash()
+}
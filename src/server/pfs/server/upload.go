@@ -0,0 +1,161 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
+	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+)
+
+// uploadSessionTTL is how long, in seconds, an upload lasts without a chunk
+// being put before it's dropped. The chunks it had acknowledged are left to
+// garbage collection.
+const uploadSessionTTL = 24 * 60 * 60
+
+// startUpload starts a resumable upload of file, whose commit must be open.
+func (d *driver) startUpload(ctx context.Context, file *pfs.File, mode pfs.PutFileMode) (*pfs.UploadSession, error) {
+	if err := d.checkIsAuthorized(ctx, file.Commit.Repo, auth.Scope_WRITER); err != nil {
+		return nil, err
+	}
+	if err := d.checkPathIsWritable(ctx, file, false); err != nil {
+		return nil, err
+	}
+	if err := checkPath(file.Path); err != nil {
+		return nil, err
+	}
+	commit, err := d.openCommit(ctx, file.Commit)
+	if err != nil {
+		return nil, err
+	}
+	d.featureUsage.inc("upload")
+	session := &pfs.UploadSession{
+		ID:   uuid.NewWithoutDashes(),
+		File: client.NewFile(commit.Repo.Name, commit.ID, path.Clean("/"+file.Path)),
+		Mode: mode,
+	}
+	if _, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		return d.uploadSessions.ReadWrite(stm).PutTTL(session.ID, session, uploadSessionTTL)
+	}); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// putChunk puts the data in reader into the blob store as the chunk at
+// index of the upload id, which must be the next chunk of the upload. The
+// chunk is acknowledged, and the upload's lease renewed, once it returns.
+func (d *driver) putChunk(ctx context.Context, id string, index int64, reader io.Reader) (*pfs.UploadSession, error) {
+	session, err := d.inspectUpload(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkChunkIndex(session, index); err != nil {
+		return nil, err
+	}
+	compression, storageClass, err := d.repoCompression(ctx, session.File.Commit.Repo)
+	if err != nil {
+		return nil, err
+	}
+	records, err := d.putFileRecords(putFileOptions{}, compression, storageClass, nil, nil, nil, reader)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		uploads := d.uploadSessions.ReadWrite(stm)
+		session = &pfs.UploadSession{}
+		if err := uploads.Get(id, session); err != nil {
+			if col.IsErrNotFound(err) {
+				return pfsserver.ErrUploadNotFound{id}
+			}
+			return err
+		}
+		// another put of the same chunk may have been acknowledged since
+		// it was checked
+		if err := checkChunkIndex(session, index); err != nil {
+			return err
+		}
+		for _, record := range records.Records {
+			session.Records = append(session.Records, record)
+			session.SizeBytes += record.SizeBytes
+		}
+		session.Chunks++
+		return uploads.PutTTL(id, session, uploadSessionTTL)
+	}); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func checkChunkIndex(session *pfs.UploadSession, index int64) error {
+	if index != session.Chunks {
+		return fmt.Errorf("chunk %d can't be put, the next chunk of upload %s is %d", index, session.ID, session.Chunks)
+	}
+	return nil
+}
+
+// inspectUpload returns the upload id.
+func (d *driver) inspectUpload(ctx context.Context, id string) (*pfs.UploadSession, error) {
+	session := &pfs.UploadSession{}
+	if err := d.uploadSessions.ReadOnly(ctx).Get(id, session); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil, pfsserver.ErrUploadNotFound{id}
+		}
+		return nil, err
+	}
+	if err := d.checkIsAuthorized(ctx, session.File.Commit.Repo, auth.Scope_WRITER); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// completeUpload writes the acknowledged chunks of the upload id to its file
+// in the same way as putFile, and ends the upload.
+func (d *driver) completeUpload(ctx context.Context, id string) error {
+	session, err := d.inspectUpload(ctx, id)
+	if err != nil {
+		return err
+	}
+	if session.Mode == pfs.PutFileMode_CREATE_ONLY {
+		if err := d.checkFileNotExists(ctx, session.File); err != nil {
+			return err
+		}
+	}
+	prefix, err := d.scratchFilePrefix(ctx, session.File)
+	if err != nil {
+		return err
+	}
+	_, err = col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		uploads := d.uploadSessions.ReadWrite(stm)
+		session := &pfs.UploadSession{}
+		if err := uploads.Get(id, session); err != nil {
+			if col.IsErrNotFound(err) {
+				return pfsserver.ErrUploadNotFound{id}
+			}
+			return err
+		}
+		if err := d.openCommits.ReadWrite(stm).Get(session.File.Commit.ID, &pfs.Commit{}); err != nil {
+			if col.IsErrNotFound(err) {
+				return pfsserver.ErrCommitFinished{session.File.Commit}
+			}
+			return err
+		}
+		records := &pfs.PutFileRecords{
+			Mode:    session.Mode,
+			Records: session.Records,
+		}
+		marshalledRecords, err := records.Marshal()
+		if err != nil {
+			return err
+		}
+		stm.Put(path.Join(prefix, uuid.NewWithoutDashes()), string(marshalledRecords))
+		return uploads.Delete(id)
+	})
+	return err
+}
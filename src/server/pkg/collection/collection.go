@@ -70,6 +70,14 @@ func (c *collection) ReadOnly(ctx context.Context) ReadonlyCollection {
 	}
 }
 
+func (c *collection) ReadOnlyAt(ctx context.Context, rev int64) ReadonlyCollection {
+	return &readonlyCollection{
+		collection: c,
+		ctx:        ctx,
+		rev:        rev,
+	}
+}
+
 // Path returns the full path of a key in the etcd namespace
 func (c *collection) Path(key string) string {
 	return path.Join(c.prefix, key)
@@ -317,10 +325,12 @@ func (c *readWriteIntCollection) Delete(key string) error {
 type readonlyCollection struct {
 	*collection
 	ctx context.Context
+	// rev is the etcd revision that's read, 0 for the latest one
+	rev int64
 }
 
 func (c *readonlyCollection) Get(key string, val proto.Unmarshaler) error {
-	resp, err := c.etcdClient.Get(c.ctx, c.Path(key))
+	resp, err := c.etcdClient.Get(c.ctx, c.Path(key), etcd.WithRev(c.rev))
 	if err != nil {
 		return err
 	}
@@ -366,7 +376,7 @@ func (i *indirectIterator) Next(key *string, val proto.Unmarshaler) (ok bool, re
 
 func (c *readonlyCollection) GetByIndex(index Index, val interface{}) (Iterator, error) {
 	valStr := fmt.Sprintf("%s", val)
-	resp, err := c.etcdClient.Get(c.ctx, c.indexDir(index, valStr), etcd.WithPrefix(), etcd.WithSort(etcd.SortByModRevision, etcd.SortDescend))
+	resp, err := c.etcdClient.Get(c.ctx, c.indexDir(index, valStr), etcd.WithPrefix(), etcd.WithSort(etcd.SortByModRevision, etcd.SortDescend), etcd.WithRev(c.rev))
 	if err != nil {
 		return nil, err
 	}
@@ -380,7 +390,7 @@ func (c *readonlyCollection) GetByIndex(index Index, val interface{}) (Iterator,
 // The objects are sorted by revision time in descending order, i.e. newer
 // objects are returned first.
 func (c *readonlyCollection) List() (Iterator, error) {
-	resp, err := c.etcdClient.Get(c.ctx, c.prefix, etcd.WithPrefix(), etcd.WithSort(etcd.SortByModRevision, etcd.SortDescend))
+	resp, err := c.etcdClient.Get(c.ctx, c.prefix, etcd.WithPrefix(), etcd.WithSort(etcd.SortByModRevision, etcd.SortDescend), etcd.WithRev(c.rev))
 	if err != nil {
 		return nil, err
 	}
@@ -395,7 +405,7 @@ type iterator struct {
 }
 
 func (c *readonlyCollection) Count() (int64, error) {
-	resp, err := c.etcdClient.Get(c.ctx, c.prefix, etcd.WithPrefix(), etcd.WithCountOnly())
+	resp, err := c.etcdClient.Get(c.ctx, c.prefix, etcd.WithPrefix(), etcd.WithCountOnly(), etcd.WithRev(c.rev))
 	if err != nil {
 		return 0, err
 	}
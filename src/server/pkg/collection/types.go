@@ -27,6 +27,10 @@ type Collection interface {
 	ReadWriteInt(stm STM) ReadWriteIntCollection
 	// For read-only operatons, use the ReadOnly for better performance
 	ReadOnly(ctx context.Context) ReadonlyCollection
+	// ReadOnlyAt is the same as ReadOnly except that it reads the collection
+	// as it was at etcd revision rev, so that several reads are consistent
+	// with each other. Watches aren't affected.
+	ReadOnlyAt(ctx context.Context, rev int64) ReadonlyCollection
 }
 
 // Index specifies a secondary index on a collection.
@@ -76,6 +76,10 @@ type AssetOpts struct {
 	DashOnly    bool
 	DashImage   string
 
+	// FeatureTelemetry opts in to reporting aggregate PFS feature usage, see
+	// `pachctl inspect-feature-usage`.
+	FeatureTelemetry bool
+
 	// DisableAuthentication stops Pachyderm's authentication service
 	// from talking to GitHub, for testing. Instead users can authenticate
 	// simply by providing a username.
@@ -347,6 +351,10 @@ func PachdDeployment(opts *AssetOpts, objectStoreBackend backend, hostPath strin
 									Name:  "METRICS",
 									Value: strconv.FormatBool(opts.Metrics),
 								},
+								{
+									Name:  "FEATURE_TELEMETRY",
+									Value: strconv.FormatBool(opts.FeatureTelemetry),
+								},
 								{
 									Name:  "LOG_LEVEL",
 									Value: opts.LogLevel,
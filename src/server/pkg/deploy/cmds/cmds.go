@@ -66,6 +66,7 @@ func DeployCmd(noMetrics *bool) *cobra.Command {
 	var enableDash bool
 	var dashOnly bool
 	var dashImage string
+	var featureTelemetry bool
 
 	deployLocal := &cobra.Command{
 		Use:   "local",
@@ -277,6 +278,7 @@ func DeployCmd(noMetrics *bool) *cobra.Command {
 				EnableDash:              enableDash,
 				DashOnly:                dashOnly,
 				DashImage:               dashImage,
+				FeatureTelemetry:        featureTelemetry,
 			}
 			return nil
 		}),
@@ -289,6 +291,7 @@ func DeployCmd(noMetrics *bool) *cobra.Command {
 	deploy.PersistentFlags().BoolVar(&enableDash, "dashboard", false, "Deploy the Pachyderm UI along with Pachyderm (experimental). After deployment, run \"pachctl port-forward\" to connect")
 	deploy.PersistentFlags().BoolVar(&dashOnly, "dashboard-only", false, "Only deploy the Pachyderm UI (experimental), without the rest of pachyderm. This is for launching the UI adjacent to an existing Pachyderm cluster. After deployment, run \"pachctl port-forward\" to connect")
 	deploy.PersistentFlags().StringVar(&dashImage, "dash-image", defaultDashImage, "Image URL for pachyderm dashboard")
+	deploy.PersistentFlags().BoolVar(&featureTelemetry, "feature-telemetry", false, "Opt in to periodically reporting aggregate PFS feature usage (no repo names, paths or data) to help prioritize development. Run \"pachctl inspect-feature-usage\" to see exactly what is sent.")
 	deploy.AddCommand(deployLocal)
 	deploy.AddCommand(deployAmazon)
 	deploy.AddCommand(deployGoogle)
@@ -0,0 +1,269 @@
+package hashtree
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+)
+
+// chunkedVersion is the HashTreeProto version of trees serialized by
+// SerializeChunked, whose nodes are stored in chunks instead of in Fs.
+const chunkedVersion = 2
+
+// chunkLess orders paths by their parent directory and then by their name,
+// which is the order that the nodes of chunked trees are stored in.
+func chunkLess(p string, q string) bool {
+	pDir, pBase := split(p)
+	qDir, qBase := split(q)
+	if pDir != qDir {
+		return pDir < qDir
+	}
+	return pBase < qBase
+}
+
+// SerializeChunked is like Serialize, except that if h has more than
+// chunkSize nodes, they're split into chunks of chunkSize nodes, which are
+// stored with putChunk, and only an index of the chunks is returned. Trees
+// serialized this way are read with DeserializeLazy, which only reads the
+// chunks that hold the nodes that are accessed.
+func SerializeChunked(h HashTree, chunkSize int, putChunk func([]byte) (*pfs.Object, error)) ([]byte, error) {
+	tree, err := toProto(h)
+	if err != nil {
+		return nil, err
+	}
+	if chunkSize <= 0 || len(tree.Fs) <= chunkSize {
+		return tree.Marshal()
+	}
+	paths := make([]string, 0, len(tree.Fs))
+	for path := range tree.Fs {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return chunkLess(paths[i], paths[j])
+	})
+	index := &HashTreeProto{Version: chunkedVersion}
+	for start := 0; start < len(paths); start += chunkSize {
+		end := start + chunkSize
+		if end > len(paths) {
+			end = len(paths)
+		}
+		chunk := &HashTreeProto{
+			Version: 1,
+			Fs:      make(map[string]*NodeProto, end-start),
+		}
+		for _, path := range paths[start:end] {
+			chunk.Fs[path] = tree.Fs[path]
+		}
+		data, err := chunk.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		object, err := putChunk(data)
+		if err != nil {
+			return nil, err
+		}
+		index.Chunks = append(index.Chunks, &ChunkRef{
+			FirstPath: paths[start],
+			Object:    object,
+		})
+	}
+	return index.Marshal()
+}
+
+// DeserializeLazy is like Deserialize, except that it also reads trees
+// serialized by SerializeChunked, whose chunks it reads with getChunk as
+// they're needed. Get, List and ListPage only read the chunks that hold the
+// nodes they return, the other methods read every chunk.
+func DeserializeLazy(serialized []byte, getChunk func(*pfs.Object) ([]byte, error)) (HashTree, error) {
+	h := &HashTreeProto{}
+	if err := h.Unmarshal(serialized); err != nil {
+		return nil, err
+	}
+	if h.Version == chunkedVersion {
+		return &lazyTree{
+			chunks:   h.Chunks,
+			getChunk: getChunk,
+			loaded:   make([]lazyChunk, len(h.Chunks)),
+		}, nil
+	}
+	return Deserialize(serialized)
+}
+
+// ChunkObjects returns the objects that hold the chunks of h, if it was
+// serialized by SerializeChunked, and nil otherwise.
+func ChunkObjects(h HashTree) []*pfs.Object {
+	l, ok := h.(*lazyTree)
+	if !ok {
+		return nil
+	}
+	var objects []*pfs.Object
+	for _, chunk := range l.chunks {
+		objects = append(objects, chunk.Object)
+	}
+	return objects
+}
+
+// lazyTree is a HashTree serialized by SerializeChunked, whose chunks are
+// read as they're needed.
+type lazyTree struct {
+	chunks   []*ChunkRef
+	getChunk func(*pfs.Object) ([]byte, error)
+
+	// loaded holds the nodes of each chunk that's been read, by index
+	loaded []lazyChunk
+
+	mu sync.Mutex
+	// full is the whole tree, once a method has needed every chunk
+	full *HashTreeProto
+}
+
+// lazyChunk is a chunk of a lazyTree. Each chunk has its own lock, so that
+// reading one doesn't block readers of the others, or of the whole tree,
+// while only one reader reads it.
+type lazyChunk struct {
+	mu sync.Mutex
+	fs map[string]*NodeProto
+}
+
+func (l *lazyTree) loadChunk(i int) (map[string]*NodeProto, error) {
+	c := &l.loaded[i]
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fs != nil {
+		return c.fs, nil
+	}
+	data, err := l.getChunk(l.chunks[i].Object)
+	if err != nil {
+		return nil, err
+	}
+	chunk := &HashTreeProto{}
+	if err := chunk.Unmarshal(data); err != nil {
+		return nil, errorf(CannotDeserialize, "could not deserialize chunk %d of the tree: %v", i, err)
+	}
+	if chunk.Fs == nil {
+		chunk.Fs = make(map[string]*NodeProto)
+	}
+	c.fs = chunk.Fs
+	return c.fs, nil
+}
+
+// materialize returns the whole tree, reading every chunk that hasn't been
+// read yet.
+func (l *lazyTree) materialize() (*HashTreeProto, error) {
+	l.mu.Lock()
+	full := l.full
+	l.mu.Unlock()
+	if full != nil {
+		return full, nil
+	}
+	full = &HashTreeProto{
+		Version: 1,
+		Fs:      make(map[string]*NodeProto),
+	}
+	for i := range l.chunks {
+		fs, err := l.loadChunk(i)
+		if err != nil {
+			return nil, err
+		}
+		for path, node := range fs {
+			full.Fs[path] = node
+		}
+	}
+	l.mu.Lock()
+	l.full = full
+	l.mu.Unlock()
+	// the chunks' nodes are all in full now
+	for i := range l.loaded {
+		c := &l.loaded[i]
+		c.mu.Lock()
+		c.fs = nil
+		c.mu.Unlock()
+	}
+	return full, nil
+}
+
+// Get implements HashTree.Get
+func (l *lazyTree) Get(path string) (*NodeProto, error) {
+	path = clean(path)
+	l.mu.Lock()
+	full := l.full
+	l.mu.Unlock()
+	if full != nil {
+		return get(full.Fs, path)
+	}
+	// the chunk that holds path is the last one that starts at or before it
+	i := sort.Search(len(l.chunks), func(i int) bool {
+		return chunkLess(path, l.chunks[i].FirstPath)
+	}) - 1
+	if i < 0 {
+		return nil, errorf(PathNotFound, "no node at \"%s\"", path)
+	}
+	fs, err := l.loadChunk(i)
+	if err != nil {
+		return nil, err
+	}
+	return get(fs, path)
+}
+
+// List implements HashTree.List
+func (l *lazyTree) List(path string) ([]*NodeProto, error) {
+	return l.ListPage(path, "", 0)
+}
+
+// ListPage implements HashTree.ListPage
+func (l *lazyTree) ListPage(path string, after string, limit int) ([]*NodeProto, error) {
+	return listNodes(l.Get, path, after, limit)
+}
+
+// Open implements HashTree.Open
+func (l *lazyTree) Open() OpenHashTree {
+	full, err := l.materialize()
+	if err != nil {
+		// Open can't return an error, so the tree is left empty and the error
+		// surfaces when the tree is finished
+		return &hashtree{
+			fs:      make(map[string]*NodeProto),
+			changed: make(map[string]bool),
+			err:     err,
+		}
+	}
+	return full.Open()
+}
+
+// Glob implements HashTree.Glob
+func (l *lazyTree) Glob(pattern string) ([]*NodeProto, error) {
+	return l.GlobPage(pattern, "", 0)
+}
+
+// GlobPage implements HashTree.GlobPage
+func (l *lazyTree) GlobPage(pattern string, after string, limit int) ([]*NodeProto, error) {
+	full, err := l.materialize()
+	if err != nil {
+		return nil, err
+	}
+	return full.GlobPage(pattern, after, limit)
+}
+
+// FSSize implements HashTree.FSSize
+func (l *lazyTree) FSSize() int64 {
+	node, err := l.Get("/")
+	if err != nil {
+		return 0
+	}
+	return node.SubtreeSize
+}
+
+// Walk implements HashTree.Walk
+func (l *lazyTree) Walk(path string, f func(string, *NodeProto) error) error {
+	full, err := l.materialize()
+	if err != nil {
+		return err
+	}
+	return full.Walk(path, f)
+}
+
+// Diff implements HashTree.Diff
+func (l *lazyTree) Diff(old HashTree, newPath string, oldPath string, recursiveDepth int64, f func(string, *NodeProto, bool) error) error {
+	return diff(l, old, newPath, oldPath, recursiveDepth, f)
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"fmt"
 	pathlib "path"
+	"sort"
 	"strings"
 
 	"github.com/golang/protobuf/proto"
@@ -17,17 +18,20 @@ const (
 	none         nodetype = iota // No file is present at this point in the tree
 	directory                    // The file at this point in the tree is a directory
 	file                         // ... is a regular file
+	symlink                      // ... is a symlink
 	unrecognized                 // ... is an an unknown type
 )
 
 func (n *NodeProto) nodetype() nodetype {
 	switch {
-	case n == nil || (n.DirNode == nil && n.FileNode == nil):
+	case n == nil || (n.DirNode == nil && n.FileNode == nil && n.SymlinkNode == nil):
 		return none
 	case n.DirNode != nil:
 		return directory
 	case n.FileNode != nil:
 		return file
+	case n.SymlinkNode != nil:
+		return symlink
 	default:
 		return unrecognized
 	}
@@ -41,6 +45,8 @@ func (n *OpenNode) nodetype() nodetype {
 		return directory
 	case n.FileNode != nil:
 		return file
+	case n.SymlinkNode != nil:
+		return symlink
 	default:
 		return unrecognized
 	}
@@ -54,6 +60,8 @@ func (n nodetype) tostring() string {
 		return "directory"
 	case file:
 		return "file"
+	case symlink:
+		return "symlink"
 	default:
 		return "unknown"
 	}
@@ -62,19 +70,35 @@ func (n nodetype) tostring() string {
 // Serialize serializes a HashTree so that it can be persisted. Also see
 // Deserialize(bytes).
 func Serialize(h HashTree) ([]byte, error) {
-	tree, ok := h.(*HashTreeProto)
-	if !ok {
-		return nil, fmt.Errorf("HashTree is of the wrong concrete type")
+	tree, err := toProto(h)
+	if err != nil {
+		return nil, err
 	}
 	return tree.Marshal()
 }
 
+// toProto returns h, a finished HashTree, as a HashTreeProto.
+func toProto(h HashTree) (*HashTreeProto, error) {
+	switch tree := h.(type) {
+	case *HashTreeProto:
+		return tree, nil
+	case *lazyTree:
+		return tree.materialize()
+	default:
+		return nil, fmt.Errorf("HashTree is of the wrong concrete type")
+	}
+}
+
 // Deserialize deserializes a hash tree so that it can be read or modified.
 func Deserialize(serialized []byte) (HashTree, error) {
 	h := &HashTreeProto{}
 	if err := h.Unmarshal(serialized); err != nil {
 		return nil, err
 	}
+	if h.Version == chunkedVersion {
+		return nil, errorf(Unsupported, "HashTreeProto is chunked, so it must be "+
+			"read with DeserializeLazy")
+	}
 	if h.Version != 1 {
 		return nil, errorf(Unsupported, "unsupported HashTreeProto "+
 			"version %d", h.Version)
@@ -112,10 +136,70 @@ func (h *HashTreeProto) Get(path string) (*NodeProto, error) {
 	return get(h.Fs, path)
 }
 
+// maxSymlinkHops is the number of symlinks that Resolve follows before giving
+// up, which is how it detects symlink cycles.
+const maxSymlinkHops = 40
+
+// Resolve follows the symlinks in every component of 'path' and returns the
+// path that it refers to along with the node at that path. Relative symlink
+// targets are interpreted relative to the directory that contains the symlink.
+func Resolve(h HashTree, path string) (string, *NodeProto, error) {
+	path = clean(path)
+	var resolved string
+	var hops int
+	rest := strings.Split(path, "/")
+	for len(rest) > 0 {
+		component := rest[0]
+		rest = rest[1:]
+		if component == "" {
+			continue
+		}
+		next := join(resolved, component)
+		node, err := h.Get(next)
+		if err != nil {
+			return "", nil, err
+		}
+		if node.SymlinkNode == nil {
+			resolved = next
+			continue
+		}
+		hops++
+		if hops > maxSymlinkHops {
+			return "", nil, errorf(SymlinkLoop, "too many levels of symlinks "+
+				"while resolving \"%s\"", path)
+		}
+		target := node.SymlinkNode.Target
+		if !strings.HasPrefix(target, "/") {
+			target = join(resolved, target)
+		}
+		rest = append(strings.Split(clean(target), "/"), rest...)
+		resolved = ""
+	}
+	node, err := h.Get(resolved)
+	if err != nil {
+		return "", nil, err
+	}
+	return resolved, node, nil
+}
+
 func list(fs map[string]*NodeProto, path string) ([]*NodeProto, error) {
+	return listPage(fs, path, "", 0)
+}
+
+// listPage lists the children of the directory at 'path' whose names sort
+// after 'after', at most 'limit' of them if limit is greater than 0. Children
+// are kept sorted, so the page is found without visiting earlier children.
+func listPage(fs map[string]*NodeProto, path string, after string, limit int) ([]*NodeProto, error) {
+	return listNodes(func(path string) (*NodeProto, error) {
+		return get(fs, path)
+	}, path, after, limit)
+}
+
+// listNodes is listPage for a tree whose nodes are read with getNode.
+func listNodes(getNode func(string) (*NodeProto, error), path string, after string, limit int) ([]*NodeProto, error) {
 	path = clean(path)
 
-	node, err := get(fs, path)
+	node, err := getNode(path)
 	if err != nil {
 		return nil, err
 	}
@@ -124,13 +208,24 @@ func list(fs map[string]*NodeProto, path string) ([]*NodeProto, error) {
 		return nil, errorf(PathConflict, "the file at \"%s\" is not a directory",
 			path)
 	}
-	var ok bool
-	result := make([]*NodeProto, len(d.Children))
-	for i, child := range d.Children {
-		result[i], ok = fs[join(path, child)]
-		if !ok {
-			return nil, errorf(Internal, "could not find node for the child \"%s\" "+
-				"while listing \"%s\"", join(path, child), path)
+	children := d.Children
+	if after != "" {
+		children = children[sort.Search(len(children), func(i int) bool {
+			return children[i] > after
+		}):]
+	}
+	if limit > 0 && len(children) > limit {
+		children = children[:limit]
+	}
+	result := make([]*NodeProto, len(children))
+	for i, child := range children {
+		result[i], err = getNode(join(path, child))
+		if err != nil {
+			if Code(err) == PathNotFound {
+				return nil, errorf(Internal, "could not find node for the child \"%s\" "+
+					"while listing \"%s\"", join(path, child), path)
+			}
+			return nil, err
 		}
 	}
 	return result, nil
@@ -142,13 +237,32 @@ func (h *HashTreeProto) List(path string) ([]*NodeProto, error) {
 	return list(h.Fs, path)
 }
 
+// ListPage retrieves the children of the directory at 'path' whose names sort
+// after 'after', at most 'limit' of them if limit is greater than 0.
+func (h *HashTreeProto) ListPage(path string, after string, limit int) ([]*NodeProto, error) {
+	return listPage(h.Fs, path, after, limit)
+}
+
 func glob(fs map[string]*NodeProto, pattern string) ([]*NodeProto, error) {
+	return globPage(fs, pattern, "", 0)
+}
+
+// globPage returns the nodes that match 'pattern' whose paths sort after
+// 'after', ordered by path and at most 'limit' of them if limit is greater
+// than 0.
+func globPage(fs map[string]*NodeProto, pattern string, after string, limit int) ([]*NodeProto, error) {
 	// "*" should be an allowed pattern, but our paths always start with "/", so
 	// modify the pattern to fit our path structure.
 	pattern = clean(pattern)
 
+	if after != "" {
+		after = clean(after)
+	}
 	var res []*NodeProto
 	for path, node := range fs {
+		if after != "" && path <= after {
+			continue
+		}
 		matched, err := pathlib.Match(pattern, path)
 		if err != nil {
 			if err == pathlib.ErrBadPattern {
@@ -163,6 +277,12 @@ func glob(fs map[string]*NodeProto, pattern string) ([]*NodeProto, error) {
 			res = append(res, nodeCopy)
 		}
 	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
 	return res, nil
 }
 
@@ -172,6 +292,13 @@ func (h *HashTreeProto) Glob(pattern string) ([]*NodeProto, error) {
 	return glob(h.Fs, pattern)
 }
 
+// GlobPage returns the nodes that match 'pattern' whose paths sort after
+// 'after', ordered by path and at most 'limit' of them if limit is greater
+// than 0. Like Glob, the nodes have their 'Name' field set to their full paths.
+func (h *HashTreeProto) GlobPage(pattern string, after string, limit int) ([]*NodeProto, error) {
+	return globPage(h.Fs, pattern, after, limit)
+}
+
 func size(fs map[string]*NodeProto) int64 {
 	rootNode, ok := fs[clean("/")]
 	if !ok {
@@ -187,19 +314,33 @@ func (h *HashTreeProto) FSSize() int64 {
 
 func walk(fs map[string]*NodeProto, path string, f func(string, *NodeProto) error) error {
 	path = clean(path)
-	if node, ok := fs[path]; ok && node.FileNode != nil {
-		return f(path, node)
-	} else if !ok {
+	node, ok := fs[path]
+	if !ok {
 		return errorf(PathNotFound, "no node at \"%s\"", path)
 	}
-	for rangePath, node := range fs {
-		if rangePath == "" {
-			rangePath = "/"
-		}
-		if !strings.HasPrefix(rangePath, path) {
-			continue
+	return walkNode(fs, path, node, f)
+}
+
+// walkNode calls f on node, which is at path, and then on the nodes under it,
+// depth-first and in the order of their names.
+func walkNode(fs map[string]*NodeProto, path string, node *NodeProto, f func(string, *NodeProto) error) error {
+	externalPath := path
+	if externalPath == "" {
+		externalPath = "/"
+	}
+	if err := f(externalPath, node); err != nil {
+		return err
+	}
+	if node.DirNode == nil {
+		return nil
+	}
+	for _, child := range node.DirNode.Children {
+		childPath := join(path, child)
+		childNode, ok := fs[childPath]
+		if !ok {
+			return errorf(Internal, "could not find node for \"%s\" while walking \"%s\"", childPath, path)
 		}
-		if err := f(rangePath, node); err != nil {
+		if err := walkNode(fs, childPath, childNode, f); err != nil {
 			return err
 		}
 	}
@@ -226,7 +367,7 @@ func diff(new HashTree, old HashTree, newPath string, oldPath string, recursiveD
 	}
 	children := make(map[string]bool)
 	if newNode != nil {
-		if newNode.FileNode != nil || recursiveDepth == 0 {
+		if newNode.DirNode == nil || recursiveDepth == 0 {
 			if err := f(newPath, newNode, true); err != nil {
 				return err
 			}
@@ -237,7 +378,7 @@ func diff(new HashTree, old HashTree, newPath string, oldPath string, recursiveD
 		}
 	}
 	if oldNode != nil {
-		if oldNode.FileNode != nil || recursiveDepth == 0 {
+		if oldNode.DirNode == nil || recursiveDepth == 0 {
 			if err := f(oldPath, oldNode, false); err != nil {
 				return err
 			}
@@ -276,6 +417,10 @@ type hashtree struct {
 	// changed maps a path P to 'true' if P or one of its children has been
 	// modified in 'fs', and its hash needs to be updated.
 	changed map[string]bool
+
+	// err is set if the tree that this tree was opened from couldn't be read,
+	// and is returned by Finish.
+	err error
 }
 
 // Open returns the hashtree since it's already an OpenHashTree
@@ -294,12 +439,24 @@ func (h *hashtree) List(path string) ([]*NodeProto, error) {
 	return list(h.fs, path)
 }
 
+// ListPage retrieves the children of the directory at 'path' whose names sort
+// after 'after', at most 'limit' of them if limit is greater than 0.
+func (h *hashtree) ListPage(path string, after string, limit int) ([]*NodeProto, error) {
+	return listPage(h.fs, path, after, limit)
+}
+
 // Glob returns a list of files and directories that match 'pattern'.
 // The nodes returned have their 'Name' field set to their full paths.
 func (h *hashtree) Glob(pattern string) ([]*NodeProto, error) {
 	return glob(h.fs, pattern)
 }
 
+// GlobPage returns the nodes that match 'pattern' whose paths sort after
+// 'after', see HashTreeProto.GlobPage.
+func (h *hashtree) GlobPage(pattern string, after string, limit int) ([]*NodeProto, error) {
+	return globPage(h.fs, pattern, after, limit)
+}
+
 // FSSize returns the size of the file system that the hashtree represents.
 func (h *hashtree) FSSize() int64 {
 	return size(h.fs)
@@ -387,9 +544,13 @@ func (h *hashtree) canonicalize(path string) error {
 		for _, object := range n.FileNode.Objects {
 			hash.Write([]byte(object.Hash))
 		}
+	case symlink:
+		// Prefix the target so that a symlink's hash can't collide with a
+		// file's.
+		hash.Write([]byte(fmt.Sprintf("symlink:%s", n.SymlinkNode.Target)))
 	default:
 		return errorf(Internal,
-			"malformed node at \"%s\" is neither a file nor a directory", path)
+			"malformed node at \"%s\" is neither a file, a directory nor a symlink", path)
 	}
 
 	// Update hash of 'n'
@@ -452,7 +613,7 @@ func (h *hashtree) removeFromMap(path string) error {
 	}
 
 	switch n.nodetype() {
-	case file:
+	case file, symlink:
 		delete(h.fs, path)
 	case directory:
 		for _, child := range n.DirNode.Children {
@@ -463,7 +624,42 @@ func (h *hashtree) removeFromMap(path string) error {
 		delete(h.fs, path)
 	case unrecognized:
 		return errorf(Internal,
-			"malformed node at \"%s\": it's neither a file nor a directory", path)
+			"malformed node at \"%s\": it's neither a file, a directory nor a symlink", path)
+	}
+	return nil
+}
+
+// Verify implements OpenHashTree.Verify
+func (h *hashtree) Verify() error {
+	if h.err != nil {
+		return h.err
+	}
+	for path, node := range h.fs {
+		if node.DirNode != nil {
+			for _, child := range node.DirNode.Children {
+				if _, ok := h.fs[join(path, child)]; !ok {
+					return errorf(Internal, "directory \"%s\" lists \"%s\", "+
+						"which doesn't exist", path, child)
+				}
+			}
+		}
+		if path == "" {
+			continue // the root has no parent
+		}
+		parent, child := split(path)
+		parentNode, ok := h.fs[parent]
+		if !ok {
+			return errorf(Internal, "orphaned file \"%s\": its parent directory "+
+				"doesn't exist", path)
+		}
+		if parentNode.DirNode == nil {
+			return errorf(Internal, "node at \"%s\" is a file, but \"%s\" exists "+
+				"under it", parent, path)
+		}
+		idx := sort.SearchStrings(parentNode.DirNode.Children, child)
+		if idx == len(parentNode.DirNode.Children) || parentNode.DirNode.Children[idx] != child {
+			return errorf(Internal, "parent of \"%s\" does not contain it", path)
+		}
 	}
 	return nil
 }
@@ -471,6 +667,9 @@ func (h *hashtree) removeFromMap(path string) error {
 // Finish makes a deep copy of the OpenHashTree, updates all of the hashes in
 // the copy, and returns the copy
 func (h *hashtree) Finish() (HashTree, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
 	if err := h.canonicalize(""); err != nil {
 		return nil, err
 	}
@@ -520,6 +719,11 @@ func (h *hashtree) putFile(path string, objects []*pfs.Object, overwriteIndex *p
 	}
 	node.SubtreeSize += sizeDelta
 	node.FileNode.Objects = append(node.FileNode.Objects, objects...)
+	// The file's content changed, so any stats computed on ingest are stale
+	node.FileNode.Stats = nil
+	node.FileNode.RecordCount = 0
+	node.FileNode.HeaderLines = 0
+	node.FileNode.FooterLines = 0
 	h.changed[path] = true
 
 	// Add 'path' to parent (if it's new) & mark nodes as 'changed' back to root
@@ -538,6 +742,20 @@ func (h *hashtree) putFile(path string, objects []*pfs.Object, overwriteIndex *p
 	})
 }
 
+// PutFileNode implements OpenHashTree.PutFileNode
+func (h *hashtree) PutFileNode(path string, fileNode *FileNodeProto, size int64) error {
+	if node, ok := h.fs[clean(path)]; ok && node.nodetype() == file {
+		if err := h.DeleteFile(path); err != nil {
+			return err
+		}
+	}
+	if err := h.putFile(path, fileNode.Objects, nil, size); err != nil {
+		return err
+	}
+	h.fs[clean(path)].FileNode = proto.Clone(fileNode).(*FileNodeProto)
+	return nil
+}
+
 // PutDir creates a directory (or does nothing if one exists).
 func (h *hashtree) PutDir(path string) error {
 	path = clean(path)
@@ -577,7 +795,47 @@ func (h *hashtree) PutDir(path string) error {
 	})
 }
 
-// DeleteFile deletes a regular file or directory (along with its children).
+// PutSymlink creates a symlink at 'path' that points to 'target' (or changes
+// the target of the symlink that's already there).
+func (h *hashtree) PutSymlink(path string, target string) error {
+	path = clean(path)
+
+	// Detect any path conflicts before modifying 'h'
+	if err := h.visit(path, nop); err != nil {
+		return err
+	}
+
+	node, ok := h.fs[path]
+	if !ok {
+		node = &NodeProto{
+			Name:        base(path),
+			SymlinkNode: &SymlinkNodeProto{},
+		}
+		h.fs[path] = node
+	} else if node.nodetype() != symlink {
+		return errorf(PathConflict, "could not put symlink at \"%s\"; a node of "+
+			"type %s is already there", path, node.nodetype().tostring())
+	}
+	node.SymlinkNode.Target = target
+	h.changed[path] = true
+
+	// Add 'path' to parent (if it's new) & mark nodes as 'changed' back to root
+	return h.visit(path, func(node *NodeProto, parent, child string) error {
+		if node == nil {
+			node = &NodeProto{
+				Name:    base(parent),
+				DirNode: &DirectoryNodeProto{},
+			}
+			h.fs[parent] = node
+		}
+		insertStr(&node.DirNode.Children, child)
+		h.changed[parent] = true
+		return nil
+	})
+}
+
+// DeleteFile deletes a regular file, symlink or directory (along with its
+// children).
 func (h *hashtree) DeleteFile(path string) error {
 	path = clean(path)
 
@@ -623,10 +881,11 @@ func (h *hashtree) GetOpen(path string) (*OpenNode, error) {
 		return nil, errorf(PathNotFound, "no node at \"%s\"", path)
 	}
 	return &OpenNode{
-		Name:     np.Name,
-		Size:     np.SubtreeSize,
-		FileNode: np.FileNode,
-		DirNode:  np.DirNode,
+		Name:        np.Name,
+		Size:        np.SubtreeSize,
+		FileNode:    np.FileNode,
+		DirNode:     np.DirNode,
+		SymlinkNode: np.SymlinkNode,
 	}, nil
 }
 
@@ -684,14 +943,17 @@ func (h *hashtree) mergeNode(path string, srcs []HashTree) (int64, error) {
 				destNode.DirNode = &DirectoryNodeProto{}
 			} else if n.nodetype() == file {
 				destNode.FileNode = &FileNodeProto{}
+			} else if n.nodetype() == symlink {
+				destNode.SymlinkNode = &SymlinkNodeProto{}
 			} else {
 				return 0, errorf(Internal, "could not merge unrecognized node type at "+
-					"\"%s\", which is neither a file nore a directory", path)
+					"\"%s\", which is neither a file, a directory nor a symlink", path)
 			}
 			pathtype = n.nodetype()
 		} else if pathtype != n.nodetype() {
 			return sizeDelta, errorf(PathConflict, "could not merge path \"%s\" "+
-				"which is a file in some hashtrees and a directory in others", path)
+				"which is a %s in some hashtrees and a %s in others", path,
+				pathtype.tostring(), n.nodetype().tostring())
 		}
 		switch n.nodetype() {
 		case directory:
@@ -702,9 +964,33 @@ func (h *hashtree) mergeNode(path string, srcs []HashTree) (int64, error) {
 		case file:
 			// Append new objects, and update size of target node (since that can't be
 			// done in canonicalize)
+			if len(destNode.FileNode.Objects) == 0 {
+				destNode.FileNode.Stats = n.FileNode.Stats
+				destNode.FileNode.RecordCount = n.FileNode.RecordCount
+				destNode.FileNode.Delimiter = n.FileNode.Delimiter
+				destNode.FileNode.HeaderLines = n.FileNode.HeaderLines
+				destNode.FileNode.FooterLines = n.FileNode.FooterLines
+			} else {
+				destNode.FileNode.Stats = pfs.MergeTableStats(destNode.FileNode.Stats, n.FileNode.Stats)
+				// the records can only be counted if both sides were split
+				// the same way, with no header or footer lines between them
+				if destNode.FileNode.RecordCount > 0 && n.FileNode.RecordCount > 0 &&
+					destNode.FileNode.Delimiter == n.FileNode.Delimiter &&
+					destNode.FileNode.FooterLines == 0 && n.FileNode.HeaderLines == 0 {
+					destNode.FileNode.RecordCount += n.FileNode.RecordCount
+					destNode.FileNode.FooterLines = n.FileNode.FooterLines
+				} else {
+					destNode.FileNode.RecordCount = 0
+					destNode.FileNode.HeaderLines = 0
+					destNode.FileNode.FooterLines = 0
+				}
+			}
 			destNode.FileNode.Objects = append(destNode.FileNode.Objects,
 				n.FileNode.Objects...)
 			sizeDelta += n.SubtreeSize
+		case symlink:
+			// Symlinks can't be appended to, the last tree's target wins
+			destNode.SymlinkNode.Target = n.SymlinkNode.Target
 		default:
 			return sizeDelta, errorf(Internal, "malformed node at \"%s\" in source "+
 				"hashtree is neither a file nor a directory", path)
@@ -10,8 +10,10 @@
 	It has these top-level messages:
 		FileNodeProto
 		DirectoryNodeProto
+		SymlinkNodeProto
 		NodeProto
 		HashTreeProto
+		ChunkRef
 */
 package hashtree
 
@@ -38,6 +40,19 @@ type FileNodeProto struct {
 	// Object references an object in the object store which contains the content
 	// of the data.
 	Objects []*pfs.Object `protobuf:"bytes,4,rep,name=objects" json:"objects,omitempty"`
+	// Stats are the table statistics computed when the file was ingested with
+	// compute_stats set, nil otherwise.
+	Stats *pfs.TableStats `protobuf:"bytes,5,opt,name=stats" json:"stats,omitempty"`
+	// RecordCount is the number of records in the file if it was written by a
+	// split PutFile, which split the records with Delimiter. It's 0 if the
+	// number isn't known.
+	RecordCount int64         `protobuf:"varint,6,opt,name=record_count,json=recordCount,proto3" json:"record_count,omitempty"`
+	Delimiter   pfs.Delimiter `protobuf:"varint,7,opt,name=delimiter,proto3,enum=pfs.Delimiter" json:"delimiter,omitempty"`
+	// HeaderLines and FooterLines are the number of lines at the start and end
+	// of the file that were added to it by a split PutFile, they aren't counted
+	// in RecordCount.
+	HeaderLines int64 `protobuf:"varint,8,opt,name=header_lines,json=headerLines,proto3" json:"header_lines,omitempty"`
+	FooterLines int64 `protobuf:"varint,9,opt,name=footer_lines,json=footerLines,proto3" json:"footer_lines,omitempty"`
 }
 
 func (m *FileNodeProto) Reset()                    { *m = FileNodeProto{} }
@@ -52,6 +67,41 @@ func (m *FileNodeProto) GetObjects() []*pfs.Object {
 	return nil
 }
 
+func (m *FileNodeProto) GetStats() *pfs.TableStats {
+	if m != nil {
+		return m.Stats
+	}
+	return nil
+}
+
+func (m *FileNodeProto) GetRecordCount() int64 {
+	if m != nil {
+		return m.RecordCount
+	}
+	return 0
+}
+
+func (m *FileNodeProto) GetDelimiter() pfs.Delimiter {
+	if m != nil {
+		return m.Delimiter
+	}
+	return pfs.Delimiter_NONE
+}
+
+func (m *FileNodeProto) GetHeaderLines() int64 {
+	if m != nil {
+		return m.HeaderLines
+	}
+	return 0
+}
+
+func (m *FileNodeProto) GetFooterLines() int64 {
+	if m != nil {
+		return m.FooterLines
+	}
+	return 0
+}
+
 // DirectoryNodeProto is a node corresponding to a directory.
 type DirectoryNodeProto struct {
 	// Children of this directory. Note that paths are relative, so if "/foo/bar"
@@ -74,7 +124,28 @@ func (m *DirectoryNodeProto) GetChildren() []string {
 	return nil
 }
 
-// NodeProto is a node in the file tree (either a file or a directory)
+// SymlinkNodeProto is a node corresponding to a symlink (which is also a leaf
+// node).
+type SymlinkNodeProto struct {
+	// Target is the path that the symlink points to. An absolute target is a
+	// path in the same commit, a relative one is relative to the directory that
+	// contains the symlink.
+	Target string `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+}
+
+func (m *SymlinkNodeProto) Reset()                    { *m = SymlinkNodeProto{} }
+func (m *SymlinkNodeProto) String() string            { return proto.CompactTextString(m) }
+func (*SymlinkNodeProto) ProtoMessage()               {}
+func (*SymlinkNodeProto) Descriptor() ([]byte, []int) { return fileDescriptorHashtree, []int{2} }
+
+func (m *SymlinkNodeProto) GetTarget() string {
+	if m != nil {
+		return m.Target
+	}
+	return ""
+}
+
+// NodeProto is a node in the file tree (a file, a directory or a symlink)
 type NodeProto struct {
 	// Name is the name (not path) of the file/directory (e.g. /lib).
 	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
@@ -87,14 +158,15 @@ type NodeProto struct {
 	SubtreeSize int64 `protobuf:"varint,3,opt,name=subtree_size,json=subtreeSize,proto3" json:"subtree_size,omitempty"`
 	// Exactly one of the following fields must be set. The type of this node will
 	// be determined by which field is set.
-	FileNode *FileNodeProto      `protobuf:"bytes,4,opt,name=file_node,json=fileNode" json:"file_node,omitempty"`
-	DirNode  *DirectoryNodeProto `protobuf:"bytes,5,opt,name=dir_node,json=dirNode" json:"dir_node,omitempty"`
+	FileNode    *FileNodeProto      `protobuf:"bytes,4,opt,name=file_node,json=fileNode" json:"file_node,omitempty"`
+	DirNode     *DirectoryNodeProto `protobuf:"bytes,5,opt,name=dir_node,json=dirNode" json:"dir_node,omitempty"`
+	SymlinkNode *SymlinkNodeProto   `protobuf:"bytes,6,opt,name=symlink_node,json=symlinkNode" json:"symlink_node,omitempty"`
 }
 
 func (m *NodeProto) Reset()                    { *m = NodeProto{} }
 func (m *NodeProto) String() string            { return proto.CompactTextString(m) }
 func (*NodeProto) ProtoMessage()               {}
-func (*NodeProto) Descriptor() ([]byte, []int) { return fileDescriptorHashtree, []int{2} }
+func (*NodeProto) Descriptor() ([]byte, []int) { return fileDescriptorHashtree, []int{3} }
 
 func (m *NodeProto) GetName() string {
 	if m != nil {
@@ -131,6 +203,13 @@ func (m *NodeProto) GetDirNode() *DirectoryNodeProto {
 	return nil
 }
 
+func (m *NodeProto) GetSymlinkNode() *SymlinkNodeProto {
+	if m != nil {
+		return m.SymlinkNode
+	}
+	return nil
+}
+
 // HashTreeProto is a tree corresponding to the complete file contents of a
 // pachyderm repo at a given commit (based on a Merkle Tree). We store one
 // HashTree for every PFS commit.
@@ -147,12 +226,17 @@ type HashTreeProto struct {
 	// Note that the key must end in "/" if an only if the value has .dir_node set
 	// (i.e. iff the path points to a directory).
 	Fs map[string]*NodeProto `protobuf:"bytes,2,rep,name=fs" json:"fs,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value"`
+	// Chunks is set instead of Fs in trees serialized by SerializeChunked
+	// (version 2). Each chunk is a version 1 HashTreeProto holding a run of
+	// the tree's nodes, ordered by their parent directory and then by their
+	// name, so that the children of a directory are in consecutive chunks.
+	Chunks []*ChunkRef `protobuf:"bytes,3,rep,name=chunks" json:"chunks,omitempty"`
 }
 
 func (m *HashTreeProto) Reset()                    { *m = HashTreeProto{} }
 func (m *HashTreeProto) String() string            { return proto.CompactTextString(m) }
 func (*HashTreeProto) ProtoMessage()               {}
-func (*HashTreeProto) Descriptor() ([]byte, []int) { return fileDescriptorHashtree, []int{3} }
+func (*HashTreeProto) Descriptor() ([]byte, []int) { return fileDescriptorHashtree, []int{4} }
 
 func (m *HashTreeProto) GetVersion() int32 {
 	if m != nil {
@@ -168,11 +252,46 @@ func (m *HashTreeProto) GetFs() map[string]*NodeProto {
 	return nil
 }
 
+func (m *HashTreeProto) GetChunks() []*ChunkRef {
+	if m != nil {
+		return m.Chunks
+	}
+	return nil
+}
+
+// ChunkRef references a chunk of a chunked HashTreeProto.
+type ChunkRef struct {
+	// FirstPath is the path of the chunk's first node.
+	FirstPath string      `protobuf:"bytes,1,opt,name=first_path,json=firstPath,proto3" json:"first_path,omitempty"`
+	Object    *pfs.Object `protobuf:"bytes,2,opt,name=object" json:"object,omitempty"`
+}
+
+func (m *ChunkRef) Reset()                    { *m = ChunkRef{} }
+func (m *ChunkRef) String() string            { return proto.CompactTextString(m) }
+func (*ChunkRef) ProtoMessage()               {}
+func (*ChunkRef) Descriptor() ([]byte, []int) { return fileDescriptorHashtree, []int{5} }
+
+func (m *ChunkRef) GetFirstPath() string {
+	if m != nil {
+		return m.FirstPath
+	}
+	return ""
+}
+
+func (m *ChunkRef) GetObject() *pfs.Object {
+	if m != nil {
+		return m.Object
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*FileNodeProto)(nil), "FileNodeProto")
 	proto.RegisterType((*DirectoryNodeProto)(nil), "DirectoryNodeProto")
+	proto.RegisterType((*SymlinkNodeProto)(nil), "SymlinkNodeProto")
 	proto.RegisterType((*NodeProto)(nil), "NodeProto")
 	proto.RegisterType((*HashTreeProto)(nil), "HashTreeProto")
+	proto.RegisterType((*ChunkRef)(nil), "ChunkRef")
 }
 func (m *FileNodeProto) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
@@ -201,6 +320,36 @@ func (m *FileNodeProto) MarshalTo(dAtA []byte) (int, error) {
 			i += n
 		}
 	}
+	if m.Stats != nil {
+		dAtA[i] = 0x2a
+		i++
+		i = encodeVarintHashtree(dAtA, i, uint64(m.Stats.Size()))
+		n1, err := m.Stats.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n1
+	}
+	if m.RecordCount != 0 {
+		dAtA[i] = 0x30
+		i++
+		i = encodeVarintHashtree(dAtA, i, uint64(m.RecordCount))
+	}
+	if m.Delimiter != 0 {
+		dAtA[i] = 0x38
+		i++
+		i = encodeVarintHashtree(dAtA, i, uint64(m.Delimiter))
+	}
+	if m.HeaderLines != 0 {
+		dAtA[i] = 0x40
+		i++
+		i = encodeVarintHashtree(dAtA, i, uint64(m.HeaderLines))
+	}
+	if m.FooterLines != 0 {
+		dAtA[i] = 0x48
+		i++
+		i = encodeVarintHashtree(dAtA, i, uint64(m.FooterLines))
+	}
 	return i, nil
 }
 
@@ -237,6 +386,30 @@ func (m *DirectoryNodeProto) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
+func (m *SymlinkNodeProto) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SymlinkNodeProto) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Target) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintHashtree(dAtA, i, uint64(len(m.Target)))
+		i += copy(dAtA[i:], m.Target)
+	}
+	return i, nil
+}
+
 func (m *NodeProto) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -273,21 +446,31 @@ func (m *NodeProto) MarshalTo(dAtA []byte) (int, error) {
 		dAtA[i] = 0x22
 		i++
 		i = encodeVarintHashtree(dAtA, i, uint64(m.FileNode.Size()))
-		n1, err := m.FileNode.MarshalTo(dAtA[i:])
+		n2, err := m.FileNode.MarshalTo(dAtA[i:])
 		if err != nil {
 			return 0, err
 		}
-		i += n1
+		i += n2
 	}
 	if m.DirNode != nil {
 		dAtA[i] = 0x2a
 		i++
 		i = encodeVarintHashtree(dAtA, i, uint64(m.DirNode.Size()))
-		n2, err := m.DirNode.MarshalTo(dAtA[i:])
+		n3, err := m.DirNode.MarshalTo(dAtA[i:])
 		if err != nil {
 			return 0, err
 		}
-		i += n2
+		i += n3
+	}
+	if m.SymlinkNode != nil {
+		dAtA[i] = 0x32
+		i++
+		i = encodeVarintHashtree(dAtA, i, uint64(m.SymlinkNode.Size()))
+		n4, err := m.SymlinkNode.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n4
 	}
 	return i, nil
 }
@@ -332,13 +515,59 @@ func (m *HashTreeProto) MarshalTo(dAtA []byte) (int, error) {
 				dAtA[i] = 0x12
 				i++
 				i = encodeVarintHashtree(dAtA, i, uint64(v.Size()))
-				n3, err := v.MarshalTo(dAtA[i:])
+				n5, err := v.MarshalTo(dAtA[i:])
 				if err != nil {
 					return 0, err
 				}
-				i += n3
+				i += n5
+			}
+		}
+	}
+	if len(m.Chunks) > 0 {
+		for _, msg := range m.Chunks {
+			dAtA[i] = 0x1a
+			i++
+			i = encodeVarintHashtree(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
 			}
+			i += n
+		}
+	}
+	return i, nil
+}
+
+func (m *ChunkRef) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ChunkRef) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.FirstPath) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintHashtree(dAtA, i, uint64(len(m.FirstPath)))
+		i += copy(dAtA[i:], m.FirstPath)
+	}
+	if m.Object != nil {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintHashtree(dAtA, i, uint64(m.Object.Size()))
+		n6, err := m.Object.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
 		}
+		i += n6
 	}
 	return i, nil
 }
@@ -379,6 +608,22 @@ func (m *FileNodeProto) Size() (n int) {
 			n += 1 + l + sovHashtree(uint64(l))
 		}
 	}
+	if m.Stats != nil {
+		l = m.Stats.Size()
+		n += 1 + l + sovHashtree(uint64(l))
+	}
+	if m.RecordCount != 0 {
+		n += 1 + sovHashtree(uint64(m.RecordCount))
+	}
+	if m.Delimiter != 0 {
+		n += 1 + sovHashtree(uint64(m.Delimiter))
+	}
+	if m.HeaderLines != 0 {
+		n += 1 + sovHashtree(uint64(m.HeaderLines))
+	}
+	if m.FooterLines != 0 {
+		n += 1 + sovHashtree(uint64(m.FooterLines))
+	}
 	return n
 }
 
@@ -394,6 +639,16 @@ func (m *DirectoryNodeProto) Size() (n int) {
 	return n
 }
 
+func (m *SymlinkNodeProto) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Target)
+	if l > 0 {
+		n += 1 + l + sovHashtree(uint64(l))
+	}
+	return n
+}
+
 func (m *NodeProto) Size() (n int) {
 	var l int
 	_ = l
@@ -416,6 +671,10 @@ func (m *NodeProto) Size() (n int) {
 		l = m.DirNode.Size()
 		n += 1 + l + sovHashtree(uint64(l))
 	}
+	if m.SymlinkNode != nil {
+		l = m.SymlinkNode.Size()
+		n += 1 + l + sovHashtree(uint64(l))
+	}
 	return n
 }
 
@@ -438,6 +697,26 @@ func (m *HashTreeProto) Size() (n int) {
 			n += mapEntrySize + 1 + sovHashtree(uint64(mapEntrySize))
 		}
 	}
+	if len(m.Chunks) > 0 {
+		for _, e := range m.Chunks {
+			l = e.Size()
+			n += 1 + l + sovHashtree(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ChunkRef) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.FirstPath)
+	if l > 0 {
+		n += 1 + l + sovHashtree(uint64(l))
+	}
+	if m.Object != nil {
+		l = m.Object.Size()
+		n += 1 + l + sovHashtree(uint64(l))
+	}
 	return n
 }
 
@@ -514,6 +793,115 @@ func (m *FileNodeProto) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Stats", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHashtree
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthHashtree
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Stats == nil {
+				m.Stats = &pfs.TableStats{}
+			}
+			if err := m.Stats.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RecordCount", wireType)
+			}
+			m.RecordCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHashtree
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.RecordCount |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Delimiter", wireType)
+			}
+			m.Delimiter = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHashtree
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Delimiter |= (pfs.Delimiter(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HeaderLines", wireType)
+			}
+			m.HeaderLines = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHashtree
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.HeaderLines |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FooterLines", wireType)
+			}
+			m.FooterLines = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHashtree
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FooterLines |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipHashtree(dAtA[iNdEx:])
@@ -614,6 +1002,85 @@ func (m *DirectoryNodeProto) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *SymlinkNodeProto) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowHashtree
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SymlinkNodeProto: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SymlinkNodeProto: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Target", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHashtree
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthHashtree
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Target = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipHashtree(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthHashtree
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func (m *NodeProto) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
@@ -788,6 +1255,39 @@ func (m *NodeProto) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SymlinkNode", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHashtree
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthHashtree
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.SymlinkNode == nil {
+				m.SymlinkNode = &SymlinkNodeProto{}
+			}
+			if err := m.SymlinkNode.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipHashtree(dAtA[iNdEx:])
@@ -978,6 +1478,149 @@ func (m *HashTreeProto) Unmarshal(dAtA []byte) error {
 				m.Fs[mapkey] = mapvalue
 			}
 			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Chunks", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHashtree
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthHashtree
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Chunks = append(m.Chunks, &ChunkRef{})
+			if err := m.Chunks[len(m.Chunks)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipHashtree(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthHashtree
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ChunkRef) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowHashtree
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ChunkRef: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ChunkRef: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FirstPath", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHashtree
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthHashtree
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.FirstPath = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Object", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHashtree
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthHashtree
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Object == nil {
+				m.Object = &pfs.Object{}
+			}
+			if err := m.Object.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipHashtree(dAtA[iNdEx:])
@@ -1107,28 +1750,40 @@ var (
 func init() { proto.RegisterFile("server/pkg/hashtree/hashtree.proto", fileDescriptorHashtree) }
 
 var fileDescriptorHashtree = []byte{
-	// 367 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x64, 0x51, 0xc1, 0xaa, 0xd3, 0x40,
-	0x14, 0x75, 0x92, 0xd6, 0x34, 0x37, 0xad, 0x94, 0x51, 0x64, 0xe8, 0x22, 0xc4, 0x80, 0x12, 0x10,
-	0xa6, 0x52, 0x41, 0xc4, 0x9d, 0xa2, 0xc5, 0x95, 0xca, 0xe8, 0xbe, 0xa4, 0xc9, 0x8d, 0x19, 0x1b,
-	0x93, 0x32, 0x93, 0x16, 0xda, 0xef, 0x70, 0xe1, 0x7f, 0xf8, 0x13, 0x2e, 0xfd, 0x04, 0xe9, 0xfb,
-	0x91, 0xc7, 0x4c, 0xd3, 0x96, 0xf2, 0x16, 0x03, 0xe7, 0x9c, 0x7b, 0x2e, 0x9c, 0x7b, 0x06, 0x62,
-	0x8d, 0x6a, 0x8b, 0x6a, 0xba, 0x5e, 0x7d, 0x9f, 0x96, 0xa9, 0x2e, 0x5b, 0x85, 0x78, 0x06, 0x7c,
-	0xad, 0x9a, 0xb6, 0x99, 0x3c, 0xca, 0x2a, 0x89, 0x75, 0x3b, 0x5d, 0x17, 0xda, 0xbc, 0xa3, 0x1a,
-	0xbf, 0x82, 0xd1, 0x5c, 0x56, 0xf8, 0xa9, 0xc9, 0xf1, 0x8b, 0x11, 0xe8, 0x53, 0xf0, 0x9a, 0xe5,
-	0x0f, 0xcc, 0x5a, 0xcd, 0x7a, 0x91, 0x9b, 0x04, 0xb3, 0x80, 0x1b, 0xf7, 0x67, 0xab, 0x89, 0xd3,
-	0x2c, 0x7e, 0x01, 0xf4, 0xbd, 0x54, 0x98, 0xb5, 0x8d, 0xda, 0x5d, 0x96, 0x27, 0x30, 0xc8, 0x4a,
-	0x59, 0xe5, 0x0a, 0x6b, 0xe6, 0x46, 0x6e, 0xe2, 0x8b, 0x33, 0x8f, 0xff, 0x10, 0xf0, 0x2f, 0x4e,
-	0x0a, 0xbd, 0x3a, 0xfd, 0x89, 0x8c, 0x44, 0x24, 0xf1, 0x85, 0xc5, 0x46, 0x33, 0x99, 0x99, 0x13,
-	0x91, 0x64, 0x28, 0x2c, 0xa6, 0x4f, 0x60, 0xa8, 0x37, 0x4b, 0x73, 0xc6, 0x42, 0xcb, 0x3d, 0x32,
-	0x37, 0x22, 0x89, 0x2b, 0x82, 0x4e, 0xfb, 0x2a, 0xf7, 0x48, 0x9f, 0x83, 0x5f, 0xc8, 0x0a, 0x17,
-	0x75, 0x93, 0x23, 0xeb, 0x45, 0x24, 0x09, 0x66, 0x0f, 0xf8, 0xd5, 0x51, 0x62, 0x50, 0x74, 0x94,
-	0x72, 0x18, 0xe4, 0x52, 0x1d, 0xbd, 0x7d, 0xeb, 0x7d, 0xc8, 0xef, 0x1e, 0x22, 0xbc, 0x5c, 0x2a,
-	0xc3, 0xe2, 0x5f, 0x04, 0x46, 0x1f, 0x53, 0x5d, 0x7e, 0x53, 0xd8, 0x25, 0x67, 0xe0, 0x6d, 0x51,
-	0x69, 0xd9, 0xd4, 0x36, 0x7c, 0x5f, 0x9c, 0x28, 0x7d, 0x06, 0x4e, 0xa1, 0x99, 0x63, 0x5b, 0x7b,
-	0xcc, 0xaf, 0xb6, 0xf8, 0x5c, 0x7f, 0xa8, 0x5b, 0xb5, 0x13, 0x4e, 0xa1, 0x27, 0x6f, 0xc1, 0xeb,
-	0x28, 0x1d, 0x83, 0xbb, 0xc2, 0x5d, 0xd7, 0x82, 0x81, 0x34, 0x82, 0xfe, 0x36, 0xad, 0x36, 0x68,
-	0x5b, 0x08, 0x66, 0xc0, 0x2f, 0xa1, 0x8e, 0x83, 0x37, 0xce, 0x6b, 0xf2, 0x6e, 0xfc, 0xf7, 0x10,
-	0x92, 0x7f, 0x87, 0x90, 0xfc, 0x3f, 0x84, 0xe4, 0xf7, 0x4d, 0x78, 0x6f, 0x79, 0xdf, 0xfe, 0xe7,
-	0xcb, 0xdb, 0x00, 0x00, 0x00, 0xff, 0xff, 0xba, 0x83, 0xf2, 0x7a, 0x0b, 0x02, 0x00, 0x00,
+	// 558 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x64, 0x93, 0xcd, 0x6e, 0xd3, 0x40,
+	0x10, 0xc7, 0xd9, 0x7c, 0x7b, 0xdc, 0x96, 0xb0, 0xa0, 0x6a, 0x55, 0x89, 0xc8, 0x18, 0x15, 0x59,
+	0x80, 0x5c, 0x14, 0x38, 0x20, 0x6e, 0xd0, 0x52, 0x21, 0x84, 0x4a, 0xb5, 0xed, 0x3d, 0x72, 0xec,
+	0x71, 0xbd, 0xc4, 0xb5, 0xa3, 0xdd, 0x4d, 0xa5, 0xf4, 0x49, 0x78, 0x13, 0x5e, 0x81, 0x23, 0x8f,
+	0x80, 0xca, 0x89, 0x57, 0xe0, 0x84, 0x76, 0xd7, 0x69, 0x14, 0x38, 0x58, 0x9a, 0xf9, 0xcd, 0x7f,
+	0x46, 0xf3, 0xe1, 0x85, 0x50, 0xa1, 0xbc, 0x42, 0x79, 0x30, 0x9f, 0x5d, 0x1c, 0x14, 0x89, 0x2a,
+	0xb4, 0x44, 0xbc, 0x35, 0xe2, 0xb9, 0xac, 0x75, 0xbd, 0xf7, 0x20, 0x2d, 0x05, 0x56, 0xfa, 0x60,
+	0x9e, 0x2b, 0xf3, 0x39, 0x1a, 0xfe, 0x21, 0xb0, 0x7d, 0x2c, 0x4a, 0x3c, 0xa9, 0x33, 0x3c, 0x35,
+	0x84, 0xee, 0x43, 0xbf, 0x9e, 0x7e, 0xc1, 0x54, 0x2b, 0xd6, 0x09, 0xda, 0x91, 0x3f, 0xf6, 0x63,
+	0x23, 0xff, 0x6c, 0x19, 0x5f, 0xc5, 0xe8, 0x3e, 0x74, 0x95, 0x4e, 0xb4, 0x62, 0xdd, 0x80, 0x44,
+	0xfe, 0xf8, 0xae, 0x15, 0x9d, 0x27, 0xd3, 0x12, 0xcf, 0x0c, 0xe6, 0x2e, 0x4a, 0x1f, 0xc1, 0x96,
+	0xc4, 0xb4, 0x96, 0xd9, 0x24, 0xad, 0x17, 0x95, 0x66, 0xbd, 0x80, 0x44, 0x6d, 0xee, 0x3b, 0x76,
+	0x68, 0x10, 0x7d, 0x0e, 0x5e, 0x86, 0xa5, 0xb8, 0x14, 0x1a, 0x25, 0xeb, 0x07, 0x24, 0xda, 0x19,
+	0xef, 0xd8, 0x6a, 0x47, 0x2b, 0xca, 0xd7, 0x02, 0x53, 0xb0, 0xc0, 0x24, 0x43, 0x39, 0x29, 0x45,
+	0x85, 0x8a, 0x0d, 0x5c, 0x41, 0xc7, 0x3e, 0x19, 0x64, 0x24, 0x79, 0x5d, 0xeb, 0x5b, 0x89, 0xe7,
+	0x24, 0x8e, 0x59, 0xc9, 0xc7, 0xce, 0x00, 0x86, 0x7e, 0xf8, 0x02, 0xe8, 0x91, 0x90, 0x98, 0xea,
+	0x5a, 0x2e, 0xd7, 0x0b, 0xd8, 0x83, 0x41, 0x5a, 0x88, 0x32, 0x93, 0x58, 0xb1, 0x76, 0xd0, 0x8e,
+	0x3c, 0x7e, 0xeb, 0x87, 0x4f, 0x61, 0x78, 0xb6, 0xbc, 0x2c, 0x45, 0x35, 0x5b, 0xeb, 0x77, 0xa1,
+	0xa7, 0x13, 0x79, 0x81, 0x9a, 0x91, 0x80, 0x44, 0x1e, 0x6f, 0xbc, 0xf0, 0x37, 0x01, 0x6f, 0xad,
+	0xa2, 0xd0, 0xa9, 0x92, 0x4b, 0x6c, 0x34, 0xd6, 0x36, 0xcc, 0x1c, 0x89, 0xb5, 0x02, 0x12, 0x6d,
+	0x71, 0x6b, 0x9b, 0xe6, 0xd5, 0x62, 0x6a, 0xee, 0x36, 0x51, 0xe2, 0x1a, 0x59, 0xdb, 0x35, 0xdf,
+	0xb0, 0x33, 0x71, 0x8d, 0xf4, 0x19, 0x78, 0xb9, 0x28, 0x71, 0x52, 0xd5, 0x19, 0xb2, 0x8e, 0x5d,
+	0xff, 0x4e, 0xbc, 0x71, 0x44, 0x3e, 0xc8, 0x1b, 0x97, 0xc6, 0x30, 0xc8, 0x84, 0x74, 0x5a, 0x77,
+	0xaa, 0xfb, 0xf1, 0xff, 0x43, 0xf3, 0x7e, 0x26, 0xa4, 0xd5, 0xbf, 0x82, 0x2d, 0xe5, 0x26, 0x74,
+	0x39, 0x3d, 0x9b, 0x73, 0x2f, 0xfe, 0x77, 0x6c, 0xee, 0xab, 0x35, 0x09, 0xbf, 0x11, 0xd8, 0xfe,
+	0x90, 0xa8, 0xe2, 0x5c, 0x62, 0x33, 0x2f, 0x83, 0xfe, 0x15, 0x4a, 0x25, 0xea, 0xca, 0x8e, 0xdc,
+	0xe5, 0x2b, 0x97, 0x3e, 0x81, 0x56, 0xae, 0x58, 0xcb, 0xfe, 0x5b, 0xbb, 0xf1, 0x46, 0x56, 0x7c,
+	0xac, 0xde, 0x57, 0x5a, 0x2e, 0x79, 0x2b, 0x37, 0x67, 0xec, 0xa5, 0xc5, 0xa2, 0x9a, 0x29, 0x7b,
+	0x05, 0x7f, 0xec, 0xc5, 0x87, 0xc6, 0xe5, 0x98, 0xf3, 0x26, 0xb0, 0xf7, 0x16, 0xfa, 0x4d, 0x06,
+	0x1d, 0x42, 0x7b, 0x86, 0xcb, 0x66, 0xbd, 0xc6, 0xa4, 0x01, 0x74, 0xaf, 0x92, 0x72, 0x81, 0x76,
+	0xbd, 0xfe, 0x18, 0xe2, 0x75, 0xef, 0x2e, 0xf0, 0xa6, 0xf5, 0x9a, 0x84, 0x27, 0x30, 0x58, 0x95,
+	0xa5, 0x0f, 0x01, 0x72, 0x21, 0x95, 0x9e, 0xcc, 0x13, 0x5d, 0x34, 0xa5, 0x3c, 0x4b, 0x4e, 0x13,
+	0x5d, 0xd0, 0xc7, 0xd0, 0x73, 0x7f, 0x7f, 0x53, 0x71, 0xe3, 0x61, 0x34, 0xa1, 0x77, 0xc3, 0xef,
+	0x37, 0x23, 0xf2, 0xe3, 0x66, 0x44, 0x7e, 0xde, 0x8c, 0xc8, 0xd7, 0x5f, 0xa3, 0x3b, 0xd3, 0x9e,
+	0x7d, 0x69, 0x2f, 0xff, 0x0e, 0x00, 0x65, 0x0b, 0xf5, 0x9d, 0xa5, 0x03, 0x00, 0x00,
 }
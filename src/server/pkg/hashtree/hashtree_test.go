@@ -5,11 +5,14 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"runtime"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/pachyderm/pachyderm/src/client/pfs"
 	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"golang.org/x/sync/errgroup"
 )
 
 // obj parses a string as an Object
@@ -150,6 +153,27 @@ func TestPutFileBasic(t *testing.T) {
 	require.Equal(t, int64(2), h2.Fs["/foo"].SubtreeSize)
 }
 
+func TestPutFileNode(t *testing.T) {
+	h := NewHashTree()
+	require.NoError(t, h.PutFile("/foo", obj(`hash:"20c27"`), 1))
+	fileNode := &FileNodeProto{
+		Objects:     obj(`hash:"ebc57"`),
+		RecordCount: 2,
+		Delimiter:   pfs.Delimiter_LINE,
+	}
+	// the file that's there is replaced, and the node's metadata is kept
+	require.NoError(t, h.PutFileNode("/foo", fileNode, 2))
+	h1 := finish(t, h)
+	require.Equal(t, int64(2), h1.Fs["/foo"].SubtreeSize)
+	require.Equal(t, int64(2), h1.Fs[""].SubtreeSize)
+	require.Equal(t, 1, len(h1.Fs["/foo"].FileNode.Objects))
+	require.Equal(t, "ebc57", h1.Fs["/foo"].FileNode.Objects[0].Hash)
+	require.Equal(t, int64(2), h1.Fs["/foo"].FileNode.RecordCount)
+	// a directory isn't replaced
+	require.NoError(t, h.PutFile("/dir/bar", obj(`hash:"8e02c"`), 1))
+	require.YesError(t, h.PutFileNode("/dir", fileNode, 2))
+}
+
 func TestPutDirBasic(t *testing.T) {
 	h := NewHashTree().(*hashtree)
 	emptySha := sha256.Sum256([]byte{})
@@ -249,6 +273,34 @@ func TestDeleteDirError(t *testing.T) {
 	require.Equal(t, 1, len(h.fs))
 }
 
+func TestVerify(t *testing.T) {
+	h := NewHashTree()
+	require.NoError(t, h.PutFile("/foo/bar", obj(`hash:"20c27"`), 1))
+	require.NoError(t, h.PutFile("/foo/baz/buzz", obj(`hash:"ebc57"`), 1))
+	require.NoError(t, h.Verify())
+
+	// deleting a directory leaves nothing under it
+	require.NoError(t, h.DeleteFile("/foo"))
+	require.NoError(t, h.Verify())
+	_, err := h.Get("/foo/bar")
+	require.Equal(t, PathNotFound, Code(err))
+
+	// a file that survives its directory is caught
+	require.NoError(t, h.PutFile("/foo/bar", obj(`hash:"20c27"`), 1))
+	delete(h.(*hashtree).fs, "/foo")
+	err = h.Verify()
+	require.YesError(t, err)
+	require.Equal(t, Internal, Code(err))
+
+	// and so is a directory that lists a child that doesn't exist
+	h = NewHashTree()
+	require.NoError(t, h.PutFile("/foo/bar", obj(`hash:"20c27"`), 1))
+	delete(h.(*hashtree).fs, "/foo/bar")
+	err = h.Verify()
+	require.YesError(t, err)
+	require.Equal(t, Internal, Code(err))
+}
+
 // Given a directory D, test that adding and then deleting a file/directory to
 // D does not change D.
 func TestAddDeleteReverts(t *testing.T) {
@@ -468,6 +520,51 @@ func TestGlobFile(t *testing.T) {
 	}
 }
 
+func TestPagination(t *testing.T) {
+	hTmp := NewHashTree()
+	for _, name := range []string{"e", "b", "d", "a", "c"} {
+		require.NoError(t, hTmp.PutFile("/dir/"+name, obj(`hash:"20c27"`), 1))
+	}
+	require.NoError(t, hTmp.PutFile("/foo", obj(`hash:"ebc57"`), 1))
+	h, err := hTmp.Finish()
+	require.NoError(t, err)
+
+	names := func(nodes []*NodeProto) []string {
+		var result []string
+		for _, node := range nodes {
+			result = append(result, node.Name)
+		}
+		return result
+	}
+
+	nodes, err := h.ListPage("/dir", "", 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, names(nodes))
+	nodes, err = h.ListPage("/dir", "b", 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"c", "d"}, names(nodes))
+	nodes, err = h.ListPage("/dir", "d", 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"e"}, names(nodes))
+	nodes, err = h.ListPage("/dir", "e", 2)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(nodes))
+	// the token doesn't have to be the name of a child
+	nodes, err = h.ListPage("/dir", "bb", 0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"c", "d", "e"}, names(nodes))
+
+	nodes, err = h.GlobPage("/*/*", "", 3)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/dir/a", "/dir/b", "/dir/c"}, names(nodes))
+	nodes, err = h.GlobPage("/*/*", "/dir/c", 3)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/dir/d", "/dir/e"}, names(nodes))
+	nodes, err = h.GlobPage("*", "dir", 0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/foo"}, names(nodes))
+}
+
 func TestMerge(t *testing.T) {
 	lTmp, rTmp := NewHashTree(), NewHashTree()
 	lTmp.PutFile("/foo-left", obj(`hash:"20c27"`), 1)
@@ -527,6 +624,87 @@ func TestMergeEmpty(t *testing.T) {
 	requireSame(t, expected, finish(t, r))
 }
 
+// Test that Merge() adds up the record counts of files that were split the
+// same way
+func TestMergeRecordCounts(t *testing.T) {
+	setRecords := func(h OpenHashTree, path string, count int64, delimiter pfs.Delimiter) {
+		node, err := h.GetOpen(path)
+		require.NoError(t, err)
+		node.FileNode.RecordCount = count
+		node.FileNode.Delimiter = delimiter
+	}
+	lTmp, rTmp := NewHashTree(), NewHashTree()
+	require.NoError(t, lTmp.PutFile("/same", obj(`hash:"20c27"`), 1))
+	setRecords(lTmp, "/same", 2, pfs.Delimiter_LINE)
+	require.NoError(t, lTmp.PutFile("/different", obj(`hash:"ebc57"`), 1))
+	setRecords(lTmp, "/different", 2, pfs.Delimiter_LINE)
+	require.NoError(t, rTmp.PutFile("/same", obj(`hash:"8e02c"`), 1))
+	setRecords(rTmp, "/same", 3, pfs.Delimiter_LINE)
+	require.NoError(t, rTmp.PutFile("/different", obj(`hash:"9d432"`), 1))
+	setRecords(rTmp, "/different", 3, pfs.Delimiter_JSON)
+
+	h := NewHashTree()
+	require.NoError(t, h.Merge(finish(t, lTmp), finish(t, rTmp)))
+	merged := finish(t, h)
+	node, err := merged.Get("/same")
+	require.NoError(t, err)
+	require.Equal(t, int64(5), node.FileNode.RecordCount)
+	node, err = merged.Get("/different")
+	require.NoError(t, err)
+	require.Equal(t, int64(0), node.FileNode.RecordCount)
+
+	// appending to a file makes its record count unknown
+	h = merged.Open()
+	require.NoError(t, h.PutFile("/same", obj(`hash:"c3c23"`), 1))
+	node, err = finish(t, h).Get("/same")
+	require.NoError(t, err)
+	require.Equal(t, int64(0), node.FileNode.RecordCount)
+}
+
+// Test that Merge() only adds up the record counts of files split with header
+// or footer lines if no header or footer ends up between their records
+func TestMergeSplitFrames(t *testing.T) {
+	setRecords := func(h OpenHashTree, path string, count int64, headerLines int64, footerLines int64) {
+		node, err := h.GetOpen(path)
+		require.NoError(t, err)
+		node.FileNode.RecordCount = count
+		node.FileNode.Delimiter = pfs.Delimiter_LINE
+		node.FileNode.HeaderLines = headerLines
+		node.FileNode.FooterLines = footerLines
+	}
+	lTmp, rTmp := NewHashTree(), NewHashTree()
+	require.NoError(t, lTmp.PutFile("/header", obj(`hash:"20c27"`), 1))
+	setRecords(lTmp, "/header", 2, 1, 0)
+	require.NoError(t, lTmp.PutFile("/footer", obj(`hash:"ebc57"`), 1))
+	setRecords(lTmp, "/footer", 2, 0, 1)
+	require.NoError(t, rTmp.PutFile("/header", obj(`hash:"8e02c"`), 1))
+	setRecords(rTmp, "/header", 3, 0, 1)
+	require.NoError(t, rTmp.PutFile("/footer", obj(`hash:"9d432"`), 1))
+	setRecords(rTmp, "/footer", 3, 0, 0)
+
+	h := NewHashTree()
+	require.NoError(t, h.Merge(finish(t, lTmp), finish(t, rTmp)))
+	merged := finish(t, h)
+	node, err := merged.Get("/header")
+	require.NoError(t, err)
+	require.Equal(t, int64(5), node.FileNode.RecordCount)
+	require.Equal(t, int64(1), node.FileNode.HeaderLines)
+	require.Equal(t, int64(1), node.FileNode.FooterLines)
+	node, err = merged.Get("/footer")
+	require.NoError(t, err)
+	require.Equal(t, int64(0), node.FileNode.RecordCount)
+	require.Equal(t, int64(0), node.FileNode.FooterLines)
+
+	// appending to a file drops its header and footer lines along with its
+	// record count
+	h = merged.Open()
+	require.NoError(t, h.PutFile("/header", obj(`hash:"c3c23"`), 1))
+	node, err = finish(t, h).Get("/header")
+	require.NoError(t, err)
+	require.Equal(t, int64(0), node.FileNode.HeaderLines)
+	require.Equal(t, int64(0), node.FileNode.FooterLines)
+}
+
 // Test that Walk() works
 func TestWalk(t *testing.T) {
 	tmp := NewHashTree()
@@ -549,6 +727,87 @@ func TestWalk(t *testing.T) {
 	require.Equal(t, 0, len(expectedPaths))
 }
 
+// Test that Walk() doesn't visit siblings whose names start with the path
+func TestWalkSiblingPrefix(t *testing.T) {
+	tmp := NewHashTree()
+	tmp.PutFile("/dir/foo", obj(`hash:"20c27"`), 1)
+	tmp.PutFile("/dir2/bar", obj(`hash:"ebc57"`), 1)
+	tree, err := tmp.Finish()
+	require.NoError(t, err)
+
+	expectedPaths := map[string]bool{
+		"/dir":     true,
+		"/dir/foo": true,
+	}
+	require.NoError(t, tree.Walk("/dir", func(path string, node *NodeProto) error {
+		require.True(t, expectedPaths[path])
+		delete(expectedPaths, path)
+		return nil
+	}))
+	require.Equal(t, 0, len(expectedPaths))
+}
+
+// Test that Walk() visits directories before their children, and children in
+// the order of their names
+func TestWalkOrder(t *testing.T) {
+	tmp := NewHashTree()
+	tmp.PutFile("/b", obj(`hash:"20c27"`), 1)
+	tmp.PutFile("/a/d", obj(`hash:"ebc57"`), 1)
+	tmp.PutFile("/a/c/e", obj(`hash:"c3c23"`), 1)
+	tmp.PutFile("/a.txt", obj(`hash:"20c27"`), 1)
+	tree, err := tmp.Finish()
+	require.NoError(t, err)
+
+	var paths []string
+	require.NoError(t, tree.Walk("/", func(path string, node *NodeProto) error {
+		paths = append(paths, path)
+		return nil
+	}))
+	require.Equal(t, []string{"/", "/a", "/a/c", "/a/c/e", "/a/d", "/a.txt", "/b"}, paths)
+}
+
+func TestSymlink(t *testing.T) {
+	h := NewHashTree()
+	require.NoError(t, h.PutFile("/dir/foo", obj(`hash:"20c27"`), 1))
+	require.NoError(t, h.PutSymlink("/abs", "/dir/foo"))
+	require.NoError(t, h.PutSymlink("/dir/rel", "foo"))
+	require.NoError(t, h.PutSymlink("/link", "dir"))
+	require.NoError(t, h.PutSymlink("/chain", "/link/rel"))
+	require.NoError(t, h.PutSymlink("/loop1", "/loop2"))
+	require.NoError(t, h.PutSymlink("/loop2", "/loop1"))
+	tree := finish(t, h)
+
+	absNode, err := tree.Get("/abs")
+	require.NoError(t, err)
+	require.Equal(t, "/dir/foo", absNode.SymlinkNode.Target)
+	for _, p := range []string{"/abs", "/dir/rel", "/link/foo", "/chain"} {
+		path, node, err := Resolve(tree, p)
+		require.NoError(t, err)
+		require.Equal(t, "/dir/foo", path)
+		require.NotNil(t, node.FileNode)
+	}
+	path, node, err := Resolve(tree, "/link")
+	require.NoError(t, err)
+	require.Equal(t, "/dir", path)
+	require.NotNil(t, node.DirNode)
+
+	_, _, err = Resolve(tree, "/loop1")
+	require.Equal(t, SymlinkLoop, Code(err))
+	_, _, err = Resolve(tree, "/link/bar")
+	require.Equal(t, PathNotFound, Code(err))
+
+	// retargeting a symlink changes its hash, but a symlink can't replace
+	// a file
+	h = tree.Open()
+	require.NoError(t, h.PutSymlink("/abs", "/dir/rel"))
+	require.Equal(t, PathConflict, Code(h.PutSymlink("/dir/foo", "/abs")))
+	require.Equal(t, PathConflict, Code(h.PutFile("/abs", obj(`hash:"20c27"`), 1)))
+	tree2 := finish(t, h)
+	absNode2, err := tree2.Get("/abs")
+	require.NoError(t, err)
+	require.False(t, bytes.Equal(absNode.Hash, absNode2.Hash))
+}
+
 // Test that HashTree methods return the right error codes
 func TestErrorCode(t *testing.T) {
 	require.Equal(t, OK, Code(nil))
@@ -616,3 +875,143 @@ func TestListEmpty(t *testing.T) {
 	_, err = tree.Glob("/*")
 	require.NoError(t, err)
 }
+
+func TestSerializeChunked(t *testing.T) {
+	hTmp := NewHashTree()
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 10; j++ {
+			require.NoError(t, hTmp.PutFile(fmt.Sprintf("/dir%d/file%d", i, j), obj(`hash:"20c27"`), 1))
+		}
+	}
+	h := finish(t, hTmp)
+
+	// chunks are kept in memory, and reads of them are counted
+	chunks := make(map[string][]byte)
+	putChunk := func(data []byte) (*pfs.Object, error) {
+		hash := fmt.Sprintf("%x", sha256.Sum256(data))
+		chunks[hash] = data
+		return &pfs.Object{Hash: hash}, nil
+	}
+	var reads int
+	getChunk := func(object *pfs.Object) ([]byte, error) {
+		reads++
+		return chunks[object.Hash], nil
+	}
+
+	// a tree with fewer nodes than the chunk size isn't chunked
+	bts, err := SerializeChunked(h, 1000, putChunk)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(chunks))
+	h2, err := Deserialize(bts)
+	require.NoError(t, err)
+	requireSame(t, h, h2)
+
+	bts, err = SerializeChunked(h, 5, putChunk)
+	require.NoError(t, err)
+	require.Equal(t, 23, len(chunks)) // 111 nodes
+	_, err = Deserialize(bts)
+	require.YesError(t, err)
+	require.Equal(t, Unsupported, Code(err))
+	lazy, err := DeserializeLazy(bts, getChunk)
+	require.NoError(t, err)
+	require.Equal(t, 23, len(ChunkObjects(lazy)))
+
+	// listing a directory only reads the chunks that hold it and its children
+	nodes, err := lazy.List("/dir3")
+	require.NoError(t, err)
+	require.Equal(t, 10, len(nodes))
+	for j, node := range nodes {
+		require.Equal(t, fmt.Sprintf("file%d", j), node.Name)
+	}
+	require.True(t, reads <= 4)
+	nodes, err = lazy.ListPage("/dir3", "file4", 2)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(nodes))
+	require.Equal(t, "file5", nodes[0].Name)
+	node, err := lazy.Get("/dir9/file9")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), node.SubtreeSize)
+	_, err = lazy.Get("/dir9/file10")
+	require.YesError(t, err)
+	require.Equal(t, PathNotFound, Code(err))
+	require.Equal(t, h.FSSize(), lazy.FSSize())
+	require.True(t, reads < 23)
+
+	// the other methods read the whole tree
+	var lazyPaths, paths []string
+	require.NoError(t, lazy.Walk("/", func(path string, node *NodeProto) error {
+		lazyPaths = append(lazyPaths, path)
+		return nil
+	}))
+	require.NoError(t, h.Walk("/", func(path string, node *NodeProto) error {
+		paths = append(paths, path)
+		return nil
+	}))
+	require.Equal(t, paths, lazyPaths)
+	requireSame(t, h, finish(t, lazy.Open()))
+	bts, err = Serialize(lazy)
+	require.NoError(t, err)
+	h3, err := Deserialize(bts)
+	require.NoError(t, err)
+	requireSame(t, h, h3)
+}
+
+func TestLazyTreeConcurrentChunks(t *testing.T) {
+	hTmp := NewHashTree()
+	for i := 0; i < 10; i++ {
+		require.NoError(t, hTmp.PutFile(fmt.Sprintf("/dir%d/file", i), obj(`hash:"20c27"`), 1))
+	}
+	h := finish(t, hTmp)
+	chunks := make(map[string][]byte)
+	var first string
+	bts, err := SerializeChunked(h, 5, func(data []byte) (*pfs.Object, error) {
+		hash := fmt.Sprintf("%x", sha256.Sum256(data))
+		if first == "" {
+			first = hash
+		}
+		chunks[hash] = data
+		return &pfs.Object{Hash: hash}, nil
+	})
+	require.NoError(t, err)
+
+	// reads of the first chunk block until it's released
+	release := make(chan struct{})
+	var mu sync.Mutex
+	reads := make(map[string]int)
+	lazy, err := DeserializeLazy(bts, func(object *pfs.Object) ([]byte, error) {
+		mu.Lock()
+		reads[object.Hash]++
+		mu.Unlock()
+		if object.Hash == first {
+			<-release
+		}
+		return chunks[object.Hash], nil
+	})
+	require.NoError(t, err)
+	var eg errgroup.Group
+	for i := 0; i < 2; i++ {
+		eg.Go(func() error {
+			_, err := lazy.Get("/")
+			return err
+		})
+	}
+
+	// a blocked chunk doesn't block reads of the others
+	done := make(chan error, 1)
+	go func() {
+		_, err := lazy.Get("/dir9/file")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("reading a chunk was blocked by the read of another")
+	}
+	close(release)
+	require.NoError(t, eg.Wait())
+	// and concurrent readers of a chunk read it once
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, 1, reads[first])
+}
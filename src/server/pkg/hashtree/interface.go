@@ -43,6 +43,10 @@ const (
 	//    points to a file.
 	// 3. Merge is forced to merge a directory into a file
 	PathConflict
+
+	// SymlinkLoop is returned when Resolve() follows too many symlinks, which
+	// usually means that they form a cycle.
+	SymlinkLoop
 )
 
 // HashTree is the signature of a hash tree provided by this library. To get a
@@ -59,16 +63,27 @@ type HashTree interface {
 	// 'path'.
 	List(path string) ([]*NodeProto, error)
 
+	// ListPage is like List, but it only returns the children whose names
+	// sort after 'after', at most 'limit' of them if limit is greater than 0.
+	ListPage(path string, after string, limit int) ([]*NodeProto, error)
+
 	// Glob returns a list of files and directories that match 'pattern'.
 	Glob(pattern string) ([]*NodeProto, error)
 
+	// GlobPage is like Glob, but its results are ordered by path and it only
+	// returns the matches whose paths sort after 'after', at most 'limit' of
+	// them if limit is greater than 0.
+	GlobPage(pattern string, after string, limit int) ([]*NodeProto, error)
+
 	// FSSize gets the size of the file system that this tree represents.
 	// It's essentially a helper around h.Get("/").SubtreeBytes
 	FSSize() int64
 
 	// Walk calls a given function against every node in the hash tree.
-	// The order of traversal is not guaranteed.  If any invocation of the
-	// function returns an error, the walk stops and returns the error.
+	// Nodes are visited depth-first in path order: each directory comes
+	// before its children, which are visited in the order of their names.
+	// If any invocation of the function returns an error, the walk stops
+	// and returns the error.
 	Walk(path string, f func(path string, node *NodeProto) error) error
 
 	// Diff returns a the diff of 2 HashTrees at particular Paths. It takes a
@@ -84,8 +99,9 @@ type OpenNode struct {
 	Name string
 	Size int64
 
-	FileNode *FileNodeProto
-	DirNode  *DirectoryNodeProto
+	FileNode    *FileNodeProto
+	DirNode     *DirectoryNodeProto
+	SymlinkNode *SymlinkNodeProto
 }
 
 // OpenHashTree is like HashTree, except that it can be modified. Once an
@@ -109,10 +125,20 @@ type OpenHashTree interface {
 	// the size of the objects removed.
 	PutFileOverwrite(path string, objects []*pfs.Object, overwriteIndex *pfs.OverwriteIndex, sizeDelta int64) error
 
+	// PutFileNode puts a file at path whose node is a copy of fileNode,
+	// keeping its stats and record count, which PutFile clears. It replaces
+	// the file that's at path, if there is one.
+	PutFileNode(path string, fileNode *FileNodeProto, size int64) error
+
 	// PutDir creates a directory (or does nothing if one exists).
 	PutDir(path string) error
 
-	// DeleteFile deletes a regular file or directory (along with its children).
+	// PutSymlink creates a symlink at 'path' that points to 'target' (or
+	// changes the target of the symlink that's already there).
+	PutSymlink(path string, target string) error
+
+	// DeleteFile deletes a regular file, symlink or directory (along with its
+	// children).
 	DeleteFile(path string) error
 
 	// Merge adds all of the files and directories in each tree in 'trees' into
@@ -121,6 +147,13 @@ type OpenHashTree interface {
 	// state of the tree you should Finish and then Open the tree.
 	Merge(trees ...HashTree) error
 
+	// Verify checks that the tree's directories are consistent with its
+	// nodes: that every node is listed by its parent, which is a directory,
+	// and that every child a directory lists exists. Deleting a directory
+	// deletes everything under it, so a violation, such as a file surviving
+	// the deletion of its directory, is an Internal error.
+	Verify() error
+
 	// Finish makes a deep copy of the OpenHashTree, updates all of the hashes and
 	// node size metadata in the copy, and returns the copy
 	Finish() (HashTree, error)
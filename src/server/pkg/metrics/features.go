@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+
+	"github.com/segmentio/analytics-go"
+	log "github.com/sirupsen/logrus"
+)
+
+// FeatureReporter reports aggregate PFS feature usage to segment. Unlike
+// cluster metrics it's opt-in, pachd only creates one when FEATURE_TELEMETRY
+// is set.
+type FeatureReporter struct {
+	segmentClient *analytics.Client
+	clusterID     string
+}
+
+// NewFeatureReporter creates a new FeatureReporter.
+func NewFeatureReporter(clusterID string) *FeatureReporter {
+	return &FeatureReporter{
+		segmentClient: newPersistentClient(),
+		clusterID:     clusterID,
+	}
+}
+
+// ClusterID returns the ID of the cluster the usage is reported for.
+func (r *FeatureReporter) ClusterID() string {
+	return r.clusterID
+}
+
+// Report reports the usage returned by usage every reportingInterval, it
+// never returns.
+func (r *FeatureReporter) Report(usage func() (*pfs.FeatureUsage, error)) {
+	for {
+		time.Sleep(reportingInterval)
+		featureUsage, err := usage()
+		if err != nil {
+			log.Errorf("error collecting feature usage: %v", err)
+			continue
+		}
+		reportFeatureUsageToSegment(r.segmentClient, featureUsage)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+
 	"github.com/segmentio/analytics-go"
 	log "github.com/sirupsen/logrus"
 )
@@ -71,3 +73,23 @@ func reportUserMetricsToSegment(client *analytics.Client, userID string, prefix
 		log.Errorf("error reporting user action to Segment: %s", err.Error())
 	}
 }
+
+func reportFeatureUsageToSegment(client *analytics.Client, usage *pfs.FeatureUsage) {
+	properties := map[string]interface{}{
+		"repos": usage.Repos,
+	}
+	for feature, count := range usage.Features {
+		properties[feature] = count
+	}
+	for finding, count := range usage.AuditFindings {
+		properties["audit_"+finding] = count
+	}
+	err := client.Track(&analytics.Track{
+		Event:       "pfs.features",
+		AnonymousId: usage.ClusterID,
+		Properties:  properties,
+	})
+	if err != nil {
+		log.Errorf("error reporting feature usage to Segment: %s", err.Error())
+	}
+}
@@ -79,7 +79,11 @@ func newAmazonClient(bucket string, cloudfrontDistribution string, id string, se
 }
 
 func (c *amazonClient) Writer(name string) (io.WriteCloser, error) {
-	return newBackoffWriteCloser(c, newWriter(c, name)), nil
+	return newBackoffWriteCloser(c, newWriter(c, name, "")), nil
+}
+
+func (c *amazonClient) WriterWithStorageClass(name string, storageClass string) (io.WriteCloser, error) {
+	return newBackoffWriteCloser(c, newWriter(c, name, storageClass)), nil
 }
 
 func (c *amazonClient) Walk(name string, fn func(name string) error) error {
@@ -222,20 +226,26 @@ type amazonWriter struct {
 	pipe    *io.PipeWriter
 }
 
-func newWriter(client *amazonClient, name string) *amazonWriter {
+// newWriter returns a writer of the object called name, in storageClass
+// unless it's "", which is the bucket's default class.
+func newWriter(client *amazonClient, name string, storageClass string) *amazonWriter {
 	reader, writer := io.Pipe()
 	w := &amazonWriter{
 		errChan: make(chan error),
 		pipe:    writer,
 	}
+	input := &s3manager.UploadInput{
+		ACL:             &uploadACL,
+		Body:            reader,
+		Bucket:          aws.String(client.bucket),
+		Key:             aws.String(name),
+		ContentEncoding: aws.String("application/octet-stream"),
+	}
+	if storageClass != "" {
+		input.StorageClass = aws.String(storageClass)
+	}
 	go func() {
-		_, err := client.uploader.Upload(&s3manager.UploadInput{
-			ACL:             &uploadACL,
-			Body:            reader,
-			Bucket:          aws.String(client.bucket),
-			Key:             aws.String(name),
-			ContentEncoding: aws.String("application/octet-stream"),
-		})
+		_, err := client.uploader.Upload(input)
 		w.errChan <- err
 	}()
 	return w
@@ -37,6 +37,12 @@ func (c *googleClient) Writer(name string) (io.WriteCloser, error) {
 	return newBackoffWriteCloser(c, c.bucket.Object(name).NewWriter(c.ctx)), nil
 }
 
+func (c *googleClient) WriterWithStorageClass(name string, storageClass string) (io.WriteCloser, error) {
+	writer := c.bucket.Object(name).NewWriter(c.ctx)
+	writer.StorageClass = storageClass
+	return newBackoffWriteCloser(c, writer), nil
+}
+
 func (c *googleClient) Walk(name string, fn func(name string) error) error {
 	query := &storage.Query{Prefix: name}
 	for query != nil {
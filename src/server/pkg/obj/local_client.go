@@ -35,6 +35,12 @@ func (c *localClient) Writer(path string) (io.WriteCloser, error) {
 	return file, nil
 }
 
+// WriterWithStorageClass writes to path as Writer does, as the local file
+// system has a single storage class.
+func (c *localClient) WriterWithStorageClass(path string, storageClass string) (io.WriteCloser, error) {
+	return c.Writer(path)
+}
+
 func (c *localClient) Reader(path string, offset uint64, size uint64) (io.ReadCloser, error) {
 	file, err := os.Open(filepath.Join(c.root, path))
 	if err != nil {
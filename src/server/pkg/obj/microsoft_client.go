@@ -39,6 +39,12 @@ func (c *microsoftClient) Writer(name string) (io.WriteCloser, error) {
 	return newBackoffWriteCloser(c, writer), nil
 }
 
+// WriterWithStorageClass writes to name as Writer does, as blobs' access
+// tiers can't be set with the blob API that's used.
+func (c *microsoftClient) WriterWithStorageClass(name string, storageClass string) (io.WriteCloser, error) {
+	return c.Writer(name)
+}
+
 func (c *microsoftClient) Reader(name string, offset uint64, size uint64) (io.ReadCloser, error) {
 	byteRange := byteRange(offset, size)
 	var reader io.ReadCloser
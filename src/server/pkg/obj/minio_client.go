@@ -30,15 +30,20 @@ type minioWriter struct {
 	pipe    *io.PipeWriter
 }
 
-// Creates a new minio writer and a go routine to upload objects to minio server
-func newMinioWriter(client *minioClient, name string) *minioWriter {
+// Creates a new minio writer and a go routine to upload objects to minio server,
+// in storageClass unless it's "", which is the server's default class
+func newMinioWriter(client *minioClient, name string, storageClass string) *minioWriter {
 	reader, writer := io.Pipe()
 	w := &minioWriter{
 		errChan: make(chan error),
 		pipe:    writer,
 	}
+	metaData := map[string][]string{"Content-Type": {"application/octet-stream"}}
+	if storageClass != "" {
+		metaData["X-Amz-Storage-Class"] = []string{storageClass}
+	}
 	go func() {
-		_, err := client.PutObject(client.bucket, name, reader, "application/octet-stream")
+		_, err := client.PutObjectWithMetadata(client.bucket, name, reader, metaData, nil)
 		if err != nil {
 			reader.CloseWithError(err)
 		}
@@ -60,7 +65,11 @@ func (w *minioWriter) Close() error {
 }
 
 func (c *minioClient) Writer(name string) (io.WriteCloser, error) {
-	return newMinioWriter(c, name), nil
+	return newMinioWriter(c, name, ""), nil
+}
+
+func (c *minioClient) WriterWithStorageClass(name string, storageClass string) (io.WriteCloser, error) {
+	return newMinioWriter(c, name, storageClass), nil
 }
 
 func (c *minioClient) Walk(name string, fn func(name string) error) error {
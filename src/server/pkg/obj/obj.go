@@ -23,6 +23,10 @@ type Client interface {
 	// It should error if the object already exists or we don't have sufficient
 	// permissions to write it.
 	Writer(name string) (io.WriteCloser, error)
+	// WriterWithStorageClass is like Writer, but the object is written in
+	// storageClass, e.g. STANDARD_IA on S3, rather than the store's default
+	// class. Stores without storage classes write it as Writer does.
+	WriterWithStorageClass(name string, storageClass string) (io.WriteCloser, error)
 	// Reader returns a reader which reads from an object.
 	// If `size == 0`, the reader should read from the offset till the end of the object.
 	// It should error if the object doesn't exist or we don't have sufficient
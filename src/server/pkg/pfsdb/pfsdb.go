@@ -12,11 +12,27 @@ import (
 )
 
 const (
-	reposPrefix         = "/repos"
-	repoRefCountsPrefix = "/repoRefCounts"
-	commitsPrefix       = "/commits"
-	branchesPrefix      = "/branches"
-	openCommitsPrefix   = "/openCommits"
+	reposPrefix               = "/repos"
+	repoRefCountsPrefix       = "/repoRefCounts"
+	commitsPrefix             = "/commits"
+	branchesPrefix            = "/branches"
+	openCommitsPrefix         = "/openCommits"
+	commitProgressPrefix      = "/commitProgress"
+	schemasPrefix             = "/schemas"
+	commitHooksPrefix         = "/commitHooks"
+	objectRefCountsPrefix     = "/objectRefCounts"
+	unreferencedObjectsPrefix = "/unreferencedObjects"
+	compactionPoliciesPrefix  = "/compactionPolicies"
+	commitLocksPrefix         = "/commitLocks"
+	repoLeasesPrefix          = "/repoLeases"
+	uploadSessionsPrefix      = "/uploadSessions"
+	pathExpirationsPrefix     = "/pathExpirations"
+	expirationOwnersPrefix    = "/pathExpirationOwners"
+	featureFlagsPrefix        = "/featureFlags"
+	adminJobsPrefix           = "/adminJobs"
+	repoQuotasPrefix          = "/repoQuotas"
+	metadataExportsPrefix     = "/metadataExports"
+	retentionPoliciesPrefix   = "/retentionPolicies"
 )
 
 var (
@@ -57,6 +73,12 @@ func Commits(etcdClient *etcd.Client, etcdPrefix string, repo string) col.Collec
 	)
 }
 
+// CommitsPrefix returns the prefix under which the commits of every repo are
+// stored
+func CommitsPrefix(etcdPrefix string) string {
+	return path.Join(etcdPrefix, commitsPrefix)
+}
+
 // Branches returns a collection of branches
 func Branches(etcdClient *etcd.Client, etcdPrefix string, repo string) col.Collection {
 	return col.NewCollection(
@@ -83,3 +105,193 @@ func OpenCommits(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
 		nil,
 	)
 }
+
+// Schemas returns a collection of the schemas set in each repo
+func Schemas(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
+	return col.NewCollection(
+		etcdClient,
+		path.Join(etcdPrefix, schemasPrefix),
+		nil,
+		&pfs.RepoSchemas{},
+		nil,
+	)
+}
+
+// CommitHooks returns a collection of the commit hooks in each repo
+func CommitHooks(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
+	return col.NewCollection(
+		etcdClient,
+		path.Join(etcdPrefix, commitHooksPrefix),
+		nil,
+		&pfs.CommitHookInfos{},
+		nil,
+	)
+}
+
+// CompactionPolicies returns a collection of the compaction policy of each
+// repo
+func CompactionPolicies(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
+	return col.NewCollection(
+		etcdClient,
+		path.Join(etcdPrefix, compactionPoliciesPrefix),
+		nil,
+		&pfs.CompactionPolicyInfo{},
+		nil,
+	)
+}
+
+// CommitLocks returns a collection of the locks held on each open commit,
+// keyed by commit ID
+func CommitLocks(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
+	return col.NewCollection(
+		etcdClient,
+		path.Join(etcdPrefix, commitLocksPrefix),
+		nil,
+		&pfs.CommitLocks{},
+		nil,
+	)
+}
+
+// RepoLeases returns a collection of the leases of temporary repos
+func RepoLeases(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
+	return col.NewCollection(
+		etcdClient,
+		path.Join(etcdPrefix, repoLeasesPrefix),
+		nil,
+		&pfs.RepoLease{},
+		nil,
+	)
+}
+
+// UploadSessions returns a collection of the resumable uploads in progress,
+// keyed by upload ID
+func UploadSessions(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
+	return col.NewCollection(
+		etcdClient,
+		path.Join(etcdPrefix, uploadSessionsPrefix),
+		nil,
+		&pfs.UploadSession{},
+		nil,
+	)
+}
+
+// PathExpirations returns a collection of when the paths written with a ttl
+// expire, keyed by a digest of the repo, branch and path
+func PathExpirations(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
+	return col.NewCollection(
+		etcdClient,
+		path.Join(etcdPrefix, pathExpirationsPrefix),
+		nil,
+		&pfs.PathExpiration{},
+		nil,
+	)
+}
+
+// PathExpirationOwners returns a collection of the capabilities that the
+// paths written with a ttl are deleted with, keyed by a digest of their
+// owner's username
+func PathExpirationOwners(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
+	return col.NewCollection(
+		etcdClient,
+		path.Join(etcdPrefix, expirationOwnersPrefix),
+		nil,
+		&pfs.PathExpirationOwner{},
+		nil,
+	)
+}
+
+// FeatureFlags returns a collection of the feature flags set for the cluster
+// and for each repo, keyed by repo name
+func FeatureFlags(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
+	return col.NewCollection(
+		etcdClient,
+		path.Join(etcdPrefix, featureFlagsPrefix),
+		nil,
+		&pfs.FeatureFlagSettings{},
+		nil,
+	)
+}
+
+// ObjectRefCounts returns a collection of the number of finished commits that
+// reference each object, keyed by object hash
+func ObjectRefCounts(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
+	return col.NewCollection(
+		etcdClient,
+		path.Join(etcdPrefix, objectRefCountsPrefix),
+		nil,
+		nil,
+		nil,
+	)
+}
+
+// UnreferencedObjects returns a collection of the objects that have lost
+// their last ref, keyed by hash
+func UnreferencedObjects(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
+	return col.NewCollection(
+		etcdClient,
+		path.Join(etcdPrefix, unreferencedObjectsPrefix),
+		nil,
+		&pfs.UnreferencedObject{},
+		nil,
+	)
+}
+
+// CommitProgress returns a collection of progress reports for commits that
+// are being finished
+func CommitProgress(etcdClient *etcd.Client, etcdPrefix string, repo string) col.Collection {
+	return col.NewCollection(
+		etcdClient,
+		path.Join(etcdPrefix, commitProgressPrefix, repo),
+		nil,
+		&pfs.CommitProgress{},
+		nil,
+	)
+}
+
+// AdminJobs returns a collection of the status of long-running admin
+// operations, keyed by job ID
+func AdminJobs(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
+	return col.NewCollection(
+		etcdClient,
+		path.Join(etcdPrefix, adminJobsPrefix),
+		nil,
+		&pfs.AdminJobInfo{},
+		nil,
+	)
+}
+
+// RepoQuotas returns a collection of the limits on the rate of repos'
+// operations, keyed by repo name
+func RepoQuotas(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
+	return col.NewCollection(
+		etcdClient,
+		path.Join(etcdPrefix, repoQuotasPrefix),
+		nil,
+		&pfs.RepoQuota{},
+		nil,
+	)
+}
+
+// MetadataExports returns a collection of the cluster's metadata export,
+// which has a single key
+func MetadataExports(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
+	return col.NewCollection(
+		etcdClient,
+		path.Join(etcdPrefix, metadataExportsPrefix),
+		nil,
+		&pfs.MetadataExportInfo{},
+		nil,
+	)
+}
+
+// RetentionPolicies returns a collection of the retention policy of each
+// repo
+func RetentionPolicies(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
+	return col.NewCollection(
+		etcdClient,
+		path.Join(etcdPrefix, retentionPoliciesPrefix),
+		nil,
+		&pfs.RetentionPolicyInfo{},
+		nil,
+	)
+}
@@ -26,6 +26,16 @@ func Ago(timestamp *types.Timestamp) string {
 	return fmt.Sprintf("%s ago", units.HumanDuration(time.Since(t)))
 }
 
+// Until pretty-prints the amount of time that's left until timestamp as a
+// human-readable string.
+func Until(timestamp *types.Timestamp) string {
+	t, _ := types.TimestampFromProto(timestamp)
+	if t.Equal(time.Time{}) {
+		return ""
+	}
+	return fmt.Sprintf("in %s", units.HumanDuration(time.Until(t)))
+}
+
 // TimeDifference pretty-prints the duration of time between from
 // and to as a human-reabable string.
 func TimeDifference(from *types.Timestamp, to *types.Timestamp) string {
@@ -0,0 +1,181 @@
+package sync
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Reasons reported in a PathTranslation.
+const (
+	// ReasonReservedName is reported when a path component is a device name
+	// reserved by Windows, such as "CON" or "lpt1.txt".
+	ReasonReservedName = "reserved name"
+	// ReasonInvalidCharacter is reported when a path component contains a
+	// character that Windows doesn't allow in file names, including "\",
+	// which Windows treats as a path separator.
+	ReasonInvalidCharacter = "invalid character"
+	// ReasonTrailingCharacter is reported when a path component ends in a "."
+	// or " ", which Windows silently strips.
+	ReasonTrailingCharacter = "trailing dot or space"
+	// ReasonCaseCollision is reported when a path only differs in case from a
+	// path that has already been materialized.
+	ReasonCaseCollision = "case collision"
+)
+
+// PathTranslation records a PFS path that had to be renamed in order to be
+// materialized on the local filesystem.
+type PathTranslation struct {
+	PFSPath   string
+	LocalPath string
+	Reasons   []string
+}
+
+// PathTranslator maps PFS paths to local paths that can be created on
+// Windows without losing files: reserved device names, characters Windows
+// doesn't allow (including "\"), trailing dots and spaces are escaped, and
+// paths that only differ in case are given distinct names. Paths that don't
+// need translating are returned unchanged. It's safe to use a PathTranslator
+// from multiple goroutines.
+type PathTranslator struct {
+	mu sync.Mutex
+	// local maps each PFS path that has been translated to its local path
+	local map[string]string
+	// taken maps the case-folded version of each local path that's been
+	// handed out to the PFS path it was handed out for
+	taken        map[string]string
+	translations []*PathTranslation
+}
+
+// NewPathTranslator creates a new PathTranslator.
+func NewPathTranslator() *PathTranslator {
+	return &PathTranslator{
+		local: make(map[string]string),
+		taken: make(map[string]string),
+	}
+}
+
+// Translate returns the local path that pfsPath should be materialized at.
+// The result is relative if pfsPath is, and always uses "/" as its
+// separator, so callers should pass it through filepath.FromSlash.
+func (t *PathTranslator) Translate(pfsPath string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.translate(path.Clean(pfsPath))
+}
+
+// Translations returns every path that Translate has renamed so far, in the
+// order they were first translated.
+func (t *PathTranslator) Translations() []*PathTranslation {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*PathTranslation(nil), t.translations...)
+}
+
+func (t *PathTranslator) translate(pfsPath string) string {
+	if pfsPath == "." || pfsPath == "/" || pfsPath == "" {
+		return pfsPath
+	}
+	if local, ok := t.local[pfsPath]; ok {
+		return local
+	}
+	dir, base := path.Split(pfsPath)
+	localDir := dir
+	if dir != "" && dir != "/" {
+		localDir = t.translate(strings.TrimSuffix(dir, "/")) + "/"
+	}
+	localBase, reasons := translateName(base)
+	local := localDir + localBase
+	for i := 1; ; i++ {
+		owner, ok := t.taken[strings.ToLower(local)]
+		if !ok || owner == pfsPath {
+			break
+		}
+		if i == 1 {
+			reasons = append(reasons, ReasonCaseCollision)
+		}
+		local = localDir + withSuffix(localBase, fmt.Sprintf("~%d", i))
+	}
+	t.taken[strings.ToLower(local)] = pfsPath
+	t.local[pfsPath] = local
+	if len(reasons) > 0 {
+		t.translations = append(t.translations, &PathTranslation{
+			PFSPath:   pfsPath,
+			LocalPath: local,
+			Reasons:   reasons,
+		})
+	}
+	return local
+}
+
+// maxNameLength is the longest file name, in bytes, that the filesystems
+// that commits are pulled to allow.
+const maxNameLength = 255
+
+// CheckPullable returns an error if pfsPath can't be materialized by a
+// Puller, even once it's translated, because one of its components is too
+// long to be a file name. PFS checks the paths that are written with it, so
+// that every file in a commit can be pulled.
+func CheckPullable(pfsPath string) error {
+	for _, name := range strings.Split(pfsPath, "/") {
+		if name == "" {
+			continue
+		}
+		if translated, _ := translateName(name); len(translated) > maxNameLength {
+			return fmt.Errorf("%q is too long to be pulled, file names can be at most %d bytes (once characters that Windows doesn't allow are escaped)", name, maxNameLength)
+		}
+	}
+	return nil
+}
+
+// reservedNames are the device names that Windows doesn't allow as file
+// names, with or without an extension.
+var reservedNames = map[string]bool{
+	"con": true, "prn": true, "aux": true, "nul": true,
+	"com1": true, "com2": true, "com3": true, "com4": true, "com5": true,
+	"com6": true, "com7": true, "com8": true, "com9": true,
+	"lpt1": true, "lpt2": true, "lpt3": true, "lpt4": true, "lpt5": true,
+	"lpt6": true, "lpt7": true, "lpt8": true, "lpt9": true,
+}
+
+// translateName escapes a single path component so that it's a legal file
+// name on Windows, and returns the reasons it had to be changed.
+func translateName(name string) (string, []string) {
+	var reasons []string
+	var result bytes.Buffer
+	for _, r := range name {
+		if r < 0x20 || strings.ContainsRune(`<>:"\|?*`, r) {
+			fmt.Fprintf(&result, "%%%02X", r)
+			if len(reasons) == 0 {
+				reasons = append(reasons, ReasonInvalidCharacter)
+			}
+			continue
+		}
+		result.WriteRune(r)
+	}
+	name = result.String()
+	if last := name[len(name)-1]; last == '.' || last == ' ' {
+		name = fmt.Sprintf("%s%%%02X", name[:len(name)-1], last)
+		reasons = append(reasons, ReasonTrailingCharacter)
+	}
+	stem := name
+	if i := strings.Index(name, "."); i >= 0 {
+		stem = name[:i]
+	}
+	if reservedNames[strings.ToLower(stem)] {
+		name = stem + "_" + name[len(stem):]
+		reasons = append(reasons, ReasonReservedName)
+	}
+	return name, reasons
+}
+
+// withSuffix inserts suffix before the extension of name.
+func withSuffix(name string, suffix string) string {
+	ext := path.Ext(name)
+	if ext == name {
+		ext = ""
+	}
+	return name[:len(name)-len(ext)] + suffix + ext
+}
@@ -0,0 +1,69 @@
+package sync
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+)
+
+func TestTranslateUnchanged(t *testing.T) {
+	translator := NewPathTranslator()
+	require.Equal(t, "foo/bar.txt", translator.Translate("foo/bar.txt"))
+	require.Equal(t, "/foo/bar", translator.Translate("/foo/bar"))
+	require.Equal(t, 0, len(translator.Translations()))
+}
+
+func TestTranslateReservedNames(t *testing.T) {
+	translator := NewPathTranslator()
+	require.Equal(t, "CON_", translator.Translate("CON"))
+	require.Equal(t, "dir/nul_.txt", translator.Translate("dir/nul.txt"))
+	require.Equal(t, "lpt1_/file", translator.Translate("lpt1/file"))
+	require.Equal(t, "console", translator.Translate("console"))
+	translations := translator.Translations()
+	require.Equal(t, 3, len(translations))
+	require.Equal(t, "dir/nul.txt", translations[1].PFSPath)
+	require.OneOfEquals(t, ReasonReservedName, translations[1].Reasons)
+}
+
+func TestTranslateInvalidCharacters(t *testing.T) {
+	translator := NewPathTranslator()
+	require.Equal(t, "a%3Ab", translator.Translate("a:b"))
+	require.Equal(t, "a%5Cb", translator.Translate(`a\b`))
+	require.Equal(t, "dir%2E/file%20", translator.Translate("dir./file "))
+}
+
+func TestTranslateCaseCollisions(t *testing.T) {
+	translator := NewPathTranslator()
+	require.Equal(t, "dir/File.txt", translator.Translate("dir/File.txt"))
+	require.Equal(t, "dir/file~1.txt", translator.Translate("dir/file.txt"))
+	require.Equal(t, "dir/FILE~2.txt", translator.Translate("dir/FILE.txt"))
+	// Translating the same path again returns the same result
+	require.Equal(t, "dir/file~1.txt", translator.Translate("dir/file.txt"))
+	// Children of a renamed directory are materialized under the new name
+	require.Equal(t, "Dir~1", translator.Translate("Dir"))
+	require.Equal(t, "Dir~1/file.txt", translator.Translate("Dir/file.txt"))
+	require.Equal(t, 3, len(translator.Translations()))
+}
+
+func TestCheckPullable(t *testing.T) {
+	require.NoError(t, CheckPullable("/dir/"+strings.Repeat("a", 255)))
+	require.YesError(t, CheckPullable("/dir/"+strings.Repeat("a", 256)))
+	// escaped characters count towards the limit
+	require.YesError(t, CheckPullable("/"+strings.Repeat(":", 100)))
+	require.NoError(t, CheckPullable(`/a\b/c:d`))
+}
+
+func TestRelPath(t *testing.T) {
+	rel, err := relPath("/dir", `/dir/a\b/c`)
+	require.NoError(t, err)
+	require.Equal(t, `a\b/c`, rel)
+	rel, err = relPath("/", "/dir/file")
+	require.NoError(t, err)
+	require.Equal(t, "dir/file", rel)
+	rel, err = relPath("dir", "/dir")
+	require.NoError(t, err)
+	require.Equal(t, ".", rel)
+	_, err = relPath("/dir", "/dirt/file")
+	require.YesError(t, err)
+}
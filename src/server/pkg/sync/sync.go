@@ -2,6 +2,8 @@
 package sync
 
 import (
+	"context"
+	"fmt"
 	"io"
 	"os"
 	"path"
@@ -16,6 +18,7 @@ import (
 	"github.com/pachyderm/pachyderm/src/client/pfs"
 	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
 	"github.com/pachyderm/pachyderm/src/server/pkg/obj"
+	"github.com/pachyderm/pachyderm/src/server/pkg/throttle"
 
 	"golang.org/x/sync/errgroup"
 )
@@ -34,6 +37,11 @@ type Puller struct {
 	wg sync.WaitGroup
 	// size is the total amount this puller has pulled
 	size int64
+	// translator, if set, maps PFS paths to local paths that are legal
+	// on Windows
+	translator *PathTranslator
+	// throttle, if set, limits the rate at which data is pulled
+	throttle *throttle.Throttle
 }
 
 // NewPuller creates a new Puller struct.
@@ -44,6 +52,57 @@ func NewPuller() *Puller {
 	}
 }
 
+// TranslatePaths causes the Puller to materialize files at paths that are
+// legal on Windows, as computed by a PathTranslator, rather than at their
+// PFS paths. Paths that had to be renamed are returned by Translations.
+func (p *Puller) TranslatePaths() {
+	p.translator = NewPathTranslator()
+}
+
+// Throttle causes the Puller to write the data it pulls no faster, and at no
+// other times, than t allows.
+func (p *Puller) Throttle(t *throttle.Throttle) {
+	p.throttle = t
+}
+
+// Translations returns the files that have been materialized at a path
+// different from their PFS path. It's always empty unless TranslatePaths
+// has been called.
+func (p *Puller) Translations() []*PathTranslation {
+	if p.translator == nil {
+		return nil
+	}
+	return p.translator.Translations()
+}
+
+// localPath returns the path under root that the PFS path relPath (which is
+// relative to root) should be materialized at. relPath is translated before
+// it's made a local path, so a "\" in a PFS name is never taken for a
+// separator.
+func (p *Puller) localPath(root string, relPath string) string {
+	if p.translator != nil {
+		relPath = p.translator.Translate(relPath)
+	}
+	return filepath.Join(root, filepath.FromSlash(relPath))
+}
+
+// relPath returns the PFS path target relative to the PFS directory dir that
+// contains it. Unlike filepath.Rel it only treats "/" as a separator, as PFS
+// does.
+func relPath(dir string, target string) (string, error) {
+	dir, target = path.Clean("/"+dir), path.Clean("/"+target)
+	if target == dir {
+		return ".", nil
+	}
+	if dir != "/" {
+		dir += "/"
+	}
+	if !strings.HasPrefix(target, dir) {
+		return "", fmt.Errorf("%s isn't under %s", target, dir)
+	}
+	return strings.TrimPrefix(target, dir), nil
+}
+
 type sizeWriter struct {
 	w    io.Writer
 	size int64
@@ -97,7 +156,7 @@ func (p *Puller) makePipe(path string, f func(io.Writer) error) error {
 			}() {
 				return nil
 			}
-			w := &sizeWriter{w: file}
+			w := &sizeWriter{w: p.throttle.Writer(context.Background(), file)}
 			if err := f(w); err != nil {
 				return err
 			}
@@ -126,7 +185,7 @@ func (p *Puller) makeFile(path string, f func(io.Writer) error) (retErr error) {
 			retErr = err
 		}
 	}()
-	w := &sizeWriter{w: file}
+	w := &sizeWriter{w: p.throttle.Writer(context.Background(), file)}
 	if err := f(w); err != nil {
 		return err
 	}
@@ -146,26 +205,43 @@ func (p *Puller) Pull(client *pachclient.APIClient, root string, repo, commit, f
 	limiter := limit.New(concurrency)
 	var eg errgroup.Group
 	if err := client.Walk(repo, commit, file, func(fileInfo *pfs.FileInfo) error {
-		basepath, err := filepath.Rel(file, fileInfo.File.Path)
+		basepath, err := relPath(file, fileInfo.File.Path)
 		if err != nil {
 			return err
 		}
+		var symlinkTarget string
+		if fileInfo.FileType == pfs.FileType_SYMLINK {
+			symlinkTarget, err = relativeSymlinkTarget(fileInfo)
+			if err != nil {
+				return err
+			}
+		}
 		if tree != nil {
 			treePath := path.Join(treeRoot, basepath)
 			if fileInfo.FileType == pfs.FileType_DIR {
 				if err := tree.PutDir(treePath); err != nil {
 					return err
 				}
+			} else if fileInfo.FileType == pfs.FileType_SYMLINK {
+				if err := tree.PutSymlink(treePath, symlinkTarget); err != nil {
+					return err
+				}
 			} else {
 				if err := tree.PutFile(treePath, fileInfo.Objects, int64(fileInfo.SizeBytes)); err != nil {
 					return err
 				}
 			}
 		}
-		path := filepath.Join(root, basepath)
+		path := p.localPath(root, basepath)
 		if fileInfo.FileType == pfs.FileType_DIR {
 			return os.MkdirAll(path, 0700)
 		}
+		if fileInfo.FileType == pfs.FileType_SYMLINK {
+			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				return err
+			}
+			return os.Symlink(filepath.FromSlash(symlinkTarget), path)
+		}
 		if pipes {
 			return p.makePipe(path, func(w io.Writer) error {
 				return client.GetFile(repo, commit, fileInfo.File.Path, 0, 0, w)
@@ -185,10 +261,27 @@ func (p *Puller) Pull(client *pachclient.APIClient, root string, repo, commit, f
 	return eg.Wait()
 }
 
+// relativeSymlinkTarget returns the target of the symlink described by
+// fileInfo relative to the directory that contains it. Absolute targets are
+// paths in the symlink's commit, so making them relative keeps them pointing
+// into the pulled content.
+func relativeSymlinkTarget(fileInfo *pfs.FileInfo) (string, error) {
+	if !strings.HasPrefix(fileInfo.SymlinkTarget, "/") {
+		return fileInfo.SymlinkTarget, nil
+	}
+	dir := path.Dir(path.Join("/", fileInfo.File.Path))
+	target, err := filepath.Rel(dir, fileInfo.SymlinkTarget)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(target), nil
+}
+
 // PullDiff is like Pull except that it materializes a Diff of the content
 // rather than a the actual content. If newOnly is true then only new files
 // will be downloaded and they will be downloaded under root. Otherwise new and
 // old files will be downloaded under root/new and root/old respectively.
+// Symlinks are downloaded as the content of the files they point to.
 func (p *Puller) PullDiff(client *pachclient.APIClient, root string, newRepo, newCommit, newPath, oldRepo, oldCommit, oldPath string,
 	newOnly bool, pipes bool, concurrency int, tree hashtree.OpenHashTree, treeRoot string) error {
 	limiter := limit.New(concurrency)
@@ -198,7 +291,7 @@ func (p *Puller) PullDiff(client *pachclient.APIClient, root string, newRepo, ne
 		return err
 	}
 	for _, newFile := range newFiles {
-		basepath, err := filepath.Rel(newPath, newFile.File.Path)
+		basepath, err := relPath(newPath, newFile.File.Path)
 		if err != nil {
 			return err
 		}
@@ -211,13 +304,13 @@ func (p *Puller) PullDiff(client *pachclient.APIClient, root string, newRepo, ne
 				return err
 			}
 		}
-		path := filepath.Join(root, "new", basepath)
+		path := p.localPath(filepath.Join(root, "new"), basepath)
 		if newOnly {
-			path = filepath.Join(root, basepath)
+			path = p.localPath(root, basepath)
 		}
 		if pipes {
 			if err := p.makePipe(path, func(w io.Writer) error {
-				return client.GetFile(newFile.File.Commit.Repo.Name, newFile.File.Commit.ID, newFile.File.Path, 0, 0, w)
+				return client.GetFileFollowSymlinks(newFile.File.Commit.Repo.Name, newFile.File.Commit.ID, newFile.File.Path, 0, 0, w)
 			}); err != nil {
 				return err
 			}
@@ -227,14 +320,14 @@ func (p *Puller) PullDiff(client *pachclient.APIClient, root string, newRepo, ne
 			eg.Go(func() error {
 				defer limiter.Release()
 				return p.makeFile(path, func(w io.Writer) error {
-					return client.GetFile(newFile.File.Commit.Repo.Name, newFile.File.Commit.ID, newFile.File.Path, 0, 0, w)
+					return client.GetFileFollowSymlinks(newFile.File.Commit.Repo.Name, newFile.File.Commit.ID, newFile.File.Path, 0, 0, w)
 				})
 			})
 		}
 	}
 	if !newOnly {
 		for _, oldFile := range oldFiles {
-			basepath, err := filepath.Rel(oldPath, oldFile.File.Path)
+			basepath, err := relPath(oldPath, oldFile.File.Path)
 			if err != nil {
 				return err
 			}
@@ -244,10 +337,10 @@ func (p *Puller) PullDiff(client *pachclient.APIClient, root string, newRepo, ne
 					return err
 				}
 			}
-			path := filepath.Join(root, "old", basepath)
+			path := p.localPath(filepath.Join(root, "old"), basepath)
 			if pipes {
 				if err := p.makePipe(path, func(w io.Writer) error {
-					return client.GetFile(oldFile.File.Commit.Repo.Name, oldFile.File.Commit.ID, oldFile.File.Path, 0, 0, w)
+					return client.GetFileFollowSymlinks(oldFile.File.Commit.Repo.Name, oldFile.File.Commit.ID, oldFile.File.Path, 0, 0, w)
 				}); err != nil {
 					return err
 				}
@@ -257,7 +350,7 @@ func (p *Puller) PullDiff(client *pachclient.APIClient, root string, newRepo, ne
 				eg.Go(func() error {
 					defer limiter.Release()
 					return p.makeFile(path, func(w io.Writer) error {
-						return client.GetFile(oldFile.File.Commit.Repo.Name, oldFile.File.Commit.ID, oldFile.File.Path, 0, 0, w)
+						return client.GetFileFollowSymlinks(oldFile.File.Commit.Repo.Name, oldFile.File.Commit.ID, oldFile.File.Path, 0, 0, w)
 					})
 				})
 			}
@@ -272,21 +365,18 @@ func (p *Puller) PullTree(client *pachclient.APIClient, root string, tree hashtr
 	var eg errgroup.Group
 	if err := tree.Walk("/", func(path string, node *hashtree.NodeProto) error {
 		if node.FileNode != nil {
-			path := filepath.Join(root, path)
-			var hashes []string
-			for _, object := range node.FileNode.Objects {
-				hashes = append(hashes, object.Hash)
-			}
+			path := p.localPath(root, path)
+			objects := node.FileNode.Objects
 			if pipes {
 				return p.makePipe(path, func(w io.Writer) error {
-					return client.GetObjects(hashes, 0, 0, w)
+					return client.GetFileObjects(objects, 0, 0, w)
 				})
 			}
 			limiter.Acquire()
 			eg.Go(func() (retErr error) {
 				defer limiter.Release()
 				return p.makeFile(path, func(w io.Writer) error {
-					return client.GetObjects(hashes, 0, 0, w)
+					return client.GetFileObjects(objects, 0, 0, w)
 				})
 			})
 		}
@@ -424,15 +514,17 @@ func PushFile(client *pachclient.APIClient, pfsFile *pfs.File, osFile io.ReadSee
 	var object *pfs.Object
 	if fileInfo != nil {
 		for i, object = range fileInfo.Objects {
-			hash := pfs.NewHash()
-			if _, err := io.CopyN(hash, osFile, pfs.ChunkSize); err != nil {
-				if err == io.EOF {
-					break
-				}
+			// objects are hashed as they're stored, so compressed ones are
+			// only matched by compressing the chunk the same way
+			hashes, n, err := pfs.ChunkHashes(object.Compression, io.LimitReader(osFile, pfs.ChunkSize))
+			if err != nil {
 				return err
 			}
+			if n == 0 {
+				break
+			}
 
-			if object.Hash != pfs.EncodeHash(hash.Sum(nil)) {
+			if object.Hash != hashes[0] {
 				break
 			}
 		}
@@ -0,0 +1,212 @@
+// Package throttle caps the bandwidth of bulk transfers, and restricts them
+// to scheduling windows, so that they don't saturate links shared with other
+// traffic.
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxChunk is the most data that's read or written at once by a throttled
+// reader or writer, so that a large read or write doesn't burst far past the
+// rate.
+const maxChunk = 32 * 1024
+
+const day = 24 * time.Hour
+
+// Window is a daily period of time, given as offsets from midnight in the
+// local time zone. A window whose end is before its start wraps past
+// midnight, and one whose end equals its start lasts all day.
+type Window struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// ParseWindows parses a comma separated list of windows, each of the form
+// HH:MM-HH:MM, e.g. "22:00-06:00,12:00-13:00".
+func ParseWindows(s string) ([]Window, error) {
+	var windows []Window
+	for _, w := range strings.Split(s, ",") {
+		w = strings.TrimSpace(w)
+		if w == "" {
+			continue
+		}
+		parts := strings.Split(w, "-")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("window %q isn't of the form HH:MM-HH:MM", w)
+		}
+		start, err := parseTimeOfDay(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseTimeOfDay(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, Window{Start: start, End: end})
+	}
+	return windows, nil
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("%q isn't a time of day of the form HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// contains returns true if offset, from midnight, is within w.
+func (w Window) contains(offset time.Duration) bool {
+	switch {
+	case w.Start == w.End:
+		return true
+	case w.Start < w.End:
+		return offset >= w.Start && offset < w.End
+	default:
+		return offset >= w.Start || offset < w.End
+	}
+}
+
+// Throttle limits the rate of the transfers that share it. A nil Throttle
+// doesn't limit anything.
+type Throttle struct {
+	bytesPerSecond int64
+	windows        []Window
+
+	mu sync.Mutex
+	// next is when the data transferred so far will have been transferred
+	// at the rate, i.e. when the next transfer can start
+	next time.Time
+}
+
+// New returns a Throttle that caps transfers at bytesPerSecond, unless it's
+// 0, and only lets them run within windows, unless there are none. It
+// returns nil if neither limit is set.
+func New(bytesPerSecond int64, windows []Window) *Throttle {
+	if bytesPerSecond <= 0 && len(windows) == 0 {
+		return nil
+	}
+	return &Throttle{
+		bytesPerSecond: bytesPerSecond,
+		windows:        windows,
+	}
+}
+
+// Wait blocks until n more bytes can be transferred, or ctx is done.
+func (t *Throttle) Wait(ctx context.Context, n int) error {
+	if t == nil {
+		return nil
+	}
+	if err := sleep(ctx, t.untilWindow(time.Now())); err != nil {
+		return err
+	}
+	if t.bytesPerSecond <= 0 {
+		return nil
+	}
+	t.mu.Lock()
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+	delay := t.next.Sub(now)
+	t.next = t.next.Add(time.Duration(n) * time.Second / time.Duration(t.bytesPerSecond))
+	t.mu.Unlock()
+	return sleep(ctx, delay)
+}
+
+// untilWindow returns how long from now until the next window starts, or 0
+// if now is within a window.
+func (t *Throttle) untilWindow(now time.Time) time.Duration {
+	if len(t.windows) == 0 {
+		return 0
+	}
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	offset := now.Sub(midnight)
+	until := day
+	for _, w := range t.windows {
+		if w.contains(offset) {
+			return 0
+		}
+		if d := (w.Start - offset + day) % day; d < until {
+			until = d
+		}
+	}
+	return until
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// Reader returns a reader of r whose reads are throttled by t.
+func (t *Throttle) Reader(ctx context.Context, r io.Reader) io.Reader {
+	if t == nil {
+		return r
+	}
+	return &reader{ctx: ctx, t: t, r: r}
+}
+
+type reader struct {
+	ctx context.Context
+	t   *Throttle
+	r   io.Reader
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if len(p) > maxChunk {
+		p = p[:maxChunk]
+	}
+	n, err := r.r.Read(p)
+	if waitErr := r.t.Wait(r.ctx, n); waitErr != nil {
+		return n, waitErr
+	}
+	return n, err
+}
+
+// Writer returns a writer to w whose writes are throttled by t.
+func (t *Throttle) Writer(ctx context.Context, w io.Writer) io.Writer {
+	if t == nil {
+		return w
+	}
+	return &writer{ctx: ctx, t: t, w: w}
+}
+
+type writer struct {
+	ctx context.Context
+	t   *Throttle
+	w   io.Writer
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	var written int
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > maxChunk {
+			chunk = chunk[:maxChunk]
+		}
+		if err := w.t.Wait(w.ctx, len(chunk)); err != nil {
+			return written, err
+		}
+		n, err := w.w.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
@@ -0,0 +1,69 @@
+package throttle
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+)
+
+func TestParseWindows(t *testing.T) {
+	windows, err := ParseWindows("22:00-06:30, 12:00-13:00")
+	require.NoError(t, err)
+	require.Equal(t, []Window{
+		{Start: 22 * time.Hour, End: 6*time.Hour + 30*time.Minute},
+		{Start: 12 * time.Hour, End: 13 * time.Hour},
+	}, windows)
+	windows, err = ParseWindows("")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(windows))
+	_, err = ParseWindows("22:00")
+	require.YesError(t, err)
+	_, err = ParseWindows("22:00-25:00")
+	require.YesError(t, err)
+}
+
+func TestUntilWindow(t *testing.T) {
+	at := func(hour, min int) time.Time {
+		return time.Date(2018, 1, 1, hour, min, 0, 0, time.UTC)
+	}
+	throttle := New(0, []Window{
+		{Start: 22 * time.Hour, End: 6 * time.Hour},
+		{Start: 12 * time.Hour, End: 13 * time.Hour},
+	})
+	require.Equal(t, time.Duration(0), throttle.untilWindow(at(23, 0)))
+	require.Equal(t, time.Duration(0), throttle.untilWindow(at(5, 59)))
+	require.Equal(t, time.Duration(0), throttle.untilWindow(at(12, 30)))
+	require.Equal(t, 6*time.Hour, throttle.untilWindow(at(6, 0)))
+	require.Equal(t, 9*time.Hour, throttle.untilWindow(at(13, 0)))
+	// a window that starts and ends at the same time lasts all day
+	throttle = New(0, []Window{{Start: 8 * time.Hour, End: 8 * time.Hour}})
+	require.Equal(t, time.Duration(0), throttle.untilWindow(at(7, 0)))
+}
+
+func TestRate(t *testing.T) {
+	require.True(t, New(0, nil) == nil)
+	// the first chunk isn't delayed, the rest are sent at the rate
+	throttle := New(1024*1024, nil)
+	data := make([]byte, 256*1024+maxChunk)
+	start := time.Now()
+	var buf bytes.Buffer
+	n, err := throttle.Writer(context.Background(), &buf).Write(data)
+	require.NoError(t, err)
+	require.Equal(t, len(data), n)
+	require.True(t, time.Since(start) >= 250*time.Millisecond)
+
+	start = time.Now()
+	read, err := ioutil.ReadAll(throttle.Reader(context.Background(), bytes.NewReader(data)))
+	require.NoError(t, err)
+	require.Equal(t, data, read)
+	require.True(t, time.Since(start) >= 250*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = throttle.Writer(ctx, &buf).Write(data)
+	require.YesError(t, err)
+}
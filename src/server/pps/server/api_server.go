@@ -550,7 +550,7 @@ func (a *apiServer) ListDatum(ctx context.Context, request *pps.ListDatumRequest
 		Commit: jobInfo.StatsCommit,
 		Path:   "/",
 	}
-	allFileInfos, err := pfsClient.ListFile(ctx, &pfs.ListFileRequest{file, true})
+	allFileInfos, err := pfsClient.ListFile(ctx, &pfs.ListFileRequest{File: file, Full: true})
 	if err != nil {
 		return nil, err
 	}
@@ -1096,8 +1096,11 @@ func (a *apiServer) authorizeModifyPipeline(ctx context.Context, operation pipel
 			}
 			if !resp.Authorized {
 				return &auth.NotAuthorizedError{
-					Repo:     inputRepo,
-					Required: auth.Scope_READER,
+					Repo:        inputRepo,
+					Required:    auth.Scope_READER,
+					Actual:      resp.Scope,
+					ACLRevision: resp.ACLRevision,
+					Cached:      resp.Cached,
 				}
 			}
 			return nil
@@ -1139,14 +1142,54 @@ func (a *apiServer) authorizeModifyPipeline(ctx context.Context, operation pipel
 		}
 		if !resp.Authorized {
 			return &auth.NotAuthorizedError{
-				Repo:     info.Pipeline.Name,
-				Required: required,
+				Repo:        info.Pipeline.Name,
+				Required:    required,
+				Actual:      resp.Scope,
+				ACLRevision: resp.ACLRevision,
+				Cached:      resp.Cached,
 			}
 		}
 	}
 	return nil
 }
 
+// checkServiceAccount checks that the service account that pipelineInfo runs
+// as, which the caller must own, can read the pipeline's inputs and write its
+// output repo, so that the pipeline doesn't only fail once it's running.
+func checkServiceAccount(ctx context.Context, authClient auth.APIClient, pipelineInfo *pps.PipelineInfo) error {
+	resp, err := authClient.GetServiceAccounts(auth.In2Out(ctx), &auth.GetServiceAccountsRequest{})
+	if err != nil {
+		return err
+	}
+	var serviceAccount *auth.ServiceAccount
+	for _, s := range resp.ServiceAccounts {
+		if s.Name == pipelineInfo.ServiceAccount {
+			serviceAccount = s
+		}
+	}
+	if serviceAccount == nil {
+		return fmt.Errorf("service account \"%s\" doesn't exist, or isn't yours", pipelineInfo.ServiceAccount)
+	}
+	readable := make(map[string]bool)
+	writable := make(map[string]bool)
+	for _, repo := range serviceAccount.InputRepos {
+		readable[repo] = true
+	}
+	for _, repo := range serviceAccount.OutputRepos {
+		readable[repo] = true
+		writable[repo] = true
+	}
+	for _, commit := range pps.InputCommits(pipelineInfo.Input) {
+		if !readable[commit.Repo.Name] {
+			return fmt.Errorf("service account \"%s\" can't read input repo \"%s\"", serviceAccount.Name, commit.Repo.Name)
+		}
+	}
+	if !writable[pipelineInfo.Pipeline.Name] {
+		return fmt.Errorf("service account \"%s\" can't write output repo \"%s\"", serviceAccount.Name, pipelineInfo.Pipeline.Name)
+	}
+	return nil
+}
+
 func (a *apiServer) CreatePipeline(ctx context.Context, request *pps.CreatePipelineRequest) (response *types.Empty, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
@@ -1179,6 +1222,7 @@ func (a *apiServer) CreatePipeline(ctx context.Context, request *pps.CreatePipel
 		Batch:              request.Batch,
 		MaxQueueSize:       request.MaxQueueSize,
 		Service:            request.Service,
+		ServiceAccount:     request.ServiceAccount,
 	}
 	setPipelineDefaults(pipelineInfo)
 	var visitErr error
@@ -1205,7 +1249,16 @@ func (a *apiServer) CreatePipeline(ctx context.Context, request *pps.CreatePipel
 	if err := a.authorizeModifyPipeline(ctx, operation, pipelineInfo); err != nil {
 		return nil, err
 	}
-	capabilityResp, err := authClient.GetCapability(auth.In2Out(ctx), &auth.GetCapabilityRequest{})
+	if pipelineInfo.ServiceAccount != "" {
+		if err := checkServiceAccount(ctx, authClient, pipelineInfo); err != nil {
+			return nil, err
+		}
+	}
+	// the pipeline's workers run with the capability, as the service
+	// account if there is one
+	capabilityResp, err := authClient.GetCapability(auth.In2Out(ctx), &auth.GetCapabilityRequest{
+		ServiceAccount: pipelineInfo.ServiceAccount,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("error getting capability for the user: %v", err)
 	}
@@ -1654,10 +1707,21 @@ func (a *apiServer) GarbageCollect(ctx context.Context, request *pps.GarbageColl
 			return fmt.Errorf("error reading commit tree: %v", err)
 		}
 
-		tree, err := hashtree.Deserialize(buf.Bytes())
+		tree, err := hashtree.DeserializeLazy(buf.Bytes(), func(chunk *pfs.Object) ([]byte, error) {
+			getChunkClient, err := objClient.GetObject(ctx, chunk)
+			if err != nil {
+				return nil, fmt.Errorf("error getting commit tree chunk: %v", err)
+			}
+			var chunkBuf bytes.Buffer
+			if err := grpcutil.WriteFromStreamingBytesClient(getChunkClient, &chunkBuf); err != nil {
+				return nil, fmt.Errorf("error reading commit tree chunk: %v", err)
+			}
+			return chunkBuf.Bytes(), nil
+		})
 		if err != nil {
 			return err
 		}
+		addActiveObjects(hashtree.ChunkObjects(tree)...)
 
 		return tree.Walk("/", func(path string, node *hashtree.NodeProto) error {
 			if node.FileNode != nil {